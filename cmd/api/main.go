@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/x509"
 	stdlog "log"
 	"os"
 	"os/signal"
@@ -31,6 +32,7 @@ import (
 	httpRouter "github.com/jhoicas/Inventario-api/internal/interfaces/http"
 	"github.com/jhoicas/Inventario-api/pkg/config"
 	"github.com/jhoicas/Inventario-api/pkg/logger"
+	"github.com/shopspring/decimal"
 )
 
 // @title Tu API ERP
@@ -87,6 +89,7 @@ func main() {
 		CertPath:     cfg.DIAN.CertPath,
 		CertKeyPath:  cfg.DIAN.CertKeyPath,
 		CertPassword: cfg.DIAN.CertPassword,
+		SingleTenant: cfg.DIAN.SingleTenant,
 	}
 
 	// Cliente SOAP DIAN — solo se usa si AppEnv es "test" o "prod".
@@ -102,12 +105,23 @@ func main() {
 		invoiceRepo, companyRepo, customerRepo, productRepo,
 		resolutionRepo, xmlBuilder, signerSvc, dianSubmitter, dianCfg,
 	)
-	dianRetryQueue := billing.NewDIANRetryQueue(1024)
+	// Validación local previa a la firma contra los XSD oficiales de UBL 2.1. Sin esquemas o sin xmllint la
+	// API arranca igual y firma sin validar localmente (la DIAN sigue validando al recibir).
+	if xmlValidator, err := infradian.NewXMLValidatorService(cfg.DIAN.XSDDir); err != nil {
+		log.Warn().Err(err).Msg("validación local UBL 2.1 desactivada (DIAN_XSD_DIR; ver scripts/fetch-ubl-xsd.sh)")
+	} else {
+		dianOrchestrator.SetXMLValidator(xmlValidator)
+	}
+	dianRetryQueue := billing.NewDIANRetryQueue(postgres.NewDIANRetryJobRepository(pool), cfg.DIAN.RetryMaxAttempts)
 	dianOrchestrator.SetRetryQueue(dianRetryQueue)
-	dianRetryWorker := billing.NewDIANRetryWorker(dianOrchestrator, dianRetryQueue, 15*time.Minute, 50)
+	dianRetryWorker := billing.NewDIANRetryWorker(dianOrchestrator, dianRetryQueue, time.Minute, 50)
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 	defer workerCancel()
 	go dianRetryWorker.Start(workerCtx)
+	dianStatusRepo := postgres.NewDIANStatusRepository(pool)
+	dianOrchestrator.SetStatusRepository(dianStatusRepo)
+	dianStatusWorker := billing.NewDIANStatusWorker(dianOrchestrator, dianStatusRepo, 30*time.Second, 50)
+	go dianStatusWorker.Start(workerCtx)
 
 	smtpCfg := dianws.SMTPConfig{
 		Host:         cfg.SMTP.Host,
@@ -131,6 +145,17 @@ func main() {
 		dianOrchestrator, dianCfg,
 	)
 
+	productKitRepo := postgres.NewProductKitRepository(pool)
+	createInvoiceUC.SetKitRepository(productKitRepo)
+	createCreditNoteUC.SetKitRepository(productKitRepo)
+
+	// Retenciones (ReteFuente, ReteIVA, ReteICA) practicadas por clientes agentes de retención.
+	uvtValue := decimal.NewFromInt(int64(cfg.DIAN.UVTValue))
+	withholdingRepo := postgres.NewWithholdingRepository(pool)
+	createInvoiceUC.SetWithholdings(withholdingRepo, uvtValue)
+	withholdingUC := billing.NewWithholdingUseCase(withholdingRepo, uvtValue)
+	receivableUC := billing.NewReceivableUseCase(postgres.NewReceivableRepository(pool), customerRepo)
+
 	createDebitNoteUC := billing.NewCreateDebitNoteUseCase(
 		txRunner,
 		customerRepo, companyRepo, productRepo, invoiceRepo,
@@ -157,8 +182,14 @@ func main() {
 	companyScreenUC := usecase.NewCompanyScreenUseCase(companyRepo, rbacRepo)
 	warehouseUC := usecase.NewWarehouseUseCase(warehouseRepo)
 	productUC := usecase.NewProductUseCase(productRepo)
+	productKitUC := usecase.NewProductKitUseCase(productRepo, productKitRepo)
 	supplierUC := usecase.NewSupplierUseCase(supplierRepo)
 	purchaseOrderUC := inventory.NewPurchaseOrderUseCase(purchaseOrderRepo, supplierRepo, warehouseRepo, txRunner, registerMovementUC)
+	reverseMovementUC := inventory.NewReverseMovementUseCase(txRunner)
+	inventoryPeriodRepo := postgres.NewInventoryPeriodRepository(pool)
+	inventoryPeriodUC := inventory.NewInventoryPeriodUseCase(inventoryPeriodRepo)
+	registerMovementUC.SetPeriodRepository(inventoryPeriodRepo)
+	reverseMovementUC.SetPeriodRepository(inventoryPeriodRepo)
 	updateReorderConfigUC := inventory.NewUpdateReorderConfigUseCase(productRepo, reorderConfigRepo)
 	encryptor, err := infrasecurity.NewAesGCMEncryptor(cfg.JWT.Secret)
 	if err != nil {
@@ -166,11 +197,85 @@ func main() {
 	}
 	certStore := infrasecurity.NewDIANCertificateFileStore(cfg.DIAN.CertStoragePath)
 	dianSettingsUC := usecase.NewDIANSettingsUseCase(companyRepo, dianSettingsRepo, certStore, encryptor)
+	// Credenciales DIAN por empresa (certificado, clave técnica, software); la configuración global queda como respaldo.
+	if !cfg.DIAN.SingleTenantSet {
+		singleTenant, err := billing.SingleTenantDefault(ctx, dianSettingsRepo, dianCfg)
+		if err != nil {
+			log.Warn().Err(err).Msg("no se pudo revisar dian_settings: DIAN_SINGLE_TENANT queda en false")
+		}
+		if singleTenant {
+			dianCfg.SingleTenant = true
+			log.Warn().Msg("DIAN_SINGLE_TENANT no definido y ninguna empresa tiene dian_settings: se firma con DIAN_CERT_PATH y DIAN_TECHNICAL_KEY. " +
+				"Cargue el certificado de la empresa (PUT /api/settings/dian) o defina DIAN_SINGLE_TENANT")
+		}
+	}
+	if !dianCfg.SingleTenant && cfg.DIAN.CertPath != "" {
+		log.Warn().Msg("DIAN_CERT_PATH está configurado pero DIAN_SINGLE_TENANT=false: las empresas sin dian_settings no firman (ERROR_GENERATION)")
+	}
+	dianCredentials := billing.NewDIANCredentialResolver(dianSettingsRepo, encryptor, dianCfg, 10*time.Minute)
+	dianOrchestrator.SetCredentialsProvider(dianCredentials)
+	dianSettingsUC.SetCredentialsCache(dianCredentials)
+
+	// Habilitación DIAN: set de pruebas por empresa enviado con SendTestSetAsync.
+	var dianTestSetSubmitter infradian.DIANTestSetSubmitter
+	if soapClient, ok := dianSubmitter.(*infradian.SOAPDIANClient); ok {
+		dianTestSetSubmitter = soapClient
+	}
+	dianHabilitacionUC := billing.NewDIANHabilitacionUseCase(
+		postgres.NewDIANHabilitacionRepository(pool), companyRepo, dianCredentials,
+		xmlBuilder, signerSvc, dianTestSetSubmitter,
+	)
+	go billing.NewDIANHabilitacionWorker(dianHabilitacionUC, 30*time.Second, 10).Start(workerCtx)
+
+	// Documento soporte en adquisiciones a no obligados a facturar (CUDS, resolución propia).
+	supportDocumentUC := billing.NewSupportDocumentUseCase(
+		postgres.NewSupportDocumentRepository(pool), companyRepo, supplierRepo, productRepo,
+		resolutionRepo, purchaseOrderRepo, dianCredentials, xmlBuilder, signerSvc, dianSubmitter,
+	)
+	go billing.NewSupportDocumentWorker(supportDocumentUC, 30*time.Second, 50).Start(workerCtx)
+
+	// Documento equivalente POS: las ventas de caja se encolan en el orquestador (workers acotados) y un
+	// barrido reencola las que sigan en DRAFT.
+	dianOrchestrator.StartQueue(workerCtx, 4, 2000)
+	posDocumentUC := billing.NewPOSDocumentUseCase(createInvoiceUC, customerRepo, postgres.NewPOSDocumentRepository(pool), dianOrchestrator)
+	go billing.NewPOSDocumentWorker(posDocumentUC, time.Minute, 2*time.Minute, 200).Start(workerCtx)
+
+	// Facturas recibidas de proveedores: ingesta desde el buzón, eventos RADIAN (SendEventUpdateStatus) y
+	// aceptación tácita.
+	var dianEventSubmitter billing.DIANEventSubmitter
+	if soapClient, ok := dianSubmitter.(*infradian.SOAPDIANClient); ok {
+		dianEventSubmitter = soapClient
+	}
+	receivedDocumentUC := billing.NewReceivedDocumentUseCase(
+		postgres.NewReceivedDocumentRepository(pool), companyRepo, supplierRepo,
+		dianCredentials, xmlBuilder, signerSvc, dianEventSubmitter,
+	)
+	// CA de confianza para la cadena de los certificados de firma: auditoría y facturas del buzón.
+	var trustedCAs *x509.CertPool
+	if cfg.DIAN.TrustedCAPath != "" {
+		if trustedCAs, err = infradian.LoadCertPool(cfg.DIAN.TrustedCAPath); err != nil {
+			log.Fatal().Err(err).Msg("cargar CA de confianza DIAN")
+		}
+	}
+	receivedDocumentUC.SetMailboxIngestion(infradian.NewReceivedInvoiceReader(trustedCAs), postgres.NewEmailInvoiceAttachmentRepository(pool), purchaseOrderRepo)
+	go billing.NewReceivedDocumentWorker(receivedDocumentUC, time.Minute, 50).Start(workerCtx)
+
+	// Verificación de firma y CUFE/CUDE de documentos firmados (auditoría).
+	documentVerificationUC := billing.NewDocumentVerificationUseCase(
+		invoiceRepo, companyRepo, dianCredentials, infradian.NewDocumentVerifierService(trustedCAs),
+	)
+	// Facturación en contingencia (tipo 03): numeración de contingencia, retención en SIGNED y transmisión
+	// en bloque al terminar.
+	dianContingencyRepo := postgres.NewDIANContingencyRepository(pool)
+	dianOrchestrator.SetContingencyRepository(dianContingencyRepo)
+	createInvoiceUC.SetContingencyRepository(dianContingencyRepo)
+	dianContingencyUC := billing.NewDIANContingencyUseCase(dianContingencyRepo, resolutionRepo, dianOrchestrator)
 	moduleSvc := usecase.NewModuleService(companyRepo)
 	analyticsUC := usecase.NewAnalyticsUseCase(analyticsRepo)
 	rawMaterialAnalyticsUC := usecase.NewRawMaterialAnalyticsUseCase(analyticsRepo)
 	replenishmentUC := inventory.NewReplenishmentUseCase(levelRepo, analyticsRepo)
 	getStockUC := inventory.NewGetStockUseCase(stockRepo)
+	getStockUC.SetKitSources(productRepo, productKitRepo)
 	listMovementsUC := inventory.NewGetMovementsUseCase(movementRepo)
 	dashboardUC := appanalytics.NewDashboardUseCase(analyticsRepo)
 
@@ -214,6 +319,31 @@ func main() {
 	if err != nil {
 		log.Error().Err(err).Msg("configurar SMTPSender para campañas CRM (usando net/smtp)")
 	}
+	// Alertas de stock bajo: los movimientos encolan producto/bodega y el worker evalúa y notifica.
+	// Los vencimientos de lotes se evalúan una vez al día.
+	var alertMailer inframail.Sender
+	if mailSender != nil {
+		alertMailer = mailSender
+	}
+	stockAlertQueue := inventory.NewStockAlertQueue(1024)
+	stockAlertUC := inventory.NewStockAlertUseCase(
+		postgres.NewStockAlertRepository(pool), postgres.NewNotificationRepository(pool), warehouseRepo, alertMailer,
+	)
+	stockAlertUC.SetExpiryWindow(cfg.Inventory.ExpiryAlertDays)
+	registerMovementUC.SetStockChangeNotifier(stockAlertQueue)
+	reverseMovementUC.SetStockChangeNotifier(stockAlertQueue)
+	stockAlertWorker := inventory.NewStockAlertWorker(stockAlertUC, stockAlertQueue, 30*time.Second, 200)
+	go stockAlertWorker.Start(workerCtx)
+	go inventory.NewStockExpiryWorker(stockAlertUC, 24*time.Hour).Start(workerCtx)
+	// Monitoreo de resoluciones DIAN: agotamiento/vencimiento en el resumen y alertas por email.
+	resolutionMonitor := billing.NewResolutionMonitor(postgres.NewResolutionAlertRepository(pool), alertMailer, billing.ResolutionAlertConfig{
+		RemainingPercent: float64(cfg.DIAN.ResolutionAlertPercent),
+		ExpiryDays:       cfg.DIAN.ResolutionAlertDays,
+		VelocityDays:     cfg.DIAN.ResolutionVelocityDays,
+	})
+	createInvoiceUC.SetResolutionMonitor(resolutionMonitor)
+	resolutionAlertWorker := billing.NewResolutionAlertWorker(resolutionMonitor, companyRepo, 24*time.Hour)
+	go resolutionAlertWorker.Start(workerCtx)
 	campaignUC := crm.NewCampaignUseCase(crmCampaignRepo, customerRepo, crmProfileRepo, crmInteractionRepo, mailSender)
 	templateUC := crm.NewCampaignTemplateUseCase(crmTemplateRepo)
 	opportunityUC := crm.NewOpportunityUseCase(crmOpportunityRepo)
@@ -302,6 +432,7 @@ func main() {
 	invoiceMailer := dianws.NewInvoiceMailer(
 		invoiceRepo, companyRepo, customerRepo, productRepo, pdfGenerator, smtpCfg,
 	)
+	invoiceMailer.SetAttachedDocumentSigner(xmlBuilder, signerSvc, dianCredentials)
 	dianOrchestrator.SetMailer(invoiceMailer)
 	rbacUC := usecase.NewRBACUseCase(rbacRepo, rbacRepo)
 	authUC := auth.NewAuthUseCase(userRepo, companyRepo, rbacRepo, auth.JWTConfig{
@@ -352,6 +483,7 @@ func main() {
 		CompanyRepo:            companyRepo,
 		WarehouseUC:            warehouseUC,
 		ProductUC:              productUC,
+		ProductKits:            productKitUC,
 		SupplierUC:             supplierUC,
 		UserRepo:               userRepo,
 		RegisterMovement:       registerMovementUC,
@@ -361,11 +493,23 @@ func main() {
 		ReorderConfig:          updateReorderConfigUC,
 		DIANSettingsUC:         dianSettingsUC,
 		PurchaseOrder:          purchaseOrderUC,
+		ReverseMovement:        reverseMovementUC,
+		InventoryPeriod:        inventoryPeriodUC,
+		StockAlerts:            stockAlertUC,
 		CustomerUC:             customerUC,
 		CreateInvoice:          createInvoiceUC,
 		ReturnInvoice:          createCreditNoteUC,
 		DebitNote:              createDebitNoteUC,
 		VoidInvoice:            createVoidInvoiceUC,
+		Withholdings:           withholdingUC,
+		Receivables:            receivableUC,
+		DIANRetryQueue:         dianRetryQueue,
+		DIANHabilitacion:       dianHabilitacionUC,
+		SupportDocuments:       supportDocumentUC,
+		POSDocuments:           posDocumentUC,
+		ReceivedDocuments:      receivedDocumentUC,
+		DocumentVerification:   documentVerificationUC,
+		DIANContingency:        dianContingencyUC,
 		InvoicePDF:             invoicePDFUC,
 		AuthUC:                 authUC,
 		ModuleService:          moduleSvc,
@@ -6,6 +6,7 @@
 # ║    ./deploy.sh                    # despliega la rama actual                 ║
 # ║    ./deploy.sh --skip-tests       # omite tests locales (no recomendado)     ║
 # ║    ./deploy.sh --rollback         # revierte al tag anterior                 ║
+# ║    UBL_ZIP_SHA256=<sha> ./deploy.sh  # XSD de UBL 2.1 en la imagen           ║
 # ║                                                                              ║
 # ║  Pre-requisitos en el Droplet:                                               ║
 # ║    - Docker + Docker Compose v2                                              ║
@@ -20,6 +21,7 @@ REMOTE_HOST="${REMOTE_HOST:-IP_DEL_DROPLET}"         # ← cambiar o exportar
 REMOTE_DIR="${REMOTE_DIR:-/opt/invorya-erp}"
 SSH_KEY="${SSH_KEY:-$HOME/.ssh/id_rsa}"
 COMPOSE_FILE="docker-compose.prod.yml"
+UBL_ZIP_SHA256="${UBL_ZIP_SHA256:-}"                  # SHA-256 de UBL-2.1.zip (XSD para validar antes de firmar)
 SKIP_TESTS=false
 ROLLBACK=false
 
@@ -52,6 +54,7 @@ if [ "$ROLLBACK" = true ]; then
         fi
         echo \"Revirtiendo a: \$PREV_TAG\"
         git checkout \$PREV_TAG
+        export UBL_ZIP_SHA256='$UBL_ZIP_SHA256'
         docker compose -f $COMPOSE_FILE up -d --build
     "
     log_ok "Rollback completado."
@@ -70,6 +73,11 @@ if grep -q "tudominio.com" Caddyfile 2>/dev/null; then
     log_error "Caddyfile contiene 'tudominio.com'. Edítalo con tu dominio real antes de desplegar."
 fi
 
+# Sin el SHA de UBL-2.1.zip la imagen no trae los XSD y la API firma sin validación local
+if [ -z "$UBL_ZIP_SHA256" ]; then
+    log_warn "UBL_ZIP_SHA256 no definido: la imagen se construye sin XSD de UBL 2.1 (sin validación local)."
+fi
+
 # El .env.example no debe usarse directamente en producción
 if [ ! -f ".env.prod" ]; then
     log_warn ".env.prod no encontrado localmente (se buscará en el servidor)."
@@ -128,6 +136,7 @@ ssh -i "$SSH_KEY" "$REMOTE_USER@$REMOTE_HOST" "
 
     echo '→ Exportando versión...'
     export APP_VERSION='$VERSION'
+    export UBL_ZIP_SHA256='$UBL_ZIP_SHA256'
 
     echo '→ Construyendo imagen (incluye tests en Dockerfile)...'
     docker compose -f $COMPOSE_FILE build --no-cache api
@@ -3,7 +3,6 @@ package billing
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strings"
 	"time"
 
@@ -11,13 +10,15 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/jhoicas/Inventario-api/internal/application/dto"
 	"github.com/jhoicas/Inventario-api/internal/domain"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
 )
 
 // CreateCreditNoteUseCase crea una Nota Crédito asociada a una factura existente.
 //  1. Valida la factura original y las cantidades devueltas.
-//  2. Si la empresa tiene módulo de inventario, registra movimientos RETURN dentro de la misma tx.
+//  2. Si la empresa tiene módulo de inventario, registra movimientos RETURN dentro de la misma tx
+//     (para un kit, sobre los componentes que se despacharon al facturarlo).
 //  3. Persiste la Nota Crédito (cabecera + detalle) y marca la factura original como Returned/Partially_Returned.
 //  4. Post-commit dispara el DIANOrchestrator para firmar y enviar la Nota Crédito.
 type CreateCreditNoteUseCase struct {
@@ -30,6 +31,7 @@ type CreateCreditNoteUseCase struct {
 	invoiceRepo      repository.InvoiceRepository
 	dianOrchestrator *DIANOrchestrator
 	dianConfig       DIANConfig
+	kitRepo          repository.ProductKitRepository // opcional: composición vigente si la factura no guardó desglose
 }
 
 // NewCreateCreditNoteUseCase construye el caso de uso para devoluciones.
@@ -57,6 +59,12 @@ func NewCreateCreditNoteUseCase(
 	}
 }
 
+// SetKitRepository permite devolver kits facturados antes de que se guardara su desglose,
+// usando la composición vigente del kit.
+func (uc *CreateCreditNoteUseCase) SetKitRepository(kitRepo repository.ProductKitRepository) {
+	uc.kitRepo = kitRepo
+}
+
 // CreateCreditNote registra una devolución parcial o total de una factura existente.
 // companyID y userID provienen del JWT; invoiceID de la ruta; el body define ítems y bodega destino.
 func (uc *CreateCreditNoteUseCase) CreateCreditNote(
@@ -110,14 +118,42 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 	taxRateByProduct := make(map[string]decimal.Decimal, len(origDetails))
 	for _, d := range origDetails {
 		soldByProduct[d.ProductID] = soldByProduct[d.ProductID].Add(d.Quantity)
-		priceByProduct[d.ProductID] = d.UnitPrice
+		priceByProduct[d.ProductID] = d.NetUnitPrice() // neto de descuento de línea
 		taxRateByProduct[d.ProductID] = d.TaxRate
 	}
 
+	// Impuestos cobrados en la factura original: la nota acredita los mismos tributos y tarifas.
+	origTaxes, err := uc.invoiceRepo.GetTaxesByInvoiceID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	taxTemplates := taxTemplatesByProduct(origDetails, origTaxes)
+
+	// Desglose de kits de la factura original: componentes a reingresar por unidad de kit.
+	var kitComponents map[string][]*entity.InvoiceKitComponent
+	if hasInventory {
+		lines, err := uc.invoiceRepo.GetKitComponentsByInvoiceID(invoiceID)
+		if err != nil {
+			return nil, err
+		}
+		kitComponents = make(map[string][]*entity.InvoiceKitComponent)
+		seen := make(map[string]struct{}, len(lines))
+		for _, l := range lines {
+			// Si el kit aparece en varias líneas, la composición es la misma: se toma una vez.
+			key := l.KitProductID + "|" + l.ComponentProductID
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			kitComponents[l.KitProductID] = append(kitComponents[l.KitProductID], l)
+		}
+	}
+
 	// Validar ítems devueltos y calcular totales esperados de la Nota Crédito.
 	var netTotal, taxTotal decimal.Decimal
 	returnQtyByProduct := make(map[string]decimal.Decimal, len(in.Items))
-	for _, item := range in.Items {
+	lineTaxes := make([][]*entity.InvoiceTax, len(in.Items))
+	for i, item := range in.Items {
 		if item.ProductID == "" || !item.Quantity.GreaterThan(decimal.Zero) {
 			return nil, domain.ErrInvalidInput
 		}
@@ -134,7 +170,8 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 		unitPrice := priceByProduct[item.ProductID]
 		lineSubtotal := item.Quantity.Mul(unitPrice)
 		netTotal = netTotal.Add(lineSubtotal)
-		taxTotal = taxTotal.Add(lineSubtotal.Mul(taxRateByProduct[item.ProductID]))
+		lineTaxes[i] = noteLineTaxes(taxTemplates[item.ProductID], taxRateByProduct[item.ProductID], item.Quantity, lineSubtotal)
+		taxTotal = taxTotal.Add(domaindian.SumTaxes(lineTaxes[i]))
 	}
 	if netTotal.IsZero() {
 		return nil, domain.ErrInvalidInput
@@ -164,6 +201,7 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 	var creditDetails []*entity.InvoiceDetail
 
 	// ── Transacción atómica: inventario RETURN + Nota Crédito + marcar factura ──
+	inventory := newTxInventory(uc.inventoryUC)
 	err = uc.txRunner.RunBilling(ctx, func(
 		movRepo repository.InventoryMovementRepository,
 		stockRepo repository.StockRepository,
@@ -171,6 +209,7 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 		_ repository.CustomerRepository,
 		invoiceRepo repository.InvoiceRepository,
 	) error {
+		inventory.reset()
 		// Reingreso de inventario (RETURN) si aplica.
 		if hasInventory {
 			for _, item := range in.Items {
@@ -181,7 +220,14 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 				if product.CompanyID != companyID {
 					return domain.ErrForbidden
 				}
-				if err := uc.inventoryUC.RegisterReturnInTx(
+				if product.IsKit() {
+					if err := uc.returnKitComponents(ctx, inventory, movRepo, stockRepo, productRepo, product,
+						kitComponents[item.ProductID], in.WarehouseID, userID, item.Quantity, now, creditNoteID); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := inventory.RegisterReturnInTx(
 					ctx,
 					movRepo, stockRepo, productRepo,
 					product,
@@ -199,7 +245,11 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 		}
 
 		// Construir cabecera de Nota Crédito.
-		number := fmt.Sprintf("%s-NC-%d", origInv.Number, now.Unix())
+		prefix, number, err := allocateNoteConsecutive(invoiceRepo, companyID, strings.TrimSpace(in.Prefix),
+			entity.ResolutionDocumentCreditNote, now)
+		if err != nil {
+			return err
+		}
 		var concept entity.CreditNoteConcept
 		if fullReturn {
 			concept = entity.CreditNoteConceptAnulacion
@@ -211,7 +261,7 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 			ID:         creditNoteID,
 			CompanyID:  companyID,
 			CustomerID: origInv.CustomerID,
-			Prefix:     origInv.Prefix,
+			Prefix:     prefix,
 			Number:     number,
 			Date:       now,
 			NetTotal:   netTotal,
@@ -226,6 +276,8 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 			OriginalInvoiceIssueOn: origInv.Date,
 			DiscrepancyCode:      concept,
 			DiscrepancyReason:    in.Reason,
+			CurrencyCode:         origInv.CurrencyCode, // la nota se expresa en la moneda y TRM de la factura
+			ExchangeRate:         origInv.ExchangeRate,
 			CreatedAt:            now,
 			UpdatedAt:            now,
 		}
@@ -254,6 +306,9 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 				return err
 			}
 		}
+		if err := persistLineTaxes(invoiceRepo, creditDetails, lineTaxes); err != nil {
+			return err
+		}
 
 		// Marcar la factura original con el estado de devolución.
 		if err := invoiceRepo.UpdateReturnStatus(origInv.ID, returnStatus); err != nil {
@@ -265,9 +320,10 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 	if err != nil {
 		return nil, err
 	}
+	inventory.committed(companyID)
 
 	// ── Post-commit: disparar orquestador DIAN para la Nota Crédito ─────────────
-	if uc.dianConfig.TechnicalKey != "" {
+	if uc.dianConfig.TechnicalKey != "" || uc.dianOrchestrator.ResolvesCredentialsPerCompany() {
 		uc.dianOrchestrator.ProcessAsync(creditNoteID)
 	}
 
@@ -302,3 +358,60 @@ func (uc *CreateCreditNoteUseCase) CreateCreditNote(
 	return resp, nil
 }
 
+// returnKitComponents reingresa los componentes de un kit devuelto. Usa el desglose guardado
+// al facturar; si no existe (kit facturado antes del desglose) recurre a la composición vigente.
+// El kit en sí nunca recibe stock.
+func (uc *CreateCreditNoteUseCase) returnKitComponents(
+	ctx context.Context,
+	inventory InventoryUseCase,
+	movRepo repository.InventoryMovementRepository,
+	stockRepo repository.StockRepository,
+	productRepo repository.ProductRepository,
+	kit *entity.Product,
+	breakdown []*entity.InvoiceKitComponent,
+	warehouseID, userID string,
+	kitQty decimal.Decimal,
+	now time.Time,
+	creditNoteID string,
+) error {
+	perKit := make(map[string]decimal.Decimal, len(breakdown))
+	order := make([]string, 0, len(breakdown))
+	for _, l := range breakdown {
+		perKit[l.ComponentProductID] = l.Quantity
+		order = append(order, l.ComponentProductID)
+	}
+	if len(order) == 0 && uc.kitRepo != nil {
+		components, err := uc.kitRepo.ListComponents(kit.ID)
+		if err != nil {
+			return err
+		}
+		for _, c := range components {
+			perKit[c.ComponentProductID] = c.Quantity
+			order = append(order, c.ComponentProductID)
+		}
+	}
+	if len(order) == 0 {
+		return domain.ErrInvalidInput
+	}
+	for _, componentID := range order {
+		component, err := productRepo.GetByID(componentID)
+		if err != nil || component == nil {
+			return domain.ErrNotFound
+		}
+		if component.CompanyID != kit.CompanyID {
+			return domain.ErrForbidden
+		}
+		if err := inventory.RegisterReturnInTx(
+			ctx,
+			movRepo, stockRepo, productRepo,
+			component,
+			componentID, warehouseID, userID,
+			kitQty.Mul(perKit[componentID]),
+			now,
+			creditNoteID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
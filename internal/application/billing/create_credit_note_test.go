@@ -144,6 +144,47 @@ func TestCreateCreditNoteUseCase_CreateCreditNote(t *testing.T) {
 				assert.True(t, out.TaxTotal.Equal(expectedTax), "TaxTotal")
 				assert.True(t, out.GrandTotal.Equal(expectedGrand), "GrandTotal")
 				assert.Equal(t, "Cliente Prueba", out.CustomerName)
+				// Consecutivo de la resolución de notas crédito, no de la factura.
+				assert.Equal(t, "NC", out.Prefix)
+				assert.Equal(t, "1001", out.Number)
+			},
+		},
+		{
+			name:      "NoCreditNoteResolution_UsesCompanySequence",
+			companyID: testCompanyID,
+			userID:    testUserID,
+			invoiceID: testInvoiceID,
+			in:        validCreateCreditNoteRequest(),
+			setup: func() (*fakeBillingTxRunner, *fakeInventoryUC, *fakeCustomerRepo, *fakeCompanyRepo, *fakeProductRepo, *fakeWarehouseRepo, *fakeInvoiceRepo) {
+				customerRepo := &fakeCustomerRepo{getByIDFunc: func(_ string) (*entity.Customer, error) { return validCustomer(testCompanyID), nil }}
+				companyRepo := &fakeCompanyRepo{
+					getByIDFunc:         func(id string) (*entity.Company, error) { return validCompany(id), nil },
+					hasActiveModuleFunc: func(_ context.Context, _, _ string) (bool, error) { return false, nil },
+				}
+				invoiceRepo := &fakeInvoiceRepo{
+					noResolution: true,
+					getByIDFunc: func(id string) (*entity.Invoice, error) {
+						return validOriginalInvoice(testCompanyID, testInvoiceID), nil
+					},
+					getDetailsByInvoiceIDFunc: func(id string) ([]*entity.InvoiceDetail, error) { return validOriginalDetails(id), nil },
+				}
+				txRunner := &fakeBillingTxRunner{
+					runFunc: func(_ context.Context, fn func(
+						repository.InventoryMovementRepository,
+						repository.StockRepository,
+						repository.ProductRepository,
+						repository.CustomerRepository,
+						repository.InvoiceRepository,
+					) error) error {
+						return fn(nil, nil, &fakeProductRepo{}, customerRepo, invoiceRepo)
+					},
+				}
+				return txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, &fakeProductRepo{}, &fakeWarehouseRepo{}, invoiceRepo
+			},
+			validateOut: func(t *testing.T, out *dto.InvoiceResponse) {
+				// Sin resolución de notas crédito: secuencia de notas de la empresa.
+				assert.Equal(t, "NC", out.Prefix)
+				assert.Equal(t, "1", out.Number)
 			},
 		},
 		{
@@ -460,3 +501,89 @@ func TestCreateCreditNoteUseCase_CreateCreditNote(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateCreditNoteUseCase_KitReturnsComponents(t *testing.T) {
+	kit, kitRepo := giftBox()
+	components := kitRepo.components[testKitID]
+	customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return validCustomer(testCompanyID), nil }}
+	companyRepo := &fakeCompanyRepo{
+		hasActiveModuleFunc: func(context.Context, string, string) (bool, error) { return true, nil },
+	}
+	productRepo := &fakeProductRepo{getByIDFunc: func(id string) (*entity.Product, error) {
+		switch id {
+		case testKitID:
+			return kit, nil
+		case testProductID1:
+			return components[0].Component, nil
+		case testProductID2:
+			return components[1].Component, nil
+		}
+		return nil, nil
+	}}
+	warehouseRepo := &fakeWarehouseRepo{getByIDFunc: func(string) (*entity.Warehouse, error) { return validWarehouse(testCompanyID), nil }}
+	newInvoiceRepo := func(breakdown []*entity.InvoiceKitComponent) *fakeInvoiceRepo {
+		return &fakeInvoiceRepo{
+			getByIDFunc: func(id string) (*entity.Invoice, error) { return validOriginalInvoice(testCompanyID, id), nil },
+			getDetailsByInvoiceIDFunc: func(id string) ([]*entity.InvoiceDetail, error) {
+				return []*entity.InvoiceDetail{{ID: "det-kit", InvoiceID: id, ProductID: testKitID, Quantity: decimal.NewFromInt(3), UnitPrice: decimal.NewFromInt(27000), TaxRate: decimal.NewFromFloat(0.19)}}, nil
+			},
+			kitComponents: breakdown,
+		}
+	}
+	run := func(invoiceRepo *fakeInvoiceRepo, withKitRepo bool) (map[string]decimal.Decimal, error) {
+		returned := map[string]decimal.Decimal{}
+		inventoryUC := &fakeInventoryUC{
+			registerReturnFunc: func(_ context.Context, _ repository.InventoryMovementRepository, _ repository.StockRepository, _ repository.ProductRepository, _ *entity.Product, productID, _, _ string, quantity decimal.Decimal, _ time.Time, _ string) error {
+				returned[productID] = returned[productID].Add(quantity)
+				return nil
+			},
+		}
+		txRunner := &fakeBillingTxRunner{
+			runFunc: func(_ context.Context, fn func(
+				repository.InventoryMovementRepository,
+				repository.StockRepository,
+				repository.ProductRepository,
+				repository.CustomerRepository,
+				repository.InvoiceRepository,
+			) error) error {
+				return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+			},
+		}
+		uc := NewCreateCreditNoteUseCase(txRunner, inventoryUC, customerRepo, companyRepo, productRepo, warehouseRepo, invoiceRepo, nil, DIANConfig{})
+		if withKitRepo {
+			uc.SetKitRepository(kitRepo)
+		}
+		_, err := uc.CreateCreditNote(context.Background(), testCompanyID, testUserID, testInvoiceID, dto.ReturnInvoiceRequest{
+			WarehouseID: testWarehouseID,
+			Items:       []dto.ReturnItemRequest{{ProductID: testKitID, Quantity: decimal.NewFromInt(2)}},
+		})
+		return returned, err
+	}
+
+	t.Run("UsesInvoicedBreakdown", func(t *testing.T) {
+		// Al facturar, la caja llevaba 1× product1 y 3× product2 (composición distinta a la vigente).
+		breakdown := []*entity.InvoiceKitComponent{
+			{InvoiceID: testInvoiceID, KitProductID: testKitID, ComponentProductID: testProductID1, Quantity: decimal.NewFromInt(1)},
+			{InvoiceID: testInvoiceID, KitProductID: testKitID, ComponentProductID: testProductID2, Quantity: decimal.NewFromInt(3)},
+		}
+		returned, err := run(newInvoiceRepo(breakdown), true)
+		require.NoError(t, err)
+		require.Len(t, returned, 2)
+		assert.True(t, returned[testProductID1].Equal(decimal.NewFromInt(2)))
+		assert.True(t, returned[testProductID2].Equal(decimal.NewFromInt(6)))
+	})
+
+	t.Run("FallsBackToCurrentDefinition", func(t *testing.T) {
+		returned, err := run(newInvoiceRepo(nil), true)
+		require.NoError(t, err)
+		assert.True(t, returned[testProductID1].Equal(decimal.NewFromInt(4)))
+		assert.True(t, returned[testProductID2].Equal(decimal.NewFromInt(4)))
+		_, kitReturned := returned[testKitID]
+		assert.False(t, kitReturned)
+	})
+
+	t.Run("NoBreakdownNorDefinition", func(t *testing.T) {
+		_, err := run(newInvoiceRepo(nil), false)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
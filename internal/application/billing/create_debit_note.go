@@ -2,13 +2,13 @@ package billing
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jhoicas/Inventario-api/internal/application/dto"
 	"github.com/jhoicas/Inventario-api/internal/domain"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
 	"github.com/shopspring/decimal"
@@ -74,7 +74,8 @@ func (uc *CreateDebitNoteUseCase) CreateDebitNote(
 	}
 
 	var netTotal, taxTotal decimal.Decimal
-	for _, item := range in.Items {
+	lineTaxes := make([][]*entity.InvoiceTax, len(in.Items))
+	for i, item := range in.Items {
 		if item.ProductID == "" || !item.Quantity.GreaterThan(decimal.Zero) || !item.UnitPrice.GreaterThan(decimal.Zero) {
 			return nil, domain.ErrInvalidInput
 		}
@@ -88,7 +89,8 @@ func (uc *CreateDebitNoteUseCase) CreateDebitNote(
 
 		lineSubtotal := item.Quantity.Mul(item.UnitPrice)
 		netTotal = netTotal.Add(lineSubtotal)
-		taxTotal = taxTotal.Add(lineSubtotal.Mul(product.TaxRate))
+		lineTaxes[i] = domaindian.LineTaxes(product, item.Quantity, lineSubtotal)
+		taxTotal = taxTotal.Add(domaindian.SumTaxes(lineTaxes[i]))
 	}
 	if netTotal.IsZero() {
 		return nil, domain.ErrInvalidInput
@@ -107,13 +109,17 @@ func (uc *CreateDebitNoteUseCase) CreateDebitNote(
 		_ repository.CustomerRepository,
 		invoiceRepo repository.InvoiceRepository,
 	) error {
-		number := fmt.Sprintf("%s-ND-%d", origInv.Number, now.Unix())
+		prefix, number, err := allocateNoteConsecutive(invoiceRepo, companyID, strings.TrimSpace(in.Prefix),
+			entity.ResolutionDocumentDebitNote, now)
+		if err != nil {
+			return err
+		}
 
 		debitInv = &entity.Invoice{
 			ID:                     debitNoteID,
 			CompanyID:              companyID,
 			CustomerID:             origInv.CustomerID,
-			Prefix:                 origInv.Prefix,
+			Prefix:                 prefix,
 			Number:                 number,
 			Date:                   now,
 			NetTotal:               netTotal,
@@ -127,6 +133,8 @@ func (uc *CreateDebitNoteUseCase) CreateDebitNote(
 			OriginalInvoiceIssueOn: origInv.Date,
 			DiscrepancyCode:        entity.CreditNoteConceptOtros,
 			DiscrepancyReason:      in.Reason,
+			CurrencyCode:           origInv.CurrencyCode, // la nota se expresa en la moneda y TRM de la factura
+			ExchangeRate:           origInv.ExchangeRate,
 			CreatedAt:              now,
 			UpdatedAt:              now,
 		}
@@ -157,6 +165,9 @@ func (uc *CreateDebitNoteUseCase) CreateDebitNote(
 				return err
 			}
 		}
+		if err := persistLineTaxes(invoiceRepo, debitDetails, lineTaxes); err != nil {
+			return err
+		}
 
 		return nil
 	})
@@ -164,7 +175,7 @@ func (uc *CreateDebitNoteUseCase) CreateDebitNote(
 		return nil, err
 	}
 
-	if uc.dianConfig.TechnicalKey != "" {
+	if uc.dianConfig.TechnicalKey != "" || uc.dianOrchestrator.ResolvesCredentialsPerCompany() {
 		uc.dianOrchestrator.ProcessSync(debitNoteID)
 		latest, getErr := uc.invoiceRepo.GetByID(debitNoteID)
 		if getErr == nil && latest != nil {
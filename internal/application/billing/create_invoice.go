@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jhoicas/Inventario-api/internal/application/dto"
 	"github.com/jhoicas/Inventario-api/internal/domain"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	domaininventory "github.com/jhoicas/Inventario-api/internal/domain/inventory"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
 	"github.com/shopspring/decimal"
 )
 
@@ -22,6 +26,9 @@ type DIANConfig struct {
 	CertPath     string
 	CertKeyPath  string
 	CertPassword string
+	// SingleTenant permite firmar con el certificado y la clave técnica globales a las empresas sin
+	// configuración propia; solo para instalaciones de una sola empresa.
+	SingleTenant bool
 }
 
 // CreateInvoiceUseCase crea una factura con lógica de inventario condicional según módulos activos.
@@ -38,6 +45,11 @@ type CreateInvoiceUseCase struct {
 	invoiceRepo      repository.InvoiceRepository
 	dianOrchestrator *DIANOrchestrator
 	dianConfig       DIANConfig
+	kitRepo          repository.ProductKitRepository // opcional: venta de kits por componentes
+	resolutionMon    *ResolutionMonitor              // opcional: estado de resoluciones en el resumen DIAN
+	withholdingRepo  WithholdingRepository           // opcional: retenciones de clientes agentes de retención
+	uvt              decimal.Decimal                 // valor UVT vigente para las bases mínimas de retención
+	contingencies    DIANContingencyRepository       // opcional: facturación tipo 03 durante la contingencia
 }
 
 // NewCreateInvoiceUseCase construye el caso de uso.
@@ -65,17 +77,72 @@ func NewCreateInvoiceUseCase(
 	}
 }
 
+// SetKitRepository habilita la venta de productos tipo KIT: al facturar un kit se descuenta
+// el stock de sus componentes. Sin repositorio, facturar un kit se rechaza.
+func (uc *CreateInvoiceUseCase) SetKitRepository(kitRepo repository.ProductKitRepository) {
+	uc.kitRepo = kitRepo
+}
+
+// SetResolutionMonitor incluye en el resumen DIAN el estado de numeración y vigencia de las resoluciones.
+func (uc *CreateInvoiceUseCase) SetResolutionMonitor(m *ResolutionMonitor) {
+	uc.resolutionMon = m
+}
+
+// SetWithholdings habilita el cálculo de retenciones (ReteFuente, ReteIVA, ReteICA) cuando el
+// cliente es agente de retención, con las tarifas de la empresa y el valor UVT vigente.
+func (uc *CreateInvoiceUseCase) SetWithholdings(repo WithholdingRepository, uvt decimal.Decimal) {
+	uc.withholdingRepo = repo
+	uc.uvt = uvt
+}
+
+// SetContingencyRepository habilita la facturación en contingencia: mientras la empresa tenga una
+// contingencia activa sus facturas se emiten como tipo 03 con la numeración de contingencia.
+func (uc *CreateInvoiceUseCase) SetContingencyRepository(repo DIANContingencyRepository) {
+	uc.contingencies = repo
+}
+
 // CreateInvoice flujo principal:
 //  1. Validaciones previas a la transacción (cliente, empresa, bodega si inventario, productos).
 //  2. Verificar módulo "inventory" activo (lectura fuera de tx).
 //  3. Transacción atómica:
-//     a. Si hasInventory: validar stock y registrar salidas OUT por ítem.
-//     b. Siempre: persistir cabecera DRAFT y detalles.
+//     a. Si hasInventory: validar stock y registrar salidas OUT por ítem (por componente si es kit).
+//     b. Siempre: asignar consecutivo de la resolución activa (si no viene número),
+//     persistir cabecera DRAFT, detalles, descuentos/cargos, cuotas, desglose de kits y retenciones del cliente.
 //  4. Post-commit: disparar DIANOrchestrator.ProcessAsync(invoiceID).
 func (uc *CreateInvoiceUseCase) CreateInvoice(ctx context.Context, companyID, userID string, in dto.CreateInvoiceRequest) (*dto.InvoiceResponse, error) {
+	// El documento equivalente POS tiene su propio flujo (POSDocumentUseCase).
+	if strings.TrimSpace(in.InvoiceTypeCode) == dian.InvoiceTypeDocumentoEquivalentePOS {
+		return nil, domain.ErrInvalidInput
+	}
+	// En contingencia la venta nacional se emite como factura tipo 03 con el prefijo de la contingencia
+	// (el número explícito permite registrar las facturas del talonario de contingencia).
+	in.ContingencyID = ""
+	if uc.contingencies != nil {
+		active, err := uc.contingencies.GetActive(ctx, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			switch strings.TrimSpace(in.InvoiceTypeCode) {
+			case "", dian.InvoiceTypeVenta, dian.InvoiceTypeContingencia:
+			default:
+				return nil, fmt.Errorf("%w: en contingencia solo se emiten facturas de venta nacional (tipo 03)", domain.ErrInvalidInput)
+			}
+			in.Prefix = active.Prefix
+			in.InvoiceTypeCode = dian.InvoiceTypeContingencia
+			in.ContingencyID = active.ID
+		}
+	}
+	return uc.createDocument(ctx, companyID, userID, in, "INVOICE")
+}
+
+// createDocument es el flujo de CreateInvoice para facturas ("INVOICE") y documentos equivalentes POS
+// (entity.DocumentTypePOS); estos últimos se encolan en el orquestador en lugar de lanzar una goroutine.
+func (uc *CreateInvoiceUseCase) createDocument(ctx context.Context, companyID, userID string, in dto.CreateInvoiceRequest, documentType string) (*dto.InvoiceResponse, error) {
 	if in.CustomerID == "" || len(in.Items) == 0 || in.Prefix == "" {
 		return nil, domain.ErrInvalidInput
 	}
+	in.Number = strings.TrimSpace(in.Number)
 
 	// ── Validaciones de solo lectura (fuera de tx) ────────────────────────────
 	customer, err := uc.customerRepo.GetByID(in.CustomerID)
@@ -105,6 +172,7 @@ func (uc *CreateInvoiceUseCase) CreateInvoice(ctx context.Context, companyID, us
 	}
 
 	productsByID := make(map[string]*entity.Product, len(in.Items))
+	kitsByID := make(map[string][]*entity.KitComponent)
 	for i := range in.Items {
 		item := &in.Items[i]
 		if item.ProductID == "" || !item.Quantity.GreaterThan(decimal.Zero) {
@@ -124,14 +192,56 @@ func (uc *CreateInvoiceUseCase) CreateInvoice(ctx context.Context, companyID, us
 		if item.UnitPrice.IsZero() {
 			in.Items[i].UnitPrice = product.Price
 		}
+		if product.IsKit() {
+			if _, loaded := kitsByID[item.ProductID]; loaded {
+				continue
+			}
+			components, err := uc.loadKitComponents(product)
+			if err != nil {
+				return nil, err
+			}
+			kitsByID[item.ProductID] = components
+		}
+	}
+
+	// Descuentos de línea y descuentos/cargos globales (sobre el subtotal neto de las líneas).
+	lineDiscounts := make([]decimal.Decimal, len(in.Items))
+	lineNetTotal := decimal.Zero
+	for i, item := range in.Items {
+		discount, err := lineDiscount(item)
+		if err != nil {
+			return nil, err
+		}
+		lineDiscounts[i] = discount
+		lineNetTotal = lineNetTotal.Add(item.Quantity.Mul(item.UnitPrice).Sub(discount))
+	}
+	allowanceCharges, err := globalAllowanceCharges(in.AllowanceCharges, lineNetTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := resolveDocumentTerms(in, customer)
+	if err != nil {
+		return nil, err
+	}
+
+	withholdingIn, err := uc.withholdingInput(ctx, companyID, customer)
+	if err != nil {
+		return nil, err
 	}
 
-	// ── Transacción atómica ───────────────────────────────────────────────────
 	now := time.Now()
+	payment, err := resolvePaymentTerms(in, customer, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// ── Transacción atómica ───────────────────────────────────────────────────
 	invoiceID := uuid.New().String()
 	var inv *entity.Invoice
 	var details []*entity.InvoiceDetail
 
+	inventory := newTxInventory(uc.inventoryUC)
 	err = uc.txRunner.RunBilling(ctx, func(
 		movRepo repository.InventoryMovementRepository,
 		stockRepo repository.StockRepository,
@@ -139,17 +249,17 @@ func (uc *CreateInvoiceUseCase) CreateInvoice(ctx context.Context, companyID, us
 		_ repository.CustomerRepository,
 		invoiceRepo repository.InvoiceRepository,
 	) error {
+		inventory.reset()
 
 		// ── Bloque condicional: movimientos de inventario ─────────────────────
 		if hasInventory {
-			for _, item := range in.Items {
-				product := productsByID[item.ProductID]
-				if err := uc.inventoryUC.RegisterOUTInTx(
+			registerOUT := func(product *entity.Product, quantity decimal.Decimal) error {
+				if err := inventory.RegisterOUTInTx(
 					ctx,
 					movRepo, stockRepo, productRepo,
 					product,
-					item.ProductID, in.WarehouseID, userID,
-					item.Quantity,
+					product.ID, in.WarehouseID, userID,
+					quantity,
 					now,
 					invoiceID,
 				); err != nil {
@@ -159,6 +269,21 @@ func (uc *CreateInvoiceUseCase) CreateInvoice(ctx context.Context, companyID, us
 					}
 					return err
 				}
+				return nil
+			}
+			for _, item := range in.Items {
+				product := productsByID[item.ProductID]
+				if !product.IsKit() {
+					if err := registerOUT(product, item.Quantity); err != nil {
+						return err
+					}
+					continue
+				}
+				for _, c := range kitsByID[item.ProductID] {
+					if err := registerOUT(c.Component, item.Quantity.Mul(c.Quantity)); err != nil {
+						return err
+					}
+				}
 			}
 		}
 
@@ -169,18 +294,61 @@ func (uc *CreateInvoiceUseCase) CreateInvoice(ctx context.Context, companyID, us
 			}
 			return rate
 		}
-		var netTotal, taxTotal decimal.Decimal
-		for _, item := range in.Items {
+		// Cada línea lleva su desglose (IVA, INC, impuesto por unidad) sobre el subtotal neto de
+		// descuento; TaxTotal es su suma. Los descuentos y cargos globales no alteran la base gravable.
+		var netTotal, taxTotal, lineDiscountTotal decimal.Decimal
+		lineSubtotals := make([]decimal.Decimal, len(in.Items))
+		lineTaxes := make([][]*entity.InvoiceTax, len(in.Items))
+		for i, item := range in.Items {
 			product := productsByID[item.ProductID]
-			subtotal := item.Quantity.Mul(item.UnitPrice)
+			subtotal := item.Quantity.Mul(item.UnitPrice).Sub(lineDiscounts[i])
+			lineSubtotals[i] = subtotal
 			netTotal = netTotal.Add(subtotal)
-			taxTotal = taxTotal.Add(subtotal.Mul(toRate(product.TaxRate)))
+			lineDiscountTotal = lineDiscountTotal.Add(lineDiscounts[i])
+			lineTaxes[i] = domaindian.LineTaxes(product, item.Quantity, subtotal)
+			taxTotal = taxTotal.Add(domaindian.SumTaxes(lineTaxes[i]))
+		}
+		allowanceTotal, chargeTotal := domaindian.SumAllowanceCharges(allowanceCharges)
+		grandTotal := netTotal.Add(taxTotal).Sub(allowanceTotal).Add(chargeTotal)
+		if grandTotal.LessThan(decimal.Zero) {
+			return domain.ErrInvalidInput
+		}
+
+		// Retenciones que practicará el cliente (solo si es agente de retención).
+		var withholdings []*entity.InvoiceWithholding
+		if withholdingIn != nil {
+			for i, item := range in.Items {
+				withholdingIn.Lines = append(withholdingIn.Lines, domaindian.WithholdingLine{
+					Concept:  productsByID[item.ProductID].WithholdingConcept,
+					Subtotal: lineSubtotals[i],
+					IVA:      domaindian.TaxAmountByCode(lineTaxes[i], dian.TaxCodeIVA),
+				})
+			}
+			withholdingIn.ExchangeRate = terms.exchangeRate // bases mínimas en pesos
+			withholdings = domaindian.ComputeWithholdings(*withholdingIn)
+		}
+		// El plan de cuotas debe cubrir exactamente el neto a pagar (total menos retenciones).
+		if len(payment.installments) > 0 {
+			installmentTotal := decimal.Zero
+			for _, inst := range payment.installments {
+				installmentTotal = installmentTotal.Add(inst.Amount)
+			}
+			if !installmentTotal.Equal(grandTotal.Sub(domaindian.SumWithholdings(withholdings))) {
+				return domain.ErrInvalidInput
+			}
 		}
-		grandTotal := netTotal.Add(taxTotal)
 
+		// Consecutivo de la resolución activa del prefijo, dentro de esta misma transacción. Un número
+		// explícito se valida y registra contra la misma resolución bloqueada: debe ser su siguiente.
 		number := in.Number
 		if number == "" {
-			number = fmt.Sprintf("%s-%d", in.Prefix, now.Unix())
+			allocated, err := allocateConsecutive(invoiceRepo, companyID, in.Prefix, now)
+			if err != nil {
+				return err
+			}
+			number = allocated
+		} else if err := claimConsecutive(invoiceRepo, companyID, in.Prefix, number, now); err != nil {
+			return err
 		}
 
 		// ── Construir entidades ───────────────────────────────────────────────
@@ -195,24 +363,54 @@ func (uc *CreateInvoiceUseCase) CreateInvoice(ctx context.Context, companyID, us
 			TaxTotal:     taxTotal,
 			GrandTotal:   grandTotal,
 			DIAN_Status:  entity.DIANStatusDraft,
-			DocumentType: "INVOICE",
+			DocumentType: documentType,
 			CreatedAt:    now,
 			UpdatedAt:    now,
+
+			WithholdingTotal: domaindian.SumWithholdings(withholdings),
+			Withholdings:     withholdings,
+			DiscountTotal:    lineDiscountTotal.Add(allowanceTotal),
+			AllowanceTotal:   allowanceTotal,
+			ChargeTotal:      chargeTotal,
+
+			CurrencyCode:    terms.currency,
+			ExchangeRate:    terms.exchangeRate,
+			InvoiceTypeCode: terms.invoiceType,
+			Incoterm:        terms.incoterm,
+			ContingencyID:   in.ContingencyID,
+
+			PaymentFormCode:    payment.form,
+			PaymentMethodCodes: payment.methods,
+			DueDate:            payment.dueDate,
+			Installments:       payment.installments,
 		}
-		for _, item := range in.Items {
+		inv.ComputeCOPTotals()
+		for i, item := range in.Items {
 			product := productsByID[item.ProductID]
-			subtotal := item.Quantity.Mul(item.UnitPrice)
 			rate := toRate(product.TaxRate)
-			details = append(details, &entity.InvoiceDetail{
-				ID:        uuid.New().String(),
-				InvoiceID: inv.ID,
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				UnitPrice: item.UnitPrice,
-				TaxRate:   rate,
-				Subtotal:  subtotal,
-			})
+			d := &entity.InvoiceDetail{
+				ID:             uuid.New().String(),
+				InvoiceID:      inv.ID,
+				ProductID:      item.ProductID,
+				Quantity:       item.Quantity,
+				UnitPrice:      item.UnitPrice,
+				TaxRate:        rate,
+				DiscountAmount: lineDiscounts[i],
+				Subtotal:       lineSubtotals[i],
+			}
+			details = append(details, d)
+			if lineDiscounts[i].IsPositive() {
+				inv.AllowanceCharges = append(inv.AllowanceCharges, &entity.InvoiceAllowanceCharge{
+					InvoiceDetailID: d.ID,
+					ReasonCode:      dian.DiscountReasonGeneral,
+					Reason:          "Descuento",
+					Rate:            item.DiscountRate,
+					BaseAmount:      item.Quantity.Mul(item.UnitPrice).Round(2),
+					Amount:          lineDiscounts[i],
+				})
+			}
 		}
+		inv.AllowanceCharges = append(inv.AllowanceCharges, allowanceCharges...)
 
 		// ── Persistencia inicial en DRAFT ─────────────────────────────────────
 		if err := invoiceRepo.Create(inv); err != nil {
@@ -223,23 +421,342 @@ func (uc *CreateInvoiceUseCase) CreateInvoice(ctx context.Context, companyID, us
 				return err
 			}
 		}
+		if err := persistLineTaxes(invoiceRepo, details, lineTaxes); err != nil {
+			return err
+		}
+		for _, w := range withholdings {
+			w.InvoiceID = inv.ID
+			if err := invoiceRepo.CreateWithholding(w); err != nil {
+				return err
+			}
+		}
+		for _, ac := range inv.AllowanceCharges {
+			ac.InvoiceID = inv.ID
+			if err := invoiceRepo.CreateAllowanceCharge(ac); err != nil {
+				return err
+			}
+		}
+		for _, inst := range inv.Installments {
+			inst.InvoiceID = inv.ID
+			if err := invoiceRepo.CreateInstallment(inst); err != nil {
+				return err
+			}
+		}
+		for i, item := range in.Items {
+			components, ok := kitsByID[item.ProductID]
+			if !ok {
+				continue
+			}
+			for _, line := range kitBreakdown(invoiceID, item.ProductID, details[i].NetUnitPrice(), components) {
+				if err := invoiceRepo.CreateKitComponent(line); err != nil {
+					return err
+				}
+			}
+		}
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	inventory.committed(companyID)
 
 	// ── Post-commit: firma DIAN asíncrona ─────────────────────────────────────
 	// La factura ya está committed en DRAFT. El orquestador re-fetcha todos los
 	// datos frescos (empresa, cliente, resolución, productos) y ejecuta el ciclo
 	// CUFE → XML → Firma → QR → Update con su propio context de 30 s.
-	if uc.dianConfig.TechnicalKey != "" {
+	// El documento POS no depende de la clave técnica (su CUDE usa el PIN del software) y se encola
+	// en la cola acotada del orquestador para no saturarlo en horas pico de caja.
+	if inv.IsPOS() {
+		uc.dianOrchestrator.Enqueue(invoiceID)
+	} else if uc.dianConfig.TechnicalKey != "" || uc.dianOrchestrator.ResolvesCredentialsPerCompany() {
 		uc.dianOrchestrator.ProcessAsync(invoiceID)
 	}
 
 	return uc.toResponse(inv, customer.Name, details), nil
 }
 
+// documentTerms moneda, tasa de cambio y tipo de factura resueltos para el documento.
+type documentTerms struct {
+	currency     string
+	exchangeRate decimal.Decimal
+	invoiceType  string
+	incoterm     string
+}
+
+// resolveDocumentTerms valida la moneda y el tipo de factura:
+//   - Moneda vacía = COP con tasa 1; otra moneda admitida exige tasa de cambio positiva.
+//   - Exportación (02) exige un Incoterm válido y un cliente con país distinto de Colombia;
+//     el Incoterm no aplica a la venta nacional.
+//   - El documento equivalente POS (20) solo se emite en pesos y sin Incoterm.
+//   - La factura de contingencia (03) solo se emite con una contingencia activa y sin Incoterm.
+func resolveDocumentTerms(in dto.CreateInvoiceRequest, customer *entity.Customer) (documentTerms, error) {
+	t := documentTerms{
+		currency:    strings.ToUpper(strings.TrimSpace(in.CurrencyCode)),
+		invoiceType: strings.TrimSpace(in.InvoiceTypeCode),
+		incoterm:    strings.ToUpper(strings.TrimSpace(in.Incoterm)),
+	}
+	if t.currency == "" {
+		t.currency = dian.CurrencyCOP
+	}
+	if !dian.ValidCurrencyCodes[t.currency] {
+		return t, domain.ErrInvalidInput
+	}
+	if t.currency == dian.CurrencyCOP {
+		if !in.ExchangeRate.IsZero() && !in.ExchangeRate.Equal(decimal.NewFromInt(1)) {
+			return t, domain.ErrInvalidInput
+		}
+		t.exchangeRate = decimal.NewFromInt(1)
+	} else {
+		if !in.ExchangeRate.IsPositive() {
+			return t, domain.ErrInvalidInput
+		}
+		t.exchangeRate = in.ExchangeRate
+	}
+	switch t.invoiceType {
+	case "", dian.InvoiceTypeVenta:
+		t.invoiceType = dian.InvoiceTypeVenta
+		if t.incoterm != "" {
+			return t, domain.ErrInvalidInput
+		}
+	case dian.InvoiceTypeExportacion:
+		if _, ok := dian.ValidIncoterms[t.incoterm]; !ok {
+			return t, domain.ErrInvalidInput
+		}
+		if customer.CountryCode == "" || customer.CountryCode == "CO" {
+			return t, domain.ErrInvalidInput
+		}
+	case dian.InvoiceTypeDocumentoEquivalentePOS:
+		if t.currency != dian.CurrencyCOP || t.incoterm != "" {
+			return t, domain.ErrInvalidInput
+		}
+	case dian.InvoiceTypeContingencia:
+		if in.ContingencyID == "" || t.incoterm != "" {
+			return t, domain.ErrInvalidInput
+		}
+	default:
+		return t, domain.ErrInvalidInput
+	}
+	return t, nil
+}
+
+// paymentTerms forma de pago, medios, vencimiento y cuotas resueltos para la factura.
+type paymentTerms struct {
+	form         string
+	methods      []string
+	dueDate      *time.Time
+	installments []*entity.InvoiceInstallment
+}
+
+// resolvePaymentTerms valida la forma y los medios de pago:
+//   - Contado (por defecto) no admite vencimiento ni cuotas.
+//   - Crédito vence en due_date, en la última cuota o, sin ninguno, en la fecha de emisión más el
+//     plazo de pago del cliente; sin ninguno de ellos se rechaza.
+//   - Las cuotas tienen valor positivo, vencen en orden y nunca antes de la emisión.
+func resolvePaymentTerms(in dto.CreateInvoiceRequest, customer *entity.Customer, issued time.Time) (paymentTerms, error) {
+	p := paymentTerms{form: strings.TrimSpace(in.PaymentFormCode)}
+	if p.form == "" {
+		p.form = dian.PaymentFormContado
+	}
+	if !dian.ValidPaymentForms[p.form] {
+		return p, domain.ErrInvalidInput
+	}
+	seen := make(map[string]bool, len(in.PaymentMethodCodes))
+	for _, code := range in.PaymentMethodCodes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if !dian.ValidPaymentMethods[code] {
+			return p, domain.ErrInvalidInput
+		}
+		if !seen[code] {
+			seen[code] = true
+			p.methods = append(p.methods, code)
+		}
+	}
+	if len(p.methods) == 0 {
+		p.methods = []string{dian.PaymentMethodEfectivo}
+	}
+
+	if p.form == dian.PaymentFormContado {
+		if in.DueDate != "" || len(in.Installments) > 0 {
+			return p, domain.ErrInvalidInput
+		}
+		return p, nil
+	}
+
+	issueDay := time.Date(issued.Year(), issued.Month(), issued.Day(), 0, 0, 0, 0, time.UTC)
+	var dueDate *time.Time
+	if in.DueDate != "" {
+		d, err := time.Parse("2006-01-02", in.DueDate)
+		if err != nil || d.Before(issueDay) {
+			return p, domain.ErrInvalidInput
+		}
+		dueDate = &d
+	}
+	var previous time.Time
+	for i, req := range in.Installments {
+		d, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil || d.Before(issueDay) || d.Before(previous) || !req.Amount.IsPositive() {
+			return p, domain.ErrInvalidInput
+		}
+		previous = d
+		p.installments = append(p.installments, &entity.InvoiceInstallment{
+			Number:  i + 1,
+			DueDate: d,
+			Amount:  req.Amount,
+		})
+	}
+	if n := len(p.installments); n > 0 {
+		last := p.installments[n-1].DueDate
+		if dueDate != nil && !dueDate.Equal(last) {
+			return p, domain.ErrInvalidInput
+		}
+		dueDate = &last
+	}
+	if dueDate == nil && customer.PaymentTermDays > 0 {
+		d := issueDay.AddDate(0, 0, customer.PaymentTermDays)
+		dueDate = &d
+	}
+	if dueDate == nil {
+		return p, domain.ErrInvalidInput
+	}
+	p.dueDate = dueDate
+	return p, nil
+}
+
+// lineDiscount valida y resuelve el descuento de una línea: porcentaje (0-100) sobre cantidad ×
+// precio o valor fijo, no ambos; el descuento no puede superar el valor bruto de la línea.
+func lineDiscount(item dto.InvoiceItemRequest) (decimal.Decimal, error) {
+	if item.DiscountRate.LessThan(decimal.Zero) || item.DiscountRate.GreaterThan(decimal.NewFromInt(100)) ||
+		item.DiscountAmount.LessThan(decimal.Zero) {
+		return decimal.Zero, domain.ErrInvalidInput
+	}
+	if item.DiscountRate.IsPositive() && item.DiscountAmount.IsPositive() {
+		return decimal.Zero, domain.ErrInvalidInput
+	}
+	gross := item.Quantity.Mul(item.UnitPrice)
+	discount := domaindian.AllowanceAmount(gross, item.DiscountRate, item.DiscountAmount)
+	if discount.GreaterThan(gross) {
+		return decimal.Zero, domain.ErrInvalidInput
+	}
+	return discount, nil
+}
+
+// globalAllowanceCharges valida los descuentos y cargos globales y calcula su valor sobre el
+// subtotal neto. Los descuentos llevan código DIAN (Tabla 13.3.8; por defecto 09 descuento general).
+func globalAllowanceCharges(reqs []dto.AllowanceChargeRequest, base decimal.Decimal) ([]*entity.InvoiceAllowanceCharge, error) {
+	out := make([]*entity.InvoiceAllowanceCharge, 0, len(reqs))
+	for _, r := range reqs {
+		if r.Rate.LessThan(decimal.Zero) || r.Rate.GreaterThan(decimal.NewFromInt(100)) || r.Amount.LessThan(decimal.Zero) {
+			return nil, domain.ErrInvalidInput
+		}
+		if r.Rate.IsPositive() && r.Amount.IsPositive() {
+			return nil, domain.ErrInvalidInput
+		}
+		amount := domaindian.AllowanceAmount(base, r.Rate, r.Amount)
+		if !amount.IsPositive() {
+			return nil, domain.ErrInvalidInput
+		}
+		ac := &entity.InvoiceAllowanceCharge{
+			ChargeIndicator: r.ChargeIndicator,
+			Reason:          strings.TrimSpace(r.Reason),
+			Rate:            r.Rate,
+			BaseAmount:      base.Round(2),
+			Amount:          amount,
+		}
+		if r.ChargeIndicator {
+			if ac.Reason == "" {
+				ac.Reason = "Cargo"
+			}
+		} else {
+			ac.ReasonCode = strings.TrimSpace(r.ReasonCode)
+			if ac.ReasonCode == "" {
+				ac.ReasonCode = dian.DiscountReasonGeneral
+			}
+			if !dian.ValidDiscountReasonCodes[ac.ReasonCode] {
+				return nil, domain.ErrInvalidInput
+			}
+			if ac.Reason == "" {
+				ac.Reason = "Descuento"
+			}
+		}
+		out = append(out, ac)
+	}
+	return out, nil
+}
+
+// withholdingInput prepara el cálculo de retenciones: nil si no hay configuración o el cliente no
+// es agente de retención (O-13 / O-23). Las responsabilidades del emisor y las tarifas se leen
+// fuera de la transacción, igual que el resto de validaciones de solo lectura.
+func (uc *CreateInvoiceUseCase) withholdingInput(ctx context.Context, companyID string, customer *entity.Customer) (*domaindian.WithholdingInput, error) {
+	if uc.withholdingRepo == nil || !domaindian.IsWithholdingAgent(customer.FiscalResponsibilities) {
+		return nil, nil
+	}
+	seller, err := uc.withholdingRepo.GetCompanyResponsibilities(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := uc.withholdingRepo.ListRules(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return &domaindian.WithholdingInput{
+		SellerResponsibilities: seller,
+		BuyerResponsibilities:  customer.FiscalResponsibilities,
+		Rules:                  rules,
+		UVT:                    uc.uvt,
+	}, nil
+}
+
+// loadKitComponents carga la composición del kit y valida que sea vendible: debe tener
+// componentes y todos deben ser productos estándar de la misma empresa.
+func (uc *CreateInvoiceUseCase) loadKitComponents(kit *entity.Product) ([]*entity.KitComponent, error) {
+	if uc.kitRepo == nil {
+		return nil, domain.ErrInvalidInput
+	}
+	components, err := uc.kitRepo.ListComponents(kit.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) == 0 {
+		return nil, domain.ErrInvalidInput
+	}
+	for _, c := range components {
+		if c.Component == nil || c.Component.CompanyID != kit.CompanyID || c.Component.IsKit() {
+			return nil, domain.ErrInvalidInput
+		}
+	}
+	return components, nil
+}
+
+// kitBreakdown arma el desglose de una línea de kit: el precio unitario del kit se prorratea
+// entre los componentes según su precio de lista (o su costo si no tienen precio) por cantidad.
+func kitBreakdown(invoiceID, kitProductID string, kitUnitPrice decimal.Decimal, components []*entity.KitComponent) []*entity.InvoiceKitComponent {
+	weights := make([]decimal.Decimal, len(components))
+	for i, c := range components {
+		weight := c.Component.Price
+		if !weight.GreaterThan(decimal.Zero) {
+			weight = c.Component.Cost
+		}
+		weights[i] = weight.Mul(c.Quantity)
+	}
+	shares := domaininventory.KitPriceShares(kitUnitPrice, weights)
+	lines := make([]*entity.InvoiceKitComponent, 0, len(components))
+	for i, c := range components {
+		lines = append(lines, &entity.InvoiceKitComponent{
+			ID:                 uuid.New().String(),
+			InvoiceID:          invoiceID,
+			KitProductID:       kitProductID,
+			ComponentProductID: c.ComponentProductID,
+			Quantity:           c.Quantity,
+			UnitPrice:          shares[i].Div(c.Quantity).Round(2),
+			UnitCost:           c.Component.Cost,
+		})
+	}
+	return lines
+}
+
 func (uc *CreateInvoiceUseCase) toResponse(inv *entity.Invoice, customerName string, details []*entity.InvoiceDetail) *dto.InvoiceResponse {
 	resp := &dto.InvoiceResponse{
 		ID:           inv.ID,
@@ -256,15 +773,62 @@ func (uc *CreateInvoiceUseCase) toResponse(inv *entity.Invoice, customerName str
 		CUFE:         inv.CUFE,
 		QRData:       inv.QRData,
 		Details:      make([]dto.InvoiceDetailResponse, 0, len(details)),
+
+		WithholdingTotal: inv.WithholdingTotal,
+		PayableAmount:    inv.PayableAmount(),
+		DiscountTotal:    inv.DiscountTotal,
+		AllowanceTotal:   inv.AllowanceTotal,
+		ChargeTotal:      inv.ChargeTotal,
+		CurrencyCode:     inv.CurrencyCode,
+		ExchangeRate:     inv.ExchangeRate,
+		InvoiceTypeCode:  inv.InvoiceTypeCode,
+		ContingencyID:    inv.ContingencyID,
+		Incoterm:         inv.Incoterm,
+		NetTotalCOP:      inv.NetTotalCOP,
+		TaxTotalCOP:      inv.TaxTotalCOP,
+		GrandTotalCOP:    inv.GrandTotalCOP,
+		PaymentFormCode:  inv.PaymentFormCode,
+		PaymentMethods:   inv.PaymentMethodCodes,
+	}
+	if inv.DueDate != nil {
+		resp.DueDate = inv.DueDate.Format("2006-01-02")
+	}
+	for _, inst := range inv.Installments {
+		resp.Installments = append(resp.Installments, dto.InstallmentDTO{
+			Number:  inst.Number,
+			DueDate: inst.DueDate.Format("2006-01-02"),
+			Amount:  inst.Amount,
+		})
+	}
+	for _, ac := range inv.AllowanceCharges {
+		resp.AllowanceCharges = append(resp.AllowanceCharges, dto.AllowanceChargeDTO{
+			InvoiceDetailID: ac.InvoiceDetailID,
+			ChargeIndicator: ac.ChargeIndicator,
+			ReasonCode:      ac.ReasonCode,
+			Reason:          ac.Reason,
+			Rate:            ac.Rate,
+			BaseAmount:      ac.BaseAmount,
+			Amount:          ac.Amount,
+		})
+	}
+	for _, w := range inv.Withholdings {
+		resp.Withholdings = append(resp.Withholdings, dto.InvoiceWithholdingDTO{
+			TaxCode:    w.TaxCode,
+			Concept:    w.Concept,
+			BaseAmount: w.BaseAmount,
+			Rate:       w.Rate,
+			Amount:     w.Amount,
+		})
 	}
 	for _, d := range details {
 		resp.Details = append(resp.Details, dto.InvoiceDetailResponse{
-			ID:        d.ID,
-			ProductID: d.ProductID,
-			Quantity:  d.Quantity,
-			UnitPrice: d.UnitPrice,
-			TaxRate:   d.TaxRate,
-			Subtotal:  d.Subtotal,
+			ID:             d.ID,
+			ProductID:      d.ProductID,
+			Quantity:       d.Quantity,
+			UnitPrice:      d.UnitPrice,
+			TaxRate:        d.TaxRate,
+			DiscountAmount: d.DiscountAmount,
+			Subtotal:       d.Subtotal,
 		})
 	}
 	return resp
@@ -289,6 +853,7 @@ func (uc *CreateInvoiceUseCase) GetInvoiceDIANStatus(ctx context.Context, compan
 		CUFE:       inv.CUFE,
 		TrackID:    inv.TrackID,
 		Errors:     inv.DIANErrors,
+		Rules:      toDIANRuleDTOs(inv.DIANRules),
 	}, nil
 }
 
@@ -303,11 +868,20 @@ func (uc *CreateInvoiceUseCase) GetDIANSummary(ctx context.Context, companyID st
 		return nil, err
 	}
 
-	return &dto.DIANSummaryDTO{
-		SentToday: summary.SentToday,
-		Pending:   summary.Pending,
-		Rejected:  summary.Rejected,
-	}, nil
+	out := &dto.DIANSummaryDTO{
+		SentToday:   summary.SentToday,
+		Pending:     summary.Pending,
+		Rejected:    summary.Rejected,
+		Resolutions: []dto.ResolutionStatusDTO{},
+	}
+	if uc.resolutionMon != nil {
+		resolutions, err := uc.resolutionMon.Status(ctx, companyID)
+		if err != nil {
+			return nil, err
+		}
+		out.Resolutions = resolutions
+	}
+	return out, nil
 }
 
 // ListInvoices retorna facturas paginadas y filtradas para una empresa.
@@ -366,6 +940,21 @@ func (uc *CreateInvoiceUseCase) GetInvoice(ctx context.Context, companyID, id st
 	if err != nil {
 		return nil, err
 	}
+	if inv.WithholdingTotal.GreaterThan(decimal.Zero) {
+		if inv.Withholdings, err = uc.invoiceRepo.GetWithholdingsByInvoiceID(id); err != nil {
+			return nil, err
+		}
+	}
+	if inv.DiscountTotal.IsPositive() || inv.ChargeTotal.IsPositive() {
+		if inv.AllowanceCharges, err = uc.invoiceRepo.GetAllowanceChargesByInvoiceID(id); err != nil {
+			return nil, err
+		}
+	}
+	if inv.IsCredit() {
+		if inv.Installments, err = uc.invoiceRepo.GetInstallmentsByInvoiceID(id); err != nil {
+			return nil, err
+		}
+	}
 	customer, _ := uc.customerRepo.GetByID(inv.CustomerID)
 	customerName := ""
 	if customer != nil {
@@ -398,5 +987,14 @@ func (uc *CreateInvoiceUseCase) RetryDIAN(ctx context.Context, companyID, id str
 		CUFE:       inv.CUFE,
 		TrackID:    inv.TrackID,
 		Errors:     inv.DIANErrors,
+		Rules:      toDIANRuleDTOs(inv.DIANRules),
 	}, nil
 }
+
+func toDIANRuleDTOs(rules []entity.DIANValidationRule) []dto.DIANRuleDTO {
+	out := make([]dto.DIANRuleDTO, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, dto.DIANRuleDTO{Code: r.Code, Description: r.Description, Severity: r.Severity})
+	}
+	return out
+}
@@ -59,6 +59,7 @@ var _ BillingTxRunner = (*fakeBillingTxRunner)(nil)
 type fakeInventoryUC struct {
 	registerOUTFunc    func(ctx context.Context, movRepo repository.InventoryMovementRepository, stockRepo repository.StockRepository, productRepo repository.ProductRepository, product *entity.Product, productID, warehouseID, userID string, quantity decimal.Decimal, now time.Time, transactionID string) error
 	registerReturnFunc func(ctx context.Context, movRepo repository.InventoryMovementRepository, stockRepo repository.StockRepository, productRepo repository.ProductRepository, product *entity.Product, productID, warehouseID, userID string, quantity decimal.Decimal, now time.Time, transactionID string) error
+	notified           []string // producto@bodega avisados tras el commit
 }
 
 func (f *fakeInventoryUC) RegisterOUTInTx(
@@ -95,6 +96,10 @@ func (f *fakeInventoryUC) RegisterReturnInTx(
 	return nil
 }
 
+func (f *fakeInventoryUC) NotifyStockChanged(companyID, productID, warehouseID string) {
+	f.notified = append(f.notified, productID+"@"+warehouseID)
+}
+
 var _ InventoryUseCase = (*fakeInventoryUC)(nil)
 
 // ── Fake CustomerRepository ────────────────────────────────────────────────────
@@ -154,6 +159,10 @@ func (f *fakeCustomerRepo) SetActive(companyID, id string, isActive bool) error
 	return nil
 }
 
+func (f *fakeCustomerRepo) GetByCompanyAndEmail(companyID, email string) (*entity.Customer, error) {
+	return nil, nil
+}
+
 var _ repository.CustomerRepository = (*fakeCustomerRepo)(nil)
 
 // ── Fake CompanyRepository ───────────────────────────────────────────────────
@@ -211,6 +220,40 @@ func (f *fakeCompanyRepo) Delete(id string) error {
 	return nil
 }
 
+func (f *fakeCompanyRepo) ListForAdmin(limit, offset int) ([]*entity.Company, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepo) ListModules(ctx context.Context, companyID string) ([]*entity.CompanyModule, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepo) GetModule(ctx context.Context, companyID, moduleName string) (*entity.CompanyModule, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepo) UpsertModule(ctx context.Context, module *entity.CompanyModule) error {
+	return nil
+}
+func (f *fakeCompanyRepo) DeleteModule(ctx context.Context, companyID, moduleName string) error {
+	return nil
+}
+func (f *fakeCompanyRepo) HasActiveScreen(ctx context.Context, companyID, screenID string) (bool, error) {
+	return true, nil
+}
+func (f *fakeCompanyRepo) ListScreens(ctx context.Context, companyID string) ([]*entity.CompanyScreen, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepo) GetScreen(ctx context.Context, companyID, screenID string) (*entity.CompanyScreen, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepo) UpsertScreen(ctx context.Context, screen *entity.CompanyScreen) error {
+	return nil
+}
+func (f *fakeCompanyRepo) ReplaceScreens(ctx context.Context, companyID string, screenIDs []string) error {
+	return nil
+}
+func (f *fakeCompanyRepo) DeleteScreen(ctx context.Context, companyID, screenID string) error {
+	return nil
+}
+
 var _ repository.CompanyRepository = (*fakeCompanyRepo)(nil)
 
 // ── Fake ProductRepository ─────────────────────────────────────────────────────
@@ -325,6 +368,17 @@ type fakeInvoiceRepo struct {
 	getDIANSummaryFunc        func(companyID string) (*repository.DIANSummary, error)
 	updateReturnStatusFunc    func(invoiceID string, status string) error
 	listFunc                  func(filter repository.InvoiceListFilter) ([]*entity.Invoice, int, error)
+	kitComponents             []*entity.InvoiceKitComponent
+	taxes                     []*entity.InvoiceTax
+	withholdings              []*entity.InvoiceWithholding
+	allowanceCharges          []*entity.InvoiceAllowanceCharge
+	installments              []*entity.InvoiceInstallment
+	// resolution es la resolución activa del prefijo; nil usa una vigente con rango amplio.
+	resolution   *entity.BillingResolution
+	noResolution bool
+	// noteResolution es la resolución de notas; nil usa una vigente del tipo pedido con prefijo "NC"/"ND".
+	noteResolution *entity.BillingResolution
+	noteSequences  map[string]int64
 }
 
 func (f *fakeInvoiceRepo) Create(invoice *entity.Invoice) error {
@@ -357,6 +411,115 @@ func (f *fakeInvoiceRepo) GetDetailsByInvoiceID(invoiceID string) ([]*entity.Inv
 	}
 	return nil, nil
 }
+func (f *fakeInvoiceRepo) CreateKitComponent(line *entity.InvoiceKitComponent) error {
+	f.kitComponents = append(f.kitComponents, line)
+	return nil
+}
+func (f *fakeInvoiceRepo) CreateTax(tax *entity.InvoiceTax) error {
+	f.taxes = append(f.taxes, tax)
+	return nil
+}
+func (f *fakeInvoiceRepo) GetTaxesByInvoiceID(invoiceID string) ([]*entity.InvoiceTax, error) {
+	var out []*entity.InvoiceTax
+	for _, t := range f.taxes {
+		if t.InvoiceID == invoiceID {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+func (f *fakeInvoiceRepo) CreateWithholding(w *entity.InvoiceWithholding) error {
+	f.withholdings = append(f.withholdings, w)
+	return nil
+}
+func (f *fakeInvoiceRepo) GetWithholdingsByInvoiceID(invoiceID string) ([]*entity.InvoiceWithholding, error) {
+	var out []*entity.InvoiceWithholding
+	for _, w := range f.withholdings {
+		if w.InvoiceID == invoiceID {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+func (f *fakeInvoiceRepo) CreateAllowanceCharge(ac *entity.InvoiceAllowanceCharge) error {
+	f.allowanceCharges = append(f.allowanceCharges, ac)
+	return nil
+}
+func (f *fakeInvoiceRepo) GetAllowanceChargesByInvoiceID(invoiceID string) ([]*entity.InvoiceAllowanceCharge, error) {
+	var out []*entity.InvoiceAllowanceCharge
+	for _, ac := range f.allowanceCharges {
+		if ac.InvoiceID == invoiceID {
+			out = append(out, ac)
+		}
+	}
+	return out, nil
+}
+func (f *fakeInvoiceRepo) CreateInstallment(inst *entity.InvoiceInstallment) error {
+	f.installments = append(f.installments, inst)
+	return nil
+}
+func (f *fakeInvoiceRepo) GetInstallmentsByInvoiceID(invoiceID string) ([]*entity.InvoiceInstallment, error) {
+	var out []*entity.InvoiceInstallment
+	for _, inst := range f.installments {
+		if inst.InvoiceID == invoiceID {
+			out = append(out, inst)
+		}
+	}
+	return out, nil
+}
+func (f *fakeInvoiceRepo) GetKitComponentsByInvoiceID(invoiceID string) ([]*entity.InvoiceKitComponent, error) {
+	var out []*entity.InvoiceKitComponent
+	for _, l := range f.kitComponents {
+		if l.InvoiceID == invoiceID {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+func (f *fakeInvoiceRepo) LockActiveResolution(companyID, prefix string) (*entity.BillingResolution, error) {
+	if f.noResolution {
+		return nil, nil
+	}
+	if f.noteResolution != nil && f.noteResolution.Prefix == prefix {
+		return f.noteResolution, nil
+	}
+	if f.resolution == nil {
+		f.resolution = validResolution(companyID, prefix)
+	}
+	return f.resolution, nil
+}
+func (f *fakeInvoiceRepo) LockActiveNoteResolution(companyID, documentType string) (*entity.BillingResolution, error) {
+	if f.noResolution {
+		return nil, nil
+	}
+	if f.noteResolution == nil {
+		prefix := "NC"
+		if documentType == entity.ResolutionDocumentDebitNote {
+			prefix = "ND"
+		}
+		f.noteResolution = validResolution(companyID, prefix)
+		f.noteResolution.DocumentType = documentType
+	}
+	if f.noteResolution.DocumentType != documentType {
+		return nil, nil
+	}
+	return f.noteResolution, nil
+}
+func (f *fakeInvoiceRepo) NextNoteSequence(companyID, documentType string) (int64, error) {
+	if f.noteSequences == nil {
+		f.noteSequences = map[string]int64{}
+	}
+	f.noteSequences[documentType]++
+	return f.noteSequences[documentType], nil
+}
+func (f *fakeInvoiceRepo) ConsumeResolutionNumber(resolutionID string) error {
+	if f.noteResolution != nil && f.noteResolution.ID == resolutionID {
+		f.noteResolution.UsedNumbers++
+		return nil
+	}
+	f.resolution.UsedNumbers++
+	return nil
+}
 func (f *fakeInvoiceRepo) GetDIANStatus(id string) (*entity.Invoice, error) {
 	if f.getDIANStatusFunc != nil {
 		return f.getDIANStatusFunc(id)
@@ -426,6 +589,22 @@ func validProduct(companyID, productID string, price, taxRate decimal.Decimal) *
 	}
 }
 
+// validResolution devuelve una resolución vigente hoy con rango 1001-5000 sin números usados.
+func validResolution(companyID, prefix string) *entity.BillingResolution {
+	now := time.Now()
+	return &entity.BillingResolution{
+		ID:               "res-" + prefix,
+		CompanyID:        companyID,
+		ResolutionNumber: "18764000000001",
+		Prefix:           prefix,
+		RangeFrom:        1001,
+		RangeTo:          5000,
+		DateFrom:         now.AddDate(0, -1, 0),
+		DateTo:           now.AddDate(1, 0, 0),
+		IsActive:         true,
+	}
+}
+
 func validWarehouse(companyID string) *entity.Warehouse {
 	return &entity.Warehouse{
 		ID:        testWarehouseID,
@@ -890,3 +1069,374 @@ func TestCreateInvoiceUseCase_CreateInvoice(t *testing.T) {
 		})
 	}
 }
+
+// ── Kits ──────────────────────────────────────────────────────────────────────
+
+const testKitID = "kit-001"
+
+type fakeKitRepo struct {
+	components map[string][]*entity.KitComponent
+}
+
+func (f *fakeKitRepo) ListComponents(kitProductID string) ([]*entity.KitComponent, error) {
+	return f.components[kitProductID], nil
+}
+func (f *fakeKitRepo) ReplaceComponents(kitProductID string, components []*entity.KitComponent) error {
+	f.components[kitProductID] = components
+	return nil
+}
+
+var _ repository.ProductKitRepository = (*fakeKitRepo)(nil)
+
+// giftBox kit de 27000 con 2× product1 (precio 10000) y 2× product2 (precio 5000).
+func giftBox() (*entity.Product, *fakeKitRepo) {
+	kit := validProduct(testCompanyID, testKitID, decimal.NewFromInt(27000), decimal.NewFromInt(19))
+	kit.ProductType = entity.ProductTypeKit
+	c1 := validProduct(testCompanyID, testProductID1, decimal.NewFromInt(10000), decimal.NewFromInt(19))
+	c1.Cost = decimal.NewFromInt(6000)
+	c2 := validProduct(testCompanyID, testProductID2, decimal.NewFromInt(5000), decimal.NewFromInt(19))
+	c2.Cost = decimal.NewFromInt(3000)
+	return kit, &fakeKitRepo{components: map[string][]*entity.KitComponent{
+		testKitID: {
+			{KitProductID: testKitID, ComponentProductID: testProductID1, Quantity: decimal.NewFromInt(2), Component: c1},
+			{KitProductID: testKitID, ComponentProductID: testProductID2, Quantity: decimal.NewFromInt(2), Component: c2},
+		},
+	}}
+}
+
+func TestCreateInvoiceUseCase_KitDeductsComponents(t *testing.T) {
+	kit, kitRepo := giftBox()
+	customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return validCustomer(testCompanyID), nil }}
+	companyRepo := &fakeCompanyRepo{
+		getByIDFunc:         func(id string) (*entity.Company, error) { return validCompany(id), nil },
+		hasActiveModuleFunc: func(context.Context, string, string) (bool, error) { return true, nil },
+	}
+	productRepo := &fakeProductRepo{getByIDFunc: func(string) (*entity.Product, error) { return kit, nil }}
+	warehouseRepo := &fakeWarehouseRepo{getByIDFunc: func(string) (*entity.Warehouse, error) { return validWarehouse(testCompanyID), nil }}
+	invoiceRepo := &fakeInvoiceRepo{}
+	txRunner := &fakeBillingTxRunner{
+		runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository,
+			repository.StockRepository,
+			repository.ProductRepository,
+			repository.CustomerRepository,
+			repository.InvoiceRepository,
+		) error) error {
+			return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+		},
+	}
+	outs := map[string]decimal.Decimal{}
+	inventoryUC := &fakeInventoryUC{
+		registerOUTFunc: func(_ context.Context, _ repository.InventoryMovementRepository, _ repository.StockRepository, _ repository.ProductRepository, _ *entity.Product, productID, _, _ string, quantity decimal.Decimal, _ time.Time, _ string) error {
+			outs[productID] = outs[productID].Add(quantity)
+			return nil
+		},
+	}
+	in := dto.CreateInvoiceRequest{
+		CustomerID:  testCustomerID,
+		WarehouseID: testWarehouseID,
+		Prefix:      "FV",
+		Items:       []dto.InvoiceItemRequest{{ProductID: testKitID, Quantity: decimal.NewFromInt(3)}},
+	}
+
+	t.Run("WithoutKitRepository", func(t *testing.T) {
+		uc := NewCreateInvoiceUseCase(txRunner, inventoryUC, customerRepo, companyRepo, productRepo, warehouseRepo, invoiceRepo, nil, DIANConfig{})
+		_, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, in)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		assert.Empty(t, outs)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		uc := NewCreateInvoiceUseCase(txRunner, inventoryUC, customerRepo, companyRepo, productRepo, warehouseRepo, invoiceRepo, nil, DIANConfig{})
+		uc.SetKitRepository(kitRepo)
+		out, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, in)
+		require.NoError(t, err)
+
+		require.Len(t, out.Details, 1)
+		assert.Equal(t, testKitID, out.Details[0].ProductID)
+		assert.True(t, out.NetTotal.Equal(decimal.NewFromInt(81000)), "NetTotal %s", out.NetTotal)
+
+		require.Len(t, outs, 2)
+		assert.True(t, outs[testProductID1].Equal(decimal.NewFromInt(6)))
+		assert.True(t, outs[testProductID2].Equal(decimal.NewFromInt(6)))
+
+		require.Len(t, invoiceRepo.kitComponents, 2)
+		byComponent := map[string]*entity.InvoiceKitComponent{}
+		for _, l := range invoiceRepo.kitComponents {
+			assert.Equal(t, out.ID, l.InvoiceID)
+			byComponent[l.ComponentProductID] = l
+		}
+		// 27000 se prorratea 2:1 según precio de lista → 18000 / 9000 por kit, 9000 / 4500 por unidad.
+		assert.True(t, byComponent[testProductID1].UnitPrice.Equal(decimal.NewFromInt(9000)))
+		assert.True(t, byComponent[testProductID2].UnitPrice.Equal(decimal.NewFromInt(4500)))
+		assert.True(t, byComponent[testProductID1].UnitCost.Equal(decimal.NewFromInt(6000)))
+	})
+}
+
+// ── Descuentos y cargos (AllowanceCharge) ─────────────────────────────────────
+
+func TestCreateInvoiceUseCase_AllowanceCharges(t *testing.T) {
+	customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return validCustomer(testCompanyID), nil }}
+	companyRepo := &fakeCompanyRepo{
+		getByIDFunc:         func(id string) (*entity.Company, error) { return validCompany(id), nil },
+		hasActiveModuleFunc: func(context.Context, string, string) (bool, error) { return false, nil },
+	}
+	productRepo := &fakeProductRepo{getByIDFunc: func(id string) (*entity.Product, error) {
+		if id == testProductID1 {
+			return validProduct(testCompanyID, id, decimal.NewFromInt(10000), decimal.NewFromInt(19)), nil
+		}
+		return validProduct(testCompanyID, id, decimal.NewFromInt(50000), decimal.NewFromInt(5)), nil
+	}}
+	invoiceRepo := &fakeInvoiceRepo{}
+	txRunner := &fakeBillingTxRunner{
+		runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository,
+			repository.StockRepository,
+			repository.ProductRepository,
+			repository.CustomerRepository,
+			repository.InvoiceRepository,
+		) error) error {
+			return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+		},
+	}
+	uc := NewCreateInvoiceUseCase(txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, productRepo, &fakeWarehouseRepo{}, invoiceRepo, nil, DIANConfig{})
+
+	// Ítem1: 20000 − 10% = 18000, IVA 19% = 3420. Ítem2: 50000 − 5000 = 45000, IVA 5% = 2250.
+	// Descuento pronto pago 5% de 63000 = 3150; flete 8000.
+	// GrandTotal = 63000 + 5670 − 3150 + 8000 = 73520.
+	req := validCreateInvoiceRequest()
+	req.Items[0].DiscountRate = decimal.NewFromInt(10)
+	req.Items[1].DiscountAmount = decimal.NewFromInt(5000)
+	req.AllowanceCharges = []dto.AllowanceChargeRequest{
+		{ReasonCode: "03", Rate: decimal.NewFromInt(5)},
+		{ChargeIndicator: true, Reason: "Flete", Amount: decimal.NewFromInt(8000)},
+	}
+
+	out, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, req)
+	require.NoError(t, err)
+	assert.True(t, out.NetTotal.Equal(decimal.NewFromInt(63000)), "NetTotal: %s", out.NetTotal)
+	assert.True(t, out.TaxTotal.Equal(decimal.NewFromInt(5670)), "TaxTotal: %s", out.TaxTotal)
+	assert.True(t, out.AllowanceTotal.Equal(decimal.NewFromInt(3150)), "AllowanceTotal: %s", out.AllowanceTotal)
+	assert.True(t, out.ChargeTotal.Equal(decimal.NewFromInt(8000)), "ChargeTotal: %s", out.ChargeTotal)
+	assert.True(t, out.DiscountTotal.Equal(decimal.NewFromInt(10150)), "DiscountTotal: %s", out.DiscountTotal)
+	assert.True(t, out.GrandTotal.Equal(decimal.NewFromInt(73520)), "GrandTotal: %s", out.GrandTotal)
+	require.Len(t, out.Details, 2)
+	assert.True(t, out.Details[0].DiscountAmount.Equal(decimal.NewFromInt(2000)))
+	assert.True(t, out.Details[0].Subtotal.Equal(decimal.NewFromInt(18000)))
+
+	stored, err := invoiceRepo.GetAllowanceChargesByInvoiceID(out.ID)
+	require.NoError(t, err)
+	require.Len(t, stored, 4)
+	assert.Equal(t, out.Details[0].ID, stored[0].InvoiceDetailID)
+	assert.Equal(t, "03", stored[2].ReasonCode)
+	assert.Empty(t, stored[2].InvoiceDetailID)
+	assert.True(t, stored[3].ChargeIndicator)
+
+	invalid := map[string]func(r *dto.CreateInvoiceRequest){
+		"RateAndAmount": func(r *dto.CreateInvoiceRequest) {
+			r.Items[0].DiscountRate = decimal.NewFromInt(10)
+			r.Items[0].DiscountAmount = decimal.NewFromInt(100)
+		},
+		"DiscountAboveGross": func(r *dto.CreateInvoiceRequest) { r.Items[0].DiscountAmount = decimal.NewFromInt(20001) },
+		"RateAbove100":       func(r *dto.CreateInvoiceRequest) { r.Items[0].DiscountRate = decimal.NewFromInt(101) },
+		"UnknownReasonCode": func(r *dto.CreateInvoiceRequest) {
+			r.AllowanceCharges = []dto.AllowanceChargeRequest{{ReasonCode: "99", Amount: decimal.NewFromInt(100)}}
+		},
+		"NegativeGrandTotal": func(r *dto.CreateInvoiceRequest) {
+			r.AllowanceCharges = []dto.AllowanceChargeRequest{{Amount: decimal.NewFromInt(100000)}}
+		},
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			r := validCreateInvoiceRequest()
+			mutate(&r)
+			_, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, r)
+			assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		})
+	}
+}
+
+func TestCreateInvoiceUseCase_ForeignCurrencyAndExport(t *testing.T) {
+	customer := validCustomer(testCompanyID)
+	customer.IdentificationType = "50"
+	customer.CountryCode = "US"
+	customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return customer, nil }}
+	companyRepo := &fakeCompanyRepo{
+		getByIDFunc:         func(id string) (*entity.Company, error) { return validCompany(id), nil },
+		hasActiveModuleFunc: func(context.Context, string, string) (bool, error) { return false, nil },
+	}
+	productRepo := &fakeProductRepo{getByIDFunc: func(id string) (*entity.Product, error) {
+		if id == testProductID1 {
+			return validProduct(testCompanyID, id, decimal.NewFromInt(10000), decimal.NewFromInt(19)), nil
+		}
+		return validProduct(testCompanyID, id, decimal.NewFromInt(50000), decimal.NewFromInt(5)), nil
+	}}
+	invoiceRepo := &fakeInvoiceRepo{}
+	txRunner := &fakeBillingTxRunner{
+		runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository,
+			repository.StockRepository,
+			repository.ProductRepository,
+			repository.CustomerRepository,
+			repository.InvoiceRepository,
+		) error) error {
+			return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+		},
+	}
+	uc := NewCreateInvoiceUseCase(txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, productRepo, &fakeWarehouseRepo{}, invoiceRepo, nil, DIANConfig{})
+
+	// Factura de exportación en USD con TRM 4000: 70000 + 6300 = 76300 USD → 305.200.000 COP.
+	req := validCreateInvoiceRequest()
+	req.CurrencyCode = "usd"
+	req.ExchangeRate = decimal.NewFromInt(4000)
+	req.InvoiceTypeCode = "02"
+	req.Incoterm = "fob"
+
+	out, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, req)
+	require.NoError(t, err)
+	assert.Equal(t, "USD", out.CurrencyCode)
+	assert.Equal(t, "02", out.InvoiceTypeCode)
+	assert.Equal(t, "FOB", out.Incoterm)
+	assert.True(t, out.GrandTotal.Equal(decimal.NewFromInt(76300)), "GrandTotal: %s", out.GrandTotal)
+	assert.True(t, out.NetTotalCOP.Equal(decimal.NewFromInt(280000000)), "NetTotalCOP: %s", out.NetTotalCOP)
+	assert.True(t, out.TaxTotalCOP.Equal(decimal.NewFromInt(25200000)), "TaxTotalCOP: %s", out.TaxTotalCOP)
+	assert.True(t, out.GrandTotalCOP.Equal(decimal.NewFromInt(305200000)), "GrandTotalCOP: %s", out.GrandTotalCOP)
+
+	// Factura nacional en COP: la TRM es 1 y los totales COP coinciden con los del documento.
+	out, err = uc.CreateInvoice(context.Background(), testCompanyID, testUserID, validCreateInvoiceRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "COP", out.CurrencyCode)
+	assert.Equal(t, "01", out.InvoiceTypeCode)
+	assert.True(t, out.ExchangeRate.Equal(decimal.NewFromInt(1)))
+	assert.True(t, out.GrandTotalCOP.Equal(out.GrandTotal), "GrandTotalCOP: %s", out.GrandTotalCOP)
+
+	invalid := map[string]func(r *dto.CreateInvoiceRequest){
+		"UnknownCurrency":    func(r *dto.CreateInvoiceRequest) { r.CurrencyCode = "XYZ"; r.ExchangeRate = decimal.NewFromInt(10) },
+		"ForeignWithoutRate": func(r *dto.CreateInvoiceRequest) { r.CurrencyCode = "EUR" },
+		"COPWithRate":        func(r *dto.CreateInvoiceRequest) { r.ExchangeRate = decimal.NewFromInt(4000) },
+		"ExportWithoutTerm":  func(r *dto.CreateInvoiceRequest) { r.InvoiceTypeCode = "02" },
+		"ExportUnknownTerm":  func(r *dto.CreateInvoiceRequest) { r.InvoiceTypeCode = "02"; r.Incoterm = "XXX" },
+		"IncotermOnDomestic": func(r *dto.CreateInvoiceRequest) { r.Incoterm = "FOB" },
+		"UnknownInvoiceType": func(r *dto.CreateInvoiceRequest) { r.InvoiceTypeCode = "09" },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			r := validCreateInvoiceRequest()
+			mutate(&r)
+			_, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, r)
+			assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		})
+	}
+
+	t.Run("ExportToDomesticCustomer", func(t *testing.T) {
+		customer.CountryCode = "CO"
+		r := validCreateInvoiceRequest()
+		r.InvoiceTypeCode = "02"
+		r.Incoterm = "FOB"
+		_, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, r)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+func TestCreateInvoiceUseCase_PaymentTerms(t *testing.T) {
+	customer := validCustomer(testCompanyID)
+	customer.PaymentTermDays = 30
+	customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return customer, nil }}
+	companyRepo := &fakeCompanyRepo{
+		getByIDFunc:         func(id string) (*entity.Company, error) { return validCompany(id), nil },
+		hasActiveModuleFunc: func(context.Context, string, string) (bool, error) { return false, nil },
+	}
+	productRepo := &fakeProductRepo{getByIDFunc: func(id string) (*entity.Product, error) {
+		if id == testProductID1 {
+			return validProduct(testCompanyID, id, decimal.NewFromInt(10000), decimal.NewFromInt(19)), nil
+		}
+		return validProduct(testCompanyID, id, decimal.NewFromInt(50000), decimal.NewFromInt(5)), nil
+	}}
+	invoiceRepo := &fakeInvoiceRepo{}
+	txRunner := &fakeBillingTxRunner{
+		runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository,
+			repository.StockRepository,
+			repository.ProductRepository,
+			repository.CustomerRepository,
+			repository.InvoiceRepository,
+		) error) error {
+			return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+		},
+	}
+	uc := NewCreateInvoiceUseCase(txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, productRepo, &fakeWarehouseRepo{}, invoiceRepo, nil, DIANConfig{})
+	day := func(days int) string { return time.Now().AddDate(0, 0, days).Format("2006-01-02") }
+
+	// Contado por defecto: efectivo, sin vencimiento.
+	out, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, validCreateInvoiceRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "1", out.PaymentFormCode)
+	assert.Equal(t, []string{"10"}, out.PaymentMethods)
+	assert.Empty(t, out.DueDate)
+
+	// Crédito sin fecha: vence según el plazo del cliente.
+	req := validCreateInvoiceRequest()
+	req.PaymentFormCode = "2"
+	req.PaymentMethodCodes = []string{"47", "47", "48"}
+	out, err = uc.CreateInvoice(context.Background(), testCompanyID, testUserID, req)
+	require.NoError(t, err)
+	assert.Equal(t, "2", out.PaymentFormCode)
+	assert.Equal(t, []string{"47", "48"}, out.PaymentMethods)
+	assert.Equal(t, day(30), out.DueDate)
+
+	// Crédito en cuotas: vence con la última cuota y las cuotas suman el total (76300).
+	req = validCreateInvoiceRequest()
+	req.PaymentFormCode = "2"
+	req.Installments = []dto.InstallmentRequest{
+		{DueDate: day(30), Amount: decimal.NewFromInt(38150)},
+		{DueDate: day(60), Amount: decimal.NewFromInt(38150)},
+	}
+	out, err = uc.CreateInvoice(context.Background(), testCompanyID, testUserID, req)
+	require.NoError(t, err)
+	assert.Equal(t, day(60), out.DueDate)
+	require.Len(t, out.Installments, 2)
+	assert.Equal(t, 2, out.Installments[1].Number)
+	stored, err := invoiceRepo.GetInstallmentsByInvoiceID(out.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+
+	invalid := map[string]func(r *dto.CreateInvoiceRequest){
+		"UnknownForm":      func(r *dto.CreateInvoiceRequest) { r.PaymentFormCode = "3" },
+		"UnknownMethod":    func(r *dto.CreateInvoiceRequest) { r.PaymentMethodCodes = []string{"99"} },
+		"CashWithDueDate":  func(r *dto.CreateInvoiceRequest) { r.DueDate = day(10) },
+		"DueDateInThePast": func(r *dto.CreateInvoiceRequest) { r.PaymentFormCode = "2"; r.DueDate = day(-1) },
+		"MalformedDueDate": func(r *dto.CreateInvoiceRequest) { r.PaymentFormCode = "2"; r.DueDate = "30/12/2026" },
+		"InstallmentsShort": func(r *dto.CreateInvoiceRequest) {
+			r.PaymentFormCode = "2"
+			r.Installments = []dto.InstallmentRequest{{DueDate: day(30), Amount: decimal.NewFromInt(1000)}}
+		},
+		"InstallmentsOutOfOrder": func(r *dto.CreateInvoiceRequest) {
+			r.PaymentFormCode = "2"
+			r.Installments = []dto.InstallmentRequest{
+				{DueDate: day(60), Amount: decimal.NewFromInt(38150)},
+				{DueDate: day(30), Amount: decimal.NewFromInt(38150)},
+			}
+		},
+		"DueDateNotLastInstallment": func(r *dto.CreateInvoiceRequest) {
+			r.PaymentFormCode = "2"
+			r.DueDate = day(90)
+			r.Installments = []dto.InstallmentRequest{{DueDate: day(30), Amount: decimal.NewFromInt(76300)}}
+		},
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			r := validCreateInvoiceRequest()
+			mutate(&r)
+			_, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, r)
+			assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		})
+	}
+
+	t.Run("CreditWithoutTerms", func(t *testing.T) {
+		customer.PaymentTermDays = 0
+		r := validCreateInvoiceRequest()
+		r.PaymentFormCode = "2"
+		_, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, r)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
@@ -2,7 +2,6 @@ package billing
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"time"
 
@@ -75,6 +74,24 @@ func (uc *CreateVoidInvoiceUseCase) VoidInvoice(
 		return nil, domain.ErrInvalidInput
 	}
 
+	// La anulación acredita exactamente los impuestos cobrados en la factura.
+	origTaxes, err := uc.invoiceRepo.GetTaxesByInvoiceID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	taxesByDetail := make(map[string][]*entity.InvoiceTax, len(origDetails))
+	for _, t := range origTaxes {
+		taxesByDetail[t.InvoiceDetailID] = append(taxesByDetail[t.InvoiceDetailID], t)
+	}
+
+	// Los descuentos y cargos de la factura se replican en la nota para que sus totales cuadren.
+	var origAllowanceCharges []*entity.InvoiceAllowanceCharge
+	if origInv.DiscountTotal.IsPositive() || origInv.ChargeTotal.IsPositive() {
+		if origAllowanceCharges, err = uc.invoiceRepo.GetAllowanceChargesByInvoiceID(invoiceID); err != nil {
+			return nil, err
+		}
+	}
+
 	now := time.Now()
 	creditNoteID := uuid.New().String()
 
@@ -82,12 +99,13 @@ func (uc *CreateVoidInvoiceUseCase) VoidInvoice(
 		ID:                     creditNoteID,
 		CompanyID:              companyID,
 		CustomerID:             origInv.CustomerID,
-		Prefix:                 origInv.Prefix,
-		Number:                 fmt.Sprintf("%s-NCV-%d", origInv.Number, now.Unix()),
 		Date:                   now,
 		NetTotal:               origInv.NetTotal,
 		TaxTotal:               origInv.TaxTotal,
 		GrandTotal:             origInv.GrandTotal,
+		DiscountTotal:          origInv.DiscountTotal,
+		AllowanceTotal:         origInv.AllowanceTotal,
+		ChargeTotal:            origInv.ChargeTotal,
 		DIAN_Status:            entity.DIANStatusDraft,
 		DocumentType:           "CREDIT_NOTE",
 		OriginalInvoiceID:      origInv.ID,
@@ -96,21 +114,38 @@ func (uc *CreateVoidInvoiceUseCase) VoidInvoice(
 		OriginalInvoiceIssueOn: origInv.Date,
 		DiscrepancyCode:        mapVoidConceptToCreditNote(in.ConceptCode),
 		DiscrepancyReason:      strings.TrimSpace(in.Reason),
+		CurrencyCode:           origInv.CurrencyCode,
+		ExchangeRate:           origInv.ExchangeRate,
 		CreatedAt:              now,
 		UpdatedAt:              now,
 	}
 
 	creditDetails := make([]*entity.InvoiceDetail, 0, len(origDetails))
+	lineTaxes := make([][]*entity.InvoiceTax, 0, len(origDetails))
+	detailIDs := make(map[string]string, len(origDetails))
 	for _, d := range origDetails {
-		creditDetails = append(creditDetails, &entity.InvoiceDetail{
-			ID:        uuid.New().String(),
-			InvoiceID: creditInv.ID,
-			ProductID: d.ProductID,
-			Quantity:  d.Quantity,
-			UnitPrice: d.UnitPrice,
-			TaxRate:   d.TaxRate,
-			Subtotal:  d.Subtotal,
-		})
+		lineTaxes = append(lineTaxes, noteLineTaxes(taxesByDetail[d.ID], d.TaxRate, d.Quantity, d.Subtotal))
+		detail := &entity.InvoiceDetail{
+			ID:             uuid.New().String(),
+			InvoiceID:      creditInv.ID,
+			ProductID:      d.ProductID,
+			Quantity:       d.Quantity,
+			UnitPrice:      d.UnitPrice,
+			TaxRate:        d.TaxRate,
+			DiscountAmount: d.DiscountAmount,
+			Subtotal:       d.Subtotal,
+		}
+		detailIDs[d.ID] = detail.ID
+		creditDetails = append(creditDetails, detail)
+	}
+	for _, ac := range origAllowanceCharges {
+		copied := *ac
+		copied.ID = ""
+		copied.InvoiceID = creditInv.ID
+		if ac.InvoiceDetailID != "" {
+			copied.InvoiceDetailID = detailIDs[ac.InvoiceDetailID]
+		}
+		creditInv.AllowanceCharges = append(creditInv.AllowanceCharges, &copied)
 	}
 
 	err = uc.txRunner.RunBilling(ctx, func(
@@ -120,6 +155,12 @@ func (uc *CreateVoidInvoiceUseCase) VoidInvoice(
 		_ repository.CustomerRepository,
 		invoiceRepo repository.InvoiceRepository,
 	) error {
+		prefix, number, err := allocateNoteConsecutive(invoiceRepo, companyID, strings.TrimSpace(in.Prefix),
+			entity.ResolutionDocumentCreditNote, now)
+		if err != nil {
+			return err
+		}
+		creditInv.Prefix, creditInv.Number = prefix, number
 		if err := invoiceRepo.Create(creditInv); err != nil {
 			return err
 		}
@@ -128,6 +169,16 @@ func (uc *CreateVoidInvoiceUseCase) VoidInvoice(
 				return err
 			}
 		}
+		if len(origTaxes) > 0 {
+			if err := persistLineTaxes(invoiceRepo, creditDetails, lineTaxes); err != nil {
+				return err
+			}
+		}
+		for _, ac := range creditInv.AllowanceCharges {
+			if err := invoiceRepo.CreateAllowanceCharge(ac); err != nil {
+				return err
+			}
+		}
 		if err := invoiceRepo.UpdateReturnStatus(origInv.ID, "VOID"); err != nil {
 			return err
 		}
@@ -137,7 +188,7 @@ func (uc *CreateVoidInvoiceUseCase) VoidInvoice(
 		return nil, err
 	}
 
-	if uc.dianConfig.TechnicalKey != "" {
+	if uc.dianConfig.TechnicalKey != "" || uc.dianOrchestrator.ResolvesCredentialsPerCompany() {
 		uc.dianOrchestrator.ProcessSync(creditNoteID)
 		latest, getErr := uc.invoiceRepo.GetByID(creditNoteID)
 		if getErr == nil && latest != nil {
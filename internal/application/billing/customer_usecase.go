@@ -1,6 +1,7 @@
 package billing
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,6 +9,7 @@ import (
 	"github.com/jhoicas/Inventario-api/internal/domain"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
 )
 
 // CustomerUseCase casos de uso para clientes (facturación).
@@ -25,32 +27,51 @@ func (uc *CustomerUseCase) Create(companyID string, in dto.CreateCustomerRequest
 	if in.Name == "" || in.TaxID == "" {
 		return nil, domain.ErrInvalidInput
 	}
+	responsibilities, err := normalizeFiscalResponsibilities(in.FiscalResponsibilities)
+	if err != nil {
+		return nil, err
+	}
+	idType, country, err := normalizeCustomerIdentification(in.IdentificationType, in.CountryCode)
+	if err != nil {
+		return nil, err
+	}
+	if in.PaymentTermDays < 0 {
+		return nil, domain.ErrInvalidInput
+	}
 	existing, _ := uc.repo.GetByCompanyAndTaxID(companyID, in.TaxID)
 	if existing != nil {
 		return nil, domain.ErrDuplicate
 	}
 	now := time.Now()
 	customer := &entity.Customer{
-		ID:        uuid.New().String(),
-		CompanyID: companyID,
-		Name:      in.Name,
-		TaxID:     in.TaxID,
-		Email:     in.Email,
-		Phone:     in.Phone,
-		IsActive:  true,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:                     uuid.New().String(),
+		CompanyID:              companyID,
+		Name:                   in.Name,
+		TaxID:                  in.TaxID,
+		Email:                  in.Email,
+		Phone:                  in.Phone,
+		FiscalResponsibilities: responsibilities,
+		IdentificationType:     idType,
+		CountryCode:            country,
+		PaymentTermDays:        in.PaymentTermDays,
+		IsActive:               true,
+		CreatedAt:              now,
+		UpdatedAt:              now,
 	}
 	if err := uc.repo.Create(customer); err != nil {
 		return nil, err
 	}
 	return &dto.CustomerResponse{
-		ID:        customer.ID,
-		CompanyID: customer.CompanyID,
-		Name:      customer.Name,
-		TaxID:     customer.TaxID,
-		Email:     customer.Email,
-		Phone:     customer.Phone,
+		ID:                     customer.ID,
+		CompanyID:              customer.CompanyID,
+		Name:                   customer.Name,
+		TaxID:                  customer.TaxID,
+		Email:                  customer.Email,
+		Phone:                  customer.Phone,
+		FiscalResponsibilities: customer.FiscalResponsibilities,
+		IdentificationType:     customer.IdentificationType,
+		CountryCode:            customer.CountryCode,
+		PaymentTermDays:        customer.PaymentTermDays,
 	}, nil
 }
 
@@ -87,12 +108,16 @@ func (uc *CustomerUseCase) List(companyID string, search string, limit, offset i
 	out := make([]*dto.CustomerResponse, 0, len(list))
 	for _, c := range list {
 		out = append(out, &dto.CustomerResponse{
-			ID:        c.ID,
-			CompanyID: c.CompanyID,
-			Name:      c.Name,
-			TaxID:     c.TaxID,
-			Email:     c.Email,
-			Phone:     c.Phone,
+			ID:                     c.ID,
+			CompanyID:              c.CompanyID,
+			Name:                   c.Name,
+			TaxID:                  c.TaxID,
+			Email:                  c.Email,
+			Phone:                  c.Phone,
+			FiscalResponsibilities: c.FiscalResponsibilities,
+			IdentificationType:     c.IdentificationType,
+			CountryCode:            c.CountryCode,
+			PaymentTermDays:        c.PaymentTermDays,
 		})
 	}
 	return out, nil
@@ -126,16 +151,62 @@ func (uc *CustomerUseCase) Update(companyID, customerID string, in dto.UpdateCus
 	current.TaxID = in.TaxID
 	current.Email = in.Email
 	current.Phone = in.Phone
+	if in.FiscalResponsibilities != nil {
+		responsibilities, err := normalizeFiscalResponsibilities(in.FiscalResponsibilities)
+		if err != nil {
+			return nil, err
+		}
+		current.FiscalResponsibilities = responsibilities
+	}
+	if in.IdentificationType != "" || in.CountryCode != "" {
+		idType, country := in.IdentificationType, in.CountryCode
+		if idType == "" {
+			idType = current.IdentificationType
+		}
+		if country == "" {
+			country = current.CountryCode
+		}
+		if current.IdentificationType, current.CountryCode, err = normalizeCustomerIdentification(idType, country); err != nil {
+			return nil, err
+		}
+	}
+	if in.PaymentTermDays != nil {
+		if *in.PaymentTermDays < 0 {
+			return nil, domain.ErrInvalidInput
+		}
+		current.PaymentTermDays = *in.PaymentTermDays
+	}
 	current.UpdatedAt = time.Now()
 	if err := uc.repo.Update(current); err != nil {
 		return nil, err
 	}
 	return &dto.CustomerResponse{
-		ID:        current.ID,
-		CompanyID: current.CompanyID,
-		Name:      current.Name,
-		TaxID:     current.TaxID,
-		Email:     current.Email,
-		Phone:     current.Phone,
+		ID:                     current.ID,
+		CompanyID:              current.CompanyID,
+		Name:                   current.Name,
+		TaxID:                  current.TaxID,
+		Email:                  current.Email,
+		Phone:                  current.Phone,
+		FiscalResponsibilities: current.FiscalResponsibilities,
+		IdentificationType:     current.IdentificationType,
+		CountryCode:            current.CountryCode,
+		PaymentTermDays:        current.PaymentTermDays,
 	}, nil
 }
+
+// normalizeCustomerIdentification valida el tipo de identificación (Tabla 3 DIAN; vacío = se infiere
+// del documento) y el país (ISO 3166 alfa-2; vacío = CO).
+func normalizeCustomerIdentification(idType, country string) (string, string, error) {
+	idType = strings.TrimSpace(idType)
+	if idType != "" && !dian.ValidIdentificationTypes[idType] {
+		return "", "", domain.ErrInvalidInput
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country == "" {
+		country = "CO"
+	}
+	if len(country) != 2 {
+		return "", "", domain.ErrInvalidInput
+	}
+	return idType, country, nil
+}
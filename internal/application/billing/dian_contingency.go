@@ -0,0 +1,234 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+// DIANContingencyUseCase gestiona la facturación en contingencia (tipo 03) cuando la DIAN no está
+// disponible por un periodo prolongado. A diferencia del estado CONTINGENCIA (timeout de un envío que
+// se reintenta solo), la empresa entra en contingencia de forma explícita: sus facturas se emiten con
+// la numeración de contingencia, se firman y se entregan con su representación gráfica, y se
+// transmiten en bloque al terminar la contingencia, con la conciliación de lo aceptado y lo pendiente.
+type DIANContingencyUseCase struct {
+	repo           DIANContingencyRepository
+	resolutionRepo repository.BillingResolutionRepository
+	orchestrator   *DIANOrchestrator
+	now            func() time.Time
+}
+
+// NewDIANContingencyUseCase construye el caso de uso.
+func NewDIANContingencyUseCase(
+	repo DIANContingencyRepository,
+	resolutionRepo repository.BillingResolutionRepository,
+	orchestrator *DIANOrchestrator,
+) *DIANContingencyUseCase {
+	return &DIANContingencyUseCase{
+		repo:           repo,
+		resolutionRepo: resolutionRepo,
+		orchestrator:   orchestrator,
+		now:            time.Now,
+	}
+}
+
+// Start pone la empresa en contingencia con el prefijo de su resolución de contingencia, que debe estar
+// activa y vigente. domain.ErrConflict si ya está en contingencia.
+func (uc *DIANContingencyUseCase) Start(ctx context.Context, companyID, userID string, in dto.StartDIANContingencyRequest) (*dto.DIANContingencyDTO, error) {
+	prefix := strings.TrimSpace(in.Prefix)
+	reason := strings.TrimSpace(in.Reason)
+	if prefix == "" || reason == "" {
+		return nil, fmt.Errorf("%w: prefix y reason son requeridos", domain.ErrInvalidInput)
+	}
+	now := uc.now()
+	res, err := uc.resolutionRepo.GetActiveByCompanyAndPrefix(ctx, companyID, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || !res.CoversDate(now) {
+		return nil, fmt.Errorf("prefijo de contingencia %q: %w", prefix, domain.ErrNoActiveResolution)
+	}
+
+	c := &entity.DIANContingency{
+		ID:        uuid.New().String(),
+		CompanyID: companyID,
+		Prefix:    prefix,
+		Reason:    reason,
+		StartedAt: now,
+		StartedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := uc.repo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	log.Printf("[DIAN][CONTINGENCIA][%s] empresa %s en contingencia (prefijo %s): %s", c.ID, companyID, prefix, reason)
+	out := toDIANContingencyDTO(c)
+	return &out, nil
+}
+
+// End termina la contingencia activa de la empresa y encola la transmisión de sus facturas.
+// domain.ErrConflict si la empresa no está en contingencia.
+func (uc *DIANContingencyUseCase) End(ctx context.Context, companyID, userID string) (*dto.DIANContingencyReconciliationDTO, error) {
+	c, err := uc.repo.GetActive(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%w: la empresa no está en contingencia", domain.ErrConflict)
+	}
+	now := uc.now()
+	c.EndedAt, c.EndedBy, c.UpdatedAt = &now, userID, now
+	if err := uc.repo.End(ctx, c); err != nil {
+		return nil, err
+	}
+	log.Printf("[DIAN][CONTINGENCIA][%s] contingencia terminada: inicia la transmisión", c.ID)
+	return uc.transmit(ctx, c)
+}
+
+// List devuelve las contingencias de la empresa, de la más reciente a la más antigua.
+func (uc *DIANContingencyUseCase) List(ctx context.Context, companyID string) ([]dto.DIANContingencyDTO, error) {
+	list, err := uc.repo.List(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.DIANContingencyDTO, 0, len(list))
+	for _, c := range list {
+		out = append(out, toDIANContingencyDTO(c))
+	}
+	return out, nil
+}
+
+// Transmit vuelve a encolar las facturas de una contingencia terminada que siguen sin transmitir (cola
+// llena, reinicio del proceso o credenciales corregidas). domain.ErrConflict si sigue activa.
+func (uc *DIANContingencyUseCase) Transmit(ctx context.Context, companyID, id string) (*dto.DIANContingencyReconciliationDTO, error) {
+	c, err := uc.get(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsActive() {
+		return nil, fmt.Errorf("%w: termine la contingencia antes de transmitir", domain.ErrConflict)
+	}
+	return uc.transmit(ctx, c)
+}
+
+// Reconcile concilia las facturas emitidas en la contingencia con su resultado en la DIAN.
+func (uc *DIANContingencyUseCase) Reconcile(ctx context.Context, companyID, id string) (*dto.DIANContingencyReconciliationDTO, error) {
+	c, err := uc.get(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+	invoices, err := uc.repo.ListInvoices(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.reconciliation(c, invoices), nil
+}
+
+func (uc *DIANContingencyUseCase) get(ctx context.Context, companyID, id string) (*entity.DIANContingency, error) {
+	c, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.CompanyID != companyID {
+		return nil, domain.ErrNotFound
+	}
+	return c, nil
+}
+
+// transmit encola en el orquestador las facturas pendientes (firmadas o aún en DRAFT) de la contingencia
+// terminada; si la cola se llena, el resto queda pendiente para el siguiente Transmit.
+func (uc *DIANContingencyUseCase) transmit(ctx context.Context, c *entity.DIANContingency) (*dto.DIANContingencyReconciliationDTO, error) {
+	invoices, err := uc.repo.ListInvoices(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	queued := 0
+	for _, inv := range invoices {
+		if !contingencyPending(inv.DIAN_Status) {
+			continue
+		}
+		if !uc.orchestrator.Enqueue(inv.ID) {
+			log.Printf("[DIAN][CONTINGENCIA][%s] cola llena: %d factura(s) encolada(s), el resto queda pendiente", c.ID, queued)
+			break
+		}
+		queued++
+	}
+	if queued > 0 {
+		now := uc.now()
+		if err := uc.repo.MarkTransmitted(ctx, c.ID, now); err != nil {
+			return nil, err
+		}
+		c.TransmittedAt = &now
+		log.Printf("[DIAN][CONTINGENCIA][%s] %d factura(s) encolada(s) para transmisión", c.ID, queued)
+	}
+	out := uc.reconciliation(c, invoices)
+	out.Queued = queued
+	return out, nil
+}
+
+// contingencyPending indica si la factura de contingencia aún no se ha transmitido.
+func contingencyPending(status string) bool {
+	return status == entity.DIANStatusDraft || status == entity.DIANStatusSigned
+}
+
+func (uc *DIANContingencyUseCase) reconciliation(c *entity.DIANContingency, invoices []*entity.Invoice) *dto.DIANContingencyReconciliationDTO {
+	out := &dto.DIANContingencyReconciliationDTO{
+		Contingency: toDIANContingencyDTO(c),
+		Total:       len(invoices),
+		GrandTotal:  decimal.Zero,
+		Documents:   make([]dto.DIANContingencyDocumentDTO, 0, len(invoices)),
+	}
+	for _, inv := range invoices {
+		switch {
+		case contingencyPending(inv.DIAN_Status):
+			out.Pending++
+		case inv.DIAN_Status == entity.DIANStatusSent || inv.DIAN_Status == entity.DIANStatusContingencia:
+			out.Sent++
+		case inv.DIAN_Status == entity.DIANStatusExitoso:
+			out.Accepted++
+		case inv.DIAN_Status == entity.DIANStatusRechazado:
+			out.Rejected++
+		default:
+			out.Failed++
+		}
+		out.GrandTotal = out.GrandTotal.Add(inv.GrandTotal)
+		out.Documents = append(out.Documents, dto.DIANContingencyDocumentDTO{
+			InvoiceID:  inv.ID,
+			Number:     inv.Prefix + inv.Number,
+			Date:       inv.Date.Format("2006-01-02"),
+			GrandTotal: inv.GrandTotal,
+			DIANStatus: inv.DIAN_Status,
+			CUFE:       inv.CUFE,
+			TrackID:    inv.TrackID,
+			Errors:     inv.DIANErrors,
+		})
+	}
+	if deadline := c.TransmissionDeadline(); deadline != nil && out.Pending > 0 {
+		out.Overdue = uc.now().After(*deadline)
+	}
+	return out
+}
+
+func toDIANContingencyDTO(c *entity.DIANContingency) dto.DIANContingencyDTO {
+	return dto.DIANContingencyDTO{
+		ID:                   c.ID,
+		Prefix:               c.Prefix,
+		Reason:               c.Reason,
+		Active:               c.IsActive(),
+		StartedAt:            c.StartedAt,
+		EndedAt:              c.EndedAt,
+		TransmissionDeadline: c.TransmissionDeadline(),
+		TransmittedAt:        c.TransmittedAt,
+	}
+}
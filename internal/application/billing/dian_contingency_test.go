@@ -0,0 +1,269 @@
+package billing
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+type fakeContingencyRepo struct {
+	items    map[string]*entity.DIANContingency
+	invoices map[string]*entity.Invoice
+}
+
+func (f *fakeContingencyRepo) Create(_ context.Context, c *entity.DIANContingency) error {
+	for _, x := range f.items {
+		if x.CompanyID == c.CompanyID && x.IsActive() {
+			return domain.ErrConflict
+		}
+	}
+	f.items[c.ID] = c
+	return nil
+}
+func (f *fakeContingencyRepo) GetActive(_ context.Context, companyID string) (*entity.DIANContingency, error) {
+	for _, x := range f.items {
+		if x.CompanyID == companyID && x.IsActive() {
+			return x, nil
+		}
+	}
+	return nil, nil
+}
+func (f *fakeContingencyRepo) GetByID(_ context.Context, id string) (*entity.DIANContingency, error) {
+	return f.items[id], nil
+}
+func (f *fakeContingencyRepo) List(_ context.Context, companyID string) ([]*entity.DIANContingency, error) {
+	out := []*entity.DIANContingency{}
+	for _, x := range f.items {
+		if x.CompanyID == companyID {
+			out = append(out, x)
+		}
+	}
+	return out, nil
+}
+func (f *fakeContingencyRepo) End(_ context.Context, c *entity.DIANContingency) error {
+	f.items[c.ID] = c
+	return nil
+}
+func (f *fakeContingencyRepo) MarkTransmitted(_ context.Context, id string, at time.Time) error {
+	f.items[id].TransmittedAt = &at
+	return nil
+}
+func (f *fakeContingencyRepo) ListInvoices(_ context.Context, contingencyID string) ([]*entity.Invoice, error) {
+	out := []*entity.Invoice{}
+	for _, inv := range f.invoices {
+		if inv.ContingencyID == contingencyID {
+			out = append(out, inv)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Number < out[j].Number })
+	return out, nil
+}
+
+var _ DIANContingencyRepository = (*fakeContingencyRepo)(nil)
+
+func TestDIANContingencyUseCase(t *testing.T) {
+	ctx := context.Background()
+	company := validCompany(testCompanyID)
+	product := validProduct(testCompanyID, testProductID1, decimal.NewFromInt(10000), decimal.NewFromInt(19))
+
+	type fixture struct {
+		uc       *DIANContingencyUseCase
+		invoices *CreateInvoiceUseCase
+		orch     *DIANOrchestrator
+		repo     *fakeContingencyRepo
+		store    map[string]*entity.Invoice
+	}
+	newFixture := func() *fixture {
+		f := &fixture{store: map[string]*entity.Invoice{}}
+		f.repo = &fakeContingencyRepo{items: map[string]*entity.DIANContingency{}, invoices: f.store}
+		details := map[string][]*entity.InvoiceDetail{}
+		customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) {
+			return validCustomer(testCompanyID), nil
+		}}
+		companyRepo := &fakeCompanyRepo{getByIDFunc: func(string) (*entity.Company, error) { return company, nil }}
+		productRepo := &fakeProductRepo{getByIDFunc: func(string) (*entity.Product, error) { return product, nil }}
+		invoiceRepo := &fakeInvoiceRepo{
+			resolution: validResolution(testCompanyID, "CT"),
+			createFunc: func(inv *entity.Invoice) error { f.store[inv.ID] = inv; return nil },
+			createDetailFunc: func(d *entity.InvoiceDetail) error {
+				details[d.InvoiceID] = append(details[d.InvoiceID], d)
+				return nil
+			},
+			getByIDFunc: func(id string) (*entity.Invoice, error) { return f.store[id], nil },
+			getDetailsByInvoiceIDFunc: func(id string) ([]*entity.InvoiceDetail, error) {
+				return details[id], nil
+			},
+			updateFunc: func(inv *entity.Invoice) error { f.store[inv.ID] = inv; return nil },
+		}
+		txRunner := &fakeBillingTxRunner{runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository, repository.StockRepository, repository.ProductRepository,
+			repository.CustomerRepository, repository.InvoiceRepository,
+		) error) error {
+			return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+		}}
+
+		resolutions := &fakeResolutionRepo{res: validResolution(testCompanyID, "CT")}
+		f.orch = NewDIANOrchestrator(invoiceRepo, companyRepo, customerRepo, productRepo,
+			resolutions, infradian.NewXMLBuilderService(), fakeSigner{}, nil, DIANConfig{})
+		f.orch.SetCredentialsProvider(&fakeCredentials{creds: &DIANCredentials{
+			AppEnv: "dev", TipoAmbiente: "2", TechnicalKey: "tk-1", SoftwareID: "sw-1", SoftwarePIN: "12345",
+		}})
+		f.orch.SetContingencyRepository(f.repo)
+		// Cola sin workers: el test decide cuándo procesar.
+		f.orch.queue = make(chan string, 10)
+
+		// La emisión usa un orquestador sin credenciales para no lanzar el procesamiento asíncrono.
+		f.invoices = NewCreateInvoiceUseCase(txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, productRepo,
+			&fakeWarehouseRepo{}, invoiceRepo, NewDIANOrchestrator(invoiceRepo, companyRepo, customerRepo, productRepo,
+				resolutions, infradian.NewXMLBuilderService(), fakeSigner{}, nil, DIANConfig{}), DIANConfig{})
+		f.invoices.SetContingencyRepository(f.repo)
+		f.uc = NewDIANContingencyUseCase(f.repo, resolutions, f.orch)
+		return f
+	}
+	sale := func() dto.CreateInvoiceRequest {
+		return dto.CreateInvoiceRequest{
+			CustomerID: testCustomerID,
+			Prefix:     "FV",
+			Items:      []dto.InvoiceItemRequest{{ProductID: testProductID1, Quantity: decimal.NewFromInt(1)}},
+		}
+	}
+
+	t.Run("ciclo completo: emisión tipo 03, retención en SIGNED, transmisión y conciliación", func(t *testing.T) {
+		f := newFixture()
+		started, err := f.uc.Start(ctx, testCompanyID, testUserID, dto.StartDIANContingencyRequest{Prefix: "CT", Reason: "caída del servicio DIAN"})
+		require.NoError(t, err)
+		assert.True(t, started.Active)
+		assert.Nil(t, started.TransmissionDeadline)
+
+		_, err = f.uc.Start(ctx, testCompanyID, testUserID, dto.StartDIANContingencyRequest{Prefix: "CT", Reason: "otra"})
+		assert.ErrorIs(t, err, domain.ErrConflict, "una sola contingencia activa por empresa")
+
+		out, err := f.invoices.CreateInvoice(ctx, testCompanyID, testUserID, sale())
+		require.NoError(t, err)
+		assert.Equal(t, "CT", out.Prefix, "la contingencia impone su prefijo")
+		assert.Equal(t, started.ID, out.ContingencyID)
+		inv := f.store[out.ID]
+		assert.Equal(t, dian.InvoiceTypeContingencia, inv.InvoiceTypeCode)
+
+		export := sale()
+		export.InvoiceTypeCode = dian.InvoiceTypeExportacion
+		_, err = f.invoices.CreateInvoice(ctx, testCompanyID, testUserID, export)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+		// Durante la contingencia se firma (representación gráfica disponible) pero no se envía.
+		f.orch.process(out.ID, false)
+		inv = f.store[out.ID]
+		assert.Equal(t, entity.DIANStatusSigned, inv.DIAN_Status)
+		assert.Len(t, inv.CUFE, 96)
+		assert.Contains(t, inv.XMLSigned, ">03</InvoiceTypeCode>")
+		signed := inv.XMLSigned
+
+		rec, err := f.uc.End(ctx, testCompanyID, testUserID)
+		require.NoError(t, err)
+		assert.False(t, rec.Contingency.Active)
+		require.NotNil(t, rec.Contingency.TransmissionDeadline)
+		assert.Equal(t, 1, rec.Total)
+		assert.Equal(t, 1, rec.Pending)
+		assert.Equal(t, 1, rec.Queued)
+		assert.NotNil(t, rec.Contingency.TransmittedAt)
+
+		_, err = f.uc.End(ctx, testCompanyID, testUserID)
+		assert.ErrorIs(t, err, domain.ErrConflict, "ya no está en contingencia")
+
+		require.Len(t, f.orch.queue, 1)
+		f.orch.process(<-f.orch.queue, false)
+		inv = f.store[out.ID]
+		assert.Equal(t, entity.DIANStatusExitoso, inv.DIAN_Status)
+		assert.Equal(t, signed, inv.XMLSigned, "se transmite el mismo XML entregado al adquiriente")
+
+		rec, err = f.uc.Reconcile(ctx, testCompanyID, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, rec.Accepted)
+		assert.Zero(t, rec.Pending)
+		assert.False(t, rec.Overdue)
+		assert.True(t, rec.GrandTotal.Equal(inv.GrandTotal))
+
+		// Terminada la contingencia la facturación vuelve a la numeración normal.
+		out, err = f.invoices.CreateInvoice(ctx, testCompanyID, testUserID, sale())
+		require.NoError(t, err)
+		assert.Equal(t, "FV", out.Prefix)
+		assert.Empty(t, out.ContingencyID)
+	})
+
+	t.Run("reintento tras timeout reenvía el XML firmado sin reconstruirlo", func(t *testing.T) {
+		f := newFixture()
+		_, err := f.uc.Start(ctx, testCompanyID, testUserID, dto.StartDIANContingencyRequest{Prefix: "CT", Reason: "caída"})
+		require.NoError(t, err)
+		out, err := f.invoices.CreateInvoice(ctx, testCompanyID, testUserID, sale())
+		require.NoError(t, err)
+		f.orch.process(out.ID, false)
+		_, err = f.uc.End(ctx, testCompanyID, testUserID)
+		require.NoError(t, err)
+
+		// Timeout del WS al transmitir: la factura queda en CONTINGENCIA para el reintento.
+		inv := f.store[out.ID]
+		delivered := inv.XMLSigned + "<!-- entregado al adquiriente -->"
+		inv.XMLSigned = delivered
+		inv.DIAN_Status = entity.DIANStatusContingencia
+
+		f.orch.process(out.ID, true)
+		inv = f.store[out.ID]
+		assert.Equal(t, entity.DIANStatusExitoso, inv.DIAN_Status)
+		assert.Equal(t, delivered, inv.XMLSigned, "el reintento no vuelve a firmar")
+	})
+
+	t.Run("validaciones, plazo vencido y aislamiento por empresa", func(t *testing.T) {
+		f := newFixture()
+		_, err := f.uc.Start(ctx, testCompanyID, testUserID, dto.StartDIANContingencyRequest{Prefix: "CT"})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		_, err = f.uc.Start(ctx, testCompanyID, testUserID, dto.StartDIANContingencyRequest{Prefix: "ZZ", Reason: "caída"})
+		assert.ErrorIs(t, err, domain.ErrNoActiveResolution)
+
+		started, err := f.uc.Start(ctx, testCompanyID, testUserID, dto.StartDIANContingencyRequest{Prefix: "CT", Reason: "caída"})
+		require.NoError(t, err)
+		_, err = f.uc.Transmit(ctx, testCompanyID, started.ID)
+		assert.ErrorIs(t, err, domain.ErrConflict, "no se transmite con la contingencia activa")
+		_, err = f.uc.Reconcile(ctx, "otra-empresa", started.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		// Talonario de contingencia: el número explícito debe ser el siguiente de la resolución.
+		manual := sale()
+		manual.Number = "1005"
+		_, err = f.invoices.CreateInvoice(ctx, testCompanyID, testUserID, manual)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		manual.Number = "1001"
+		out, err := f.invoices.CreateInvoice(ctx, testCompanyID, testUserID, manual)
+		require.NoError(t, err)
+		assert.Equal(t, "1001", out.Number)
+		assert.Equal(t, dian.InvoiceTypeContingencia, out.InvoiceTypeCode)
+		// Cola llena: la factura queda pendiente para un Transmit posterior.
+		f.orch.queue = make(chan string)
+		rec, err := f.uc.End(ctx, testCompanyID, testUserID)
+		require.NoError(t, err)
+		assert.Zero(t, rec.Queued)
+		assert.Nil(t, rec.Contingency.TransmittedAt)
+
+		f.uc.now = func() time.Time { return time.Now().Add(entity.DIANContingencyTransmissionWindow + time.Hour) }
+		rec, err = f.uc.Reconcile(ctx, testCompanyID, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, rec.Pending)
+		assert.True(t, rec.Overdue, "venció el plazo con facturas sin transmitir")
+
+		f.orch.queue = make(chan string, 10)
+		rec, err = f.uc.Transmit(ctx, testCompanyID, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, rec.Queued)
+	})
+}
@@ -0,0 +1,259 @@
+package billing
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+)
+
+// ErrDIANCredentials la empresa no tiene credenciales DIAN utilizables (configuración incompleta o inválida).
+var ErrDIANCredentials = errors.New("credenciales DIAN")
+
+// defaultDIANCredentialsTTL vigencia de las credenciales cacheadas (certificado ya cargado, secretos descifrados).
+const defaultDIANCredentialsTTL = 10 * time.Minute
+
+// DIANCredentials credenciales con las que se genera, firma y envía un documento de una empresa.
+type DIANCredentials struct {
+	Environment  string // "test" | "prod" (ambiente de dian_settings)
+	AppEnv       string // "dev" | "test" | "prod" — destino del envío SOAP
+	TipoAmbiente string // "1" producción / "2" pruebas — TipoAmb del CUFE y del QR
+	TechnicalKey string
+	SoftwareID   string
+	SoftwarePIN  string
+	Certificate  tls.Certificate
+}
+
+// SecretDecryptor descifra los secretos guardados en dian_settings (contraseña del certificado y PIN).
+type SecretDecryptor interface {
+	Decrypt(ciphertext string) (string, error)
+}
+
+// DIANCredentialResolver resuelve las credenciales DIAN de cada empresa en el momento de procesar
+// el documento: certificado y contraseña, clave técnica, software y ambiente salen de dian_settings
+// según el ambiente de la empresa. Una empresa sin configuración propia o sin clave técnica queda en
+// error: nunca firma con las credenciales de otra. Solo con SingleTenant (instalaciones de una sola
+// empresa) se usa la configuración global (DIAN_CERT_PATH, DIAN_TECHNICAL_KEY…). El resultado se
+// cachea por empresa durante ttl.
+type DIANCredentialResolver struct {
+	settingsRepo repository.DIANSettingsRepository
+	decryptor    SecretDecryptor
+	fallback     DIANConfig
+	ttl          time.Duration
+	loadCert     func(DIANConfig) (tls.Certificate, error)
+	now          func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedDIANCredentials
+}
+
+type cachedDIANCredentials struct {
+	creds     *DIANCredentials
+	expiresAt time.Time
+}
+
+// NewDIANCredentialResolver construye el resolvedor. ttl <= 0 usa 10 minutos.
+func NewDIANCredentialResolver(settingsRepo repository.DIANSettingsRepository, decryptor SecretDecryptor, fallback DIANConfig, ttl time.Duration) *DIANCredentialResolver {
+	if ttl <= 0 {
+		ttl = defaultDIANCredentialsTTL
+	}
+	return &DIANCredentialResolver{
+		settingsRepo: settingsRepo,
+		decryptor:    decryptor,
+		fallback:     fallback,
+		ttl:          ttl,
+		loadCert:     loadCertificate,
+		now:          time.Now,
+		cache:        make(map[string]cachedDIANCredentials),
+	}
+}
+
+// SingleTenantDefault decide el modo de una sola empresa cuando DIAN_SINGLE_TENANT no está definido: si
+// hay certificado y clave técnica globales (DIAN_CERT_PATH, DIAN_TECHNICAL_KEY) y ninguna empresa tiene
+// dian_settings, la instalación es anterior a la configuración por empresa y sigue firmando con ellos.
+func SingleTenantDefault(ctx context.Context, settings DIANSettingsPresence, cfg DIANConfig) (bool, error) {
+	if cfg.CertPath == "" || cfg.TechnicalKey == "" {
+		return false, nil
+	}
+	configured, err := settings.HasAny(ctx)
+	if err != nil {
+		return false, err
+	}
+	return !configured, nil
+}
+
+// Invalidate descarta las credenciales cacheadas de la empresa (p. ej. tras cargar un certificado nuevo).
+func (r *DIANCredentialResolver) Invalidate(companyID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, companyID)
+}
+
+// Resolve devuelve las credenciales de la empresa para su ambiente activo. Los errores envuelven
+// ErrDIANCredentials e indican qué falta configurar.
+func (r *DIANCredentialResolver) Resolve(ctx context.Context, company *entity.Company) (*DIANCredentials, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[company.ID]
+	r.mu.Unlock()
+	if ok && r.now().Before(cached.expiresAt) {
+		return cached.creds, nil
+	}
+
+	creds, err := r.load(ctx, company)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.cache[company.ID] = cachedDIANCredentials{creds: creds, expiresAt: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+	return creds, nil
+}
+
+func (r *DIANCredentialResolver) load(ctx context.Context, company *entity.Company) (*DIANCredentials, error) {
+	env := companyDIANEnvironment(company)
+	var (
+		settings *entity.DIANSettings
+		err      error
+	)
+	if env != "" {
+		settings, err = r.settingsRepo.GetByCompanyIDAndEnvironment(ctx, company.ID, env)
+	} else {
+		settings, err = r.settingsRepo.GetByCompanyID(ctx, company.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("consultando configuración DIAN de la empresa %s: %w", company.ID, err)
+	}
+	if settings == nil {
+		if r.fallback.SingleTenant && r.fallback.CertPath != "" {
+			return r.fromFallback()
+		}
+		if env == "" {
+			env = "test"
+		}
+		return nil, fmt.Errorf("%w: la empresa %s no tiene certificado configurado para el ambiente %s (PUT /api/settings/dian)",
+			ErrDIANCredentials, company.ID, env)
+	}
+
+	creds := &DIANCredentials{
+		Environment:  settings.Environment,
+		AppEnv:       r.appEnv(settings.Environment),
+		TipoAmbiente: tipoAmbiente(settings.Environment),
+		TechnicalKey: settings.TechnicalKey,
+		SoftwareID:   settings.SoftwareID,
+	}
+	if creds.TechnicalKey == "" && r.fallback.SingleTenant {
+		creds.TechnicalKey = r.fallback.TechnicalKey
+	}
+	if creds.TechnicalKey == "" {
+		return nil, fmt.Errorf("%w: la empresa %s no tiene clave técnica configurada para el ambiente %s",
+			ErrDIANCredentials, company.ID, settings.Environment)
+	}
+	if settings.SoftwarePINEncrypted != "" {
+		if creds.SoftwarePIN, err = r.decryptor.Decrypt(settings.SoftwarePINEncrypted); err != nil {
+			return nil, fmt.Errorf("%w: no se pudo descifrar el PIN del software de la empresa %s: %v",
+				ErrDIANCredentials, company.ID, err)
+		}
+	}
+	password, err := r.decryptor.Decrypt(settings.CertificatePasswordEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("%w: no se pudo descifrar la contraseña del certificado de la empresa %s: %v",
+			ErrDIANCredentials, company.ID, err)
+	}
+	creds.Certificate, err = r.loadCert(DIANConfig{CertPath: settings.CertificatePath, CertPassword: password})
+	if err != nil {
+		return nil, fmt.Errorf("%w: no se pudo cargar el certificado %s de la empresa %s (¿contraseña incorrecta?): %v",
+			ErrDIANCredentials, settings.CertificateFileName, company.ID, err)
+	}
+	if err := r.checkCertificate(creds.Certificate); err != nil {
+		return nil, fmt.Errorf("%w: certificado %s de la empresa %s: %v",
+			ErrDIANCredentials, settings.CertificateFileName, company.ID, err)
+	}
+	return creds, nil
+}
+
+// fromFallback credenciales globales (DIAN_SINGLE_TENANT: una sola empresa configurada por variables de entorno).
+func (r *DIANCredentialResolver) fromFallback() (*DIANCredentials, error) {
+	if r.fallback.TechnicalKey == "" {
+		return nil, fmt.Errorf("%w: DIAN_TECHNICAL_KEY no configurada", ErrDIANCredentials)
+	}
+	cert, err := r.loadCert(r.fallback)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDIANCredentials, err)
+	}
+	if err := r.checkCertificate(cert); err != nil {
+		return nil, fmt.Errorf("%w: %v: verifica DIAN_CERT_PATH y DIAN_CERT_PASSWORD", ErrDIANCredentials, err)
+	}
+	tipoAmb := r.fallback.Environment
+	if tipoAmb == "" {
+		tipoAmb = "2"
+	}
+	env := "test"
+	if tipoAmb == "1" {
+		env = "prod"
+	}
+	return &DIANCredentials{
+		Environment:  env,
+		AppEnv:       strings.ToLower(strings.TrimSpace(r.fallback.AppEnv)),
+		TipoAmbiente: tipoAmb,
+		TechnicalKey: r.fallback.TechnicalKey,
+		Certificate:  cert,
+	}, nil
+}
+
+// appEnv el modo dev global (sin envío) prima; en otro caso se envía al ambiente de la empresa.
+func (r *DIANCredentialResolver) appEnv(environment string) string {
+	global := strings.ToLower(strings.TrimSpace(r.fallback.AppEnv))
+	if global == infradian.AppEnvDev || global == "" {
+		return infradian.AppEnvDev
+	}
+	if environment == "prod" {
+		return infradian.AppEnvProd
+	}
+	return infradian.AppEnvTest
+}
+
+// checkCertificate exige clave privada y un certificado vigente.
+func (r *DIANCredentialResolver) checkCertificate(cert tls.Certificate) error {
+	if len(cert.Certificate) == 0 || cert.PrivateKey == nil {
+		return errors.New("certificado vacío o sin clave privada")
+	}
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("certificado ilegible: %v", err)
+		}
+	}
+	if now := r.now(); now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificado vencido el %s", leaf.NotAfter.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// companyDIANEnvironment traduce el ambiente de la empresa ("habilitacion"|"produccion") al de
+// dian_settings ("test"|"prod"); vacío = la configuración más reciente.
+func companyDIANEnvironment(company *entity.Company) string {
+	switch strings.ToLower(strings.TrimSpace(company.Environment)) {
+	case "produccion", "prod", "production":
+		return "prod"
+	case "habilitacion", "hab", "test", "testing":
+		return "test"
+	default:
+		return ""
+	}
+}
+
+func tipoAmbiente(environment string) string {
+	if environment == "prod" {
+		return "1"
+	}
+	return "2"
+}
@@ -0,0 +1,195 @@
+package billing
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+type fakeDIANSettingsRepo struct {
+	byEnv map[string]*entity.DIANSettings
+	reads int
+}
+
+func (f *fakeDIANSettingsRepo) Upsert(context.Context, *entity.DIANSettings) error { return nil }
+func (f *fakeDIANSettingsRepo) GetByCompanyID(_ context.Context, _ string) (*entity.DIANSettings, error) {
+	f.reads++
+	for _, s := range f.byEnv {
+		return s, nil
+	}
+	return nil, nil
+}
+func (f *fakeDIANSettingsRepo) GetByCompanyIDAndEnvironment(_ context.Context, _ string, env string) (*entity.DIANSettings, error) {
+	f.reads++
+	return f.byEnv[env], nil
+}
+func (f *fakeDIANSettingsRepo) HasAny(context.Context) (bool, error) { return len(f.byEnv) > 0, nil }
+
+type fakeDecryptor struct{}
+
+func (fakeDecryptor) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, "enc:") {
+		return "", errors.New("cipher: message authentication failed")
+	}
+	return strings.TrimPrefix(ciphertext, "enc:"), nil
+}
+
+func testCertificate(t *testing.T, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Empresa de prueba"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestDIANCredentialResolver_Resolve(t *testing.T) {
+	validCert := testCertificate(t, time.Now().AddDate(1, 0, 0))
+	prodSettings := &entity.DIANSettings{
+		CompanyID: testCompanyID, Environment: "prod",
+		CertificatePath: "/certs/prod.p12", CertificateFileName: "prod.p12", CertificatePasswordEncrypted: "enc:clave",
+		TechnicalKey: "tk-prod", SoftwareID: "sw-1", SoftwarePINEncrypted: "enc:12345",
+	}
+	company := &entity.Company{ID: testCompanyID, Environment: "produccion"}
+
+	t.Run("ambiente de la empresa con caché e invalidación", func(t *testing.T) {
+		repo := &fakeDIANSettingsRepo{byEnv: map[string]*entity.DIANSettings{"prod": prodSettings}}
+		r := NewDIANCredentialResolver(repo, fakeDecryptor{}, DIANConfig{AppEnv: "test"}, time.Hour)
+		var loadedWith DIANConfig
+		r.loadCert = func(cfg DIANConfig) (tls.Certificate, error) { loadedWith = cfg; return validCert, nil }
+
+		creds, err := r.Resolve(context.Background(), company)
+		require.NoError(t, err)
+		assert.Equal(t, "prod", creds.AppEnv)
+		assert.Equal(t, "1", creds.TipoAmbiente)
+		assert.Equal(t, "tk-prod", creds.TechnicalKey)
+		assert.Equal(t, "12345", creds.SoftwarePIN)
+		assert.Equal(t, DIANConfig{CertPath: "/certs/prod.p12", CertPassword: "clave"}, loadedWith)
+
+		_, err = r.Resolve(context.Background(), company)
+		require.NoError(t, err)
+		assert.Equal(t, 1, repo.reads, "la segunda resolución sale de la caché")
+
+		r.Invalidate(testCompanyID)
+		_, err = r.Resolve(context.Background(), company)
+		require.NoError(t, err)
+		assert.Equal(t, 2, repo.reads)
+	})
+
+	t.Run("modo dev global no envía", func(t *testing.T) {
+		repo := &fakeDIANSettingsRepo{byEnv: map[string]*entity.DIANSettings{"prod": prodSettings}}
+		r := NewDIANCredentialResolver(repo, fakeDecryptor{}, DIANConfig{AppEnv: "dev"}, 0)
+		r.loadCert = func(DIANConfig) (tls.Certificate, error) { return validCert, nil }
+		creds, err := r.Resolve(context.Background(), company)
+		require.NoError(t, err)
+		assert.Equal(t, "dev", creds.AppEnv)
+		assert.Equal(t, "1", creds.TipoAmbiente)
+	})
+
+	misconfigured := []struct {
+		name     string
+		settings *entity.DIANSettings
+		cert     tls.Certificate
+		certErr  error
+		want     string
+	}{
+		{name: "sin configuración", want: "no tiene certificado configurado para el ambiente prod"},
+		{name: "sin clave técnica", settings: &entity.DIANSettings{Environment: "prod", CertificatePasswordEncrypted: "enc:x"}, cert: validCert, want: "no tiene clave técnica"},
+		{name: "contraseña ilegible", settings: &entity.DIANSettings{Environment: "prod", TechnicalKey: "tk", CertificatePasswordEncrypted: "otra-llave"}, cert: validCert, want: "no se pudo descifrar la contraseña"},
+		{name: "certificado no abre", settings: &entity.DIANSettings{Environment: "prod", TechnicalKey: "tk", CertificatePasswordEncrypted: "enc:x", CertificateFileName: "prod.p12"}, certErr: errors.New("pkcs12: decryption password incorrect"), want: "no se pudo cargar el certificado prod.p12"},
+		{name: "certificado vencido", settings: &entity.DIANSettings{Environment: "prod", TechnicalKey: "tk", CertificatePasswordEncrypted: "enc:x"}, cert: testCertificate(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)), want: "certificado vencido el 2024-01-31"},
+	}
+	for _, tc := range misconfigured {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeDIANSettingsRepo{byEnv: map[string]*entity.DIANSettings{}}
+			if tc.settings != nil {
+				repo.byEnv["prod"] = tc.settings
+			}
+			r := NewDIANCredentialResolver(repo, fakeDecryptor{}, DIANConfig{AppEnv: "prod"}, 0)
+			r.loadCert = func(DIANConfig) (tls.Certificate, error) { return tc.cert, tc.certErr }
+			_, err := r.Resolve(context.Background(), company)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrDIANCredentials))
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+
+	t.Run("respaldo global sin configuración de la empresa", func(t *testing.T) {
+		repo := &fakeDIANSettingsRepo{byEnv: map[string]*entity.DIANSettings{}}
+		global := DIANConfig{TechnicalKey: "tk-global", CertPath: "/certs/global.p12", AppEnv: "test"}
+		r := NewDIANCredentialResolver(repo, fakeDecryptor{}, global, 0)
+		r.loadCert = func(DIANConfig) (tls.Certificate, error) { return validCert, nil }
+		_, err := r.Resolve(context.Background(), &entity.Company{ID: testCompanyID})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrDIANCredentials))
+		assert.Contains(t, err.Error(), "no tiene certificado configurado para el ambiente test")
+	})
+
+	t.Run("clave técnica global no completa la de la empresa", func(t *testing.T) {
+		settings := *prodSettings
+		settings.TechnicalKey = ""
+		repo := &fakeDIANSettingsRepo{byEnv: map[string]*entity.DIANSettings{"prod": &settings}}
+		r := NewDIANCredentialResolver(repo, fakeDecryptor{}, DIANConfig{TechnicalKey: "tk-global", AppEnv: "test"}, 0)
+		r.loadCert = func(DIANConfig) (tls.Certificate, error) { return validCert, nil }
+		_, err := r.Resolve(context.Background(), company)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrDIANCredentials))
+		assert.Contains(t, err.Error(), "no tiene clave técnica")
+	})
+
+	t.Run("instalación de una sola empresa usa la configuración global", func(t *testing.T) {
+		repo := &fakeDIANSettingsRepo{byEnv: map[string]*entity.DIANSettings{}}
+		global := DIANConfig{TechnicalKey: "tk-global", CertPath: "/certs/global.p12", AppEnv: "test", SingleTenant: true}
+		r := NewDIANCredentialResolver(repo, fakeDecryptor{}, global, 0)
+		r.loadCert = func(DIANConfig) (tls.Certificate, error) { return validCert, nil }
+		creds, err := r.Resolve(context.Background(), &entity.Company{ID: testCompanyID})
+		require.NoError(t, err)
+		assert.Equal(t, "tk-global", creds.TechnicalKey)
+		assert.Equal(t, "2", creds.TipoAmbiente)
+		assert.Equal(t, "test", creds.AppEnv)
+	})
+}
+
+func TestSingleTenantDefault(t *testing.T) {
+	ctx := context.Background()
+	global := DIANConfig{CertPath: "/app/certs/empresa.p12", TechnicalKey: "clave-global"}
+	configured := &fakeDIANSettingsRepo{byEnv: map[string]*entity.DIANSettings{"test": {CompanyID: "c1", Environment: "test"}}}
+
+	t.Run("instalación anterior a dian_settings sigue firmando con la configuración global", func(t *testing.T) {
+		single, err := SingleTenantDefault(ctx, &fakeDIANSettingsRepo{}, global)
+		require.NoError(t, err)
+		assert.True(t, single)
+	})
+	t.Run("con alguna empresa configurada cada una usa la suya", func(t *testing.T) {
+		single, err := SingleTenantDefault(ctx, configured, global)
+		require.NoError(t, err)
+		assert.False(t, single)
+	})
+	t.Run("sin certificado o clave técnica globales no hay respaldo", func(t *testing.T) {
+		for _, cfg := range []DIANConfig{{CertPath: global.CertPath}, {TechnicalKey: global.TechnicalKey}} {
+			single, err := SingleTenantDefault(ctx, &fakeDIANSettingsRepo{}, cfg)
+			require.NoError(t, err)
+			assert.False(t, single)
+		}
+	})
+}
@@ -0,0 +1,450 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// Set de pruebas estándar y resolución de pruebas publicada por la DIAN para la habilitación.
+const (
+	habilitacionPrefix             = "SETP"
+	habilitacionResolution         = "18760000001"
+	habilitacionRangeFrom    int64 = 990000000
+	habilitacionRangeTo      int64 = 995000000
+	habilitacionInvoices           = 8
+	habilitacionCreditNotes        = 1
+	habilitacionDebitNotes         = 1
+	habilitacionMaxDocuments       = 100
+	habilitacionCreditPrefix       = "NC"
+	habilitacionDebitPrefix        = "ND"
+)
+
+var (
+	habilitacionResolutionFrom = time.Date(2019, 1, 19, 0, 0, 0, 0, time.UTC)
+	habilitacionResolutionTo   = time.Date(2030, 1, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// DIANHabilitacionUseCase guía la habilitación de una empresa ante la DIAN: genera y firma el set de
+// pruebas con la resolución de pruebas y las credenciales de la empresa, lo envía con SendTestSetAsync
+// y sigue la validación de cada documento hasta que el set queda aceptado o con rechazos.
+type DIANHabilitacionUseCase struct {
+	repo        DIANHabilitacionRepository
+	companyRepo repository.CompanyRepository
+	credentials DIANCredentialsProvider
+	xmlBuilder  *infradian.XMLBuilderService
+	signer      pkgdian.Signer
+	submitter   infradian.DIANTestSetSubmitter
+	now         func() time.Time
+}
+
+// NewDIANHabilitacionUseCase construye el caso de uso. submitter puede ser nil (modo dev): el set se
+// genera y firma, pero no se envía.
+func NewDIANHabilitacionUseCase(
+	repo DIANHabilitacionRepository,
+	companyRepo repository.CompanyRepository,
+	credentials DIANCredentialsProvider,
+	xmlBuilder *infradian.XMLBuilderService,
+	signer pkgdian.Signer,
+	submitter infradian.DIANTestSetSubmitter,
+) *DIANHabilitacionUseCase {
+	return &DIANHabilitacionUseCase{
+		repo:        repo,
+		companyRepo: companyRepo,
+		credentials: credentials,
+		xmlBuilder:  xmlBuilder,
+		signer:      signer,
+		submitter:   submitter,
+		now:         time.Now,
+	}
+}
+
+// Start genera y firma el set de pruebas de la empresa y lo deja en cola para el envío.
+// La empresa debe estar en ambiente de habilitación y sin otro proceso en curso.
+func (uc *DIANHabilitacionUseCase) Start(ctx context.Context, companyID, userID string, in dto.StartDIANHabilitacionRequest) (*dto.DIANHabilitacionDTO, error) {
+	in.TestSetID = strings.TrimSpace(in.TestSetID)
+	if in.TestSetID == "" {
+		return nil, fmt.Errorf("%w: test_set_id es requerido", domain.ErrInvalidInput)
+	}
+	applyHabilitacionDefaults(&in)
+	total := in.Invoices + in.CreditNotes + in.DebitNotes
+	if in.Invoices < 1 || in.CreditNotes < 0 || in.DebitNotes < 0 || total > habilitacionMaxDocuments {
+		return nil, fmt.Errorf("%w: el set requiere al menos una factura y máximo %d documentos", domain.ErrInvalidInput, habilitacionMaxDocuments)
+	}
+	if in.RangeFrom > in.RangeTo {
+		return nil, fmt.Errorf("%w: rango de numeración inválido", domain.ErrInvalidInput)
+	}
+
+	company, err := uc.companyRepo.GetByID(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if company == nil {
+		return nil, domain.ErrNotFound
+	}
+	creds, err := uc.credentials.Resolve(ctx, company)
+	if err != nil {
+		return nil, err
+	}
+	if creds.Environment != "test" {
+		return nil, fmt.Errorf("%w: la empresa debe estar en ambiente de habilitación para enviar el set de pruebas", domain.ErrInvalidInput)
+	}
+
+	last, err := uc.repo.LastNumber(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	next := in.RangeFrom
+	if last >= next {
+		next = last + 1
+	}
+	if next+int64(total)-1 > in.RangeTo {
+		return nil, fmt.Errorf("%w: el rango de la resolución de pruebas no alcanza para %d documentos", domain.ErrInvalidInput, total)
+	}
+
+	now := uc.now()
+	h := &entity.DIANHabilitacion{
+		ID:        uuid.New().String(),
+		CompanyID: companyID,
+		TestSetID: in.TestSetID,
+		Prefix:    in.Prefix,
+		Status:    entity.DIANHabilitacionInProgress,
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	resolution := &infradian.BillingResolutionData{
+		Number: in.ResolutionNumber, Prefix: in.Prefix, From: in.RangeFrom, To: in.RangeTo,
+		DateFrom: habilitacionResolutionFrom, DateTo: habilitacionResolutionTo,
+	}
+	var invoices []*entity.Invoice
+	for seq := 1; seq <= total; seq++ {
+		docType, prefix := "INVOICE", in.Prefix
+		switch {
+		case seq > in.Invoices+in.CreditNotes:
+			docType, prefix = "DEBIT_NOTE", habilitacionDebitPrefix
+		case seq > in.Invoices:
+			docType, prefix = "CREDIT_NOTE", habilitacionCreditPrefix
+		}
+		var original *entity.Invoice
+		if docType != "INVOICE" {
+			original = invoices[(seq-in.Invoices-1)%len(invoices)]
+		}
+		inv, doc, err := uc.buildTestDocument(company, creds, resolution, docType, prefix, next, seq, original, now)
+		if err != nil {
+			return nil, fmt.Errorf("generando documento %d del set de pruebas: %w", seq, err)
+		}
+		doc.HabilitacionID = h.ID
+		h.Documents = append(h.Documents, doc)
+		if docType == "INVOICE" {
+			invoices = append(invoices, inv)
+		}
+		next++
+	}
+
+	if err := uc.repo.Create(ctx, h); err != nil {
+		return nil, err
+	}
+	log.Printf("[DIAN][HABILITACION][%s] set de pruebas %s generado: %d documento(s)", companyID, h.TestSetID, total)
+	return toDIANHabilitacionDTO(h), nil
+}
+
+// Get devuelve el progreso del último proceso de habilitación de la empresa.
+func (uc *DIANHabilitacionUseCase) Get(ctx context.Context, companyID string) (*dto.DIANHabilitacionDTO, error) {
+	h, err := uc.repo.GetLatest(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, domain.ErrNotFound
+	}
+	return toDIANHabilitacionDTO(h), nil
+}
+
+// AdvanceAll avanza los procesos en curso (lo invoca DIANHabilitacionWorker).
+func (uc *DIANHabilitacionUseCase) AdvanceAll(ctx context.Context, limit int) error {
+	list, err := uc.repo.ListInProgress(ctx, limit)
+	if err != nil {
+		return err
+	}
+	for _, h := range list {
+		if err := uc.Advance(ctx, h); err != nil {
+			log.Printf("[DIAN][HABILITACION][%s] error avanzando el set %s: %v", h.CompanyID, h.TestSetID, err)
+		}
+	}
+	return nil
+}
+
+// Advance envía los documentos pendientes, consulta los enviados cuya consulta venció y, cuando todos
+// tienen resultado, cierra el proceso como ACCEPTED o FAILED. Los errores de red se reintentan en la
+// siguiente pasada.
+func (uc *DIANHabilitacionUseCase) Advance(ctx context.Context, h *entity.DIANHabilitacion) error {
+	if uc.submitter == nil {
+		return nil
+	}
+	h.LastError = ""
+	for _, doc := range h.Documents {
+		now := uc.now()
+		switch {
+		case doc.Status == entity.DIANTestDocPending:
+			if err := uc.sendTestDocument(ctx, h, doc, now); err != nil {
+				h.LastError = fmt.Sprintf("documento %s: %v", doc.Prefix+doc.Number, err)
+				continue
+			}
+		case doc.Status == entity.DIANTestDocSent && (doc.NextCheckAt == nil || !doc.NextCheckAt.After(now)):
+			uc.checkTestDocument(ctx, doc, now)
+		default:
+			continue
+		}
+		doc.UpdatedAt = now
+		if err := uc.repo.UpdateDocument(ctx, doc); err != nil {
+			return err
+		}
+	}
+
+	var accepted, rejected int
+	for _, doc := range h.Documents {
+		switch doc.Status {
+		case entity.DIANTestDocAccepted:
+			accepted++
+		case entity.DIANTestDocRejected:
+			rejected++
+		}
+	}
+	now := uc.now()
+	if accepted+rejected == len(h.Documents) {
+		h.Status = entity.DIANHabilitacionAccepted
+		if rejected > 0 {
+			h.Status = entity.DIANHabilitacionFailed
+			h.LastError = fmt.Sprintf("%d de %d documento(s) rechazado(s) por la DIAN", rejected, len(h.Documents))
+		}
+		h.CompletedAt = &now
+		log.Printf("[DIAN][HABILITACION][%s] set de pruebas %s → %s (%d aceptado(s))", h.CompanyID, h.TestSetID, h.Status, accepted)
+	}
+	h.UpdatedAt = now
+	return uc.repo.UpdateStatus(ctx, h)
+}
+
+func (uc *DIANHabilitacionUseCase) sendTestDocument(ctx context.Context, h *entity.DIANHabilitacion, doc *entity.DIANTestSetDocument, now time.Time) error {
+	zipBytes, err := infradian.CompressXMLToZip([]byte(doc.XMLSigned), strings.TrimSuffix(doc.ZipName, ".zip")+".xml")
+	if err != nil {
+		return err
+	}
+	res, err := uc.submitter.SendTestSetAsync(ctx, zipBytes, doc.ZipName, h.TestSetID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !res.Accepted:
+		doc.Status, doc.Errors = entity.DIANTestDocRejected, res.Errors
+	case res.TrackID == "":
+		doc.Status, doc.Errors = entity.DIANTestDocRejected, "la DIAN no devolvió ZipKey para el documento"
+	default:
+		next := now.Add(statusCheckBaseDelay)
+		doc.Status, doc.TrackID, doc.NextCheckAt = entity.DIANTestDocSent, res.TrackID, &next
+	}
+	return nil
+}
+
+// checkTestDocument consulta GetStatusZip con el mismo backoff de los envíos de facturas.
+func (uc *DIANHabilitacionUseCase) checkTestDocument(ctx context.Context, doc *entity.DIANTestSetDocument, now time.Time) {
+	doc.StatusChecks++
+	res, err := uc.submitter.GetStatusZip(ctx, doc.TrackID, infradian.AppEnvTest)
+	if err == nil && !res.IsPending() {
+		status, dianErrors, _ := validationOutcome(res)
+		doc.NextCheckAt = nil
+		if status == entity.DIANStatusExitoso {
+			doc.Status, doc.Errors = entity.DIANTestDocAccepted, ""
+		} else {
+			doc.Status, doc.Errors = entity.DIANTestDocRejected, dianErrors
+		}
+		return
+	}
+	if err != nil {
+		doc.Errors = err.Error()
+	}
+	if doc.StatusChecks >= statusCheckMaxAttempts {
+		doc.Status = entity.DIANTestDocRejected
+		doc.Errors = fmt.Sprintf("sin resultado definitivo de la DIAN tras %d consultas del TrackID %s", doc.StatusChecks, doc.TrackID)
+		doc.NextCheckAt = nil
+		return
+	}
+	next := now.Add(statusCheckBackoff(doc.StatusChecks))
+	doc.NextCheckAt = &next
+}
+
+// buildTestDocument arma un documento sintético del set (consumidor final, una línea gravada con IVA
+// 19 %), calcula su CUFE/CUDE y lo firma con el certificado de la empresa.
+func (uc *DIANHabilitacionUseCase) buildTestDocument(
+	company *entity.Company, creds *DIANCredentials, resolution *infradian.BillingResolutionData,
+	docType, prefix string, number int64, seq int, original *entity.Invoice, now time.Time,
+) (*entity.Invoice, *entity.DIANTestSetDocument, error) {
+	base := decimal.NewFromInt(100000).Add(decimal.NewFromInt(int64(seq) * 1000))
+	rate := decimal.RequireFromString("0.19")
+	tax := base.Mul(rate).Round(2)
+	inv := &entity.Invoice{
+		ID:              uuid.New().String(),
+		CompanyID:       company.ID,
+		Prefix:          prefix,
+		Number:          strconv.FormatInt(number, 10),
+		Date:            now,
+		NetTotal:        base,
+		TaxTotal:        tax,
+		GrandTotal:      base.Add(tax),
+		DocumentType:    docType,
+		CurrencyCode:    "COP",
+		ExchangeRate:    decimal.NewFromInt(1),
+		InvoiceTypeCode: "01",
+		PaymentFormCode: "1",
+	}
+	inv.ComputeCOPTotals()
+	buildCtx := &infradian.InvoiceBuildContext{
+		Invoice:                        inv,
+		Company:                        company,
+		Customer:                       habilitacionCustomer(company.ID),
+		PaymentFormCode:                "1",
+		PaymentMethodCodes:             []string{"10"},
+		CustomerIdentificationTypeCode: "13",
+		CompanyIdentificationTypeCode:  "31",
+		DocumentType:                   docType,
+		SoftwareID:                     creds.SoftwareID,
+		SoftwareSecurityCode:           softwareSecurityCode(creds, inv),
+		TipoAmbiente:                   creds.TipoAmbiente,
+	}
+	// Las notas usan el PIN del software en el CUDE; las facturas la clave técnica de la resolución.
+	key := creds.TechnicalKey
+	if docType == "INVOICE" {
+		buildCtx.Resolution = resolution
+	} else {
+		if creds.SoftwarePIN != "" {
+			key = creds.SoftwarePIN
+		}
+		inv.OriginalInvoiceNumber = original.Prefix + original.Number
+		inv.OriginalInvoiceCUFE = original.CUFE
+		inv.OriginalInvoiceIssueOn = original.Date
+		inv.DiscrepancyCode = entity.CreditNoteConceptOtros
+		inv.DiscrepancyReason = "Set de pruebas de habilitación"
+		buildCtx.OriginalInvoiceNumber = inv.OriginalInvoiceNumber
+		buildCtx.OriginalInvoiceCUFE = inv.OriginalInvoiceCUFE
+		buildCtx.OriginalIssueDate = original.Date.Format("2006-01-02")
+		buildCtx.DiscrepancyCode = inv.DiscrepancyCode
+		buildCtx.DiscrepancyReason = inv.DiscrepancyReason
+	}
+	if _, err := infradian.CalculateCufeFromInvoice(&infradian.CufeContext{
+		Invoice: inv, Company: company, Customer: buildCtx.Customer,
+		ClaveTecnica: key, TipoAmbiente: creds.TipoAmbiente,
+	}); err != nil {
+		return nil, nil, err
+	}
+	buildCtx.Details = []infradian.InvoiceLineForXML{{
+		Detail:      &entity.InvoiceDetail{ID: uuid.New().String(), InvoiceID: inv.ID, Quantity: decimal.NewFromInt(1), UnitPrice: base, TaxRate: rate, Subtotal: base},
+		ProductName: "Producto set de pruebas",
+		ProductCode: "SETP-001",
+		UnitCode:    pkgdian.UnitUnit,
+		Quantity:    decimal.NewFromInt(1),
+		UnitPrice:   base,
+		TaxRate:     rate,
+		Subtotal:    base,
+	}}
+
+	xmlBytes, err := uc.xmlBuilder.Build(buildCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	signed, err := uc.signer.Sign(xmlBytes, creds.Certificate)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, zipName := infradian.DIANFilenames(company, inv)
+	return inv, &entity.DIANTestSetDocument{
+		ID:           uuid.New().String(),
+		Sequence:     seq,
+		DocumentType: docType,
+		Prefix:       prefix,
+		Number:       inv.Number,
+		CUFE:         inv.CUFE,
+		XMLSigned:    string(signed),
+		ZipName:      zipName,
+		Status:       entity.DIANTestDocPending,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// habilitacionCustomer adquirente de los documentos del set: consumidor final.
+func habilitacionCustomer(companyID string) *entity.Customer {
+	return &entity.Customer{
+		CompanyID:          companyID,
+		Name:               "Consumidor final",
+		TaxID:              "222222222222",
+		IdentificationType: "13",
+		CountryCode:        "CO",
+	}
+}
+
+func applyHabilitacionDefaults(in *dto.StartDIANHabilitacionRequest) {
+	in.Prefix = strings.ToUpper(strings.TrimSpace(in.Prefix))
+	if in.Prefix == "" {
+		in.Prefix = habilitacionPrefix
+	}
+	if strings.TrimSpace(in.ResolutionNumber) == "" {
+		in.ResolutionNumber = habilitacionResolution
+	}
+	if in.RangeFrom <= 0 {
+		in.RangeFrom = habilitacionRangeFrom
+	}
+	if in.RangeTo <= 0 {
+		in.RangeTo = habilitacionRangeTo
+	}
+	if in.Invoices == 0 && in.CreditNotes == 0 && in.DebitNotes == 0 {
+		in.Invoices, in.CreditNotes, in.DebitNotes = habilitacionInvoices, habilitacionCreditNotes, habilitacionDebitNotes
+	}
+}
+
+func toDIANHabilitacionDTO(h *entity.DIANHabilitacion) *dto.DIANHabilitacionDTO {
+	out := &dto.DIANHabilitacionDTO{
+		ID:          h.ID,
+		TestSetID:   h.TestSetID,
+		Status:      h.Status,
+		Total:       len(h.Documents),
+		LastError:   h.LastError,
+		Documents:   make([]dto.DIANHabilitacionDocumentDTO, 0, len(h.Documents)),
+		CreatedAt:   h.CreatedAt,
+		UpdatedAt:   h.UpdatedAt,
+		CompletedAt: h.CompletedAt,
+	}
+	for _, d := range h.Documents {
+		switch d.Status {
+		case entity.DIANTestDocPending:
+			out.Pending++
+		case entity.DIANTestDocSent:
+			out.Sent++
+		case entity.DIANTestDocAccepted:
+			out.Accepted++
+		case entity.DIANTestDocRejected:
+			out.Rejected++
+		}
+		out.Documents = append(out.Documents, dto.DIANHabilitacionDocumentDTO{
+			Sequence:     d.Sequence,
+			DocumentType: d.DocumentType,
+			Number:       d.Prefix + d.Number,
+			CUFE:         d.CUFE,
+			TrackID:      d.TrackID,
+			Status:       d.Status,
+			Errors:       d.Errors,
+		})
+	}
+	return out
+}
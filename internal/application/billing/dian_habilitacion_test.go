@@ -0,0 +1,164 @@
+package billing
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+)
+
+type fakeHabilitacionRepo struct {
+	h       *entity.DIANHabilitacion
+	updates int
+}
+
+func (f *fakeHabilitacionRepo) Create(_ context.Context, h *entity.DIANHabilitacion) error {
+	if f.h != nil && f.h.Status == entity.DIANHabilitacionInProgress {
+		return domain.ErrConflict
+	}
+	f.h = h
+	return nil
+}
+func (f *fakeHabilitacionRepo) GetLatest(context.Context, string) (*entity.DIANHabilitacion, error) {
+	return f.h, nil
+}
+func (f *fakeHabilitacionRepo) ListInProgress(context.Context, int) ([]*entity.DIANHabilitacion, error) {
+	if f.h == nil || f.h.Status != entity.DIANHabilitacionInProgress {
+		return nil, nil
+	}
+	return []*entity.DIANHabilitacion{f.h}, nil
+}
+func (f *fakeHabilitacionRepo) LastNumber(context.Context, string) (int64, error) { return 0, nil }
+func (f *fakeHabilitacionRepo) UpdateDocument(context.Context, *entity.DIANTestSetDocument) error {
+	f.updates++
+	return nil
+}
+func (f *fakeHabilitacionRepo) UpdateStatus(context.Context, *entity.DIANHabilitacion) error {
+	return nil
+}
+
+var _ DIANHabilitacionRepository = (*fakeHabilitacionRepo)(nil)
+
+type fakeTestSetSubmitter struct {
+	sent      []string
+	testSetID string
+	status    map[string]*infradian.StatusResult
+}
+
+func (f *fakeTestSetSubmitter) SendTestSetAsync(_ context.Context, _ []byte, filename, testSetID string) (*infradian.SubmitResult, error) {
+	f.sent = append(f.sent, filename)
+	f.testSetID = testSetID
+	return &infradian.SubmitResult{TrackID: "zip-" + filename, Accepted: true}, nil
+}
+func (f *fakeTestSetSubmitter) GetStatusZip(_ context.Context, trackID, _ string) (*infradian.StatusResult, error) {
+	if res, ok := f.status[trackID]; ok {
+		return res, nil
+	}
+	return &infradian.StatusResult{IsValid: true, StatusCode: infradian.StatusCodeProcessed}, nil
+}
+
+type fakeCredentials struct {
+	creds *DIANCredentials
+	err   error
+}
+
+func (f *fakeCredentials) Resolve(context.Context, *entity.Company) (*DIANCredentials, error) {
+	return f.creds, f.err
+}
+
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(xmlBytes []byte, _ tls.Certificate) ([]byte, error) { return xmlBytes, nil }
+
+func TestDIANHabilitacionUseCase(t *testing.T) {
+	company := &entity.Company{ID: testCompanyID, NIT: "900123456", Name: "Empresa de prueba", Environment: "habilitacion"}
+	companyRepo := &fakeCompanyRepo{getByIDFunc: func(string) (*entity.Company, error) { return company, nil }}
+	testCreds := &DIANCredentials{Environment: "test", AppEnv: "test", TipoAmbiente: "2", TechnicalKey: "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c", SoftwareID: "sw-1", SoftwarePIN: "12345"}
+
+	newUseCase := func(creds *fakeCredentials, sub *fakeTestSetSubmitter) (*DIANHabilitacionUseCase, *fakeHabilitacionRepo) {
+		repo := &fakeHabilitacionRepo{}
+		return NewDIANHabilitacionUseCase(repo, companyRepo, creds, infradian.NewXMLBuilderService(), fakeSigner{}, sub), repo
+	}
+
+	t.Run("genera, envía y queda aceptado", func(t *testing.T) {
+		sub := &fakeTestSetSubmitter{}
+		uc, repo := newUseCase(&fakeCredentials{creds: testCreds}, sub)
+		ctx := context.Background()
+
+		out, err := uc.Start(ctx, testCompanyID, "user-1", dto.StartDIANHabilitacionRequest{TestSetID: " set-abc "})
+		require.NoError(t, err)
+		assert.Equal(t, 10, out.Total)
+		assert.Equal(t, 10, out.Pending)
+		assert.Equal(t, "SETP990000000", out.Documents[0].Number)
+		assert.Equal(t, "CREDIT_NOTE", out.Documents[8].DocumentType)
+		assert.Equal(t, "NC990000008", out.Documents[8].Number)
+		assert.Equal(t, "DEBIT_NOTE", out.Documents[9].DocumentType)
+		for _, d := range repo.h.Documents {
+			assert.Len(t, d.CUFE, 96)
+			assert.Contains(t, d.XMLSigned, "SoftwareSecurityCode")
+		}
+		assert.Contains(t, repo.h.Documents[8].XMLSigned, repo.h.Documents[0].CUFE, "la nota referencia la primera factura")
+
+		_, err = uc.Start(ctx, testCompanyID, "user-1", dto.StartDIANHabilitacionRequest{TestSetID: "set-abc"})
+		assert.True(t, errors.Is(err, domain.ErrConflict))
+
+		// Primera pasada: envío; la consulta se programa con el backoff de GetStatusZip.
+		require.NoError(t, uc.AdvanceAll(ctx, 10))
+		assert.Len(t, sub.sent, 10)
+		assert.Equal(t, "set-abc", sub.testSetID)
+		progress, err := uc.Get(ctx, testCompanyID)
+		require.NoError(t, err)
+		assert.Equal(t, 10, progress.Sent)
+		assert.Equal(t, entity.DIANHabilitacionInProgress, progress.Status)
+
+		uc.now = func() time.Time { return time.Now().Add(statusCheckBaseDelay) }
+		require.NoError(t, uc.AdvanceAll(ctx, 10))
+		progress, err = uc.Get(ctx, testCompanyID)
+		require.NoError(t, err)
+		assert.Equal(t, 10, progress.Accepted)
+		assert.Equal(t, entity.DIANHabilitacionAccepted, progress.Status)
+		assert.NotNil(t, progress.CompletedAt)
+	})
+
+	t.Run("rechazos dejan el set en FAILED", func(t *testing.T) {
+		sub := &fakeTestSetSubmitter{status: map[string]*infradian.StatusResult{
+			"zip-900123456NC990000001.zip": {StatusCode: infradian.StatusCodeValidationErrs, ErrorMessages: []string{"Regla: CAD09e, Rechazo: CUDE inválido"}},
+		}}
+		uc, _ := newUseCase(&fakeCredentials{creds: testCreds}, sub)
+		ctx := context.Background()
+		_, err := uc.Start(ctx, testCompanyID, "", dto.StartDIANHabilitacionRequest{TestSetID: "set-abc", Invoices: 1, CreditNotes: 1})
+		require.NoError(t, err)
+		require.NoError(t, uc.AdvanceAll(ctx, 10))
+		uc.now = func() time.Time { return time.Now().Add(statusCheckBaseDelay) }
+		require.NoError(t, uc.AdvanceAll(ctx, 10))
+
+		progress, err := uc.Get(ctx, testCompanyID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.DIANHabilitacionFailed, progress.Status)
+		assert.Equal(t, 1, progress.Rejected)
+		assert.Equal(t, "CAD09e: CUDE inválido", progress.Documents[1].Errors)
+	})
+
+	t.Run("validaciones", func(t *testing.T) {
+		uc, _ := newUseCase(&fakeCredentials{creds: testCreds}, nil)
+		_, err := uc.Start(context.Background(), testCompanyID, "", dto.StartDIANHabilitacionRequest{})
+		assert.True(t, errors.Is(err, domain.ErrInvalidInput))
+
+		uc, _ = newUseCase(&fakeCredentials{creds: &DIANCredentials{Environment: "prod"}}, nil)
+		_, err = uc.Start(context.Background(), testCompanyID, "", dto.StartDIANHabilitacionRequest{TestSetID: "set-abc"})
+		assert.True(t, errors.Is(err, domain.ErrInvalidInput))
+
+		uc, _ = newUseCase(&fakeCredentials{err: ErrDIANCredentials}, nil)
+		_, err = uc.Start(context.Background(), testCompanyID, "", dto.StartDIANHabilitacionRequest{TestSetID: "set-abc"})
+		assert.True(t, errors.Is(err, ErrDIANCredentials))
+	})
+}
@@ -0,0 +1,47 @@
+package billing
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DIANHabilitacionWorker avanza periódicamente los sets de pruebas en curso: envía los documentos
+// pendientes y consulta la validación de los enviados.
+type DIANHabilitacionWorker struct {
+	uc        *DIANHabilitacionUseCase
+	interval  time.Duration
+	batchSize int
+}
+
+func NewDIANHabilitacionWorker(uc *DIANHabilitacionUseCase, interval time.Duration, batchSize int) *DIANHabilitacionWorker {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+	return &DIANHabilitacionWorker{uc: uc, interval: interval, batchSize: batchSize}
+}
+
+func (w *DIANHabilitacionWorker) Start(ctx context.Context) {
+	if w.uc == nil {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+			if err := w.uc.AdvanceAll(runCtx, w.batchSize); err != nil {
+				log.Printf("[DIAN][HABILITACION] no se pudieron listar sets en curso: %v", err)
+			}
+			cancel()
+		}
+	}
+}
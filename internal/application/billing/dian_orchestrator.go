@@ -8,11 +8,14 @@ import (
 	"log"
 	"net"
 	"strings"
+	"sync"
 	"time"
 
 	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
 	"github.com/jhoicas/Inventario-api/internal/infrastructure/dian/signer"
 
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
 	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
@@ -20,7 +23,7 @@ import (
 
 // DIANOrchestrator orquesta el ciclo completo de firma y envío electrónico DIAN:
 //
-//	CUFE → XML UBL 2.1 → Firma XAdES-EPES → ZIP → Envío SOAP → Update DB
+//	CUFE → XML UBL 2.1 → Validación local → Firma XAdES-EPES → ZIP → Envío SOAP → Update DB
 //
 // Se ejecuta siempre en goroutine independiente (ProcessAsync) con su propio
 // context.Background() + timeout 30 s, desacoplado del ciclo HTTP.
@@ -36,11 +39,17 @@ type DIANOrchestrator struct {
 	productRepo    repository.ProductRepository
 	resolutionRepo repository.BillingResolutionRepository
 	xmlBuilder     *infradian.XMLBuilderService
+	validator      *infradian.XMLValidatorService // esquema UBL y reglas DIAN antes de firmar; nil → sin validación
 	signer         pkgdian.Signer
 	submitter      infradian.DIANSubmitter // cliente SOAP; nil en dev
 	dianConfig     DIANConfig
 	mailer         InvoiceMailerPort // optional; nil → no email
 	retryQueue     *DIANRetryQueue
+	statusRepo     DIANStatusRepository      // seguimiento de GetStatusZip; nil → sin polling
+	credentials    DIANCredentialsProvider   // credenciales por empresa; nil → DIANConfig global
+	contingencies  DIANContingencyRepository // facturas tipo 03 retenidas mientras dura la contingencia
+	queue          chan string               // cola acotada de StartQueue; nil → Enqueue usa ProcessAsync
+	queued         sync.Map                  // documentos en cola o en proceso (evita encolarlos dos veces)
 }
 
 // InvoiceMailerPort es el puerto opcional de envío de correo tras validación DIAN.
@@ -79,11 +88,40 @@ func (o *DIANOrchestrator) SetMailer(m InvoiceMailerPort) {
 	o.mailer = m
 }
 
-// SetRetryQueue inyecta una cola para reintentos DIAN en estado CONTINGENCIA.
+// SetXMLValidator inyecta la validación local (esquema UBL 2.1 y reglas de negocio DIAN) que corre entre
+// la construcción del XML y la firma; un documento con rechazos queda en ERROR_GENERATION sin enviarse.
+func (o *DIANOrchestrator) SetXMLValidator(v *infradian.XMLValidatorService) {
+	o.validator = v
+}
+
+// SetRetryQueue inyecta la cola persistente de reintentos DIAN en estado CONTINGENCIA.
 func (o *DIANOrchestrator) SetRetryQueue(q *DIANRetryQueue) {
 	o.retryQueue = q
 }
 
+// SetStatusRepository inyecta la persistencia del seguimiento de envíos asíncronos (GetStatusZip).
+func (o *DIANOrchestrator) SetStatusRepository(repo DIANStatusRepository) {
+	o.statusRepo = repo
+}
+
+// SetCredentialsProvider inyecta la resolución de credenciales DIAN por empresa (certificado,
+// clave técnica, software y ambiente). Sin provider se usa la configuración global DIANConfig.
+func (o *DIANOrchestrator) SetCredentialsProvider(p DIANCredentialsProvider) {
+	o.credentials = p
+}
+
+// SetContingencyRepository habilita la facturación en contingencia: las facturas tipo 03 se firman pero
+// no se envían mientras su contingencia siga activa; al terminarla se encolan para transmitirlas.
+func (o *DIANOrchestrator) SetContingencyRepository(repo DIANContingencyRepository) {
+	o.contingencies = repo
+}
+
+// ResolvesCredentialsPerCompany indica si las credenciales salen de la configuración de cada empresa;
+// en ese caso el procesamiento no depende de la clave técnica global.
+func (o *DIANOrchestrator) ResolvesCredentialsPerCompany() bool {
+	return o != nil && o.credentials != nil
+}
+
 // ProcessAsync dispara el procesamiento DIAN en una goroutine independiente.
 // invoiceID es el ID de la factura ya persistida en estado DRAFT.
 func (o *DIANOrchestrator) ProcessAsync(invoiceID string) {
@@ -95,6 +133,57 @@ func (o *DIANOrchestrator) ProcessSync(invoiceID string) {
 	o.process(invoiceID, false)
 }
 
+// StartQueue arranca workers goroutines que procesan los documentos de Enqueue, con hasta size en
+// espera. Limita la concurrencia frente a la DIAN cuando el volumen es alto (documentos POS); los
+// workers terminan con ctx.
+func (o *DIANOrchestrator) StartQueue(ctx context.Context, workers, size int) {
+	if workers <= 0 {
+		workers = 4
+	}
+	if size <= 0 {
+		size = 1000
+	}
+	o.queue = make(chan string, size)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-o.queue:
+					o.process(id, false)
+					o.queued.Delete(id)
+				}
+			}
+		}()
+	}
+}
+
+// Enqueue encola el documento en DRAFT para firma y envío sin bloquear a quien lo emite; una factura de
+// contingencia ya firmada (SIGNED) se encola para transmitirla. Devuelve false si la cola está llena: el
+// documento sigue en su estado hasta que se vuelva a encolar. Sin StartQueue procesa en su propia
+// goroutine como ProcessAsync.
+func (o *DIANOrchestrator) Enqueue(invoiceID string) bool {
+	if o == nil {
+		return false
+	}
+	if o.queue == nil {
+		o.ProcessAsync(invoiceID)
+		return true
+	}
+	if _, already := o.queued.LoadOrStore(invoiceID, struct{}{}); already {
+		return true
+	}
+	select {
+	case o.queue <- invoiceID:
+		return true
+	default:
+		o.queued.Delete(invoiceID)
+		log.Printf("[DIAN][%s] cola de procesamiento llena: el documento queda en DRAFT para reencolar", invoiceID)
+		return false
+	}
+}
+
 // RetryAsync reintenta una factura en contingencia.
 func (o *DIANOrchestrator) RetryAsync(invoiceID string) {
 	go o.process(invoiceID, true)
@@ -106,35 +195,12 @@ func (o *DIANOrchestrator) RetrySync(invoiceID string) {
 }
 
 // process es el núcleo síncrono del orquestador. Siempre termina actualizando
-// dian_status en la DB (EXITOSO, RECHAZADO o ERROR_GENERATION).
+// dian_status en la DB (EXITOSO, RECHAZADO o ERROR_GENERATION), salvo las facturas de contingencia
+// que quedan en SIGNED hasta que termina la contingencia.
 func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// markError actualiza la factura a ERROR_GENERATION y hace log del problema.
-	markError := func(inv *entity.Invoice, step, msg string) {
-		inv.DIAN_Status = entity.DIANStatusErrorGeneration
-		inv.UpdatedAt = time.Now()
-		if err := o.invoiceRepo.Update(inv); err != nil {
-			log.Printf("[DIAN][%s] no se pudo persistir ERROR_GENERATION: %v", invoiceID, err)
-		}
-		log.Printf("[DIAN][%s] ERROR en %s: %s", invoiceID, step, msg)
-	}
-
-	markContingency := func(inv *entity.Invoice, msg string) {
-		inv.DIAN_Status = entity.DIANStatusContingencia
-		inv.DIANErrors = msg
-		inv.UpdatedAt = time.Now()
-		if err := o.invoiceRepo.Update(inv); err != nil {
-			log.Printf("[DIAN][%s] no se pudo persistir CONTINGENCIA: %v", invoiceID, err)
-			return
-		}
-		if o.retryQueue != nil {
-			o.retryQueue.Enqueue(invoiceID)
-		}
-		log.Printf("[DIAN][%s] timeout DIAN: factura enviada a CONTINGENCIA y encolada", invoiceID)
-	}
-
 	// ═══════════════════════════════════════════════════════════════════════════
 	// 0. Re-fetch datos frescos (evita data races con el goroutine HTTP)
 	// ═══════════════════════════════════════════════════════════════════════════
@@ -148,6 +214,15 @@ func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 			log.Printf("[DIAN][%s] retry omitido: estado actual %q (se esperaba CONTINGENCIA)", invoiceID, inv.DIAN_Status)
 			return
 		}
+		// La factura de contingencia se reenvía con el XML ya entregado al adquiriente: reconstruirla o
+		// volver a firmarla cambiaría la firma (y la hora de firma) del documento que tiene el cliente.
+		if inv.IsContingency() && inv.XMLSigned != "" {
+			o.transmitContingency(ctx, inv)
+			return
+		}
+	} else if inv.DIAN_Status == entity.DIANStatusSigned && inv.IsContingency() {
+		o.transmitContingency(ctx, inv)
+		return
 	} else if inv.DIAN_Status != entity.DIANStatusDraft {
 		log.Printf("[DIAN][%s] estado %q inesperado (ya procesada?), saltando", invoiceID, inv.DIAN_Status)
 		return
@@ -155,28 +230,79 @@ func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 
 	company, err := o.companyRepo.GetByID(inv.CompanyID)
 	if err != nil || company == nil {
-		markError(inv, "fetch-company", fmt.Sprintf("empresa %s no encontrada: %v", inv.CompanyID, err))
+		o.markError(inv, "fetch-company", fmt.Sprintf("empresa %s no encontrada: %v", inv.CompanyID, err))
+		return
+	}
+
+	creds, err := o.credentialsFor(ctx, company)
+	if err != nil {
+		o.markError(inv, "credentials", err.Error())
 		return
 	}
 
 	customer, err := o.customerRepo.GetByID(inv.CustomerID)
 	if err != nil || customer == nil {
-		markError(inv, "fetch-customer", fmt.Sprintf("cliente %s no encontrado: %v", inv.CustomerID, err))
+		o.markError(inv, "fetch-customer", fmt.Sprintf("cliente %s no encontrado: %v", inv.CustomerID, err))
 		return
 	}
 
 	resolution, err := o.resolutionRepo.GetActiveByCompanyAndPrefix(ctx, inv.CompanyID, inv.Prefix)
 	if err != nil {
-		markError(inv, "fetch-resolution", fmt.Sprintf("error consultando resolución: %v", err))
+		o.markError(inv, "fetch-resolution", fmt.Sprintf("error consultando resolución: %v", err))
 		return
 	}
 
 	details, err := o.invoiceRepo.GetDetailsByInvoiceID(invoiceID)
 	if err != nil {
-		markError(inv, "fetch-details", fmt.Sprintf("error obteniendo detalles: %v", err))
+		o.markError(inv, "fetch-details", fmt.Sprintf("error obteniendo detalles: %v", err))
 		return
 	}
 
+	// Desglose de impuestos por línea; los documentos anteriores al desglose no tienen filas y
+	// el XML/CUFE toman el IVA de cada detalle.
+	taxes, err := o.invoiceRepo.GetTaxesByInvoiceID(invoiceID)
+	if err != nil {
+		o.markError(inv, "fetch-taxes", fmt.Sprintf("error obteniendo impuestos: %v", err))
+		return
+	}
+	// Retenciones del cliente (cac:WithholdingTaxTotal); solo las facturas con WithholdingTotal las tienen.
+	var withholdings []*entity.InvoiceWithholding
+	if inv.WithholdingTotal.IsPositive() {
+		if withholdings, err = o.invoiceRepo.GetWithholdingsByInvoiceID(invoiceID); err != nil {
+			o.markError(inv, "fetch-withholdings", fmt.Sprintf("error obteniendo retenciones: %v", err))
+			return
+		}
+	}
+	taxesByDetail := make(map[string][]*entity.InvoiceTax, len(details))
+	for _, t := range taxes {
+		taxesByDetail[t.InvoiceDetailID] = append(taxesByDetail[t.InvoiceDetailID], t)
+	}
+	// Descuentos y cargos (cac:AllowanceCharge): los de línea van en su InvoiceLine, el resto en el documento.
+	var globalAllowanceCharges []*entity.InvoiceAllowanceCharge
+	allowancesByDetail := make(map[string][]*entity.InvoiceAllowanceCharge)
+	if inv.DiscountTotal.IsPositive() || inv.ChargeTotal.IsPositive() {
+		allowanceCharges, err := o.invoiceRepo.GetAllowanceChargesByInvoiceID(invoiceID)
+		if err != nil {
+			o.markError(inv, "fetch-allowance-charges", fmt.Sprintf("error obteniendo descuentos y cargos: %v", err))
+			return
+		}
+		for _, ac := range allowanceCharges {
+			if ac.InvoiceDetailID == "" {
+				globalAllowanceCharges = append(globalAllowanceCharges, ac)
+				continue
+			}
+			allowancesByDetail[ac.InvoiceDetailID] = append(allowancesByDetail[ac.InvoiceDetailID], ac)
+		}
+	}
+
+	var installments []*entity.InvoiceInstallment
+	if inv.IsCredit() {
+		if installments, err = o.invoiceRepo.GetInstallmentsByInvoiceID(invoiceID); err != nil {
+			o.markError(inv, "fetch-installments", fmt.Sprintf("error obteniendo cuotas: %v", err))
+			return
+		}
+	}
+
 	// ═══════════════════════════════════════════════════════════════════════════
 	// 1. Enriquecer líneas con datos de producto
 	// ═══════════════════════════════════════════════════════════════════════════
@@ -191,13 +317,15 @@ func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 			linesForXML[i] = infradian.InvoiceLineForXML{
 				Detail: d, ProductName: product.Name, ProductCode: product.SKU,
 				UnitCode: unitCode, Quantity: d.Quantity, UnitPrice: d.UnitPrice,
-				TaxRate: d.TaxRate, Subtotal: d.Subtotal,
+				TaxRate: d.TaxRate, Subtotal: d.Subtotal, Taxes: taxesByDetail[d.ID],
+				AllowanceCharges: allowancesByDetail[d.ID],
 			}
 		} else {
 			linesForXML[i] = infradian.InvoiceLineForXML{
 				Detail: d, ProductName: "Producto " + d.ProductID, ProductCode: d.ProductID,
 				UnitCode: unitCode, Quantity: d.Quantity, UnitPrice: d.UnitPrice,
-				TaxRate: d.TaxRate, Subtotal: d.Subtotal,
+				TaxRate: d.TaxRate, Subtotal: d.Subtotal, Taxes: taxesByDetail[d.ID],
+				AllowanceCharges: allowancesByDetail[d.ID],
 			}
 		}
 	}
@@ -205,18 +333,17 @@ func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 	// ═══════════════════════════════════════════════════════════════════════════
 	// 2. Calcular CUFE (SHA-384, Anexo Técnico 1.9)
 	// ═══════════════════════════════════════════════════════════════════════════
-	tipoAmb := o.dianConfig.Environment
-	if tipoAmb == "" {
-		tipoAmb = "2"
-	}
+	tipoAmb := creds.TipoAmbiente
 	if _, err := infradian.CalculateCufeFromInvoice(&infradian.CufeContext{
 		Invoice:      inv,
 		Company:      company,
 		Customer:     customer,
-		ClaveTecnica: o.dianConfig.TechnicalKey,
+		ClaveTecnica: creds.TechnicalKey,
+		SoftwarePIN:  creds.SoftwarePIN,
 		TipoAmbiente: tipoAmb,
+		Taxes:        taxes,
 	}); err != nil {
-		markError(inv, "cufe", err.Error())
+		o.markError(inv, "cufe", err.Error())
 		return
 	}
 
@@ -238,30 +365,52 @@ func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 		Customer:                       customer,
 		Details:                        linesForXML,
 		Resolution:                     resData,
-		CustomerIdentificationTypeCode: identTypeCode(customer.TaxID),
+		Taxes:                          taxes,
+		Withholdings:                   withholdings,
+		AllowanceCharges:               globalAllowanceCharges,
+		PaymentFormCode:                inv.PaymentFormCode,
+		PaymentMethodCodes:             inv.PaymentMethodCodes,
+		DueDate:                        inv.DueDate,
+		Installments:                   installments,
+		CustomerIdentificationTypeCode: customerIdentTypeCode(customer),
 		CompanyIdentificationTypeCode:  "31",
+		SoftwareID:                     creds.SoftwareID,
+		SoftwareSecurityCode:           softwareSecurityCode(creds, inv),
+		TipoAmbiente:                   tipoAmb,
+		DocumentType:                   xmlDocumentType(inv),
+		OriginalInvoiceNumber:          inv.OriginalInvoiceNumber,
+		OriginalInvoiceCUFE:            inv.OriginalInvoiceCUFE,
+		OriginalIssueDate:              originalIssueDate(inv),
+		DiscrepancyCode:                inv.DiscrepancyCode,
+		DiscrepancyReason:              inv.DiscrepancyReason,
 	})
 	if errXML != nil {
-		markError(inv, "xml-build", errXML.Error())
+		o.markError(inv, "xml-build", errXML.Error())
 		return
 	}
 
 	// ═══════════════════════════════════════════════════════════════════════════
-	// 4. Firma digital XAdES-EPES
+	// 3b. Validación local: esquema UBL 2.1 y reglas de negocio DIAN
 	// ═══════════════════════════════════════════════════════════════════════════
-	cert, errCert := loadCertificate(o.dianConfig)
-	if errCert != nil {
-		markError(inv, "cert-load", errCert.Error())
-		return
-	}
-	if len(cert.Certificate) == 0 || cert.PrivateKey == nil {
-		markError(inv, "cert-load", "certificado vacío: verifica DIAN_CERT_PATH y DIAN_CERT_PASSWORD")
-		return
+	if o.validator != nil {
+		rules, errVal := o.validator.Validate(ctx, xmlBytes)
+		if errVal != nil {
+			o.markError(inv, "xml-validate", errVal.Error())
+			return
+		}
+		inv.DIANRules = rules
+		if rejections := domaindian.FormatRejections(rules); rejections != "" {
+			o.markError(inv, "xml-validate", rejections)
+			return
+		}
 	}
 
-	signedXMLBytes, errSign := o.signer.Sign(xmlBytes, cert)
+	// ═══════════════════════════════════════════════════════════════════════════
+	// 4. Firma digital XAdES-EPES
+	// ═══════════════════════════════════════════════════════════════════════════
+	signedXMLBytes, errSign := o.signer.Sign(xmlBytes, creds.Certificate)
 	if errSign != nil {
-		markError(inv, "xml-sign", errSign.Error())
+		o.markError(inv, "xml-sign", errSign.Error())
 		return
 	}
 
@@ -275,20 +424,71 @@ func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 		return
 	}
 
+	// Factura de contingencia (tipo 03): queda firmada, con CUFE y QR para la representación gráfica, y se
+	// transmite cuando la empresa termina la contingencia.
+	if o.heldByContingency(inv) {
+		log.Printf("[DIAN][%s] factura de contingencia firmada: pendiente de transmisión", invoiceID)
+		return
+	}
+
+	o.submit(ctx, inv, company, creds, signedXMLBytes)
+}
+
+// transmitContingency envía a la DIAN una factura de contingencia (tipo 03) ya firmada, con el mismo XML
+// que se entregó al adquiriente, una vez terminada su contingencia.
+func (o *DIANOrchestrator) transmitContingency(ctx context.Context, inv *entity.Invoice) {
+	if inv.XMLSigned == "" {
+		o.markError(inv, "contingency", "la factura de contingencia no tiene XML firmado")
+		return
+	}
+	if o.heldByContingency(inv) {
+		log.Printf("[DIAN][%s] transmisión omitida: la contingencia sigue activa", inv.ID)
+		return
+	}
+	company, err := o.companyRepo.GetByID(inv.CompanyID)
+	if err != nil || company == nil {
+		o.markError(inv, "fetch-company", fmt.Sprintf("empresa %s no encontrada: %v", inv.CompanyID, err))
+		return
+	}
+	creds, err := o.credentialsFor(ctx, company)
+	if err != nil {
+		o.markError(inv, "credentials", err.Error())
+		return
+	}
+	o.submit(ctx, inv, company, creds, []byte(inv.XMLSigned))
+}
+
+// heldByContingency indica si la factura de contingencia debe quedar firmada sin enviarse: su contingencia
+// sigue activa (o no se pudo consultar).
+func (o *DIANOrchestrator) heldByContingency(inv *entity.Invoice) bool {
+	if !inv.IsContingency() || inv.ContingencyID == "" || o.contingencies == nil {
+		return false
+	}
+	c, err := o.contingencies.GetByID(context.Background(), inv.ContingencyID)
+	if err != nil {
+		log.Printf("[DIAN][%s] no se pudo consultar la contingencia %s: %v", inv.ID, inv.ContingencyID, err)
+		return true
+	}
+	return c != nil && c.IsActive()
+}
+
+// submit empaqueta el XML firmado, lo envía al WS DIAN según el ambiente y persiste el resultado.
+func (o *DIANOrchestrator) submit(ctx context.Context, inv *entity.Invoice, company *entity.Company, creds *DIANCredentials, signedXMLBytes []byte) {
+	invoiceID := inv.ID
 	// ═══════════════════════════════════════════════════════════════════════════
 	// 5. Empaquetar en ZIP
 	// ═══════════════════════════════════════════════════════════════════════════
 	xmlName, zipName := infradian.DIANFilenames(company, inv)
 	zipBytes, errZIP := infradian.CompressXMLToZip(signedXMLBytes, xmlName)
 	if errZIP != nil {
-		markError(inv, "zip", errZIP.Error())
+		o.markError(inv, "zip", errZIP.Error())
 		return
 	}
 
 	// ═══════════════════════════════════════════════════════════════════════════
 	// 6. Envío condicional al WS DIAN
 	// ═══════════════════════════════════════════════════════════════════════════
-	appEnv := strings.ToLower(strings.TrimSpace(o.dianConfig.AppEnv))
+	appEnv := creds.AppEnv
 
 	var finalStatus, trackID, dianErrors string
 
@@ -303,30 +503,35 @@ func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 	case infradian.AppEnvTest, infradian.AppEnvProd:
 		// ── Modo test/prod: llamada real al WS DIAN ────────────────────────
 		if o.submitter == nil {
-			markError(inv, "soap", "DIANSubmitter no inyectado para entorno "+appEnv)
+			o.markError(inv, "soap", "DIANSubmitter no inyectado para entorno "+appEnv)
 			return
 		}
 		result, soapErr := o.submitter.SubmitZip(ctx, zipBytes, zipName, appEnv)
 		if soapErr != nil {
 			if isDIANTimeoutError(soapErr) {
-				markContingency(inv, soapErr.Error())
+				o.markContingency(inv, soapErr.Error())
 				return
 			}
-			markError(inv, "soap", soapErr.Error())
+			o.markError(inv, "soap", soapErr.Error())
 			return
 		}
 		trackID = result.TrackID
 		dianErrors = result.Errors
-		if result.Accepted {
+		switch {
+		case result.Accepted && trackID != "" && o.statusRepo != nil:
+			// Envío asíncrono recibido: la validación definitiva llega por GetStatusZip (DIANStatusWorker).
+			finalStatus = entity.DIANStatusSent
+			log.Printf("[DIAN][%s] Recibida por la DIAN → TrackID: %s (validación pendiente)", invoiceID, trackID)
+		case result.Accepted:
 			finalStatus = entity.DIANStatusExitoso
 			log.Printf("[DIAN][%s] Aceptada por la DIAN → TrackID: %s", invoiceID, trackID)
-		} else {
+		default:
 			finalStatus = entity.DIANStatusRechazado
 			log.Printf("[DIAN][%s] Rechazada por la DIAN — Errores: %s", invoiceID, dianErrors)
 		}
 
 	default:
-		markError(inv, "config", fmt.Sprintf("DIAN_ENV desconocido: %q (usar dev|test|prod)", appEnv))
+		o.markError(inv, "config", fmt.Sprintf("DIAN_ENV desconocido: %q (usar dev|test|prod)", appEnv))
 		return
 	}
 
@@ -353,6 +558,134 @@ func (o *DIANOrchestrator) process(invoiceID string, isRetry bool) {
 	}
 }
 
+// markError actualiza la factura a ERROR_GENERATION con el motivo (visible en el estado DIAN) y hace log.
+func (o *DIANOrchestrator) markError(inv *entity.Invoice, step, msg string) {
+	inv.DIAN_Status = entity.DIANStatusErrorGeneration
+	inv.DIANErrors = msg
+	inv.UpdatedAt = time.Now()
+	if err := o.invoiceRepo.Update(inv); err != nil {
+		log.Printf("[DIAN][%s] no se pudo persistir ERROR_GENERATION: %v", inv.ID, err)
+	}
+	log.Printf("[DIAN][%s] ERROR en %s: %s", inv.ID, step, msg)
+}
+
+// markContingency deja la factura en CONTINGENCIA (timeout del WS) y la encola para reintento.
+func (o *DIANOrchestrator) markContingency(inv *entity.Invoice, msg string) {
+	inv.DIAN_Status = entity.DIANStatusContingencia
+	inv.DIANErrors = msg
+	inv.UpdatedAt = time.Now()
+	if err := o.invoiceRepo.Update(inv); err != nil {
+		log.Printf("[DIAN][%s] no se pudo persistir CONTINGENCIA: %v", inv.ID, err)
+		return
+	}
+	if o.retryQueue != nil {
+		o.retryQueue.Enqueue(context.Background(), inv.ID, inv.CompanyID, msg) // ctx puede haber vencido (timeout DIAN)
+	}
+	log.Printf("[DIAN][%s] timeout DIAN: factura enviada a CONTINGENCIA y encolada", inv.ID)
+}
+
+// Backoff de las consultas GetStatusZip: 30 s, 1 min, 2 min… hasta 1 h entre consultas.
+const (
+	statusCheckBaseDelay   = 30 * time.Second
+	statusCheckMaxDelay    = time.Hour
+	statusCheckMaxAttempts = 30
+)
+
+// CheckStatus consulta GetStatusZip para un envío en estado Sent. Con resultado definitivo pasa la
+// factura a EXITOSO o RECHAZADO con las reglas de validación (y envía el correo si fue aceptada);
+// si la DIAN aún no responde reprograma la consulta con backoff y, agotados los intentos, la deja en Error.
+func (o *DIANOrchestrator) CheckStatus(ctx context.Context, check *entity.DIANStatusCheck) error {
+	if o.submitter == nil || o.statusRepo == nil {
+		return nil
+	}
+	appEnv := o.statusCheckEnv(ctx, check)
+	attempts := check.Attempts + 1
+
+	res, err := o.submitter.GetStatusZip(ctx, check.TrackID, appEnv)
+	if err != nil {
+		log.Printf("[DIAN][%s] GetStatusZip falló (intento %d): %v", check.InvoiceID, attempts, err)
+	}
+	if err == nil && !res.IsPending() {
+		status, dianErrors, rules := validationOutcome(res)
+		if err := o.statusRepo.SaveValidationResult(ctx, check.InvoiceID, status, dianErrors, rules, res.ApplicationResponse); err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				return nil // ya resuelta por otra consulta
+			}
+			return err
+		}
+		log.Printf("[DIAN][%s] validación DIAN → %s (TrackID: %s, %d regla(s))", check.InvoiceID, status, check.TrackID, len(rules))
+		if status == entity.DIANStatusExitoso && o.mailer != nil {
+			o.mailer.SendInvoiceEmail(check.InvoiceID)
+		}
+		return nil
+	}
+
+	if attempts >= statusCheckMaxAttempts {
+		msg := fmt.Sprintf("sin resultado definitivo de la DIAN tras %d consultas del TrackID %s", attempts, check.TrackID)
+		log.Printf("[DIAN][%s] %s", check.InvoiceID, msg)
+		if err := o.statusRepo.SaveValidationResult(ctx, check.InvoiceID, entity.DIANStatusError, msg, nil, nil); err != nil && !errors.Is(err, domain.ErrConflict) {
+			return err
+		}
+		return nil
+	}
+	return o.statusRepo.ScheduleStatusCheck(ctx, check.InvoiceID, attempts, time.Now().Add(statusCheckBackoff(attempts)))
+}
+
+// statusCheckEnv ambiente DIAN donde se envió el documento: el de la empresa si hay credenciales por
+// empresa, si no (o si ya no se pueden resolver) el global.
+func (o *DIANOrchestrator) statusCheckEnv(ctx context.Context, check *entity.DIANStatusCheck) string {
+	if o.credentials != nil {
+		company, err := o.companyRepo.GetByID(check.CompanyID)
+		if err == nil && company != nil {
+			creds, err := o.credentials.Resolve(ctx, company)
+			if err == nil {
+				return creds.AppEnv
+			}
+			log.Printf("[DIAN][%s] credenciales de la empresa %s: %v", check.InvoiceID, check.CompanyID, err)
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(o.dianConfig.AppEnv))
+}
+
+// statusCheckBackoff espera antes de la siguiente consulta tras attempts consultas sin resultado.
+func statusCheckBackoff(attempts int) time.Duration {
+	delay := statusCheckBaseDelay
+	for i := 1; i < attempts && delay < statusCheckMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > statusCheckMaxDelay {
+		delay = statusCheckMaxDelay
+	}
+	return delay
+}
+
+// validationOutcome traduce la respuesta definitiva de GetStatusZip en estado, resumen de rechazos
+// y reglas (de ErrorMessage y del ApplicationResponse). El código 04 del ApplicationResponse prima
+// sobre IsValid.
+func validationOutcome(res *infradian.StatusResult) (string, string, []entity.DIANValidationRule) {
+	rules := domaindian.ParseValidationMessages(res.ErrorMessages)
+	accepted := res.IsValid && res.StatusCode == infradian.StatusCodeProcessed
+	if len(res.ApplicationResponse) > 0 {
+		code, arRules, err := domaindian.ParseApplicationResponse(res.ApplicationResponse)
+		if err != nil {
+			log.Printf("[DIAN] ApplicationResponse ilegible: %v", err)
+		} else {
+			rules = domaindian.MergeValidationRules(rules, arRules)
+			if code == domaindian.ApplicationResponseRejected {
+				accepted = false
+			}
+		}
+	}
+	if accepted {
+		return entity.DIANStatusExitoso, "", rules
+	}
+	dianErrors := domaindian.FormatRejections(rules)
+	if dianErrors == "" {
+		dianErrors = strings.TrimSpace(res.StatusDescription + " " + res.StatusMessage)
+	}
+	return entity.DIANStatusRechazado, dianErrors, rules
+}
+
 func isDIANTimeoutError(err error) bool {
 	if err == nil {
 		return false
@@ -372,6 +705,38 @@ func isDIANTimeoutError(err error) bool {
 
 // ── helpers privados ──────────────────────────────────────────────────────────
 
+// credentialsFor credenciales DIAN de la empresa; sin provider usa la configuración global (DIANConfig).
+func (o *DIANOrchestrator) credentialsFor(ctx context.Context, company *entity.Company) (*DIANCredentials, error) {
+	if o.credentials != nil {
+		return o.credentials.Resolve(ctx, company)
+	}
+	cert, err := loadCertificate(o.dianConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(cert.Certificate) == 0 || cert.PrivateKey == nil {
+		return nil, errors.New("certificado vacío: verifica DIAN_CERT_PATH y DIAN_CERT_PASSWORD")
+	}
+	tipoAmb := o.dianConfig.Environment
+	if tipoAmb == "" {
+		tipoAmb = "2"
+	}
+	return &DIANCredentials{
+		AppEnv:       strings.ToLower(strings.TrimSpace(o.dianConfig.AppEnv)),
+		TipoAmbiente: tipoAmb,
+		TechnicalKey: o.dianConfig.TechnicalKey,
+		Certificate:  cert,
+	}, nil
+}
+
+// softwareSecurityCode SHA-384 de SoftwareID + PIN + número del documento; vacío sin software registrado.
+func softwareSecurityCode(creds *DIANCredentials, inv *entity.Invoice) string {
+	if creds.SoftwareID == "" || creds.SoftwarePIN == "" {
+		return ""
+	}
+	return infradian.SoftwareSecurityCode(creds.SoftwareID, creds.SoftwarePIN, strings.TrimSpace(inv.Prefix)+strings.TrimSpace(inv.Number))
+}
+
 func loadCertificate(cfg DIANConfig) (tls.Certificate, error) {
 	if cfg.CertPath == "" {
 		return tls.Certificate{}, fmt.Errorf("DIAN_CERT_PATH no configurado")
@@ -383,6 +748,33 @@ func loadCertificate(cfg DIANConfig) (tls.Certificate, error) {
 	return infradian.LoadCertFromPEM(cfg.CertPath, cfg.CertKeyPath)
 }
 
+// xmlDocumentType raíz UBL del documento: las notas crédito y débito tienen la propia; la factura y el
+// documento equivalente POS usan Invoice.
+func xmlDocumentType(inv *entity.Invoice) string {
+	switch inv.DocumentType {
+	case "CREDIT_NOTE", "DEBIT_NOTE":
+		return inv.DocumentType
+	}
+	return "INVOICE"
+}
+
+// originalIssueDate fecha de emisión de la factura que afecta la nota (AAAA-MM-DD); vacío si no aplica.
+func originalIssueDate(inv *entity.Invoice) string {
+	if inv.OriginalInvoiceIssueOn.IsZero() {
+		return ""
+	}
+	return inv.OriginalInvoiceIssueOn.Format("2006-01-02")
+}
+
+// customerIdentTypeCode usa el tipo de identificación registrado en el cliente; si no tiene,
+// lo infiere del número (NIT o cédula).
+func customerIdentTypeCode(customer *entity.Customer) string {
+	if customer.IdentificationType != "" {
+		return customer.IdentificationType
+	}
+	return identTypeCode(customer.TaxID)
+}
+
 func identTypeCode(taxID string) string {
 	digits := strings.Map(func(r rune) rune {
 		if r >= '0' && r <= '9' {
@@ -1,53 +1,140 @@
 package billing
 
-import "sync"
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
 
-// DIANRetryQueue mantiene una cola en memoria de facturas en CONTINGENCIA.
-// Deduplica IDs para evitar reintentos duplicados innecesarios.
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+// Backoff de reintentos en contingencia: 1 min, 2 min, 4 min… hasta 6 h entre intentos.
+const (
+	retryBaseDelay          = time.Minute
+	retryMaxDelay           = 6 * time.Hour
+	defaultRetryMaxAttempts = 10
+)
+
+// retryClaimTimeout tiempo tras el cual un trabajo RUNNING sin actividad se da por abandonado (el worker
+// se cayó a mitad del lote) y otro lo puede tomar. Cubre un lote completo de envíos con timeout.
+const retryClaimTimeout = 30 * time.Minute
+
+// DIANRetryQueue cola persistente (dian_retry_jobs) de facturas en CONTINGENCIA.
+// Sobrevive a reinicios: cada factura tiene una sola fila con sus intentos y el próximo intento.
 type DIANRetryQueue struct {
-	mu      sync.Mutex
-	items   []string
-	indexed map[string]struct{}
+	repo        DIANRetryJobRepository
+	maxAttempts int
 }
 
-func NewDIANRetryQueue(initialCapacity int) *DIANRetryQueue {
-	if initialCapacity <= 0 {
-		initialCapacity = 64
-	}
-	return &DIANRetryQueue{
-		items:   make([]string, 0, initialCapacity),
-		indexed: make(map[string]struct{}, initialCapacity),
+// NewDIANRetryQueue construye la cola. Tras maxAttempts intentos fallidos el trabajo pasa a DEAD.
+func NewDIANRetryQueue(repo DIANRetryJobRepository, maxAttempts int) *DIANRetryQueue {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryMaxAttempts
 	}
+	return &DIANRetryQueue{repo: repo, maxAttempts: maxAttempts}
 }
 
-func (q *DIANRetryQueue) Enqueue(invoiceID string) {
+// Enqueue encola la factura para un primer reintento tras retryBaseDelay. Los errores solo se
+// registran: la factura sigue en CONTINGENCIA y Recover la vuelve a encolar al arrancar.
+func (q *DIANRetryQueue) Enqueue(ctx context.Context, invoiceID, companyID, reason string) {
 	if invoiceID == "" {
 		return
 	}
-	q.mu.Lock()
-	defer q.mu.Unlock()
-	if _, exists := q.indexed[invoiceID]; exists {
-		return
+	if err := q.repo.Enqueue(ctx, invoiceID, companyID, reason, time.Now().Add(retryBaseDelay)); err != nil {
+		log.Printf("[DIAN][%s] no se pudo encolar el reintento: %v", invoiceID, err)
 	}
-	q.items = append(q.items, invoiceID)
-	q.indexed[invoiceID] = struct{}{}
 }
 
-func (q *DIANRetryQueue) Drain(max int) []string {
-	q.mu.Lock()
-	defer q.mu.Unlock()
+// Recover encola las facturas en CONTINGENCIA sin trabajo (p. ej. tras un reinicio o una caída
+// de la base de datos al encolar).
+func (q *DIANRetryQueue) Recover(ctx context.Context) (int, error) {
+	return q.repo.EnqueueContingent(ctx, time.Now())
+}
 
-	if len(q.items) == 0 {
-		return nil
+// Due limpia los trabajos ya resueltos y toma (RUNNING) los que toca reintentar: varias instancias
+// pueden consultar a la vez sin reintentar dos veces la misma factura. Los que agotaron sus intentos
+// pasan a DEAD; a los demás se les registra el intento y su próximo reintento antes de devolverlos,
+// de modo que un fallo del proceso no los reintente en bucle. Tras el reintento se llama Release.
+func (q *DIANRetryQueue) Due(ctx context.Context, now time.Time, limit int) ([]*entity.DIANRetryJob, error) {
+	if _, err := q.repo.DeleteResolved(ctx); err != nil {
+		return nil, err
+	}
+	jobs, err := q.repo.ClaimDue(ctx, now, now.Add(-retryClaimTimeout), limit)
+	if err != nil {
+		return nil, err
 	}
-	if max <= 0 || max > len(q.items) {
-		max = len(q.items)
+	due := make([]*entity.DIANRetryJob, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Attempts >= q.maxAttempts {
+			reason := strings.TrimSpace(job.LastError + " (intentos agotados)")
+			if err := q.repo.MarkDead(ctx, job.InvoiceID, reason); err != nil {
+				return nil, err
+			}
+			log.Printf("[DIAN][%s] reintentos agotados (%d): trabajo en DEAD", job.InvoiceID, job.Attempts)
+			continue
+		}
+		job.Attempts++
+		job.NextAttemptAt = now.Add(retryBackoff(job.Attempts))
+		if err := q.repo.RecordAttempt(ctx, job.InvoiceID, job.Attempts, job.NextAttemptAt); err != nil {
+			return nil, err
+		}
+		due = append(due, job)
 	}
+	return due, nil
+}
 
-	batch := append([]string(nil), q.items[:max]...)
-	q.items = q.items[max:]
-	for _, id := range batch {
-		delete(q.indexed, id)
+// Release devuelve el trabajo a la cola tras su reintento. Si la factura volvió a CONTINGENCIA,
+// Enqueue solo actualizó el error (el trabajo sigue RUNNING hasta aquí); si salió de ella, Due lo
+// elimina en la siguiente pasada.
+func (q *DIANRetryQueue) Release(ctx context.Context, invoiceID string) {
+	if err := q.repo.Release(ctx, invoiceID); err != nil {
+		log.Printf("[DIAN][%s] no se pudo liberar el trabajo de reintento: %v", invoiceID, err)
+	}
+}
+
+// List devuelve los trabajos de la empresa; status: QUEUED, RUNNING, DEAD o vacío (todos).
+func (q *DIANRetryQueue) List(ctx context.Context, companyID, status string) ([]dto.DIANRetryJobDTO, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	validStatus := status == "" || status == entity.DIANRetryJobQueued || status == entity.DIANRetryJobRunning || status == entity.DIANRetryJobDead
+	if companyID == "" || !validStatus {
+		return nil, domain.ErrInvalidInput
+	}
+	jobs, err := q.repo.List(ctx, companyID, status)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.DIANRetryJobDTO, 0, len(jobs))
+	for _, j := range jobs {
+		item := dto.DIANRetryJobDTO{
+			InvoiceID:     j.InvoiceID,
+			InvoiceNumber: j.InvoiceNumber,
+			Status:        j.Status,
+			Attempts:      j.Attempts,
+			MaxAttempts:   q.maxAttempts,
+			LastError:     j.LastError,
+			CreatedAt:     j.CreatedAt,
+			UpdatedAt:     j.UpdatedAt,
+		}
+		if j.Status != entity.DIANRetryJobDead {
+			next := j.NextAttemptAt
+			item.NextAttemptAt = &next
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// retryBackoff espera antes del siguiente reintento tras attempts intentos.
+func retryBackoff(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
 	}
-	return batch
+	return delay
 }
@@ -0,0 +1,181 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+// fakeRetryJobRepo cola en memoria con la semántica de dian_retry_jobs.
+type fakeRetryJobRepo struct {
+	jobs     map[string]*entity.DIANRetryJob
+	resolved map[string]bool // facturas que ya salieron de CONTINGENCIA
+}
+
+func newFakeRetryJobRepo() *fakeRetryJobRepo {
+	return &fakeRetryJobRepo{jobs: map[string]*entity.DIANRetryJob{}, resolved: map[string]bool{}}
+}
+
+func (f *fakeRetryJobRepo) Enqueue(_ context.Context, invoiceID, companyID, lastError string, next time.Time) error {
+	if j, ok := f.jobs[invoiceID]; ok {
+		j.LastError = lastError
+		if j.Status == entity.DIANRetryJobDead {
+			j.Attempts, j.NextAttemptAt = 0, next
+		}
+		if j.Status != entity.DIANRetryJobRunning {
+			j.Status = entity.DIANRetryJobQueued
+		}
+		return nil
+	}
+	f.jobs[invoiceID] = &entity.DIANRetryJob{InvoiceID: invoiceID, CompanyID: companyID, Status: entity.DIANRetryJobQueued, NextAttemptAt: next, LastError: lastError}
+	return nil
+}
+func (f *fakeRetryJobRepo) EnqueueContingent(context.Context, time.Time) (int, error) { return 0, nil }
+func (f *fakeRetryJobRepo) DeleteResolved(context.Context) (int, error) {
+	n := 0
+	for id := range f.jobs {
+		if f.resolved[id] {
+			delete(f.jobs, id)
+			n++
+		}
+	}
+	return n, nil
+}
+func (f *fakeRetryJobRepo) ClaimDue(_ context.Context, now, staleBefore time.Time, _ int) ([]*entity.DIANRetryJob, error) {
+	var out []*entity.DIANRetryJob
+	for _, j := range f.jobs {
+		due := j.Status == entity.DIANRetryJobQueued && !j.NextAttemptAt.After(now)
+		stale := j.Status == entity.DIANRetryJobRunning && j.UpdatedAt.Before(staleBefore)
+		if due || stale {
+			j.Status, j.UpdatedAt = entity.DIANRetryJobRunning, now
+			cp := *j
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+func (f *fakeRetryJobRepo) Release(_ context.Context, invoiceID string) error {
+	if j, ok := f.jobs[invoiceID]; ok && j.Status == entity.DIANRetryJobRunning {
+		j.Status = entity.DIANRetryJobQueued
+	}
+	return nil
+}
+func (f *fakeRetryJobRepo) RecordAttempt(_ context.Context, invoiceID string, attempts int, next time.Time) error {
+	f.jobs[invoiceID].Attempts, f.jobs[invoiceID].NextAttemptAt = attempts, next
+	return nil
+}
+func (f *fakeRetryJobRepo) MarkDead(_ context.Context, invoiceID, reason string) error {
+	f.jobs[invoiceID].Status, f.jobs[invoiceID].LastError = entity.DIANRetryJobDead, reason
+	return nil
+}
+func (f *fakeRetryJobRepo) List(_ context.Context, companyID, status string) ([]*entity.DIANRetryJob, error) {
+	var out []*entity.DIANRetryJob
+	for _, j := range f.jobs {
+		if j.CompanyID == companyID && (status == "" || j.Status == status) {
+			out = append(out, j)
+		}
+	}
+	return out, nil
+}
+
+var _ DIANRetryJobRepository = (*fakeRetryJobRepo)(nil)
+
+func TestDIANRetryQueue_BackoffAndDeadLetter(t *testing.T) {
+	repo := newFakeRetryJobRepo()
+	q := NewDIANRetryQueue(repo, 3)
+	ctx := context.Background()
+
+	q.Enqueue(ctx, "inv-1", testCompanyID, "timeout")
+	now := time.Now()
+
+	due, err := q.Due(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "el primer reintento espera retryBaseDelay")
+
+	now = now.Add(retryBaseDelay)
+	for attempt := 1; attempt <= 3; attempt++ {
+		due, err = q.Due(ctx, now, 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1, "intento %d", attempt)
+		assert.Equal(t, attempt, due[0].Attempts)
+		assert.Equal(t, now.Add(retryBackoff(attempt)), repo.jobs["inv-1"].NextAttemptAt)
+		q.Enqueue(ctx, "inv-1", testCompanyID, "timeout de nuevo") // el reintento vuelve a caer en contingencia
+		q.Release(ctx, "inv-1")
+		now = now.Add(retryBackoff(attempt))
+	}
+
+	due, err = q.Due(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	assert.Equal(t, entity.DIANRetryJobDead, repo.jobs["inv-1"].Status)
+
+	dead, err := q.List(ctx, testCompanyID, "dead")
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Nil(t, dead[0].NextAttemptAt)
+	assert.Equal(t, 3, dead[0].MaxAttempts)
+
+	// Un nuevo timeout (reintento manual) reactiva el trabajo.
+	q.Enqueue(ctx, "inv-1", testCompanyID, "timeout")
+	assert.Equal(t, entity.DIANRetryJobQueued, repo.jobs["inv-1"].Status)
+	assert.Zero(t, repo.jobs["inv-1"].Attempts)
+
+	// Resuelta la factura, el trabajo desaparece.
+	repo.resolved["inv-1"] = true
+	_, err = q.Due(ctx, now.Add(retryMaxDelay), 10)
+	require.NoError(t, err)
+	assert.Empty(t, repo.jobs)
+
+	_, err = q.List(ctx, testCompanyID, "OTRO")
+	assert.True(t, errors.Is(err, domain.ErrInvalidInput))
+
+	assert.Equal(t, time.Minute, retryBackoff(1))
+	assert.Equal(t, 4*time.Minute, retryBackoff(3))
+	assert.Equal(t, retryMaxDelay, retryBackoff(30))
+}
+
+func TestDIANRetryQueue_ClaimsEachJobOnce(t *testing.T) {
+	repo := newFakeRetryJobRepo()
+	q := NewDIANRetryQueue(repo, 5)
+	ctx := context.Background()
+	q.Enqueue(ctx, "inv-1", testCompanyID, "timeout")
+	now := time.Now().Add(retryBaseDelay)
+
+	due, err := q.Due(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, entity.DIANRetryJobRunning, repo.jobs["inv-1"].Status)
+
+	// Otra instancia consulta mientras el reintento sigue en curso, aun después de su próximo intento.
+	due, err = q.Due(ctx, now.Add(retryBackoff(1)), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "un trabajo RUNNING no se entrega dos veces")
+
+	running, err := q.List(ctx, testCompanyID, "running")
+	require.NoError(t, err)
+	require.Len(t, running, 1)
+	assert.NotNil(t, running[0].NextAttemptAt)
+
+	// Si el worker se cae sin liberarlo, se vuelve a tomar pasado retryClaimTimeout.
+	due, err = q.Due(ctx, now.Add(retryClaimTimeout+time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 2, due[0].Attempts)
+
+	// El reintento vuelve a fallar y el orquestador re-encola: el trabajo sigue reservado.
+	q.Enqueue(ctx, "inv-1", testCompanyID, "timeout")
+	assert.Equal(t, entity.DIANRetryJobRunning, repo.jobs["inv-1"].Status)
+	due, err = q.Due(ctx, now.Add(retryClaimTimeout+time.Second), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "re-encolar no libera un trabajo RUNNING")
+
+	q.Release(ctx, "inv-1")
+	assert.Equal(t, entity.DIANRetryJobQueued, repo.jobs["inv-1"].Status)
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 )
 
-// DIANRetryWorker procesa la cola de facturas en CONTINGENCIA cada intervalo.
+// DIANRetryWorker reintenta las facturas en CONTINGENCIA según la cola persistente: al arrancar
+// encola las que hayan quedado sin trabajo y en cada intervalo procesa las que tienen el próximo
+// intento vencido.
 type DIANRetryWorker struct {
 	orchestrator *DIANOrchestrator
 	queue        *DIANRetryQueue
@@ -16,7 +18,7 @@ type DIANRetryWorker struct {
 
 func NewDIANRetryWorker(orchestrator *DIANOrchestrator, queue *DIANRetryQueue, interval time.Duration, batchSize int) *DIANRetryWorker {
 	if interval <= 0 {
-		interval = 15 * time.Minute
+		interval = time.Minute
 	}
 	if batchSize <= 0 {
 		batchSize = 50
@@ -34,6 +36,12 @@ func (w *DIANRetryWorker) Start(ctx context.Context) {
 		return
 	}
 
+	if n, err := w.queue.Recover(ctx); err != nil {
+		log.Printf("[DIAN][WORKER] no se pudieron recuperar facturas en contingencia: %v", err)
+	} else if n > 0 {
+		log.Printf("[DIAN][WORKER] %d factura(s) en contingencia encolada(s) al arrancar", n)
+	}
+
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
@@ -42,19 +50,27 @@ func (w *DIANRetryWorker) Start(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			w.runOnce()
+			w.runOnce(ctx)
 		}
 	}
 }
 
-func (w *DIANRetryWorker) runOnce() {
-	ids := w.queue.Drain(w.batchSize)
-	if len(ids) == 0 {
+func (w *DIANRetryWorker) runOnce(ctx context.Context) {
+	jobs, err := w.queue.Due(ctx, time.Now(), w.batchSize)
+	if err != nil {
+		log.Printf("[DIAN][WORKER] no se pudo leer la cola de reintentos: %v", err)
+		return
+	}
+	if len(jobs) == 0 {
 		return
 	}
 
-	log.Printf("[DIAN][WORKER] reintentando %d factura(s) en contingencia", len(ids))
-	for _, invoiceID := range ids {
-		w.orchestrator.RetryAsync(invoiceID)
+	log.Printf("[DIAN][WORKER] reintentando %d factura(s) en contingencia", len(jobs))
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		w.orchestrator.RetrySync(job.InvoiceID)
+		w.queue.Release(ctx, job.InvoiceID)
 	}
 }
@@ -0,0 +1,69 @@
+package billing
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DIANStatusWorker consulta periódicamente GetStatusZip para los envíos asíncronos en estado Sent
+// y registra el resultado definitivo de la DIAN (EXITOSO o RECHAZADO).
+type DIANStatusWorker struct {
+	orchestrator *DIANOrchestrator
+	repo         DIANStatusRepository
+	interval     time.Duration
+	batchSize    int
+}
+
+func NewDIANStatusWorker(orchestrator *DIANOrchestrator, repo DIANStatusRepository, interval time.Duration, batchSize int) *DIANStatusWorker {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	return &DIANStatusWorker{
+		orchestrator: orchestrator,
+		repo:         repo,
+		interval:     interval,
+		batchSize:    batchSize,
+	}
+}
+
+func (w *DIANStatusWorker) Start(ctx context.Context) {
+	if w.orchestrator == nil || w.repo == nil {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+func (w *DIANStatusWorker) runOnce(ctx context.Context) {
+	checks, err := w.repo.ListAwaitingStatus(ctx, time.Now(), w.batchSize)
+	if err != nil {
+		log.Printf("[DIAN][STATUS] no se pudieron listar envíos pendientes: %v", err)
+		return
+	}
+	if len(checks) == 0 {
+		return
+	}
+
+	log.Printf("[DIAN][STATUS] consultando %d envío(s) pendiente(s) de validación", len(checks))
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		if err := w.orchestrator.CheckStatus(checkCtx, check); err != nil {
+			log.Printf("[DIAN][%s] error registrando estado DIAN: %v", check.InvoiceID, err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,110 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+)
+
+type fakeStatusSubmitter struct {
+	status *infradian.StatusResult
+	err    error
+}
+
+func (f *fakeStatusSubmitter) SubmitZip(context.Context, []byte, string, string) (*infradian.SubmitResult, error) {
+	return nil, errors.New("no usado")
+}
+func (f *fakeStatusSubmitter) GetStatusZip(context.Context, string, string) (*infradian.StatusResult, error) {
+	return f.status, f.err
+}
+
+type fakeDIANStatusRepo struct {
+	status    string
+	errors    string
+	rules     []entity.DIANValidationRule
+	appResp   []byte
+	attempts  int
+	nextCheck time.Time
+}
+
+func (f *fakeDIANStatusRepo) ListAwaitingStatus(context.Context, time.Time, int) ([]*entity.DIANStatusCheck, error) {
+	return nil, nil
+}
+func (f *fakeDIANStatusRepo) ScheduleStatusCheck(_ context.Context, _ string, attempts int, next time.Time) error {
+	f.attempts, f.nextCheck = attempts, next
+	return nil
+}
+func (f *fakeDIANStatusRepo) SaveValidationResult(_ context.Context, _ string, status, errs string, rules []entity.DIANValidationRule, appResp []byte) error {
+	f.status, f.errors, f.rules, f.appResp = status, errs, rules, appResp
+	return nil
+}
+
+var _ DIANStatusRepository = (*fakeDIANStatusRepo)(nil)
+
+type fakeMailer struct{ sent []string }
+
+func (f *fakeMailer) SendInvoiceEmail(invoiceID string) { f.sent = append(f.sent, invoiceID) }
+
+func TestDIANOrchestrator_CheckStatus(t *testing.T) {
+	newOrchestrator := func(sub *fakeStatusSubmitter) (*DIANOrchestrator, *fakeDIANStatusRepo, *fakeMailer) {
+		repo := &fakeDIANStatusRepo{}
+		mailer := &fakeMailer{}
+		o := NewDIANOrchestrator(nil, nil, nil, nil, nil, nil, nil, sub, DIANConfig{AppEnv: "test"})
+		o.SetStatusRepository(repo)
+		o.SetMailer(mailer)
+		return o, repo, mailer
+	}
+	check := &entity.DIANStatusCheck{InvoiceID: "inv-1", TrackID: "track-1", Attempts: 2}
+
+	t.Run("validada con notificaciones", func(t *testing.T) {
+		o, repo, mailer := newOrchestrator(&fakeStatusSubmitter{status: &infradian.StatusResult{
+			IsValid: true, StatusCode: infradian.StatusCodeProcessed,
+			ErrorMessages: []string{"Regla: FAJ44b, Notificación: Nit no registrado en el RUT"},
+		}})
+		require.NoError(t, o.CheckStatus(context.Background(), check))
+		assert.Equal(t, entity.DIANStatusExitoso, repo.status)
+		assert.Empty(t, repo.errors)
+		require.Len(t, repo.rules, 1)
+		assert.Equal(t, entity.DIANRuleNotification, repo.rules[0].Severity)
+		assert.Equal(t, []string{"inv-1"}, mailer.sent)
+	})
+
+	t.Run("rechazada con reglas estructuradas", func(t *testing.T) {
+		appResponse := `<ApplicationResponse><DocumentResponse><Response><ResponseCode>04</ResponseCode></Response>
+			<LineResponse><Response><ResponseCode>FAD06</ResponseCode><Description>NIT inválido</Description></Response></LineResponse>
+			</DocumentResponse></ApplicationResponse>`
+		o, repo, mailer := newOrchestrator(&fakeStatusSubmitter{status: &infradian.StatusResult{
+			StatusCode: infradian.StatusCodeValidationErrs, ApplicationResponse: []byte(appResponse),
+			ErrorMessages: []string{"Regla: FAD06, Rechazo: NIT inválido", "Regla: FAK24, Rechazo: Dirección requerida"},
+		}})
+		require.NoError(t, o.CheckStatus(context.Background(), check))
+		assert.Equal(t, entity.DIANStatusRechazado, repo.status)
+		assert.Equal(t, "FAD06: NIT inválido; FAK24: Dirección requerida", repo.errors)
+		assert.Len(t, repo.rules, 2)
+		assert.Equal(t, appResponse, string(repo.appResp))
+		assert.Empty(t, mailer.sent)
+	})
+
+	t.Run("pendiente reprograma con backoff", func(t *testing.T) {
+		o, repo, _ := newOrchestrator(&fakeStatusSubmitter{status: &infradian.StatusResult{StatusCode: infradian.StatusCodeInValidation}})
+		before := time.Now()
+		require.NoError(t, o.CheckStatus(context.Background(), check))
+		assert.Empty(t, repo.status)
+		assert.Equal(t, 3, repo.attempts)
+		assert.WithinDuration(t, before.Add(2*time.Minute), repo.nextCheck, 5*time.Second)
+
+		o, repo, _ = newOrchestrator(&fakeStatusSubmitter{err: errors.New("soap: timeout")})
+		require.NoError(t, o.CheckStatus(context.Background(), &entity.DIANStatusCheck{InvoiceID: "inv-2", TrackID: "t", Attempts: statusCheckMaxAttempts - 1}))
+		assert.Equal(t, entity.DIANStatusError, repo.status)
+	})
+
+	assert.Equal(t, 30*time.Second, statusCheckBackoff(1))
+	assert.Equal(t, time.Hour, statusCheckBackoff(20))
+}
@@ -0,0 +1,243 @@
+package billing
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+)
+
+// testXMLValidator valida contra los XSD oficiales de DIAN_XSD_DIR o, sin ellos (las pruebas corren sin red),
+// contra testdata/ubl-subset: un subconjunto de UBL 2.1 con los elementos que emite el builder, que solo
+// cubre la integración con xmllint y las reglas DIAN.
+func testXMLValidator(t *testing.T) *infradian.XMLValidatorService {
+	t.Helper()
+	if _, err := exec.LookPath("xmllint"); err != nil {
+		t.Skip("xmllint no está instalado")
+	}
+	dir := os.Getenv("DIAN_XSD_DIR")
+	if dir == "" {
+		dir = "../../infrastructure/dian/testdata/ubl-subset"
+	}
+	v, err := infradian.NewXMLValidatorService(dir)
+	require.NoError(t, err)
+	return v
+}
+
+// officialXSDDir directorio xsd/ del paquete os-UBL-2.1 de OASIS (scripts/fetch-ubl-xsd.sh) en DIAN_XSD_DIR.
+// Salta la prueba si no está: el subconjunto de testdata no sirve para contrastar con UBL real.
+func officialXSDDir(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("xmllint"); err != nil {
+		t.Skip("xmllint no está instalado")
+	}
+	dir := os.Getenv("DIAN_XSD_DIR")
+	for _, f := range []string{"maindoc/UBL-Invoice-2.1.xsd", "common/UBL-CommonAggregateComponents-2.1.xsd"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); dir == "" || err != nil {
+			t.Skip("DIAN_XSD_DIR no apunta a los XSD oficiales de UBL 2.1 (scripts/fetch-ubl-xsd.sh)")
+		}
+	}
+	return dir
+}
+
+// validationFixture orquestador con validación local sobre una factura (o nota) de prueba en memoria.
+type validationFixture struct {
+	orch     *DIANOrchestrator
+	invoice  *entity.Invoice
+	company  *entity.Company
+	customer *entity.Customer
+}
+
+func newValidationFixture(validator *infradian.XMLValidatorService, docType string) *validationFixture {
+	product := validProduct(testCompanyID, testProductID1, decimal.NewFromInt(10000), decimal.NewFromInt(19))
+	f := &validationFixture{company: validCompany(testCompanyID), customer: validCustomer(testCompanyID)}
+	f.company.NIT = "900111222-1"
+	f.invoice = &entity.Invoice{
+		ID: "inv-1", CompanyID: testCompanyID, CustomerID: testCustomerID, Prefix: "SETP", Number: "1001",
+		Date: time.Now(), NetTotal: decimal.NewFromInt(20000), TaxTotal: decimal.NewFromInt(3800),
+		GrandTotal: decimal.NewFromInt(23800), DIAN_Status: entity.DIANStatusDraft, DocumentType: docType,
+	}
+	if docType == "CREDIT_NOTE" || docType == "DEBIT_NOTE" {
+		f.invoice.Prefix, f.invoice.Number = "NC", "1"
+		f.invoice.OriginalInvoiceNumber = "SETP1000"
+		f.invoice.OriginalInvoiceCUFE = "cufe-original"
+		f.invoice.OriginalInvoiceIssueOn = time.Now().AddDate(0, 0, -1)
+		f.invoice.DiscrepancyCode = entity.CreditNoteConceptOtros
+		f.invoice.DiscrepancyReason = "Devolución"
+	}
+	details := []*entity.InvoiceDetail{{
+		ID: "det-1", InvoiceID: "inv-1", ProductID: testProductID1, Quantity: decimal.NewFromInt(2),
+		UnitPrice: decimal.NewFromInt(10000), TaxRate: decimal.NewFromInt(19), Subtotal: decimal.NewFromInt(20000),
+	}}
+	invoiceRepo := &fakeInvoiceRepo{
+		getByIDFunc:               func(string) (*entity.Invoice, error) { return f.invoice, nil },
+		getDetailsByInvoiceIDFunc: func(string) ([]*entity.InvoiceDetail, error) { return details, nil },
+		updateFunc:                func(inv *entity.Invoice) error { f.invoice = inv; return nil },
+	}
+	var resolution *entity.BillingResolution
+	if docType != "CREDIT_NOTE" && docType != "DEBIT_NOTE" {
+		resolution = validResolution(testCompanyID, "SETP")
+	}
+	f.orch = NewDIANOrchestrator(invoiceRepo,
+		&fakeCompanyRepo{getByIDFunc: func(string) (*entity.Company, error) { return f.company, nil }},
+		&fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return f.customer, nil }},
+		&fakeProductRepo{getByIDFunc: func(string) (*entity.Product, error) { return product, nil }},
+		&fakeResolutionRepo{res: resolution}, infradian.NewXMLBuilderService(), fakeSigner{}, nil, DIANConfig{})
+	f.orch.SetCredentialsProvider(&fakeCredentials{creds: &DIANCredentials{
+		AppEnv: "dev", TipoAmbiente: "2", TechnicalKey: "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c", SoftwareID: "sw-1", SoftwarePIN: "12345",
+	}})
+	f.orch.SetXMLValidator(validator)
+	return f
+}
+
+func TestDIANOrchestrator_LocalValidation(t *testing.T) {
+	validator := testXMLValidator(t)
+
+	t.Run("el XML generado pasa esquema y reglas", func(t *testing.T) {
+		f := newValidationFixture(validator, "INVOICE")
+		f.orch.ProcessSync("inv-1")
+
+		assert.Equal(t, entity.DIANStatusExitoso, f.invoice.DIAN_Status, f.invoice.DIANErrors)
+		assert.Contains(t, f.invoice.XMLSigned, ">2</ProfileExecutionID>")
+		// El NIT del cliente sin dígito de verificación solo se notifica.
+		require.Len(t, f.invoice.DIANRules, 1)
+		assert.Equal(t, domaindian.RuleCustomerNIT, f.invoice.DIANRules[0].Code)
+		assert.Equal(t, entity.DIANRuleNotification, f.invoice.DIANRules[0].Severity)
+	})
+
+	t.Run("nota crédito con su propia raíz UBL", func(t *testing.T) {
+		f := newValidationFixture(validator, "CREDIT_NOTE")
+		f.orch.ProcessSync("inv-1")
+
+		assert.Equal(t, entity.DIANStatusExitoso, f.invoice.DIAN_Status, f.invoice.DIANErrors)
+		assert.Contains(t, f.invoice.XMLSigned, "<CreditNote ")
+		assert.Contains(t, f.invoice.XMLSigned, "SETP1000")
+	})
+
+	// Todo documento que arma el orquestador debe pasar esquema y reglas sin errores.
+	t.Run("todos los tipos de documento pasan esquema y reglas", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			docType string
+			setup   func(f *validationFixture)
+			root    string
+			marks   []string // fragmentos propios del tipo que deben quedar en el XML
+		}{
+			{name: "factura de venta 01", docType: "INVOICE", root: "<Invoice "},
+			{name: "nota crédito", docType: "CREDIT_NOTE", root: "<CreditNote "},
+			{name: "nota débito", docType: "DEBIT_NOTE", root: "<DebitNote "},
+			{name: "documento equivalente POS 20", docType: entity.DocumentTypePOS, root: "<Invoice ", marks: []string{">20</InvoiceTypeCode>"}, setup: func(f *validationFixture) {
+				f.invoice.InvoiceTypeCode = "20"
+			}},
+			{name: "contingencia 03", docType: "INVOICE", root: "<Invoice ", marks: []string{">03</InvoiceTypeCode>"}, setup: func(f *validationFixture) {
+				f.invoice.InvoiceTypeCode = "03"
+				f.invoice.ContingencyID = "cont-1"
+			}},
+			{name: "venta nacional en moneda extranjera", docType: "INVOICE", root: "<Invoice ", marks: []string{">USD</DocumentCurrencyCode>", "PaymentExchangeRate"}, setup: func(f *validationFixture) {
+				f.invoice.CurrencyCode = "USD"
+				f.invoice.ExchangeRate = decimal.NewFromInt(4000)
+				f.invoice.ComputeCOPTotals()
+			}},
+			{name: "exportación 02 en moneda extranjera", docType: "INVOICE", root: "<Invoice ", marks: []string{">02</InvoiceTypeCode>", "DeliveryTerms", "PaymentExchangeRate"}, setup: func(f *validationFixture) {
+				f.invoice.InvoiceTypeCode = "02"
+				f.invoice.Incoterm = "FOB"
+				f.invoice.CurrencyCode = "USD"
+				f.invoice.ExchangeRate = decimal.NewFromInt(4000)
+				f.invoice.ComputeCOPTotals()
+				f.customer.CountryCode = "US"
+			}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				f := newValidationFixture(validator, tc.docType)
+				if tc.setup != nil {
+					tc.setup(f)
+				}
+				f.orch.ProcessSync("inv-1")
+
+				assert.Equal(t, entity.DIANStatusExitoso, f.invoice.DIAN_Status, f.invoice.DIANErrors)
+				assert.Contains(t, f.invoice.XMLSigned, tc.root)
+				for _, m := range tc.marks {
+					assert.Contains(t, f.invoice.XMLSigned, m)
+				}
+				for _, r := range f.invoice.DIANRules {
+					assert.NotEqual(t, entity.DIANRuleError, r.Severity, "%s: %s", r.Code, r.Description)
+				}
+			})
+		}
+	})
+
+	t.Run("reglas incumplidas: no se firma y quedan en la factura", func(t *testing.T) {
+		f := newValidationFixture(validator, "INVOICE")
+		f.company.NIT = "900111222-5"
+		f.invoice.Number = "6000"
+		f.invoice.GrandTotal = decimal.NewFromInt(25000)
+		f.orch.ProcessSync("inv-1")
+
+		assert.Equal(t, entity.DIANStatusErrorGeneration, f.invoice.DIAN_Status)
+		assert.Empty(t, f.invoice.XMLSigned)
+		codes := make([]string, 0, len(f.invoice.DIANRules))
+		for _, r := range f.invoice.DIANRules {
+			codes = append(codes, r.Code)
+		}
+		assert.Subset(t, codes, []string{domaindian.RuleSupplierNIT, domaindian.RuleResolutionRange, domaindian.RulePayable})
+		assert.Contains(t, f.invoice.DIANErrors, domaindian.RulePayable+": ")
+	})
+
+	t.Run("errores de esquema de xmllint", func(t *testing.T) {
+		rules, err := validator.Validate(context.Background(), []byte(`<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"/>`))
+		require.NoError(t, err)
+		require.NotEmpty(t, rules)
+		assert.Equal(t, domaindian.RuleSchema, rules[0].Code)
+		assert.Contains(t, rules[0].Description, "línea 1: ")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = validator.Validate(ctx, []byte(`<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"/>`))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("sin esquemas no hay validador", func(t *testing.T) {
+		_, err := infradian.NewXMLValidatorService("")
+		assert.Error(t, err)
+		_, err = infradian.NewXMLValidatorService(t.TempDir())
+		assert.Error(t, err, "directorio sin esquemas")
+	})
+}
+
+// Contra el paquete completo de OASIS: lo que arma el builder para cada tipo de documento debe cumplir
+// el esquema real, no solo el subconjunto de testdata.
+func TestDIANOrchestrator_LocalValidation_OfficialSchemas(t *testing.T) {
+	validator, err := infradian.NewXMLValidatorService(officialXSDDir(t))
+	require.NoError(t, err)
+
+	for _, docType := range []string{"INVOICE", "CREDIT_NOTE", "DEBIT_NOTE", entity.DocumentTypePOS} {
+		t.Run(docType, func(t *testing.T) {
+			f := newValidationFixture(validator, docType)
+			if docType == entity.DocumentTypePOS {
+				f.invoice.InvoiceTypeCode = "20"
+			}
+			f.orch.ProcessSync("inv-1")
+
+			assert.Equal(t, entity.DIANStatusExitoso, f.invoice.DIAN_Status, f.invoice.DIANErrors)
+			for _, r := range f.invoice.DIANRules {
+				assert.NotEqual(t, domaindian.RuleSchema, r.Code, r.Description)
+			}
+		})
+	}
+
+	rules, err := validator.Validate(context.Background(), []byte(`<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"/>`))
+	require.NoError(t, err)
+	require.NotEmpty(t, rules, "una factura vacía no cumple UBL 2.1")
+	assert.Equal(t, domaindian.RuleSchema, rules[0].Code)
+}
@@ -0,0 +1,119 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+)
+
+// maxVerificationXMLSize tamaño máximo del XML cargado para verificar.
+const maxVerificationXMLSize = 5 << 20
+
+// DocumentVerificationUseCase verifica la integridad de los documentos firmados para auditoría: la firma
+// XAdES-EPES, el certificado del firmante y el CUFE/CUDE recalculado desde el XML. Los secretos del
+// cálculo (clave técnica y PIN) son los de la empresa, así que el CUFE solo se recalcula en sus propios
+// documentos.
+type DocumentVerificationUseCase struct {
+	invoiceRepo repository.InvoiceRepository
+	companyRepo repository.CompanyRepository
+	credentials DIANCredentialsProvider
+	verifier    *infradian.DocumentVerifierService
+}
+
+// NewDocumentVerificationUseCase construye el caso de uso.
+func NewDocumentVerificationUseCase(
+	invoiceRepo repository.InvoiceRepository,
+	companyRepo repository.CompanyRepository,
+	credentials DIANCredentialsProvider,
+	verifier *infradian.DocumentVerifierService,
+) *DocumentVerificationUseCase {
+	return &DocumentVerificationUseCase{
+		invoiceRepo: invoiceRepo,
+		companyRepo: companyRepo,
+		credentials: credentials,
+		verifier:    verifier,
+	}
+}
+
+// VerifyInvoice verifica el XML firmado guardado de la factura o nota (Invoice.XMLSigned).
+func (uc *DocumentVerificationUseCase) VerifyInvoice(ctx context.Context, companyID, invoiceID string) (*dto.DocumentVerificationDTO, error) {
+	inv, err := uc.invoiceRepo.GetByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil || inv.CompanyID != companyID {
+		return nil, domain.ErrNotFound
+	}
+	if strings.TrimSpace(inv.XMLSigned) == "" {
+		return nil, fmt.Errorf("%w: el documento no tiene XML firmado", domain.ErrInvalidInput)
+	}
+	out, err := uc.verify(ctx, companyID, []byte(inv.XMLSigned))
+	if err != nil {
+		return nil, err
+	}
+	out.InvoiceID = inv.ID
+	return out, nil
+}
+
+// VerifyXML verifica un XML cargado. Si lo emitió la empresa, el CUFE/CUDE se recalcula con sus
+// credenciales; en documentos de terceros esa comprobación queda omitida.
+func (uc *DocumentVerificationUseCase) VerifyXML(ctx context.Context, companyID string, xmlBytes []byte) (*dto.DocumentVerificationDTO, error) {
+	if len(xmlBytes) == 0 {
+		return nil, fmt.Errorf("%w: el XML es requerido", domain.ErrInvalidInput)
+	}
+	if len(xmlBytes) > maxVerificationXMLSize {
+		return nil, fmt.Errorf("%w: el XML supera %d MB", domain.ErrInvalidInput, maxVerificationXMLSize>>20)
+	}
+	return uc.verify(ctx, companyID, xmlBytes)
+}
+
+func (uc *DocumentVerificationUseCase) verify(ctx context.Context, companyID string, xmlBytes []byte) (*dto.DocumentVerificationDTO, error) {
+	company, err := uc.companyRepo.GetByID(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if company == nil {
+		return nil, domain.ErrNotFound
+	}
+	// Primera pasada sin secretos para saber quién emitió el documento.
+	v, err := uc.verifier.Verify(xmlBytes, infradian.DocumentKeys{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+	if sameNIT(company.NIT, v.SupplierNIT) {
+		creds, err := uc.credentials.Resolve(ctx, company)
+		if err != nil {
+			return nil, err
+		}
+		if v, err = uc.verifier.Verify(xmlBytes, infradian.DocumentKeys{TechnicalKey: creds.TechnicalKey, SoftwarePIN: creds.SoftwarePIN}); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+		}
+	}
+	return toDocumentVerificationDTO(v), nil
+}
+
+func toDocumentVerificationDTO(v *infradian.DocumentVerification) *dto.DocumentVerificationDTO {
+	out := &dto.DocumentVerificationDTO{
+		DocumentType: v.DocumentType,
+		Number:       v.Number,
+		UUID:         v.UUID,
+		UUIDScheme:   v.UUIDScheme,
+		SupplierNIT:  v.SupplierNIT,
+		Signer:       v.Signer,
+		Valid:        v.Valid(),
+		Checks:       make([]dto.VerificationCheckDTO, 0, len(v.Checks)),
+	}
+	if !v.SigningTime.IsZero() {
+		t := v.SigningTime
+		out.SigningTime = &t
+	}
+	for _, c := range v.Checks {
+		out.Checks = append(out.Checks, dto.VerificationCheckDTO{Name: c.Name, Status: c.Status, Detail: c.Detail})
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+)
+
+func TestDocumentVerificationUseCase(t *testing.T) {
+	creds := &DIANCredentials{TipoAmbiente: "2", TechnicalKey: "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c", SoftwarePIN: "12345"}
+	company := validCompany(testCompanyID)
+	company.NIT = "900111222-1"
+
+	// documentXML factura sin firmar (como queda si el certificado no está configurado) del emisor nit.
+	documentXML := func(nit string) string {
+		issuer := *company
+		issuer.NIT = nit
+		inv := &entity.Invoice{
+			ID: "inv-1", CompanyID: testCompanyID, Prefix: "SETP", Number: "1001", Date: time.Now(),
+			NetTotal: decimal.NewFromInt(10000), TaxTotal: decimal.NewFromInt(1900), GrandTotal: decimal.NewFromInt(11900),
+		}
+		customer := validCustomer(testCompanyID)
+		_, err := infradian.CalculateCufeFromInvoice(&infradian.CufeContext{
+			Invoice: inv, Company: &issuer, Customer: customer, ClaveTecnica: creds.TechnicalKey, TipoAmbiente: "2",
+		})
+		require.NoError(t, err)
+		detail := &entity.InvoiceDetail{ID: "d1", Quantity: decimal.NewFromInt(1), UnitPrice: decimal.NewFromInt(10000),
+			TaxRate: decimal.NewFromInt(19), Subtotal: decimal.NewFromInt(10000)}
+		xmlBytes, err := infradian.NewXMLBuilderService().Build(&infradian.InvoiceBuildContext{
+			Invoice: inv, Company: &issuer, Customer: customer, TipoAmbiente: "2",
+			Details: []infradian.InvoiceLineForXML{{Detail: detail, ProductName: "Producto", UnitCode: "94",
+				Quantity: detail.Quantity, UnitPrice: detail.UnitPrice, TaxRate: detail.TaxRate, Subtotal: detail.Subtotal}},
+		})
+		require.NoError(t, err)
+		return string(xmlBytes)
+	}
+
+	stored := &entity.Invoice{ID: "inv-1", CompanyID: testCompanyID, XMLSigned: documentXML(company.NIT)}
+	uc := NewDocumentVerificationUseCase(
+		&fakeInvoiceRepo{getByIDFunc: func(string) (*entity.Invoice, error) { return stored, nil }},
+		&fakeCompanyRepo{getByIDFunc: func(string) (*entity.Company, error) { return company, nil }},
+		&fakeCredentials{creds: creds},
+		infradian.NewDocumentVerifierService(nil),
+	)
+
+	t.Run("documento propio: CUFE recalculado con la clave técnica", func(t *testing.T) {
+		out, err := uc.VerifyInvoice(context.Background(), testCompanyID, "inv-1")
+		require.NoError(t, err)
+		assert.Equal(t, "inv-1", out.InvoiceID)
+		assert.Equal(t, "SETP1001", out.Number)
+		assert.False(t, out.Valid, "el XML no tiene firma")
+		byName := map[string]string{}
+		for _, c := range out.Checks {
+			byName[c.Name] = c.Status
+		}
+		assert.Equal(t, infradian.VerificationFailed, byName[infradian.CheckSignature])
+		assert.Equal(t, infradian.VerificationOK, byName[infradian.CheckDocumentUUID])
+	})
+
+	t.Run("XML de otro emisor: el CUFE no se puede recalcular", func(t *testing.T) {
+		out, err := uc.VerifyXML(context.Background(), testCompanyID, []byte(documentXML("800197268-4")))
+		require.NoError(t, err)
+		for _, c := range out.Checks {
+			if c.Name == infradian.CheckDocumentUUID {
+				assert.Equal(t, infradian.VerificationSkipped, c.Status)
+			}
+		}
+	})
+
+	t.Run("errores", func(t *testing.T) {
+		_, err := uc.VerifyXML(context.Background(), testCompanyID, []byte("no es XML"))
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+		_, err = uc.VerifyInvoice(context.Background(), "otra-empresa", "inv-1")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		stored.XMLSigned = ""
+		_, err = uc.VerifyInvoice(context.Background(), testCompanyID, "inv-1")
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
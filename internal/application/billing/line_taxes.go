@@ -0,0 +1,54 @@
+package billing
+
+import (
+	"github.com/google/uuid"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+	"github.com/shopspring/decimal"
+)
+
+// taxTemplatesByProduct agrupa por producto los impuestos de la primera línea de la factura original
+// en que aparece. Las facturas anteriores al desglose no tienen filas: el producto queda sin plantilla.
+func taxTemplatesByProduct(details []*entity.InvoiceDetail, taxes []*entity.InvoiceTax) map[string][]*entity.InvoiceTax {
+	byDetail := make(map[string][]*entity.InvoiceTax)
+	for _, t := range taxes {
+		byDetail[t.InvoiceDetailID] = append(byDetail[t.InvoiceDetailID], t)
+	}
+	out := make(map[string][]*entity.InvoiceTax, len(details))
+	for _, d := range details {
+		if _, done := out[d.ProductID]; done {
+			continue
+		}
+		if lineTaxes := byDetail[d.ID]; len(lineTaxes) > 0 {
+			out[d.ProductID] = lineTaxes
+		}
+	}
+	return out
+}
+
+// noteLineTaxes impuestos de una línea de nota: prorratea la plantilla de la factura original o,
+// si no existe, un IVA con la tarifa del detalle original.
+func noteLineTaxes(template []*entity.InvoiceTax, taxRate, quantity, subtotal decimal.Decimal) []*entity.InvoiceTax {
+	if len(template) == 0 {
+		template = []*entity.InvoiceTax{{TaxCode: pkgdian.TaxCodeIVA, Rate: domaindian.PercentRate(taxRate)}}
+	}
+	return domaindian.ProrateTaxes(template, quantity, subtotal)
+}
+
+// persistLineTaxes asigna identificadores a los impuestos de cada detalle y los guarda.
+// lineTaxes va en el mismo orden que details.
+func persistLineTaxes(invoiceRepo repository.InvoiceRepository, details []*entity.InvoiceDetail, lineTaxes [][]*entity.InvoiceTax) error {
+	for i, d := range details {
+		for _, t := range lineTaxes[i] {
+			t.ID = uuid.New().String()
+			t.InvoiceID = d.InvoiceID
+			t.InvoiceDetailID = d.ID
+			if err := invoiceRepo.CreateTax(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package billing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+func TestCreateInvoiceUseCase_MultipleTaxesPerLine(t *testing.T) {
+	customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return validCustomer(testCompanyID), nil }}
+	companyRepo := &fakeCompanyRepo{
+		getByIDFunc:         func(id string) (*entity.Company, error) { return validCompany(id), nil },
+		hasActiveModuleFunc: func(context.Context, string, string) (bool, error) { return false, nil },
+	}
+	productRepo := &fakeProductRepo{getByIDFunc: func(id string) (*entity.Product, error) {
+		switch id {
+		case testProductID1: // IVA 19% + bolsa $66 por unidad
+			p := validProduct(testCompanyID, id, decimal.NewFromInt(10000), decimal.NewFromInt(19))
+			p.UnitTaxCode, p.UnitTaxAmount = pkgdian.TaxCodeINCBolsas, decimal.NewFromInt(66)
+			return p, nil
+		default: // exento de IVA con INC 8%
+			p := validProduct(testCompanyID, id, decimal.NewFromInt(50000), decimal.Zero)
+			p.IncRate = decimal.NewFromInt(8)
+			return p, nil
+		}
+	}}
+	invoiceRepo := &fakeInvoiceRepo{}
+	txRunner := &fakeBillingTxRunner{
+		runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository,
+			repository.StockRepository,
+			repository.ProductRepository,
+			repository.CustomerRepository,
+			repository.InvoiceRepository,
+		) error) error {
+			return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+		},
+	}
+	uc := NewCreateInvoiceUseCase(txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, productRepo, &fakeWarehouseRepo{}, invoiceRepo, nil, DIANConfig{})
+
+	out, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, validCreateInvoiceRequest())
+	require.NoError(t, err)
+
+	// Línea 1: 20000 → IVA 3800 + bolsas 2×66 = 132. Línea 2: 50000 → IVA 0 + INC 4000.
+	assert.True(t, out.NetTotal.Equal(decimal.NewFromInt(70000)))
+	assert.True(t, out.TaxTotal.Equal(decimal.NewFromInt(7932)), "TaxTotal: %s", out.TaxTotal)
+	assert.True(t, out.GrandTotal.Equal(decimal.NewFromInt(77932)), "GrandTotal: %s", out.GrandTotal)
+
+	taxes, err := invoiceRepo.GetTaxesByInvoiceID(out.ID)
+	require.NoError(t, err)
+	require.Len(t, taxes, 4)
+	codes := make(map[string]decimal.Decimal)
+	for _, tax := range taxes {
+		assert.NotEmpty(t, tax.ID)
+		assert.NotEmpty(t, tax.InvoiceDetailID)
+		codes[tax.TaxCode] = codes[tax.TaxCode].Add(tax.Amount)
+	}
+	assert.True(t, codes[pkgdian.TaxCodeIVA].Equal(decimal.NewFromInt(3800)))
+	assert.True(t, codes[pkgdian.TaxCodeINC].Equal(decimal.NewFromInt(4000)))
+	assert.True(t, codes[pkgdian.TaxCodeINCBolsas].Equal(decimal.NewFromInt(132)))
+}
+
+func TestNoteLineTaxes(t *testing.T) {
+	details := []*entity.InvoiceDetail{
+		{ID: "d1", ProductID: testProductID1, Quantity: decimal.NewFromInt(4), TaxRate: decimal.NewFromFloat(0.19)},
+		{ID: "d2", ProductID: testProductID2, Quantity: decimal.NewFromInt(1), TaxRate: decimal.NewFromFloat(0.05)},
+	}
+	taxes := []*entity.InvoiceTax{
+		{InvoiceDetailID: "d1", TaxCode: pkgdian.TaxCodeIVA, Rate: decimal.NewFromInt(19)},
+		{InvoiceDetailID: "d1", TaxCode: pkgdian.TaxCodeINCBolsas, PerUnitAmount: decimal.NewFromInt(66)},
+	}
+	templates := taxTemplatesByProduct(details, taxes)
+
+	t.Run("ProratesOriginalTaxes", func(t *testing.T) {
+		lines := noteLineTaxes(templates[testProductID1], details[0].TaxRate, decimal.NewFromInt(1), decimal.NewFromInt(10000))
+		require.Len(t, lines, 2)
+		assert.True(t, lines[0].Amount.Equal(decimal.NewFromInt(1900)))
+		assert.True(t, lines[1].Amount.Equal(decimal.NewFromInt(66)))
+	})
+
+	t.Run("LegacyFallsBackToDetailRate", func(t *testing.T) {
+		lines := noteLineTaxes(templates[testProductID2], details[1].TaxRate, decimal.NewFromInt(1), decimal.NewFromInt(50000))
+		require.Len(t, lines, 1)
+		assert.Equal(t, pkgdian.TaxCodeIVA, lines[0].TaxCode)
+		assert.True(t, lines[0].Amount.Equal(decimal.NewFromInt(2500)))
+	})
+}
@@ -0,0 +1,118 @@
+package billing
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+// allocateConsecutive asigna el siguiente número consecutivo de la resolución activa del prefijo.
+// Debe invocarse con el invoiceRepo de la transacción que persiste el documento: la fila de la
+// resolución queda bloqueada hasta el commit (las facturas concurrentes del prefijo esperan su
+// turno) y si la transacción se revierte el número vuelve a estar disponible, sin huecos.
+//
+// Valida que la fecha de emisión esté dentro de la vigencia (DateFrom/DateTo) y que el número no
+// supere RangeTo. El número se devuelve sin prefijo, como lo espera la DIAN en el XML.
+func allocateConsecutive(invoiceRepo repository.InvoiceRepository, companyID, prefix string, on time.Time) (string, error) {
+	res, err := invoiceRepo.LockActiveResolution(companyID, prefix)
+	if err != nil {
+		return "", err
+	}
+	if res == nil || res.IsNoteResolution() {
+		return "", fmt.Errorf("prefijo %q: %w", prefix, domain.ErrNoActiveResolution)
+	}
+	return consumeConsecutive(invoiceRepo, res, on)
+}
+
+// claimConsecutive registra un número indicado por el usuario (talonario de contingencia) con las
+// mismas garantías de allocateConsecutive: debe estar en el rango de la resolución bloqueada y ser su
+// siguiente consecutivo, de modo que la numeración no tenga huecos ni choque con asignaciones futuras.
+func claimConsecutive(invoiceRepo repository.InvoiceRepository, companyID, prefix, number string, on time.Time) error {
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("%w: número %q inválido", domain.ErrInvalidInput, number)
+	}
+	res, err := invoiceRepo.LockActiveResolution(companyID, prefix)
+	if err != nil {
+		return err
+	}
+	if res == nil || res.IsNoteResolution() {
+		return fmt.Errorf("prefijo %q: %w", prefix, domain.ErrNoActiveResolution)
+	}
+	if n < res.RangeFrom || n > res.RangeTo {
+		return fmt.Errorf("%w: el número %d está fuera del rango %d-%d de la resolución %s",
+			domain.ErrInvalidInput, n, res.RangeFrom, res.RangeTo, res.ResolutionNumber)
+	}
+	if next := res.NextNumber(); n != next {
+		return fmt.Errorf("%w: el siguiente consecutivo de la resolución %s es %d",
+			domain.ErrInvalidInput, res.ResolutionNumber, next)
+	}
+	_, err = consumeConsecutive(invoiceRepo, res, on)
+	return err
+}
+
+// allocateNoteConsecutive asigna el consecutivo de una nota crédito o débito (documentType
+// entity.ResolutionDocumentCreditNote o entity.ResolutionDocumentDebitNote) con las mismas garantías de
+// allocateConsecutive. Con prefijo explícito usa su resolución activa si numera ese tipo de nota; sin
+// prefijo, la resolución de notas activa de la empresa. Si no la hay (las notas no requieren
+// resolución de la DIAN), toma el número de la secuencia de notas de la empresa para el tipo, también
+// bloqueada hasta el fin de la transacción, con el prefijo indicado o el predeterminado (NC/ND).
+// Devuelve el prefijo y el número.
+func allocateNoteConsecutive(invoiceRepo repository.InvoiceRepository, companyID, prefix, documentType string, on time.Time) (string, string, error) {
+	var res *entity.BillingResolution
+	var err error
+	if prefix != "" {
+		res, err = invoiceRepo.LockActiveResolution(companyID, prefix)
+	} else {
+		res, err = invoiceRepo.LockActiveNoteResolution(companyID, documentType)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if res == nil || res.DocumentType != documentType {
+		n, err := invoiceRepo.NextNoteSequence(companyID, documentType)
+		if err != nil {
+			return "", "", err
+		}
+		if prefix == "" {
+			prefix = defaultNotePrefix(documentType)
+		}
+		return prefix, strconv.FormatInt(n, 10), nil
+	}
+	number, err := consumeConsecutive(invoiceRepo, res, on)
+	if err != nil {
+		return "", "", err
+	}
+	return res.Prefix, number, nil
+}
+
+// defaultNotePrefix es el prefijo de las notas numeradas con la secuencia de la empresa.
+func defaultNotePrefix(documentType string) string {
+	if documentType == entity.ResolutionDocumentDebitNote {
+		return "ND"
+	}
+	return "NC"
+}
+
+// consumeConsecutive valida la vigencia y el rango de la resolución bloqueada y marca como usado su
+// siguiente número.
+func consumeConsecutive(invoiceRepo repository.InvoiceRepository, res *entity.BillingResolution, on time.Time) (string, error) {
+	if !res.CoversDate(on) {
+		return "", fmt.Errorf("resolución %s fuera de vigencia (%s a %s): %w",
+			res.ResolutionNumber, res.DateFrom.Format("2006-01-02"), res.DateTo.Format("2006-01-02"),
+			domain.ErrNoActiveResolution)
+	}
+	next := res.NextNumber()
+	if next > res.RangeTo {
+		return "", fmt.Errorf("resolución %s (rango %d-%d): %w",
+			res.ResolutionNumber, res.RangeFrom, res.RangeTo, domain.ErrResolutionExhausted)
+	}
+	if err := invoiceRepo.ConsumeResolutionNumber(res.ID); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(next, 10), nil
+}
@@ -0,0 +1,199 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+func TestAllocateConsecutive(t *testing.T) {
+	now := time.Now()
+
+	t.Run("SequentialNumbers", func(t *testing.T) {
+		repo := &fakeInvoiceRepo{}
+		first, err := allocateConsecutive(repo, testCompanyID, "FV", now)
+		require.NoError(t, err)
+		second, err := allocateConsecutive(repo, testCompanyID, "FV", now)
+		require.NoError(t, err)
+		assert.Equal(t, "1001", first)
+		assert.Equal(t, "1002", second)
+		assert.Equal(t, int64(2), repo.resolution.UsedNumbers)
+	})
+
+	t.Run("NoActiveResolution", func(t *testing.T) {
+		_, err := allocateConsecutive(&fakeInvoiceRepo{noResolution: true}, testCompanyID, "FV", now)
+		assert.ErrorIs(t, err, domain.ErrNoActiveResolution)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		res := validResolution(testCompanyID, "FV")
+		res.DateTo = now.AddDate(0, 0, -1)
+		repo := &fakeInvoiceRepo{resolution: res}
+		_, err := allocateConsecutive(repo, testCompanyID, "FV", now)
+		assert.ErrorIs(t, err, domain.ErrNoActiveResolution)
+		assert.Zero(t, res.UsedNumbers)
+	})
+
+	t.Run("NotYetValid", func(t *testing.T) {
+		res := validResolution(testCompanyID, "FV")
+		res.DateFrom = now.AddDate(0, 0, 1)
+		_, err := allocateConsecutive(&fakeInvoiceRepo{resolution: res}, testCompanyID, "FV", now)
+		assert.ErrorIs(t, err, domain.ErrNoActiveResolution)
+	})
+
+	t.Run("LastNumberThenExhausted", func(t *testing.T) {
+		res := validResolution(testCompanyID, "FV")
+		res.UsedNumbers = res.RangeTo - res.RangeFrom
+		repo := &fakeInvoiceRepo{resolution: res}
+		last, err := allocateConsecutive(repo, testCompanyID, "FV", now)
+		require.NoError(t, err)
+		assert.Equal(t, "5000", last)
+
+		_, err = allocateConsecutive(repo, testCompanyID, "FV", now)
+		assert.ErrorIs(t, err, domain.ErrResolutionExhausted)
+		assert.Equal(t, res.RangeTo-res.RangeFrom+1, res.UsedNumbers)
+	})
+}
+
+func TestAllocateNoteConsecutive(t *testing.T) {
+	now := time.Now()
+
+	t.Run("CompanyNoteResolutionWithoutPrefix", func(t *testing.T) {
+		repo := &fakeInvoiceRepo{}
+		prefix, number, err := allocateNoteConsecutive(repo, testCompanyID, "", entity.ResolutionDocumentCreditNote, now)
+		require.NoError(t, err)
+		assert.Equal(t, "NC", prefix)
+		assert.Equal(t, "1001", number)
+		_, number, err = allocateNoteConsecutive(repo, testCompanyID, "NC", entity.ResolutionDocumentCreditNote, now)
+		require.NoError(t, err)
+		assert.Equal(t, "1002", number)
+		assert.Nil(t, repo.resolution, "la resolución de facturas no se toca")
+	})
+
+	t.Run("NoNoteResolutionUsesCompanySequence", func(t *testing.T) {
+		repo := &fakeInvoiceRepo{noResolution: true}
+		prefix, number, err := allocateNoteConsecutive(repo, testCompanyID, "", entity.ResolutionDocumentDebitNote, now)
+		require.NoError(t, err)
+		assert.Equal(t, "ND", prefix)
+		assert.Equal(t, "1", number)
+		_, number, err = allocateNoteConsecutive(repo, testCompanyID, "", entity.ResolutionDocumentDebitNote, now)
+		require.NoError(t, err)
+		assert.Equal(t, "2", number)
+		_, number, err = allocateNoteConsecutive(repo, testCompanyID, "", entity.ResolutionDocumentCreditNote, now)
+		require.NoError(t, err)
+		assert.Equal(t, "1", number, "cada tipo de nota tiene su propia secuencia")
+	})
+
+	t.Run("InvoiceResolutionPrefixUsesCompanySequence", func(t *testing.T) {
+		repo := &fakeInvoiceRepo{}
+		prefix, number, err := allocateNoteConsecutive(repo, testCompanyID, "FV", entity.ResolutionDocumentCreditNote, now)
+		require.NoError(t, err)
+		assert.Equal(t, "FV", prefix)
+		assert.Equal(t, "1", number)
+		assert.Zero(t, repo.resolution.UsedNumbers, "la resolución de facturas no se consume")
+	})
+
+	t.Run("NoteResolutionNotUsedForInvoices", func(t *testing.T) {
+		note := validResolution(testCompanyID, "NC")
+		note.DocumentType = entity.ResolutionDocumentCreditNote
+		_, err := allocateConsecutive(&fakeInvoiceRepo{noteResolution: note}, testCompanyID, "NC", now)
+		assert.ErrorIs(t, err, domain.ErrNoActiveResolution)
+	})
+}
+
+func TestClaimConsecutive(t *testing.T) {
+	now := time.Now()
+
+	t.Run("NextNumberConsumes", func(t *testing.T) {
+		repo := &fakeInvoiceRepo{resolution: validResolution(testCompanyID, "CT")}
+		require.NoError(t, claimConsecutive(repo, testCompanyID, "CT", "1001", now))
+		assert.EqualValues(t, 1, repo.resolution.UsedNumbers)
+	})
+
+	t.Run("RejectsGapsAndOutOfRange", func(t *testing.T) {
+		repo := &fakeInvoiceRepo{resolution: validResolution(testCompanyID, "CT")}
+		for _, number := range []string{"1005", "990", "9999", "abc", "0"} {
+			assert.ErrorIs(t, claimConsecutive(repo, testCompanyID, "CT", number, now), domain.ErrInvalidInput, number)
+		}
+		assert.Zero(t, repo.resolution.UsedNumbers)
+	})
+
+	t.Run("NoActiveResolution", func(t *testing.T) {
+		err := claimConsecutive(&fakeInvoiceRepo{noResolution: true}, testCompanyID, "CT", "1001", now)
+		assert.ErrorIs(t, err, domain.ErrNoActiveResolution)
+	})
+}
+
+func TestCreateInvoiceUseCase_ConsecutiveNumber(t *testing.T) {
+	customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return validCustomer(testCompanyID), nil }}
+	companyRepo := &fakeCompanyRepo{
+		getByIDFunc:         func(id string) (*entity.Company, error) { return validCompany(id), nil },
+		hasActiveModuleFunc: func(context.Context, string, string) (bool, error) { return false, nil },
+	}
+	productRepo := &fakeProductRepo{getByIDFunc: func(id string) (*entity.Product, error) {
+		return validProduct(testCompanyID, id, decimal.NewFromInt(10000), decimal.NewFromInt(19)), nil
+	}}
+	newUseCase := func(invoiceRepo *fakeInvoiceRepo) *CreateInvoiceUseCase {
+		txRunner := &fakeBillingTxRunner{
+			runFunc: func(_ context.Context, fn func(
+				repository.InventoryMovementRepository,
+				repository.StockRepository,
+				repository.ProductRepository,
+				repository.CustomerRepository,
+				repository.InvoiceRepository,
+			) error) error {
+				return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+			},
+		}
+		return NewCreateInvoiceUseCase(txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, productRepo, &fakeWarehouseRepo{}, invoiceRepo, nil, DIANConfig{})
+	}
+
+	t.Run("AllocatesFromResolution", func(t *testing.T) {
+		invoiceRepo := &fakeInvoiceRepo{}
+		uc := newUseCase(invoiceRepo)
+		first, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, validCreateInvoiceRequest())
+		require.NoError(t, err)
+		second, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, validCreateInvoiceRequest())
+		require.NoError(t, err)
+		assert.Equal(t, "FV", first.Prefix)
+		assert.Equal(t, "1001", first.Number)
+		assert.Equal(t, "1002", second.Number)
+	})
+
+	t.Run("ExplicitNumberClaimsNextConsecutive", func(t *testing.T) {
+		invoiceRepo := &fakeInvoiceRepo{}
+		in := validCreateInvoiceRequest()
+		in.Number = "1005"
+		_, err := newUseCase(invoiceRepo).CreateInvoice(context.Background(), testCompanyID, testUserID, in)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		in.Number = "1001"
+		out, err := newUseCase(invoiceRepo).CreateInvoice(context.Background(), testCompanyID, testUserID, in)
+		require.NoError(t, err)
+		assert.Equal(t, "1001", out.Number)
+		in.Number = ""
+		next, err := newUseCase(invoiceRepo).CreateInvoice(context.Background(), testCompanyID, testUserID, in)
+		require.NoError(t, err)
+		assert.Equal(t, "1002", next.Number, "el número explícito consume el consecutivo")
+	})
+
+	t.Run("ExhaustedResolutionRejects", func(t *testing.T) {
+		res := validResolution(testCompanyID, "FV")
+		res.UsedNumbers = res.RangeTo - res.RangeFrom + 1
+		created := 0
+		invoiceRepo := &fakeInvoiceRepo{
+			resolution: res,
+			createFunc: func(*entity.Invoice) error { created++; return nil },
+		}
+		_, err := newUseCase(invoiceRepo).CreateInvoice(context.Background(), testCompanyID, testUserID, validCreateInvoiceRequest())
+		assert.ErrorIs(t, err, domain.ErrResolutionExhausted)
+		assert.Zero(t, created)
+	})
+}
@@ -96,6 +96,17 @@ func (uc *PDFUseCase) DownloadInvoicePDF(
 		})
 	}
 
+	if inv.WithholdingTotal.IsPositive() {
+		if inv.Withholdings, err = uc.invoiceRepo.GetWithholdingsByInvoiceID(invoiceID); err != nil {
+			return nil, "", fmt.Errorf("pdf: obtener retenciones: %w", err)
+		}
+	}
+	if inv.IsCredit() {
+		if inv.Installments, err = uc.invoiceRepo.GetInstallmentsByInvoiceID(invoiceID); err != nil {
+			return nil, "", fmt.Errorf("pdf: obtener cuotas: %w", err)
+		}
+	}
+
 	// ── 6. Generar PDF ────────────────────────────────────────────────────────
 	pdfBytes, err = uc.generator.GenerateInvoicePDF(ctx, inv, company, customer, enriched)
 	if err != nil {
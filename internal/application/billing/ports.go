@@ -2,11 +2,14 @@ package billing
 
 import (
 	"context"
+	"crypto/tls"
 	"time"
 
 	"github.com/shopspring/decimal"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
 )
 
 // InvoicePDFGenerator es el puerto de salida para la generación de representaciones
@@ -24,6 +27,22 @@ type InvoicePDFGenerator interface {
 	) ([]byte, error)
 }
 
+// AttachedDocumentBuilder arma el ZIP de entrega al adquiriente de una factura validada por la DIAN: el
+// AttachedDocument firmado (con el XML firmado y el ApplicationResponse de la factura) y el PDF. La
+// implementación concreta se encuentra en internal/infrastructure/dian/.
+type AttachedDocumentBuilder interface {
+	// BuildAttachedDocumentZip devuelve el nombre y los bytes del ZIP.
+	BuildAttachedDocumentZip(
+		invoice *entity.Invoice,
+		company *entity.Company,
+		customer *entity.Customer,
+		tipoAmbiente string,
+		signer pkgdian.Signer,
+		cert tls.Certificate,
+		pdf []byte,
+	) (string, []byte, error)
+}
+
 // InvoiceDetailForPDF agrega el nombre del producto a la línea de detalle,
 // ya que entity.InvoiceDetail solo guarda el productID.
 type InvoiceDetailForPDF struct {
@@ -71,4 +90,252 @@ type InventoryUseCase interface {
 		now time.Time,
 		transactionID string,
 	) error
+
+	// NotifyStockChanged encola el producto/bodega para la evaluación de alertas de stock. El caller lo
+	// invoca después del commit de la transacción que movió el stock, nunca dentro de ella.
+	NotifyStockChanged(companyID, productID, warehouseID string)
+}
+
+// EmailSender envía correos de texto plano; el adaptador SMTP se conecta en cmd/api.
+type EmailSender interface {
+	Send(to, subject, body string) error
+}
+
+// ResolutionAlertRepository define persistencia para el monitoreo de resoluciones de facturación.
+type ResolutionAlertRepository interface {
+	// ListActiveResolutions devuelve las resoluciones activas de la empresa con sus números usados.
+	ListActiveResolutions(ctx context.Context, companyID string) ([]*entity.BillingResolution, error)
+	// CountIssuedSince cuenta los documentos de los tipos dados (invoices.document_type) emitidos con el
+	// prefijo desde la fecha dada.
+	CountIssuedSince(ctx context.Context, companyID, prefix string, documentTypes []string, since time.Time) (int64, error)
+	// CreateIfAbsent registra la alerta si la resolución no tiene otra del mismo tipo; indica si la creó.
+	CreateIfAbsent(ctx context.Context, alert *entity.ResolutionAlert) (bool, error)
+	// ListAdminEmails devuelve los correos de los administradores activos de la empresa.
+	ListAdminEmails(ctx context.Context, companyID string) ([]string, error)
+}
+
+// WithholdingRepository define persistencia de la configuración de retenciones de la empresa:
+// responsabilidades fiscales del emisor y tarifas por tributo y concepto.
+type WithholdingRepository interface {
+	// GetCompanyResponsibilities devuelve las responsabilidades fiscales (O-13, O-15…) de la empresa.
+	GetCompanyResponsibilities(ctx context.Context, companyID string) ([]string, error)
+	// ListRules devuelve las tarifas de retención de la empresa (activas e inactivas).
+	ListRules(ctx context.Context, companyID string) ([]*entity.WithholdingRule, error)
+	// ReplaceConfig reemplaza las responsabilidades fiscales y todas las tarifas de retención de la empresa
+	// en una sola transacción: si falla una parte no queda aplicada la otra.
+	ReplaceConfig(ctx context.Context, companyID string, codes []string, rules []*entity.WithholdingRule) error
+}
+
+// ReceivableFilter criterios para consultar la cartera. Campos vacíos se ignoran.
+type ReceivableFilter struct {
+	CustomerID   string
+	CurrencyCode string
+	OpenOnly     bool // solo facturas con saldo pendiente
+	// Cutoff corte histórico: solo facturas, notas y abonos anteriores a este instante; cero = saldos vigentes.
+	Cutoff time.Time
+}
+
+// PaymentFilter criterios para consultar recaudos. Campos vacíos se ignoran.
+type PaymentFilter struct {
+	CustomerID    string
+	CurrencyCode  string
+	UnappliedOnly bool // solo recaudos con saldo a favor sin aplicar
+	// Cutoff corte histórico: solo recaudos anteriores a este instante, con el saldo a favor que tenían
+	// antes de las aplicaciones posteriores; cero = saldos vigentes.
+	Cutoff time.Time
+}
+
+// ReceivableRepository define persistencia de cartera: recaudos de clientes, su aplicación a
+// facturas y el saldo pendiente de cada factura.
+type ReceivableRepository interface {
+	// ListInvoices devuelve las facturas de la empresa con su valor a cobrar y lo abonado,
+	// ordenadas por fecha de emisión.
+	ListInvoices(ctx context.Context, companyID string, filter ReceivableFilter) ([]*entity.ReceivableInvoice, error)
+	// CreatePayment persiste el recaudo con sus aplicaciones y abona las facturas en una sola
+	// transacción. Si una aplicación supera el saldo vigente de la factura devuelve ErrConflict.
+	CreatePayment(ctx context.Context, p *entity.Payment) error
+	// ApplyPayment aplica saldo a favor de un recaudo existente a facturas en una transacción.
+	// Si el recaudo no tiene saldo suficiente o una factura no lo admite devuelve ErrConflict.
+	ApplyPayment(ctx context.Context, paymentID string, apps []*entity.PaymentApplication) error
+	// GetPayment devuelve el recaudo con sus aplicaciones; nil si no existe.
+	GetPayment(ctx context.Context, id string) (*entity.Payment, error)
+	// ListPayments devuelve los recaudos de la empresa ordenados por fecha (sin aplicaciones).
+	ListPayments(ctx context.Context, companyID string, filter PaymentFilter) ([]*entity.Payment, error)
+}
+
+// DIANStatusRepository define persistencia del seguimiento de envíos asíncronos a la DIAN
+// (facturas en estado Sent con TrackID a la espera de GetStatusZip).
+type DIANStatusRepository interface {
+	// ListAwaitingStatus devuelve hasta limit envíos en estado Sent cuya próxima consulta es anterior a now.
+	ListAwaitingStatus(ctx context.Context, now time.Time, limit int) ([]*entity.DIANStatusCheck, error)
+	// ScheduleStatusCheck registra una consulta sin resultado definitivo y la fecha de la siguiente.
+	ScheduleStatusCheck(ctx context.Context, invoiceID string, attempts int, next time.Time) error
+	// SaveValidationResult persiste el resultado definitivo: estado, resumen de rechazos, reglas y el
+	// ApplicationResponse de la DIAN (nil si no llegó; base del AttachedDocument para el adquiriente).
+	SaveValidationResult(ctx context.Context, invoiceID, status, errors string, rules []entity.DIANValidationRule, applicationResponse []byte) error
+}
+
+// DIANRetryJobRepository define persistencia de la cola de reintentos de facturas en CONTINGENCIA.
+type DIANRetryJobRepository interface {
+	// Enqueue encola la factura para reintentar en next. Si ya está encolada solo actualiza el
+	// último error; si estaba en DEAD la reactiva con los intentos en cero.
+	Enqueue(ctx context.Context, invoiceID, companyID, lastError string, next time.Time) error
+	// EnqueueContingent encola las facturas en CONTINGENCIA que no tienen trabajo; devuelve cuántas.
+	EnqueueContingent(ctx context.Context, next time.Time) (int, error)
+	// DeleteResolved elimina los trabajos cuya factura ya salió de CONTINGENCIA; devuelve cuántos.
+	DeleteResolved(ctx context.Context) (int, error)
+	// ClaimDue toma de forma atómica hasta limit trabajos QUEUED con próximo intento anterior a now, o
+	// RUNNING sin actividad desde staleBefore (su worker terminó a medias), y los pasa a RUNNING. Otro
+	// proceso que consulte a la vez no recibe los mismos trabajos.
+	ClaimDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]*entity.DIANRetryJob, error)
+	// Release devuelve a QUEUED un trabajo RUNNING cuyo reintento terminó sin sacar la factura de CONTINGENCIA.
+	Release(ctx context.Context, invoiceID string) error
+	// RecordAttempt registra un intento y la fecha del siguiente.
+	RecordAttempt(ctx context.Context, invoiceID string, attempts int, next time.Time) error
+	// MarkDead pasa el trabajo a DEAD con el motivo.
+	MarkDead(ctx context.Context, invoiceID, reason string) error
+	// List devuelve los trabajos de la empresa (status vacío = todos) con el número de factura.
+	List(ctx context.Context, companyID, status string) ([]*entity.DIANRetryJob, error)
+}
+
+// DIANContingencyRepository define persistencia de los periodos de facturación en contingencia (tipo 03).
+type DIANContingencyRepository interface {
+	// Create registra la contingencia activa; domain.ErrConflict si la empresa ya tiene una activa.
+	Create(ctx context.Context, c *entity.DIANContingency) error
+	// GetActive devuelve la contingencia activa de la empresa; nil si no tiene.
+	GetActive(ctx context.Context, companyID string) (*entity.DIANContingency, error)
+	// GetByID devuelve la contingencia; nil si no existe.
+	GetByID(ctx context.Context, id string) (*entity.DIANContingency, error)
+	// List devuelve las contingencias de la empresa, de la más reciente a la más antigua.
+	List(ctx context.Context, companyID string) ([]*entity.DIANContingency, error)
+	// End persiste el cierre (EndedAt, EndedBy); domain.ErrConflict si ya estaba terminada.
+	End(ctx context.Context, c *entity.DIANContingency) error
+	// MarkTransmitted registra el inicio de una transmisión masiva de sus facturas.
+	MarkTransmitted(ctx context.Context, id string, at time.Time) error
+	// ListInvoices devuelve las facturas emitidas en la contingencia por número, con los campos de
+	// estado DIAN (sin XML ni detalle).
+	ListInvoices(ctx context.Context, contingencyID string) ([]*entity.Invoice, error)
+}
+
+// DIANCredentialsProvider resuelve las credenciales DIAN (certificado, clave técnica, software y
+// ambiente) de una empresa al procesar sus documentos.
+type DIANCredentialsProvider interface {
+	Resolve(ctx context.Context, company *entity.Company) (*DIANCredentials, error)
+}
+
+// DIANHabilitacionRepository define persistencia del proceso de habilitación DIAN (set de pruebas).
+type DIANHabilitacionRepository interface {
+	// Create guarda el proceso con sus documentos; domain.ErrConflict si la empresa ya tiene uno en curso.
+	Create(ctx context.Context, h *entity.DIANHabilitacion) error
+	// GetLatest devuelve el último proceso de la empresa con sus documentos; nil si no tiene.
+	GetLatest(ctx context.Context, companyID string) (*entity.DIANHabilitacion, error)
+	// ListInProgress devuelve hasta limit procesos en curso con sus documentos.
+	ListInProgress(ctx context.Context, limit int) ([]*entity.DIANHabilitacion, error)
+	// LastNumber mayor consecutivo usado en los sets de pruebas de la empresa (0 si no hay).
+	LastNumber(ctx context.Context, companyID string) (int64, error)
+	// UpdateDocument persiste el envío y la validación de un documento del set.
+	UpdateDocument(ctx context.Context, doc *entity.DIANTestSetDocument) error
+	// UpdateStatus actualiza estado, último error y fecha de cierre del proceso.
+	UpdateStatus(ctx context.Context, h *entity.DIANHabilitacion) error
+}
+
+// SupportDocumentRepository define persistencia de los documentos soporte en adquisiciones a no
+// obligados a facturar y de sus notas de ajuste.
+type SupportDocumentRepository interface {
+	// Create asigna el consecutivo de la resolución activa del prefijo (domain.ErrNoActiveResolution o
+	// domain.ErrResolutionExhausted si no alcanza) y persiste el documento con sus líneas en la misma
+	// transacción. domain.ErrConflict si la orden de compra ya tiene documento soporte.
+	Create(ctx context.Context, doc *entity.SupportDocument) error
+	// GetByID devuelve el documento con sus líneas; nil si no existe.
+	GetByID(ctx context.Context, id string) (*entity.SupportDocument, error)
+	// List devuelve los documentos de la empresa (documentType vacío = todos), del más reciente al más antiguo.
+	List(ctx context.Context, companyID, documentType string) ([]*entity.SupportDocument, error)
+	// Update persiste CUDS, XML firmado, estado DIAN y seguimiento de validación.
+	Update(ctx context.Context, doc *entity.SupportDocument) error
+	// ListPending devuelve hasta limit documentos en Sent o CONTINGENCIA con próxima acción anterior a now.
+	ListPending(ctx context.Context, now time.Time, limit int) ([]*entity.SupportDocument, error)
+}
+
+// PurchaseOrderReader lectura de órdenes de compra para generar documentos soporte.
+type PurchaseOrderReader interface {
+	GetByID(ctx context.Context, id string) (*entity.PurchaseOrder, error)
+}
+
+// POSDocumentRepository consultas de los documentos equivalentes POS pendientes de firma y envío.
+type POSDocumentRepository interface {
+	// ListStaleDrafts devuelve los IDs de hasta limit documentos POS en DRAFT creados antes de before,
+	// más antiguos primero.
+	ListStaleDrafts(ctx context.Context, before time.Time, limit int) ([]string, error)
+}
+
+// ReceivedDocumentRepository define persistencia de las facturas electrónicas recibidas de proveedores y
+// de su historial de eventos RADIAN.
+type ReceivedDocumentRepository interface {
+	// Create registra la factura recibida con sus líneas e impuestos. domain.ErrDuplicate si la empresa ya
+	// la registró (mismo CUFE).
+	Create(ctx context.Context, doc *entity.ReceivedDocument) error
+	// GetByID devuelve la factura con sus líneas, impuestos e historial de eventos; nil si no existe.
+	GetByID(ctx context.Context, id string) (*entity.ReceivedDocument, error)
+	// List devuelve las facturas de la empresa sin eventos (status vacío = todas), de la más reciente a la más antigua.
+	List(ctx context.Context, companyID, status string) ([]*entity.ReceivedDocument, error)
+	// CreateEvent persiste el evento; si no es un registro local le asigna el siguiente consecutivo de
+	// eventos de la empresa. Con doc != nil guarda también su estado RADIAN en la misma transacción.
+	// domain.ErrConflict si la factura ya tiene ese evento en curso o validado.
+	CreateEvent(ctx context.Context, ev *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument) error
+	// GetEvent devuelve el evento; nil si no existe.
+	GetEvent(ctx context.Context, id string) (*entity.ReceivedDocumentEvent, error)
+	// UpdateEvent persiste CUDE, XML firmado, estado DIAN y reenvíos del evento. Con doc != nil guarda
+	// también el estado RADIAN de la factura en la misma transacción.
+	UpdateEvent(ctx context.Context, ev *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument) error
+	// ListPendingEvents devuelve hasta limit eventos en CONTINGENCIA cuyo reenvío venció.
+	ListPendingEvents(ctx context.Context, now time.Time, limit int) ([]*entity.ReceivedDocumentEvent, error)
+	// ListGoodsReceivedBefore devuelve, con sus eventos, hasta limit facturas en GOODS_RECEIVED cuyo recibo
+	// del bien es anterior a before (candidatas a la aceptación tácita).
+	ListGoodsReceivedBefore(ctx context.Context, before time.Time, limit int) ([]*entity.ReceivedDocument, error)
+}
+
+// RADIANEventBuilder genera y empaqueta los eventos RADIAN (ApplicationResponse) del adquiriente. La
+// implementación concreta se encuentra en internal/infrastructure/dian/.
+type RADIANEventBuilder interface {
+	// BuildRADIANEvent calcula el CUDE del evento (queda en ev.CUDE) y genera su XML sin firmar.
+	BuildRADIANEvent(ev *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument, company *entity.Company, softwareID, softwarePIN, tipoAmbiente string) ([]byte, error)
+	// PackageRADIANEvent comprime el XML firmado del evento; devuelve el nombre y los bytes del ZIP.
+	PackageRADIANEvent(ev *entity.ReceivedDocumentEvent, company *entity.Company) (string, []byte, error)
+}
+
+// DIANEventSubmitter envía los eventos RADIAN a la DIAN: SendEventUpdateStatus valida el evento de forma
+// síncrona y devuelve el resultado con el mismo formato de GetStatusZip.
+type DIANEventSubmitter interface {
+	SendEventUpdateStatus(ctx context.Context, zipBytes []byte, env string) (*domaindian.StatusResult, error)
+}
+
+// EmailInvoiceAttachmentRepository adjuntos del buzón candidatos a factura electrónica de proveedor.
+type EmailInvoiceAttachmentRepository interface {
+	// ListPending devuelve hasta limit adjuntos ZIP/XML con contenido, de cuentas activas, que aún no se
+	// procesaron; más antiguos primero.
+	ListPending(ctx context.Context, limit int) ([]*entity.EmailInvoiceAttachment, error)
+	// MarkProcessed registra el resultado del adjunto para no volver a procesarlo.
+	MarkProcessed(ctx context.Context, in *entity.EmailInvoiceIngestion) error
+}
+
+// DIANSettingsPresence indica si alguna empresa ya tiene configuración DIAN propia (dian_settings).
+type DIANSettingsPresence interface {
+	HasAny(ctx context.Context) (bool, error)
+}
+
+// ReceivedInvoiceReader lee las facturas electrónicas que los proveedores envían por correo. La
+// implementación concreta se encuentra en internal/infrastructure/dian/.
+type ReceivedInvoiceReader interface {
+	// ParseReceivedInvoice lee el adjunto (ZIP o XML con el AttachedDocument o el Invoice);
+	// domaindian.ErrNotElectronicInvoice si no contiene una factura electrónica.
+	ParseReceivedInvoice(fileName string, data []byte) (*domaindian.ReceivedInvoice, error)
+	// VerifySignature verifica la firma XAdES del documento, la cadena del certificado hasta una CA de
+	// confianza y su vigencia en la hora de firma; devuelve el NIT del titular del certificado.
+	VerifySignature(xmlBytes []byte) (signerNIT string, err error)
+}
+
+// OpenPurchaseOrderLister órdenes de compra abiertas de un proveedor, para conciliar las facturas recibidas.
+type OpenPurchaseOrderLister interface {
+	// ListOpenBySupplier devuelve con sus ítems las órdenes ENVIADA, CONFIRMADA o RECIBIDA_PARCIAL del proveedor.
+	ListOpenBySupplier(ctx context.Context, companyID, supplierID string) ([]*entity.PurchaseOrder, error)
 }
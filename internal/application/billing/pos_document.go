@@ -0,0 +1,133 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// POSDocumentUseCase emite documentos equivalentes electrónicos POS (tiquetes de venta en mostrador).
+// Reutiliza el flujo de la factura (inventario, impuestos y consecutivo de la resolución del prefijo POS)
+// con el consumidor final como adquiriente por defecto; la firma y el envío a la DIAN se encolan en el
+// orquestador para que la caja no espere a la DIAN.
+type POSDocumentUseCase struct {
+	invoices     *CreateInvoiceUseCase
+	customerRepo repository.CustomerRepository
+	pending      POSDocumentRepository
+	orchestrator *DIANOrchestrator
+}
+
+// NewPOSDocumentUseCase construye el caso de uso.
+func NewPOSDocumentUseCase(
+	invoices *CreateInvoiceUseCase,
+	customerRepo repository.CustomerRepository,
+	pending POSDocumentRepository,
+	orchestrator *DIANOrchestrator,
+) *POSDocumentUseCase {
+	return &POSDocumentUseCase{
+		invoices:     invoices,
+		customerRepo: customerRepo,
+		pending:      pending,
+		orchestrator: orchestrator,
+	}
+}
+
+// Create registra la venta en DRAFT con tipo 20 y la encola para CUDE, firma y envío. El adquiriente es
+// el cliente indicado, el comprador de buyer (se da de alta si no existe) o el consumidor final.
+func (uc *POSDocumentUseCase) Create(ctx context.Context, companyID, userID string, in dto.CreatePOSDocumentRequest) (*dto.InvoiceResponse, error) {
+	if in.CustomerID != "" && in.Buyer != nil {
+		return nil, domain.ErrInvalidInput
+	}
+	customerID := in.CustomerID
+	if customerID == "" {
+		buyer, err := uc.buyer(companyID, in.Buyer)
+		if err != nil {
+			return nil, err
+		}
+		customerID = buyer.ID
+	}
+	return uc.invoices.createDocument(ctx, companyID, userID, dto.CreateInvoiceRequest{
+		CustomerID:         customerID,
+		WarehouseID:        in.WarehouseID,
+		Prefix:             strings.TrimSpace(in.Prefix),
+		Items:              in.Items,
+		InvoiceTypeCode:    dian.InvoiceTypeDocumentoEquivalentePOS,
+		PaymentMethodCodes: in.PaymentMethodCodes,
+	}, entity.DocumentTypePOS)
+}
+
+// RequeueStale vuelve a encolar los documentos POS que siguen en DRAFT después de olderThan (cola llena
+// en un pico de ventas o reinicio del proceso antes de firmarlos). Devuelve cuántos encoló.
+func (uc *POSDocumentUseCase) RequeueStale(ctx context.Context, olderThan time.Duration, limit int) (int, error) {
+	ids, err := uc.pending.ListStaleDrafts(ctx, time.Now().Add(-olderThan), limit)
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for _, id := range ids {
+		if !uc.orchestrator.Enqueue(id) {
+			break // cola llena: el resto espera al siguiente barrido
+		}
+		queued++
+	}
+	if queued > 0 {
+		log.Printf("[DIAN][POS] %d documento(s) en DRAFT reencolado(s)", queued)
+	}
+	return queued, nil
+}
+
+// buyer cliente de la empresa para los datos simplificados del comprador; sin comprador, el consumidor
+// final. Lo crea si aún no existe.
+func (uc *POSDocumentUseCase) buyer(companyID string, in *dto.POSBuyerRequest) (*entity.Customer, error) {
+	c := &entity.Customer{
+		TaxID:              dian.FinalConsumerIdentification,
+		Name:               dian.FinalConsumerName,
+		IdentificationType: dian.IdentificationTypeCC,
+	}
+	if in != nil {
+		c.TaxID = strings.TrimSpace(in.Identification)
+		c.Name = strings.TrimSpace(in.Name)
+		c.Email = strings.TrimSpace(in.Email)
+		if idType := strings.TrimSpace(in.IdentificationType); idType != "" {
+			c.IdentificationType = idType
+		}
+		if c.TaxID == "" || c.Name == "" || !dian.ValidIdentificationTypes[c.IdentificationType] {
+			return nil, domain.ErrInvalidInput
+		}
+	}
+
+	existing, err := uc.customerRepo.GetByCompanyAndTaxID(companyID, c.TaxID)
+	if err != nil {
+		return nil, fmt.Errorf("buscar comprador POS: %w", err)
+	}
+	if existing != nil {
+		return existing, nil
+	}
+	now := time.Now()
+	c.ID = uuid.New().String()
+	c.CompanyID = companyID
+	c.CountryCode = "CO"
+	c.IsActive = true
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	if err := uc.customerRepo.Create(c); err != nil {
+		if errors.Is(err, domain.ErrDuplicate) {
+			// Otra caja lo creó al mismo tiempo.
+			if existing, err := uc.customerRepo.GetByCompanyAndTaxID(companyID, c.TaxID); err == nil && existing != nil {
+				return existing, nil
+			}
+		}
+		return nil, fmt.Errorf("crear comprador POS: %w", err)
+	}
+	return c, nil
+}
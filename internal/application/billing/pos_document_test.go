@@ -0,0 +1,176 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+type fakePOSDrafts struct {
+	ids []string
+}
+
+func (f *fakePOSDrafts) ListStaleDrafts(context.Context, time.Time, int) ([]string, error) {
+	return f.ids, nil
+}
+
+func TestPOSDocumentUseCase(t *testing.T) {
+	ctx := context.Background()
+	company := validCompany(testCompanyID)
+	product := validProduct(testCompanyID, testProductID1, decimal.NewFromInt(10000), decimal.NewFromInt(19))
+
+	type fixture struct {
+		uc        *POSDocumentUseCase
+		orch      *DIANOrchestrator
+		customers map[string]*entity.Customer
+		invoices  map[string]*entity.Invoice
+		details   map[string][]*entity.InvoiceDetail
+	}
+	newFixture := func(queueSize int) *fixture {
+		f := &fixture{
+			customers: map[string]*entity.Customer{},
+			invoices:  map[string]*entity.Invoice{},
+			details:   map[string][]*entity.InvoiceDetail{},
+		}
+		customerRepo := &fakeCustomerRepo{
+			getByIDFunc: func(id string) (*entity.Customer, error) { return f.customers[id], nil },
+			getByCompanyAndTaxIDFunc: func(companyID, taxID string) (*entity.Customer, error) {
+				for _, c := range f.customers {
+					if c.CompanyID == companyID && c.TaxID == taxID {
+						return c, nil
+					}
+				}
+				return nil, nil
+			},
+			createFunc: func(c *entity.Customer) error { f.customers[c.ID] = c; return nil },
+		}
+		companyRepo := &fakeCompanyRepo{getByIDFunc: func(string) (*entity.Company, error) { return company, nil }}
+		productRepo := &fakeProductRepo{getByIDFunc: func(string) (*entity.Product, error) { return product, nil }}
+		invoiceRepo := &fakeInvoiceRepo{
+			resolution: validResolution(testCompanyID, "POS"),
+			createFunc: func(inv *entity.Invoice) error { f.invoices[inv.ID] = inv; return nil },
+			createDetailFunc: func(d *entity.InvoiceDetail) error {
+				f.details[d.InvoiceID] = append(f.details[d.InvoiceID], d)
+				return nil
+			},
+			getByIDFunc: func(id string) (*entity.Invoice, error) { return f.invoices[id], nil },
+			getDetailsByInvoiceIDFunc: func(id string) ([]*entity.InvoiceDetail, error) {
+				return f.details[id], nil
+			},
+			updateFunc: func(inv *entity.Invoice) error { f.invoices[inv.ID] = inv; return nil },
+		}
+		txRunner := &fakeBillingTxRunner{runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository, repository.StockRepository, repository.ProductRepository,
+			repository.CustomerRepository, repository.InvoiceRepository,
+		) error) error {
+			return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+		}}
+
+		f.orch = NewDIANOrchestrator(invoiceRepo, companyRepo, customerRepo, productRepo,
+			&fakeResolutionRepo{res: validResolution(testCompanyID, "POS")}, infradian.NewXMLBuilderService(), fakeSigner{}, nil, DIANConfig{})
+		f.orch.SetCredentialsProvider(&fakeCredentials{creds: &DIANCredentials{AppEnv: "dev", TipoAmbiente: "2", SoftwareID: "sw-1", SoftwarePIN: "12345"}})
+		// Cola sin workers: el test decide cuándo procesar.
+		f.orch.queue = make(chan string, queueSize)
+
+		invoices := NewCreateInvoiceUseCase(txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, productRepo,
+			&fakeWarehouseRepo{}, invoiceRepo, f.orch, DIANConfig{})
+		f.uc = NewPOSDocumentUseCase(invoices, customerRepo, &fakePOSDrafts{}, f.orch)
+		return f
+	}
+	items := []dto.InvoiceItemRequest{{ProductID: testProductID1, Quantity: decimal.NewFromInt(1)}}
+
+	t.Run("consumidor final: tipo 20, encolado y procesado con CUDE", func(t *testing.T) {
+		f := newFixture(10)
+		out, err := f.uc.Create(ctx, testCompanyID, testUserID, dto.CreatePOSDocumentRequest{Prefix: "POS", Items: items})
+		require.NoError(t, err)
+		assert.Equal(t, "1001", out.Number)
+		assert.Equal(t, dian.FinalConsumerName, out.CustomerName)
+		assert.Equal(t, entity.DIANStatusDraft, out.DIAN_Status)
+
+		inv := f.invoices[out.ID]
+		require.NotNil(t, inv)
+		assert.Equal(t, entity.DocumentTypePOS, inv.DocumentType)
+		assert.Equal(t, dian.InvoiceTypeDocumentoEquivalentePOS, inv.InvoiceTypeCode)
+		require.Len(t, f.customers, 1)
+		assert.Equal(t, dian.FinalConsumerIdentification, f.customers[inv.CustomerID].TaxID)
+
+		require.Len(t, f.orch.queue, 1, "la venta se encola sin procesar en la petición")
+		id := <-f.orch.queue
+		assert.Equal(t, out.ID, id)
+		f.orch.process(id, false)
+
+		inv = f.invoices[out.ID]
+		assert.Equal(t, entity.DIANStatusExitoso, inv.DIAN_Status)
+		assert.Len(t, inv.CUFE, 96, "CUDE SHA-384 con el PIN del software, sin clave técnica")
+		assert.Contains(t, inv.XMLSigned, ">20</InvoiceTypeCode>")
+		assert.Contains(t, inv.XMLSigned, `schemeName="CUDE-SHA384"`)
+		assert.Contains(t, inv.XMLSigned, dian.FinalConsumerIdentification)
+
+		// La segunda venta reutiliza el consumidor final.
+		_, err = f.uc.Create(ctx, testCompanyID, testUserID, dto.CreatePOSDocumentRequest{Prefix: "POS", Items: items})
+		require.NoError(t, err)
+		assert.Len(t, f.customers, 1)
+	})
+
+	t.Run("comprador identificado y validaciones", func(t *testing.T) {
+		f := newFixture(10)
+		out, err := f.uc.Create(ctx, testCompanyID, testUserID, dto.CreatePOSDocumentRequest{
+			Prefix: "POS", Items: items, Buyer: &dto.POSBuyerRequest{Identification: "10203040", Name: "Ana Gómez"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Ana Gómez", out.CustomerName)
+		assert.Equal(t, dian.IdentificationTypeCC, f.customers[f.invoices[out.ID].CustomerID].IdentificationType)
+
+		_, err = f.uc.Create(ctx, testCompanyID, testUserID, dto.CreatePOSDocumentRequest{
+			Prefix: "POS", Items: items, Buyer: &dto.POSBuyerRequest{Identification: "10203040"},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, "el comprador identificado requiere nombre")
+
+		_, err = f.uc.Create(ctx, testCompanyID, testUserID, dto.CreatePOSDocumentRequest{
+			Prefix: "POS", Items: items, CustomerID: "c-1", Buyer: &dto.POSBuyerRequest{Identification: "1", Name: "x"},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+		_, err = f.uc.invoices.CreateInvoice(ctx, testCompanyID, testUserID, dto.CreateInvoiceRequest{
+			CustomerID: out.CustomerID, Prefix: "POS", Items: items, InvoiceTypeCode: dian.InvoiceTypeDocumentoEquivalentePOS,
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, "el tipo 20 solo se emite por el flujo POS")
+	})
+
+	t.Run("cola llena: queda en DRAFT y el barrido lo reencola", func(t *testing.T) {
+		f := newFixture(1)
+		first, err := f.uc.Create(ctx, testCompanyID, testUserID, dto.CreatePOSDocumentRequest{Prefix: "POS", Items: items})
+		require.NoError(t, err)
+		second, err := f.uc.Create(ctx, testCompanyID, testUserID, dto.CreatePOSDocumentRequest{Prefix: "POS", Items: items})
+		require.NoError(t, err, "la caja no se bloquea aunque la cola esté llena")
+		assert.Equal(t, entity.DIANStatusDraft, f.invoices[second.ID].DIAN_Status)
+
+		f.uc.pending = &fakePOSDrafts{ids: []string{first.ID, second.ID}}
+		n, err := f.uc.RequeueStale(ctx, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n, "el primero ya está en cola; el segundo no cabe")
+		require.Len(t, f.orch.queue, 1)
+
+		id := <-f.orch.queue
+		assert.Equal(t, first.ID, id)
+		f.orch.process(id, false)
+		f.orch.queued.Delete(id)
+
+		f.uc.pending = &fakePOSDrafts{ids: []string{second.ID}}
+		n, err = f.uc.RequeueStale(ctx, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, second.ID, <-f.orch.queue)
+	})
+}
@@ -0,0 +1,51 @@
+package billing
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// POSDocumentWorker reencola periódicamente los documentos equivalentes POS que siguen en DRAFT
+// (cola del orquestador llena o reinicio del proceso antes de firmarlos).
+type POSDocumentWorker struct {
+	uc         *POSDocumentUseCase
+	interval   time.Duration
+	staleAfter time.Duration
+	batchSize  int
+}
+
+func NewPOSDocumentWorker(uc *POSDocumentUseCase, interval, staleAfter time.Duration, batchSize int) *POSDocumentWorker {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if staleAfter <= 0 {
+		staleAfter = 2 * time.Minute
+	}
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+	return &POSDocumentWorker{uc: uc, interval: interval, staleAfter: staleAfter, batchSize: batchSize}
+}
+
+func (w *POSDocumentWorker) Start(ctx context.Context) {
+	if w.uc == nil {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			if _, err := w.uc.RequeueStale(runCtx, w.staleAfter, w.batchSize); err != nil {
+				log.Printf("[DIAN][POS] no se pudieron listar documentos POS en DRAFT: %v", err)
+			}
+			cancel()
+		}
+	}
+}
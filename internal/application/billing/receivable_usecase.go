@@ -0,0 +1,476 @@
+package billing
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+	"github.com/shopspring/decimal"
+)
+
+// ReceivableUseCase administra la cartera de clientes: registra recaudos (totales o parciales),
+// los aplica a una o varias facturas, conserva los excedentes como saldo a favor y produce el
+// estado de cuenta y la cartera por edades.
+type ReceivableUseCase struct {
+	repo         ReceivableRepository
+	customerRepo repository.CustomerRepository
+}
+
+// NewReceivableUseCase construye el caso de uso.
+func NewReceivableUseCase(repo ReceivableRepository, customerRepo repository.CustomerRepository) *ReceivableUseCase {
+	return &ReceivableUseCase{repo: repo, customerRepo: customerRepo}
+}
+
+// RegisterPayment registra un recaudo del cliente:
+//   - Con applications, cada abono debe ser positivo, a una factura abierta del cliente en la moneda
+//     del recaudo y sin superar su saldo; la suma no puede superar el valor recibido.
+//   - Sin applications, el recaudo se aplica a las facturas abiertas más antiguas (por vencimiento).
+//   - Lo no aplicado queda como saldo a favor del cliente.
+func (uc *ReceivableUseCase) RegisterPayment(ctx context.Context, companyID, userID string, in dto.RegisterPaymentRequest) (*dto.PaymentResponse, error) {
+	if companyID == "" || in.CustomerID == "" || !in.Amount.IsPositive() {
+		return nil, domain.ErrInvalidInput
+	}
+	if _, err := uc.customer(companyID, in.CustomerID); err != nil {
+		return nil, err
+	}
+	currency := strings.ToUpper(strings.TrimSpace(in.CurrencyCode))
+	if currency == "" {
+		currency = dian.CurrencyCOP
+	}
+	if !dian.ValidCurrencyCodes[currency] {
+		return nil, domain.ErrInvalidInput
+	}
+	method := strings.ToUpper(strings.TrimSpace(in.MethodCode))
+	if method == "" {
+		method = dian.PaymentMethodEfectivo
+	}
+	if !dian.ValidPaymentMethods[method] {
+		return nil, domain.ErrInvalidInput
+	}
+	now := time.Now()
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if in.Date != "" {
+		d, err := time.Parse("2006-01-02", in.Date)
+		if err != nil || d.After(date) {
+			return nil, domain.ErrInvalidInput
+		}
+		date = d
+	}
+
+	open, err := uc.repo.ListInvoices(ctx, companyID, ReceivableFilter{
+		CustomerID: in.CustomerID, CurrencyCode: currency, OpenOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var apps []*entity.PaymentApplication
+	if len(in.Applications) > 0 {
+		if apps, err = validateApplications(in.Applications, open, in.Amount); err != nil {
+			return nil, err
+		}
+	} else {
+		apps = autoApply(open, in.Amount)
+	}
+
+	p := &entity.Payment{
+		ID:              uuid.New().String(),
+		CompanyID:       companyID,
+		CustomerID:      in.CustomerID,
+		Date:            date,
+		Amount:          in.Amount,
+		CurrencyCode:    currency,
+		MethodCode:      method,
+		Reference:       strings.TrimSpace(in.Reference),
+		Notes:           strings.TrimSpace(in.Notes),
+		UnappliedAmount: in.Amount.Sub(sumApplications(apps)),
+		CreatedBy:       userID,
+		CreatedAt:       now,
+		Applications:    apps,
+	}
+	// Los abonos del recaudo rigen desde su fecha (aunque se registre con fecha anterior), que es la
+	// que usa la cartera por edades a un corte histórico.
+	for _, a := range apps {
+		a.PaymentID = p.ID
+		a.AppliedAt = date
+	}
+	if err := uc.repo.CreatePayment(ctx, p); err != nil {
+		return nil, err
+	}
+	return toPaymentResponse(p), nil
+}
+
+// ApplyCredit aplica el saldo a favor de un recaudo a facturas abiertas del mismo cliente y moneda.
+func (uc *ReceivableUseCase) ApplyCredit(ctx context.Context, companyID, paymentID string, in dto.ApplyPaymentRequest) (*dto.PaymentResponse, error) {
+	if companyID == "" || paymentID == "" || len(in.Applications) == 0 {
+		return nil, domain.ErrInvalidInput
+	}
+	p, err := uc.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, domain.ErrNotFound
+	}
+	if p.CompanyID != companyID {
+		return nil, domain.ErrForbidden
+	}
+	open, err := uc.repo.ListInvoices(ctx, companyID, ReceivableFilter{
+		CustomerID: p.CustomerID, CurrencyCode: p.CurrencyCode, OpenOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	apps, err := validateApplications(in.Applications, open, p.UnappliedAmount)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, a := range apps {
+		a.PaymentID = p.ID
+		a.AppliedAt = now
+	}
+	if err := uc.repo.ApplyPayment(ctx, p.ID, apps); err != nil {
+		return nil, err
+	}
+	p.Applications = append(p.Applications, apps...)
+	p.UnappliedAmount = p.UnappliedAmount.Sub(sumApplications(apps))
+	return toPaymentResponse(p), nil
+}
+
+// GetPayment devuelve un recaudo con sus aplicaciones.
+func (uc *ReceivableUseCase) GetPayment(ctx context.Context, companyID, paymentID string) (*dto.PaymentResponse, error) {
+	p, err := uc.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, domain.ErrNotFound
+	}
+	if p.CompanyID != companyID {
+		return nil, domain.ErrForbidden
+	}
+	return toPaymentResponse(p), nil
+}
+
+// ListInvoices devuelve las facturas con su saldo y estado de pago (todas o solo las abiertas).
+func (uc *ReceivableUseCase) ListInvoices(ctx context.Context, companyID, customerID string, openOnly bool) ([]dto.ReceivableInvoiceDTO, error) {
+	if companyID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	list, err := uc.repo.ListInvoices(ctx, companyID, ReceivableFilter{CustomerID: customerID, OpenOnly: openOnly})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]dto.ReceivableInvoiceDTO, 0, len(list))
+	for _, r := range list {
+		out = append(out, toReceivableInvoiceDTO(r, now))
+	}
+	return out, nil
+}
+
+// GetStatement estado de cuenta del cliente en la moneda dada (vacío = COP) entre start y end
+// (inclusive): saldo inicial, facturas (cargos) y recaudos (abonos) con saldo acumulado, saldo
+// final, saldo a favor sin aplicar y facturas abiertas. Las notas crédito y débito se reflejan en
+// el valor de la factura que afectan.
+func (uc *ReceivableUseCase) GetStatement(ctx context.Context, companyID, customerID, currency string, start, end time.Time) (*dto.CustomerStatementDTO, error) {
+	if companyID == "" || customerID == "" || end.Before(start) {
+		return nil, domain.ErrInvalidInput
+	}
+	customer, err := uc.customer(companyID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = dian.CurrencyCOP
+	}
+	invoices, err := uc.repo.ListInvoices(ctx, companyID, ReceivableFilter{CustomerID: customerID, CurrencyCode: currency})
+	if err != nil {
+		return nil, err
+	}
+	payments, err := uc.repo.ListPayments(ctx, companyID, PaymentFilter{CustomerID: customerID, CurrencyCode: currency})
+	if err != nil {
+		return nil, err
+	}
+
+	startDay := dayOf(start)
+	endDay := dayOf(end)
+	entries := make([]dto.StatementEntryDTO, 0, len(invoices)+len(payments))
+	type movement struct {
+		date  time.Time
+		entry dto.StatementEntryDTO
+	}
+	var moves []movement
+	opening := decimal.Zero
+	for _, r := range invoices {
+		d := dayOf(r.Date)
+		if d.Before(startDay) {
+			opening = opening.Add(r.Total)
+			continue
+		}
+		if d.After(endDay) {
+			continue
+		}
+		moves = append(moves, movement{date: d, entry: dto.StatementEntryDTO{
+			Type: "INVOICE", DocumentID: r.InvoiceID, Reference: r.Prefix + r.Number, Debit: r.Total,
+		}})
+	}
+	unapplied := decimal.Zero
+	for _, p := range payments {
+		unapplied = unapplied.Add(p.UnappliedAmount)
+		d := dayOf(p.Date)
+		if d.Before(startDay) {
+			opening = opening.Sub(p.Amount)
+			continue
+		}
+		if d.After(endDay) {
+			continue
+		}
+		moves = append(moves, movement{date: d, entry: dto.StatementEntryDTO{
+			Type: "PAYMENT", DocumentID: p.ID, Reference: p.Reference, Credit: p.Amount,
+		}})
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].date.Before(moves[j].date) })
+	balance := opening
+	for _, m := range moves {
+		balance = balance.Add(m.entry.Debit).Sub(m.entry.Credit)
+		m.entry.Date = m.date.Format("2006-01-02")
+		m.entry.Balance = balance
+		entries = append(entries, m.entry)
+	}
+
+	out := &dto.CustomerStatementDTO{
+		CustomerID:      customer.ID,
+		CustomerName:    customer.Name,
+		CurrencyCode:    currency,
+		StartDate:       startDay.Format("2006-01-02"),
+		EndDate:         endDay.Format("2006-01-02"),
+		OpeningBalance:  opening,
+		ClosingBalance:  balance,
+		UnappliedCredit: unapplied,
+		Entries:         entries,
+		OpenInvoices:    []dto.ReceivableInvoiceDTO{},
+	}
+	now := time.Now()
+	for _, r := range invoices {
+		if r.Balance().IsPositive() {
+			out.OpenInvoices = append(out.OpenInvoices, toReceivableInvoiceDTO(r, now))
+		}
+	}
+	return out, nil
+}
+
+// GetAging cartera por edades a la fecha asOf en la moneda dada (vacío = COP): saldo de cada
+// cliente repartido por días vencidos (corriente, 1–30, 31–60, 61–90, más de 90) y su saldo a favor.
+// Los saldos se reconstruyen al cierre de asOf: solo cuentan las facturas, notas y abonos fechados
+// ese día o antes.
+func (uc *ReceivableUseCase) GetAging(ctx context.Context, companyID, currency string, asOf time.Time) (*dto.ReceivablesAgingDTO, error) {
+	if companyID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = dian.CurrencyCOP
+	}
+	cutoff := dayOf(asOf).AddDate(0, 0, 1)
+	open, err := uc.repo.ListInvoices(ctx, companyID, ReceivableFilter{CurrencyCode: currency, OpenOnly: true, Cutoff: cutoff})
+	if err != nil {
+		return nil, err
+	}
+	credits, err := uc.repo.ListPayments(ctx, companyID, PaymentFilter{CurrencyCode: currency, UnappliedOnly: true, Cutoff: cutoff})
+	if err != nil {
+		return nil, err
+	}
+
+	byCustomer := make(map[string]*dto.AgingCustomerDTO)
+	var order []string
+	row := func(id, name string) *dto.AgingCustomerDTO {
+		if c, ok := byCustomer[id]; ok {
+			if c.CustomerName == "" {
+				c.CustomerName = name
+			}
+			return c
+		}
+		c := &dto.AgingCustomerDTO{CustomerID: id, CustomerName: name}
+		byCustomer[id] = c
+		order = append(order, id)
+		return c
+	}
+	out := &dto.ReceivablesAgingDTO{
+		AsOf:         dayOf(asOf).Format("2006-01-02"),
+		CurrencyCode: currency,
+		Customers:    []dto.AgingCustomerDTO{},
+	}
+	for _, r := range open {
+		balance := r.Balance()
+		c := row(r.CustomerID, r.CustomerName)
+		addToBucket(&c.AgingBucketsDTO, r.DaysOverdue(asOf), balance)
+		addToBucket(&out.Totals, r.DaysOverdue(asOf), balance)
+	}
+	for _, p := range credits {
+		c := row(p.CustomerID, "")
+		c.UnappliedCredit = c.UnappliedCredit.Add(p.UnappliedAmount)
+	}
+	for _, id := range order {
+		out.Customers = append(out.Customers, *byCustomer[id])
+	}
+	sort.SliceStable(out.Customers, func(i, j int) bool {
+		return out.Customers[i].Total.GreaterThan(out.Customers[j].Total)
+	})
+	return out, nil
+}
+
+// AgingBucket rango de la cartera por edades según los días vencidos.
+func AgingBucket(daysOverdue int) string {
+	switch {
+	case daysOverdue <= 0:
+		return "current"
+	case daysOverdue <= 30:
+		return "1-30"
+	case daysOverdue <= 60:
+		return "31-60"
+	case daysOverdue <= 90:
+		return "61-90"
+	default:
+		return "90+"
+	}
+}
+
+func addToBucket(b *dto.AgingBucketsDTO, daysOverdue int, amount decimal.Decimal) {
+	switch AgingBucket(daysOverdue) {
+	case "current":
+		b.Current = b.Current.Add(amount)
+	case "1-30":
+		b.Days1To30 = b.Days1To30.Add(amount)
+	case "31-60":
+		b.Days31To60 = b.Days31To60.Add(amount)
+	case "61-90":
+		b.Days61To90 = b.Days61To90.Add(amount)
+	default:
+		b.Over90 = b.Over90.Add(amount)
+	}
+	b.Total = b.Total.Add(amount)
+}
+
+// validateApplications valida los abonos contra las facturas abiertas: positivos, sin facturas
+// repetidas, sin superar el saldo de cada factura ni, en total, el valor disponible.
+func validateApplications(reqs []dto.PaymentApplicationRequest, open []*entity.ReceivableInvoice, available decimal.Decimal) ([]*entity.PaymentApplication, error) {
+	byID := make(map[string]*entity.ReceivableInvoice, len(open))
+	for _, r := range open {
+		byID[r.InvoiceID] = r
+	}
+	seen := make(map[string]bool, len(reqs))
+	apps := make([]*entity.PaymentApplication, 0, len(reqs))
+	total := decimal.Zero
+	for _, req := range reqs {
+		inv, ok := byID[req.InvoiceID]
+		if !ok || seen[req.InvoiceID] || !req.Amount.IsPositive() || req.Amount.GreaterThan(inv.Balance()) {
+			return nil, domain.ErrInvalidInput
+		}
+		seen[req.InvoiceID] = true
+		total = total.Add(req.Amount)
+		apps = append(apps, &entity.PaymentApplication{
+			ID:        uuid.New().String(),
+			InvoiceID: req.InvoiceID,
+			Amount:    req.Amount,
+		})
+	}
+	if total.GreaterThan(available) {
+		return nil, domain.ErrInvalidInput
+	}
+	return apps, nil
+}
+
+// autoApply reparte el valor entre las facturas abiertas de vencimiento más antiguo primero.
+func autoApply(open []*entity.ReceivableInvoice, amount decimal.Decimal) []*entity.PaymentApplication {
+	sorted := append([]*entity.ReceivableInvoice(nil), open...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].DueDate.Before(sorted[j].DueDate) })
+	var apps []*entity.PaymentApplication
+	remaining := amount
+	for _, r := range sorted {
+		if !remaining.IsPositive() {
+			break
+		}
+		applied := decimal.Min(remaining, r.Balance())
+		if !applied.IsPositive() {
+			continue
+		}
+		apps = append(apps, &entity.PaymentApplication{
+			ID:        uuid.New().String(),
+			InvoiceID: r.InvoiceID,
+			Amount:    applied,
+		})
+		remaining = remaining.Sub(applied)
+	}
+	return apps
+}
+
+func sumApplications(apps []*entity.PaymentApplication) decimal.Decimal {
+	total := decimal.Zero
+	for _, a := range apps {
+		total = total.Add(a.Amount)
+	}
+	return total
+}
+
+func (uc *ReceivableUseCase) customer(companyID, customerID string) (*entity.Customer, error) {
+	customer, err := uc.customerRepo.GetByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, domain.ErrNotFound
+	}
+	if customer.CompanyID != companyID {
+		return nil, domain.ErrForbidden
+	}
+	return customer, nil
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func toPaymentResponse(p *entity.Payment) *dto.PaymentResponse {
+	out := &dto.PaymentResponse{
+		ID:              p.ID,
+		CustomerID:      p.CustomerID,
+		Date:            p.Date.Format("2006-01-02"),
+		Amount:          p.Amount,
+		CurrencyCode:    p.CurrencyCode,
+		MethodCode:      p.MethodCode,
+		Reference:       p.Reference,
+		Notes:           p.Notes,
+		UnappliedAmount: p.UnappliedAmount,
+		Applications:    make([]dto.PaymentApplicationDTO, 0, len(p.Applications)),
+	}
+	for _, a := range p.Applications {
+		out.Applications = append(out.Applications, dto.PaymentApplicationDTO{InvoiceID: a.InvoiceID, Amount: a.Amount})
+	}
+	return out
+}
+
+func toReceivableInvoiceDTO(r *entity.ReceivableInvoice, asOf time.Time) dto.ReceivableInvoiceDTO {
+	return dto.ReceivableInvoiceDTO{
+		InvoiceID:     r.InvoiceID,
+		CustomerID:    r.CustomerID,
+		CustomerName:  r.CustomerName,
+		Number:        r.Prefix + r.Number,
+		Date:          r.Date.Format("2006-01-02"),
+		DueDate:       r.DueDate.Format("2006-01-02"),
+		CurrencyCode:  r.CurrencyCode,
+		Total:         r.Total,
+		AmountPaid:    r.AmountPaid,
+		Balance:       r.Balance(),
+		PaymentStatus: r.PaymentStatus(),
+		DaysOverdue:   r.DaysOverdue(asOf),
+	}
+}
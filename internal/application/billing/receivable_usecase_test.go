@@ -0,0 +1,337 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+// fakeReceivableRepo cartera en memoria: abona amount_paid y descuenta saldos a favor como el adaptador SQL.
+// Con Cutoff descuenta los abonos registrados desde el corte, como la reconstrucción histórica del SQL.
+// statuses guarda el estado DIAN por factura (sin entrada = EXITOSO) y filtra las no emitidas como el SQL.
+type fakeReceivableRepo struct {
+	invoices []*entity.ReceivableInvoice
+	payments []*entity.Payment
+	applied  []*entity.PaymentApplication
+	statuses map[string]string
+}
+
+func (f *fakeReceivableRepo) ListInvoices(_ context.Context, _ string, filter ReceivableFilter) ([]*entity.ReceivableInvoice, error) {
+	out := make([]*entity.ReceivableInvoice, 0)
+	for _, r := range f.invoices {
+		if st, ok := f.statuses[r.InvoiceID]; ok && !entity.IsReceivableDIANStatus(st) {
+			continue
+		}
+		if filter.CustomerID != "" && r.CustomerID != filter.CustomerID {
+			continue
+		}
+		if filter.CurrencyCode != "" && r.CurrencyCode != filter.CurrencyCode {
+			continue
+		}
+		cp := *r
+		if !filter.Cutoff.IsZero() {
+			if !r.Date.Before(filter.Cutoff) {
+				continue
+			}
+			for _, a := range f.applied {
+				if a.InvoiceID == r.InvoiceID && !a.AppliedAt.Before(filter.Cutoff) {
+					cp.AmountPaid = cp.AmountPaid.Sub(a.Amount)
+				}
+			}
+		}
+		if filter.OpenOnly && !cp.Balance().IsPositive() {
+			continue
+		}
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+func (f *fakeReceivableRepo) CreatePayment(_ context.Context, p *entity.Payment) error {
+	if err := f.apply(p.Applications); err != nil {
+		return err
+	}
+	f.payments = append(f.payments, p)
+	return nil
+}
+func (f *fakeReceivableRepo) ApplyPayment(_ context.Context, paymentID string, apps []*entity.PaymentApplication) error {
+	for _, p := range f.payments {
+		if p.ID == paymentID {
+			total := sumApplications(apps)
+			if p.UnappliedAmount.LessThan(total) {
+				return domain.ErrConflict
+			}
+			if err := f.apply(apps); err != nil {
+				return err
+			}
+			p.UnappliedAmount = p.UnappliedAmount.Sub(total)
+			return nil
+		}
+	}
+	return domain.ErrConflict
+}
+func (f *fakeReceivableRepo) apply(apps []*entity.PaymentApplication) error {
+	for _, a := range apps {
+		for _, r := range f.invoices {
+			if r.InvoiceID == a.InvoiceID {
+				if r.Balance().LessThan(a.Amount) {
+					return domain.ErrConflict
+				}
+				r.AmountPaid = r.AmountPaid.Add(a.Amount)
+			}
+		}
+		f.applied = append(f.applied, a)
+	}
+	return nil
+}
+func (f *fakeReceivableRepo) GetPayment(_ context.Context, id string) (*entity.Payment, error) {
+	for _, p := range f.payments {
+		if p.ID == id {
+			cp := *p
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+func (f *fakeReceivableRepo) ListPayments(_ context.Context, _ string, filter PaymentFilter) ([]*entity.Payment, error) {
+	out := make([]*entity.Payment, 0)
+	for _, p := range f.payments {
+		if filter.CustomerID != "" && p.CustomerID != filter.CustomerID {
+			continue
+		}
+		cp := *p
+		if !filter.Cutoff.IsZero() {
+			if !p.Date.Before(filter.Cutoff) {
+				continue
+			}
+			for _, a := range f.applied {
+				if a.PaymentID == p.ID && !a.AppliedAt.Before(filter.Cutoff) {
+					cp.UnappliedAmount = cp.UnappliedAmount.Add(a.Amount)
+				}
+			}
+		}
+		if filter.UnappliedOnly && !cp.UnappliedAmount.IsPositive() {
+			continue
+		}
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+var _ ReceivableRepository = (*fakeReceivableRepo)(nil)
+
+func receivableInvoice(id string, date, due time.Time, total int64) *entity.ReceivableInvoice {
+	return &entity.ReceivableInvoice{
+		InvoiceID: id, CustomerID: testCustomerID, CustomerName: "Cliente", Prefix: "FV", Number: id,
+		Date: date, DueDate: due, CurrencyCode: "COP", Total: decimal.NewFromInt(total),
+	}
+}
+
+func newReceivableUseCase(repo *fakeReceivableRepo) *ReceivableUseCase {
+	customers := &fakeCustomerRepo{getByIDFunc: func(id string) (*entity.Customer, error) {
+		if id != testCustomerID {
+			return nil, nil
+		}
+		return &entity.Customer{ID: testCustomerID, CompanyID: testCompanyID, Name: "Cliente"}, nil
+	}}
+	return NewReceivableUseCase(repo, customers)
+}
+
+func TestReceivableUseCase_RegisterPayment(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("auto-aplica a las facturas más antiguas y deja saldo a favor", func(t *testing.T) {
+		repo := &fakeReceivableRepo{invoices: []*entity.ReceivableInvoice{
+			receivableInvoice("2", day(5), day(20), 500),
+			receivableInvoice("1", day(1), day(10), 300),
+		}}
+		uc := newReceivableUseCase(repo)
+
+		out, err := uc.RegisterPayment(context.Background(), testCompanyID, "user-1", dto.RegisterPaymentRequest{
+			CustomerID: testCustomerID, Date: "2026-01-25", Amount: decimal.NewFromInt(400), MethodCode: "47",
+		})
+		require.NoError(t, err)
+		require.Len(t, out.Applications, 2)
+		assert.Equal(t, "1", out.Applications[0].InvoiceID)
+		assert.True(t, out.Applications[0].Amount.Equal(decimal.NewFromInt(300)))
+		assert.True(t, out.Applications[1].Amount.Equal(decimal.NewFromInt(100)))
+		assert.True(t, out.UnappliedAmount.IsZero())
+		assert.Equal(t, entity.PaymentStatusPartial, repo.invoices[0].PaymentStatus())
+		assert.Equal(t, entity.PaymentStatusPaid, repo.invoices[1].PaymentStatus())
+
+		over, err := uc.RegisterPayment(context.Background(), testCompanyID, "user-1", dto.RegisterPaymentRequest{
+			CustomerID: testCustomerID, Amount: decimal.NewFromInt(1000),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "10", over.MethodCode)
+		assert.True(t, over.UnappliedAmount.Equal(decimal.NewFromInt(600)))
+
+		repo.invoices = append(repo.invoices, receivableInvoice("3", day(26), day(26), 250))
+		applied, err := uc.ApplyCredit(context.Background(), testCompanyID, over.ID, dto.ApplyPaymentRequest{
+			Applications: []dto.PaymentApplicationRequest{{InvoiceID: "3", Amount: decimal.NewFromInt(250)}},
+		})
+		require.NoError(t, err)
+		assert.True(t, applied.UnappliedAmount.Equal(decimal.NewFromInt(350)))
+		assert.Equal(t, entity.PaymentStatusPaid, repo.invoices[2].PaymentStatus())
+	})
+
+	t.Run("rechaza entradas inválidas", func(t *testing.T) {
+		repo := &fakeReceivableRepo{invoices: []*entity.ReceivableInvoice{receivableInvoice("1", day(1), day(10), 300)}}
+		uc := newReceivableUseCase(repo)
+		invalid := []dto.RegisterPaymentRequest{
+			{CustomerID: testCustomerID, Amount: decimal.Zero},
+			{CustomerID: testCustomerID, Amount: decimal.NewFromInt(10), MethodCode: "99"},
+			{CustomerID: testCustomerID, Amount: decimal.NewFromInt(10), CurrencyCode: "XXX"},
+			{CustomerID: testCustomerID, Amount: decimal.NewFromInt(10), Date: "25/01/2026"},
+			{CustomerID: testCustomerID, Amount: decimal.NewFromInt(500), Applications: []dto.PaymentApplicationRequest{
+				{InvoiceID: "1", Amount: decimal.NewFromInt(301)},
+			}},
+			{CustomerID: testCustomerID, Amount: decimal.NewFromInt(100), Applications: []dto.PaymentApplicationRequest{
+				{InvoiceID: "1", Amount: decimal.NewFromInt(150)},
+			}},
+			{CustomerID: testCustomerID, Amount: decimal.NewFromInt(100), Applications: []dto.PaymentApplicationRequest{
+				{InvoiceID: "otra", Amount: decimal.NewFromInt(50)},
+			}},
+		}
+		for _, in := range invalid {
+			_, err := uc.RegisterPayment(context.Background(), testCompanyID, "", in)
+			assert.True(t, errors.Is(err, domain.ErrInvalidInput), "in: %+v", in)
+		}
+		_, err := uc.RegisterPayment(context.Background(), testCompanyID, "", dto.RegisterPaymentRequest{
+			CustomerID: "desconocido", Amount: decimal.NewFromInt(10),
+		})
+		assert.True(t, errors.Is(err, domain.ErrNotFound))
+		assert.Empty(t, repo.payments)
+	})
+}
+
+func TestReceivableUseCase_StatementAndAging(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	repo := &fakeReceivableRepo{invoices: []*entity.ReceivableInvoice{
+		receivableInvoice("A", day(1, 1), day(1, 1), 100),   // 120 días vencida al 1-may
+		receivableInvoice("B", day(3, 1), day(3, 15), 200),  // 47 días
+		receivableInvoice("C", day(4, 10), day(4, 20), 300), // 11 días
+		receivableInvoice("D", day(4, 25), day(5, 25), 400), // corriente
+	}}
+	repo.invoices[1].AmountPaid = decimal.NewFromInt(50)
+	repo.payments = []*entity.Payment{{
+		ID: "p1", CompanyID: testCompanyID, CustomerID: testCustomerID, Date: day(3, 20), CurrencyCode: "COP",
+		Amount: decimal.NewFromInt(80), UnappliedAmount: decimal.NewFromInt(30),
+	}}
+	uc := newReceivableUseCase(repo)
+
+	aging, err := uc.GetAging(context.Background(), testCompanyID, "", day(5, 1))
+	require.NoError(t, err)
+	require.Len(t, aging.Customers, 1)
+	assert.True(t, aging.Totals.Over90.Equal(decimal.NewFromInt(100)))
+	assert.True(t, aging.Totals.Days31To60.Equal(decimal.NewFromInt(150)))
+	assert.True(t, aging.Totals.Days1To30.Equal(decimal.NewFromInt(300)))
+	assert.True(t, aging.Totals.Current.Equal(decimal.NewFromInt(400)))
+	assert.True(t, aging.Totals.Total.Equal(decimal.NewFromInt(950)))
+	assert.True(t, aging.Customers[0].UnappliedCredit.Equal(decimal.NewFromInt(30)))
+
+	st, err := uc.GetStatement(context.Background(), testCompanyID, testCustomerID, "", day(3, 1), day(4, 30))
+	require.NoError(t, err)
+	assert.True(t, st.OpeningBalance.Equal(decimal.NewFromInt(100)))
+	require.Len(t, st.Entries, 4)
+	assert.Equal(t, []string{"INVOICE", "PAYMENT", "INVOICE", "INVOICE"},
+		[]string{st.Entries[0].Type, st.Entries[1].Type, st.Entries[2].Type, st.Entries[3].Type})
+	assert.True(t, st.Entries[1].Balance.Equal(decimal.NewFromInt(220)))
+	assert.True(t, st.ClosingBalance.Equal(decimal.NewFromInt(920)))
+	assert.True(t, st.UnappliedCredit.Equal(decimal.NewFromInt(30)))
+	assert.Len(t, st.OpenInvoices, 4)
+}
+
+func TestReceivableUseCase_AgingAsOfIgnoresLaterActivity(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	repo := &fakeReceivableRepo{invoices: []*entity.ReceivableInvoice{
+		receivableInvoice("A", day(1, 1), day(1, 1), 100),
+		receivableInvoice("B", day(3, 1), day(3, 15), 200),
+		receivableInvoice("E", day(5, 10), day(5, 10), 500), // emitida después del corte
+	}}
+	repo.payments = []*entity.Payment{{
+		ID: "p1", CompanyID: testCompanyID, CustomerID: testCustomerID, Date: day(3, 20), CurrencyCode: "COP",
+		Amount: decimal.NewFromInt(80), UnappliedAmount: decimal.NewFromInt(80),
+	}}
+	uc := newReceivableUseCase(repo)
+	ctx := context.Background()
+
+	// Después del corte: un recaudo fechado el 2-may salda A y el saldo a favor de p1 se cruza hoy con B.
+	_, err := uc.RegisterPayment(ctx, testCompanyID, "", dto.RegisterPaymentRequest{
+		CustomerID: testCustomerID, Amount: decimal.NewFromInt(100), Date: "2026-05-02",
+		Applications: []dto.PaymentApplicationRequest{{InvoiceID: "A", Amount: decimal.NewFromInt(100)}},
+	})
+	require.NoError(t, err)
+	_, err = uc.ApplyCredit(ctx, testCompanyID, "p1", dto.ApplyPaymentRequest{
+		Applications: []dto.PaymentApplicationRequest{{InvoiceID: "B", Amount: decimal.NewFromInt(80)}},
+	})
+	require.NoError(t, err)
+
+	aging, err := uc.GetAging(ctx, testCompanyID, "", day(5, 1))
+	require.NoError(t, err)
+	require.Len(t, aging.Customers, 1)
+	assert.True(t, aging.Totals.Over90.Equal(decimal.NewFromInt(100)), "A seguía pendiente al 1-may")
+	assert.True(t, aging.Totals.Days31To60.Equal(decimal.NewFromInt(200)), "B sin el cruce posterior")
+	assert.True(t, aging.Totals.Total.Equal(decimal.NewFromInt(300)), "E no existía al corte")
+	assert.True(t, aging.Customers[0].UnappliedCredit.Equal(decimal.NewFromInt(80)))
+
+	current, err := uc.GetAging(ctx, testCompanyID, "", time.Now())
+	require.NoError(t, err)
+	assert.True(t, current.Totals.Total.Equal(decimal.NewFromInt(620)), "B 120 + E 500")
+	assert.True(t, current.Customers[0].UnappliedCredit.IsZero())
+}
+
+func TestReceivableUseCase_OnlyIssuedInvoices(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	for _, status := range entity.ReceivableDIANStatuses {
+		t.Run("incluye "+status, func(t *testing.T) {
+			repo := &fakeReceivableRepo{
+				invoices: []*entity.ReceivableInvoice{receivableInvoice("A", day(4, 1), day(4, 1), 100)},
+				statuses: map[string]string{"A": status},
+			}
+			aging, err := newReceivableUseCase(repo).GetAging(ctx, testCompanyID, "", day(5, 1))
+			require.NoError(t, err)
+			assert.True(t, aging.Totals.Total.Equal(decimal.NewFromInt(100)))
+		})
+	}
+
+	excluded := []string{
+		entity.DIANStatusDraft,
+		entity.DIANStatusErrorGeneration,
+		entity.DIANStatusError,
+		entity.DIANStatusRechazado,
+	}
+	for _, status := range excluded {
+		t.Run("excluye "+status, func(t *testing.T) {
+			repo := &fakeReceivableRepo{
+				invoices: []*entity.ReceivableInvoice{
+					receivableInvoice("A", day(4, 1), day(4, 1), 100),
+					receivableInvoice("X", day(4, 2), day(4, 2), 500),
+				},
+				statuses: map[string]string{"X": status},
+			}
+			uc := newReceivableUseCase(repo)
+
+			aging, err := uc.GetAging(ctx, testCompanyID, "", day(5, 1))
+			require.NoError(t, err)
+			assert.True(t, aging.Totals.Total.Equal(decimal.NewFromInt(100)), "total %s", aging.Totals.Total)
+
+			_, err = uc.RegisterPayment(ctx, testCompanyID, "", dto.RegisterPaymentRequest{
+				CustomerID: testCustomerID, Amount: decimal.NewFromInt(50),
+				Applications: []dto.PaymentApplicationRequest{{InvoiceID: "X", Amount: decimal.NewFromInt(50)}},
+			})
+			assert.ErrorIs(t, err, domain.ErrInvalidInput)
+			assert.Empty(t, repo.payments)
+		})
+	}
+}
@@ -0,0 +1,523 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+var cufeRe = regexp.MustCompile(`^[0-9a-fA-F]{96}$`)
+
+// ReceivedDocumentUseCase registra las facturas electrónicas recibidas de proveedores y emite sobre ellas
+// los eventos RADIAN del adquiriente (030 acuse, 032 recibo del bien, 033 aceptación expresa, 031 reclamo)
+// con el mismo ciclo de los documentos de la empresa:
+//
+//	CUDE → ApplicationResponse → Firma XAdES-EPES → ZIP → SendEventUpdateStatus
+//
+// Vencido el término sin reclamo ni aceptación, deja la factura aceptada tácitamente (034).
+type ReceivedDocumentUseCase struct {
+	repo         ReceivedDocumentRepository
+	companyRepo  repository.CompanyRepository
+	supplierRepo repository.SupplierRepository
+	credentials  DIANCredentialsProvider
+	events       RADIANEventBuilder
+	signer       pkgdian.Signer
+	submitter    DIANEventSubmitter // nil en dev
+	invoices     ReceivedInvoiceReader
+	attachments  EmailInvoiceAttachmentRepository
+	orders       OpenPurchaseOrderLister
+	now          func() time.Time
+	dispatch     func(id string) // procesamiento DIAN del evento tras persistirlo; por defecto en goroutine
+}
+
+// NewReceivedDocumentUseCase construye el caso de uso. submitter puede ser nil: solo funciona el modo dev.
+func NewReceivedDocumentUseCase(
+	repo ReceivedDocumentRepository,
+	companyRepo repository.CompanyRepository,
+	supplierRepo repository.SupplierRepository,
+	credentials DIANCredentialsProvider,
+	events RADIANEventBuilder,
+	signer pkgdian.Signer,
+	submitter DIANEventSubmitter,
+) *ReceivedDocumentUseCase {
+	uc := &ReceivedDocumentUseCase{
+		repo:         repo,
+		companyRepo:  companyRepo,
+		supplierRepo: supplierRepo,
+		credentials:  credentials,
+		events:       events,
+		signer:       signer,
+		submitter:    submitter,
+		now:          time.Now,
+	}
+	uc.dispatch = func(id string) { go uc.processDetached(id) }
+	return uc
+}
+
+// SetMailboxIngestion habilita la ingesta de facturas desde los adjuntos del buzón (IngestMailbox), que
+// invoices lee y verifica, y la conciliación con órdenes de compra abiertas; orders puede ser nil.
+func (uc *ReceivedDocumentUseCase) SetMailboxIngestion(invoices ReceivedInvoiceReader, attachments EmailInvoiceAttachmentRepository, orders OpenPurchaseOrderLister) {
+	uc.invoices = invoices
+	uc.attachments = attachments
+	uc.orders = orders
+}
+
+// Register registra una factura recibida de un proveedor; la asocia al proveedor de la empresa con el
+// mismo NIT si existe.
+func (uc *ReceivedDocumentUseCase) Register(ctx context.Context, companyID, userID string, in dto.RegisterReceivedDocumentRequest) (*dto.ReceivedDocumentDTO, error) {
+	in.IssuerNIT = strings.TrimSpace(in.IssuerNIT)
+	in.IssuerName = strings.TrimSpace(in.IssuerName)
+	in.Number = strings.ToUpper(strings.Join(strings.Fields(in.Number), ""))
+	in.CUFE = strings.ToLower(strings.TrimSpace(in.CUFE))
+	if in.IssuerNIT == "" || in.IssuerName == "" || in.Number == "" {
+		return nil, fmt.Errorf("%w: issuer_nit, issuer_name y number son requeridos", domain.ErrInvalidInput)
+	}
+	if !cufeRe.MatchString(in.CUFE) {
+		return nil, fmt.Errorf("%w: cufe debe ser un SHA-384 de 96 caracteres hexadecimales", domain.ErrInvalidInput)
+	}
+	issueDate, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(in.IssueDate), uc.now().Location())
+	if err != nil {
+		return nil, fmt.Errorf("%w: issue_date debe tener formato YYYY-MM-DD", domain.ErrInvalidInput)
+	}
+	if in.NetTotal.IsNegative() || in.TaxTotal.IsNegative() || in.GrandTotal.IsNegative() {
+		return nil, fmt.Errorf("%w: los totales no pueden ser negativos", domain.ErrInvalidInput)
+	}
+	if in.GrandTotal.IsZero() {
+		in.GrandTotal = in.NetTotal.Add(in.TaxTotal)
+	}
+
+	var supplierID string
+	supplier, err := uc.supplierRepo.GetByCompanyAndNIT(companyID, in.IssuerNIT)
+	if err != nil {
+		return nil, err
+	}
+	if supplier != nil {
+		supplierID = supplier.ID
+	}
+
+	now := uc.now()
+	doc := &entity.ReceivedDocument{
+		ID:               uuid.New().String(),
+		CompanyID:        companyID,
+		SupplierID:       supplierID,
+		IssuerNIT:        in.IssuerNIT,
+		IssuerName:       in.IssuerName,
+		Number:           in.Number,
+		DocumentTypeCode: pkgdian.InvoiceTypeVenta,
+		CUFE:             in.CUFE,
+		IssueDate:        issueDate,
+		NetTotal:         in.NetTotal,
+		TaxTotal:         in.TaxTotal,
+		GrandTotal:       in.GrandTotal,
+		XML:              in.XML,
+		Source:           entity.ReceivedDocSourceManual,
+		Status:           entity.ReceivedDocStatusReceived,
+		CreatedBy:        userID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := uc.repo.Create(ctx, doc); err != nil {
+		return nil, err
+	}
+	return toReceivedDocumentDTO(doc), nil
+}
+
+// Get devuelve la factura recibida con su historial de eventos.
+func (uc *ReceivedDocumentUseCase) Get(ctx context.Context, companyID, id string) (*dto.ReceivedDocumentDTO, error) {
+	doc, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.CompanyID != companyID {
+		return nil, domain.ErrNotFound
+	}
+	return toReceivedDocumentDTO(doc), nil
+}
+
+// List devuelve las facturas recibidas de la empresa (status vacío = todas).
+func (uc *ReceivedDocumentUseCase) List(ctx context.Context, companyID, status string) ([]dto.ReceivedDocumentDTO, error) {
+	docs, err := uc.repo.List(ctx, companyID, strings.ToUpper(strings.TrimSpace(status)))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.ReceivedDocumentDTO, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, *toReceivedDocumentDTO(d))
+	}
+	return out, nil
+}
+
+// EmitEvent registra un evento del adquiriente sobre la factura recibida y lo envía a la DIAN en segundo
+// plano. El evento anterior de la secuencia debe estar validado; domain.ErrConflict si el orden no lo
+// permite o si venció el término para aceptar o reclamar.
+func (uc *ReceivedDocumentUseCase) EmitEvent(ctx context.Context, companyID, userID, documentID string, in dto.CreateReceivedDocumentEventRequest) (*dto.ReceivedDocumentEventDTO, error) {
+	code := strings.TrimSpace(in.EventCode)
+	ev := &entity.ReceivedDocumentEvent{
+		ID:                 uuid.New().String(),
+		ReceivedDocumentID: documentID,
+		CompanyID:          companyID,
+		EventCode:          code,
+		ClaimConcept:       strings.TrimSpace(in.ClaimConcept),
+		Note:               strings.TrimSpace(in.Note),
+		DIANStatus:         entity.DIANStatusDraft,
+		CreatedBy:          userID,
+	}
+	if in.Person != nil {
+		ev.PersonID = strings.TrimSpace(in.Person.Identification)
+		ev.PersonName = strings.TrimSpace(in.Person.Name)
+		ev.PersonJobTitle = strings.TrimSpace(in.Person.JobTitle)
+	}
+	switch code {
+	case pkgdian.EventAcuseRecibo, pkgdian.EventReciboBien:
+		if ev.PersonID == "" || ev.PersonName == "" {
+			return nil, fmt.Errorf("%w: el evento %s requiere la identificación y el nombre de quien recibe", domain.ErrInvalidInput, code)
+		}
+	case pkgdian.EventReclamo:
+		if !pkgdian.ValidClaimConcepts[ev.ClaimConcept] {
+			return nil, fmt.Errorf("%w: claim_concept debe estar entre 01 y 04", domain.ErrInvalidInput)
+		}
+	case pkgdian.EventAceptacionExpresa:
+	default:
+		return nil, fmt.Errorf("%w: event_code debe ser 030, 031, 032 o 033", domain.ErrInvalidInput)
+	}
+	if code != pkgdian.EventReclamo {
+		ev.ClaimConcept = ""
+	}
+
+	doc, err := uc.repo.GetByID(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.CompanyID != companyID {
+		return nil, domain.ErrNotFound
+	}
+	validated, pending := eventHistory(doc)
+	if err := domaindian.CheckEventSequence(code, validated, pending); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrConflict, err)
+	}
+	now := uc.now()
+	if (code == pkgdian.EventAceptacionExpresa || code == pkgdian.EventReclamo) && doc.GoodsReceivedAt != nil &&
+		!now.Before(domaindian.TacitAcceptanceDeadline(*doc.GoodsReceivedAt)) {
+		return nil, fmt.Errorf("%w: venció el término para aceptar o reclamar; la factura quedó aceptada tácitamente", domain.ErrConflict)
+	}
+
+	ev.Date, ev.CreatedAt, ev.UpdatedAt = now, now, now
+	if err := uc.repo.CreateEvent(ctx, ev, nil); err != nil {
+		return nil, err
+	}
+	uc.dispatch(ev.ID)
+	out := toReceivedDocumentEventDTO(ev)
+	return &out, nil
+}
+
+// processDetached procesa el evento con su propio contexto, desacoplado del ciclo HTTP.
+func (uc *ReceivedDocumentUseCase) processDetached(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := uc.ProcessEvent(ctx, id); err != nil {
+		log.Printf("[DIAN][RADIAN][%s] %v", id, err)
+	}
+}
+
+// ProcessEvent calcula el CUDE, genera y firma el ApplicationResponse y lo envía a la DIAN. Los errores
+// de generación dejan el evento en ERROR_GENERATION; los timeouts del WS en CONTINGENCIA para el worker.
+func (uc *ReceivedDocumentUseCase) ProcessEvent(ctx context.Context, id string) error {
+	ev, err := uc.repo.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+	if ev == nil {
+		return domain.ErrNotFound
+	}
+	if ev.DIANStatus != entity.DIANStatusDraft {
+		return nil // ya procesado
+	}
+	doc, err := uc.repo.GetByID(ctx, ev.ReceivedDocumentID)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return uc.markEventError(ctx, ev, "factura recibida "+ev.ReceivedDocumentID+" no encontrada")
+	}
+	company, err := uc.companyRepo.GetByID(ev.CompanyID)
+	if err != nil || company == nil {
+		return uc.markEventError(ctx, ev, fmt.Sprintf("empresa %s no encontrada: %v", ev.CompanyID, err))
+	}
+	creds, err := uc.credentials.Resolve(ctx, company)
+	if err != nil {
+		return uc.markEventError(ctx, ev, err.Error())
+	}
+	if creds.SoftwarePIN == "" {
+		return uc.markEventError(ctx, ev, "el CUDE del evento requiere el PIN del software DIAN de la empresa")
+	}
+
+	xmlBytes, err := uc.events.BuildRADIANEvent(ev, doc, company, creds.SoftwareID, creds.SoftwarePIN, creds.TipoAmbiente)
+	if err != nil {
+		return uc.markEventError(ctx, ev, err.Error())
+	}
+	signed, err := uc.signer.Sign(xmlBytes, creds.Certificate)
+	if err != nil {
+		return uc.markEventError(ctx, ev, err.Error())
+	}
+	ev.XMLSigned = string(signed)
+	ev.DIANStatus = entity.DIANStatusSigned
+	ev.UpdatedAt = uc.now()
+	if err := uc.repo.UpdateEvent(ctx, ev, nil); err != nil {
+		return err
+	}
+	return uc.submitEvent(ctx, ev, doc, company, creds.AppEnv)
+}
+
+// submitEvent envía el evento firmado según el ambiente: en dev simula la validación; en test/prod usa
+// SendEventUpdateStatus, que responde la validación de forma síncrona. Un evento validado actualiza el
+// estado RADIAN de la factura.
+func (uc *ReceivedDocumentUseCase) submitEvent(ctx context.Context, ev *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument, company *entity.Company, appEnv string) error {
+	zipName, zipBytes, err := uc.events.PackageRADIANEvent(ev, company)
+	if err != nil {
+		return uc.markEventError(ctx, ev, err.Error())
+	}
+	now := uc.now()
+	ev.UpdatedAt = now
+	switch appEnv {
+	case domaindian.AppEnvDev, "":
+		log.Printf("[DIAN][RADIAN][%s] [DEV] Simulando envío del evento %s — ZIP generado: %s (%d bytes)", ev.ID, ev.EventCode, zipName, len(zipBytes))
+		ev.DIANStatus, ev.TrackID, ev.DIANErrors, ev.NextCheckAt = entity.DIANStatusExitoso, "MOCK-TRACK-123", "", nil
+	case domaindian.AppEnvTest, domaindian.AppEnvProd:
+		if uc.submitter == nil {
+			return uc.markEventError(ctx, ev, "DIANEventSubmitter no inyectado para entorno "+appEnv)
+		}
+		res, err := uc.submitter.SendEventUpdateStatus(ctx, zipBytes, appEnv)
+		if err != nil && !isDIANTimeoutError(err) {
+			return uc.markEventError(ctx, ev, err.Error())
+		}
+		if err != nil || res.IsPending() {
+			ev.StatusChecks++
+			if ev.StatusChecks >= statusCheckMaxAttempts {
+				ev.DIANStatus, ev.NextCheckAt = entity.DIANStatusError, nil
+				ev.DIANErrors = fmt.Sprintf("sin respuesta de la DIAN tras %d envíos del evento", ev.StatusChecks)
+				break
+			}
+			next := now.Add(statusCheckBackoff(ev.StatusChecks))
+			ev.DIANStatus, ev.NextCheckAt = entity.DIANStatusContingencia, &next
+			if err != nil {
+				ev.DIANErrors = err.Error()
+			}
+			log.Printf("[DIAN][RADIAN][%s] sin respuesta de la DIAN: evento en CONTINGENCIA", ev.ID)
+			break
+		}
+		ev.DIANStatus, ev.DIANErrors, _ = validationOutcome(res)
+		ev.TrackID, ev.NextCheckAt = res.DocumentKey, nil
+	default:
+		return uc.markEventError(ctx, ev, fmt.Sprintf("DIAN_ENV desconocido: %q (usar dev|test|prod)", appEnv))
+	}
+
+	var updated *entity.ReceivedDocument
+	if ev.DIANStatus == entity.DIANStatusExitoso {
+		applyEvent(doc, ev, now)
+		updated = doc
+	}
+	if err := uc.repo.UpdateEvent(ctx, ev, updated); err != nil {
+		return err
+	}
+	log.Printf("[DIAN][RADIAN][%s] evento %s de la factura %s → %s", ev.ID, ev.EventCode, doc.Number, ev.DIANStatus)
+	return nil
+}
+
+// AdvancePending reenvía los eventos que quedaron en CONTINGENCIA (lo invoca ReceivedDocumentWorker).
+func (uc *ReceivedDocumentUseCase) AdvancePending(ctx context.Context, limit int) error {
+	if uc.submitter == nil {
+		return nil
+	}
+	events, err := uc.repo.ListPendingEvents(ctx, uc.now(), limit)
+	if err != nil {
+		return err
+	}
+	for _, ev := range events {
+		doc, err := uc.repo.GetByID(ctx, ev.ReceivedDocumentID)
+		if err != nil || doc == nil {
+			log.Printf("[DIAN][RADIAN][%s] factura recibida %s no encontrada: %v", ev.ID, ev.ReceivedDocumentID, err)
+			continue
+		}
+		company, err := uc.companyRepo.GetByID(ev.CompanyID)
+		if err != nil || company == nil {
+			log.Printf("[DIAN][RADIAN][%s] empresa %s no encontrada: %v", ev.ID, ev.CompanyID, err)
+			continue
+		}
+		creds, err := uc.credentials.Resolve(ctx, company)
+		if err != nil {
+			log.Printf("[DIAN][RADIAN][%s] credenciales de la empresa %s: %v", ev.ID, ev.CompanyID, err)
+			continue
+		}
+		if err := uc.submitEvent(ctx, ev, doc, company, creds.AppEnv); err != nil {
+			log.Printf("[DIAN][RADIAN][%s] %v", ev.ID, err)
+		}
+	}
+	return nil
+}
+
+// ApplyTacitAcceptance deja aceptadas tácitamente las facturas con recibo del bien validado cuyo término
+// venció sin reclamo ni aceptación expresa. El evento 034 lo emite el facturador electrónico: aquí solo
+// queda en el historial (LOCAL). Devuelve cuántas facturas cambió.
+func (uc *ReceivedDocumentUseCase) ApplyTacitAcceptance(ctx context.Context, limit int) (int, error) {
+	now := uc.now()
+	// Tres días hábiles son al menos tres días calendario; el término exacto se verifica por factura.
+	docs, err := uc.repo.ListGoodsReceivedBefore(ctx, now.AddDate(0, 0, -domaindian.TacitAcceptanceBusinessDays), limit)
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for _, doc := range docs {
+		if doc.GoodsReceivedAt == nil || now.Before(domaindian.TacitAcceptanceDeadline(*doc.GoodsReceivedAt)) {
+			continue
+		}
+		validated, pending := eventHistory(doc)
+		if pending[pkgdian.EventAceptacionExpresa] || pending[pkgdian.EventReclamo] {
+			continue // hay una aceptación o un reclamo en curso; se decide con la respuesta de la DIAN
+		}
+		if domaindian.CheckEventSequence(pkgdian.EventAceptacionExpresa, validated, pending) != nil {
+			continue
+		}
+		ev := &entity.ReceivedDocumentEvent{
+			ID:                 uuid.New().String(),
+			ReceivedDocumentID: doc.ID,
+			CompanyID:          doc.CompanyID,
+			EventCode:          pkgdian.EventAceptacionTacita,
+			Date:               now,
+			Note: fmt.Sprintf("Sin reclamo ni aceptación expresa %d días hábiles después del recibo del bien",
+				domaindian.TacitAcceptanceBusinessDays),
+			DIANStatus: entity.EventDIANStatusLocal,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		applyEvent(doc, ev, now)
+		if err := uc.repo.CreateEvent(ctx, ev, doc); err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				continue
+			}
+			return applied, err
+		}
+		applied++
+	}
+	if applied > 0 {
+		log.Printf("[DIAN][RADIAN] %d factura(s) recibida(s) aceptada(s) tácitamente", applied)
+	}
+	return applied, nil
+}
+
+func (uc *ReceivedDocumentUseCase) markEventError(ctx context.Context, ev *entity.ReceivedDocumentEvent, msg string) error {
+	ev.DIANStatus = entity.DIANStatusErrorGeneration
+	ev.DIANErrors = msg
+	ev.NextCheckAt = nil
+	ev.UpdatedAt = uc.now()
+	if err := uc.repo.UpdateEvent(ctx, ev, nil); err != nil {
+		return err
+	}
+	return fmt.Errorf("evento %s: %s", ev.EventCode, msg)
+}
+
+// eventHistory separa los eventos de la factura en validados (o locales) y en curso; los fallidos no
+// cuentan y pueden volver a emitirse.
+func eventHistory(doc *entity.ReceivedDocument) (validated, pending map[string]bool) {
+	validated, pending = map[string]bool{}, map[string]bool{}
+	for _, e := range doc.Events {
+		switch {
+		case e.IsValidated():
+			validated[e.EventCode] = true
+		case !e.IsFailed():
+			pending[e.EventCode] = true
+		}
+	}
+	return validated, pending
+}
+
+// applyEvent lleva la factura al estado del evento validado; el recibo del bien inicia el término de la
+// aceptación tácita.
+func applyEvent(doc *entity.ReceivedDocument, ev *entity.ReceivedDocumentEvent, now time.Time) {
+	doc.Status = entity.StatusForEvent(ev.EventCode)
+	if ev.EventCode == pkgdian.EventReciboBien {
+		doc.GoodsReceivedAt = &now
+	}
+	doc.UpdatedAt = now
+}
+
+func toReceivedDocumentDTO(d *entity.ReceivedDocument) *dto.ReceivedDocumentDTO {
+	out := &dto.ReceivedDocumentDTO{
+		ID:               d.ID,
+		SupplierID:       d.SupplierID,
+		IssuerNIT:        d.IssuerNIT,
+		IssuerName:       d.IssuerName,
+		Number:           d.Number,
+		CUFE:             d.CUFE,
+		IssueDate:        d.IssueDate,
+		NetTotal:         d.NetTotal,
+		TaxTotal:         d.TaxTotal,
+		GrandTotal:       d.GrandTotal,
+		Currency:         d.Currency,
+		PurchaseOrderID:  d.PurchaseOrderID,
+		SignatureValid:   d.SignatureValid,
+		CUFEValid:        d.CUFEValid,
+		ValidationErrors: d.ValidationErrors,
+		Source:           d.Source,
+		Status:           d.Status,
+		GoodsReceivedAt:  d.GoodsReceivedAt,
+		CreatedAt:        d.CreatedAt,
+	}
+	if d.GoodsReceivedAt != nil && d.Status == entity.ReceivedDocStatusGoodsReceived {
+		deadline := domaindian.TacitAcceptanceDeadline(*d.GoodsReceivedAt)
+		out.TacitAcceptanceFrom = &deadline
+	}
+	for _, l := range d.Lines {
+		out.Lines = append(out.Lines, dto.ReceivedDocumentLineDTO{
+			LineNumber:    l.LineNumber,
+			ItemCode:      l.ItemCode,
+			Description:   l.Description,
+			Quantity:      l.Quantity,
+			UnitCode:      l.UnitCode,
+			UnitPrice:     l.UnitPrice,
+			LineExtension: l.LineExtension,
+			TaxAmount:     l.TaxAmount,
+		})
+	}
+	for _, t := range d.Taxes {
+		out.Taxes = append(out.Taxes, dto.ReceivedDocumentTaxDTO{
+			TaxCode:       t.TaxCode,
+			TaxName:       t.TaxName,
+			Percent:       t.Percent,
+			TaxableAmount: t.TaxableAmount,
+			TaxAmount:     t.TaxAmount,
+		})
+	}
+	for _, e := range d.Events {
+		out.Events = append(out.Events, toReceivedDocumentEventDTO(e))
+	}
+	return out
+}
+
+func toReceivedDocumentEventDTO(e *entity.ReceivedDocumentEvent) dto.ReceivedDocumentEventDTO {
+	return dto.ReceivedDocumentEventDTO{
+		ID:           e.ID,
+		EventCode:    e.EventCode,
+		EventName:    pkgdian.EventNames[e.EventCode],
+		Number:       e.Number,
+		Date:         e.Date,
+		ClaimConcept: e.ClaimConcept,
+		Note:         e.Note,
+		CUDE:         e.CUDE,
+		DIANStatus:   e.DIANStatus,
+		DIANErrors:   e.DIANErrors,
+		TrackID:      e.TrackID,
+	}
+}
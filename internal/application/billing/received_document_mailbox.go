@@ -0,0 +1,234 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// IngestMailbox procesa los adjuntos ZIP/XML sincronizados de los buzones de las empresas: lee el
+// AttachedDocument, valida la firma del proveedor y el CUFE, concilia proveedor y orden de compra y
+// registra la factura recibida (origen EMAIL). Cada adjunto queda marcado con su resultado para no volver
+// a leerlo. Devuelve cuántas facturas registró; sin SetMailboxIngestion no hace nada.
+func (uc *ReceivedDocumentUseCase) IngestMailbox(ctx context.Context, limit int) (int, error) {
+	if uc.attachments == nil {
+		return 0, nil
+	}
+	pending, err := uc.attachments.ListPending(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	imported := 0
+	for _, a := range pending {
+		res := uc.ingestAttachment(ctx, a)
+		res.AttachmentID, res.CompanyID, res.ProcessedAt = a.ID, a.CompanyID, uc.now()
+		if err := uc.attachments.MarkProcessed(ctx, res); err != nil {
+			return imported, err
+		}
+		switch res.Status {
+		case entity.EmailInvoiceImported:
+			imported++
+		case entity.EmailInvoiceFailed:
+			log.Printf("[DIAN][BUZON][%s] %s (%s): %s", a.CompanyID, a.FileName, a.FromAddress, res.Error)
+		}
+	}
+	if imported > 0 {
+		log.Printf("[DIAN][BUZON] %d factura(s) de proveedor registrada(s) desde el buzón", imported)
+	}
+	return imported, nil
+}
+
+func (uc *ReceivedDocumentUseCase) ingestAttachment(ctx context.Context, a *entity.EmailInvoiceAttachment) *entity.EmailInvoiceIngestion {
+	parsed, err := uc.invoices.ParseReceivedInvoice(a.FileName, a.Content)
+	if errors.Is(err, domaindian.ErrNotElectronicInvoice) {
+		return &entity.EmailInvoiceIngestion{Status: entity.EmailInvoiceIgnored, Error: err.Error()}
+	}
+	if err != nil {
+		return &entity.EmailInvoiceIngestion{Status: entity.EmailInvoiceFailed, Error: err.Error()}
+	}
+	company, err := uc.companyRepo.GetByID(a.CompanyID)
+	if err != nil || company == nil {
+		return &entity.EmailInvoiceIngestion{Status: entity.EmailInvoiceFailed, Error: fmt.Sprintf("empresa %s no encontrada: %v", a.CompanyID, err)}
+	}
+	doc := parsed.Document
+	if !sameNIT(doc.CustomerNIT, company.NIT) {
+		return &entity.EmailInvoiceIngestion{
+			Status: entity.EmailInvoiceIgnored,
+			Error:  fmt.Sprintf("la factura %s está dirigida al NIT %s, no a la empresa", doc.Number, doc.CustomerNIT),
+		}
+	}
+
+	signatureOK, cufeOK, problems := uc.verifyReceivedInvoice(parsed)
+	now := uc.now()
+	doc.ID = uuid.New().String()
+	doc.CompanyID = a.CompanyID
+	doc.EmailAttachmentID = a.ID
+	doc.Source = entity.ReceivedDocSourceEmail
+	doc.Status = entity.ReceivedDocStatusReceived
+	doc.SignatureValid, doc.CUFEValid = &signatureOK, &cufeOK
+	doc.ValidationErrors = strings.Join(problems, "; ")
+	doc.XML = string(parsed.InvoiceXML)
+	if parsed.ContainerXML != nil {
+		doc.XML = string(parsed.ContainerXML)
+	}
+	doc.CreatedAt, doc.UpdatedAt = now, now
+
+	supplier, err := uc.findSupplier(a.CompanyID, doc.IssuerNIT)
+	if err != nil {
+		return &entity.EmailInvoiceIngestion{Status: entity.EmailInvoiceFailed, Error: err.Error()}
+	}
+	if supplier != nil {
+		doc.SupplierID = supplier.ID
+		if doc.IssuerName == "" {
+			doc.IssuerName = supplier.Name
+		}
+		if uc.orders != nil {
+			orders, err := uc.orders.ListOpenBySupplier(ctx, a.CompanyID, supplier.ID)
+			if err != nil {
+				return &entity.EmailInvoiceIngestion{Status: entity.EmailInvoiceFailed, Error: err.Error()}
+			}
+			doc.PurchaseOrderID = matchPurchaseOrder(orders, parsed.OrderReference, doc.NetTotal)
+		}
+	}
+
+	if err := uc.repo.Create(ctx, doc); err != nil {
+		if errors.Is(err, domain.ErrDuplicate) {
+			return &entity.EmailInvoiceIngestion{Status: entity.EmailInvoiceDuplicate, Error: "la factura " + doc.Number + " ya estaba registrada"}
+		}
+		return &entity.EmailInvoiceIngestion{Status: entity.EmailInvoiceFailed, Error: err.Error()}
+	}
+	return &entity.EmailInvoiceIngestion{Status: entity.EmailInvoiceImported, ReceivedDocumentID: doc.ID, Error: doc.ValidationErrors}
+}
+
+// verifyReceivedInvoice valida la firma XAdES del Invoice (cadena hasta una CA de confianza, vigencia del
+// certificado al firmar y que su titular sea el emisor) y el CUFE. La clave técnica del emisor no viaja en
+// el XML, así que el CUFE no se recalcula: se verifica su forma, que coincida con el que declara el
+// AttachedDocument y que la DIAN lo haya validado (dianValidationProblem).
+func (uc *ReceivedDocumentUseCase) verifyReceivedInvoice(inv *domaindian.ReceivedInvoice) (signatureOK, cufeOK bool, problems []string) {
+	signerNIT, err := uc.invoices.VerifySignature(inv.InvoiceXML)
+	switch {
+	case err != nil:
+		problems = append(problems, "firma: "+err.Error())
+	case !sameNIT(signerNIT, inv.Document.IssuerNIT):
+		problems = append(problems, fmt.Sprintf("firma: el certificado es del NIT %q, no del emisor %s", signerNIT, inv.Document.IssuerNIT))
+	default:
+		signatureOK = true
+	}
+
+	cufe := inv.Document.CUFE
+	cufeOK = true
+	switch {
+	case !cufeRe.MatchString(cufe):
+		problems = append(problems, "CUFE: no es un SHA-384 de 96 caracteres hexadecimales")
+		cufeOK = false
+	case inv.UUIDScheme != "" && !strings.EqualFold(inv.UUIDScheme, "CUFE-SHA384"):
+		problems = append(problems, "CUFE: cbc:UUID con schemeName "+inv.UUIDScheme)
+		cufeOK = false
+	case inv.ParentCUFE != "" && inv.ParentCUFE != cufe:
+		problems = append(problems, "CUFE: no coincide con el declarado en el AttachedDocument")
+		cufeOK = false
+	}
+	if problem := uc.dianValidationProblem(inv); problem != "" {
+		problems = append(problems, "CUFE: "+problem)
+		cufeOK = false
+	}
+	return signatureOK, cufeOK, problems
+}
+
+// dianValidationProblem explica por qué no se puede dar por validada la factura; vacío si el
+// AttachedDocument trae un ApplicationResponse con firma válida de la DIAN que acepta este CUFE. El código
+// de cac:ResultOfVerification solo no basta: no lo firma nadie.
+func (uc *ReceivedDocumentUseCase) dianValidationProblem(inv *domaindian.ReceivedInvoice) string {
+	if inv.ApplicationResponseXML == nil {
+		if inv.DIANResponseCode == "" {
+			return "el adjunto no trae la validación de la DIAN"
+		}
+		return "la validación de la DIAN no trae su ApplicationResponse firmado"
+	}
+	signerNIT, err := uc.invoices.VerifySignature(inv.ApplicationResponseXML)
+	switch {
+	case err != nil:
+		return "firma del ApplicationResponse de la DIAN: " + err.Error()
+	case !sameNIT(signerNIT, domaindian.NITDIAN):
+		return fmt.Sprintf("el ApplicationResponse no está firmado por la DIAN (certificado del NIT %q)", signerNIT)
+	case !strings.EqualFold(inv.ResponseCUFE, inv.Document.CUFE):
+		return "el ApplicationResponse de la DIAN valida otro documento"
+	case inv.DIANResponseCode != domaindian.ApplicationResponseAccepted:
+		return "la DIAN no validó la factura (código " + inv.DIANResponseCode + ")"
+	}
+	return ""
+}
+
+// findSupplier busca el proveedor por el NIT del emisor, con y sin dígito de verificación.
+func (uc *ReceivedDocumentUseCase) findSupplier(companyID, nit string) (*entity.Supplier, error) {
+	supplier, err := uc.supplierRepo.GetByCompanyAndNIT(companyID, nit)
+	if err != nil || supplier != nil {
+		return supplier, err
+	}
+	if dv, err := pkgdian.ComputeNITVerificationDigit(nit); err == nil && len(nit) == 9 {
+		return uc.supplierRepo.GetByCompanyAndNIT(companyID, nit+"-"+string(dv))
+	}
+	return nil, nil
+}
+
+// matchPurchaseOrder elige la orden de compra abierta de la factura: la que indica cac:OrderReference o,
+// sin referencia, la única cuyo valor (cantidad × costo) coincide con la base de la factura.
+func matchPurchaseOrder(orders []*entity.PurchaseOrder, reference string, netTotal decimal.Decimal) string {
+	if ref := normalizeDocNumber(reference); ref != "" {
+		for _, po := range orders {
+			if normalizeDocNumber(po.Number) == ref {
+				return po.ID
+			}
+		}
+	}
+	var match string
+	for _, po := range orders {
+		total := decimal.Zero
+		for _, it := range po.Items {
+			total = total.Add(it.Quantity.Mul(it.UnitCost))
+		}
+		if total.Round(2).Equal(netTotal.Round(2)) {
+			if match != "" {
+				return "" // ambigua: la concilia un usuario
+			}
+			match = po.ID
+		}
+	}
+	return match
+}
+
+func normalizeDocNumber(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+// sameNIT compara dos NIT por sus dígitos, con o sin dígito de verificación.
+func sameNIT(a, b string) bool {
+	da, db := digitsOnly(a), digitsOnly(b)
+	if da == "" || db == "" {
+		return false
+	}
+	if len(da) > len(db) {
+		da, db = db, da
+	}
+	return da == db || (len(db) == len(da)+1 && strings.HasPrefix(db, da))
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
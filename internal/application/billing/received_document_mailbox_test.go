@@ -0,0 +1,332 @@
+package billing
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+	"github.com/jhoicas/Inventario-api/internal/infrastructure/dian/signer"
+)
+
+type fakeMailbox struct {
+	pending []*entity.EmailInvoiceAttachment
+	marked  map[string]*entity.EmailInvoiceIngestion
+}
+
+func (f *fakeMailbox) ListPending(context.Context, int) ([]*entity.EmailInvoiceAttachment, error) {
+	var out []*entity.EmailInvoiceAttachment
+	for _, a := range f.pending {
+		if f.marked[a.ID] == nil {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeMailbox) MarkProcessed(_ context.Context, in *entity.EmailInvoiceIngestion) error {
+	f.marked[in.AttachmentID] = in
+	return nil
+}
+
+type fakeOpenOrders struct{ orders []*entity.PurchaseOrder }
+
+func (f *fakeOpenOrders) ListOpenBySupplier(_ context.Context, _, supplierID string) ([]*entity.PurchaseOrder, error) {
+	var out []*entity.PurchaseOrder
+	for _, po := range f.orders {
+		if po.SupplierID == supplierID {
+			out = append(out, po)
+		}
+	}
+	return out, nil
+}
+
+const (
+	testNsInvoice = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	testNsCAC     = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	testNsCBC     = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+	testNsEXT     = "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"
+)
+
+// supplierInvoiceXML arma el Invoice del proveedor ya en forma canónica (C14N), con el ExtensionContent
+// vacío donde va la firma.
+func supplierInvoiceXML(cufe, customerNIT, payable string) string {
+	party := func(nit, name string) string {
+		return `<cac:Party><cac:PartyTaxScheme><cbc:RegistrationName>` + name + `</cbc:RegistrationName>` +
+			`<cbc:CompanyID schemeID="4" schemeName="31">` + nit + `</cbc:CompanyID></cac:PartyTaxScheme></cac:Party>`
+	}
+	return `<Invoice xmlns="` + testNsInvoice + `" xmlns:cac="` + testNsCAC + `" xmlns:cbc="` + testNsCBC + `" xmlns:ext="` + testNsEXT + `">` +
+		`<ext:UBLExtensions><ext:UBLExtension><ext:ExtensionContent></ext:ExtensionContent></ext:UBLExtension></ext:UBLExtensions>` +
+		`<cbc:ProfileExecutionID>1</cbc:ProfileExecutionID><cbc:ID>FE1234</cbc:ID>` +
+		`<cbc:UUID schemeName="CUFE-SHA384">` + cufe + `</cbc:UUID>` +
+		`<cbc:IssueDate>2024-05-06</cbc:IssueDate><cbc:IssueTime>10:15:00-05:00</cbc:IssueTime>` +
+		`<cbc:InvoiceTypeCode>01</cbc:InvoiceTypeCode><cbc:DocumentCurrencyCode>COP</cbc:DocumentCurrencyCode>` +
+		`<cac:OrderReference><cbc:ID>OC-77</cbc:ID></cac:OrderReference>` +
+		`<cac:AccountingSupplierParty>` + party("800987654", "Proveedor S.A.S.") + `</cac:AccountingSupplierParty>` +
+		`<cac:AccountingCustomerParty>` + party(customerNIT, "Empresa de prueba") + `</cac:AccountingCustomerParty>` +
+		`<cac:TaxTotal><cbc:TaxAmount currencyID="COP">19000.00</cbc:TaxAmount><cac:TaxSubtotal>` +
+		`<cbc:TaxableAmount currencyID="COP">100000.00</cbc:TaxableAmount><cbc:TaxAmount currencyID="COP">19000.00</cbc:TaxAmount>` +
+		`<cac:TaxCategory><cbc:Percent>19.00</cbc:Percent><cac:TaxScheme><cbc:ID>01</cbc:ID><cbc:Name>IVA</cbc:Name></cac:TaxScheme></cac:TaxCategory>` +
+		`</cac:TaxSubtotal></cac:TaxTotal>` +
+		`<cac:LegalMonetaryTotal><cbc:LineExtensionAmount currencyID="COP">100000.00</cbc:LineExtensionAmount>` +
+		`<cbc:TaxInclusiveAmount currencyID="COP">119000.00</cbc:TaxInclusiveAmount>` +
+		`<cbc:PayableAmount currencyID="COP">` + payable + `</cbc:PayableAmount></cac:LegalMonetaryTotal>` +
+		`<cac:InvoiceLine><cbc:ID>1</cbc:ID><cbc:InvoicedQuantity unitCode="94">10</cbc:InvoicedQuantity>` +
+		`<cbc:LineExtensionAmount currencyID="COP">100000.00</cbc:LineExtensionAmount>` +
+		`<cac:TaxTotal><cbc:TaxAmount currencyID="COP">19000.00</cbc:TaxAmount></cac:TaxTotal>` +
+		`<cac:Item><cbc:Description>Caja de tornillos</cbc:Description><cac:StandardItemIdentification><cbc:ID>TOR-01</cbc:ID></cac:StandardItemIdentification></cac:Item>` +
+		`<cac:Price><cbc:PriceAmount currencyID="COP">10000.00</cbc:PriceAmount></cac:Price></cac:InvoiceLine>` +
+		`</Invoice>`
+}
+
+// testSigningCA CA de pruebas que emite los certificados de firma del proveedor y de la DIAN.
+type testSigningCA struct {
+	cert  *x509.Certificate
+	key   *rsa.PrivateKey
+	roots *x509.CertPool
+}
+
+func newTestSigningCA(t *testing.T) *testSigningCA {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "CA de pruebas"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &testSigningCA{cert: cert, key: key, roots: roots}
+}
+
+// issue emite un certificado de firma para el NIT (SERIALNUMBER del sujeto).
+func (ca *testSigningCA) issue(t *testing.T, name, nit string) tls.Certificate {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name, SerialNumber: nit},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// signXML firma el documento con la firma XAdES del signer (URI vacía: la raíz no tiene Id).
+func signXML(t *testing.T, unsigned string, cert tls.Certificate) string {
+	t.Helper()
+	signed, err := signer.NewDigitalSignatureService().Sign([]byte(unsigned), cert)
+	require.NoError(t, err)
+	return string(signed)
+}
+
+// dianApplicationResponseXML ApplicationResponse de validación de la DIAN para el CUFE, sin firmar.
+func dianApplicationResponseXML(cufe, code string) string {
+	return `<ApplicationResponse xmlns="urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2" xmlns:cac="` + testNsCAC +
+		`" xmlns:cbc="` + testNsCBC + `" xmlns:ext="` + testNsEXT + `">` +
+		`<ext:UBLExtensions><ext:UBLExtension><ext:ExtensionContent></ext:ExtensionContent></ext:UBLExtension></ext:UBLExtensions>` +
+		`<cbc:ID>AR-1</cbc:ID><cac:DocumentResponse><cac:Response><cbc:ResponseCode>` + code + `</cbc:ResponseCode>` +
+		`<cbc:Description>Documento validado por la DIAN</cbc:Description></cac:Response>` +
+		`<cac:DocumentReference><cbc:ID>FE1234</cbc:ID><cbc:UUID schemeName="CUFE-SHA384">` + cufe + `</cbc:UUID></cac:DocumentReference>` +
+		`</cac:DocumentResponse></ApplicationResponse>`
+}
+
+// attachedDocumentZip empaqueta el Invoice firmado en el AttachedDocument con la validación de la DIAN,
+// junto con la representación gráfica, como llega en el correo del proveedor. applicationResponse es el
+// ApplicationResponse firmado; vacío deja solo el cac:ResultOfVerification con validationCode.
+func attachedDocumentZip(t *testing.T, signedInvoice, cufe, validationCode, applicationResponse string) []byte {
+	t.Helper()
+	var responseAttachment string
+	if applicationResponse != "" {
+		responseAttachment = `<cac:Attachment><cac:ExternalReference><cbc:MimeCode>text/xml</cbc:MimeCode>` +
+			`<cbc:Description><![CDATA[` + applicationResponse + `]]></cbc:Description></cac:ExternalReference></cac:Attachment>`
+	}
+	container := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<AttachedDocument xmlns="urn:oasis:names:specification:ubl:schema:xsd:AttachedDocument-2" xmlns:cac="` + testNsCAC + `" xmlns:cbc="` + testNsCBC + `">` +
+		`<cbc:UBLVersionID>UBL 2.1</cbc:UBLVersionID><cbc:CustomizationID>Documentos adjuntos</cbc:CustomizationID>` +
+		`<cbc:DocumentType>Contenedor de Factura Electrónica</cbc:DocumentType><cbc:ParentDocumentID>FE1234</cbc:ParentDocumentID>` +
+		`<cac:Attachment><cac:ExternalReference><cbc:MimeCode>text/xml</cbc:MimeCode><cbc:EncodingCode>UTF-8</cbc:EncodingCode>` +
+		`<cbc:Description><![CDATA[` + signedInvoice + `]]></cbc:Description></cac:ExternalReference></cac:Attachment>` +
+		`<cac:ParentDocumentLineReference><cbc:LineID>1</cbc:LineID><cac:DocumentReference><cbc:ID>FE1234</cbc:ID>` +
+		`<cbc:UUID schemeName="CUFE-SHA384">` + cufe + `</cbc:UUID><cbc:DocumentType>ApplicationResponse</cbc:DocumentType>` +
+		responseAttachment +
+		`<cac:ResultOfVerification><cbc:ValidatorID>DIAN</cbc:ValidatorID><cbc:ValidationResultCode>` + validationCode + `</cbc:ValidationResultCode></cac:ResultOfVerification>` +
+		`</cac:DocumentReference></cac:ParentDocumentLineReference></AttachedDocument>`
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"ad0800987654000240000001.xml": container, "FE1234.pdf": "%PDF-1.4"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestReceivedDocumentUseCase_IngestMailbox(t *testing.T) {
+	ctx := context.Background()
+	ca := newTestSigningCA(t)
+	supplierCert := ca.issue(t, "Proveedor S.A.S.", "800987654")
+	dianCert := ca.issue(t, "DIAN", "800197268-4")
+	otherCert := ca.issue(t, "Otra empresa", "811222333")
+	untrusted := newTestSigningCA(t).issue(t, "Proveedor S.A.S.", "800987654")
+
+	company := &entity.Company{ID: testCompanyID, NIT: "900123456", Name: "Empresa de prueba"}
+	companyRepo := &fakeCompanyRepo{getByIDFunc: func(string) (*entity.Company, error) { return company, nil }}
+	supplier := &entity.Supplier{ID: "sup-1", CompanyID: testCompanyID, NIT: "800987654", Name: "Proveedor S.A.S."}
+	orders := &fakeOpenOrders{orders: []*entity.PurchaseOrder{
+		{ID: "po-76", SupplierID: "sup-1", Number: "OC-76"},
+		{ID: "po-77", SupplierID: "sup-1", Number: "OC-77"},
+	}}
+	cufe := func(prefix string) string { return strings.Repeat(prefix, 48) }
+	invoice := func(c string, cert tls.Certificate) string {
+		return signXML(t, supplierInvoiceXML(c, "900123456", "119000.00"), cert)
+	}
+	dianResponse := func(c string, cert tls.Certificate) string {
+		return signXML(t, dianApplicationResponseXML(c, "02"), cert)
+	}
+	attached := func(c string, invCert, arCert tls.Certificate) []byte {
+		return attachedDocumentZip(t, invoice(c, invCert), c, "02", dianResponse(c, arCert))
+	}
+
+	valid := attached(cufe("a1"), supplierCert, dianCert)
+	tampered := strings.Replace(invoice(cufe("b2"), supplierCert), ">119000.00</cbc:PayableAmount>", ">1190000.00</cbc:PayableAmount>", 1)
+	foreign := signXML(t, supplierInvoiceXML(cufe("c3"), "811222333", "119000.00"), supplierCert)
+
+	mailbox := &fakeMailbox{marked: map[string]*entity.EmailInvoiceIngestion{}, pending: []*entity.EmailInvoiceAttachment{
+		{ID: "att-1", CompanyID: testCompanyID, FileName: "z0800987654000240000001.zip", Content: valid},
+		{ID: "att-2", CompanyID: testCompanyID, FileName: "reenvio.zip", Content: valid},
+		{ID: "att-3", CompanyID: testCompanyID, FileName: "FE1235.xml", Content: []byte(tampered)},
+		{ID: "att-4", CompanyID: testCompanyID, FileName: "otra-empresa.xml", Content: []byte(foreign)},
+		{ID: "att-5", CompanyID: testCompanyID, FileName: "cotizacion.xml", Content: []byte(`<Quotation/>`)},
+		{ID: "att-6", CompanyID: testCompanyID, FileName: "roto.zip", Content: []byte("PK\x03\x04 truncado")},
+		{ID: "att-7", CompanyID: testCompanyID, FileName: "otro-firmante.zip", Content: attached(cufe("d4"), otherCert, dianCert)},
+		{ID: "att-8", CompanyID: testCompanyID, FileName: "ca-desconocida.zip", Content: attached(cufe("e5"), untrusted, dianCert)},
+		{ID: "att-9", CompanyID: testCompanyID, FileName: "sin-respuesta.zip", Content: attachedDocumentZip(t, invoice(cufe("f6"), supplierCert), cufe("f6"), "02", "")},
+		{ID: "att-10", CompanyID: testCompanyID, FileName: "respuesta-falsa.zip", Content: attached(cufe("a7"), supplierCert, otherCert)},
+		{ID: "att-11", CompanyID: testCompanyID, FileName: "respuesta-ajena.zip", Content: attachedDocumentZip(t,
+			invoice(cufe("b8"), supplierCert), cufe("b8"), "02", dianResponse(cufe("a1"), dianCert))},
+	}}
+
+	now := time.Date(2024, 5, 7, 9, 0, 0, 0, time.UTC)
+	repo := &fakeReceivedDocumentRepo{docs: map[string]*entity.ReceivedDocument{}}
+	uc := NewReceivedDocumentUseCase(repo, companyRepo, &fakeSupplierRepo{supplier: supplier}, &fakeCredentials{},
+		infradian.NewXMLBuilderService(), fakeSigner{}, nil)
+	uc.now = func() time.Time { return now }
+
+	n, err := uc.IngestMailbox(ctx, 50)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "sin SetMailboxIngestion no hay ingesta")
+
+	uc.SetMailboxIngestion(infradian.NewReceivedInvoiceReader(ca.roots), mailbox, orders)
+	n, err = uc.IngestMailbox(ctx, 50)
+	require.NoError(t, err)
+	assert.Equal(t, 7, n)
+
+	res := mailbox.marked["att-1"]
+	require.NotNil(t, res)
+	require.Equal(t, entity.EmailInvoiceImported, res.Status, res.Error)
+	doc := repo.docs[res.ReceivedDocumentID]
+	require.NotNil(t, doc)
+	assert.Equal(t, entity.ReceivedDocSourceEmail, doc.Source)
+	assert.Equal(t, entity.ReceivedDocStatusReceived, doc.Status)
+	assert.Equal(t, "att-1", doc.EmailAttachmentID)
+	assert.Equal(t, "FE1234", doc.Number)
+	assert.Equal(t, cufe("a1"), doc.CUFE)
+	assert.Equal(t, "sup-1", doc.SupplierID)
+	assert.Equal(t, "po-77", doc.PurchaseOrderID, "conciliada por cac:OrderReference")
+	assert.Equal(t, "900123456", doc.CustomerNIT)
+	require.NotNil(t, doc.SignatureValid)
+	require.NotNil(t, doc.CUFEValid)
+	assert.True(t, *doc.SignatureValid)
+	assert.True(t, *doc.CUFEValid)
+	assert.Empty(t, doc.ValidationErrors)
+	assert.True(t, decimal.NewFromInt(100000).Equal(doc.NetTotal))
+	assert.True(t, decimal.NewFromInt(19000).Equal(doc.TaxTotal))
+	assert.True(t, decimal.NewFromInt(119000).Equal(doc.GrandTotal))
+	require.Len(t, doc.Lines, 1)
+	assert.Equal(t, "TOR-01", doc.Lines[0].ItemCode)
+	assert.Equal(t, "Caja de tornillos", doc.Lines[0].Description)
+	assert.True(t, decimal.NewFromInt(10).Equal(doc.Lines[0].Quantity))
+	require.Len(t, doc.Taxes, 1)
+	assert.Equal(t, "01", doc.Taxes[0].TaxCode)
+	assert.True(t, decimal.NewFromInt(19).Equal(doc.Taxes[0].Percent))
+	assert.Contains(t, doc.XML, "<AttachedDocument")
+
+	assert.Equal(t, entity.EmailInvoiceDuplicate, mailbox.marked["att-2"].Status)
+
+	res = mailbox.marked["att-3"]
+	require.Equal(t, entity.EmailInvoiceImported, res.Status)
+	doc = repo.docs[res.ReceivedDocumentID]
+	assert.False(t, *doc.SignatureValid, "el total se alteró después de firmar")
+	assert.False(t, *doc.CUFEValid, "el Invoice suelto no trae la validación de la DIAN")
+	assert.Contains(t, doc.ValidationErrors, "digest")
+	assert.Contains(t, doc.ValidationErrors, "validación de la DIAN")
+
+	assert.Equal(t, entity.EmailInvoiceIgnored, mailbox.marked["att-4"].Status)
+	assert.Contains(t, mailbox.marked["att-4"].Error, "811222333")
+	assert.Equal(t, entity.EmailInvoiceIgnored, mailbox.marked["att-5"].Status)
+	assert.Equal(t, entity.EmailInvoiceFailed, mailbox.marked["att-6"].Status)
+
+	checkRejected := func(attachment string, signatureOK, cufeOK bool, problem string) {
+		t.Helper()
+		res := mailbox.marked[attachment]
+		require.Equal(t, entity.EmailInvoiceImported, res.Status, res.Error)
+		doc := repo.docs[res.ReceivedDocumentID]
+		assert.Equal(t, signatureOK, *doc.SignatureValid, attachment)
+		assert.Equal(t, cufeOK, *doc.CUFEValid, attachment)
+		assert.Contains(t, doc.ValidationErrors, problem, attachment)
+	}
+	checkRejected("att-7", false, true, "firma: el certificado es del NIT \"811222333\", no del emisor 800987654")
+	checkRejected("att-8", false, true, "firma: x509")
+	checkRejected("att-9", true, false, "no trae su ApplicationResponse firmado")
+	checkRejected("att-10", true, false, "no está firmado por la DIAN")
+	checkRejected("att-11", true, false, "valida otro documento")
+
+	n, err = uc.IngestMailbox(ctx, 50)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "los adjuntos procesados no se vuelven a leer")
+}
+
+func TestMatchPurchaseOrder(t *testing.T) {
+	item := func(qty, cost int64) entity.PurchaseOrderItem {
+		return entity.PurchaseOrderItem{Quantity: decimal.NewFromInt(qty), UnitCost: decimal.NewFromInt(cost)}
+	}
+	orders := []*entity.PurchaseOrder{
+		{ID: "po-1", Number: "OC-1", Items: []entity.PurchaseOrderItem{item(10, 10000)}},
+		{ID: "po-2", Number: "OC-2", Items: []entity.PurchaseOrderItem{item(5, 10000), item(2, 1000)}},
+	}
+	assert.Equal(t, "po-2", matchPurchaseOrder(orders, " oc-2 ", decimal.Zero))
+	assert.Equal(t, "po-1", matchPurchaseOrder(orders, "", decimal.NewFromInt(100000)))
+	assert.Equal(t, "", matchPurchaseOrder(orders, "", decimal.NewFromInt(70000)))
+	orders = append(orders, &entity.PurchaseOrder{ID: "po-3", Number: "OC-3", Items: []entity.PurchaseOrderItem{item(1, 100000)}})
+	assert.Equal(t, "", matchPurchaseOrder(orders, "", decimal.NewFromInt(100000)), "dos órdenes con el mismo valor")
+}
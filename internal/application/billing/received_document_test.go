@@ -0,0 +1,262 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+type fakeReceivedDocumentRepo struct {
+	docs   map[string]*entity.ReceivedDocument
+	events []*entity.ReceivedDocumentEvent
+	seq    int
+}
+
+func (f *fakeReceivedDocumentRepo) Create(_ context.Context, d *entity.ReceivedDocument) error {
+	for _, existing := range f.docs {
+		if existing.CompanyID == d.CompanyID && existing.CUFE == d.CUFE {
+			return domain.ErrDuplicate
+		}
+	}
+	f.docs[d.ID] = d
+	return nil
+}
+
+func (f *fakeReceivedDocumentRepo) GetByID(_ context.Context, id string) (*entity.ReceivedDocument, error) {
+	d := f.docs[id]
+	if d == nil {
+		return nil, nil
+	}
+	d.Events = nil
+	for _, e := range f.events {
+		if e.ReceivedDocumentID == id {
+			d.Events = append(d.Events, e)
+		}
+	}
+	return d, nil
+}
+
+func (f *fakeReceivedDocumentRepo) List(context.Context, string, string) ([]*entity.ReceivedDocument, error) {
+	return nil, nil
+}
+
+func (f *fakeReceivedDocumentRepo) CreateEvent(_ context.Context, ev *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument) error {
+	for _, e := range f.events {
+		if e.ReceivedDocumentID == ev.ReceivedDocumentID && e.EventCode == ev.EventCode && !e.IsFailed() {
+			return domain.ErrConflict
+		}
+	}
+	if ev.DIANStatus != entity.EventDIANStatusLocal {
+		f.seq++
+		ev.Number = strconv.Itoa(f.seq)
+	}
+	f.events = append(f.events, ev)
+	if doc != nil {
+		f.docs[doc.ID] = doc
+	}
+	return nil
+}
+
+func (f *fakeReceivedDocumentRepo) GetEvent(_ context.Context, id string) (*entity.ReceivedDocumentEvent, error) {
+	for _, e := range f.events {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeReceivedDocumentRepo) UpdateEvent(_ context.Context, _ *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument) error {
+	if doc != nil {
+		f.docs[doc.ID] = doc
+	}
+	return nil
+}
+
+func (f *fakeReceivedDocumentRepo) ListPendingEvents(_ context.Context, now time.Time, _ int) ([]*entity.ReceivedDocumentEvent, error) {
+	var out []*entity.ReceivedDocumentEvent
+	for _, e := range f.events {
+		if e.DIANStatus == entity.DIANStatusContingencia && (e.NextCheckAt == nil || !e.NextCheckAt.After(now)) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeReceivedDocumentRepo) ListGoodsReceivedBefore(ctx context.Context, before time.Time, _ int) ([]*entity.ReceivedDocument, error) {
+	var out []*entity.ReceivedDocument
+	for id, d := range f.docs {
+		if d.Status == entity.ReceivedDocStatusGoodsReceived && d.GoodsReceivedAt != nil && !d.GoodsReceivedAt.After(before) {
+			doc, _ := f.GetByID(ctx, id)
+			out = append(out, doc)
+		}
+	}
+	return out, nil
+}
+
+type fakeEventSubmitter struct {
+	calls int
+	err   error
+	res   *infradian.StatusResult
+}
+
+func (f *fakeEventSubmitter) SendEventUpdateStatus(context.Context, []byte, string) (*infradian.StatusResult, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.res, nil
+}
+
+func TestReceivedDocumentUseCase(t *testing.T) {
+	ctx := context.Background()
+	company := &entity.Company{ID: testCompanyID, NIT: "900123456", Name: "Empresa de prueba"}
+	companyRepo := &fakeCompanyRepo{getByIDFunc: func(string) (*entity.Company, error) { return company, nil }}
+	bogota := time.FixedZone("COT", -5*3600)
+	cufe := "8bb918b19ba22a694f1da11c643b5e9de39adf60311cf179179e9b33381030bcd4c3c3f156c506ed5908f9276f5bd9b4"
+	person := &dto.EventPersonRequest{Identification: "1020304050", Name: "Ana Gómez", JobTitle: "Almacenista"}
+
+	newUseCase := func(appEnv string, sub *fakeEventSubmitter, now *time.Time) (*ReceivedDocumentUseCase, *fakeReceivedDocumentRepo) {
+		repo := &fakeReceivedDocumentRepo{docs: map[string]*entity.ReceivedDocument{}}
+		creds := &fakeCredentials{creds: &DIANCredentials{AppEnv: appEnv, TipoAmbiente: "2", SoftwareID: "sw-1", SoftwarePIN: "12345"}}
+		var submitter DIANEventSubmitter
+		if sub != nil {
+			submitter = sub
+		}
+		uc := NewReceivedDocumentUseCase(repo, companyRepo, &fakeSupplierRepo{}, creds, infradian.NewXMLBuilderService(), fakeSigner{}, submitter)
+		uc.now = func() time.Time { return *now }
+		uc.dispatch = func(id string) { _ = uc.ProcessEvent(ctx, id) }
+		return uc, repo
+	}
+	register := func(t *testing.T, uc *ReceivedDocumentUseCase) string {
+		out, err := uc.Register(ctx, testCompanyID, testUserID, dto.RegisterReceivedDocumentRequest{
+			IssuerNIT: "800987654", IssuerName: "Proveedor S.A.S.", Number: "FE 1234", CUFE: cufe,
+			IssueDate: "2024-05-06", NetTotal: decimal.NewFromInt(100000), TaxTotal: decimal.NewFromInt(19000),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "FE1234", out.Number)
+		assert.True(t, decimal.NewFromInt(119000).Equal(out.GrandTotal))
+		assert.Equal(t, entity.ReceivedDocStatusReceived, out.Status)
+		return out.ID
+	}
+
+	t.Run("secuencia 030 → 032 → 031 con ApplicationResponse firmado", func(t *testing.T) {
+		now := time.Date(2024, 5, 8, 10, 0, 0, 0, bogota)
+		uc, repo := newUseCase("dev", nil, &now)
+		id := register(t, uc)
+
+		_, err := uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventReciboBien, Person: person})
+		assert.ErrorIs(t, err, domain.ErrConflict, "el recibo del bien exige el acuse validado")
+		_, err = uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventAcuseRecibo})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, "el acuse exige la persona que recibe")
+		_, err = uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventAceptacionTacita})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, "la aceptación tácita no la emite el adquiriente")
+
+		ack, err := uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventAcuseRecibo, Person: person})
+		require.NoError(t, err)
+		assert.Equal(t, "1", ack.Number)
+		ev := repo.events[0]
+		assert.Equal(t, entity.DIANStatusExitoso, ev.DIANStatus)
+		assert.Len(t, ev.CUDE, 96)
+		assert.Contains(t, ev.XMLSigned, "<ApplicationResponse")
+		assert.Contains(t, ev.XMLSigned, ">030</ResponseCode>")
+		assert.Contains(t, ev.XMLSigned, `schemeName="CUFE-SHA384">`+cufe)
+		assert.Contains(t, ev.XMLSigned, `schemeName="CUDE-SHA384">`+ev.CUDE)
+		assert.Contains(t, ev.XMLSigned, ">800987654</CompanyID>")
+		assert.Contains(t, ev.XMLSigned, "Ana Gómez")
+		assert.Equal(t, entity.ReceivedDocStatusAcknowledged, repo.docs[id].Status)
+
+		_, err = uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventAcuseRecibo, Person: person})
+		assert.ErrorIs(t, err, domain.ErrConflict, "cada evento se emite una vez")
+
+		_, err = uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventReciboBien, Person: person})
+		require.NoError(t, err)
+		doc := repo.docs[id]
+		assert.Equal(t, entity.ReceivedDocStatusGoodsReceived, doc.Status)
+		require.NotNil(t, doc.GoodsReceivedAt)
+
+		_, err = uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventReclamo})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, "el reclamo exige concepto")
+		claim, err := uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{
+			EventCode: dian.EventReclamo, ClaimConcept: dian.ClaimConceptEntregaParcial, Note: "Llegaron 8 de 10 cajas",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, entity.DIANStatusExitoso, claim.DIANStatus)
+		assert.Contains(t, repo.events[2].XMLSigned, `listID="03">031</ResponseCode>`)
+		assert.Equal(t, entity.ReceivedDocStatusClaimed, repo.docs[id].Status)
+
+		_, err = uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventAceptacionExpresa})
+		assert.ErrorIs(t, err, domain.ErrConflict, "reclamo y aceptación se excluyen")
+	})
+
+	t.Run("aceptación tácita a los tres días hábiles del recibo del bien", func(t *testing.T) {
+		now := time.Date(2024, 5, 8, 10, 0, 0, 0, bogota) // miércoles
+		uc, repo := newUseCase("dev", nil, &now)
+		id := register(t, uc)
+		for _, code := range []string{dian.EventAcuseRecibo, dian.EventReciboBien} {
+			_, err := uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: code, Person: person})
+			require.NoError(t, err)
+		}
+		out, err := uc.Get(ctx, testCompanyID, id)
+		require.NoError(t, err)
+		require.NotNil(t, out.TacitAcceptanceFrom)
+		assert.Equal(t, time.Date(2024, 5, 14, 0, 0, 0, 0, bogota), *out.TacitAcceptanceFrom)
+
+		now = time.Date(2024, 5, 13, 18, 0, 0, 0, bogota) // lunes: aún en término
+		n, err := uc.ApplyTacitAcceptance(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		now = time.Date(2024, 5, 14, 8, 0, 0, 0, bogota)
+		n, err = uc.ApplyTacitAcceptance(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, entity.ReceivedDocStatusTacitlyAccepted, repo.docs[id].Status)
+		tacit := repo.events[len(repo.events)-1]
+		assert.Equal(t, dian.EventAceptacionTacita, tacit.EventCode)
+		assert.Equal(t, entity.EventDIANStatusLocal, tacit.DIANStatus)
+		assert.Empty(t, tacit.Number, "el 034 no consume el consecutivo de eventos de la empresa")
+
+		_, err = uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventReclamo, ClaimConcept: "01"})
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		n, err = uc.ApplyTacitAcceptance(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+	})
+
+	t.Run("timeout de SendEventUpdateStatus: contingencia y reenvío", func(t *testing.T) {
+		now := time.Date(2024, 5, 8, 10, 0, 0, 0, bogota)
+		sub := &fakeEventSubmitter{err: errors.New("soap: timeout o cancelación: context deadline exceeded")}
+		uc, repo := newUseCase("prod", sub, &now)
+		id := register(t, uc)
+
+		_, err := uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventAcuseRecibo, Person: person})
+		require.NoError(t, err)
+		ev := repo.events[0]
+		assert.Equal(t, entity.DIANStatusContingencia, ev.DIANStatus)
+		require.NotNil(t, ev.NextCheckAt)
+		assert.Equal(t, entity.ReceivedDocStatusReceived, repo.docs[id].Status)
+
+		_, err = uc.EmitEvent(ctx, testCompanyID, testUserID, id, dto.CreateReceivedDocumentEventRequest{EventCode: dian.EventReciboBien, Person: person})
+		assert.ErrorIs(t, err, domain.ErrConflict, "el acuse sigue sin validar")
+
+		sub.err, sub.res = nil, &infradian.StatusResult{IsValid: true, StatusCode: infradian.StatusCodeProcessed, DocumentKey: ev.CUDE}
+		now = now.Add(time.Hour)
+		require.NoError(t, uc.AdvancePending(ctx, 10))
+		assert.Equal(t, 2, sub.calls)
+		assert.Equal(t, entity.DIANStatusExitoso, ev.DIANStatus)
+		assert.Equal(t, entity.ReceivedDocStatusAcknowledged, repo.docs[id].Status)
+	})
+}
@@ -0,0 +1,54 @@
+package billing
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ReceivedDocumentWorker registra periódicamente las facturas de proveedores que llegaron al buzón, reenvía
+// los eventos RADIAN que quedaron en CONTINGENCIA y aplica la aceptación tácita a las facturas recibidas
+// cuyo término venció.
+type ReceivedDocumentWorker struct {
+	uc        *ReceivedDocumentUseCase
+	interval  time.Duration
+	batchSize int
+}
+
+func NewReceivedDocumentWorker(uc *ReceivedDocumentUseCase, interval time.Duration, batchSize int) *ReceivedDocumentWorker {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	return &ReceivedDocumentWorker{uc: uc, interval: interval, batchSize: batchSize}
+}
+
+func (w *ReceivedDocumentWorker) Start(ctx context.Context) {
+	if w.uc == nil {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+			if _, err := w.uc.IngestMailbox(runCtx, w.batchSize); err != nil {
+				log.Printf("[DIAN][RADIAN] ingesta de facturas del buzón: %v", err)
+			}
+			if err := w.uc.AdvancePending(runCtx, w.batchSize); err != nil {
+				log.Printf("[DIAN][RADIAN] no se pudieron listar eventos pendientes: %v", err)
+			}
+			if _, err := w.uc.ApplyTacitAcceptance(runCtx, w.batchSize); err != nil {
+				log.Printf("[DIAN][RADIAN] aceptación tácita: %v", err)
+			}
+			cancel()
+		}
+	}
+}
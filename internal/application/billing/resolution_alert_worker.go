@@ -0,0 +1,73 @@
+package billing
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+// ResolutionAlertWorker evalúa periódicamente las resoluciones activas de todas las empresas.
+type ResolutionAlertWorker struct {
+	monitor     *ResolutionMonitor
+	companyRepo repository.CompanyRepository
+	interval    time.Duration
+}
+
+func NewResolutionAlertWorker(monitor *ResolutionMonitor, companyRepo repository.CompanyRepository, interval time.Duration) *ResolutionAlertWorker {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &ResolutionAlertWorker{
+		monitor:     monitor,
+		companyRepo: companyRepo,
+		interval:    interval,
+	}
+}
+
+// Start ejecuta una evaluación inmediata y luego una por intervalo hasta que ctx sea cancelado.
+// Debe lanzarse como goroutine.
+func (w *ResolutionAlertWorker) Start(ctx context.Context) {
+	if w.monitor == nil || w.companyRepo == nil {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.runOnce(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+// resolutionAlertPageSize empresas por página al recorrer el listado.
+const resolutionAlertPageSize = 500
+
+// runOnce recorre todas las empresas por páginas hasta recibir una página incompleta.
+func (w *ResolutionAlertWorker) runOnce(ctx context.Context) {
+	for offset := 0; ; offset += resolutionAlertPageSize {
+		if ctx.Err() != nil {
+			return
+		}
+		companies, err := w.companyRepo.List(resolutionAlertPageSize, offset)
+		if err != nil {
+			log.Printf("[RESOLUTION_ALERTS][WORKER] listar empresas (offset %d): %v", offset, err)
+			return
+		}
+		for _, c := range companies {
+			if err := w.monitor.Evaluate(ctx, c.ID); err != nil {
+				log.Printf("[RESOLUTION_ALERTS][WORKER] evaluar empresa %s: %v", c.ID, err)
+			}
+		}
+		if len(companies) < resolutionAlertPageSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+// ResolutionAlertConfig umbrales del monitoreo de resoluciones.
+type ResolutionAlertConfig struct {
+	RemainingPercent float64 // alerta LOW_NUMBERS si quedan <= este % del rango (defecto 10)
+	ExpiryDays       int     // alerta NEAR_EXPIRY si vence en <= N días; también aplica a la proyección de agotamiento (defecto 30)
+	VelocityDays     int     // ventana en días para calcular la velocidad de facturación (defecto 30)
+}
+
+func (c ResolutionAlertConfig) withDefaults() ResolutionAlertConfig {
+	if c.RemainingPercent <= 0 {
+		c.RemainingPercent = 10
+	}
+	if c.ExpiryDays <= 0 {
+		c.ExpiryDays = 30
+	}
+	if c.VelocityDays <= 0 {
+		c.VelocityDays = 30
+	}
+	return c
+}
+
+// ResolutionMonitor calcula, por prefijo, los números restantes y los días a vencimiento de las
+// resoluciones activas, proyecta la fecha de agotamiento según la velocidad reciente de facturación
+// y notifica por email a los administradores cuando se cruzan los umbrales configurados.
+type ResolutionMonitor struct {
+	repo       ResolutionAlertRepository
+	mailSender EmailSender
+	cfg        ResolutionAlertConfig
+}
+
+// NewResolutionMonitor construye el monitor. mailSender puede ser nil (solo tablero).
+func NewResolutionMonitor(repo ResolutionAlertRepository, mailSender EmailSender, cfg ResolutionAlertConfig) *ResolutionMonitor {
+	return &ResolutionMonitor{repo: repo, mailSender: mailSender, cfg: cfg.withDefaults()}
+}
+
+// Status devuelve el estado de las resoluciones activas de la empresa.
+func (m *ResolutionMonitor) Status(ctx context.Context, companyID string) ([]dto.ResolutionStatusDTO, error) {
+	if companyID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	now := time.Now()
+	resolutions, err := m.repo.ListActiveResolutions(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	since := now.AddDate(0, 0, -m.cfg.VelocityDays)
+	out := make([]dto.ResolutionStatusDTO, 0, len(resolutions))
+	for _, res := range resolutions {
+		issued, err := m.repo.CountIssuedSince(ctx, companyID, res.Prefix, res.IssuedDocumentTypes(), since)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, resolutionStatus(res, issued, m.cfg, now))
+	}
+	return out, nil
+}
+
+// Evaluate registra y notifica las alertas vigentes de la empresa, una sola vez por resolución, tipo y
+// cruce de umbral: si la resolución se amplía (nuevo rango final o vigencia) y vuelve a acercarse al
+// límite, se alerta de nuevo. Los fallos de envío de correo se registran y no interrumpen la evaluación.
+func (m *ResolutionMonitor) Evaluate(ctx context.Context, companyID string) error {
+	statuses, err := m.Status(ctx, companyID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var recipients []string
+	loaded := false
+	for _, st := range statuses {
+		validUntil, err := time.Parse("2006-01-02", st.ValidUntil)
+		if err != nil {
+			return fmt.Errorf("resolución %s: vigencia %q: %w", st.ResolutionID, st.ValidUntil, err)
+		}
+		for _, kind := range st.Alerts {
+			subject, body := resolutionAlertMessage(st, kind)
+			created, err := m.repo.CreateIfAbsent(ctx, &entity.ResolutionAlert{
+				ID:           uuid.New().String(),
+				CompanyID:    companyID,
+				ResolutionID: st.ResolutionID,
+				Kind:         kind,
+				RangeTo:      st.RangeTo,
+				DateTo:       validUntil,
+				Message:      subject,
+				CreatedAt:    now,
+			})
+			if err != nil {
+				return err
+			}
+			if !created || m.mailSender == nil {
+				continue
+			}
+			if !loaded {
+				if recipients, err = m.repo.ListAdminEmails(ctx, companyID); err != nil {
+					log.Printf("[RESOLUTION_ALERTS] listar administradores de empresa %s: %v", companyID, err)
+				}
+				loaded = true
+			}
+			for _, to := range recipients {
+				if strings.TrimSpace(to) == "" {
+					continue
+				}
+				if err := m.mailSender.Send(to, subject, body); err != nil {
+					log.Printf("[RESOLUTION_ALERTS] email a %s: %v", to, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// resolutionStatus calcula el estado de una resolución a la fecha now. issued es la cantidad de
+// facturas emitidas con el prefijo en los últimos cfg.VelocityDays días.
+func resolutionStatus(res *entity.BillingResolution, issued int64, cfg ResolutionAlertConfig, now time.Time) dto.ResolutionStatusDTO {
+	total := res.RangeTo - res.RangeFrom + 1
+	if total < 0 {
+		total = 0
+	}
+	remaining := res.RangeTo - res.NextNumber() + 1
+	if remaining < 0 {
+		remaining = 0
+	}
+	if remaining > total {
+		remaining = total
+	}
+	percent := 0.0
+	if total > 0 {
+		percent = math.Round(float64(remaining)*10000/float64(total)) / 100
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(res.DateTo.Year(), res.DateTo.Month(), res.DateTo.Day(), 0, 0, 0, 0, time.UTC)
+	daysToExpiry := int(expiry.Sub(today).Hours() / 24)
+
+	st := dto.ResolutionStatusDTO{
+		ResolutionID:     res.ID,
+		ResolutionNumber: res.ResolutionNumber,
+		Prefix:           res.Prefix,
+		RangeFrom:        res.RangeFrom,
+		RangeTo:          res.RangeTo,
+		NextNumber:       res.NextNumber(),
+		Remaining:        remaining,
+		RemainingPercent: percent,
+		ValidUntil:       expiry.Format("2006-01-02"),
+		DaysToExpiry:     daysToExpiry,
+		Alerts:           []string{},
+	}
+
+	lowNumbers := percent <= cfg.RemainingPercent
+	if issued > 0 {
+		velocity := float64(issued) / float64(cfg.VelocityDays)
+		st.DailyVelocity = math.Round(velocity*100) / 100
+		daysLeft := int(math.Ceil(float64(remaining) / velocity))
+		projected := today.AddDate(0, 0, daysLeft).Format("2006-01-02")
+		st.ProjectedExhaustion = &projected
+		if daysLeft <= cfg.ExpiryDays {
+			lowNumbers = true
+		}
+	}
+	if lowNumbers {
+		st.Alerts = append(st.Alerts, entity.ResolutionAlertKindLowNumbers)
+	}
+	if daysToExpiry <= cfg.ExpiryDays {
+		st.Alerts = append(st.Alerts, entity.ResolutionAlertKindNearExpiry)
+	}
+	return st
+}
+
+func resolutionAlertMessage(st dto.ResolutionStatusDTO, kind string) (string, string) {
+	var subject string
+	if kind == entity.ResolutionAlertKindNearExpiry {
+		subject = fmt.Sprintf("Resolución DIAN %s (prefijo %s) próxima a vencer", st.ResolutionNumber, st.Prefix)
+	} else {
+		subject = fmt.Sprintf("Resolución DIAN %s (prefijo %s) próxima a agotarse", st.ResolutionNumber, st.Prefix)
+	}
+	projected := "sin facturación reciente"
+	if st.ProjectedExhaustion != nil {
+		projected = *st.ProjectedExhaustion
+	}
+	body := fmt.Sprintf(
+		"La resolución %s del prefijo %s requiere atención.\n"+
+			"Números restantes: %d de %d-%d (%.2f%%)\n"+
+			"Vigente hasta: %s (%d días)\n"+
+			"Agotamiento proyectado: %s\n"+
+			"Solicite una nueva resolución de numeración ante la DIAN para no interrumpir la facturación.\n",
+		st.ResolutionNumber, st.Prefix, st.Remaining, st.RangeFrom, st.RangeTo, st.RemainingPercent,
+		st.ValidUntil, st.DaysToExpiry, projected,
+	)
+	return subject, body
+}
@@ -0,0 +1,168 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+type fakeResolutionAlertRepo struct {
+	resolutions []*entity.BillingResolution
+	issued      int64
+	counted     [][]string
+	created     []*entity.ResolutionAlert
+	admins      []string
+}
+
+func (f *fakeResolutionAlertRepo) ListActiveResolutions(_ context.Context, _ string) ([]*entity.BillingResolution, error) {
+	return f.resolutions, nil
+}
+func (f *fakeResolutionAlertRepo) CountIssuedSince(_ context.Context, _, _ string, documentTypes []string, _ time.Time) (int64, error) {
+	f.counted = append(f.counted, documentTypes)
+	return f.issued, nil
+}
+func (f *fakeResolutionAlertRepo) CreateIfAbsent(_ context.Context, a *entity.ResolutionAlert) (bool, error) {
+	for _, c := range f.created {
+		if c.ResolutionID == a.ResolutionID && c.Kind == a.Kind && c.RangeTo == a.RangeTo && c.DateTo.Equal(a.DateTo) {
+			return false, nil
+		}
+	}
+	f.created = append(f.created, a)
+	return true, nil
+}
+func (f *fakeResolutionAlertRepo) ListAdminEmails(_ context.Context, _ string) ([]string, error) {
+	return f.admins, nil
+}
+
+var _ ResolutionAlertRepository = (*fakeResolutionAlertRepo)(nil)
+
+type fakeMailSender struct {
+	subjects []string
+}
+
+func (f *fakeMailSender) Send(_, subject, _ string) error {
+	f.subjects = append(f.subjects, subject)
+	return nil
+}
+
+func TestResolutionStatus(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	cfg := ResolutionAlertConfig{}.withDefaults()
+	res := &entity.BillingResolution{
+		ID: "res-1", Prefix: "FV", ResolutionNumber: "18764000000001",
+		RangeFrom: 1, RangeTo: 1000, UsedNumbers: 400,
+		DateFrom: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		DateTo:   time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Healthy", func(t *testing.T) {
+		st := resolutionStatus(res, 30, cfg, now)
+		assert.Equal(t, int64(600), st.Remaining)
+		assert.Equal(t, 60.0, st.RemainingPercent)
+		assert.Equal(t, int64(401), st.NextNumber)
+		assert.Equal(t, 305, st.DaysToExpiry)
+		assert.Equal(t, 1.0, st.DailyVelocity)
+		require.NotNil(t, st.ProjectedExhaustion)
+		assert.Equal(t, "2027-10-22", *st.ProjectedExhaustion)
+		assert.Empty(t, st.Alerts)
+	})
+
+	t.Run("ProjectedExhaustionWithinThreshold", func(t *testing.T) {
+		st := resolutionStatus(res, 900, cfg, now) // 30 por día → 20 días
+		require.NotNil(t, st.ProjectedExhaustion)
+		assert.Equal(t, "2026-03-21", *st.ProjectedExhaustion)
+		assert.Equal(t, []string{entity.ResolutionAlertKindLowNumbers}, st.Alerts)
+	})
+
+	t.Run("LowRemainingAndNearExpiry", func(t *testing.T) {
+		low := *res
+		low.UsedNumbers = 950
+		low.DateTo = time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+		st := resolutionStatus(&low, 0, cfg, now)
+		assert.Nil(t, st.ProjectedExhaustion)
+		assert.Equal(t, []string{entity.ResolutionAlertKindLowNumbers, entity.ResolutionAlertKindNearExpiry}, st.Alerts)
+	})
+}
+
+func TestResolutionMonitor_EvaluateNotifiesOnce(t *testing.T) {
+	res := validResolution(testCompanyID, "FV")
+	res.UsedNumbers = res.RangeTo - res.RangeFrom - 10
+	repo := &fakeResolutionAlertRepo{
+		resolutions: []*entity.BillingResolution{res},
+		admins:      []string{"admin@empresa.co", ""},
+	}
+	mailer := &fakeMailSender{}
+	m := NewResolutionMonitor(repo, mailer, ResolutionAlertConfig{})
+
+	require.NoError(t, m.Evaluate(context.Background(), testCompanyID))
+	require.Len(t, repo.created, 1)
+	assert.Equal(t, entity.ResolutionAlertKindLowNumbers, repo.created[0].Kind)
+	assert.Len(t, mailer.subjects, 1)
+
+	require.NoError(t, m.Evaluate(context.Background(), testCompanyID))
+	assert.Len(t, repo.created, 1)
+	assert.Len(t, mailer.subjects, 1)
+}
+
+func TestResolutionMonitor_EvaluateAlertsAgainAfterResolutionUpdate(t *testing.T) {
+	res := validResolution(testCompanyID, "FV")
+	res.UsedNumbers = res.RangeTo - res.RangeFrom - 10
+	repo := &fakeResolutionAlertRepo{
+		resolutions: []*entity.BillingResolution{res},
+		admins:      []string{"admin@empresa.co"},
+	}
+	mailer := &fakeMailSender{}
+	m := NewResolutionMonitor(repo, mailer, ResolutionAlertConfig{})
+	require.NoError(t, m.Evaluate(context.Background(), testCompanyID))
+	require.Len(t, repo.created, 1)
+
+	// Ampliación del rango: deja de alertar mientras esté holgada.
+	res.RangeTo += 1000
+	require.NoError(t, m.Evaluate(context.Background(), testCompanyID))
+	assert.Len(t, repo.created, 1)
+
+	// Al agotarse el nuevo rango se vuelve a alertar.
+	res.UsedNumbers = res.RangeTo - res.RangeFrom - 10
+	require.NoError(t, m.Evaluate(context.Background(), testCompanyID))
+	require.Len(t, repo.created, 2)
+	assert.Equal(t, res.RangeTo, repo.created[1].RangeTo)
+	assert.Len(t, mailer.subjects, 2)
+}
+
+func TestResolutionMonitor_CountsDocumentTypesOfResolution(t *testing.T) {
+	note := validResolution(testCompanyID, "NC")
+	note.DocumentType = entity.ResolutionDocumentCreditNote
+	repo := &fakeResolutionAlertRepo{
+		resolutions: []*entity.BillingResolution{validResolution(testCompanyID, "FV"), note},
+	}
+	_, err := NewResolutionMonitor(repo, nil, ResolutionAlertConfig{}).Status(context.Background(), testCompanyID)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{entity.ResolutionDocumentInvoice, entity.DocumentTypePOS},
+		{entity.ResolutionDocumentCreditNote},
+	}, repo.counted)
+}
+
+func TestResolutionAlertWorker_PagesThroughAllCompanies(t *testing.T) {
+	total := resolutionAlertPageSize*2 + 3
+	var offsets []int
+	companyRepo := &fakeCompanyRepo{listFunc: func(limit, offset int) ([]*entity.Company, error) {
+		offsets = append(offsets, offset)
+		var page []*entity.Company
+		for i := offset; i < total && i < offset+limit; i++ {
+			page = append(page, validCompany(testCompanyID))
+		}
+		return page, nil
+	}}
+	repo := &fakeResolutionAlertRepo{resolutions: []*entity.BillingResolution{validResolution(testCompanyID, "FV")}}
+	w := NewResolutionAlertWorker(NewResolutionMonitor(repo, nil, ResolutionAlertConfig{}), companyRepo, time.Hour)
+
+	w.runOnce(context.Background())
+	assert.Equal(t, []int{0, resolutionAlertPageSize, resolutionAlertPageSize * 2}, offsets)
+	assert.Len(t, repo.counted, total, "una evaluación por empresa")
+}
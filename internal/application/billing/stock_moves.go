@@ -0,0 +1,74 @@
+package billing
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+// txInventory envuelve InventoryUseCase durante la transacción de un documento y recuerda los
+// productos/bodegas movidos, para avisar a inventario (alertas de stock) solo después del commit.
+type txInventory struct {
+	InventoryUseCase
+	moved [][2]string
+}
+
+func newTxInventory(inventoryUC InventoryUseCase) *txInventory {
+	return &txInventory{InventoryUseCase: inventoryUC}
+}
+
+// RegisterOUTInTx registra la salida y recuerda el producto/bodega si tuvo éxito.
+func (t *txInventory) RegisterOUTInTx(
+	ctx context.Context,
+	movRepo repository.InventoryMovementRepository,
+	stockRepo repository.StockRepository,
+	productRepo repository.ProductRepository,
+	product *entity.Product,
+	productID, warehouseID, userID string,
+	quantity decimal.Decimal,
+	now time.Time,
+	transactionID string,
+) error {
+	if err := t.InventoryUseCase.RegisterOUTInTx(ctx, movRepo, stockRepo, productRepo, product,
+		productID, warehouseID, userID, quantity, now, transactionID); err != nil {
+		return err
+	}
+	t.moved = append(t.moved, [2]string{productID, warehouseID})
+	return nil
+}
+
+// RegisterReturnInTx registra la devolución y recuerda el producto/bodega si tuvo éxito.
+func (t *txInventory) RegisterReturnInTx(
+	ctx context.Context,
+	movRepo repository.InventoryMovementRepository,
+	stockRepo repository.StockRepository,
+	productRepo repository.ProductRepository,
+	product *entity.Product,
+	productID, warehouseID, userID string,
+	quantity decimal.Decimal,
+	now time.Time,
+	transactionID string,
+) error {
+	if err := t.InventoryUseCase.RegisterReturnInTx(ctx, movRepo, stockRepo, productRepo, product,
+		productID, warehouseID, userID, quantity, now, transactionID); err != nil {
+		return err
+	}
+	t.moved = append(t.moved, [2]string{productID, warehouseID})
+	return nil
+}
+
+// reset descarta lo recordado; se llama al iniciar (o reintentar) la transacción.
+func (t *txInventory) reset() {
+	t.moved = t.moved[:0]
+}
+
+// committed avisa los cambios de stock de la transacción ya confirmada.
+func (t *txInventory) committed(companyID string) {
+	for _, m := range t.moved {
+		t.InventoryUseCase.NotifyStockChanged(companyID, m[0], m[1])
+	}
+}
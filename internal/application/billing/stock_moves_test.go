@@ -0,0 +1,42 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+func TestTxInventory_NotifiesOnlyAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	product := validProduct(testCompanyID, testProductID1, decimal.NewFromInt(1000), decimal.Zero)
+	fail := errors.New("stock")
+	inner := &fakeInventoryUC{registerOUTFunc: func(_ context.Context, _ repository.InventoryMovementRepository, _ repository.StockRepository, _ repository.ProductRepository, _ *entity.Product, productID, _, _ string, _ decimal.Decimal, _ time.Time, _ string) error {
+		if productID == testProductID2 {
+			return fail
+		}
+		return nil
+	}}
+	inventory := newTxInventory(inner)
+
+	require.NoError(t, inventory.RegisterOUTInTx(ctx, nil, nil, nil, product, testProductID1, testWarehouseID, testUserID, decimal.NewFromInt(1), time.Now(), "inv-1"))
+	require.NoError(t, inventory.RegisterReturnInTx(ctx, nil, nil, nil, product, testProductID1, "otra-bodega", testUserID, decimal.NewFromInt(1), time.Now(), "inv-1"))
+	assert.ErrorIs(t, inventory.RegisterOUTInTx(ctx, nil, nil, nil, product, testProductID2, testWarehouseID, testUserID, decimal.NewFromInt(1), time.Now(), "inv-1"), fail)
+	assert.Empty(t, inner.notified, "nada se avisa dentro de la transacción")
+
+	inventory.committed(testCompanyID)
+	assert.Equal(t, []string{testProductID1 + "@" + testWarehouseID, testProductID1 + "@otra-bodega"}, inner.notified)
+
+	// Un reintento de la transacción empieza sin lo recordado del intento anterior.
+	inner.notified = nil
+	inventory.reset()
+	inventory.committed(testCompanyID)
+	assert.Empty(t, inner.notified)
+}
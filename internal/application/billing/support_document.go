@@ -0,0 +1,549 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// SupportDocumentUseCase emite el documento soporte en adquisiciones a no obligados a facturar (y su
+// nota de ajuste): lo genera desde una orden de compra recibida o una compra manual, toma el
+// consecutivo de su propia resolución y lo procesa con el mismo ciclo de las facturas:
+//
+//	CUDS → XML UBL 2.1 → Firma XAdES-EPES → ZIP → Envío SOAP → GetStatusZip
+type SupportDocumentUseCase struct {
+	repo           SupportDocumentRepository
+	companyRepo    repository.CompanyRepository
+	supplierRepo   repository.SupplierRepository
+	productRepo    repository.ProductRepository
+	resolutionRepo repository.BillingResolutionRepository
+	purchaseOrders PurchaseOrderReader
+	credentials    DIANCredentialsProvider
+	xmlBuilder     *infradian.XMLBuilderService
+	signer         pkgdian.Signer
+	submitter      infradian.DIANSubmitter // nil en dev
+	now            func() time.Time
+	dispatch       func(id string) // procesamiento DIAN tras persistir; por defecto en goroutine
+}
+
+// NewSupportDocumentUseCase construye el caso de uso. submitter puede ser nil: solo funciona el modo dev.
+func NewSupportDocumentUseCase(
+	repo SupportDocumentRepository,
+	companyRepo repository.CompanyRepository,
+	supplierRepo repository.SupplierRepository,
+	productRepo repository.ProductRepository,
+	resolutionRepo repository.BillingResolutionRepository,
+	purchaseOrders PurchaseOrderReader,
+	credentials DIANCredentialsProvider,
+	xmlBuilder *infradian.XMLBuilderService,
+	signer pkgdian.Signer,
+	submitter infradian.DIANSubmitter,
+) *SupportDocumentUseCase {
+	uc := &SupportDocumentUseCase{
+		repo:           repo,
+		companyRepo:    companyRepo,
+		supplierRepo:   supplierRepo,
+		productRepo:    productRepo,
+		resolutionRepo: resolutionRepo,
+		purchaseOrders: purchaseOrders,
+		credentials:    credentials,
+		xmlBuilder:     xmlBuilder,
+		signer:         signer,
+		submitter:      submitter,
+		now:            time.Now,
+	}
+	uc.dispatch = func(id string) { go uc.processDetached(id) }
+	return uc
+}
+
+// Create registra el documento soporte de una orden de compra recibida (purchase_order_id) o de una
+// compra manual (lines) y lo procesa ante la DIAN en segundo plano.
+func (uc *SupportDocumentUseCase) Create(ctx context.Context, companyID, userID string, in dto.CreateSupportDocumentRequest) (*dto.SupportDocumentDTO, error) {
+	in.Prefix = strings.ToUpper(strings.TrimSpace(in.Prefix))
+	if in.Prefix == "" {
+		return nil, fmt.Errorf("%w: prefix es requerido", domain.ErrInvalidInput)
+	}
+	date, err := uc.documentDate(in.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []*entity.SupportDocumentLine
+	if poID := strings.TrimSpace(in.PurchaseOrderID); poID != "" {
+		po, err := uc.purchaseOrders.GetByID(ctx, poID)
+		if err != nil {
+			return nil, err
+		}
+		if po == nil || po.CompanyID != companyID {
+			return nil, domain.ErrNotFound
+		}
+		if po.Status != entity.PurchaseOrderStatusClosed {
+			return nil, fmt.Errorf("%w: la orden de compra %s aún no ha sido recibida", domain.ErrInvalidInput, po.Number)
+		}
+		if in.SupplierID != "" && in.SupplierID != po.SupplierID {
+			return nil, fmt.Errorf("%w: el proveedor no corresponde a la orden de compra", domain.ErrInvalidInput)
+		}
+		in.SupplierID, in.PurchaseOrderID = po.SupplierID, po.ID
+		if lines, err = uc.purchaseOrderLines(po); err != nil {
+			return nil, err
+		}
+	} else {
+		if lines, err = supportDocumentLines(in.Lines); err != nil {
+			return nil, err
+		}
+	}
+
+	supplier, err := uc.supplierRepo.GetByID(in.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	if supplier == nil || supplier.CompanyID != companyID {
+		return nil, domain.ErrNotFound
+	}
+	identType := strings.TrimSpace(in.SupplierIdentificationType)
+	if identType == "" {
+		identType = identTypeCode(supplier.NIT)
+	}
+	if !pkgdian.ValidIdentificationTypes[identType] {
+		return nil, fmt.Errorf("%w: supplier_identification_type %q no válido", domain.ErrInvalidInput, identType)
+	}
+
+	now := uc.now()
+	doc := &entity.SupportDocument{
+		ID:                         uuid.New().String(),
+		CompanyID:                  companyID,
+		SupplierID:                 supplier.ID,
+		PurchaseOrderID:            in.PurchaseOrderID,
+		DocumentType:               entity.SupportDocumentTypeDS,
+		Prefix:                     in.Prefix,
+		Date:                       date,
+		SupplierName:               supplier.Name,
+		SupplierIdentification:     supplier.NIT,
+		SupplierIdentificationType: identType,
+		DIANStatus:                 entity.DIANStatusDraft,
+		Notes:                      strings.TrimSpace(in.Notes),
+		Lines:                      lines,
+		CreatedBy:                  userID,
+		CreatedAt:                  now,
+		UpdatedAt:                  now,
+	}
+	computeSupportDocumentTotals(doc)
+	if err := uc.repo.Create(ctx, doc); err != nil {
+		return nil, err
+	}
+	uc.dispatch(doc.ID)
+	return toSupportDocumentDTO(doc), nil
+}
+
+// CreateAdjustment emite la nota de ajuste de un documento soporte aceptado por la DIAN. Sin líneas
+// ajusta el documento completo; el total de la nota no puede superar el del documento.
+func (uc *SupportDocumentUseCase) CreateAdjustment(ctx context.Context, companyID, userID, documentID string, in dto.CreateSupportAdjustmentRequest) (*dto.SupportDocumentDTO, error) {
+	in.Prefix = strings.ToUpper(strings.TrimSpace(in.Prefix))
+	if in.Prefix == "" {
+		return nil, fmt.Errorf("%w: prefix es requerido", domain.ErrInvalidInput)
+	}
+	concept := entity.CreditNoteConcept(strings.TrimSpace(in.Concept))
+	if concept < entity.CreditNoteConceptDevolucionParcial || concept > entity.CreditNoteConceptOtros || len(concept) != 1 {
+		return nil, fmt.Errorf("%w: concept debe estar entre 1 y 6", domain.ErrInvalidInput)
+	}
+	original, err := uc.repo.GetByID(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || original.CompanyID != companyID {
+		return nil, domain.ErrNotFound
+	}
+	if original.IsAdjustment() || original.DIANStatus != entity.DIANStatusExitoso {
+		return nil, fmt.Errorf("%w: solo se ajustan documentos soporte aceptados por la DIAN", domain.ErrInvalidInput)
+	}
+
+	var lines []*entity.SupportDocumentLine
+	if len(in.Lines) == 0 {
+		for _, l := range original.Lines {
+			copied := *l
+			copied.ID = uuid.New().String()
+			lines = append(lines, &copied)
+		}
+	} else if lines, err = supportDocumentLines(in.Lines); err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	doc := &entity.SupportDocument{
+		ID:                         uuid.New().String(),
+		CompanyID:                  companyID,
+		SupplierID:                 original.SupplierID,
+		DocumentType:               entity.SupportDocumentTypeAdjustment,
+		Prefix:                     in.Prefix,
+		Date:                       now,
+		SupplierName:               original.SupplierName,
+		SupplierIdentification:     original.SupplierIdentification,
+		SupplierIdentificationType: original.SupplierIdentificationType,
+		DIANStatus:                 entity.DIANStatusDraft,
+		OriginalDocumentID:         original.ID,
+		OriginalDocumentNumber:     original.FullNumber(),
+		OriginalDocumentCUDS:       original.CUDS,
+		OriginalIssueOn:            original.Date,
+		DiscrepancyCode:            concept,
+		DiscrepancyReason:          strings.TrimSpace(in.Reason),
+		Lines:                      lines,
+		CreatedBy:                  userID,
+		CreatedAt:                  now,
+		UpdatedAt:                  now,
+	}
+	computeSupportDocumentTotals(doc)
+	if doc.GrandTotal.GreaterThan(original.GrandTotal) {
+		return nil, fmt.Errorf("%w: la nota de ajuste supera el total del documento soporte", domain.ErrInvalidInput)
+	}
+	if err := uc.repo.Create(ctx, doc); err != nil {
+		return nil, err
+	}
+	uc.dispatch(doc.ID)
+	return toSupportDocumentDTO(doc), nil
+}
+
+// Get devuelve el documento con sus líneas y estado DIAN.
+func (uc *SupportDocumentUseCase) Get(ctx context.Context, companyID, id string) (*dto.SupportDocumentDTO, error) {
+	doc, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.CompanyID != companyID {
+		return nil, domain.ErrNotFound
+	}
+	return toSupportDocumentDTO(doc), nil
+}
+
+// List devuelve los documentos soporte y notas de ajuste de la empresa (documentType vacío = todos).
+func (uc *SupportDocumentUseCase) List(ctx context.Context, companyID, documentType string) ([]dto.SupportDocumentDTO, error) {
+	docs, err := uc.repo.List(ctx, companyID, strings.ToUpper(strings.TrimSpace(documentType)))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.SupportDocumentDTO, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, *toSupportDocumentDTO(d))
+	}
+	return out, nil
+}
+
+// processDetached procesa el documento con su propio contexto, desacoplado del ciclo HTTP.
+func (uc *SupportDocumentUseCase) processDetached(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := uc.Process(ctx, id); err != nil {
+		log.Printf("[DIAN][DS][%s] %v", id, err)
+	}
+}
+
+// Process calcula el CUDS, genera y firma el XML y lo envía a la DIAN. Los errores de generación
+// dejan el documento en ERROR_GENERATION; los timeouts del WS en CONTINGENCIA para el worker.
+func (uc *SupportDocumentUseCase) Process(ctx context.Context, id string) error {
+	doc, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return domain.ErrNotFound
+	}
+	if doc.DIANStatus != entity.DIANStatusDraft {
+		return nil // ya procesado
+	}
+	company, err := uc.companyRepo.GetByID(doc.CompanyID)
+	if err != nil || company == nil {
+		return uc.markError(ctx, doc, fmt.Sprintf("empresa %s no encontrada: %v", doc.CompanyID, err))
+	}
+	creds, err := uc.credentials.Resolve(ctx, company)
+	if err != nil {
+		return uc.markError(ctx, doc, err.Error())
+	}
+	if creds.SoftwarePIN == "" {
+		return uc.markError(ctx, doc, "el CUDS requiere el PIN del software DIAN de la empresa")
+	}
+
+	var resData *infradian.BillingResolutionData
+	if !doc.IsAdjustment() {
+		res, err := uc.resolutionRepo.GetActiveByCompanyAndPrefix(ctx, doc.CompanyID, doc.Prefix)
+		if err != nil {
+			return uc.markError(ctx, doc, fmt.Sprintf("error consultando resolución: %v", err))
+		}
+		if res == nil {
+			return uc.markError(ctx, doc, fmt.Sprintf("sin resolución activa para el prefijo %s", doc.Prefix))
+		}
+		resData = &infradian.BillingResolutionData{
+			Number: res.ResolutionNumber, Prefix: res.Prefix,
+			From: res.RangeFrom, To: res.RangeTo,
+			DateFrom: res.DateFrom, DateTo: res.DateTo,
+		}
+	}
+
+	if _, err := infradian.CalculateCudsFromSupportDocument(doc, company, creds.SoftwarePIN, creds.TipoAmbiente); err != nil {
+		return uc.markError(ctx, doc, err.Error())
+	}
+	var securityCode string
+	if creds.SoftwareID != "" {
+		securityCode = infradian.SoftwareSecurityCode(creds.SoftwareID, creds.SoftwarePIN, doc.FullNumber())
+	}
+	xmlBytes, err := uc.xmlBuilder.BuildSupportDocument(&infradian.SupportDocumentBuildContext{
+		Document:                      doc,
+		Company:                       company,
+		Resolution:                    resData,
+		SoftwareID:                    creds.SoftwareID,
+		SoftwareSecurityCode:          securityCode,
+		TipoAmbiente:                  creds.TipoAmbiente,
+		CompanyIdentificationTypeCode: "31",
+	})
+	if err != nil {
+		return uc.markError(ctx, doc, err.Error())
+	}
+	signed, err := uc.signer.Sign(xmlBytes, creds.Certificate)
+	if err != nil {
+		return uc.markError(ctx, doc, err.Error())
+	}
+	doc.XMLSigned = string(signed)
+	doc.DIANStatus = entity.DIANStatusSigned
+	doc.UpdatedAt = uc.now()
+	if err := uc.repo.Update(ctx, doc); err != nil {
+		return err
+	}
+	return uc.submit(ctx, doc, company, creds.AppEnv)
+}
+
+// submit envía el XML firmado según el ambiente: en dev simula la aceptación; en test/prod usa
+// SendBillAsync y deja el documento en Sent hasta que GetStatusZip dé el resultado.
+func (uc *SupportDocumentUseCase) submit(ctx context.Context, doc *entity.SupportDocument, company *entity.Company, appEnv string) error {
+	xmlName, zipName := infradian.DIANDocumentFilenames(company, doc.Prefix, doc.Number)
+	zipBytes, err := infradian.CompressXMLToZip([]byte(doc.XMLSigned), xmlName)
+	if err != nil {
+		return uc.markError(ctx, doc, err.Error())
+	}
+	now := uc.now()
+	doc.UpdatedAt = now
+	switch appEnv {
+	case infradian.AppEnvDev, "":
+		log.Printf("[DIAN][DS][%s] [DEV] Simulando envío a DIAN — ZIP generado: %s (%d bytes)", doc.ID, zipName, len(zipBytes))
+		doc.DIANStatus, doc.TrackID, doc.DIANErrors, doc.NextCheckAt = entity.DIANStatusExitoso, "MOCK-TRACK-123", "", nil
+	case infradian.AppEnvTest, infradian.AppEnvProd:
+		if uc.submitter == nil {
+			return uc.markError(ctx, doc, "DIANSubmitter no inyectado para entorno "+appEnv)
+		}
+		res, err := uc.submitter.SubmitZip(ctx, zipBytes, zipName, appEnv)
+		if err != nil {
+			if !isDIANTimeoutError(err) {
+				return uc.markError(ctx, doc, err.Error())
+			}
+			doc.StatusChecks++
+			next := now.Add(statusCheckBackoff(doc.StatusChecks))
+			doc.DIANStatus, doc.DIANErrors, doc.NextCheckAt = entity.DIANStatusContingencia, err.Error(), &next
+			log.Printf("[DIAN][DS][%s] timeout DIAN: documento en CONTINGENCIA", doc.ID)
+			break
+		}
+		doc.TrackID, doc.DIANErrors = res.TrackID, res.Errors
+		switch {
+		case res.Accepted && res.TrackID != "":
+			next := now.Add(statusCheckBaseDelay)
+			doc.DIANStatus, doc.StatusChecks, doc.NextCheckAt = entity.DIANStatusSent, 0, &next
+		case res.Accepted:
+			doc.DIANStatus, doc.NextCheckAt = entity.DIANStatusExitoso, nil
+		default:
+			doc.DIANStatus, doc.NextCheckAt = entity.DIANStatusRechazado, nil
+		}
+	default:
+		return uc.markError(ctx, doc, fmt.Sprintf("DIAN_ENV desconocido: %q (usar dev|test|prod)", appEnv))
+	}
+	if err := uc.repo.Update(ctx, doc); err != nil {
+		return err
+	}
+	log.Printf("[DIAN][DS][%s] %s procesado → %s (TrackID: %s)", doc.ID, doc.FullNumber(), doc.DIANStatus, doc.TrackID)
+	return nil
+}
+
+// AdvancePending reenvía los documentos en CONTINGENCIA y consulta GetStatusZip de los enviados cuya
+// consulta venció (lo invoca SupportDocumentWorker).
+func (uc *SupportDocumentUseCase) AdvancePending(ctx context.Context, limit int) error {
+	if uc.submitter == nil {
+		return nil
+	}
+	docs, err := uc.repo.ListPending(ctx, uc.now(), limit)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		company, err := uc.companyRepo.GetByID(doc.CompanyID)
+		if err != nil || company == nil {
+			log.Printf("[DIAN][DS][%s] empresa %s no encontrada: %v", doc.ID, doc.CompanyID, err)
+			continue
+		}
+		creds, err := uc.credentials.Resolve(ctx, company)
+		if err != nil {
+			log.Printf("[DIAN][DS][%s] credenciales de la empresa %s: %v", doc.ID, doc.CompanyID, err)
+			continue
+		}
+		if doc.DIANStatus == entity.DIANStatusContingencia {
+			err = uc.submit(ctx, doc, company, creds.AppEnv)
+		} else {
+			err = uc.checkStatus(ctx, doc, creds.AppEnv)
+		}
+		if err != nil {
+			log.Printf("[DIAN][DS][%s] %v", doc.ID, err)
+		}
+	}
+	return nil
+}
+
+// checkStatus consulta la validación de un documento en Sent con el mismo backoff de las facturas.
+func (uc *SupportDocumentUseCase) checkStatus(ctx context.Context, doc *entity.SupportDocument, appEnv string) error {
+	now := uc.now()
+	doc.StatusChecks++
+	doc.UpdatedAt = now
+	res, err := uc.submitter.GetStatusZip(ctx, doc.TrackID, appEnv)
+	switch {
+	case err == nil && !res.IsPending():
+		doc.DIANStatus, doc.DIANErrors, _ = validationOutcome(res)
+		doc.NextCheckAt = nil
+	case doc.StatusChecks >= statusCheckMaxAttempts:
+		doc.DIANStatus = entity.DIANStatusError
+		doc.DIANErrors = fmt.Sprintf("sin resultado definitivo de la DIAN tras %d consultas del TrackID %s", doc.StatusChecks, doc.TrackID)
+		doc.NextCheckAt = nil
+	default:
+		if err != nil {
+			log.Printf("[DIAN][DS][%s] GetStatusZip falló (intento %d): %v", doc.ID, doc.StatusChecks, err)
+		}
+		next := now.Add(statusCheckBackoff(doc.StatusChecks))
+		doc.NextCheckAt = &next
+	}
+	return uc.repo.Update(ctx, doc)
+}
+
+func (uc *SupportDocumentUseCase) markError(ctx context.Context, doc *entity.SupportDocument, msg string) error {
+	doc.DIANStatus = entity.DIANStatusErrorGeneration
+	doc.DIANErrors = msg
+	doc.NextCheckAt = nil
+	doc.UpdatedAt = uc.now()
+	if err := uc.repo.Update(ctx, doc); err != nil {
+		return err
+	}
+	return fmt.Errorf("documento soporte %s: %s", doc.FullNumber(), msg)
+}
+
+func (uc *SupportDocumentUseCase) documentDate(raw string) (time.Time, error) {
+	now := uc.now()
+	if strings.TrimSpace(raw) == "" {
+		return now, nil
+	}
+	d, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(raw), now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date debe tener formato YYYY-MM-DD", domain.ErrInvalidInput)
+	}
+	// Conserva la hora de emisión (HorDS del CUDS).
+	return time.Date(d.Year(), d.Month(), d.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location()), nil
+}
+
+// purchaseOrderLines líneas del documento a partir de lo recibido en la orden de compra (sin IVA:
+// el vendedor no es responsable del impuesto).
+func (uc *SupportDocumentUseCase) purchaseOrderLines(po *entity.PurchaseOrder) ([]*entity.SupportDocumentLine, error) {
+	if len(po.Items) == 0 {
+		return nil, fmt.Errorf("%w: la orden de compra %s no tiene ítems", domain.ErrInvalidInput, po.Number)
+	}
+	lines := make([]*entity.SupportDocumentLine, 0, len(po.Items))
+	for _, item := range po.Items {
+		description := "Producto " + item.ProductID
+		if product, err := uc.productRepo.GetByID(item.ProductID); err == nil && product != nil {
+			description = product.Name
+		}
+		lines = append(lines, &entity.SupportDocumentLine{
+			ID:          uuid.New().String(),
+			ProductID:   item.ProductID,
+			Description: description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitCost,
+			TaxRate:     decimal.Zero,
+		})
+	}
+	return lines, nil
+}
+
+func supportDocumentLines(in []dto.SupportDocumentLineRequest) ([]*entity.SupportDocumentLine, error) {
+	if len(in) == 0 {
+		return nil, fmt.Errorf("%w: el documento soporte requiere al menos una línea", domain.ErrInvalidInput)
+	}
+	lines := make([]*entity.SupportDocumentLine, 0, len(in))
+	for i, l := range in {
+		description := strings.TrimSpace(l.Description)
+		if description == "" || !l.Quantity.IsPositive() || l.UnitPrice.IsNegative() || l.TaxRate.IsNegative() || l.TaxRate.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+			return nil, fmt.Errorf("%w: línea %d inválida (descripción, cantidad > 0, precio >= 0 y tax_rate en [0, 1))", domain.ErrInvalidInput, i+1)
+		}
+		lines = append(lines, &entity.SupportDocumentLine{
+			ID:          uuid.New().String(),
+			ProductID:   strings.TrimSpace(l.ProductID),
+			Description: description,
+			Quantity:    l.Quantity,
+			UnitPrice:   l.UnitPrice,
+			TaxRate:     l.TaxRate,
+		})
+	}
+	return lines, nil
+}
+
+// computeSupportDocumentTotals calcula subtotal e IVA por línea (redondeados a 2 decimales) y los totales.
+func computeSupportDocumentTotals(doc *entity.SupportDocument) {
+	doc.NetTotal, doc.TaxTotal = decimal.Zero, decimal.Zero
+	for _, l := range doc.Lines {
+		l.Subtotal = l.Quantity.Mul(l.UnitPrice).Round(2)
+		l.TaxAmount = l.Subtotal.Mul(l.TaxRate).Round(2)
+		doc.NetTotal = doc.NetTotal.Add(l.Subtotal)
+		doc.TaxTotal = doc.TaxTotal.Add(l.TaxAmount)
+	}
+	doc.GrandTotal = doc.NetTotal.Add(doc.TaxTotal)
+}
+
+func toSupportDocumentDTO(d *entity.SupportDocument) *dto.SupportDocumentDTO {
+	out := &dto.SupportDocumentDTO{
+		ID:                         d.ID,
+		DocumentType:               d.DocumentType,
+		Number:                     d.FullNumber(),
+		Date:                       d.Date,
+		SupplierID:                 d.SupplierID,
+		SupplierName:               d.SupplierName,
+		SupplierIdentification:     d.SupplierIdentification,
+		SupplierIdentificationType: d.SupplierIdentificationType,
+		PurchaseOrderID:            d.PurchaseOrderID,
+		OriginalDocumentID:         d.OriginalDocumentID,
+		OriginalDocumentNumber:     d.OriginalDocumentNumber,
+		DiscrepancyCode:            string(d.DiscrepancyCode),
+		DiscrepancyReason:          d.DiscrepancyReason,
+		NetTotal:                   d.NetTotal,
+		TaxTotal:                   d.TaxTotal,
+		GrandTotal:                 d.GrandTotal,
+		CUDS:                       d.CUDS,
+		DIANStatus:                 d.DIANStatus,
+		DIANErrors:                 d.DIANErrors,
+		TrackID:                    d.TrackID,
+		Notes:                      d.Notes,
+		CreatedAt:                  d.CreatedAt,
+	}
+	for _, l := range d.Lines {
+		out.Lines = append(out.Lines, dto.SupportDocumentLineDTO{
+			ProductID:   l.ProductID,
+			Description: l.Description,
+			Quantity:    l.Quantity,
+			UnitPrice:   l.UnitPrice,
+			TaxRate:     l.TaxRate,
+			Subtotal:    l.Subtotal,
+			TaxAmount:   l.TaxAmount,
+		})
+	}
+	return out
+}
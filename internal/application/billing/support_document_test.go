@@ -0,0 +1,236 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+)
+
+type fakeSupportDocumentRepo struct {
+	docs map[string]*entity.SupportDocument
+	next int64
+}
+
+func (f *fakeSupportDocumentRepo) Create(_ context.Context, doc *entity.SupportDocument) error {
+	for _, d := range f.docs {
+		if doc.PurchaseOrderID != "" && d.PurchaseOrderID == doc.PurchaseOrderID && !d.IsAdjustment() {
+			return domain.ErrConflict
+		}
+	}
+	f.next++
+	doc.Number = decimal.NewFromInt(f.next).String()
+	f.docs[doc.ID] = doc
+	return nil
+}
+func (f *fakeSupportDocumentRepo) GetByID(_ context.Context, id string) (*entity.SupportDocument, error) {
+	return f.docs[id], nil
+}
+func (f *fakeSupportDocumentRepo) List(context.Context, string, string) ([]*entity.SupportDocument, error) {
+	return nil, nil
+}
+func (f *fakeSupportDocumentRepo) Update(context.Context, *entity.SupportDocument) error { return nil }
+func (f *fakeSupportDocumentRepo) ListPending(_ context.Context, now time.Time, _ int) ([]*entity.SupportDocument, error) {
+	var out []*entity.SupportDocument
+	for _, d := range f.docs {
+		if (d.DIANStatus == entity.DIANStatusSent || d.DIANStatus == entity.DIANStatusContingencia) &&
+			(d.NextCheckAt == nil || !d.NextCheckAt.After(now)) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+var _ SupportDocumentRepository = (*fakeSupportDocumentRepo)(nil)
+
+type fakeSupplierRepo struct{ supplier *entity.Supplier }
+
+func (f *fakeSupplierRepo) Create(*entity.Supplier) error { return nil }
+func (f *fakeSupplierRepo) GetByID(id string) (*entity.Supplier, error) {
+	if f.supplier != nil && f.supplier.ID == id {
+		return f.supplier, nil
+	}
+	return nil, nil
+}
+func (f *fakeSupplierRepo) GetByCompanyAndNIT(_ string, nit string) (*entity.Supplier, error) {
+	if f.supplier != nil && f.supplier.NIT == nit {
+		return f.supplier, nil
+	}
+	return nil, nil
+}
+func (f *fakeSupplierRepo) Update(*entity.Supplier) error { return nil }
+func (f *fakeSupplierRepo) ListByCompany(string, string, int, int) ([]*entity.Supplier, error) {
+	return nil, nil
+}
+func (f *fakeSupplierRepo) SetActive(string, string, bool) error { return nil }
+
+type fakeResolutionRepo struct{ res *entity.BillingResolution }
+
+func (f *fakeResolutionRepo) Create(context.Context, *entity.BillingResolution) error { return nil }
+func (f *fakeResolutionRepo) GetByID(context.Context, string) (*entity.BillingResolution, error) {
+	return f.res, nil
+}
+func (f *fakeResolutionRepo) GetActiveByCompanyAndPrefix(_ context.Context, _, prefix string) (*entity.BillingResolution, error) {
+	if f.res != nil && f.res.Prefix == prefix {
+		return f.res, nil
+	}
+	return nil, nil
+}
+func (f *fakeResolutionRepo) ListByCompany(context.Context, string) ([]*entity.BillingResolution, error) {
+	return nil, nil
+}
+func (f *fakeResolutionRepo) Update(context.Context, *entity.BillingResolution) error { return nil }
+
+type fakePurchaseOrders struct{ po *entity.PurchaseOrder }
+
+func (f *fakePurchaseOrders) GetByID(_ context.Context, id string) (*entity.PurchaseOrder, error) {
+	if f.po != nil && f.po.ID == id {
+		return f.po, nil
+	}
+	return nil, nil
+}
+
+type fakeSupportSubmitter struct {
+	submitErr error
+	status    *infradian.StatusResult
+	submitted []string
+}
+
+func (f *fakeSupportSubmitter) SubmitZip(_ context.Context, _ []byte, filename, _ string) (*infradian.SubmitResult, error) {
+	f.submitted = append(f.submitted, filename)
+	if f.submitErr != nil {
+		return nil, f.submitErr
+	}
+	return &infradian.SubmitResult{TrackID: "track-" + filename, Accepted: true}, nil
+}
+func (f *fakeSupportSubmitter) GetStatusZip(context.Context, string, string) (*infradian.StatusResult, error) {
+	return f.status, nil
+}
+
+func TestSupportDocumentUseCase(t *testing.T) {
+	ctx := context.Background()
+	company := &entity.Company{ID: testCompanyID, NIT: "900123456", Name: "Empresa de prueba", Address: "Calle 1"}
+	supplier := &entity.Supplier{ID: "sup-1", CompanyID: testCompanyID, Name: "Juan Pérez", NIT: "10203040"}
+	resolution := &entity.BillingResolution{
+		ResolutionNumber: "18760000009", Prefix: "DS", RangeFrom: 1, RangeTo: 1000,
+		DateFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), DateTo: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	po := &entity.PurchaseOrder{
+		ID: "po-1", CompanyID: testCompanyID, SupplierID: supplier.ID, Number: "OC-1", Status: entity.PurchaseOrderStatusClosed,
+		Items: []entity.PurchaseOrderItem{{ProductID: "prod-1", Quantity: decimal.NewFromInt(10), UnitCost: decimal.NewFromInt(2500)}},
+	}
+	creds := &DIANCredentials{AppEnv: "dev", TipoAmbiente: "2", SoftwareID: "sw-1", SoftwarePIN: "12345"}
+	productRepo := &fakeProductRepo{getByIDFunc: func(id string) (*entity.Product, error) {
+		return &entity.Product{ID: id, Name: "Café en grano"}, nil
+	}}
+
+	newUseCase := func(creds *DIANCredentials, sub infradian.DIANSubmitter) (*SupportDocumentUseCase, *fakeSupportDocumentRepo, *[]string) {
+		repo := &fakeSupportDocumentRepo{docs: map[string]*entity.SupportDocument{}}
+		uc := NewSupportDocumentUseCase(repo,
+			&fakeCompanyRepo{getByIDFunc: func(string) (*entity.Company, error) { return company, nil }},
+			&fakeSupplierRepo{supplier: supplier}, productRepo, &fakeResolutionRepo{res: resolution},
+			&fakePurchaseOrders{po: po}, &fakeCredentials{creds: creds}, infradian.NewXMLBuilderService(), fakeSigner{}, sub)
+		dispatched := &[]string{}
+		uc.dispatch = func(id string) { *dispatched = append(*dispatched, id) }
+		return uc, repo, dispatched
+	}
+
+	t.Run("desde orden de compra recibida: CUDS, XML tipo 05 y aceptado en dev", func(t *testing.T) {
+		uc, repo, dispatched := newUseCase(creds, nil)
+		out, err := uc.Create(ctx, testCompanyID, "user-1", dto.CreateSupportDocumentRequest{PurchaseOrderID: "po-1", Prefix: " ds "})
+		require.NoError(t, err)
+		assert.Equal(t, "DS1", out.Number)
+		assert.Equal(t, "13", out.SupplierIdentificationType, "cédula inferida del NIT del proveedor")
+		assert.True(t, out.GrandTotal.Equal(decimal.NewFromInt(25000)))
+		require.Len(t, out.Lines, 1)
+		assert.Equal(t, "Café en grano", out.Lines[0].Description)
+		require.Equal(t, []string{out.ID}, *dispatched)
+
+		require.NoError(t, uc.Process(ctx, out.ID))
+		doc := repo.docs[out.ID]
+		assert.Equal(t, entity.DIANStatusExitoso, doc.DIANStatus)
+		assert.Len(t, doc.CUDS, 96)
+		assert.Contains(t, doc.XMLSigned, ">05</InvoiceTypeCode>")
+		assert.Contains(t, doc.XMLSigned, `schemeName="CUDS-SHA384"`)
+		assert.Contains(t, doc.XMLSigned, "18760000009")
+
+		_, err = uc.Create(ctx, testCompanyID, "user-1", dto.CreateSupportDocumentRequest{PurchaseOrderID: "po-1", Prefix: "DS"})
+		assert.True(t, errors.Is(err, domain.ErrConflict), "una orden de compra origina un solo documento soporte")
+
+		adj, err := uc.CreateAdjustment(ctx, testCompanyID, "user-1", out.ID, dto.CreateSupportAdjustmentRequest{
+			Prefix: "NAS", Concept: "2", Reason: "Compra anulada",
+		})
+		require.NoError(t, err)
+		require.NoError(t, uc.Process(ctx, adj.ID))
+		note := repo.docs[adj.ID]
+		assert.Equal(t, entity.DIANStatusExitoso, note.DIANStatus)
+		assert.Contains(t, note.XMLSigned, ">95</CreditNoteTypeCode>")
+		assert.Contains(t, note.XMLSigned, doc.CUDS, "la nota referencia el CUDS del documento ajustado")
+	})
+
+	t.Run("validaciones de la compra manual y de la nota", func(t *testing.T) {
+		uc, repo, _ := newUseCase(creds, nil)
+		_, err := uc.Create(ctx, testCompanyID, "user-1", dto.CreateSupportDocumentRequest{SupplierID: "sup-1", Prefix: "DS"})
+		assert.True(t, errors.Is(err, domain.ErrInvalidInput), "la compra manual requiere líneas")
+
+		out, err := uc.Create(ctx, testCompanyID, "user-1", dto.CreateSupportDocumentRequest{
+			SupplierID: "sup-1", Prefix: "DS",
+			Lines: []dto.SupportDocumentLineRequest{{Description: "Servicio de transporte", Quantity: decimal.NewFromInt(1), UnitPrice: decimal.NewFromInt(80000)}},
+		})
+		require.NoError(t, err)
+		_, err = uc.CreateAdjustment(ctx, testCompanyID, "user-1", out.ID, dto.CreateSupportAdjustmentRequest{Prefix: "NAS", Concept: "2"})
+		assert.True(t, errors.Is(err, domain.ErrInvalidInput), "solo se ajustan documentos aceptados")
+
+		repo.docs[out.ID].DIANStatus = entity.DIANStatusExitoso
+		_, err = uc.CreateAdjustment(ctx, testCompanyID, "user-1", out.ID, dto.CreateSupportAdjustmentRequest{
+			Prefix: "NAS", Concept: "3",
+			Lines: []dto.SupportDocumentLineRequest{{Description: "Rebaja", Quantity: decimal.NewFromInt(1), UnitPrice: decimal.NewFromInt(90000)}},
+		})
+		assert.True(t, errors.Is(err, domain.ErrInvalidInput), "la nota no puede superar el documento")
+	})
+
+	t.Run("sin PIN del software queda en ERROR_GENERATION", func(t *testing.T) {
+		uc, repo, _ := newUseCase(&DIANCredentials{AppEnv: "dev", TipoAmbiente: "2"}, nil)
+		out, err := uc.Create(ctx, testCompanyID, "user-1", dto.CreateSupportDocumentRequest{PurchaseOrderID: "po-1", Prefix: "DS"})
+		require.NoError(t, err)
+		assert.Error(t, uc.Process(ctx, out.ID))
+		assert.Equal(t, entity.DIANStatusErrorGeneration, repo.docs[out.ID].DIANStatus)
+	})
+
+	t.Run("envío asíncrono, contingencia y validación por GetStatusZip", func(t *testing.T) {
+		sub := &fakeSupportSubmitter{submitErr: context.DeadlineExceeded}
+		testCreds := *creds
+		testCreds.AppEnv = "test"
+		uc, repo, _ := newUseCase(&testCreds, sub)
+		now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+		uc.now = func() time.Time { return now }
+
+		out, err := uc.Create(ctx, testCompanyID, "user-1", dto.CreateSupportDocumentRequest{PurchaseOrderID: "po-1", Prefix: "DS"})
+		require.NoError(t, err)
+		require.NoError(t, uc.Process(ctx, out.ID))
+		doc := repo.docs[out.ID]
+		assert.Equal(t, entity.DIANStatusContingencia, doc.DIANStatus)
+		assert.Equal(t, []string{"900123456DS1.zip"}, sub.submitted)
+
+		sub.submitErr = nil
+		now = now.Add(time.Hour)
+		require.NoError(t, uc.AdvancePending(ctx, 10))
+		assert.Equal(t, entity.DIANStatusSent, doc.DIANStatus)
+		assert.Equal(t, "track-900123456DS1.zip", doc.TrackID)
+
+		sub.status = &infradian.StatusResult{IsValid: true, StatusCode: infradian.StatusCodeProcessed}
+		now = now.Add(time.Minute)
+		require.NoError(t, uc.AdvancePending(ctx, 10))
+		assert.Equal(t, entity.DIANStatusExitoso, doc.DIANStatus)
+		assert.Nil(t, doc.NextCheckAt)
+	})
+}
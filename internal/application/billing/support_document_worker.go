@@ -0,0 +1,47 @@
+package billing
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// SupportDocumentWorker avanza periódicamente los documentos soporte pendientes: reenvía los que
+// quedaron en CONTINGENCIA y consulta GetStatusZip de los enviados.
+type SupportDocumentWorker struct {
+	uc        *SupportDocumentUseCase
+	interval  time.Duration
+	batchSize int
+}
+
+func NewSupportDocumentWorker(uc *SupportDocumentUseCase, interval time.Duration, batchSize int) *SupportDocumentWorker {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	return &SupportDocumentWorker{uc: uc, interval: interval, batchSize: batchSize}
+}
+
+func (w *SupportDocumentWorker) Start(ctx context.Context) {
+	if w.uc == nil {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+			if err := w.uc.AdvancePending(runCtx, w.batchSize); err != nil {
+				log.Printf("[DIAN][DS] no se pudieron listar documentos soporte pendientes: %v", err)
+			}
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package billing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+
+	"github.com/shopspring/decimal"
+)
+
+// WithholdingUseCase administra la configuración de retenciones de la empresa: responsabilidades
+// fiscales del emisor y tarifas por tributo (ReteIVA, ReteFuente, ReteICA) y concepto.
+type WithholdingUseCase struct {
+	repo WithholdingRepository
+	uvt  decimal.Decimal
+}
+
+// NewWithholdingUseCase construye el caso de uso. uvt es el valor de la UVT vigente.
+func NewWithholdingUseCase(repo WithholdingRepository, uvt decimal.Decimal) *WithholdingUseCase {
+	return &WithholdingUseCase{repo: repo, uvt: uvt}
+}
+
+// GetConfig devuelve la configuración de retenciones de la empresa.
+func (uc *WithholdingUseCase) GetConfig(ctx context.Context, companyID string) (*dto.WithholdingConfigDTO, error) {
+	if companyID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	codes, err := uc.repo.GetCompanyResponsibilities(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := uc.repo.ListRules(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.toConfigDTO(codes, rules), nil
+}
+
+// UpdateConfig reemplaza las responsabilidades fiscales y las tarifas de retención de la empresa.
+// Cada tarifa debe tener un tributo de retención válido (05, 06, 07), tarifa entre 0 y 100 y base
+// mínima no negativa; no se admiten dos tarifas para el mismo tributo y concepto.
+func (uc *WithholdingUseCase) UpdateConfig(ctx context.Context, companyID string, in dto.UpdateWithholdingConfigRequest) (*dto.WithholdingConfigDTO, error) {
+	if companyID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	codes, err := normalizeFiscalResponsibilities(in.FiscalResponsibilities)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	seen := make(map[string]struct{}, len(in.Rules))
+	rules := make([]*entity.WithholdingRule, 0, len(in.Rules))
+	for _, r := range in.Rules {
+		taxCode := strings.TrimSpace(r.TaxCode)
+		switch taxCode {
+		case dian.TaxCodeReteIVA, dian.TaxCodeReteFuente, dian.TaxCodeReteICA:
+		default:
+			return nil, domain.ErrInvalidInput
+		}
+		if r.Rate.LessThan(decimal.Zero) || r.Rate.GreaterThan(decimal.NewFromInt(100)) || r.MinBaseUVT.LessThan(decimal.Zero) {
+			return nil, domain.ErrInvalidInput
+		}
+		concept := domaindian.NormalizeWithholdingConcept(r.Concept)
+		key := taxCode + "|" + concept
+		if _, dup := seen[key]; dup {
+			return nil, domain.ErrInvalidInput
+		}
+		seen[key] = struct{}{}
+		rules = append(rules, &entity.WithholdingRule{
+			CompanyID:  companyID,
+			TaxCode:    taxCode,
+			Concept:    concept,
+			Rate:       r.Rate,
+			MinBaseUVT: r.MinBaseUVT,
+			IsActive:   r.IsActive,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+	}
+	if err := uc.repo.ReplaceConfig(ctx, companyID, codes, rules); err != nil {
+		return nil, err
+	}
+	return uc.toConfigDTO(codes, rules), nil
+}
+
+func (uc *WithholdingUseCase) toConfigDTO(codes []string, rules []*entity.WithholdingRule) *dto.WithholdingConfigDTO {
+	if codes == nil {
+		codes = []string{}
+	}
+	out := &dto.WithholdingConfigDTO{
+		FiscalResponsibilities: codes,
+		Rules:                  make([]dto.WithholdingRuleDTO, 0, len(rules)),
+		UVTValue:               uc.uvt,
+	}
+	for _, r := range rules {
+		out.Rules = append(out.Rules, dto.WithholdingRuleDTO{
+			TaxCode:    r.TaxCode,
+			Concept:    r.Concept,
+			Rate:       r.Rate,
+			MinBaseUVT: r.MinBaseUVT,
+			IsActive:   r.IsActive,
+		})
+	}
+	return out
+}
+
+// normalizeFiscalResponsibilities valida los códigos contra la Tabla 17 DIAN, los homologa a
+// mayúsculas y elimina duplicados conservando el orden.
+func normalizeFiscalResponsibilities(codes []string) ([]string, error) {
+	out := make([]string, 0, len(codes))
+	seen := make(map[string]struct{}, len(codes))
+	for _, c := range codes {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "" {
+			continue
+		}
+		if !dian.ValidFiscalResponsibilityCodes[c] {
+			return nil, domain.ErrInvalidInput
+		}
+		if _, dup := seen[c]; dup {
+			continue
+		}
+		seen[c] = struct{}{}
+		out = append(out, c)
+	}
+	return out, nil
+}
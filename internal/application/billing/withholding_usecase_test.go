@@ -0,0 +1,131 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+type fakeWithholdingRepo struct {
+	responsibilities []string
+	rules            []*entity.WithholdingRule
+}
+
+func (f *fakeWithholdingRepo) GetCompanyResponsibilities(context.Context, string) ([]string, error) {
+	return f.responsibilities, nil
+}
+func (f *fakeWithholdingRepo) ListRules(context.Context, string) ([]*entity.WithholdingRule, error) {
+	return f.rules, nil
+}
+func (f *fakeWithholdingRepo) ReplaceConfig(_ context.Context, _ string, codes []string, rules []*entity.WithholdingRule) error {
+	f.responsibilities = codes
+	f.rules = rules
+	return nil
+}
+
+var _ WithholdingRepository = (*fakeWithholdingRepo)(nil)
+
+func TestCreateInvoiceUseCase_Withholdings(t *testing.T) {
+	customer := validCustomer(testCompanyID)
+	customer.FiscalResponsibilities = []string{pkgdian.TaxLevelGranContribuyente}
+	customerRepo := &fakeCustomerRepo{getByIDFunc: func(string) (*entity.Customer, error) { return customer, nil }}
+	companyRepo := &fakeCompanyRepo{
+		getByIDFunc:         func(id string) (*entity.Company, error) { return validCompany(id), nil },
+		hasActiveModuleFunc: func(context.Context, string, string) (bool, error) { return false, nil },
+	}
+	productRepo := &fakeProductRepo{getByIDFunc: func(id string) (*entity.Product, error) {
+		switch id {
+		case testProductID1: // compras: 2 × 10000
+			return validProduct(testCompanyID, id, decimal.NewFromInt(10000), decimal.NewFromInt(19)), nil
+		default: // servicios: 1 × 50000, por debajo de la base mínima
+			p := validProduct(testCompanyID, id, decimal.NewFromInt(50000), decimal.NewFromInt(19))
+			p.WithholdingConcept = "SERVICIOS"
+			return p, nil
+		}
+	}}
+	invoiceRepo := &fakeInvoiceRepo{}
+	txRunner := &fakeBillingTxRunner{
+		runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository,
+			repository.StockRepository,
+			repository.ProductRepository,
+			repository.CustomerRepository,
+			repository.InvoiceRepository,
+		) error) error {
+			return fn(nil, nil, productRepo, customerRepo, invoiceRepo)
+		},
+	}
+	withholdingRepo := &fakeWithholdingRepo{rules: []*entity.WithholdingRule{
+		{TaxCode: pkgdian.TaxCodeReteFuente, Concept: "COMPRAS", Rate: decimal.NewFromFloat(2.5), IsActive: true},
+		{TaxCode: pkgdian.TaxCodeReteFuente, Concept: "SERVICIOS", Rate: decimal.NewFromInt(4), MinBaseUVT: decimal.NewFromInt(4), IsActive: true},
+		{TaxCode: pkgdian.TaxCodeReteIVA, Concept: "COMPRAS", Rate: decimal.NewFromInt(15), IsActive: true},
+	}}
+	uc := NewCreateInvoiceUseCase(txRunner, &fakeInventoryUC{}, customerRepo, companyRepo, productRepo, &fakeWarehouseRepo{}, invoiceRepo, nil, DIANConfig{})
+	uc.SetWithholdings(withholdingRepo, decimal.NewFromInt(50000))
+
+	out, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, validCreateInvoiceRequest())
+	require.NoError(t, err)
+
+	// COMPRAS: ReteIVA 15% de 3800 = 570; ReteFuente 2.5% de 20000 = 500.
+	// SERVICIOS (50000) no alcanza 4 UVT (200000).
+	assert.True(t, out.GrandTotal.Equal(decimal.NewFromInt(83300)), "GrandTotal: %s", out.GrandTotal)
+	assert.True(t, out.WithholdingTotal.Equal(decimal.NewFromInt(1070)), "WithholdingTotal: %s", out.WithholdingTotal)
+	assert.True(t, out.PayableAmount.Equal(decimal.NewFromInt(82230)), "PayableAmount: %s", out.PayableAmount)
+	require.Len(t, out.Withholdings, 2)
+	assert.Equal(t, pkgdian.TaxCodeReteIVA, out.Withholdings[0].TaxCode)
+	assert.Equal(t, pkgdian.TaxCodeReteFuente, out.Withholdings[1].TaxCode)
+
+	stored, err := invoiceRepo.GetWithholdingsByInvoiceID(out.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+
+	t.Run("CustomerNotAgent", func(t *testing.T) {
+		customer.FiscalResponsibilities = nil
+		out, err := uc.CreateInvoice(context.Background(), testCompanyID, testUserID, validCreateInvoiceRequest())
+		require.NoError(t, err)
+		assert.True(t, out.WithholdingTotal.IsZero())
+		assert.True(t, out.PayableAmount.Equal(out.GrandTotal))
+		assert.Empty(t, out.Withholdings)
+	})
+}
+
+func TestWithholdingUseCase_UpdateConfig(t *testing.T) {
+	repo := &fakeWithholdingRepo{}
+	uc := NewWithholdingUseCase(repo, decimal.NewFromInt(50000))
+
+	out, err := uc.UpdateConfig(context.Background(), testCompanyID, dto.UpdateWithholdingConfigRequest{
+		FiscalResponsibilities: []string{" o-15", "O-15", pkgdian.TaxLevelResponsableIVA},
+		Rules: []dto.WithholdingRuleDTO{
+			{TaxCode: pkgdian.TaxCodeReteFuente, Concept: "", Rate: decimal.NewFromFloat(2.5), MinBaseUVT: decimal.NewFromInt(27), IsActive: true},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{pkgdian.TaxLevelAutorretenedor, pkgdian.TaxLevelResponsableIVA}, out.FiscalResponsibilities)
+	require.Len(t, out.Rules, 1)
+	assert.Equal(t, entity.WithholdingConceptCompras, out.Rules[0].Concept)
+	assert.True(t, out.UVTValue.Equal(decimal.NewFromInt(50000)))
+
+	invalid := []dto.UpdateWithholdingConfigRequest{
+		{FiscalResponsibilities: []string{"O-99"}},
+		{Rules: []dto.WithholdingRuleDTO{{TaxCode: pkgdian.TaxCodeIVA, Rate: decimal.NewFromInt(1)}}},
+		{Rules: []dto.WithholdingRuleDTO{{TaxCode: pkgdian.TaxCodeReteICA, Rate: decimal.NewFromInt(101)}}},
+		{Rules: []dto.WithholdingRuleDTO{
+			{TaxCode: pkgdian.TaxCodeReteICA, Concept: "compras", Rate: decimal.NewFromInt(1)},
+			{TaxCode: pkgdian.TaxCodeReteICA, Concept: "COMPRAS", Rate: decimal.NewFromInt(1)},
+		}},
+	}
+	for _, in := range invalid {
+		_, err := uc.UpdateConfig(context.Background(), testCompanyID, in)
+		assert.True(t, errors.Is(err, domain.ErrInvalidInput), "in: %+v", in)
+	}
+}
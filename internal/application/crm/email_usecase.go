@@ -3,6 +3,7 @@ package crm
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -1282,13 +1283,17 @@ func extractBodiesAndAttachments(message *stdmail.Message) (string, string, []en
 				if partMediaType == "" {
 					partMediaType = "application/octet-stream"
 				}
-				attachments = append(attachments, entity.EmailAttachment{
+				attachment := entity.EmailAttachment{
 					ID:       uuid.New().String(),
 					FileName: fileName,
 					FileURL:  "",
 					MIMEType: partMediaType,
 					Size:     len(partBytes),
-				})
+				}
+				if isElectronicInvoiceCandidate(fileName, partMediaType, len(partBytes)) {
+					attachment.Content = decodeTransferEncoding(part.Header.Get("Content-Transfer-Encoding"), partBytes)
+				}
+				attachments = append(attachments, attachment)
 				continue
 			}
 			if strings.HasPrefix(strings.ToLower(partMediaType), "text/html") {
@@ -1307,6 +1312,39 @@ func extractBodiesAndAttachments(message *stdmail.Message) (string, string, []en
 	return string(bodyBytes), "", nil
 }
 
+// maxInvoiceAttachmentSize tope de los adjuntos que se guardan para la ingesta de facturas de proveedores.
+const maxInvoiceAttachmentSize = 10 << 20
+
+// isElectronicInvoiceCandidate indica si el adjunto puede ser un AttachedDocument DIAN (ZIP o XML); solo
+// de esos se guarda el contenido.
+func isElectronicInvoiceCandidate(fileName, mediaType string, size int) bool {
+	if size == 0 || size > maxInvoiceAttachmentSize {
+		return false
+	}
+	name := strings.ToLower(strings.TrimSpace(fileName))
+	if strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".xml") {
+		return true
+	}
+	switch strings.ToLower(mediaType) {
+	case "application/zip", "application/x-zip-compressed", "application/xml", "text/xml":
+		return true
+	}
+	return false
+}
+
+// decodeTransferEncoding decodifica el cuerpo de una parte en base64 (multipart.Reader solo decodifica
+// quoted-printable); si no es base64 o no es válido lo devuelve tal cual.
+func decodeTransferEncoding(encoding string, data []byte) []byte {
+	if !strings.EqualFold(strings.TrimSpace(encoding), "base64") {
+		return data
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	if err != nil {
+		return data
+	}
+	return decoded
+}
+
 func toEmailAccountResponse(item *entity.EmailAccount) *dto.EmailAccountResponse {
 	return &dto.EmailAccountResponse{
 		ID:           item.ID,
@@ -34,6 +34,18 @@ func (f *loyaltyProfileRepoFake) ListByCompany(companyID string, limit, offset i
 	return nil, nil
 }
 
+func (f *loyaltyProfileRepoFake) GetDashboardKPIs(companyID string) (*repository.CRMDashboardKPIs, error) {
+	return nil, nil
+}
+
+func (f *loyaltyProfileRepoFake) GetDashboardSegmentation(companyID string) ([]*repository.CRMSegmentDistribution, error) {
+	return nil, nil
+}
+
+func (f *loyaltyProfileRepoFake) GetDashboardMonthlyEvolution(companyID string, months int) ([]*repository.CRMMonthlySales, error) {
+	return nil, nil
+}
+
 type loyaltyCustomerRepoFake struct {
 	customer *entity.Customer
 }
@@ -51,6 +63,10 @@ func (f *loyaltyCustomerRepoFake) GetByCompanyAndTaxID(companyID, taxID string)
 	return nil, nil
 }
 
+func (f *loyaltyCustomerRepoFake) GetByCompanyAndEmail(companyID, email string) (*entity.Customer, error) {
+	return nil, nil
+}
+
 func (f *loyaltyCustomerRepoFake) ListByCompany(companyID string, search string, limit, offset int) ([]*entity.Customer, error) {
 	return nil, nil
 }
@@ -93,6 +109,8 @@ func (f *loyaltyBenefitRepoFake) Update(benefit *entity.CRMBenefit) error { retu
 
 func (f *loyaltyBenefitRepoFake) Delete(id string) error { return nil }
 
+func (f *loyaltyBenefitRepoFake) SetActive(companyID, id string, isActive bool) error { return nil }
+
 type loyaltyInteractionRepoFake struct {
 	events      []*entity.CRMInteraction
 	createCalls int
@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/shopspring/decimal"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // ── Query parameters ──────────────────────────────────────────────────────────
 
@@ -75,3 +79,51 @@ type MarginsReportDTO struct {
 	SKURanking      []SKURankingDTO         `json:"sku_ranking"`       // top N por margen
 	ParetoSKUs      []SKURankingDTO         `json:"pareto_skus"`       // SKUs del top 20% que generan ~80% ingresos
 }
+
+// ── Antigüedad de inventario ──────────────────────────────────────────────────
+
+// InventoryAgingRequest parámetros para GET /api/analytics/inventory-aging.
+type InventoryAgingRequest struct {
+	WarehouseID   string `query:"warehouse_id"`    // vacío = todas las bodegas
+	DeadStockDays int    `query:"dead_stock_days"` // días sin venta para marcar stock muerto (default 90)
+	Format        string `query:"format"`          // json (defecto) | csv
+}
+
+// AgingBucketDTO total de stock valorizado en un rango de antigüedad.
+type AgingBucketDTO struct {
+	Label        string          `json:"label"`    // 0-30, 31-90, 91-180, 180+
+	MinDays      int             `json:"min_days"`
+	MaxDays      *int            `json:"max_days"` // nil = sin tope
+	ProductCount int             `json:"product_count"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	Value        decimal.Decimal `json:"value"` // cantidad × costo promedio
+	ValuePct     decimal.Decimal `json:"value_pct"`
+}
+
+// InventoryAgingItemDTO antigüedad y valorización de un producto con stock.
+type InventoryAgingItemDTO struct {
+	ProductID       string          `json:"product_id"`
+	SKU             string          `json:"sku"`
+	ProductName     string          `json:"product_name"`
+	Quantity        decimal.Decimal `json:"quantity"`
+	UnitCost        decimal.Decimal `json:"unit_cost"`
+	Value           decimal.Decimal `json:"value"`
+	LastSaleAt      *time.Time      `json:"last_sale_at"`
+	LastReceiptAt   *time.Time      `json:"last_receipt_at"`
+	DaysSinceMove   *int            `json:"days_since_movement"` // desde la última venta o recepción; nil si no hay ninguna
+	DaysWithoutSale *int            `json:"days_without_sale"`   // nil si nunca se ha vendido
+	Bucket          string          `json:"bucket"`
+	IsDeadStock     bool            `json:"is_dead_stock"` // stock sin ventas en DeadStockDays días
+}
+
+// InventoryAgingReportDTO respuesta de GET /api/analytics/inventory-aging.
+type InventoryAgingReportDTO struct {
+	AsOf           string                  `json:"as_of"`
+	WarehouseID    string                  `json:"warehouse_id,omitempty"`
+	DeadStockDays  int                     `json:"dead_stock_days"`
+	TotalValue     decimal.Decimal         `json:"total_value"`
+	DeadStockValue decimal.Decimal         `json:"dead_stock_value"`
+	DeadStockCount int                     `json:"dead_stock_count"`
+	Buckets        []AgingBucketDTO        `json:"buckets"`
+	Items          []InventoryAgingItemDTO `json:"items"`
+}
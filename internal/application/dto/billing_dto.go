@@ -3,29 +3,47 @@ package dto
 import "github.com/shopspring/decimal"
 
 // CreateCustomerRequest body para POST /api/customers.
+// FiscalResponsibilities: códigos DIAN Tabla 17 (O-13, O-23…); deciden si el cliente practica retenciones.
+// IdentificationType (Tabla 3: 13, 31, 22, 41, 42, 50) y CountryCode (ISO 3166 alfa-2, por defecto CO)
+// identifican a los clientes del exterior en facturas de exportación.
+// PaymentTermDays: plazo de pago en días para calcular el vencimiento de sus facturas a crédito.
 type CreateCustomerRequest struct {
-	Name  string `json:"name"`
-	TaxID string `json:"tax_id"`
-	Email string `json:"email,omitempty"`
-	Phone string `json:"phone,omitempty"`
+	Name                   string   `json:"name"`
+	TaxID                  string   `json:"tax_id"`
+	Email                  string   `json:"email,omitempty"`
+	Phone                  string   `json:"phone,omitempty"`
+	FiscalResponsibilities []string `json:"fiscal_responsibilities,omitempty"`
+	IdentificationType     string   `json:"identification_type,omitempty"`
+	CountryCode            string   `json:"country_code,omitempty"`
+	PaymentTermDays        int      `json:"payment_term_days,omitempty"`
 }
 
 // CustomerResponse cliente en respuestas.
 type CustomerResponse struct {
-	ID        string `json:"id"`
-	CompanyID string `json:"company_id"`
-	Name      string `json:"name"`
-	TaxID     string `json:"tax_id"`
-	Email     string `json:"email,omitempty"`
-	Phone     string `json:"phone,omitempty"`
+	ID                     string   `json:"id"`
+	CompanyID              string   `json:"company_id"`
+	Name                   string   `json:"name"`
+	TaxID                  string   `json:"tax_id"`
+	Email                  string   `json:"email,omitempty"`
+	Phone                  string   `json:"phone,omitempty"`
+	FiscalResponsibilities []string `json:"fiscal_responsibilities"`
+	IdentificationType     string   `json:"identification_type,omitempty"`
+	CountryCode            string   `json:"country_code"`
+	PaymentTermDays        int      `json:"payment_term_days"`
 }
 
 // UpdateCustomerRequest body para actualizar un cliente.
+// FiscalResponsibilities nil conserva las responsabilidades actuales; IdentificationType y
+// CountryCode vacíos conservan los actuales; PaymentTermDays nil conserva el plazo actual.
 type UpdateCustomerRequest struct {
-	Name  string `json:"name"`
-	TaxID string `json:"tax_id"`
-	Email string `json:"email"`
-	Phone string `json:"phone"`
+	Name                   string   `json:"name"`
+	TaxID                  string   `json:"tax_id"`
+	Email                  string   `json:"email"`
+	Phone                  string   `json:"phone"`
+	FiscalResponsibilities []string `json:"fiscal_responsibilities,omitempty"`
+	IdentificationType     string   `json:"identification_type,omitempty"`
+	CountryCode            string   `json:"country_code,omitempty"`
+	PaymentTermDays        *int     `json:"payment_term_days,omitempty"`
 }
 
 // CreateInvoiceRequest body para POST /api/invoices.
@@ -34,15 +52,55 @@ type CreateInvoiceRequest struct {
 	CustomerID  string               `json:"customer_id"`
 	WarehouseID string               `json:"warehouse_id"`
 	Prefix      string               `json:"prefix"`
-	Number      string               `json:"number,omitempty"` // opcional; si va vacío se puede generar
+	Number      string               `json:"number,omitempty"` // opcional; vacío = consecutivo de la resolución
 	Items       []InvoiceItemRequest `json:"items"`
+	// AllowanceCharges descuentos y cargos globales (ej: descuento comercial, flete) sobre el subtotal neto.
+	AllowanceCharges []AllowanceChargeRequest `json:"allowance_charges,omitempty"`
+
+	// Moneda extranjera y exportación. CurrencyCode vacío = COP; en otra moneda ExchangeRate (TRM,
+	// COP por unidad) es obligatoria. InvoiceTypeCode "02" (exportación) exige Incoterm y cliente del exterior.
+	CurrencyCode    string          `json:"currency_code,omitempty"`
+	ExchangeRate    decimal.Decimal `json:"exchange_rate,omitempty"`
+	InvoiceTypeCode string          `json:"invoice_type_code,omitempty"`
+	Incoterm        string          `json:"incoterm,omitempty"`
+
+	// Forma y medios de pago. PaymentFormCode "1" contado (por defecto) o "2" crédito; PaymentMethodCodes
+	// vacío = efectivo (10). A crédito el vencimiento (YYYY-MM-DD) es due_date, la última cuota o, sin
+	// ninguno, la fecha de emisión más el plazo de pago del cliente. Las cuotas suman el neto a pagar.
+	PaymentFormCode    string               `json:"payment_form_code,omitempty"`
+	PaymentMethodCodes []string             `json:"payment_method_codes,omitempty"`
+	DueDate            string               `json:"due_date,omitempty"`
+	Installments       []InstallmentRequest `json:"installments,omitempty"`
+
+	// ContingencyID lo asigna CreateInvoice cuando la empresa factura en contingencia (tipo 03).
+	ContingencyID string `json:"-"`
+}
+
+// InstallmentRequest cuota del plan de pagos de una factura a crédito.
+type InstallmentRequest struct {
+	DueDate string          `json:"due_date"` // YYYY-MM-DD
+	Amount  decimal.Decimal `json:"amount"`
 }
 
 // InvoiceItemRequest línea de factura (producto, cantidad, precio unitario).
+// El descuento de línea se indica como porcentaje (discount_rate) o como valor (discount_amount), no ambos.
 type InvoiceItemRequest struct {
-	ProductID string          `json:"product_id"`
-	Quantity  decimal.Decimal `json:"quantity"`
-	UnitPrice decimal.Decimal `json:"unit_price"`
+	ProductID      string          `json:"product_id"`
+	Quantity       decimal.Decimal `json:"quantity"`
+	UnitPrice      decimal.Decimal `json:"unit_price"`
+	DiscountRate   decimal.Decimal `json:"discount_rate,omitempty"`
+	DiscountAmount decimal.Decimal `json:"discount_amount,omitempty"`
+}
+
+// AllowanceChargeRequest descuento (charge_indicator=false) o cargo (true) global de la factura.
+// reason_code es el código de descuento DIAN (Tabla 13.3.8; por defecto 09 descuento general);
+// el valor se indica como porcentaje (rate) o como valor fijo (amount).
+type AllowanceChargeRequest struct {
+	ChargeIndicator bool            `json:"charge_indicator"`
+	ReasonCode      string          `json:"reason_code,omitempty"`
+	Reason          string          `json:"reason,omitempty"`
+	Rate            decimal.Decimal `json:"rate,omitempty"`
+	Amount          decimal.Decimal `json:"amount,omitempty"`
 }
 
 // ReturnItemRequest línea de devolución (producto y cantidad devuelta).
@@ -53,10 +111,13 @@ type ReturnItemRequest struct {
 
 // ReturnInvoiceRequest body para POST /api/invoices/{id}/return.
 // WarehouseID: bodega a la que se reingresa el stock devuelto.
+// Prefix (opcional): prefijo de una resolución activa de notas crédito; sin él, el consecutivo se toma
+// de la resolución de notas crédito activa de la empresa o, si no hay, de su secuencia de notas (NC).
 type ReturnInvoiceRequest struct {
 	WarehouseID string              `json:"warehouse_id"`
 	Items       []ReturnItemRequest `json:"items"`
 	Reason      string              `json:"reason,omitempty"`
+	Prefix      string              `json:"prefix,omitempty"`
 }
 
 // DebitNoteItemRequest línea de nota débito (producto, cantidad y precio unitario).
@@ -67,9 +128,12 @@ type DebitNoteItemRequest struct {
 }
 
 // CreateDebitNoteRequest body para POST /api/invoices/{id}/debit-note.
+// Prefix (opcional): prefijo de una resolución activa de notas débito; sin él, se usa la resolución de
+// notas débito activa de la empresa o, si no hay, su secuencia de notas (ND).
 type CreateDebitNoteRequest struct {
 	Reason string                 `json:"reason,omitempty"`
 	Items  []DebitNoteItemRequest `json:"items"`
+	Prefix string                 `json:"prefix,omitempty"`
 }
 
 // DebitNoteResponse respuesta resumida de creación de nota débito.
@@ -81,9 +145,12 @@ type DebitNoteResponse struct {
 
 // CreateVoidInvoiceRequest body para POST /api/invoices/{id}/void.
 // concept_code: 1=Devolución, 2=Anulación, 3=Descuento, 4=Ajuste precio, 5=Otros.
+// prefix (opcional): prefijo de una resolución activa de notas crédito; sin él, se usa la resolución de
+// notas crédito activa de la empresa o, si no hay, su secuencia de notas (NC).
 type CreateVoidInvoiceRequest struct {
 	ConceptCode int    `json:"concept_code"`
 	Reason      string `json:"reason"`
+	Prefix      string `json:"prefix,omitempty"`
 }
 
 // SendCustomEmailRequest body para POST /api/emails/send.
@@ -102,30 +169,77 @@ type VoidInvoiceResponse struct {
 
 // InvoiceResponse factura con detalle para GET /api/invoices/:id.
 type InvoiceResponse struct {
-	ID           string                  `json:"id"`
-	CompanyID    string                  `json:"company_id"`
-	CustomerID   string                  `json:"customer_id"`
-	CustomerName string                  `json:"customer_name,omitempty"`
-	Prefix       string                  `json:"prefix"`
-	Number       string                  `json:"number"`
-	Date         string                  `json:"date"`
-	NetTotal     decimal.Decimal         `json:"net_total"`
-	TaxTotal     decimal.Decimal         `json:"tax_total"`
-	GrandTotal   decimal.Decimal         `json:"grand_total"`
-	DIAN_Status  string                  `json:"dian_status"`
-	CUFE         string                  `json:"cufe,omitempty"`
-	QRData       string                  `json:"qr_data,omitempty"` // String para generar QR (NumFac|FecFac|...|Cufe|UrlValidacionDIAN)
-	Details      []InvoiceDetailResponse `json:"details"`
+	ID               string                  `json:"id"`
+	CompanyID        string                  `json:"company_id"`
+	CustomerID       string                  `json:"customer_id"`
+	CustomerName     string                  `json:"customer_name,omitempty"`
+	Prefix           string                  `json:"prefix"`
+	Number           string                  `json:"number"`
+	Date             string                  `json:"date"`
+	NetTotal         decimal.Decimal         `json:"net_total"`
+	TaxTotal         decimal.Decimal         `json:"tax_total"`
+	GrandTotal       decimal.Decimal         `json:"grand_total"`
+	DiscountTotal    decimal.Decimal         `json:"discount_total"`  // descuentos de línea + globales
+	AllowanceTotal   decimal.Decimal         `json:"allowance_total"` // descuentos globales
+	ChargeTotal      decimal.Decimal         `json:"charge_total"`    // cargos globales
+	CurrencyCode     string                  `json:"currency_code"`
+	ExchangeRate     decimal.Decimal         `json:"exchange_rate"`
+	InvoiceTypeCode  string                  `json:"invoice_type_code,omitempty"`
+	Incoterm         string                  `json:"incoterm,omitempty"`
+	ContingencyID    string                  `json:"contingency_id,omitempty"` // periodo de contingencia (facturas tipo 03)
+	NetTotalCOP      decimal.Decimal         `json:"net_total_cop"`
+	TaxTotalCOP      decimal.Decimal         `json:"tax_total_cop"`
+	GrandTotalCOP    decimal.Decimal         `json:"grand_total_cop"`
+	WithholdingTotal decimal.Decimal         `json:"withholding_total"`
+	PayableAmount    decimal.Decimal         `json:"payable_amount"` // GrandTotal - WithholdingTotal (neto a recibir)
+	PaymentFormCode  string                  `json:"payment_form_code"`
+	PaymentMethods   []string                `json:"payment_method_codes"`
+	DueDate          string                  `json:"due_date,omitempty"` // YYYY-MM-DD; solo crédito
+	Installments     []InstallmentDTO        `json:"installments,omitempty"`
+	DIAN_Status      string                  `json:"dian_status"`
+	CUFE             string                  `json:"cufe,omitempty"`
+	QRData           string                  `json:"qr_data,omitempty"` // String para generar QR (NumFac|FecFac|...|Cufe|UrlValidacionDIAN)
+	Details          []InvoiceDetailResponse `json:"details"`
+	Withholdings     []InvoiceWithholdingDTO `json:"withholdings,omitempty"`
+	AllowanceCharges []AllowanceChargeDTO    `json:"allowance_charges,omitempty"`
+}
+
+// InstallmentDTO cuota del plan de pagos de una factura a crédito.
+type InstallmentDTO struct {
+	Number  int             `json:"number"`
+	DueDate string          `json:"due_date"`
+	Amount  decimal.Decimal `json:"amount"`
+}
+
+// AllowanceChargeDTO descuento o cargo aplicado al documento; invoice_detail_id vacío = global.
+type AllowanceChargeDTO struct {
+	InvoiceDetailID string          `json:"invoice_detail_id,omitempty"`
+	ChargeIndicator bool            `json:"charge_indicator"`
+	ReasonCode      string          `json:"reason_code,omitempty"`
+	Reason          string          `json:"reason,omitempty"`
+	Rate            decimal.Decimal `json:"rate"`
+	BaseAmount      decimal.Decimal `json:"base_amount"`
+	Amount          decimal.Decimal `json:"amount"`
+}
+
+// InvoiceWithholdingDTO retención practicada por el cliente (05 ReteIVA, 06 ReteFuente, 07 ReteICA).
+type InvoiceWithholdingDTO struct {
+	TaxCode    string          `json:"tax_code"`
+	Concept    string          `json:"concept"`
+	BaseAmount decimal.Decimal `json:"base_amount"`
+	Rate       decimal.Decimal `json:"rate"`
+	Amount     decimal.Decimal `json:"amount"`
 }
 
 // InvoiceDetailResponse línea de detalle en la respuesta.
 type InvoiceDetailResponse struct {
-	ID        string          `json:"id"`
-	ProductID string          `json:"product_id"`
-	Quantity  decimal.Decimal `json:"quantity"`
-	UnitPrice decimal.Decimal `json:"unit_price"`
-	TaxRate   decimal.Decimal `json:"tax_rate"`
-	Subtotal  decimal.Decimal `json:"subtotal"`
+	ID             string          `json:"id"`
+	ProductID      string          `json:"product_id"`
+	Quantity       decimal.Decimal `json:"quantity"`
+	UnitPrice      decimal.Decimal `json:"unit_price"`
+	TaxRate        decimal.Decimal `json:"tax_rate"`
+	DiscountAmount decimal.Decimal `json:"discount_amount"`
+	Subtotal       decimal.Decimal `json:"subtotal"` // neto de descuento
 }
 
 // InvoiceFilter parámetros de filtrado y paginación para GET /api/invoices.
@@ -180,15 +294,69 @@ type PurchaseHistoryResponse struct {
 // "EXITOSO" o "RECHAZADO".
 type InvoiceDIANStatusDTO struct {
 	ID         string `json:"id"`
-	DIANStatus string `json:"dian_status"` // DRAFT|SIGNED|EXITOSO|RECHAZADO|ERROR_GENERATION
+	DIANStatus string `json:"dian_status"` // DRAFT|SIGNED|Sent|EXITOSO|RECHAZADO|ERROR_GENERATION
 	CUFE       string `json:"cufe"`        // Código único de factura (SHA-384)
 	TrackID    string `json:"track_id"`    // ZipKey devuelto por el WS DIAN
 	Errors     string `json:"errors"`      // Mensajes de rechazo de la DIAN (vacío si OK)
+	// Rules reglas de validación de GetStatusZip: rechazos (ERROR) y notificaciones (NOTIFICATION).
+	Rules []DIANRuleDTO `json:"rules"`
+}
+
+// DIANRuleDTO regla de validación reportada por la DIAN (ej. FAD06, FAJ44b).
+type DIANRuleDTO struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"` // ERROR | NOTIFICATION
 }
 
 // DIANSummaryDTO resumen de estados DIAN para dashboard de facturación.
+// Resolutions: estado de numeración y vigencia de las resoluciones activas (widget de alertas).
 type DIANSummaryDTO struct {
-	SentToday int `json:"sent_today"`
-	Pending   int `json:"pending"`
-	Rejected  int `json:"rejected"`
+	SentToday   int                   `json:"sent_today"`
+	Pending     int                   `json:"pending"`
+	Rejected    int                   `json:"rejected"`
+	Resolutions []ResolutionStatusDTO `json:"resolutions"`
+}
+
+// ResolutionStatusDTO números restantes, días a vencimiento y proyección de agotamiento de una resolución.
+// ProjectedExhaustion es nil si no hubo facturación en la ventana de velocidad.
+type ResolutionStatusDTO struct {
+	ResolutionID        string   `json:"resolution_id"`
+	ResolutionNumber    string   `json:"resolution_number"`
+	Prefix              string   `json:"prefix"`
+	RangeFrom           int64    `json:"range_from"`
+	RangeTo             int64    `json:"range_to"`
+	NextNumber          int64    `json:"next_number"`
+	Remaining           int64    `json:"remaining"`
+	RemainingPercent    float64  `json:"remaining_percent"`
+	ValidUntil          string   `json:"valid_until"`
+	DaysToExpiry        int      `json:"days_to_expiry"`
+	DailyVelocity       float64  `json:"daily_velocity"`
+	ProjectedExhaustion *string  `json:"projected_exhaustion,omitempty"`
+	Alerts              []string `json:"alerts"`
+}
+
+// WithholdingRuleDTO tarifa de retención por tributo (05, 06, 07) y concepto (COMPRAS, SERVICIOS…).
+// Rate en porcentaje; MinBaseUVT base mínima en UVT (0 = siempre retiene).
+type WithholdingRuleDTO struct {
+	TaxCode    string          `json:"tax_code"`
+	Concept    string          `json:"concept"`
+	Rate       decimal.Decimal `json:"rate"`
+	MinBaseUVT decimal.Decimal `json:"min_base_uvt"`
+	IsActive   bool            `json:"is_active"`
+}
+
+// WithholdingConfigDTO configuración de retenciones de la empresa (GET/PUT /api/billing/withholdings).
+// FiscalResponsibilities: responsabilidades fiscales del emisor (O-13, O-15, O-47…).
+// UVTValue: valor de la UVT vigente con el que se evalúan las bases mínimas (solo lectura).
+type WithholdingConfigDTO struct {
+	FiscalResponsibilities []string             `json:"fiscal_responsibilities"`
+	Rules                  []WithholdingRuleDTO `json:"rules"`
+	UVTValue               decimal.Decimal      `json:"uvt_value"`
+}
+
+// UpdateWithholdingConfigRequest body de PUT /api/billing/withholdings (reemplaza la configuración).
+type UpdateWithholdingConfigRequest struct {
+	FiscalResponsibilities []string             `json:"fiscal_responsibilities"`
+	Rules                  []WithholdingRuleDTO `json:"rules"`
 }
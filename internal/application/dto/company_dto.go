@@ -51,6 +51,7 @@ type CreateResolutionRequest struct {
 	ValidUntil       string `json:"valid_to"`                  // formato YYYY-MM-DD (respetar nombre del frontend)
 	AlertThreshold   int    `json:"alert_threshold,omitempty"` // porcentaje; por ahora solo compatibilidad, cálculo interno sigue siendo 10%
 	Environment      string `json:"environment,omitempty"`     // test|prod; opcional en este payload
+	DocumentType     string `json:"document_type,omitempty"`   // INVOICE (por defecto) | CREDIT_NOTE | DEBIT_NOTE
 }
 
 // ResolutionResponse salida de resolución con alerta de umbral.
@@ -64,6 +65,7 @@ type ResolutionResponse struct {
 	ValidFrom        time.Time `json:"valid_from"`
 	ValidUntil       time.Time `json:"valid_until"`
 	Environment      string    `json:"environment"`
+	DocumentType     string    `json:"document_type"`
 	AlertThreshold   bool      `json:"alert_threshold"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
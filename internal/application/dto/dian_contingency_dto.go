@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StartDIANContingencyRequest activa la facturación en contingencia (POST /api/billing/dian/contingency).
+// Prefix es el de la resolución de numeración de contingencia autorizada por la DIAN.
+type StartDIANContingencyRequest struct {
+	Prefix string `json:"prefix"`
+	Reason string `json:"reason"`
+}
+
+// DIANContingencyDTO periodo de facturación en contingencia (tipo 03).
+type DIANContingencyDTO struct {
+	ID                   string     `json:"id"`
+	Prefix               string     `json:"prefix"`
+	Reason               string     `json:"reason"`
+	Active               bool       `json:"active"`
+	StartedAt            time.Time  `json:"started_at"`
+	EndedAt              *time.Time `json:"ended_at,omitempty"`
+	TransmissionDeadline *time.Time `json:"transmission_deadline,omitempty"` // 48 h después del cierre
+	TransmittedAt        *time.Time `json:"transmitted_at,omitempty"`
+}
+
+// DIANContingencyReconciliationDTO conciliación de las facturas emitidas en la contingencia: pendientes de
+// transmitir (DRAFT o SIGNED), enviadas sin resultado (Sent o CONTINGENCIA), aceptadas, rechazadas y con
+// error de generación o envío. Overdue indica que venció el plazo con facturas sin transmitir.
+type DIANContingencyReconciliationDTO struct {
+	Contingency DIANContingencyDTO           `json:"contingency"`
+	Total       int                          `json:"total"`
+	Pending     int                          `json:"pending"`
+	Sent        int                          `json:"sent"`
+	Accepted    int                          `json:"accepted"`
+	Rejected    int                          `json:"rejected"`
+	Failed      int                          `json:"failed"`
+	Queued      int                          `json:"queued,omitempty"` // encoladas para transmitir en esta solicitud
+	Overdue     bool                         `json:"overdue"`
+	GrandTotal  decimal.Decimal              `json:"grand_total"`
+	Documents   []DIANContingencyDocumentDTO `json:"documents"`
+}
+
+// DIANContingencyDocumentDTO factura de contingencia con su estado DIAN.
+type DIANContingencyDocumentDTO struct {
+	InvoiceID  string          `json:"invoice_id"`
+	Number     string          `json:"number"`
+	Date       string          `json:"date"`
+	GrandTotal decimal.Decimal `json:"grand_total"`
+	DIANStatus string          `json:"dian_status"`
+	CUFE       string          `json:"cufe,omitempty"`
+	TrackID    string          `json:"track_id,omitempty"`
+	Errors     string          `json:"errors,omitempty"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+import "time"
+
+// StartDIANHabilitacionRequest inicia el set de pruebas de habilitación DIAN (POST /api/billing/dian/habilitacion).
+// La resolución de pruebas por defecto es la publicada por la DIAN (SETP 990000000-995000000); las
+// cantidades en cero usan el set estándar (8 facturas, 1 nota crédito y 1 nota débito).
+type StartDIANHabilitacionRequest struct {
+	TestSetID        string `json:"test_set_id"`
+	Prefix           string `json:"prefix,omitempty"`
+	ResolutionNumber string `json:"resolution_number,omitempty"`
+	RangeFrom        int64  `json:"range_from,omitempty"`
+	RangeTo          int64  `json:"range_to,omitempty"`
+	Invoices         int    `json:"invoices,omitempty"`
+	CreditNotes      int    `json:"credit_notes,omitempty"`
+	DebitNotes       int    `json:"debit_notes,omitempty"`
+}
+
+// DIANHabilitacionDTO progreso del proceso de habilitación (GET /api/billing/dian/habilitacion).
+// status: IN_PROGRESS | ACCEPTED | FAILED.
+type DIANHabilitacionDTO struct {
+	ID          string                        `json:"id"`
+	TestSetID   string                        `json:"test_set_id"`
+	Status      string                        `json:"status"`
+	Total       int                           `json:"total"`
+	Pending     int                           `json:"pending"`
+	Sent        int                           `json:"sent"`
+	Accepted    int                           `json:"accepted"`
+	Rejected    int                           `json:"rejected"`
+	LastError   string                        `json:"last_error,omitempty"`
+	Documents   []DIANHabilitacionDocumentDTO `json:"documents"`
+	CreatedAt   time.Time                     `json:"created_at"`
+	UpdatedAt   time.Time                     `json:"updated_at"`
+	CompletedAt *time.Time                    `json:"completed_at,omitempty"`
+}
+
+// DIANHabilitacionDocumentDTO documento del set de pruebas con su resultado en la DIAN.
+// status: PENDING | SENT | ACCEPTED | REJECTED.
+type DIANHabilitacionDocumentDTO struct {
+	Sequence     int    `json:"sequence"`
+	DocumentType string `json:"document_type"`
+	Number       string `json:"number"`
+	CUFE         string `json:"cufe"`
+	TrackID      string `json:"track_id,omitempty"`
+	Status       string `json:"status"`
+	Errors       string `json:"errors,omitempty"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// DIANRetryJobDTO documento en la cola de reintentos DIAN (GET /api/billing/dian/retry-queue).
+// status: QUEUED (pendiente de reintento) | RUNNING (reintento en curso) | DEAD (intentos agotados, requiere revisión).
+type DIANRetryJobDTO struct {
+	InvoiceID     string     `json:"invoice_id"`
+	InvoiceNumber string     `json:"invoice_number"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	MaxAttempts   int        `json:"max_attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     string     `json:"last_error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
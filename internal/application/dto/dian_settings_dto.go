@@ -8,6 +8,10 @@ type UpsertDIANSettingsRequest struct {
 	CertificateFileName string
 	CertificateData     []byte
 	CertificatePassword string
+	// Opcionales: vacíos conservan el valor ya guardado para el ambiente.
+	TechnicalKey string
+	SoftwareID   string
+	SoftwarePIN  string
 }
 
 // DIANSettingsResponse representa la configuración DIAN guardada para la empresa.
@@ -16,5 +20,8 @@ type DIANSettingsResponse struct {
 	Environment         string    `json:"environment"`
 	CertificateFileName string    `json:"certificate_file_name"`
 	CertificateFileSize int64     `json:"certificate_file_size"`
+	SoftwareID          string    `json:"software_id,omitempty"`
+	HasTechnicalKey     bool      `json:"has_technical_key"`
+	HasSoftwarePIN      bool      `json:"has_software_pin"`
 	UpdatedAt           time.Time `json:"updated_at"`
 }
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// DocumentVerificationDTO resultado de verificar la integridad de un documento firmado
+// (GET /api/billing/dian/verification/:invoice_id y POST /api/billing/dian/verification).
+// valid es false si alguna comprobación quedó en FAILED; las SKIPPED no invalidan el documento.
+type DocumentVerificationDTO struct {
+	InvoiceID    string                 `json:"invoice_id,omitempty"`
+	DocumentType string                 `json:"document_type"`
+	Number       string                 `json:"number"`
+	UUID         string                 `json:"uuid"`
+	UUIDScheme   string                 `json:"uuid_scheme,omitempty"`
+	SupplierNIT  string                 `json:"supplier_nit"`
+	Signer       string                 `json:"signer,omitempty"`
+	SigningTime  *time.Time             `json:"signing_time,omitempty"`
+	Valid        bool                   `json:"valid"`
+	Checks       []VerificationCheckDTO `json:"checks"`
+}
+
+// VerificationCheckDTO comprobación individual.
+// name: SIGNATURE | XADES | CERTIFICATE_VALIDITY | CERTIFICATE_CHAIN | UUID. status: OK | FAILED | SKIPPED.
+type VerificationCheckDTO struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
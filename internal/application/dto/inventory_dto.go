@@ -21,6 +21,14 @@ type RegisterMovementRequest struct {
 	// AdjustmentReason es obligatorio cuando Type == "ADJUSTMENT".
 	// Valores válidos: MERMA | ROBO | VENCIMIENTO | CONTEO_FISICO | DETERIORO | OTRO
 	AdjustmentReason string `json:"adjustment_reason,omitempty"`
+	// Date fecha contable opcional; una fecha anterior a hoy solo la pueden registrar administradores.
+	Date *time.Time `json:"date,omitempty"`
+	// AllowBackdated lo fija el handler según el rol; no se acepta desde el cliente.
+	AllowBackdated bool `json:"-"`
+	// ExpiryDate registra la entrada (IN o ajuste positivo) como un lote con vencimiento; las salidas
+	// consumen los lotes FEFO. LotNumber es opcional y requiere expiry_date.
+	ExpiryDate *time.Time `json:"expiry_date,omitempty"`
+	LotNumber  string     `json:"lot_number,omitempty"`
 }
 
 // ReorderConfigRequest body para configurar niveles de reposición por producto y bodega.
@@ -88,6 +96,9 @@ type MovementDTO struct {
 	Date          time.Time       `json:"date"`
 	CreatedAt     time.Time       `json:"created_at"`
 	CreatedBy     string          `json:"created_by,omitempty"`
+	ReversalOfID  string          `json:"reversal_of_id,omitempty"`
+	ReversedAt    *time.Time      `json:"reversed_at,omitempty"`
+	ReversedBy    string          `json:"reversed_by,omitempty"`
 }
 
 // PaginatedMovementsDTO respuesta paginada de movimientos.
@@ -96,6 +107,99 @@ type PaginatedMovementsDTO struct {
 	Total int64         `json:"total"`
 }
 
+// ReverseMovementRequest body para revertir un movimiento o una transacción completa.
+type ReverseMovementRequest struct {
+	Reason string `json:"reason"`
+}
+
+// MovementReversalDTO resultado de una reversión: transacción y asientos compensatorios creados.
+type MovementReversalDTO struct {
+	TransactionID string        `json:"transaction_id"`
+	Movements     []MovementDTO `json:"movements"`
+}
+
+// ReopenInventoryPeriodRequest body para reabrir un período de inventario cerrado.
+type ReopenInventoryPeriodRequest struct {
+	Reason string `json:"reason"`
+}
+
+// InventoryPeriodDTO período mensual de inventario.
+type InventoryPeriodDTO struct {
+	ID        string     `json:"id"`
+	Year      int        `json:"year"`
+	Month     int        `json:"month"`
+	Status    string     `json:"status"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	ClosedBy  string     `json:"closed_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// InventoryPeriodValuationDTO valorización congelada por producto y bodega.
+type InventoryPeriodValuationDTO struct {
+	ProductID   string          `json:"product_id"`
+	WarehouseID string          `json:"warehouse_id"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	UnitCost    decimal.Decimal `json:"unit_cost"`
+	TotalValue  decimal.Decimal `json:"total_value"`
+}
+
+// InventoryPeriodEventDTO evento de auditoría (CLOSE | REOPEN).
+type InventoryPeriodEventDTO struct {
+	Action    string    `json:"action"`
+	Reason    string    `json:"reason,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InventoryPeriodDetailDTO período con valorización y bitácora.
+type InventoryPeriodDetailDTO struct {
+	InventoryPeriodDTO
+	TotalValue decimal.Decimal               `json:"total_value"`
+	Valuations []InventoryPeriodValuationDTO `json:"valuations"`
+	Events     []InventoryPeriodEventDTO     `json:"events"`
+}
+
+// StockAlertDTO alerta de stock bajo por producto y bodega.
+type StockAlertDTO struct {
+	ID            string          `json:"id"`
+	ProductID     string          `json:"product_id"`
+	SKU           string          `json:"sku,omitempty"`
+	ProductName   string          `json:"product_name,omitempty"`
+	WarehouseID   string          `json:"warehouse_id"`
+	WarehouseName string          `json:"warehouse_name,omitempty"`
+	Kind          string          `json:"kind"` // REORDER_POINT | MIN_STOCK | NEAR_EXPIRY | EXPIRED
+	Quantity      decimal.Decimal `json:"quantity"`
+	Threshold     decimal.Decimal `json:"threshold"`
+	Status        string          `json:"status"` // OPEN | RESOLVED
+	CreatedAt     time.Time       `json:"created_at"`
+	ResolvedAt    *time.Time      `json:"resolved_at,omitempty"`
+	LotNumber     string          `json:"lot_number,omitempty"`  // alertas de vencimiento
+	ExpiryDate    *time.Time      `json:"expiry_date,omitempty"` // alertas de vencimiento
+}
+
+// StockAlertSubscriptionDTO preferencia de notificación por bodega (warehouse_id vacío = todas).
+type StockAlertSubscriptionDTO struct {
+	WarehouseID string `json:"warehouse_id,omitempty"`
+	NotifyEmail bool   `json:"notify_email"`
+	NotifyInApp bool   `json:"notify_in_app"`
+}
+
+// UpdateStockAlertSubscriptionsRequest reemplaza las suscripciones del usuario autenticado.
+type UpdateStockAlertSubscriptionsRequest struct {
+	Subscriptions []StockAlertSubscriptionDTO `json:"subscriptions"`
+}
+
+// NotificationDTO notificación in-app del usuario.
+type NotificationDTO struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	ReferenceID string     `json:"reference_id,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
 // Compatibilidad retroactiva con nombres anteriores.
 type InventoryMovementFilter = MovementFiltersDTO
 type InventoryMovementDTO = MovementDTO
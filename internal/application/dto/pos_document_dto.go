@@ -0,0 +1,22 @@
+package dto
+
+// CreatePOSDocumentRequest body para POST /api/billing/pos-documents (documento equivalente electrónico POS).
+// prefix: prefijo de la resolución POS. Sin customer_id ni buyer el documento sale a nombre del consumidor
+// final (222222222222); buyer registra al comprador que pide ser identificado sin darlo de alta antes.
+// Siempre es de contado; payment_method_codes vacío = efectivo (10).
+type CreatePOSDocumentRequest struct {
+	Prefix             string               `json:"prefix"`
+	WarehouseID        string               `json:"warehouse_id"`
+	CustomerID         string               `json:"customer_id,omitempty"`
+	Buyer              *POSBuyerRequest     `json:"buyer,omitempty"`
+	PaymentMethodCodes []string             `json:"payment_method_codes,omitempty"`
+	Items              []InvoiceItemRequest `json:"items"`
+}
+
+// POSBuyerRequest datos simplificados del comprador. identification_type: Tabla 3 DIAN (vacío = cédula 13).
+type POSBuyerRequest struct {
+	Identification     string `json:"identification"`
+	IdentificationType string `json:"identification_type,omitempty"`
+	Name               string `json:"name"`
+	Email              string `json:"email,omitempty"`
+}
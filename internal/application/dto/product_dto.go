@@ -9,42 +9,56 @@ import (
 
 // CreateProductRequest entrada para crear un producto.
 type CreateProductRequest struct {
-	SKU         string          `json:"sku" validate:"required,min=1,max=100"`
-	Name        string          `json:"name" validate:"required,min=1,max=200"`
-	Description string          `json:"description"`
-	Price       decimal.Decimal `json:"price"`
-	TaxRate     decimal.Decimal `json:"tax_rate"`
-	UNSPSC_Code string          `json:"unspsc_code"`
-	UnitMeasure string          `json:"unit_measure" validate:"required"`
-	Attributes  json.RawMessage `json:"attributes"`
+	SKU                string          `json:"sku" validate:"required,min=1,max=100"`
+	Name               string          `json:"name" validate:"required,min=1,max=200"`
+	Description        string          `json:"description"`
+	Price              decimal.Decimal `json:"price"`
+	TaxRate            decimal.Decimal `json:"tax_rate"`
+	IncRate            decimal.Decimal `json:"inc_rate"`            // impuesto nacional al consumo (%)
+	UnitTaxCode        string          `json:"unit_tax_code"`       // impuesto por unidad, p. ej. "22" (bolsas)
+	UnitTaxAmount      decimal.Decimal `json:"unit_tax_amount"`     // valor del impuesto por unidad
+	WithholdingConcept string          `json:"withholding_concept"` // concepto de retención (COMPRAS, SERVICIOS…); vacío = COMPRAS
+	UNSPSC_Code        string          `json:"unspsc_code"`
+	UnitMeasure        string          `json:"unit_measure" validate:"required"`
+	Attributes         json.RawMessage `json:"attributes"`
+	ProductType        string          `json:"product_type"` // STANDARD (defecto) | KIT
 }
 
 // UpdateProductRequest entrada para actualizar un producto (sin Cost ni Stock).
 type UpdateProductRequest struct {
-	Name        *string          `json:"name" validate:"omitempty,min=1,max=200"`
-	Description *string          `json:"description"`
-	Price       *decimal.Decimal `json:"price"`
-	TaxRate     *decimal.Decimal `json:"tax_rate"`
-	UNSPSC_Code *string         `json:"unspsc_code"`
-	UnitMeasure *string         `json:"unit_measure"`
-	Attributes  json.RawMessage `json:"attributes"`
+	Name               *string          `json:"name" validate:"omitempty,min=1,max=200"`
+	Description        *string          `json:"description"`
+	Price              *decimal.Decimal `json:"price"`
+	TaxRate            *decimal.Decimal `json:"tax_rate"`
+	IncRate            *decimal.Decimal `json:"inc_rate"`
+	UnitTaxCode        *string          `json:"unit_tax_code"`
+	UnitTaxAmount      *decimal.Decimal `json:"unit_tax_amount"`
+	WithholdingConcept *string          `json:"withholding_concept"`
+	UNSPSC_Code        *string          `json:"unspsc_code"`
+	UnitMeasure        *string          `json:"unit_measure"`
+	Attributes         json.RawMessage  `json:"attributes"`
 }
 
 // ProductResponse salida de un producto.
 type ProductResponse struct {
-	ID          string          `json:"id"`
-	CompanyID   string          `json:"company_id"`
-	SKU         string          `json:"sku"`
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	Price       decimal.Decimal `json:"price"`
-	Cost        decimal.Decimal `json:"cost"`
-	TaxRate     decimal.Decimal `json:"tax_rate"`
-	UNSPSC_Code string          `json:"unspsc_code"`
-	UnitMeasure string          `json:"unit_measure"`
-	Attributes  json.RawMessage `json:"attributes"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID                 string          `json:"id"`
+	CompanyID          string          `json:"company_id"`
+	SKU                string          `json:"sku"`
+	Name               string          `json:"name"`
+	Description        string          `json:"description"`
+	Price              decimal.Decimal `json:"price"`
+	Cost               decimal.Decimal `json:"cost"`
+	TaxRate            decimal.Decimal `json:"tax_rate"`
+	IncRate            decimal.Decimal `json:"inc_rate"`
+	UnitTaxCode        string          `json:"unit_tax_code,omitempty"`
+	UnitTaxAmount      decimal.Decimal `json:"unit_tax_amount"`
+	WithholdingConcept string          `json:"withholding_concept"`
+	UNSPSC_Code        string          `json:"unspsc_code"`
+	UnitMeasure        string          `json:"unit_measure"`
+	Attributes         json.RawMessage `json:"attributes"`
+	ProductType        string          `json:"product_type"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
 }
 
 // ProductListResponse lista paginada de productos.
@@ -53,6 +67,19 @@ type ProductListResponse struct {
 	Page  PageResponse      `json:"page"`
 }
 
+// KitComponentDTO componente de un kit y su cantidad por unidad de kit.
+type KitComponentDTO struct {
+	ComponentProductID string          `json:"component_product_id"`
+	SKU                string          `json:"sku,omitempty"`
+	Name               string          `json:"name,omitempty"`
+	Quantity           decimal.Decimal `json:"quantity"`
+}
+
+// UpdateKitComponentsRequest cuerpo de PUT /api/products/:id/components (reemplaza la composición).
+type UpdateKitComponentsRequest struct {
+	Components []KitComponentDTO `json:"components"`
+}
+
 // ── Clasificación arancelaria por IA ─────────────────────────────────────────
 
 // AIClassificationRequest cuerpo de POST /api/ai/suggest-classification.
@@ -65,7 +92,7 @@ type AIClassificationRequest struct {
 // SuggestedTaxRate puede ser 0, 5 o 19 (tarifas de IVA Colombia).
 // ConfidenceScore va de 0.0 a 1.0; valores ≥ 0.8 se consideran alta confianza.
 type AIClassificationDTO struct {
-	SuggestedUNSPSC string          `json:"suggested_unspsc"` // código UNSPSC de 8 dígitos
+	SuggestedUNSPSC  string          `json:"suggested_unspsc"`   // código UNSPSC de 8 dígitos
 	SuggestedTaxRate decimal.Decimal `json:"suggested_tax_rate"` // 0, 5 o 19
 	ConfidenceScore  float64         `json:"confidence_score"`   // 0.0 – 1.0
 	Reasoning        string          `json:"reasoning"`          // explicación del modelo
@@ -0,0 +1,117 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// RegisterPaymentRequest body para POST /api/receivables/payments.
+// method_code: medio de pago (Tabla 13 DIAN; vacío = 10 efectivo). date: YYYY-MM-DD (vacío = hoy).
+// Sin applications el recaudo se aplica a las facturas abiertas más antiguas del cliente en la misma
+// moneda; lo que exceda los saldos queda como saldo a favor.
+type RegisterPaymentRequest struct {
+	CustomerID   string                      `json:"customer_id"`
+	Date         string                      `json:"date,omitempty"`
+	Amount       decimal.Decimal             `json:"amount"`
+	CurrencyCode string                      `json:"currency_code,omitempty"`
+	MethodCode   string                      `json:"method_code,omitempty"`
+	Reference    string                      `json:"reference,omitempty"`
+	Notes        string                      `json:"notes,omitempty"`
+	Applications []PaymentApplicationRequest `json:"applications,omitempty"`
+}
+
+// PaymentApplicationRequest abono de un recaudo a una factura.
+type PaymentApplicationRequest struct {
+	InvoiceID string          `json:"invoice_id"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+// ApplyPaymentRequest body para POST /api/receivables/payments/:id/apply (aplicar saldo a favor).
+type ApplyPaymentRequest struct {
+	Applications []PaymentApplicationRequest `json:"applications"`
+}
+
+// PaymentResponse recaudo con sus aplicaciones.
+type PaymentResponse struct {
+	ID              string                  `json:"id"`
+	CustomerID      string                  `json:"customer_id"`
+	Date            string                  `json:"date"`
+	Amount          decimal.Decimal         `json:"amount"`
+	CurrencyCode    string                  `json:"currency_code"`
+	MethodCode      string                  `json:"method_code"`
+	Reference       string                  `json:"reference,omitempty"`
+	Notes           string                  `json:"notes,omitempty"`
+	UnappliedAmount decimal.Decimal         `json:"unapplied_amount"` // saldo a favor del cliente
+	Applications    []PaymentApplicationDTO `json:"applications"`
+}
+
+// PaymentApplicationDTO abono aplicado a una factura.
+type PaymentApplicationDTO struct {
+	InvoiceID string          `json:"invoice_id"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+// ReceivableInvoiceDTO factura con su saldo en cartera.
+// payment_status: PENDING | PARTIAL | PAID.
+type ReceivableInvoiceDTO struct {
+	InvoiceID     string          `json:"invoice_id"`
+	CustomerID    string          `json:"customer_id"`
+	CustomerName  string          `json:"customer_name"`
+	Number        string          `json:"number"` // prefijo + número
+	Date          string          `json:"date"`
+	DueDate       string          `json:"due_date"`
+	CurrencyCode  string          `json:"currency_code"`
+	Total         decimal.Decimal `json:"total"` // neto a pagar con notas crédito/débito
+	AmountPaid    decimal.Decimal `json:"amount_paid"`
+	Balance       decimal.Decimal `json:"balance"`
+	PaymentStatus string          `json:"payment_status"`
+	DaysOverdue   int             `json:"days_overdue"`
+}
+
+// CustomerStatementDTO estado de cuenta de un cliente en un período y moneda.
+type CustomerStatementDTO struct {
+	CustomerID      string                 `json:"customer_id"`
+	CustomerName    string                 `json:"customer_name"`
+	CurrencyCode    string                 `json:"currency_code"`
+	StartDate       string                 `json:"start_date"`
+	EndDate         string                 `json:"end_date"`
+	OpeningBalance  decimal.Decimal        `json:"opening_balance"`
+	ClosingBalance  decimal.Decimal        `json:"closing_balance"` // negativo = saldo a favor del cliente
+	UnappliedCredit decimal.Decimal        `json:"unapplied_credit"`
+	Entries         []StatementEntryDTO    `json:"entries"`
+	OpenInvoices    []ReceivableInvoiceDTO `json:"open_invoices"`
+}
+
+// StatementEntryDTO movimiento del estado de cuenta: factura (cargo) o recaudo (abono).
+type StatementEntryDTO struct {
+	Date       string          `json:"date"`
+	Type       string          `json:"type"` // INVOICE | PAYMENT
+	DocumentID string          `json:"document_id"`
+	Reference  string          `json:"reference"`
+	Debit      decimal.Decimal `json:"debit"`
+	Credit     decimal.Decimal `json:"credit"`
+	Balance    decimal.Decimal `json:"balance"`
+}
+
+// ReceivablesAgingDTO cartera por edades (días vencidos) por cliente.
+type ReceivablesAgingDTO struct {
+	AsOf         string             `json:"as_of"`
+	CurrencyCode string             `json:"currency_code"`
+	Customers    []AgingCustomerDTO `json:"customers"`
+	Totals       AgingBucketsDTO    `json:"totals"`
+}
+
+// AgingCustomerDTO saldos vencidos de un cliente por rango de días.
+type AgingCustomerDTO struct {
+	CustomerID      string          `json:"customer_id"`
+	CustomerName    string          `json:"customer_name"`
+	UnappliedCredit decimal.Decimal `json:"unapplied_credit"`
+	AgingBucketsDTO
+}
+
+// AgingBucketsDTO saldos por rango: corriente (sin vencer), 1–30, 31–60, 61–90 y más de 90 días.
+type AgingBucketsDTO struct {
+	Current    decimal.Decimal `json:"current"`
+	Days1To30  decimal.Decimal `json:"days_1_30"`
+	Days31To60 decimal.Decimal `json:"days_31_60"`
+	Days61To90 decimal.Decimal `json:"days_61_90"`
+	Over90     decimal.Decimal `json:"over_90"`
+	Total      decimal.Decimal `json:"total"`
+}
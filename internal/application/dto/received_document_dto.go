@@ -0,0 +1,104 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RegisterReceivedDocumentRequest body para POST /api/billing/received-documents: factura electrónica de
+// venta recibida de un proveedor. cufe: CUFE de la factura (96 caracteres hex). issue_date: YYYY-MM-DD.
+// grand_total vacío = net_total + tax_total. xml: XML recibido (opcional, se guarda tal cual).
+type RegisterReceivedDocumentRequest struct {
+	IssuerNIT  string          `json:"issuer_nit"`
+	IssuerName string          `json:"issuer_name"`
+	Number     string          `json:"number"`
+	CUFE       string          `json:"cufe"`
+	IssueDate  string          `json:"issue_date"`
+	NetTotal   decimal.Decimal `json:"net_total"`
+	TaxTotal   decimal.Decimal `json:"tax_total"`
+	GrandTotal decimal.Decimal `json:"grand_total"`
+	XML        string          `json:"xml,omitempty"`
+}
+
+// CreateReceivedDocumentEventRequest body para POST /api/billing/received-documents/:id/events.
+// event_code: 030 acuse de recibo | 032 recibo del bien | 033 aceptación expresa | 031 reclamo.
+// claim_concept: obligatorio en 031 (01..04). person: quien recibe la factura o el bien (obligatorio en 030 y 032).
+type CreateReceivedDocumentEventRequest struct {
+	EventCode    string              `json:"event_code"`
+	ClaimConcept string              `json:"claim_concept,omitempty"`
+	Note         string              `json:"note,omitempty"`
+	Person       *EventPersonRequest `json:"person,omitempty"`
+}
+
+// EventPersonRequest persona que recibe (identificación con cédula).
+type EventPersonRequest struct {
+	Identification string `json:"identification"`
+	Name           string `json:"name"`
+	JobTitle       string `json:"job_title,omitempty"`
+}
+
+// ReceivedDocumentDTO factura recibida con su estado RADIAN e historial de eventos.
+// status: RECEIVED | ACKNOWLEDGED | GOODS_RECEIVED | ACCEPTED | CLAIMED | TACITLY_ACCEPTED.
+type ReceivedDocumentDTO struct {
+	ID                  string                     `json:"id"`
+	SupplierID          string                     `json:"supplier_id,omitempty"`
+	IssuerNIT           string                     `json:"issuer_nit"`
+	IssuerName          string                     `json:"issuer_name"`
+	Number              string                     `json:"number"`
+	CUFE                string                     `json:"cufe"`
+	IssueDate           time.Time                  `json:"issue_date"`
+	NetTotal            decimal.Decimal            `json:"net_total"`
+	TaxTotal            decimal.Decimal            `json:"tax_total"`
+	GrandTotal          decimal.Decimal            `json:"grand_total"`
+	Currency            string                     `json:"currency,omitempty"`
+	Lines               []ReceivedDocumentLineDTO  `json:"lines,omitempty"`
+	Taxes               []ReceivedDocumentTaxDTO   `json:"taxes,omitempty"`
+	PurchaseOrderID     string                     `json:"purchase_order_id,omitempty"` // orden de compra conciliada
+	SignatureValid      *bool                      `json:"signature_valid,omitempty"`   // nil: no verificada (registro manual)
+	CUFEValid           *bool                      `json:"cufe_valid,omitempty"`
+	ValidationErrors    string                     `json:"validation_errors,omitempty"`
+	Source              string                     `json:"source"`
+	Status              string                     `json:"status"`
+	GoodsReceivedAt     *time.Time                 `json:"goods_received_at,omitempty"`
+	TacitAcceptanceFrom *time.Time                 `json:"tacit_acceptance_from,omitempty"` // fin del término para reclamar
+	Events              []ReceivedDocumentEventDTO `json:"events,omitempty"`
+	CreatedAt           time.Time                  `json:"created_at"`
+}
+
+// ReceivedDocumentLineDTO línea de la factura recibida leída del XML.
+type ReceivedDocumentLineDTO struct {
+	LineNumber    int             `json:"line_number"`
+	ItemCode      string          `json:"item_code,omitempty"`
+	Description   string          `json:"description"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	UnitCode      string          `json:"unit_code,omitempty"`
+	UnitPrice     decimal.Decimal `json:"unit_price"`
+	LineExtension decimal.Decimal `json:"line_extension"`
+	TaxAmount     decimal.Decimal `json:"tax_amount"`
+}
+
+// ReceivedDocumentTaxDTO total por impuesto y tarifa de la factura recibida.
+type ReceivedDocumentTaxDTO struct {
+	TaxCode       string          `json:"tax_code"`
+	TaxName       string          `json:"tax_name,omitempty"`
+	Percent       decimal.Decimal `json:"percent"`
+	TaxableAmount decimal.Decimal `json:"taxable_amount"`
+	TaxAmount     decimal.Decimal `json:"tax_amount"`
+}
+
+// ReceivedDocumentEventDTO evento RADIAN emitido sobre la factura recibida. dian_status LOCAL: registro
+// del historial que no se envía a la DIAN (aceptación tácita).
+type ReceivedDocumentEventDTO struct {
+	ID           string    `json:"id"`
+	EventCode    string    `json:"event_code"`
+	EventName    string    `json:"event_name"`
+	Number       string    `json:"number,omitempty"`
+	Date         time.Time `json:"date"`
+	ClaimConcept string    `json:"claim_concept,omitempty"`
+	Note         string    `json:"note,omitempty"`
+	CUDE         string    `json:"cude,omitempty"`
+	DIANStatus   string    `json:"dian_status"`
+	DIANErrors   string    `json:"dian_errors,omitempty"`
+	TrackID      string    `json:"track_id,omitempty"`
+}
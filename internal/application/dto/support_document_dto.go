@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CreateSupportDocumentRequest body para POST /api/billing/support-documents.
+// Con purchase_order_id las líneas salen de la orden de compra recibida (CERRADA) y lines se ignora;
+// sin ella es una compra manual y lines es obligatorio. prefix: prefijo de la resolución del documento
+// soporte. supplier_identification_type: Tabla 3 DIAN (vacío = se infiere del NIT del proveedor).
+// date: YYYY-MM-DD (vacío = hoy).
+type CreateSupportDocumentRequest struct {
+	SupplierID                 string                       `json:"supplier_id"`
+	PurchaseOrderID            string                       `json:"purchase_order_id,omitempty"`
+	Prefix                     string                       `json:"prefix"`
+	Date                       string                       `json:"date,omitempty"`
+	SupplierIdentificationType string                       `json:"supplier_identification_type,omitempty"`
+	Notes                      string                       `json:"notes,omitempty"`
+	Lines                      []SupportDocumentLineRequest `json:"lines,omitempty"`
+}
+
+// SupportDocumentLineRequest bien o servicio adquirido. tax_rate: fracción (0.19); vacío = sin IVA.
+type SupportDocumentLineRequest struct {
+	ProductID   string          `json:"product_id,omitempty"`
+	Description string          `json:"description"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	TaxRate     decimal.Decimal `json:"tax_rate"`
+}
+
+// CreateSupportAdjustmentRequest body para POST /api/billing/support-documents/:id/adjustment.
+// concept: tabla de conceptos de nota crédito (1..6). Sin lines la nota ajusta el documento completo.
+type CreateSupportAdjustmentRequest struct {
+	Prefix  string                       `json:"prefix"`
+	Concept string                       `json:"concept"`
+	Reason  string                       `json:"reason"`
+	Lines   []SupportDocumentLineRequest `json:"lines,omitempty"`
+}
+
+// SupportDocumentDTO documento soporte o nota de ajuste con su estado DIAN.
+// document_type: SUPPORT_DOCUMENT | SUPPORT_ADJUSTMENT.
+type SupportDocumentDTO struct {
+	ID                         string                   `json:"id"`
+	DocumentType               string                   `json:"document_type"`
+	Number                     string                   `json:"number"` // prefijo + número
+	Date                       time.Time                `json:"date"`
+	SupplierID                 string                   `json:"supplier_id"`
+	SupplierName               string                   `json:"supplier_name"`
+	SupplierIdentification     string                   `json:"supplier_identification"`
+	SupplierIdentificationType string                   `json:"supplier_identification_type"`
+	PurchaseOrderID            string                   `json:"purchase_order_id,omitempty"`
+	OriginalDocumentID         string                   `json:"original_document_id,omitempty"`
+	OriginalDocumentNumber     string                   `json:"original_document_number,omitempty"`
+	DiscrepancyCode            string                   `json:"discrepancy_code,omitempty"`
+	DiscrepancyReason          string                   `json:"discrepancy_reason,omitempty"`
+	NetTotal                   decimal.Decimal          `json:"net_total"`
+	TaxTotal                   decimal.Decimal          `json:"tax_total"`
+	GrandTotal                 decimal.Decimal          `json:"grand_total"`
+	CUDS                       string                   `json:"cuds"`
+	DIANStatus                 string                   `json:"dian_status"`
+	DIANErrors                 string                   `json:"dian_errors,omitempty"`
+	TrackID                    string                   `json:"track_id,omitempty"`
+	Notes                      string                   `json:"notes,omitempty"`
+	Lines                      []SupportDocumentLineDTO `json:"lines,omitempty"`
+	CreatedAt                  time.Time                `json:"created_at"`
+}
+
+// SupportDocumentLineDTO línea del documento soporte.
+type SupportDocumentLineDTO struct {
+	ProductID   string          `json:"product_id,omitempty"`
+	Description string          `json:"description"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	TaxRate     decimal.Decimal `json:"tax_rate"`
+	Subtotal    decimal.Decimal `json:"subtotal"`
+	TaxAmount   decimal.Decimal `json:"tax_amount"`
+}
@@ -0,0 +1,48 @@
+package inventory
+
+import (
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/inventory"
+	"github.com/shopspring/decimal"
+)
+
+// replayAverageCost reproduce el costo promedio ponderado de un producto a partir de un saldo
+// de apertura (cantidad y costo) aplicando los movimientos en orden cronológico.
+//   - Entradas (IN, ADJUSTMENT positivo) recalculan el promedio con su costo unitario.
+//   - Salidas (OUT, ADJUSTMENT negativo) se valoran al promedio vigente; restamp recibe las
+//     que quedaron con un costo distinto para que el caller las actualice (puede ser nil).
+//   - RETURN y TRANSFER solo mueven cantidades.
+//   - Un movimiento revertido y su REVERSAL se anulan entre sí y se omiten solo si la reversión está
+//     dentro de movements (fechada antes del corte), igual que en StockAt; si se revirtió después
+//     del corte, a esa fecha seguía vigente y cuenta.
+func replayAverageCost(
+	openQty, openCost decimal.Decimal,
+	movements []*entity.InventoryMovement,
+	restamp func(m *entity.InventoryMovement, unitCost decimal.Decimal) error,
+) (decimal.Decimal, decimal.Decimal, error) {
+	reversed := make(map[string]bool)
+	for _, m := range movements {
+		if m != nil && m.Type == entity.MovementTypeReversal && m.ReversalOfID != "" {
+			reversed[m.ReversalOfID] = true
+		}
+	}
+	qty, cost := openQty, openCost
+	for _, m := range movements {
+		if m == nil || m.Type == entity.MovementTypeReversal || reversed[m.ID] {
+			continue
+		}
+		isCostType := m.Type == entity.MovementTypeIN || m.Type == entity.MovementTypeADJUSTMENT || m.Type == entity.MovementTypeOUT
+		switch {
+		case isCostType && m.Quantity.GreaterThan(decimal.Zero):
+			cost = inventory.CostCalculator(qty, cost, m.Quantity, m.UnitCost)
+		case isCostType && m.Quantity.LessThan(decimal.Zero):
+			if restamp != nil && !m.UnitCost.Equal(cost) {
+				if err := restamp(m, cost); err != nil {
+					return qty, cost, err
+				}
+			}
+		}
+		qty = qty.Add(m.Quantity)
+	}
+	return qty, cost, nil
+}
@@ -86,6 +86,9 @@ func (uc *GetMovementsUseCase) Execute(ctx context.Context, companyID string, f
 			Date:          m.Date,
 			CreatedAt:     m.CreatedAt,
 			CreatedBy:     m.CreatedBy,
+			ReversalOfID:  m.ReversalOfID,
+			ReversedAt:    m.ReversedAt,
+			ReversedBy:    m.ReversedBy,
 		})
 	}
 
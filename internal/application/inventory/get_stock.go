@@ -2,14 +2,19 @@ package inventory
 
 import (
 	"context"
+	"time"
 
 	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	domaininventory "github.com/jhoicas/Inventario-api/internal/domain/inventory"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/shopspring/decimal"
 )
 
 // GetStockUseCase obtiene el resumen de stock de un producto (una bodega o todas).
 type GetStockUseCase struct {
-	stockRepo repository.StockRepository
+	stockRepo   repository.StockRepository
+	productRepo repository.ProductRepository    // opcional: detectar kits
+	kitRepo     repository.ProductKitRepository // opcional: stock derivado de kits
 }
 
 // NewGetStockUseCase construye el caso de uso.
@@ -17,9 +22,25 @@ func NewGetStockUseCase(stockRepo repository.StockRepository) *GetStockUseCase {
 	return &GetStockUseCase{stockRepo: stockRepo}
 }
 
+// SetKitSources habilita el cálculo de stock derivado para productos tipo KIT.
+func (uc *GetStockUseCase) SetKitSources(productRepo repository.ProductRepository, kitRepo repository.ProductKitRepository) {
+	uc.productRepo = productRepo
+	uc.kitRepo = kitRepo
+}
+
 // Execute devuelve el resumen de stock. Si warehouseID está vacío, agrega stocks de todas las bodegas.
 // companyID se recibe para consistencia con otros use cases (validación de empresa puede hacerse en capa superior).
+// Para un kit, el stock es la cantidad de kits completos que se pueden armar con sus componentes.
 func (uc *GetStockUseCase) Execute(ctx context.Context, companyID, productID, warehouseID string) (*dto.StockSummaryDTO, error) {
+	if uc.productRepo != nil && uc.kitRepo != nil {
+		product, err := uc.productRepo.GetByID(productID)
+		if err != nil {
+			return nil, err
+		}
+		if product.IsKit() {
+			return uc.kitSummary(productID, warehouseID)
+		}
+	}
 	summary, err := uc.stockRepo.GetSummary(productID, warehouseID)
 	if err != nil {
 		return nil, err
@@ -34,3 +55,49 @@ func (uc *GetStockUseCase) Execute(ctx context.Context, companyID, productID, wa
 		LastUpdated:    summary.LastUpdated,
 	}, nil
 }
+
+// kitSummary deriva el stock del kit bodega por bodega (un kit se arma con componentes de
+// una misma bodega) y suma las bodegas cuando no se filtra por una. El costo es la suma del
+// costo promedio de los componentes por su cantidad en el kit.
+func (uc *GetStockUseCase) kitSummary(kitProductID, warehouseID string) (*dto.StockSummaryDTO, error) {
+	components, err := uc.kitRepo.ListComponents(kitProductID)
+	if err != nil {
+		return nil, err
+	}
+	out := &dto.StockSummaryDTO{ProductID: kitProductID, WarehouseID: warehouseID}
+	if len(components) == 0 {
+		return out, nil
+	}
+
+	perKit := make([]decimal.Decimal, len(components))
+	byWarehouse := make(map[string][]decimal.Decimal)
+	var lastUpdated time.Time
+	for i, c := range components {
+		perKit[i] = c.Quantity
+		if c.Component != nil {
+			out.AvgCost = out.AvgCost.Add(c.Component.Cost.Mul(c.Quantity))
+		}
+		stocks, err := uc.stockRepo.GetByProduct(c.ComponentProductID)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range stocks {
+			if warehouseID != "" && s.WarehouseID != warehouseID {
+				continue
+			}
+			if _, ok := byWarehouse[s.WarehouseID]; !ok {
+				byWarehouse[s.WarehouseID] = make([]decimal.Decimal, len(components))
+			}
+			byWarehouse[s.WarehouseID][i] = s.Quantity
+			if s.UpdatedAt.After(lastUpdated) {
+				lastUpdated = s.UpdatedAt
+			}
+		}
+	}
+	for _, stocks := range byWarehouse {
+		out.CurrentStock = out.CurrentStock.Add(domaininventory.KitAvailability(stocks, perKit))
+	}
+	out.AvailableStock = out.CurrentStock
+	out.LastUpdated = lastUpdated
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package inventory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+type fakeKitRepo struct {
+	components []*entity.KitComponent
+}
+
+func (f *fakeKitRepo) ListComponents(string) ([]*entity.KitComponent, error) {
+	return f.components, nil
+}
+func (f *fakeKitRepo) ReplaceComponents(_ string, components []*entity.KitComponent) error {
+	f.components = components
+	return nil
+}
+
+var _ repository.ProductKitRepository = (*fakeKitRepo)(nil)
+
+func TestGetStockUseCase_KitDerivedFromComponents(t *testing.T) {
+	kit := validProduct(testCompanyID)
+	kit.ProductType = entity.ProductTypeKit
+	productRepo := &fakeProductRepo{
+		getByIDFunc: func(string) (*entity.Product, error) { return kit, nil },
+	}
+	kitRepo := &fakeKitRepo{components: []*entity.KitComponent{
+		{ComponentProductID: "chocolate", Quantity: decimal.NewFromInt(2), Component: &entity.Product{Cost: decimal.NewFromInt(1500)}},
+		{ComponentProductID: "vino", Quantity: decimal.NewFromInt(1), Component: &entity.Product{Cost: decimal.NewFromInt(20000)}},
+	}}
+	// Bodega central: 10 chocolates y 3 vinos → 3 kits. Bodega norte: 7 chocolates y 9 vinos → 3 kits.
+	// Bodega sur: solo vinos → 0 kits.
+	stockRepo := &fakeStockRepo{
+		getByProductFunc: func(productID string) ([]*entity.Stock, error) {
+			switch productID {
+			case "chocolate":
+				return []*entity.Stock{
+					{ProductID: productID, WarehouseID: testWarehouseID, Quantity: decimal.NewFromInt(10)},
+					{ProductID: productID, WarehouseID: "norte", Quantity: decimal.NewFromInt(7)},
+				}, nil
+			case "vino":
+				return []*entity.Stock{
+					{ProductID: productID, WarehouseID: testWarehouseID, Quantity: decimal.NewFromInt(3)},
+					{ProductID: productID, WarehouseID: "norte", Quantity: decimal.NewFromInt(9)},
+					{ProductID: productID, WarehouseID: "sur", Quantity: decimal.NewFromInt(5)},
+				}, nil
+			}
+			return nil, nil
+		},
+	}
+	uc := NewGetStockUseCase(stockRepo)
+	uc.SetKitSources(productRepo, kitRepo)
+
+	all, err := uc.Execute(context.Background(), testCompanyID, testProductID, "")
+	require.NoError(t, err)
+	assert.True(t, all.AvailableStock.Equal(decimal.NewFromInt(6)), "got %s", all.AvailableStock)
+	assert.True(t, all.AvgCost.Equal(decimal.NewFromInt(23000)), "got %s", all.AvgCost)
+
+	one, err := uc.Execute(context.Background(), testCompanyID, testProductID, testWarehouseID)
+	require.NoError(t, err)
+	assert.True(t, one.CurrentStock.Equal(decimal.NewFromInt(3)), "got %s", one.CurrentStock)
+}
+
+func TestRegisterMovementUseCase_RejectsKit(t *testing.T) {
+	kit := validProduct(testCompanyID)
+	kit.ProductType = entity.ProductTypeKit
+	productRepo := &fakeProductRepo{
+		getByIDFunc: func(string) (*entity.Product, error) { return kit, nil },
+	}
+	uc := NewRegisterMovementUseCase(&fakeTxRunner{}, productRepo, &fakeWarehouseRepo{})
+	err := uc.RegisterMovement(context.Background(), validRegisterMovementDTO())
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
@@ -0,0 +1,292 @@
+package inventory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/shopspring/decimal"
+)
+
+// InventoryPeriodUseCase gestiona los cierres mensuales de inventario por empresa:
+// congela la valorización, bloquea movimientos en períodos cerrados y permite reabrir con auditoría.
+type InventoryPeriodUseCase struct {
+	periodRepo InventoryPeriodRepository
+}
+
+// NewInventoryPeriodUseCase construye el caso de uso.
+func NewInventoryPeriodUseCase(periodRepo InventoryPeriodRepository) *InventoryPeriodUseCase {
+	return &InventoryPeriodUseCase{periodRepo: periodRepo}
+}
+
+// InventoryPeriodDetail período con su valorización congelada y bitácora.
+type InventoryPeriodDetail struct {
+	Period     *entity.InventoryPeriod
+	Valuations []entity.InventoryPeriodValuation
+	Events     []entity.InventoryPeriodEvent
+}
+
+// List devuelve los períodos registrados (cerrados o reabiertos) de la empresa.
+func (uc *InventoryPeriodUseCase) List(ctx context.Context, companyID string) ([]*entity.InventoryPeriod, error) {
+	if companyID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	return uc.periodRepo.ListByCompany(ctx, companyID)
+}
+
+// Get devuelve el período con valorización y eventos de auditoría.
+func (uc *InventoryPeriodUseCase) Get(ctx context.Context, companyID string, year, month int) (*InventoryPeriodDetail, error) {
+	if companyID == "" || !validPeriod(year, month) {
+		return nil, domain.ErrInvalidInput
+	}
+	period, err := uc.periodRepo.Get(ctx, companyID, year, month)
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		return nil, domain.ErrNotFound
+	}
+	valuations, err := uc.periodRepo.ListValuations(ctx, period.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := uc.periodRepo.ListEvents(ctx, period.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &InventoryPeriodDetail{Period: period, Valuations: valuations, Events: events}, nil
+}
+
+// Close cierra el mes (year, month): calcula cantidades por producto y bodega a la fecha de corte,
+// valoriza al costo promedio reproducido desde el cierre anterior y registra el evento.
+// Solo se pueden cerrar meses ya terminados. La valorización se calcula después de bloquear el
+// período, así que incluye cualquier movimiento retroactivo confirmado antes del cierre.
+func (uc *InventoryPeriodUseCase) Close(ctx context.Context, companyID, userID string, year, month int) (*entity.InventoryPeriod, error) {
+	if companyID == "" || !validPeriod(year, month) {
+		return nil, domain.ErrInvalidInput
+	}
+	now := time.Now()
+	period, err := uc.periodRepo.Get(ctx, companyID, year, month)
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		period = &entity.InventoryPeriod{
+			ID:        uuid.New().String(),
+			CompanyID: companyID,
+			Year:      year,
+			Month:     month,
+			CreatedAt: now,
+		}
+	}
+	if period.IsClosed() {
+		return nil, domain.ErrConflict
+	}
+	if period.End().After(now) {
+		return nil, domain.ErrInvalidInput
+	}
+
+	valuate := func(periodRepo InventoryPeriodRepository, movementRepo repository.InventoryMovementRepository) ([]entity.InventoryPeriodValuation, error) {
+		valuations, err := periodRepo.StockAt(ctx, companyID, period.End())
+		if err != nil {
+			return nil, err
+		}
+		costs, err := costsAt(ctx, periodRepo, movementRepo, companyID, valuations, period.End())
+		if err != nil {
+			return nil, err
+		}
+		for i := range valuations {
+			valuations[i].UnitCost = costs[valuations[i].ProductID]
+			valuations[i].TotalValue = valuations[i].Quantity.Mul(valuations[i].UnitCost)
+		}
+		return valuations, nil
+	}
+
+	period.Status = entity.InventoryPeriodStatusClosed
+	period.ClosedAt = &now
+	period.ClosedBy = userID
+	period.UpdatedAt = now
+	event := &entity.InventoryPeriodEvent{
+		ID:        uuid.New().String(),
+		PeriodID:  period.ID,
+		Action:    entity.InventoryPeriodActionClose,
+		UserID:    userID,
+		CreatedAt: now,
+	}
+	if err := uc.periodRepo.Close(ctx, period, event, valuate); err != nil {
+		return nil, err
+	}
+	return period, nil
+}
+
+// Reopen reabre un período cerrado. Exige motivo y que no existan cierres posteriores,
+// para no invalidar valorizaciones que dependen de él.
+func (uc *InventoryPeriodUseCase) Reopen(ctx context.Context, companyID, userID string, year, month int, reason string) (*entity.InventoryPeriod, error) {
+	reason = strings.TrimSpace(reason)
+	if companyID == "" || !validPeriod(year, month) || reason == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	period, err := uc.periodRepo.Get(ctx, companyID, year, month)
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		return nil, domain.ErrNotFound
+	}
+	if !period.IsClosed() {
+		return nil, domain.ErrConflict
+	}
+	laterClosed, err := uc.periodRepo.HasClosedAfter(ctx, companyID, year, month)
+	if err != nil {
+		return nil, err
+	}
+	if laterClosed {
+		return nil, domain.ErrConflict
+	}
+
+	now := time.Now()
+	period.Status = entity.InventoryPeriodStatusOpen
+	period.UpdatedAt = now
+	event := &entity.InventoryPeriodEvent{
+		ID:        uuid.New().String(),
+		PeriodID:  period.ID,
+		Action:    entity.InventoryPeriodActionReopen,
+		Reason:    reason,
+		UserID:    userID,
+		CreatedAt: now,
+	}
+	if err := uc.periodRepo.Reopen(ctx, period, event); err != nil {
+		return nil, err
+	}
+	return period, nil
+}
+
+// costsAt reproduce el costo promedio de cada producto hasta cutoff partiendo del último cierre.
+func costsAt(
+	ctx context.Context,
+	periodRepo InventoryPeriodRepository,
+	movRepo repository.InventoryMovementRepository,
+	companyID string,
+	valuations []entity.InventoryPeriodValuation,
+	cutoff time.Time,
+) (map[string]decimal.Decimal, error) {
+	costs := make(map[string]decimal.Decimal)
+	for _, v := range valuations {
+		if _, ok := costs[v.ProductID]; ok {
+			continue
+		}
+		_, cost, err := openingAndReplay(ctx, periodRepo, movRepo, companyID, v.ProductID, cutoff, nil)
+		if err != nil {
+			return nil, err
+		}
+		costs[v.ProductID] = cost
+	}
+	return costs, nil
+}
+
+// openingAndReplay toma el saldo del último período cerrado antes de cutoff (o cero si no hay)
+// y reproduce los movimientos del producto hasta cutoff. Con cutoff cero recorre hasta el final.
+func openingAndReplay(
+	ctx context.Context,
+	periodRepo InventoryPeriodRepository,
+	movRepo repository.InventoryMovementRepository,
+	companyID, productID string,
+	cutoff time.Time,
+	restamp func(m *entity.InventoryMovement, unitCost decimal.Decimal) error,
+) (decimal.Decimal, decimal.Decimal, error) {
+	openQty, openCost := decimal.Zero, decimal.Zero
+	var from *time.Time
+	if periodRepo != nil {
+		ref := cutoff
+		if ref.IsZero() {
+			ref = time.Now()
+		}
+		prev, err := periodRepo.LastClosedBefore(ctx, companyID, ref)
+		if err != nil {
+			return openQty, openCost, err
+		}
+		if prev != nil {
+			end := prev.End()
+			from = &end
+			valuations, err := periodRepo.ListValuations(ctx, prev.ID)
+			if err != nil {
+				return openQty, openCost, err
+			}
+			for _, v := range valuations {
+				if v.ProductID == productID {
+					openQty = openQty.Add(v.Quantity)
+					openCost = v.UnitCost
+				}
+			}
+		}
+	}
+	var to *time.Time
+	if !cutoff.IsZero() {
+		to = &cutoff
+	}
+	movements, err := movRepo.ListByProductChronological(productID, from, to)
+	if err != nil {
+		return openQty, openCost, err
+	}
+	return replayAverageCost(openQty, openCost, movements, restamp)
+}
+
+// periodKey mes contable (year, month) en la zona horaria de la empresa; cero si no hay control de cierres.
+type periodKey struct {
+	year, month int
+}
+
+// companyLocation zona horaria de la empresa que delimita sus períodos (la por defecto sin repositorio).
+func companyLocation(ctx context.Context, periodRepo InventoryPeriodRepository, companyID string) (*time.Location, error) {
+	if periodRepo == nil {
+		return entity.LoadCompanyLocation("")
+	}
+	return periodRepo.CompanyLocation(ctx, companyID)
+}
+
+// checkMovementDate valida una fecha de movimiento contra los cierres de la empresa:
+// el mes no puede estar cerrado ni tener cierres posteriores. Devuelve el mes para que el
+// caller lo bloquee dentro de su transacción (lockMovementPeriod).
+func checkMovementDate(ctx context.Context, periodRepo InventoryPeriodRepository, companyID string, date time.Time) (periodKey, error) {
+	if periodRepo == nil {
+		return periodKey{}, nil
+	}
+	loc, err := periodRepo.CompanyLocation(ctx, companyID)
+	if err != nil {
+		return periodKey{}, err
+	}
+	local := date.In(loc)
+	key := periodKey{year: local.Year(), month: int(local.Month())}
+	period, err := periodRepo.Get(ctx, companyID, key.year, key.month)
+	if err != nil {
+		return periodKey{}, err
+	}
+	if period.IsClosed() {
+		return periodKey{}, domain.ErrPeriodClosed
+	}
+	laterClosed, err := periodRepo.HasClosedAfter(ctx, companyID, key.year, key.month)
+	if err != nil {
+		return periodKey{}, err
+	}
+	if laterClosed {
+		return periodKey{}, domain.ErrPeriodClosed
+	}
+	return key, nil
+}
+
+// lockMovementPeriod repite la validación de checkMovementDate dentro de la transacción del
+// movimiento bloqueando la fila del período: un cierre concurrente no puede colarse entre ambas.
+func lockMovementPeriod(movRepo repository.InventoryMovementRepository, companyID string, key periodKey) error {
+	if key.year == 0 {
+		return nil
+	}
+	return movRepo.LockOpenPeriod(companyID, key.year, key.month)
+}
+
+func validPeriod(year, month int) bool {
+	return year >= 2000 && year <= 9999 && month >= 1 && month <= 12
+}
@@ -0,0 +1,435 @@
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+// ── Fake InventoryPeriodRepository (en memoria) ────────────────────────────────
+
+type fakePeriodRepo struct {
+	periods    map[[2]int]*entity.InventoryPeriod
+	valuations map[string][]entity.InventoryPeriodValuation
+	events     []*entity.InventoryPeriodEvent
+	stockAt    []entity.InventoryPeriodValuation
+	movements  repository.InventoryMovementRepository // los que ve la valorización del cierre
+	loc        *time.Location                         // zona horaria de la empresa; nil = time.Local
+}
+
+func newFakePeriodRepo() *fakePeriodRepo {
+	return &fakePeriodRepo{
+		periods:    make(map[[2]int]*entity.InventoryPeriod),
+		valuations: make(map[string][]entity.InventoryPeriodValuation),
+	}
+}
+
+func (f *fakePeriodRepo) closed(year, month int, valuations ...entity.InventoryPeriodValuation) *entity.InventoryPeriod {
+	now := time.Now()
+	p := &entity.InventoryPeriod{
+		ID:        "period-" + time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local).Format("2006-01"),
+		CompanyID: testCompanyID,
+		Year:      year,
+		Month:     month,
+		Status:    entity.InventoryPeriodStatusClosed,
+		ClosedAt:  &now,
+		Location:  f.location(),
+	}
+	f.periods[[2]int{year, month}] = p
+	f.valuations[p.ID] = valuations
+	return p
+}
+
+func (f *fakePeriodRepo) Get(_ context.Context, _ string, year, month int) (*entity.InventoryPeriod, error) {
+	return f.periods[[2]int{year, month}], nil
+}
+func (f *fakePeriodRepo) ListByCompany(_ context.Context, _ string) ([]*entity.InventoryPeriod, error) {
+	out := make([]*entity.InventoryPeriod, 0, len(f.periods))
+	for _, p := range f.periods {
+		out = append(out, p)
+	}
+	return out, nil
+}
+func (f *fakePeriodRepo) HasClosedAfter(_ context.Context, _ string, year, month int) (bool, error) {
+	for k, p := range f.periods {
+		if p.IsClosed() && (k[0] > year || (k[0] == year && k[1] > month)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (f *fakePeriodRepo) LastClosedBefore(_ context.Context, _ string, date time.Time) (*entity.InventoryPeriod, error) {
+	var last *entity.InventoryPeriod
+	for _, p := range f.periods {
+		if p.IsClosed() && !p.End().After(date) && (last == nil || p.End().After(last.End())) {
+			last = p
+		}
+	}
+	return last, nil
+}
+func (f *fakePeriodRepo) StockAt(_ context.Context, _ string, _ time.Time) ([]entity.InventoryPeriodValuation, error) {
+	out := make([]entity.InventoryPeriodValuation, len(f.stockAt))
+	copy(out, f.stockAt)
+	return out, nil
+}
+func (f *fakePeriodRepo) Close(_ context.Context, p *entity.InventoryPeriod, event *entity.InventoryPeriodEvent, valuate PeriodValuator) error {
+	event.PeriodID = p.ID
+	movements := f.movements
+	if movements == nil {
+		movements = &fakeMovementRepo{}
+	}
+	valuations, err := valuate(f, movements)
+	if err != nil {
+		return err
+	}
+	for i := range valuations {
+		valuations[i].PeriodID = p.ID
+	}
+	f.periods[[2]int{p.Year, p.Month}] = p
+	f.valuations[p.ID] = valuations
+	f.events = append(f.events, event)
+	return nil
+}
+func (f *fakePeriodRepo) Reopen(_ context.Context, p *entity.InventoryPeriod, event *entity.InventoryPeriodEvent) error {
+	f.periods[[2]int{p.Year, p.Month}] = p
+	f.events = append(f.events, event)
+	return nil
+}
+func (f *fakePeriodRepo) ListValuations(_ context.Context, periodID string) ([]entity.InventoryPeriodValuation, error) {
+	return f.valuations[periodID], nil
+}
+func (f *fakePeriodRepo) ListEvents(_ context.Context, _ string) ([]entity.InventoryPeriodEvent, error) {
+	out := make([]entity.InventoryPeriodEvent, 0, len(f.events))
+	for _, e := range f.events {
+		out = append(out, *e)
+	}
+	return out, nil
+}
+
+func (f *fakePeriodRepo) CompanyLocation(_ context.Context, _ string) (*time.Location, error) {
+	return f.location(), nil
+}
+
+func (f *fakePeriodRepo) location() *time.Location {
+	if f.loc != nil {
+		return f.loc
+	}
+	return time.Local
+}
+
+var _ InventoryPeriodRepository = (*fakePeriodRepo)(nil)
+
+func monthsAgo(n int) (int, int) {
+	d := time.Now().AddDate(0, -n, -time.Now().Day()+1)
+	return d.Year(), int(d.Month())
+}
+
+// ── Tests Close / Reopen ──────────────────────────────────────────────────────
+
+func TestInventoryPeriodUseCase_Close(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("RejectsCurrentMonth", func(t *testing.T) {
+		uc := NewInventoryPeriodUseCase(newFakePeriodRepo())
+		now := time.Now()
+		_, err := uc.Close(ctx, testCompanyID, testUserID, now.Year(), int(now.Month()))
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("AlreadyClosed", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		year, month := monthsAgo(1)
+		repo.closed(year, month)
+		uc := NewInventoryPeriodUseCase(repo)
+		_, err := uc.Close(ctx, testCompanyID, testUserID, year, month)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("FreezesValuationAtReplayedCost", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		repo.stockAt = []entity.InventoryPeriodValuation{
+			{ProductID: testProductID, WarehouseID: testWarehouseID, Quantity: decimal.NewFromInt(15)},
+		}
+		movRepo := &fakeMovementRepo{
+			listChronoFunc: func(productID string, from, to *time.Time) ([]*entity.InventoryMovement, error) {
+				assert.Nil(t, from)
+				require.NotNil(t, to)
+				return []*entity.InventoryMovement{
+					inMovement("m1", 10, 1000),
+					inMovement("m2", 10, 2000),
+					{ID: "m3", Type: entity.MovementTypeOUT, Quantity: decimal.NewFromInt(-5)},
+				}, nil
+			},
+		}
+		repo.movements = movRepo
+		uc := NewInventoryPeriodUseCase(repo)
+		year, month := monthsAgo(1)
+
+		period, err := uc.Close(ctx, testCompanyID, testUserID, year, month)
+		require.NoError(t, err)
+		assert.True(t, period.IsClosed())
+		assert.Equal(t, testUserID, period.ClosedBy)
+
+		vals := repo.valuations[period.ID]
+		require.Len(t, vals, 1)
+		assert.True(t, vals[0].UnitCost.Equal(decimal.NewFromInt(1500)), "unit cost %s", vals[0].UnitCost)
+		assert.True(t, vals[0].TotalValue.Equal(decimal.NewFromInt(22500)))
+		require.Len(t, repo.events, 1)
+		assert.Equal(t, entity.InventoryPeriodActionClose, repo.events[0].Action)
+	})
+
+	t.Run("ReversalAfterCutoff_StillCounts", func(t *testing.T) {
+		// m2 se revirtió después del corte: su REVERSAL no está entre los movimientos anteriores al corte,
+		// así que a la fecha de cierre la entrada seguía vigente. m3 se revirtió antes del corte y se omite.
+		at := time.Now()
+		m2 := inMovement("m2", 10, 2000)
+		m2.ReversedAt = &at
+		m3 := inMovement("m3", 10, 9000)
+		m3.ReversedAt = &at
+		repo := newFakePeriodRepo()
+		repo.stockAt = []entity.InventoryPeriodValuation{
+			{ProductID: testProductID, WarehouseID: testWarehouseID, Quantity: decimal.NewFromInt(20)},
+		}
+		repo.movements = &fakeMovementRepo{
+			listChronoFunc: func(productID string, from, to *time.Time) ([]*entity.InventoryMovement, error) {
+				return []*entity.InventoryMovement{
+					inMovement("m1", 10, 1000),
+					m2,
+					m3,
+					{ID: "r3", Type: entity.MovementTypeReversal, Quantity: decimal.NewFromInt(-10), UnitCost: decimal.NewFromInt(9000), ReversalOfID: "m3"},
+				}, nil
+			},
+		}
+		uc := NewInventoryPeriodUseCase(repo)
+		year, month := monthsAgo(1)
+
+		period, err := uc.Close(ctx, testCompanyID, testUserID, year, month)
+		require.NoError(t, err)
+		vals := repo.valuations[period.ID]
+		require.Len(t, vals, 1)
+		assert.True(t, vals[0].UnitCost.Equal(decimal.NewFromInt(1500)), "unit cost %s", vals[0].UnitCost)
+	})
+
+	t.Run("ReusesPeriodRegisteredOpen", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		year, month := monthsAgo(1)
+		repo.periods[[2]int{year, month}] = &entity.InventoryPeriod{
+			ID: "period-open", CompanyID: testCompanyID, Year: year, Month: month, Status: entity.InventoryPeriodStatusOpen,
+		}
+		repo.stockAt = []entity.InventoryPeriodValuation{
+			{ProductID: testProductID, WarehouseID: testWarehouseID, Quantity: decimal.NewFromInt(3)},
+		}
+		uc := NewInventoryPeriodUseCase(repo)
+
+		period, err := uc.Close(ctx, testCompanyID, testUserID, year, month)
+		require.NoError(t, err)
+		assert.Equal(t, "period-open", period.ID)
+		require.Len(t, repo.events, 1)
+		assert.Equal(t, period.ID, repo.events[0].PeriodID)
+		require.Len(t, repo.valuations[period.ID], 1)
+		assert.Equal(t, period.ID, repo.valuations[period.ID][0].PeriodID)
+	})
+}
+
+func TestInventoryPeriodUseCase_Reopen(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("RequiresReason", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		year, month := monthsAgo(1)
+		repo.closed(year, month)
+		uc := NewInventoryPeriodUseCase(repo)
+		_, err := uc.Reopen(ctx, testCompanyID, testUserID, year, month, "  ")
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("LaterPeriodClosed", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		y1, m1 := monthsAgo(2)
+		y2, m2 := monthsAgo(1)
+		repo.closed(y1, m1)
+		repo.closed(y2, m2)
+		uc := NewInventoryPeriodUseCase(repo)
+		_, err := uc.Reopen(ctx, testCompanyID, testUserID, y1, m1, "ajuste de auditoría")
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		year, month := monthsAgo(1)
+		repo.closed(year, month)
+		uc := NewInventoryPeriodUseCase(repo)
+		period, err := uc.Reopen(ctx, testCompanyID, testUserID, year, month, "factura de compra tardía")
+		require.NoError(t, err)
+		assert.False(t, period.IsClosed())
+		require.Len(t, repo.events, 1)
+		assert.Equal(t, entity.InventoryPeriodActionReopen, repo.events[0].Action)
+		assert.Equal(t, "factura de compra tardía", repo.events[0].Reason)
+	})
+}
+
+// ── Tests movimientos con fecha anterior ──────────────────────────────────────
+
+func TestRegisterMovementUseCase_Backdated(t *testing.T) {
+	ctx := context.Background()
+	lastMonth := time.Now().AddDate(0, -1, 0)
+
+	newUseCase := func(movRepo *fakeMovementRepo, productRepo *fakeProductRepo) *RegisterMovementUseCase {
+		stockRepo := &fakeStockRepo{
+			getForUpdateFunc: func(productID, warehouseID string) (*entity.Stock, error) {
+				return validStock(decimal.NewFromInt(10)), nil
+			},
+		}
+		txRunner := &fakeTxRunner{
+			runFunc: func(_ context.Context, fn func(
+				repository.InventoryMovementRepository,
+				repository.StockRepository,
+				repository.ProductRepository,
+			) error) error {
+				return fn(movRepo, stockRepo, productRepo)
+			},
+		}
+		warehouseRepo := &fakeWarehouseRepo{
+			getByIDFunc: func(id string) (*entity.Warehouse, error) { return validWarehouse(testCompanyID), nil },
+		}
+		return NewRegisterMovementUseCase(txRunner, productRepo, warehouseRepo)
+	}
+	productRepo := func() *fakeProductRepo {
+		return &fakeProductRepo{
+			getByIDFunc: func(id string) (*entity.Product, error) { return validProduct(testCompanyID), nil },
+		}
+	}
+
+	t.Run("FutureDate", func(t *testing.T) {
+		uc := newUseCase(&fakeMovementRepo{}, productRepo())
+		in := validRegisterMovementDTO()
+		future := time.Now().Add(48 * time.Hour)
+		in.Date = &future
+		in.AllowBackdated = true
+		err := uc.RegisterMovement(ctx, in)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("NotAuthorized", func(t *testing.T) {
+		uc := newUseCase(&fakeMovementRepo{}, productRepo())
+		in := validRegisterMovementDTO()
+		in.Date = &lastMonth
+		err := uc.RegisterMovement(ctx, in)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("ClosedPeriod", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		repo.closed(lastMonth.Year(), int(lastMonth.Month()))
+		uc := newUseCase(&fakeMovementRepo{}, productRepo())
+		uc.SetPeriodRepository(repo)
+		in := validRegisterMovementDTO()
+		in.Date = &lastMonth
+		in.AllowBackdated = true
+		err := uc.RegisterMovement(ctx, in)
+		assert.ErrorIs(t, err, domain.ErrPeriodClosed)
+	})
+
+	t.Run("NilDateChecksCurrentPeriod", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		now := time.Now()
+		repo.closed(now.Year(), int(now.Month()))
+		uc := newUseCase(&fakeMovementRepo{}, productRepo())
+		uc.SetPeriodRepository(repo)
+		err := uc.RegisterMovement(ctx, validRegisterMovementDTO())
+		assert.ErrorIs(t, err, domain.ErrPeriodClosed)
+	})
+
+	t.Run("LocksPeriodInsideTransaction", func(t *testing.T) {
+		var locked [2]int
+		created := false
+		movRepo := &fakeMovementRepo{
+			// Cierre concurrente entre la validación previa y la transacción.
+			lockPeriodFunc: func(companyID string, year, month int) error {
+				assert.Equal(t, testCompanyID, companyID)
+				locked = [2]int{year, month}
+				return domain.ErrPeriodClosed
+			},
+			createFunc: func(m *entity.InventoryMovement) error {
+				created = true
+				return nil
+			},
+		}
+		uc := newUseCase(movRepo, productRepo())
+		uc.SetPeriodRepository(newFakePeriodRepo())
+		in := validRegisterMovementDTO()
+		in.Date = &lastMonth
+		in.AllowBackdated = true
+		err := uc.RegisterMovement(ctx, in)
+		assert.ErrorIs(t, err, domain.ErrPeriodClosed)
+		assert.Equal(t, [2]int{lastMonth.Year(), int(lastMonth.Month())}, locked)
+		assert.False(t, created)
+	})
+
+	t.Run("UsesCompanyTimeZone", func(t *testing.T) {
+		repo := newFakePeriodRepo()
+		repo.loc = time.FixedZone("America/Bogota", -5*60*60)
+		repo.closed(2024, 2)
+		uc := newUseCase(&fakeMovementRepo{}, productRepo())
+		uc.SetPeriodRepository(repo)
+		in := validRegisterMovementDTO()
+		// 1 de marzo en UTC es aún 29 de febrero en Bogotá: cae en el período cerrado.
+		date := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+		in.Date = &date
+		in.AllowBackdated = true
+		err := uc.RegisterMovement(ctx, in)
+		assert.ErrorIs(t, err, domain.ErrPeriodClosed)
+	})
+
+	t.Run("RestampsLaterOutflows", func(t *testing.T) {
+		var created *entity.InventoryMovement
+		restamped := map[string]decimal.Decimal{}
+		var finalCost decimal.Decimal
+		out := &entity.InventoryMovement{
+			ID: "out-1", Type: entity.MovementTypeOUT,
+			Quantity: decimal.NewFromInt(-5), UnitCost: decimal.NewFromInt(1000),
+		}
+		movRepo := &fakeMovementRepo{
+			createFunc: func(m *entity.InventoryMovement) error {
+				m.ID = "new-in"
+				created = m
+				return nil
+			},
+			listChronoFunc: func(productID string, from, to *time.Time) ([]*entity.InventoryMovement, error) {
+				// Entrada inicial, la nueva entrada con fecha anterior y una salida posterior.
+				return []*entity.InventoryMovement{inMovement("in-0", 10, 1000), created, out}, nil
+			},
+			updateCostFunc: func(id string, unitCost, totalCost decimal.Decimal) error {
+				restamped[id] = unitCost
+				return nil
+			},
+		}
+		products := productRepo()
+		products.updateCostFunc = func(_ string, cost decimal.Decimal) error {
+			finalCost = cost
+			return nil
+		}
+		uc := newUseCase(movRepo, products)
+		in := validRegisterMovementDTO()
+		unitCost := decimal.NewFromInt(3000)
+		in.UnitCost = &unitCost
+		in.Date = &lastMonth
+		in.AllowBackdated = true
+
+		err := uc.RegisterMovement(ctx, in)
+		require.NoError(t, err)
+		require.NotNil(t, created)
+		assert.True(t, created.Date.Equal(lastMonth))
+		require.Contains(t, restamped, "out-1")
+		assert.True(t, restamped["out-1"].Equal(decimal.NewFromInt(2000)), "restamp %s", restamped["out-1"])
+		assert.True(t, finalCost.Equal(decimal.NewFromInt(2000)), "final cost %s", finalCost)
+	})
+}
@@ -64,6 +64,9 @@ func (uc *ListMovementsUseCase) Execute(ctx context.Context, companyID string, i
 			Date:          m.Date,
 			CreatedAt:     m.CreatedAt,
 			CreatedBy:     m.CreatedBy,
+			ReversalOfID:  m.ReversalOfID,
+			ReversedAt:    m.ReversedAt,
+			ReversedBy:    m.ReversedBy,
 		})
 	}
 
@@ -39,3 +39,71 @@ type PurchaseOrderRepository interface {
 	ListByCompany(ctx context.Context, companyID string, limit, offset int) ([]*entity.PurchaseOrder, int64, error)
 	UpdateStatus(ctx context.Context, id, status string, updatedAt time.Time) error
 }
+
+// PeriodValuator calcula la valorización de un cierre con repositorios atados a la transacción que
+// tiene bloqueado el período, para que ningún movimiento retroactivo quede por fuera.
+type PeriodValuator func(periodRepo InventoryPeriodRepository, movementRepo repository.InventoryMovementRepository) ([]entity.InventoryPeriodValuation, error)
+
+// InventoryPeriodRepository define persistencia para cierres mensuales de inventario.
+type InventoryPeriodRepository interface {
+	// Get devuelve el período (year, month) de la empresa o nil si nunca se ha cerrado.
+	Get(ctx context.Context, companyID string, year, month int) (*entity.InventoryPeriod, error)
+	ListByCompany(ctx context.Context, companyID string) ([]*entity.InventoryPeriod, error)
+	// HasClosedAfter informa si existe un período cerrado posterior a (year, month).
+	HasClosedAfter(ctx context.Context, companyID string, year, month int) (bool, error)
+	// LastClosedBefore devuelve el último período cerrado que termina en o antes de date.
+	LastClosedBefore(ctx context.Context, companyID string, date time.Time) (*entity.InventoryPeriod, error)
+	// StockAt calcula las cantidades por producto y bodega a partir de los movimientos anteriores a cutoff.
+	StockAt(ctx context.Context, companyID string, cutoff time.Time) ([]entity.InventoryPeriodValuation, error)
+	// Close marca el período como cerrado bloqueando su fila, calcula la valorización con valuate dentro
+	// de la misma transacción, la reemplaza y registra el evento.
+	Close(ctx context.Context, period *entity.InventoryPeriod, event *entity.InventoryPeriodEvent, valuate PeriodValuator) error
+	// Reopen marca el período como abierto y registra el evento (la valorización previa se conserva).
+	Reopen(ctx context.Context, period *entity.InventoryPeriod, event *entity.InventoryPeriodEvent) error
+	ListValuations(ctx context.Context, periodID string) ([]entity.InventoryPeriodValuation, error)
+	ListEvents(ctx context.Context, periodID string) ([]entity.InventoryPeriodEvent, error)
+	// CompanyLocation zona horaria de la empresa con la que se delimitan sus meses.
+	CompanyLocation(ctx context.Context, companyID string) (*time.Location, error)
+}
+
+// StockChangeNotifier recibe los pares producto/bodega cuyo stock cambió para evaluarlos
+// en segundo plano (alertas de stock bajo). No debe bloquear la transacción del movimiento.
+type StockChangeNotifier interface {
+	Enqueue(companyID, productID, warehouseID string)
+}
+
+// StockAlertRepository define persistencia para alertas de stock bajo y sus suscripciones.
+type StockAlertRepository interface {
+	// GetThresholds devuelve el stock actual y los umbrales del producto en la bodega (nil si no existe).
+	GetThresholds(ctx context.Context, companyID, productID, warehouseID string) (*entity.StockLevelThresholds, error)
+	// ListOpenFor devuelve las alertas abiertas del producto en la bodega (de stock y de vencimiento).
+	ListOpenFor(ctx context.Context, productID, warehouseID string) ([]*entity.StockAlert, error)
+	// CreateIfAbsent inserta la alerta si no hay otra OPEN del mismo tipo (y lote); indica si la creó.
+	CreateIfAbsent(ctx context.Context, alert *entity.StockAlert) (bool, error)
+	Resolve(ctx context.Context, alertID string, resolvedAt time.Time) error
+	ListByCompany(ctx context.Context, companyID, status, warehouseID string, limit, offset int) ([]*entity.StockAlert, error)
+	// ListRecipients resuelve los suscriptores activos de la bodega; una suscripción específica
+	// de la bodega prevalece sobre la general del mismo usuario.
+	ListRecipients(ctx context.Context, companyID, warehouseID string) ([]entity.StockAlertRecipient, error)
+	ListSubscriptions(ctx context.Context, companyID, userID string) ([]*entity.StockAlertSubscription, error)
+	ReplaceSubscriptions(ctx context.Context, companyID, userID string, subs []*entity.StockAlertSubscription) error
+	// ListExpiringLots devuelve, de todas las empresas, hasta limit lotes con saldo que vencen antes de
+	// before y aún no tienen abierta la alerta que les corresponde en today (EXPIRED si vencieron antes
+	// de today, NEAR_EXPIRY si no).
+	ListExpiringLots(ctx context.Context, today, before time.Time, limit int) ([]*entity.StockLot, error)
+	// ResolveDepletedLotAlerts resuelve las alertas de vencimiento abiertas cuyo lote ya no tiene saldo.
+	ResolveDepletedLotAlerts(ctx context.Context, resolvedAt time.Time) error
+}
+
+// EmailSender envía correos de texto plano; el adaptador SMTP se conecta en cmd/api.
+type EmailSender interface {
+	Send(to, subject, body string) error
+}
+
+// NotificationRepository define persistencia para notificaciones in-app.
+type NotificationRepository interface {
+	Create(ctx context.Context, n *entity.Notification) error
+	ListByUser(ctx context.Context, companyID, userID string, unreadOnly bool, limit, offset int) ([]*entity.Notification, error)
+	// MarkRead marca la notificación como leída; ErrNotFound si no pertenece al usuario.
+	MarkRead(ctx context.Context, companyID, userID, id string, readAt time.Time) error
+}
@@ -24,6 +24,10 @@ func (uc *RegisterMovementUseCase) RegisterMovementFromRequest(ctx context.Conte
 		Quantity:         in.Quantity,
 		UnitCost:         in.UnitCost,
 		AdjustmentReason: in.AdjustmentReason,
+		Date:             in.Date,
+		AllowBackdated:   in.AllowBackdated,
+		ExpiryDate:       in.ExpiryDate,
+		LotNumber:        in.LotNumber,
 	}
 	return uc.RegisterMovement(ctx, input)
 }
@@ -57,6 +61,10 @@ func (uc *RegisterMovementUseCase) RegisterAdjustmentFromRequest(ctx context.Con
 		Quantity:         in.Quantity,
 		UnitCost:         in.UnitCost,
 		AdjustmentReason: in.AdjustmentReason,
+		Date:             in.Date,
+		AllowBackdated:   in.AllowBackdated,
+		ExpiryDate:       in.ExpiryDate,
+		LotNumber:        in.LotNumber,
 	}
 	if err := uc.RegisterMovement(ctx, input); err != nil {
 		return "", err
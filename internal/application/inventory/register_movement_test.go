@@ -156,6 +156,11 @@ type fakeMovementRepo struct {
 	listFunc            func(companyID string, f repository.MovementFilters) ([]*entity.InventoryMovement, int64, error)
 	listByWarehouseFunc func(warehouseID string, from, to *time.Time, limit, offset int) ([]*entity.InventoryMovement, error)
 	listByProductFunc   func(productID string, from, to *time.Time, limit, offset int) ([]*entity.InventoryMovement, error)
+	listByTxFunc        func(transactionID string) ([]*entity.InventoryMovement, error)
+	markReversedFunc    func(id, reversedBy string, reversedAt time.Time) error
+	listChronoFunc      func(productID string, from, to *time.Time) ([]*entity.InventoryMovement, error)
+	updateCostFunc      func(id string, unitCost, totalCost decimal.Decimal) error
+	lockPeriodFunc      func(companyID string, year, month int) error
 }
 
 func (f *fakeMovementRepo) Create(movement *entity.InventoryMovement) error {
@@ -189,6 +194,38 @@ func (f *fakeMovementRepo) ListByProduct(productID string, from, to *time.Time,
 	return nil, nil
 }
 
+func (f *fakeMovementRepo) ListByTransaction(transactionID string) ([]*entity.InventoryMovement, error) {
+	if f.listByTxFunc != nil {
+		return f.listByTxFunc(transactionID)
+	}
+	return nil, nil
+}
+func (f *fakeMovementRepo) ListByProductChronological(productID string, from, to *time.Time) ([]*entity.InventoryMovement, error) {
+	if f.listChronoFunc != nil {
+		return f.listChronoFunc(productID, from, to)
+	}
+	return nil, nil
+}
+func (f *fakeMovementRepo) UpdateCost(id string, unitCost, totalCost decimal.Decimal) error {
+	if f.updateCostFunc != nil {
+		return f.updateCostFunc(id, unitCost, totalCost)
+	}
+	return nil
+}
+func (f *fakeMovementRepo) MarkReversed(id, reversedBy string, reversedAt time.Time) error {
+	if f.markReversedFunc != nil {
+		return f.markReversedFunc(id, reversedBy, reversedAt)
+	}
+	return nil
+}
+
+func (f *fakeMovementRepo) LockOpenPeriod(companyID string, year, month int) error {
+	if f.lockPeriodFunc != nil {
+		return f.lockPeriodFunc(companyID, year, month)
+	}
+	return nil
+}
+
 var _ repository.InventoryMovementRepository = (*fakeMovementRepo)(nil)
 
 // ── Fake StockRepository ───────────────────────────────────────────────────────
@@ -199,6 +236,12 @@ type fakeStockRepo struct {
 	getSummaryFunc   func(productID, warehouseID string) (*repository.StockSummary, error)
 	getForUpdateFunc func(productID, warehouseID string) (*entity.Stock, error)
 	upsertFunc       func(stock *entity.Stock) error
+	createLotFunc    func(lot *entity.StockLot) error
+	consumeLotsFunc  func(productID, warehouseID string, quantity decimal.Decimal) ([]entity.StockLot, error)
+	consumeMovLots   func(movementID string, quantity decimal.Decimal) ([]entity.StockLot, error)
+	lotBalanceFunc   func(productID, warehouseID string) (decimal.Decimal, error)
+	recordLotsFunc   func(movementID string, lots []entity.StockLot) error
+	restoreLotsFunc  func(movementID string) ([]entity.StockLot, error)
 }
 
 func (f *fakeStockRepo) Get(productID, warehouseID string) (*entity.Stock, error) {
@@ -232,6 +275,43 @@ func (f *fakeStockRepo) Upsert(stock *entity.Stock) error {
 	return nil
 }
 
+func (f *fakeStockRepo) CreateLot(lot *entity.StockLot) error {
+	if f.createLotFunc != nil {
+		return f.createLotFunc(lot)
+	}
+	return nil
+}
+func (f *fakeStockRepo) ConsumeLotsFEFO(productID, warehouseID string, quantity decimal.Decimal) ([]entity.StockLot, error) {
+	if f.consumeLotsFunc != nil {
+		return f.consumeLotsFunc(productID, warehouseID, quantity)
+	}
+	return nil, nil
+}
+func (f *fakeStockRepo) ConsumeMovementLots(movementID string, quantity decimal.Decimal) ([]entity.StockLot, error) {
+	if f.consumeMovLots != nil {
+		return f.consumeMovLots(movementID, quantity)
+	}
+	return nil, nil
+}
+func (f *fakeStockRepo) LotBalance(productID, warehouseID string) (decimal.Decimal, error) {
+	if f.lotBalanceFunc != nil {
+		return f.lotBalanceFunc(productID, warehouseID)
+	}
+	return decimal.Zero, nil
+}
+func (f *fakeStockRepo) RecordLotConsumption(movementID string, lots []entity.StockLot) error {
+	if f.recordLotsFunc != nil {
+		return f.recordLotsFunc(movementID, lots)
+	}
+	return nil
+}
+func (f *fakeStockRepo) RestoreLotConsumption(movementID string) ([]entity.StockLot, error) {
+	if f.restoreLotsFunc != nil {
+		return f.restoreLotsFunc(movementID)
+	}
+	return nil, nil
+}
+
 var _ repository.StockRepository = (*fakeStockRepo)(nil)
 
 // ── Helpers ────────────────────────────────────────────────────────────────────
@@ -670,3 +750,200 @@ func TestRegisterMovementUseCase_RegisterMovementFromRequest(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+// ── Tests lotes con vencimiento ────────────────────────────────────────────────
+
+func TestRegisterMovementUseCase_Lots(t *testing.T) {
+	ctx := context.Background()
+	expiry := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	newUseCase := func(stockRepo *fakeStockRepo, movRepo *fakeMovementRepo) *RegisterMovementUseCase {
+		productRepo := &fakeProductRepo{
+			getByIDFunc:    func(_ string) (*entity.Product, error) { return validProduct(testCompanyID), nil },
+			updateCostFunc: func(_ string, _ decimal.Decimal) error { return nil },
+		}
+		warehouseRepo := &fakeWarehouseRepo{
+			getByIDFunc: func(_ string) (*entity.Warehouse, error) { return validWarehouse(testCompanyID), nil },
+		}
+		txRunner := &fakeTxRunner{
+			runFunc: func(_ context.Context, fn func(
+				repository.InventoryMovementRepository,
+				repository.StockRepository,
+				repository.ProductRepository,
+			) error) error {
+				return fn(movRepo, stockRepo, productRepo)
+			},
+		}
+		return NewRegisterMovementUseCase(txRunner, productRepo, warehouseRepo)
+	}
+
+	t.Run("INWithExpiryCreatesLot", func(t *testing.T) {
+		var lots []*entity.StockLot
+		stockRepo := &fakeStockRepo{
+			getForUpdateFunc: func(_, _ string) (*entity.Stock, error) { return validStock(decimal.Zero), nil },
+			createLotFunc: func(lot *entity.StockLot) error {
+				lots = append(lots, lot)
+				return nil
+			},
+		}
+		movRepo := &fakeMovementRepo{createFunc: func(m *entity.InventoryMovement) error {
+			m.ID = "mov-in"
+			return nil
+		}}
+		input := validRegisterMovementDTO()
+		input.ExpiryDate = &expiry
+		input.LotNumber = " L-001 "
+
+		require.NoError(t, newUseCase(stockRepo, movRepo).RegisterMovement(ctx, input))
+		require.Len(t, lots, 1)
+		assert.Equal(t, "L-001", lots[0].LotNumber)
+		assert.Equal(t, "mov-in", lots[0].MovementID)
+		assert.True(t, lots[0].ExpiryDate.Equal(expiry))
+		assert.True(t, lots[0].Quantity.Equal(decimal.NewFromInt(10)))
+	})
+
+	t.Run("OUTConsumesLotsFEFO", func(t *testing.T) {
+		var consumed decimal.Decimal
+		stockRepo := &fakeStockRepo{
+			getForUpdateFunc: func(_, _ string) (*entity.Stock, error) { return validStock(decimal.NewFromInt(10)), nil },
+			consumeLotsFunc: func(_, warehouseID string, quantity decimal.Decimal) ([]entity.StockLot, error) {
+				assert.Equal(t, testWarehouseID, warehouseID)
+				consumed = quantity
+				return nil, nil
+			},
+		}
+		input := validRegisterMovementDTO()
+		input.Type = string(entity.MovementTypeOUT)
+		input.UnitCost = nil
+		input.Quantity = decimal.NewFromInt(4)
+
+		require.NoError(t, newUseCase(stockRepo, &fakeMovementRepo{}).RegisterMovement(ctx, input))
+		assert.True(t, consumed.Equal(decimal.NewFromInt(4)))
+	})
+
+	t.Run("TransferMovesLotsToDestination", func(t *testing.T) {
+		var created []*entity.StockLot
+		stockRepo := &fakeStockRepo{
+			getForUpdateFunc: func(_, _ string) (*entity.Stock, error) { return validStock(decimal.NewFromInt(10)), nil },
+			consumeLotsFunc: func(_, _ string, _ decimal.Decimal) ([]entity.StockLot, error) {
+				return []entity.StockLot{
+					{CompanyID: testCompanyID, LotNumber: "L-001", ExpiryDate: expiry, Quantity: decimal.NewFromInt(2)},
+					{CompanyID: testCompanyID, LotNumber: "L-002", ExpiryDate: expiry.AddDate(0, 1, 0), Quantity: decimal.NewFromInt(3)},
+				}, nil
+			},
+			createLotFunc: func(lot *entity.StockLot) error {
+				created = append(created, lot)
+				return nil
+			},
+		}
+		movRepo := &fakeMovementRepo{createFunc: func(m *entity.InventoryMovement) error {
+			m.ID = "mov-" + m.WarehouseID
+			return nil
+		}}
+		input := validRegisterMovementDTO()
+		input.Type = string(entity.MovementTypeTRANSFER)
+		input.UnitCost = nil
+		input.Quantity = decimal.NewFromInt(5)
+		input.WarehouseID = ""
+		input.FromWarehouseID = testWarehouseID
+		input.ToWarehouseID = "wh-destino"
+
+		require.NoError(t, newUseCase(stockRepo, movRepo).RegisterMovement(ctx, input))
+		require.Len(t, created, 2)
+		for _, lot := range created {
+			assert.Equal(t, "wh-destino", lot.WarehouseID)
+			assert.Equal(t, "mov-wh-destino", lot.MovementID)
+		}
+		assert.Equal(t, "L-002", created[1].LotNumber)
+		assert.True(t, created[1].Quantity.Equal(decimal.NewFromInt(3)))
+	})
+
+	// Un producto sin lotes sigue saliendo del stock como antes: mismo saldo, mismos movimientos y
+	// ningún lote creado.
+	t.Run("OUTWithoutLotsIsUnchanged", func(t *testing.T) {
+		var upserts []*entity.Stock
+		var movements []*entity.InventoryMovement
+		stockRepo := &fakeStockRepo{
+			getForUpdateFunc: func(_, _ string) (*entity.Stock, error) { return validStock(decimal.NewFromInt(10)), nil },
+			upsertFunc: func(s *entity.Stock) error {
+				upserts = append(upserts, s)
+				return nil
+			},
+			createLotFunc: func(*entity.StockLot) error {
+				t.Fatal("OUT sin lotes no debe crear lotes")
+				return nil
+			},
+		}
+		movRepo := &fakeMovementRepo{createFunc: func(m *entity.InventoryMovement) error {
+			movements = append(movements, m)
+			return nil
+		}}
+		input := validRegisterMovementDTO()
+		input.Type = string(entity.MovementTypeOUT)
+		input.UnitCost = nil
+		input.Quantity = decimal.NewFromInt(4)
+
+		require.NoError(t, newUseCase(stockRepo, movRepo).RegisterMovement(ctx, input))
+		require.Len(t, upserts, 1)
+		assert.True(t, upserts[0].Quantity.Equal(decimal.NewFromInt(6)))
+		require.Len(t, movements, 1)
+		assert.Equal(t, entity.MovementTypeOUT, movements[0].Type)
+		assert.True(t, movements[0].Quantity.Equal(decimal.NewFromInt(-4)))
+		assert.True(t, movements[0].UnitCost.Equal(decimal.NewFromInt(5000)))
+	})
+
+	t.Run("TransferWithoutLotsIsUnchanged", func(t *testing.T) {
+		stocks := map[string]decimal.Decimal{}
+		var movements []*entity.InventoryMovement
+		stockRepo := &fakeStockRepo{
+			getForUpdateFunc: func(_, _ string) (*entity.Stock, error) { return validStock(decimal.NewFromInt(10)), nil },
+			upsertFunc: func(s *entity.Stock) error {
+				stocks[s.WarehouseID] = s.Quantity
+				return nil
+			},
+			createLotFunc: func(*entity.StockLot) error {
+				t.Fatal("traslado sin lotes no debe crear lotes en destino")
+				return nil
+			},
+		}
+		movRepo := &fakeMovementRepo{createFunc: func(m *entity.InventoryMovement) error {
+			movements = append(movements, m)
+			return nil
+		}}
+		input := validRegisterMovementDTO()
+		input.Type = string(entity.MovementTypeTRANSFER)
+		input.UnitCost = nil
+		input.Quantity = decimal.NewFromInt(5)
+		input.WarehouseID = ""
+		input.FromWarehouseID = testWarehouseID
+		input.ToWarehouseID = "wh-destino"
+
+		require.NoError(t, newUseCase(stockRepo, movRepo).RegisterMovement(ctx, input))
+		assert.True(t, stocks[testWarehouseID].Equal(decimal.NewFromInt(5)))
+		assert.True(t, stocks["wh-destino"].Equal(decimal.NewFromInt(5)))
+		require.Len(t, movements, 2)
+		assert.Equal(t, testWarehouseID, movements[0].WarehouseID)
+		assert.True(t, movements[0].Quantity.Equal(decimal.NewFromInt(-5)))
+		assert.Equal(t, "wh-destino", movements[1].WarehouseID)
+		assert.True(t, movements[1].Quantity.Equal(decimal.NewFromInt(5)))
+		assert.Equal(t, movements[0].TransactionID, movements[1].TransactionID)
+	})
+
+	t.Run("ExpiryOnOUTIsInvalid", func(t *testing.T) {
+		input := validRegisterMovementDTO()
+		input.Type = string(entity.MovementTypeOUT)
+		input.UnitCost = nil
+		input.ExpiryDate = &expiry
+
+		err := newUseCase(&fakeStockRepo{}, &fakeMovementRepo{}).RegisterMovement(ctx, input)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("LotNumberWithoutExpiryIsInvalid", func(t *testing.T) {
+		input := validRegisterMovementDTO()
+		input.LotNumber = "L-001"
+
+		err := newUseCase(&fakeStockRepo{}, &fakeMovementRepo{}).RegisterMovement(ctx, input)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
@@ -0,0 +1,293 @@
+package inventory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/inventory"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/shopspring/decimal"
+)
+
+// ReverseMovementUseCase revierte movimientos de inventario registrando asientos compensatorios
+// (tipo REVERSAL) en lugar de editar o borrar el original, que queda marcado como revertido.
+type ReverseMovementUseCase struct {
+	txRunner      TxRunner
+	periodRepo    InventoryPeriodRepository
+	stockNotifier StockChangeNotifier
+}
+
+// NewReverseMovementUseCase construye el caso de uso.
+func NewReverseMovementUseCase(txRunner TxRunner) *ReverseMovementUseCase {
+	return &ReverseMovementUseCase{txRunner: txRunner}
+}
+
+// SetPeriodRepository impide revertir movimientos fechados en períodos de inventario cerrados.
+func (uc *ReverseMovementUseCase) SetPeriodRepository(repo InventoryPeriodRepository) {
+	uc.periodRepo = repo
+}
+
+// SetStockChangeNotifier encola los productos/bodegas afectados por la reversión para alertas de stock.
+func (uc *ReverseMovementUseCase) SetStockChangeNotifier(n StockChangeNotifier) {
+	uc.stockNotifier = n
+}
+
+// ReverseMovementInput entrada para revertir un movimiento (MovementID) o una transacción (TransactionID).
+type ReverseMovementInput struct {
+	CompanyID     string
+	UserID        string
+	MovementID    string
+	TransactionID string
+	Reason        string
+}
+
+// ReverseMovement revierte un único movimiento. Los traslados (TRANSFER) se revierten
+// completos (origen y destino) para no descuadrar las bodegas.
+func (uc *ReverseMovementUseCase) ReverseMovement(ctx context.Context, in ReverseMovementInput) (*dto.MovementReversalDTO, error) {
+	if in.CompanyID == "" || strings.TrimSpace(in.MovementID) == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	var out *dto.MovementReversalDTO
+	err := uc.txRunner.Run(ctx, func(
+		movRepo repository.InventoryMovementRepository,
+		stockRepo repository.StockRepository,
+		productRepo repository.ProductRepository,
+	) error {
+		original, err := movRepo.GetByID(strings.TrimSpace(in.MovementID))
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			return domain.ErrNotFound
+		}
+		targets := []*entity.InventoryMovement{original}
+		if original.Type == entity.MovementTypeTRANSFER {
+			legs, err := movRepo.ListByTransaction(original.TransactionID)
+			if err != nil {
+				return err
+			}
+			targets = targets[:0]
+			for _, m := range legs {
+				if m.Type == entity.MovementTypeTRANSFER {
+					targets = append(targets, m)
+				}
+			}
+		}
+		out, err = uc.reverse(ctx, movRepo, stockRepo, productRepo, in, targets)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.stockChanged(in.CompanyID, out)
+	return out, nil
+}
+
+// ReverseTransaction revierte todos los movimientos de una transacción en una sola operación atómica.
+// Falla con ErrConflict si alguno de ellos ya fue revertido.
+func (uc *ReverseMovementUseCase) ReverseTransaction(ctx context.Context, in ReverseMovementInput) (*dto.MovementReversalDTO, error) {
+	if in.CompanyID == "" || strings.TrimSpace(in.TransactionID) == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	var out *dto.MovementReversalDTO
+	err := uc.txRunner.Run(ctx, func(
+		movRepo repository.InventoryMovementRepository,
+		stockRepo repository.StockRepository,
+		productRepo repository.ProductRepository,
+	) error {
+		movements, err := movRepo.ListByTransaction(strings.TrimSpace(in.TransactionID))
+		if err != nil {
+			return err
+		}
+		if len(movements) == 0 {
+			return domain.ErrNotFound
+		}
+		out, err = uc.reverse(ctx, movRepo, stockRepo, productRepo, in, movements)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.stockChanged(in.CompanyID, out)
+	return out, nil
+}
+
+// stockChanged encola para alertas de stock los productos/bodegas de la reversión ya confirmada.
+func (uc *ReverseMovementUseCase) stockChanged(companyID string, out *dto.MovementReversalDTO) {
+	if uc.stockNotifier == nil {
+		return
+	}
+	for _, m := range out.Movements {
+		uc.stockNotifier.Enqueue(companyID, m.ProductID, m.WarehouseID)
+	}
+}
+
+// reverse aplica los asientos compensatorios dentro de la transacción del caller.
+// Primero procesa las entradas (que restan stock) para fallar pronto por stock insuficiente.
+func (uc *ReverseMovementUseCase) reverse(
+	ctx context.Context,
+	movRepo repository.InventoryMovementRepository,
+	stockRepo repository.StockRepository,
+	productRepo repository.ProductRepository,
+	in ReverseMovementInput,
+	targets []*entity.InventoryMovement,
+) (*dto.MovementReversalDTO, error) {
+	products := make(map[string]*entity.Product)
+	for _, m := range targets {
+		if m.Type == entity.MovementTypeReversal {
+			return nil, domain.ErrInvalidInput
+		}
+		if m.IsReversed() {
+			return nil, domain.ErrConflict
+		}
+		key, err := checkMovementDate(ctx, uc.periodRepo, in.CompanyID, m.Date)
+		if err != nil {
+			return nil, err
+		}
+		if err := lockMovementPeriod(movRepo, in.CompanyID, key); err != nil {
+			return nil, err
+		}
+		if _, ok := products[m.ProductID]; ok {
+			continue
+		}
+		product, err := productRepo.GetByID(m.ProductID)
+		if err != nil || product == nil {
+			return nil, domain.ErrNotFound
+		}
+		if product.CompanyID != in.CompanyID {
+			return nil, domain.ErrForbidden
+		}
+		products[m.ProductID] = product
+	}
+
+	ordered := make([]*entity.InventoryMovement, len(targets))
+	copy(ordered, targets)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Quantity.GreaterThan(decimal.Zero) && !ordered[j].Quantity.GreaterThan(decimal.Zero)
+	})
+
+	now := time.Now()
+	txID := uuid.New().String()
+	notes := strings.TrimSpace(in.Reason)
+	out := &dto.MovementReversalDTO{TransactionID: txID, Movements: make([]dto.MovementDTO, 0, len(ordered))}
+
+	for _, original := range ordered {
+		product := products[original.ProductID]
+		stock, err := stockRepo.GetForUpdate(original.ProductID, original.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+		qty := original.Quantity.Neg()
+		if qty.LessThan(decimal.Zero) && stock.Quantity.LessThan(original.Quantity) {
+			return nil, domain.ErrInsufficientStock
+		}
+
+		// Solo IN, OUT y ADJUSTMENT afectaron el costo promedio; TRANSFER y RETURN mueven cantidades.
+		if affectsAverageCost(original.Type) {
+			var newCost decimal.Decimal
+			if qty.LessThan(decimal.Zero) {
+				newCost = inventory.ReverseCostCalculator(stock.Quantity, product.Cost, original.Quantity, original.UnitCost)
+			} else {
+				newCost = inventory.CostCalculator(stock.Quantity, product.Cost, qty, original.UnitCost)
+			}
+			if err := productRepo.UpdateCost(product.ID, newCost); err != nil {
+				return nil, err
+			}
+			product.Cost = newCost
+		}
+
+		stock.Quantity = stock.Quantity.Add(qty)
+		stock.UpdatedAt = now
+		if err := stockRepo.Upsert(stock); err != nil {
+			return nil, err
+		}
+		// Revertir una salida devuelve a sus lotes lo que consumió; revertir una entrada consume los lotes
+		// que ella creó.
+		var lots []entity.StockLot
+		if qty.LessThan(decimal.Zero) {
+			if lots, err = reverseEntryLots(stockRepo, original, stock.Quantity); err != nil {
+				return nil, err
+			}
+		} else if _, err := stockRepo.RestoreLotConsumption(original.ID); err != nil {
+			return nil, err
+		}
+
+		mov := &entity.InventoryMovement{
+			TransactionID: txID,
+			ProductID:     original.ProductID,
+			WarehouseID:   original.WarehouseID,
+			Type:          entity.MovementTypeReversal,
+			Quantity:      qty,
+			UnitCost:      original.UnitCost,
+			TotalCost:     qty.Mul(original.UnitCost),
+			Notes:         notes,
+			Date:          now,
+			CreatedAt:     now,
+			CreatedBy:     in.UserID,
+			ReversalOfID:  original.ID,
+		}
+		if err := movRepo.Create(mov); err != nil {
+			return nil, err
+		}
+		if err := stockRepo.RecordLotConsumption(mov.ID, lots); err != nil {
+			return nil, err
+		}
+		if err := movRepo.MarkReversed(original.ID, in.UserID, now); err != nil {
+			return nil, err
+		}
+		out.Movements = append(out.Movements, dto.MovementDTO{
+			ID:            mov.ID,
+			TransactionID: mov.TransactionID,
+			ProductID:     mov.ProductID,
+			WarehouseID:   mov.WarehouseID,
+			Type:          string(mov.Type),
+			Quantity:      mov.Quantity,
+			Balance:       stock.Quantity,
+			UnitCost:      mov.UnitCost,
+			TotalCost:     mov.TotalCost,
+			Notes:         mov.Notes,
+			Date:          mov.Date,
+			CreatedAt:     mov.CreatedAt,
+			CreatedBy:     mov.CreatedBy,
+			ReversalOfID:  mov.ReversalOfID,
+		})
+	}
+	return out, nil
+}
+
+// reverseEntryLots consume los lotes que creó la entrada original. Si parte de esos lotes ya salió,
+// el resto se descuenta del stock sin lote y solo se toman otros lotes (FEFO) cuando el saldo en lotes
+// quedaría por encima del stock resultante (balance).
+func reverseEntryLots(stockRepo repository.StockRepository, original *entity.InventoryMovement, balance decimal.Decimal) ([]entity.StockLot, error) {
+	lots, err := stockRepo.ConsumeMovementLots(original.ID, original.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	inLots, err := stockRepo.LotBalance(original.ProductID, original.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if excess := inLots.Sub(balance); excess.GreaterThan(decimal.Zero) {
+		more, err := stockRepo.ConsumeLotsFEFO(original.ProductID, original.WarehouseID, excess)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, more...)
+	}
+	return lots, nil
+}
+
+// affectsAverageCost indica si el tipo de movimiento participó en el costo promedio ponderado.
+func affectsAverageCost(t entity.MovementType) bool {
+	switch t {
+	case entity.MovementTypeIN, entity.MovementTypeOUT, entity.MovementTypeADJUSTMENT:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,340 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+// reversalFixture agrupa los fakes usados por los tests de reversión.
+type reversalFixture struct {
+	movements map[string]*entity.InventoryMovement
+	stock     map[string]decimal.Decimal
+	product   *entity.Product
+	created   []*entity.InventoryMovement
+	reversed  []string
+	// lots en orden de vencimiento; consumed: porciones de lote consumidas por movimiento.
+	lots     []*entity.StockLot
+	consumed map[string][]entity.StockLot
+}
+
+func newReversalFixture(stock decimal.Decimal, movements ...*entity.InventoryMovement) *reversalFixture {
+	f := &reversalFixture{
+		movements: make(map[string]*entity.InventoryMovement),
+		stock:     map[string]decimal.Decimal{testWarehouseID: stock},
+		product:   validProduct(testCompanyID),
+		consumed:  make(map[string][]entity.StockLot),
+	}
+	for _, m := range movements {
+		f.movements[m.ID] = m
+	}
+	return f
+}
+
+func (f *reversalFixture) txRunner() TxRunner {
+	movRepo := &fakeMovementRepo{
+		getByIDFunc: func(id string) (*entity.InventoryMovement, error) {
+			return f.movements[id], nil
+		},
+		listByTxFunc: func(transactionID string) ([]*entity.InventoryMovement, error) {
+			var out []*entity.InventoryMovement
+			for _, m := range f.movements {
+				if m.TransactionID == transactionID {
+					out = append(out, m)
+				}
+			}
+			return out, nil
+		},
+		createFunc: func(m *entity.InventoryMovement) error {
+			m.ID = fmt.Sprintf("rev-%d", len(f.created)+1)
+			f.created = append(f.created, m)
+			return nil
+		},
+		markReversedFunc: func(id, _ string, at time.Time) error {
+			m := f.movements[id]
+			if m.ReversedAt != nil {
+				return domain.ErrConflict
+			}
+			m.ReversedAt = &at
+			f.reversed = append(f.reversed, id)
+			return nil
+		},
+	}
+	stockRepo := &fakeStockRepo{
+		getForUpdateFunc: func(productID, warehouseID string) (*entity.Stock, error) {
+			return &entity.Stock{ProductID: productID, WarehouseID: warehouseID, Quantity: f.stock[warehouseID]}, nil
+		},
+		upsertFunc: func(s *entity.Stock) error {
+			f.stock[s.WarehouseID] = s.Quantity
+			return nil
+		},
+		consumeLotsFunc: func(productID, warehouseID string, quantity decimal.Decimal) ([]entity.StockLot, error) {
+			return f.consumeLots(func(l *entity.StockLot) bool {
+				return l.ProductID == productID && l.WarehouseID == warehouseID
+			}, quantity), nil
+		},
+		consumeMovLots: func(movementID string, quantity decimal.Decimal) ([]entity.StockLot, error) {
+			return f.consumeLots(func(l *entity.StockLot) bool { return l.MovementID == movementID }, quantity), nil
+		},
+		lotBalanceFunc: func(productID, warehouseID string) (decimal.Decimal, error) {
+			total := decimal.Zero
+			for _, l := range f.lots {
+				if l.ProductID == productID && l.WarehouseID == warehouseID {
+					total = total.Add(l.Quantity)
+				}
+			}
+			return total, nil
+		},
+		recordLotsFunc: func(movementID string, lots []entity.StockLot) error {
+			f.consumed[movementID] = append(f.consumed[movementID], lots...)
+			return nil
+		},
+		restoreLotsFunc: func(movementID string) ([]entity.StockLot, error) {
+			for _, c := range f.consumed[movementID] {
+				f.lot(c.ID).Quantity = f.lot(c.ID).Quantity.Add(c.Quantity)
+			}
+			return f.consumed[movementID], nil
+		},
+	}
+	productRepo := &fakeProductRepo{
+		getByIDFunc: func(id string) (*entity.Product, error) {
+			return f.product, nil
+		},
+		updateCostFunc: func(_ string, cost decimal.Decimal) error {
+			f.product.Cost = cost
+			return nil
+		},
+	}
+	return &fakeTxRunner{
+		runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository,
+			repository.StockRepository,
+			repository.ProductRepository,
+		) error) error {
+			return fn(movRepo, stockRepo, productRepo)
+		},
+	}
+}
+
+// consumeLots descuenta quantity de los lotes que cumplen match, en el orden de f.lots.
+func (f *reversalFixture) consumeLots(match func(*entity.StockLot) bool, quantity decimal.Decimal) []entity.StockLot {
+	var out []entity.StockLot
+	for _, l := range f.lots {
+		if !quantity.GreaterThan(decimal.Zero) {
+			break
+		}
+		if !match(l) || !l.Quantity.GreaterThan(decimal.Zero) {
+			continue
+		}
+		take := decimal.Min(l.Quantity, quantity)
+		l.Quantity = l.Quantity.Sub(take)
+		quantity = quantity.Sub(take)
+		portion := *l
+		portion.Quantity = take
+		out = append(out, portion)
+	}
+	return out
+}
+
+func (f *reversalFixture) lot(id string) *entity.StockLot {
+	for _, l := range f.lots {
+		if l.ID == id {
+			return l
+		}
+	}
+	return nil
+}
+
+func stockLot(id, warehouseID, movementID string, qty int64) *entity.StockLot {
+	return &entity.StockLot{
+		ID: id, ProductID: testProductID, WarehouseID: warehouseID, MovementID: movementID,
+		InitialQuantity: decimal.NewFromInt(qty), Quantity: decimal.NewFromInt(qty),
+	}
+}
+
+func inMovement(id string, qty, unitCost int64) *entity.InventoryMovement {
+	return &entity.InventoryMovement{
+		ID:            id,
+		TransactionID: "tx-" + id,
+		ProductID:     testProductID,
+		WarehouseID:   testWarehouseID,
+		Type:          entity.MovementTypeIN,
+		Quantity:      decimal.NewFromInt(qty),
+		UnitCost:      decimal.NewFromInt(unitCost),
+		TotalCost:     decimal.NewFromInt(qty * unitCost),
+	}
+}
+
+func TestReverseMovementUseCase_ReverseMovement(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success_IN_RecalculatesAverageCost", func(t *testing.T) {
+		// Stock 15 a costo 6000 incluye una entrada de 5 a 8000 → sin ella quedan 10 a 5000.
+		fx := newReversalFixture(decimal.NewFromInt(15), inMovement("mov-1", 5, 8000))
+		fx.product.Cost = decimal.NewFromInt(6000)
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		out, err := uc.ReverseMovement(ctx, ReverseMovementInput{
+			CompanyID: testCompanyID, UserID: testUserID, MovementID: "mov-1", Reason: "digitación",
+		})
+		require.NoError(t, err)
+		require.Len(t, out.Movements, 1)
+		assert.Equal(t, string(entity.MovementTypeReversal), out.Movements[0].Type)
+		assert.Equal(t, "mov-1", out.Movements[0].ReversalOfID)
+		assert.True(t, out.Movements[0].Quantity.Equal(decimal.NewFromInt(-5)))
+		assert.True(t, fx.stock[testWarehouseID].Equal(decimal.NewFromInt(10)))
+		assert.True(t, fx.product.Cost.Equal(decimal.NewFromInt(5000)), "costo: %s", fx.product.Cost)
+		assert.Equal(t, []string{"mov-1"}, fx.reversed)
+	})
+
+	t.Run("AlreadyReversed_Conflict", func(t *testing.T) {
+		mov := inMovement("mov-1", 5, 8000)
+		at := time.Now()
+		mov.ReversedAt = &at
+		fx := newReversalFixture(decimal.NewFromInt(15), mov)
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		_, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, MovementID: "mov-1"})
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		assert.Empty(t, fx.created)
+	})
+
+	t.Run("ReversalOfReversal_Invalid", func(t *testing.T) {
+		mov := inMovement("mov-1", -5, 8000)
+		mov.Type = entity.MovementTypeReversal
+		fx := newReversalFixture(decimal.NewFromInt(15), mov)
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		_, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, MovementID: "mov-1"})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("InsufficientStock", func(t *testing.T) {
+		fx := newReversalFixture(decimal.NewFromInt(2), inMovement("mov-1", 5, 8000))
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		_, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, MovementID: "mov-1"})
+		assert.ErrorIs(t, err, domain.ErrInsufficientStock)
+	})
+
+	t.Run("OtherCompany_Forbidden", func(t *testing.T) {
+		fx := newReversalFixture(decimal.NewFromInt(15), inMovement("mov-1", 5, 8000))
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		_, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: "other", UserID: testUserID, MovementID: "mov-1"})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		fx := newReversalFixture(decimal.Zero)
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		_, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, MovementID: "missing"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
+
+func TestReverseMovementUseCase_ReverseTransaction_Transfer(t *testing.T) {
+	ctx := context.Background()
+	const destWarehouse = "warehouse-dest"
+
+	out := &entity.InventoryMovement{
+		ID: "leg-out", TransactionID: "tx-1", ProductID: testProductID, WarehouseID: testWarehouseID,
+		Type: entity.MovementTypeTRANSFER, Quantity: decimal.NewFromInt(-4), UnitCost: decimal.NewFromInt(5000),
+	}
+	in := &entity.InventoryMovement{
+		ID: "leg-in", TransactionID: "tx-1", ProductID: testProductID, WarehouseID: destWarehouse,
+		Type: entity.MovementTypeTRANSFER, Quantity: decimal.NewFromInt(4), UnitCost: decimal.NewFromInt(5000),
+	}
+	fx := newReversalFixture(decimal.NewFromInt(6), out, in)
+	fx.stock[destWarehouse] = decimal.NewFromInt(4)
+	// El traslado llevó 4 de lot-a a lot-a-dest.
+	fx.lots = []*entity.StockLot{stockLot("lot-a", testWarehouseID, "mov-0", 2), stockLot("lot-a-dest", destWarehouse, "leg-in", 4)}
+	fx.consumed["leg-out"] = []entity.StockLot{{ID: "lot-a", Quantity: decimal.NewFromInt(4)}}
+	uc := NewReverseMovementUseCase(fx.txRunner())
+
+	res, err := uc.ReverseTransaction(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, TransactionID: "tx-1"})
+	require.NoError(t, err)
+	require.Len(t, res.Movements, 2)
+	assert.True(t, fx.stock[testWarehouseID].Equal(decimal.NewFromInt(10)))
+	assert.True(t, fx.stock[destWarehouse].IsZero())
+	assert.True(t, fx.product.Cost.Equal(decimal.NewFromInt(5000)), "TRANSFER no altera el costo")
+	assert.ElementsMatch(t, []string{"leg-out", "leg-in"}, fx.reversed)
+	assert.True(t, fx.lot("lot-a").Quantity.Equal(decimal.NewFromInt(6)))
+	assert.True(t, fx.lot("lot-a-dest").Quantity.IsZero())
+
+	_, err = uc.ReverseTransaction(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, TransactionID: "tx-1"})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+}
+
+func TestReverseMovementUseCase_Lots(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OUT_RestoresConsumedLots", func(t *testing.T) {
+		// La salida consumió 3 del lote que vence después; el que vence primero no se toca.
+		out := inMovement("mov-out", -3, 5000)
+		out.Type = entity.MovementTypeOUT
+		fx := newReversalFixture(decimal.NewFromInt(7), out)
+		fx.lots = []*entity.StockLot{stockLot("lot-a", testWarehouseID, "mov-a", 5), stockLot("lot-b", testWarehouseID, "mov-b", 2)}
+		fx.lots[1].InitialQuantity = decimal.NewFromInt(5)
+		fx.consumed["mov-out"] = []entity.StockLot{{ID: "lot-b", Quantity: decimal.NewFromInt(3)}}
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		_, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, MovementID: "mov-out"})
+		require.NoError(t, err)
+		assert.True(t, fx.stock[testWarehouseID].Equal(decimal.NewFromInt(10)))
+		assert.True(t, fx.lot("lot-a").Quantity.Equal(decimal.NewFromInt(5)))
+		assert.True(t, fx.lot("lot-b").Quantity.Equal(decimal.NewFromInt(5)))
+	})
+
+	t.Run("IN_ConsumesOwnLot", func(t *testing.T) {
+		// lot-a vence primero, pero la entrada revertida creó lot-b.
+		fx := newReversalFixture(decimal.NewFromInt(15), inMovement("mov-1", 5, 5000))
+		fx.lots = []*entity.StockLot{stockLot("lot-a", testWarehouseID, "mov-0", 10), stockLot("lot-b", testWarehouseID, "mov-1", 5)}
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		out, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, MovementID: "mov-1"})
+		require.NoError(t, err)
+		assert.True(t, fx.lot("lot-a").Quantity.Equal(decimal.NewFromInt(10)))
+		assert.True(t, fx.lot("lot-b").Quantity.IsZero())
+		consumed := fx.consumed[out.Movements[0].ID]
+		require.Len(t, consumed, 1)
+		assert.Equal(t, "lot-b", consumed[0].ID)
+		assert.True(t, consumed[0].Quantity.Equal(decimal.NewFromInt(5)))
+	})
+
+	t.Run("IN_PartiallySold_RestFromUnlottedStock", func(t *testing.T) {
+		// Ya salieron 3 de lot-b: quedan 2 en el lote y el resto sale del stock sin lote (15 - 12 en lotes).
+		fx := newReversalFixture(decimal.NewFromInt(15), inMovement("mov-1", 5, 5000))
+		fx.lots = []*entity.StockLot{stockLot("lot-a", testWarehouseID, "mov-0", 10), stockLot("lot-b", testWarehouseID, "mov-1", 2)}
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		_, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, MovementID: "mov-1"})
+		require.NoError(t, err)
+		assert.True(t, fx.stock[testWarehouseID].Equal(decimal.NewFromInt(10)))
+		assert.True(t, fx.lot("lot-a").Quantity.Equal(decimal.NewFromInt(10)))
+		assert.True(t, fx.lot("lot-b").Quantity.IsZero())
+	})
+
+	t.Run("IN_PartiallySold_NotEnoughUnlottedStock", func(t *testing.T) {
+		// Todo el stock está en lotes: lo que falta de lot-b se toma FEFO de lot-a.
+		fx := newReversalFixture(decimal.NewFromInt(12), inMovement("mov-1", 5, 5000))
+		fx.lots = []*entity.StockLot{stockLot("lot-a", testWarehouseID, "mov-0", 10), stockLot("lot-b", testWarehouseID, "mov-1", 2)}
+		uc := NewReverseMovementUseCase(fx.txRunner())
+
+		_, err := uc.ReverseMovement(ctx, ReverseMovementInput{CompanyID: testCompanyID, UserID: testUserID, MovementID: "mov-1"})
+		require.NoError(t, err)
+		assert.True(t, fx.stock[testWarehouseID].Equal(decimal.NewFromInt(7)))
+		assert.True(t, fx.lot("lot-a").Quantity.Equal(decimal.NewFromInt(7)))
+		assert.True(t, fx.lot("lot-b").Quantity.IsZero())
+	})
+}
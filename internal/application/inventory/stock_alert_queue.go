@@ -0,0 +1,63 @@
+package inventory
+
+import "sync"
+
+// StockAlertKey identifica un par producto/bodega pendiente de evaluación.
+type StockAlertKey struct {
+	CompanyID   string
+	ProductID   string
+	WarehouseID string
+}
+
+// StockAlertQueue cola en memoria de pares producto/bodega con cambios de stock.
+// Deduplica claves para evaluar una sola vez aunque haya varios movimientos seguidos.
+type StockAlertQueue struct {
+	mu      sync.Mutex
+	items   []StockAlertKey
+	indexed map[StockAlertKey]struct{}
+}
+
+func NewStockAlertQueue(initialCapacity int) *StockAlertQueue {
+	if initialCapacity <= 0 {
+		initialCapacity = 64
+	}
+	return &StockAlertQueue{
+		items:   make([]StockAlertKey, 0, initialCapacity),
+		indexed: make(map[StockAlertKey]struct{}, initialCapacity),
+	}
+}
+
+var _ StockChangeNotifier = (*StockAlertQueue)(nil)
+
+func (q *StockAlertQueue) Enqueue(companyID, productID, warehouseID string) {
+	if companyID == "" || productID == "" || warehouseID == "" {
+		return
+	}
+	key := StockAlertKey{CompanyID: companyID, ProductID: productID, WarehouseID: warehouseID}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, exists := q.indexed[key]; exists {
+		return
+	}
+	q.items = append(q.items, key)
+	q.indexed[key] = struct{}{}
+}
+
+func (q *StockAlertQueue) Drain(max int) []StockAlertKey {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return nil
+	}
+	if max <= 0 || max > len(q.items) {
+		max = len(q.items)
+	}
+
+	batch := append([]StockAlertKey(nil), q.items[:max]...)
+	q.items = q.items[max:]
+	for _, k := range batch {
+		delete(q.indexed, k)
+	}
+	return batch
+}
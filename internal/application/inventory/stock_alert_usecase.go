@@ -0,0 +1,428 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/shopspring/decimal"
+)
+
+// NotificationKindStockAlert tipo de notificación in-app para alertas de stock.
+const NotificationKindStockAlert = "STOCK_ALERT"
+
+// defaultExpiryAlertDays días de anticipación con que se alerta el vencimiento de un lote.
+const defaultExpiryAlertDays = 30
+
+// expiryAlertBatch lotes por evaluación de vencimientos.
+const expiryAlertBatch = 500
+
+// StockAlertUseCase detecta productos que cruzan por debajo del punto de reorden o del stock
+// mínimo en una bodega y lotes próximos a vencer o vencidos, deduplica las alertas abiertas y
+// notifica a los usuarios suscritos por email e in-app según sus preferencias.
+type StockAlertUseCase struct {
+	alertRepo        StockAlertRepository
+	notificationRepo NotificationRepository
+	warehouseRepo    repository.WarehouseRepository
+	mailSender       EmailSender
+	expiryDays       int
+}
+
+// NewStockAlertUseCase construye el caso de uso. mailSender puede ser nil (solo notificación in-app).
+func NewStockAlertUseCase(
+	alertRepo StockAlertRepository,
+	notificationRepo NotificationRepository,
+	warehouseRepo repository.WarehouseRepository,
+	mailSender EmailSender,
+) *StockAlertUseCase {
+	return &StockAlertUseCase{
+		alertRepo:        alertRepo,
+		notificationRepo: notificationRepo,
+		warehouseRepo:    warehouseRepo,
+		mailSender:       mailSender,
+		expiryDays:       defaultExpiryAlertDays,
+	}
+}
+
+// SetExpiryWindow fija con cuántos días de anticipación se alerta el vencimiento de un lote (<= 0 = 30).
+func (uc *StockAlertUseCase) SetExpiryWindow(days int) {
+	if days <= 0 {
+		days = defaultExpiryAlertDays
+	}
+	uc.expiryDays = days
+}
+
+// Evaluate compara el stock actual del producto en la bodega con sus umbrales:
+// abre (y notifica) una alerta por cada umbral cruzado que no tenga otra abierta,
+// y resuelve las alertas abiertas cuyo stock ya se recuperó.
+func (uc *StockAlertUseCase) Evaluate(ctx context.Context, companyID, productID, warehouseID string) error {
+	level, err := uc.alertRepo.GetThresholds(ctx, companyID, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+	if level == nil {
+		return nil
+	}
+	open, err := uc.alertRepo.ListOpenFor(ctx, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+	openByKind := make(map[string]*entity.StockAlert, len(open))
+	for _, a := range open {
+		openByKind[a.Kind] = a
+	}
+
+	now := time.Now()
+	thresholds := []struct {
+		kind  string
+		value decimal.Decimal
+	}{
+		{entity.StockAlertKindMinStock, level.MinStock},
+		{entity.StockAlertKindReorderPoint, level.ReorderPoint},
+	}
+	for _, th := range thresholds {
+		below := th.value.GreaterThan(decimal.Zero) && level.Quantity.LessThan(th.value)
+		existing := openByKind[th.kind]
+		switch {
+		case below && existing == nil:
+			alert := &entity.StockAlert{
+				ID:            uuid.New().String(),
+				CompanyID:     companyID,
+				ProductID:     productID,
+				WarehouseID:   warehouseID,
+				Kind:          th.kind,
+				Quantity:      level.Quantity,
+				Threshold:     th.value,
+				Status:        entity.StockAlertStatusOpen,
+				CreatedAt:     now,
+				ProductName:   level.ProductName,
+				SKU:           level.SKU,
+				WarehouseName: level.WarehouseName,
+			}
+			created, err := uc.alertRepo.CreateIfAbsent(ctx, alert)
+			if err != nil {
+				return err
+			}
+			if created {
+				uc.notify(ctx, alert)
+			}
+		case !below && existing != nil:
+			if err := uc.alertRepo.Resolve(ctx, existing.ID, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EvaluateExpiry alerta los lotes con saldo que vencen dentro de la ventana (NEAR_EXPIRY) o que ya
+// vencieron (EXPIRED), una vez por lote y tipo, y resuelve las alertas de los lotes agotados. Al
+// vencer un lote, su alerta NEAR_EXPIRY se resuelve en favor de la EXPIRED.
+func (uc *StockAlertUseCase) EvaluateExpiry(ctx context.Context, now time.Time) error {
+	if err := uc.alertRepo.ResolveDepletedLotAlerts(ctx, now); err != nil {
+		return err
+	}
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	lots, err := uc.alertRepo.ListExpiringLots(ctx, today, today.AddDate(0, 0, uc.expiryDays+1), expiryAlertBatch)
+	if err != nil {
+		return err
+	}
+	for _, lot := range lots {
+		kind := entity.StockAlertKindNearExpiry
+		if lot.IsExpired(today) {
+			kind = entity.StockAlertKindExpired
+		}
+		expiry := lot.ExpiryDate
+		alert := &entity.StockAlert{
+			ID:            uuid.New().String(),
+			CompanyID:     lot.CompanyID,
+			ProductID:     lot.ProductID,
+			WarehouseID:   lot.WarehouseID,
+			Kind:          kind,
+			Quantity:      lot.Quantity,
+			Threshold:     decimal.NewFromInt(int64(uc.expiryDays)),
+			Status:        entity.StockAlertStatusOpen,
+			CreatedAt:     now,
+			LotID:         lot.ID,
+			LotNumber:     lot.LotNumber,
+			ExpiryDate:    &expiry,
+			ProductName:   lot.ProductName,
+			SKU:           lot.SKU,
+			WarehouseName: lot.WarehouseName,
+		}
+		created, err := uc.alertRepo.CreateIfAbsent(ctx, alert)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+		if kind == entity.StockAlertKindExpired {
+			if err := uc.resolveNearExpiry(ctx, lot, now); err != nil {
+				return err
+			}
+		}
+		uc.notify(ctx, alert)
+	}
+	return nil
+}
+
+// resolveNearExpiry resuelve la alerta NEAR_EXPIRY abierta del lote, si la hay.
+func (uc *StockAlertUseCase) resolveNearExpiry(ctx context.Context, lot *entity.StockLot, now time.Time) error {
+	open, err := uc.alertRepo.ListOpenFor(ctx, lot.ProductID, lot.WarehouseID)
+	if err != nil {
+		return err
+	}
+	for _, a := range open {
+		if a.LotID == lot.ID && a.Kind == entity.StockAlertKindNearExpiry {
+			if err := uc.alertRepo.Resolve(ctx, a.ID, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notify envía la alerta a los suscriptores de la bodega. Los fallos de envío se registran
+// y no interrumpen la evaluación (la alerta ya quedó persistida).
+func (uc *StockAlertUseCase) notify(ctx context.Context, alert *entity.StockAlert) {
+	recipients, err := uc.alertRepo.ListRecipients(ctx, alert.CompanyID, alert.WarehouseID)
+	if err != nil {
+		log.Printf("[STOCK_ALERTS] listar suscriptores de bodega %s: %v", alert.WarehouseID, err)
+		return
+	}
+	title, body := stockAlertMessage(alert)
+	for _, r := range recipients {
+		if r.NotifyInApp && uc.notificationRepo != nil {
+			n := &entity.Notification{
+				ID:          uuid.New().String(),
+				CompanyID:   alert.CompanyID,
+				UserID:      r.UserID,
+				Kind:        NotificationKindStockAlert,
+				Title:       title,
+				Body:        body,
+				ReferenceID: alert.ID,
+				CreatedAt:   alert.CreatedAt,
+			}
+			if err := uc.notificationRepo.Create(ctx, n); err != nil {
+				log.Printf("[STOCK_ALERTS] notificación in-app a %s: %v", r.UserID, err)
+			}
+		}
+		if r.NotifyEmail && uc.mailSender != nil && strings.TrimSpace(r.Email) != "" {
+			if err := uc.mailSender.Send(r.Email, title, body); err != nil {
+				log.Printf("[STOCK_ALERTS] email a %s: %v", r.Email, err)
+			}
+		}
+	}
+}
+
+func stockAlertMessage(alert *entity.StockAlert) (string, string) {
+	product := alert.ProductName
+	if product == "" {
+		product = alert.ProductID
+	}
+	if alert.SKU != "" {
+		product = fmt.Sprintf("%s (%s)", product, alert.SKU)
+	}
+	warehouse := alert.WarehouseName
+	if warehouse == "" {
+		warehouse = alert.WarehouseID
+	}
+	switch alert.Kind {
+	case entity.StockAlertKindNearExpiry, entity.StockAlertKindExpired:
+		return lotExpiryMessage(alert, product, warehouse)
+	}
+	threshold := "punto de reorden"
+	if alert.Kind == entity.StockAlertKindMinStock {
+		threshold = "stock mínimo"
+	}
+	title := fmt.Sprintf("Stock bajo: %s", product)
+	body := fmt.Sprintf(
+		"El producto %s en la bodega %s quedó por debajo del %s.\nStock actual: %s\nUmbral: %s\n",
+		product, warehouse, threshold, alert.Quantity.String(), alert.Threshold.String(),
+	)
+	return title, body
+}
+
+func lotExpiryMessage(alert *entity.StockAlert, product, warehouse string) (string, string) {
+	lot := "sin número"
+	if alert.LotNumber != "" {
+		lot = alert.LotNumber
+	}
+	expiry := ""
+	if alert.ExpiryDate != nil {
+		expiry = alert.ExpiryDate.Format("2006-01-02")
+	}
+	if alert.Kind == entity.StockAlertKindExpired {
+		title := fmt.Sprintf("Lote vencido: %s", product)
+		body := fmt.Sprintf(
+			"El lote %s del producto %s en la bodega %s venció el %s.\nSaldo del lote: %s\n",
+			lot, product, warehouse, expiry, alert.Quantity.String(),
+		)
+		return title, body
+	}
+	title := fmt.Sprintf("Lote próximo a vencer: %s", product)
+	body := fmt.Sprintf(
+		"El lote %s del producto %s en la bodega %s vence el %s (alerta con %s días de anticipación).\nSaldo del lote: %s\n",
+		lot, product, warehouse, expiry, alert.Threshold.String(), alert.Quantity.String(),
+	)
+	return title, body
+}
+
+// ListAlerts lista las alertas de la empresa filtrando por estado (OPEN por defecto) y bodega opcional.
+func (uc *StockAlertUseCase) ListAlerts(ctx context.Context, companyID, status, warehouseID string, limit, offset int) ([]dto.StockAlertDTO, error) {
+	if companyID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch status {
+	case "":
+		status = entity.StockAlertStatusOpen
+	case entity.StockAlertStatusOpen, entity.StockAlertStatusResolved, "ALL":
+	default:
+		return nil, domain.ErrInvalidInput
+	}
+	if status == "ALL" {
+		status = ""
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	alerts, err := uc.alertRepo.ListByCompany(ctx, companyID, status, strings.TrimSpace(warehouseID), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.StockAlertDTO, 0, len(alerts))
+	for _, a := range alerts {
+		out = append(out, dto.StockAlertDTO{
+			ID:            a.ID,
+			ProductID:     a.ProductID,
+			SKU:           a.SKU,
+			ProductName:   a.ProductName,
+			WarehouseID:   a.WarehouseID,
+			WarehouseName: a.WarehouseName,
+			Kind:          a.Kind,
+			Quantity:      a.Quantity,
+			Threshold:     a.Threshold,
+			Status:        a.Status,
+			CreatedAt:     a.CreatedAt,
+			ResolvedAt:    a.ResolvedAt,
+			LotNumber:     a.LotNumber,
+			ExpiryDate:    a.ExpiryDate,
+		})
+	}
+	return out, nil
+}
+
+// ListSubscriptions devuelve las preferencias de alertas del usuario.
+func (uc *StockAlertUseCase) ListSubscriptions(ctx context.Context, companyID, userID string) ([]dto.StockAlertSubscriptionDTO, error) {
+	if companyID == "" || userID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	subs, err := uc.alertRepo.ListSubscriptions(ctx, companyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.StockAlertSubscriptionDTO, 0, len(subs))
+	for _, s := range subs {
+		out = append(out, dto.StockAlertSubscriptionDTO{
+			WarehouseID: s.WarehouseID,
+			NotifyEmail: s.NotifyEmail,
+			NotifyInApp: s.NotifyInApp,
+		})
+	}
+	return out, nil
+}
+
+// UpdateSubscriptions reemplaza las preferencias del usuario. Valida que cada bodega pertenezca
+// a la empresa y que no haya bodegas repetidas. Una lista vacía cancela todas las suscripciones.
+func (uc *StockAlertUseCase) UpdateSubscriptions(ctx context.Context, companyID, userID string, in dto.UpdateStockAlertSubscriptionsRequest) ([]dto.StockAlertSubscriptionDTO, error) {
+	if companyID == "" || userID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	now := time.Now()
+	seen := make(map[string]struct{}, len(in.Subscriptions))
+	subs := make([]*entity.StockAlertSubscription, 0, len(in.Subscriptions))
+	for _, s := range in.Subscriptions {
+		warehouseID := strings.TrimSpace(s.WarehouseID)
+		if _, dup := seen[warehouseID]; dup {
+			return nil, domain.ErrInvalidInput
+		}
+		seen[warehouseID] = struct{}{}
+		if !s.NotifyEmail && !s.NotifyInApp {
+			continue
+		}
+		if warehouseID != "" {
+			wh, err := uc.warehouseRepo.GetByID(warehouseID)
+			if err != nil {
+				return nil, err
+			}
+			if wh == nil || wh.CompanyID != companyID {
+				return nil, domain.ErrNotFound
+			}
+		}
+		subs = append(subs, &entity.StockAlertSubscription{
+			ID:          uuid.New().String(),
+			CompanyID:   companyID,
+			UserID:      userID,
+			WarehouseID: warehouseID,
+			NotifyEmail: s.NotifyEmail,
+			NotifyInApp: s.NotifyInApp,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+	if err := uc.alertRepo.ReplaceSubscriptions(ctx, companyID, userID, subs); err != nil {
+		return nil, err
+	}
+	return uc.ListSubscriptions(ctx, companyID, userID)
+}
+
+// ListNotifications lista las notificaciones in-app del usuario (más recientes primero).
+func (uc *StockAlertUseCase) ListNotifications(ctx context.Context, companyID, userID string, unreadOnly bool, limit, offset int) ([]dto.NotificationDTO, error) {
+	if companyID == "" || userID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	list, err := uc.notificationRepo.ListByUser(ctx, companyID, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.NotificationDTO, 0, len(list))
+	for _, n := range list {
+		out = append(out, dto.NotificationDTO{
+			ID:          n.ID,
+			Kind:        n.Kind,
+			Title:       n.Title,
+			Body:        n.Body,
+			ReferenceID: n.ReferenceID,
+			ReadAt:      n.ReadAt,
+			CreatedAt:   n.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+// MarkNotificationRead marca como leída una notificación del usuario.
+func (uc *StockAlertUseCase) MarkNotificationRead(ctx context.Context, companyID, userID, id string) error {
+	if companyID == "" || userID == "" || strings.TrimSpace(id) == "" {
+		return domain.ErrInvalidInput
+	}
+	return uc.notificationRepo.MarkRead(ctx, companyID, userID, strings.TrimSpace(id), time.Now())
+}
@@ -0,0 +1,356 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+// ── Fakes ─────────────────────────────────────────────────────────────────────
+
+type fakeStockAlertRepo struct {
+	level      *entity.StockLevelThresholds
+	open       []*entity.StockAlert
+	created    []*entity.StockAlert
+	resolved   []string
+	recipients []entity.StockAlertRecipient
+	subs       []*entity.StockAlertSubscription
+	lots       []*entity.StockLot
+	depleted   int
+}
+
+func (f *fakeStockAlertRepo) GetThresholds(_ context.Context, _, _, _ string) (*entity.StockLevelThresholds, error) {
+	return f.level, nil
+}
+func (f *fakeStockAlertRepo) ListOpenFor(_ context.Context, _, _ string) ([]*entity.StockAlert, error) {
+	return f.open, nil
+}
+func (f *fakeStockAlertRepo) CreateIfAbsent(_ context.Context, a *entity.StockAlert) (bool, error) {
+	for _, o := range f.open {
+		if o.Kind == a.Kind && o.LotID == a.LotID {
+			return false, nil
+		}
+	}
+	f.created = append(f.created, a)
+	f.open = append(f.open, a)
+	return true, nil
+}
+func (f *fakeStockAlertRepo) Resolve(_ context.Context, id string, _ time.Time) error {
+	f.resolved = append(f.resolved, id)
+	return nil
+}
+func (f *fakeStockAlertRepo) ListByCompany(_ context.Context, _, _, _ string, _, _ int) ([]*entity.StockAlert, error) {
+	return f.open, nil
+}
+func (f *fakeStockAlertRepo) ListRecipients(_ context.Context, _, _ string) ([]entity.StockAlertRecipient, error) {
+	return f.recipients, nil
+}
+func (f *fakeStockAlertRepo) ListSubscriptions(_ context.Context, _, _ string) ([]*entity.StockAlertSubscription, error) {
+	return f.subs, nil
+}
+func (f *fakeStockAlertRepo) ReplaceSubscriptions(_ context.Context, _, _ string, subs []*entity.StockAlertSubscription) error {
+	f.subs = subs
+	return nil
+}
+
+func (f *fakeStockAlertRepo) ListExpiringLots(_ context.Context, _, _ time.Time, _ int) ([]*entity.StockLot, error) {
+	return f.lots, nil
+}
+func (f *fakeStockAlertRepo) ResolveDepletedLotAlerts(_ context.Context, _ time.Time) error {
+	f.depleted++
+	return nil
+}
+
+var _ StockAlertRepository = (*fakeStockAlertRepo)(nil)
+
+type fakeNotificationRepo struct {
+	created []*entity.Notification
+}
+
+func (f *fakeNotificationRepo) Create(_ context.Context, n *entity.Notification) error {
+	f.created = append(f.created, n)
+	return nil
+}
+func (f *fakeNotificationRepo) ListByUser(_ context.Context, _, _ string, _ bool, _, _ int) ([]*entity.Notification, error) {
+	return f.created, nil
+}
+func (f *fakeNotificationRepo) MarkRead(_ context.Context, _, _, _ string, _ time.Time) error {
+	return nil
+}
+
+var _ NotificationRepository = (*fakeNotificationRepo)(nil)
+
+type fakeMailSender struct {
+	sent []string
+}
+
+func (f *fakeMailSender) Send(to, subject, body string) error {
+	f.sent = append(f.sent, to)
+	return nil
+}
+
+func lowStockLevel(qty int64) *entity.StockLevelThresholds {
+	return &entity.StockLevelThresholds{
+		CompanyID:     testCompanyID,
+		ProductID:     testProductID,
+		WarehouseID:   testWarehouseID,
+		ProductName:   "Producto Test",
+		SKU:           "SKU-001",
+		WarehouseName: "Bodega Central",
+		Quantity:      decimal.NewFromInt(qty),
+		ReorderPoint:  decimal.NewFromInt(10),
+		MinStock:      decimal.NewFromInt(3),
+	}
+}
+
+// ── Tests Evaluate ────────────────────────────────────────────────────────────
+
+func TestStockAlertUseCase_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	recipients := []entity.StockAlertRecipient{
+		{UserID: "u-email", Email: "bodega@empresa.co", NotifyEmail: true},
+		{UserID: "u-app", Email: "app@empresa.co", NotifyInApp: true},
+	}
+
+	t.Run("CrossingBelowReorderPointNotifies", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{level: lowStockLevel(5), recipients: recipients}
+		notifications := &fakeNotificationRepo{}
+		mailer := &fakeMailSender{}
+		uc := NewStockAlertUseCase(repo, notifications, &fakeWarehouseRepo{}, mailer)
+
+		require.NoError(t, uc.Evaluate(ctx, testCompanyID, testProductID, testWarehouseID))
+		require.Len(t, repo.created, 1)
+		assert.Equal(t, entity.StockAlertKindReorderPoint, repo.created[0].Kind)
+		assert.Equal(t, []string{"bodega@empresa.co"}, mailer.sent)
+		require.Len(t, notifications.created, 1)
+		assert.Equal(t, "u-app", notifications.created[0].UserID)
+		assert.Equal(t, repo.created[0].ID, notifications.created[0].ReferenceID)
+	})
+
+	t.Run("BelowMinStockOpensBothKinds", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{level: lowStockLevel(2)}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, &fakeWarehouseRepo{}, nil)
+
+		require.NoError(t, uc.Evaluate(ctx, testCompanyID, testProductID, testWarehouseID))
+		require.Len(t, repo.created, 2)
+		assert.Equal(t, entity.StockAlertKindMinStock, repo.created[0].Kind)
+	})
+
+	t.Run("OpenAlertIsNotDuplicated", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{
+			level:      lowStockLevel(4),
+			open:       []*entity.StockAlert{{ID: "a-1", Kind: entity.StockAlertKindReorderPoint}},
+			recipients: recipients,
+		}
+		mailer := &fakeMailSender{}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, &fakeWarehouseRepo{}, mailer)
+
+		require.NoError(t, uc.Evaluate(ctx, testCompanyID, testProductID, testWarehouseID))
+		assert.Empty(t, repo.created)
+		assert.Empty(t, mailer.sent)
+	})
+
+	t.Run("RecoveredStockResolves", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{
+			level: lowStockLevel(12),
+			open:  []*entity.StockAlert{{ID: "a-1", Kind: entity.StockAlertKindReorderPoint}},
+		}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, &fakeWarehouseRepo{}, nil)
+
+		require.NoError(t, uc.Evaluate(ctx, testCompanyID, testProductID, testWarehouseID))
+		assert.Equal(t, []string{"a-1"}, repo.resolved)
+		assert.Empty(t, repo.created)
+	})
+
+	t.Run("NoThresholdsConfigured", func(t *testing.T) {
+		level := lowStockLevel(0)
+		level.ReorderPoint = decimal.Zero
+		level.MinStock = decimal.Zero
+		repo := &fakeStockAlertRepo{level: level}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, &fakeWarehouseRepo{}, nil)
+
+		require.NoError(t, uc.Evaluate(ctx, testCompanyID, testProductID, testWarehouseID))
+		assert.Empty(t, repo.created)
+	})
+}
+
+func TestStockAlertUseCase_EvaluateExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	recipients := []entity.StockAlertRecipient{{UserID: "u-email", Email: "bodega@empresa.co", NotifyEmail: true}}
+	lot := func(expiry time.Time) *entity.StockLot {
+		return &entity.StockLot{
+			ID:          "lot-1",
+			CompanyID:   testCompanyID,
+			ProductID:   testProductID,
+			WarehouseID: testWarehouseID,
+			LotNumber:   "L-001",
+			ExpiryDate:  expiry,
+			Quantity:    decimal.NewFromInt(6),
+		}
+	}
+
+	t.Run("NearExpiryNotifies", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{lots: []*entity.StockLot{lot(now.AddDate(0, 0, 10))}, recipients: recipients}
+		mailer := &fakeMailSender{}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, &fakeWarehouseRepo{}, mailer)
+
+		require.NoError(t, uc.EvaluateExpiry(ctx, now))
+		assert.Equal(t, 1, repo.depleted)
+		require.Len(t, repo.created, 1)
+		assert.Equal(t, entity.StockAlertKindNearExpiry, repo.created[0].Kind)
+		assert.Equal(t, "lot-1", repo.created[0].LotID)
+		assert.Equal(t, []string{"bodega@empresa.co"}, mailer.sent)
+	})
+
+	t.Run("ExpiredResolvesNearExpiry", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{
+			lots: []*entity.StockLot{lot(now.AddDate(0, 0, -1))},
+			open: []*entity.StockAlert{{ID: "a-near", Kind: entity.StockAlertKindNearExpiry, LotID: "lot-1"}},
+		}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, &fakeWarehouseRepo{}, nil)
+
+		require.NoError(t, uc.EvaluateExpiry(ctx, now))
+		require.Len(t, repo.created, 1)
+		assert.Equal(t, entity.StockAlertKindExpired, repo.created[0].Kind)
+		assert.Equal(t, []string{"a-near"}, repo.resolved)
+	})
+
+	t.Run("ExpiresTodayIsNotExpired", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{lots: []*entity.StockLot{lot(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC))}}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, &fakeWarehouseRepo{}, nil)
+
+		require.NoError(t, uc.EvaluateExpiry(ctx, now))
+		require.Len(t, repo.created, 1)
+		assert.Equal(t, entity.StockAlertKindNearExpiry, repo.created[0].Kind)
+	})
+
+	t.Run("OpenAlertIsNotDuplicated", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{
+			lots:       []*entity.StockLot{lot(now.AddDate(0, 0, 10))},
+			open:       []*entity.StockAlert{{ID: "a-near", Kind: entity.StockAlertKindNearExpiry, LotID: "lot-1"}},
+			recipients: recipients,
+		}
+		mailer := &fakeMailSender{}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, &fakeWarehouseRepo{}, mailer)
+
+		require.NoError(t, uc.EvaluateExpiry(ctx, now))
+		assert.Empty(t, repo.created)
+		assert.Empty(t, mailer.sent)
+	})
+}
+
+func TestStockAlertUseCase_UpdateSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	warehouses := &fakeWarehouseRepo{
+		getByIDFunc: func(id string) (*entity.Warehouse, error) {
+			if id == testWarehouseID {
+				return validWarehouse(testCompanyID), nil
+			}
+			return validWarehouse("otra-empresa"), nil
+		},
+	}
+
+	t.Run("ForeignWarehouse", func(t *testing.T) {
+		uc := NewStockAlertUseCase(&fakeStockAlertRepo{}, &fakeNotificationRepo{}, warehouses, nil)
+		_, err := uc.UpdateSubscriptions(ctx, testCompanyID, testUserID, dto.UpdateStockAlertSubscriptionsRequest{
+			Subscriptions: []dto.StockAlertSubscriptionDTO{{WarehouseID: "wh-ajena", NotifyEmail: true}},
+		})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("DuplicatedWarehouse", func(t *testing.T) {
+		uc := NewStockAlertUseCase(&fakeStockAlertRepo{}, &fakeNotificationRepo{}, warehouses, nil)
+		_, err := uc.UpdateSubscriptions(ctx, testCompanyID, testUserID, dto.UpdateStockAlertSubscriptionsRequest{
+			Subscriptions: []dto.StockAlertSubscriptionDTO{{NotifyEmail: true}, {NotifyInApp: true}},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		repo := &fakeStockAlertRepo{}
+		uc := NewStockAlertUseCase(repo, &fakeNotificationRepo{}, warehouses, nil)
+		out, err := uc.UpdateSubscriptions(ctx, testCompanyID, testUserID, dto.UpdateStockAlertSubscriptionsRequest{
+			Subscriptions: []dto.StockAlertSubscriptionDTO{
+				{NotifyInApp: true},
+				{WarehouseID: testWarehouseID, NotifyEmail: true, NotifyInApp: true},
+				{WarehouseID: "wh-silenciada"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, out, 2)
+		assert.Equal(t, "", out[0].WarehouseID)
+		assert.Equal(t, testWarehouseID, out[1].WarehouseID)
+	})
+}
+
+// ── Tests cola y enganche con movimientos ─────────────────────────────────────
+
+func TestStockAlertQueue_Deduplicates(t *testing.T) {
+	q := NewStockAlertQueue(0)
+	q.Enqueue(testCompanyID, testProductID, testWarehouseID)
+	q.Enqueue(testCompanyID, testProductID, testWarehouseID)
+	q.Enqueue(testCompanyID, testProductID, "otra-bodega")
+	q.Enqueue(testCompanyID, "", testWarehouseID)
+
+	batch := q.Drain(10)
+	require.Len(t, batch, 2)
+	assert.Equal(t, testWarehouseID, batch[0].WarehouseID)
+	assert.Empty(t, q.Drain(10))
+}
+
+func TestRegisterMovementUseCase_EnqueuesStockChange(t *testing.T) {
+	productRepo := &fakeProductRepo{
+		getByIDFunc: func(id string) (*entity.Product, error) { return validProduct(testCompanyID), nil },
+	}
+	warehouseRepo := &fakeWarehouseRepo{
+		getByIDFunc: func(id string) (*entity.Warehouse, error) { return validWarehouse(testCompanyID), nil },
+	}
+	stockRepo := &fakeStockRepo{
+		getForUpdateFunc: func(productID, warehouseID string) (*entity.Stock, error) {
+			return validStock(decimal.NewFromInt(20)), nil
+		},
+	}
+	queue := NewStockAlertQueue(0)
+	var commitErr error
+	txRunner := &fakeTxRunner{
+		runFunc: func(_ context.Context, fn func(
+			repository.InventoryMovementRepository,
+			repository.StockRepository,
+			repository.ProductRepository,
+		) error) error {
+			if err := fn(&fakeMovementRepo{}, stockRepo, productRepo); err != nil {
+				return err
+			}
+			// Antes del commit el worker no debe ver la clave: evaluaría el stock anterior.
+			assert.Empty(t, queue.Drain(10), "encolado dentro de la transacción")
+			return commitErr
+		},
+	}
+	uc := NewRegisterMovementUseCase(txRunner, productRepo, warehouseRepo)
+	uc.SetStockChangeNotifier(queue)
+
+	in := validRegisterMovementDTO()
+	in.Type = string(entity.MovementTypeOUT)
+	in.UnitCost = nil
+	require.NoError(t, uc.RegisterMovement(context.Background(), in))
+
+	batch := queue.Drain(10)
+	require.Len(t, batch, 1)
+	assert.Equal(t, StockAlertKey{CompanyID: testCompanyID, ProductID: testProductID, WarehouseID: testWarehouseID}, batch[0])
+
+	// Si el commit falla no se encola nada.
+	commitErr = errors.New("commit failed")
+	require.Error(t, uc.RegisterMovement(context.Background(), in))
+	assert.Empty(t, queue.Drain(10))
+}
@@ -0,0 +1,57 @@
+package inventory
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StockAlertWorker evalúa periódicamente los productos/bodegas encolados tras cada movimiento.
+type StockAlertWorker struct {
+	uc        *StockAlertUseCase
+	queue     *StockAlertQueue
+	interval  time.Duration
+	batchSize int
+}
+
+func NewStockAlertWorker(uc *StockAlertUseCase, queue *StockAlertQueue, interval time.Duration, batchSize int) *StockAlertWorker {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+	return &StockAlertWorker{
+		uc:        uc,
+		queue:     queue,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Start ejecuta el worker en un loop hasta que ctx sea cancelado. Debe lanzarse como goroutine.
+func (w *StockAlertWorker) Start(ctx context.Context) {
+	if w.uc == nil || w.queue == nil {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+func (w *StockAlertWorker) runOnce(ctx context.Context) {
+	for _, key := range w.queue.Drain(w.batchSize) {
+		if err := w.uc.Evaluate(ctx, key.CompanyID, key.ProductID, key.WarehouseID); err != nil {
+			log.Printf("[STOCK_ALERTS][WORKER] evaluar producto %s bodega %s: %v", key.ProductID, key.WarehouseID, err)
+		}
+	}
+}
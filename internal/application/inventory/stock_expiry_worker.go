@@ -0,0 +1,47 @@
+package inventory
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StockExpiryWorker evalúa periódicamente el vencimiento de los lotes con saldo de todas las empresas.
+type StockExpiryWorker struct {
+	uc       *StockAlertUseCase
+	interval time.Duration
+}
+
+func NewStockExpiryWorker(uc *StockAlertUseCase, interval time.Duration) *StockExpiryWorker {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &StockExpiryWorker{uc: uc, interval: interval}
+}
+
+// Start ejecuta una evaluación inmediata y luego una por intervalo hasta que ctx sea cancelado.
+// Debe lanzarse como goroutine.
+func (w *StockExpiryWorker) Start(ctx context.Context) {
+	if w.uc == nil {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.runOnce(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+func (w *StockExpiryWorker) runOnce(ctx context.Context) {
+	if err := w.uc.EvaluateExpiry(ctx, time.Now()); err != nil {
+		log.Printf("[STOCK_ALERTS][EXPIRY_WORKER] evaluar vencimientos: %v", err)
+	}
+}
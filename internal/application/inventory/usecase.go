@@ -2,6 +2,7 @@ package inventory
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +19,8 @@ type RegisterMovementUseCase struct {
 	txRunner      TxRunner
 	productRepo   repository.ProductRepository
 	warehouseRepo repository.WarehouseRepository
+	periodRepo    InventoryPeriodRepository
+	stockNotifier StockChangeNotifier
 }
 
 // NewRegisterMovementUseCase construye el caso de uso.
@@ -33,6 +36,28 @@ func NewRegisterMovementUseCase(
 	}
 }
 
+// SetPeriodRepository habilita el control de cierres mensuales (bloqueo de períodos cerrados
+// y recálculo de costo promedio para movimientos retroactivos).
+func (uc *RegisterMovementUseCase) SetPeriodRepository(repo InventoryPeriodRepository) {
+	uc.periodRepo = repo
+}
+
+// SetStockChangeNotifier encola cada producto/bodega afectado para la evaluación de alertas de stock.
+func (uc *RegisterMovementUseCase) SetStockChangeNotifier(n StockChangeNotifier) {
+	uc.stockNotifier = n
+}
+
+// NotifyStockChanged avisa al notificador (si existe) que cambió el stock del producto en la bodega.
+// Solo debe llamarse después del commit: el worker de alertas lee el stock en su propia conexión y
+// descarta la clave al evaluarla, de modo que un aviso previo al commit evaluaría el stock anterior y
+// perdería la alerta. RegisterMovement lo hace por sí mismo; quien use RegisterOUTInTx o
+// RegisterReturnInTx lo invoca tras confirmar su transacción.
+func (uc *RegisterMovementUseCase) NotifyStockChanged(companyID, productID, warehouseID string) {
+	if uc.stockNotifier != nil {
+		uc.stockNotifier.Enqueue(companyID, productID, warehouseID)
+	}
+}
+
 // MovementInputDTO entrada para Registrar un movimiento de inventario.
 // Para IN/OUT/ADJUSTMENT: ProductID, WarehouseID, Type, Quantity; UnitCost obligatorio en IN.
 // Para TRANSFER: ProductID, FromWarehouseID, ToWarehouseID, Type=TRANSFER, Quantity.
@@ -54,6 +79,22 @@ type MovementInputDTO struct {
 	AdjustmentReason string
 	// Notes propaga razón/observaciones al crear el registro en inventory_movements.
 	Notes string
+	// Date fecha contable del movimiento; nil = ahora. Una fecha anterior a hoy es retroactiva
+	// y requiere AllowBackdated (lo decide el handler según el rol del usuario).
+	Date           *time.Time
+	AllowBackdated bool
+	// ExpiryDate registra la entrada (IN o ajuste positivo) como un lote con vencimiento, opcionalmente
+	// con el número de lote del proveedor; sin ExpiryDate el stock entra sin lote.
+	ExpiryDate *time.Time
+	LotNumber  string
+}
+
+// movementDate devuelve la fecha contable del movimiento (Date o now).
+func (in MovementInputDTO) movementDate(now time.Time) time.Time {
+	if in.Date != nil {
+		return *in.Date
+	}
+	return now
 }
 
 // RegisterMovement inicia una transacción, bloquea la fila en inventory_stock (SELECT FOR UPDATE),
@@ -84,6 +125,13 @@ func (uc *RegisterMovementUseCase) RegisterMovement(ctx context.Context, input M
 	default:
 		return domain.ErrInvalidInput
 	}
+	input.LotNumber = strings.TrimSpace(input.LotNumber)
+	if input.ExpiryDate == nil && input.LotNumber != "" {
+		return domain.ErrInvalidInput
+	}
+	if input.ExpiryDate != nil && !receivesStock(input) {
+		return domain.ErrInvalidInput
+	}
 
 	// Validar que producto y bodega(s) existan y sean de la empresa
 	product, err := uc.productRepo.GetByID(input.ProductID)
@@ -93,6 +141,10 @@ func (uc *RegisterMovementUseCase) RegisterMovement(ctx context.Context, input M
 	if product.CompanyID != input.CompanyID {
 		return domain.ErrForbidden
 	}
+	// Un kit no tiene stock propio: sus movimientos se registran sobre los componentes.
+	if product.IsKit() {
+		return domain.ErrInvalidInput
+	}
 
 	if input.Type == string(entity.MovementTypeTRANSFER) {
 		fromWh, _ := uc.warehouseRepo.GetByID(input.FromWarehouseID)
@@ -110,24 +162,103 @@ func (uc *RegisterMovementUseCase) RegisterMovement(ctx context.Context, input M
 	now := time.Now()
 	txID := uuid.New().String()
 
+	backdated, period, err := uc.checkDate(ctx, input, now)
+	if err != nil {
+		return err
+	}
+
 	// Inicia transacción; Commit si todo ok, Rollback si algo falla (TxRunner.Run lo hace)
-	return uc.txRunner.Run(ctx, func(
+	err = uc.txRunner.Run(ctx, func(
 		movRepo repository.InventoryMovementRepository,
 		stockRepo repository.StockRepository,
 		productRepo repository.ProductRepository,
 	) error {
+		if err := lockMovementPeriod(movRepo, input.CompanyID, period); err != nil {
+			return err
+		}
+		var err error
 		switch entity.MovementType(input.Type) {
 		case entity.MovementTypeIN:
-			return uc.doIN(movRepo, stockRepo, productRepo, product, input, now, txID)
+			err = uc.doIN(movRepo, stockRepo, productRepo, product, input, now, txID)
 		case entity.MovementTypeOUT:
-			return uc.doOUT(movRepo, stockRepo, productRepo, product, input, now, txID)
+			err = uc.doOUT(movRepo, stockRepo, productRepo, product, input, now, txID)
 		case entity.MovementTypeADJUSTMENT:
-			return uc.doADJUSTMENT(movRepo, stockRepo, productRepo, product, input, now, txID)
+			err = uc.doADJUSTMENT(movRepo, stockRepo, productRepo, product, input, now, txID)
 		case entity.MovementTypeTRANSFER:
 			return uc.doTRANSFER(movRepo, stockRepo, productRepo, input, now, txID)
+		default:
+			return domain.ErrInvalidInput
 		}
-		return domain.ErrInvalidInput
+		if err != nil || !backdated {
+			return err
+		}
+		return uc.recomputeAverageCost(ctx, movRepo, productRepo, input.CompanyID, input.ProductID)
 	})
+	if err != nil {
+		return err
+	}
+	// Alertas de stock sobre el stock ya confirmado.
+	if input.Type == string(entity.MovementTypeTRANSFER) {
+		uc.NotifyStockChanged(input.CompanyID, input.ProductID, input.FromWarehouseID)
+		uc.NotifyStockChanged(input.CompanyID, input.ProductID, input.ToWarehouseID)
+		return nil
+	}
+	uc.NotifyStockChanged(input.CompanyID, input.ProductID, input.WarehouseID)
+	return nil
+}
+
+// receivesStock indica si el movimiento es una entrada que puede registrar un lote (IN o ajuste positivo).
+func receivesStock(input MovementInputDTO) bool {
+	switch entity.MovementType(input.Type) {
+	case entity.MovementTypeIN:
+		return true
+	case entity.MovementTypeADJUSTMENT:
+		return input.Quantity.GreaterThan(decimal.Zero)
+	}
+	return false
+}
+
+// checkDate valida la fecha contable del movimiento (nil = now): no puede ser futura, si es anterior
+// a hoy (en la zona horaria de la empresa) debe estar autorizada y en ningún caso puede caer en un
+// período cerrado. Indica si es retroactiva y el mes que se debe bloquear en la transacción.
+func (uc *RegisterMovementUseCase) checkDate(ctx context.Context, input MovementInputDTO, now time.Time) (bool, periodKey, error) {
+	date := input.movementDate(now)
+	if date.After(now) {
+		return false, periodKey{}, domain.ErrInvalidInput
+	}
+	loc, err := companyLocation(ctx, uc.periodRepo, input.CompanyID)
+	if err != nil {
+		return false, periodKey{}, err
+	}
+	today := now.In(loc)
+	startOfToday := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	backdated := date.Before(startOfToday)
+	if backdated && !input.AllowBackdated {
+		return false, periodKey{}, domain.ErrForbidden
+	}
+	period, err := checkMovementDate(ctx, uc.periodRepo, input.CompanyID, date)
+	if err != nil {
+		return false, periodKey{}, err
+	}
+	return backdated, period, nil
+}
+
+// recomputeAverageCost reproduce el costo promedio del producto desde el último cierre,
+// reestampa las salidas posteriores al nuevo promedio y actualiza el costo del producto.
+func (uc *RegisterMovementUseCase) recomputeAverageCost(
+	ctx context.Context,
+	movRepo repository.InventoryMovementRepository,
+	productRepo repository.ProductRepository,
+	companyID, productID string,
+) error {
+	_, cost, err := openingAndReplay(ctx, uc.periodRepo, movRepo, companyID, productID, time.Time{},
+		func(m *entity.InventoryMovement, unitCost decimal.Decimal) error {
+			return movRepo.UpdateCost(m.ID, unitCost, m.Quantity.Mul(unitCost))
+		})
+	if err != nil {
+		return err
+	}
+	return productRepo.UpdateCost(productID, cost)
 }
 
 // doIN: bloquea fila (GetForUpdate), CostCalculator, actualiza costo producto, suma stock, guarda movimiento.
@@ -169,16 +300,33 @@ func (uc *RegisterMovementUseCase) doIN(
 		UnitCost:      unitCost,
 		TotalCost:     input.Quantity.Mul(unitCost),
 		Notes:         input.Notes,
-		Date:          now,
+		Date:          input.movementDate(now),
 		CreatedAt:     now,
 		CreatedBy:     input.UserID,
 	}
-	return movRepo.Create(mov)
+	if err := movRepo.Create(mov); err != nil {
+		return err
+	}
+	if input.ExpiryDate == nil {
+		return nil
+	}
+	return stockRepo.CreateLot(&entity.StockLot{
+		CompanyID:       input.CompanyID,
+		ProductID:       input.ProductID,
+		WarehouseID:     input.WarehouseID,
+		LotNumber:       input.LotNumber,
+		ExpiryDate:      *input.ExpiryDate,
+		InitialQuantity: input.Quantity,
+		Quantity:        input.Quantity,
+		MovementID:      mov.ID,
+		CreatedAt:       now,
+	})
 }
 
 // RegisterReturnInTx registra una devolución de venta (RETURN) reutilizando la transacción del caller.
 // A diferencia de un movimiento IN normal, no recalcula el costo promedio del producto.
-// Se usa desde facturación electrónica al emitir una Nota Crédito.
+// Se usa desde facturación electrónica al emitir una Nota Crédito; el caller avisa el cambio de stock
+// con NotifyStockChanged después del commit.
 func (uc *RegisterMovementUseCase) RegisterReturnInTx(
 	ctx context.Context,
 	movRepo repository.InventoryMovementRepository,
@@ -217,7 +365,8 @@ func (uc *RegisterMovementUseCase) RegisterReturnInTx(
 
 // RegisterOUTInTx ejecuta una salida (OUT) usando los repositorios proporcionados (misma transacción del caller).
 // Implementa la interfaz billing.InventoryUseCase para integración facturación-inventario.
-// ctx propaga la transacción SQL; transactionID suele ser el ID de la factura.
+// ctx propaga la transacción SQL; transactionID suele ser el ID de la factura. El caller avisa el
+// cambio de stock con NotifyStockChanged después del commit.
 func (uc *RegisterMovementUseCase) RegisterOUTInTx(
 	ctx context.Context,
 	movRepo repository.InventoryMovementRepository,
@@ -241,6 +390,10 @@ func (uc *RegisterMovementUseCase) RegisterOUTInTx(
 	if err := stockRepo.Upsert(stock); err != nil {
 		return err
 	}
+	lots, err := stockRepo.ConsumeLotsFEFO(productID, warehouseID, quantity)
+	if err != nil {
+		return err
+	}
 	unitCost := product.Cost
 	mov := &entity.InventoryMovement{
 		TransactionID: transactionID,
@@ -254,10 +407,14 @@ func (uc *RegisterMovementUseCase) RegisterOUTInTx(
 		CreatedAt:     now,
 		CreatedBy:     userID,
 	}
-	return movRepo.Create(mov)
+	if err := movRepo.Create(mov); err != nil {
+		return err
+	}
+	return stockRepo.RecordLotConsumption(mov.ID, lots)
 }
 
-// doOUT: bloquea fila, verifica StockActual >= CantidadSolicitada, resta cantidad, guarda movimiento al costo promedio actual.
+// doOUT: bloquea fila, verifica StockActual >= CantidadSolicitada, resta cantidad (consumiendo lotes FEFO),
+// guarda movimiento al costo promedio actual y registra qué lotes consumió para poder revertirlo.
 func (uc *RegisterMovementUseCase) doOUT(
 	movRepo repository.InventoryMovementRepository,
 	stockRepo repository.StockRepository,
@@ -279,6 +436,10 @@ func (uc *RegisterMovementUseCase) doOUT(
 	if err := stockRepo.Upsert(stock); err != nil {
 		return err
 	}
+	lots, err := stockRepo.ConsumeLotsFEFO(input.ProductID, input.WarehouseID, input.Quantity)
+	if err != nil {
+		return err
+	}
 	unitCost := product.Cost
 	mov := &entity.InventoryMovement{
 		ID:            input.MovementID,
@@ -290,11 +451,14 @@ func (uc *RegisterMovementUseCase) doOUT(
 		UnitCost:      unitCost,
 		TotalCost:     input.Quantity.Neg().Mul(unitCost),
 		Notes:         input.Notes,
-		Date:          now,
+		Date:          input.movementDate(now),
 		CreatedAt:     now,
 		CreatedBy:     input.UserID,
 	}
-	return movRepo.Create(mov)
+	if err := movRepo.Create(mov); err != nil {
+		return err
+	}
+	return stockRepo.RecordLotConsumption(mov.ID, lots)
 }
 
 // doADJUSTMENT: positivo como IN, negativo como OUT.
@@ -323,6 +487,7 @@ func (uc *RegisterMovementUseCase) doADJUSTMENT(
 }
 
 // doTRANSFER: resta de bodega origen, suma en bodega destino, misma transacción; guarda dos registros en inventory_movements.
+// Los lotes consumidos FEFO en origen se recrean en destino con su mismo vencimiento.
 func (uc *RegisterMovementUseCase) doTRANSFER(
 	movRepo repository.InventoryMovementRepository,
 	stockRepo repository.StockRepository,
@@ -353,6 +518,10 @@ func (uc *RegisterMovementUseCase) doTRANSFER(
 	if err := stockRepo.Upsert(dest); err != nil {
 		return err
 	}
+	lots, err := stockRepo.ConsumeLotsFEFO(input.ProductID, input.FromWarehouseID, input.Quantity)
+	if err != nil {
+		return err
+	}
 	product, err := productRepo.GetByID(input.ProductID)
 	if err != nil || product == nil {
 		return domain.ErrNotFound
@@ -367,13 +536,16 @@ func (uc *RegisterMovementUseCase) doTRANSFER(
 		Quantity:      input.Quantity.Neg(),
 		UnitCost:      unitCost,
 		TotalCost:     input.Quantity.Neg().Mul(unitCost),
-		Date:          now,
+		Date:          input.movementDate(now),
 		CreatedAt:     now,
 		CreatedBy:     input.UserID,
 	}
 	if err := movRepo.Create(outMov); err != nil {
 		return err
 	}
+	if err := stockRepo.RecordLotConsumption(outMov.ID, lots); err != nil {
+		return err
+	}
 	// Guarda movimiento entrada en destino
 	inMov := &entity.InventoryMovement{
 		TransactionID: txID,
@@ -383,9 +555,27 @@ func (uc *RegisterMovementUseCase) doTRANSFER(
 		Quantity:      input.Quantity,
 		UnitCost:      unitCost,
 		TotalCost:     input.Quantity.Mul(unitCost),
-		Date:          now,
+		Date:          input.movementDate(now),
 		CreatedAt:     now,
 		CreatedBy:     input.UserID,
 	}
-	return movRepo.Create(inMov)
+	if err := movRepo.Create(inMov); err != nil {
+		return err
+	}
+	for _, lot := range lots {
+		if err := stockRepo.CreateLot(&entity.StockLot{
+			CompanyID:       lot.CompanyID,
+			ProductID:       input.ProductID,
+			WarehouseID:     input.ToWarehouseID,
+			LotNumber:       lot.LotNumber,
+			ExpiryDate:      lot.ExpiryDate,
+			InitialQuantity: lot.Quantity,
+			Quantity:        lot.Quantity,
+			MovementID:      inMov.ID,
+			CreatedAt:       now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
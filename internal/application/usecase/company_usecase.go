@@ -150,6 +150,16 @@ func (uc *CompanyUseCase) CreateResolution(companyID string, in dto.CreateResolu
 	if env != "test" && env != "prod" {
 		return nil, domain.ErrInvalidInput
 	}
+	// document_type opcional: las resoluciones de notas crédito/débito numeran esas notas.
+	documentType := in.DocumentType
+	if documentType == "" {
+		documentType = entity.ResolutionDocumentInvoice
+	}
+	switch documentType {
+	case entity.ResolutionDocumentInvoice, entity.ResolutionDocumentCreditNote, entity.ResolutionDocumentDebitNote:
+	default:
+		return nil, domain.ErrInvalidInput
+	}
 	company, err := uc.repo.GetByID(companyID)
 	if err != nil {
 		return nil, err
@@ -181,6 +191,7 @@ func (uc *CompanyUseCase) CreateResolution(companyID string, in dto.CreateResolu
 		DateFrom:         validFrom,
 		DateTo:           validUntil,
 		Environment:      env,
+		DocumentType:     documentType,
 		IsActive:         true,
 		CreatedAt:        now,
 		UpdatedAt:        now,
@@ -416,6 +427,7 @@ func resolutionToDTO(res *entity.BillingResolution) *dto.ResolutionResponse {
 		ValidFrom:        res.DateFrom,
 		ValidUntil:       res.DateTo,
 		Environment:      res.Environment,
+		DocumentType:     res.DocumentType,
 		AlertThreshold:   alert,
 		CreatedAt:        res.CreatedAt,
 		UpdatedAt:        res.UpdatedAt,
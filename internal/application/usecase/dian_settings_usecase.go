@@ -20,11 +20,17 @@ type SecretEncryptor interface {
 	Encrypt(plaintext string) (string, error)
 }
 
+// DIANCredentialsCache caché de credenciales DIAN del orquestador; se invalida al guardar la configuración.
+type DIANCredentialsCache interface {
+	Invalidate(companyID string)
+}
+
 type DIANSettingsUseCase struct {
 	companyRepo  repository.CompanyRepository
 	settingsRepo repository.DIANSettingsRepository
 	certStore    DIANCertificateStore
 	encryptor    SecretEncryptor
+	credentials  DIANCredentialsCache // opcional
 }
 
 func NewDIANSettingsUseCase(
@@ -41,6 +47,11 @@ func NewDIANSettingsUseCase(
 	}
 }
 
+// SetCredentialsCache inyecta la caché de credenciales que debe invalidarse al guardar.
+func (uc *DIANSettingsUseCase) SetCredentialsCache(c DIANCredentialsCache) {
+	uc.credentials = c
+}
+
 func (uc *DIANSettingsUseCase) Save(companyID string, in dto.UpsertDIANSettingsRequest) (*dto.DIANSettingsResponse, error) {
 	if strings.TrimSpace(companyID) == "" {
 		return nil, domain.ErrUnauthorized
@@ -83,12 +94,39 @@ func (uc *DIANSettingsUseCase) Save(companyID string, in dto.UpsertDIANSettingsR
 		CertificateFileName:          storedName,
 		CertificateFileSize:          int64(len(in.CertificateData)),
 		CertificatePasswordEncrypted: passwordEncrypted,
+		TechnicalKey:                 strings.TrimSpace(in.TechnicalKey),
+		SoftwareID:                   strings.TrimSpace(in.SoftwareID),
 		CreatedAt:                    now,
 		UpdatedAt:                    now,
 	}
+	if pin := strings.TrimSpace(in.SoftwarePIN); pin != "" {
+		if settings.SoftwarePINEncrypted, err = uc.encryptor.Encrypt(pin); err != nil {
+			return nil, err
+		}
+	}
+	// Al renovar solo el certificado se conservan la clave técnica y el software ya registrados.
+	previous, err := uc.settingsRepo.GetByCompanyIDAndEnvironment(context.Background(), companyID, env)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		settings.CreatedAt = previous.CreatedAt
+		if settings.TechnicalKey == "" {
+			settings.TechnicalKey = previous.TechnicalKey
+		}
+		if settings.SoftwareID == "" {
+			settings.SoftwareID = previous.SoftwareID
+		}
+		if settings.SoftwarePINEncrypted == "" {
+			settings.SoftwarePINEncrypted = previous.SoftwarePINEncrypted
+		}
+	}
 	if err := uc.settingsRepo.Upsert(context.Background(), settings); err != nil {
 		return nil, err
 	}
+	if uc.credentials != nil {
+		uc.credentials.Invalidate(companyID)
+	}
 
 	if env == "prod" {
 		company.CertProd = settings.CertificatePath
@@ -102,13 +140,7 @@ func (uc *DIANSettingsUseCase) Save(companyID string, in dto.UpsertDIANSettingsR
 		}
 	}
 
-	return &dto.DIANSettingsResponse{
-		CompanyID:           companyID,
-		Environment:         env,
-		CertificateFileName: settings.CertificateFileName,
-		CertificateFileSize: settings.CertificateFileSize,
-		UpdatedAt:           settings.UpdatedAt,
-	}, nil
+	return toDIANSettingsResponse(settings), nil
 }
 
 // Get devuelve la configuración DIAN de la empresa.
@@ -144,13 +176,21 @@ func (uc *DIANSettingsUseCase) Get(companyID, environment string) (*dto.DIANSett
 		return nil, domain.ErrNotFound
 	}
 
+	return toDIANSettingsResponse(settings), nil
+}
+
+// toDIANSettingsResponse nunca expone secretos: solo indica si la clave técnica y el PIN están configurados.
+func toDIANSettingsResponse(settings *entity.DIANSettings) *dto.DIANSettingsResponse {
 	return &dto.DIANSettingsResponse{
 		CompanyID:           settings.CompanyID,
 		Environment:         settings.Environment,
 		CertificateFileName: settings.CertificateFileName,
 		CertificateFileSize: settings.CertificateFileSize,
+		SoftwareID:          settings.SoftwareID,
+		HasTechnicalKey:     settings.TechnicalKey != "",
+		HasSoftwarePIN:      settings.SoftwarePINEncrypted != "",
 		UpdatedAt:           settings.UpdatedAt,
-	}, nil
+	}
 }
 
 func normalizeDIANEnvironment(environment string) (string, bool) {
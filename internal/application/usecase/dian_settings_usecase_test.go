@@ -42,6 +42,9 @@ func (f *fakeCompanyRepoForDIANSettings) Update(company *entity.Company) error {
 func (f *fakeCompanyRepoForDIANSettings) List(limit, offset int) ([]*entity.Company, error) {
 	return nil, nil
 }
+func (f *fakeCompanyRepoForDIANSettings) ListForAdmin(limit, offset int) ([]*entity.Company, error) {
+	return nil, nil
+}
 func (f *fakeCompanyRepoForDIANSettings) Delete(id string) error { return nil }
 func (f *fakeCompanyRepoForDIANSettings) ListModules(ctx context.Context, companyID string) ([]*entity.CompanyModule, error) {
 	return []*entity.CompanyModule{}, nil
@@ -58,6 +61,24 @@ func (f *fakeCompanyRepoForDIANSettings) DeleteModule(ctx context.Context, compa
 func (f *fakeCompanyRepoForDIANSettings) HasActiveModule(ctx context.Context, companyID, moduleName string) (bool, error) {
 	return true, nil
 }
+func (f *fakeCompanyRepoForDIANSettings) HasActiveScreen(ctx context.Context, companyID, screenID string) (bool, error) {
+	return true, nil
+}
+func (f *fakeCompanyRepoForDIANSettings) ListScreens(ctx context.Context, companyID string) ([]*entity.CompanyScreen, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepoForDIANSettings) GetScreen(ctx context.Context, companyID, screenID string) (*entity.CompanyScreen, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepoForDIANSettings) UpsertScreen(ctx context.Context, screen *entity.CompanyScreen) error {
+	return nil
+}
+func (f *fakeCompanyRepoForDIANSettings) ReplaceScreens(ctx context.Context, companyID string, screenIDs []string) error {
+	return nil
+}
+func (f *fakeCompanyRepoForDIANSettings) DeleteScreen(ctx context.Context, companyID, screenID string) error {
+	return nil
+}
 
 type fakeDIANSettingsRepoForUseCase struct {
 	upserted *entity.DIANSettings
@@ -259,3 +280,40 @@ func TestDIANSettingsUseCase_Save_FailsOnNonLegacyCompanyUpdateError(t *testing.
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "deadlock")
 }
+
+type fakeDIANCredentialsCache struct{ invalidated []string }
+
+func (f *fakeDIANCredentialsCache) Invalidate(companyID string) {
+	f.invalidated = append(f.invalidated, companyID)
+}
+
+func TestDIANSettingsUseCase_Save_KeepsCredentialsWhenRenewingCertificate(t *testing.T) {
+	companyRepo := &fakeCompanyRepoForDIANSettings{company: &entity.Company{ID: "company-1"}}
+	settingsRepo := &fakeDIANSettingsRepoForUseCase{stored: &entity.DIANSettings{
+		CompanyID:            "company-1",
+		Environment:          "prod",
+		TechnicalKey:         "tk-prod",
+		SoftwareID:           "sw-1",
+		SoftwarePINEncrypted: "enc:12345",
+	}}
+	cache := &fakeDIANCredentialsCache{}
+	uc := NewDIANSettingsUseCase(companyRepo, settingsRepo, &fakeDIANCertificateStore{}, &fakeSecretEncryptor{})
+	uc.SetCredentialsCache(cache)
+
+	out, err := uc.Save("company-1", dto.UpsertDIANSettingsRequest{
+		Environment:         "prod",
+		CertificateFileName: "nuevo.p12",
+		CertificateData:     []byte("dummy-p12"),
+		CertificatePassword: "123456",
+		SoftwarePIN:         "67890",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "tk-prod", settingsRepo.upserted.TechnicalKey)
+	assert.Equal(t, "sw-1", settingsRepo.upserted.SoftwareID)
+	assert.Equal(t, "enc:67890", settingsRepo.upserted.SoftwarePINEncrypted)
+	assert.True(t, out.HasTechnicalKey)
+	assert.True(t, out.HasSoftwarePIN)
+	assert.Equal(t, "sw-1", out.SoftwareID)
+	assert.Equal(t, []string{"company-1"}, cache.invalidated)
+}
@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+const (
+	defaultDeadStockDays = 90
+	maxDeadStockDays     = 3650
+)
+
+// agingBuckets rangos de antigüedad (días desde la última venta o recepción).
+var agingBuckets = []struct {
+	label   string
+	minDays int
+	maxDays int // -1 = sin tope
+}{
+	{"0-30", 0, 30},
+	{"31-90", 31, 90},
+	{"91-180", 91, 180},
+	{"180+", 181, -1},
+}
+
+// GetInventoryAging genera el reporte de antigüedad de inventario: agrupa el stock vigente por
+// días desde su último movimiento comercial (venta o recepción), lo valoriza al costo promedio
+// y marca como stock muerto los productos sin ventas en los últimos DeadStockDays días.
+// Un producto sin ventas ni recepciones registradas cae en el rango más antiguo.
+func (uc *AnalyticsUseCase) GetInventoryAging(
+	ctx context.Context,
+	companyID string,
+	req dto.InventoryAgingRequest,
+) (*dto.InventoryAgingReportDTO, error) {
+	if companyID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	deadDays := req.DeadStockDays
+	if deadDays == 0 {
+		deadDays = defaultDeadStockDays
+	}
+	if deadDays < 0 || deadDays > maxDeadStockDays {
+		return nil, domain.ErrInvalidInput
+	}
+	warehouseID := strings.TrimSpace(req.WarehouseID)
+
+	rows, err := uc.analyticsRepo.GetInventoryAging(ctx, companyID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("analytics: antigüedad de inventario: %w", err)
+	}
+	report := buildInventoryAging(rows, time.Now(), deadDays)
+	report.WarehouseID = warehouseID
+	return report, nil
+}
+
+// buildInventoryAging clasifica las filas en rangos y calcula totales y stock muerto.
+func buildInventoryAging(rows []repository.InventoryAgingRow, now time.Time, deadDays int) *dto.InventoryAgingReportDTO {
+	report := &dto.InventoryAgingReportDTO{
+		AsOf:          now.Format("2006-01-02"),
+		DeadStockDays: deadDays,
+		Buckets:       make([]dto.AgingBucketDTO, len(agingBuckets)),
+		Items:         make([]dto.InventoryAgingItemDTO, 0, len(rows)),
+	}
+	for i, b := range agingBuckets {
+		report.Buckets[i] = dto.AgingBucketDTO{Label: b.label, MinDays: b.minDays}
+		if b.maxDays >= 0 {
+			maxDays := b.maxDays
+			report.Buckets[i].MaxDays = &maxDays
+		}
+	}
+
+	for _, r := range rows {
+		value := r.Quantity.Mul(r.UnitCost).Round(2)
+		item := dto.InventoryAgingItemDTO{
+			ProductID:     r.ProductID,
+			SKU:           r.SKU,
+			ProductName:   r.ProductName,
+			Quantity:      r.Quantity,
+			UnitCost:      r.UnitCost,
+			Value:         value,
+			LastSaleAt:    r.LastSaleAt,
+			LastReceiptAt: r.LastReceiptAt,
+		}
+
+		lastMove := latest(r.LastSaleAt, r.LastReceiptAt)
+		bucket := len(agingBuckets) - 1
+		if lastMove != nil {
+			days := daysBetween(*lastMove, now)
+			item.DaysSinceMove = &days
+			bucket = agingBucketFor(days)
+		}
+		item.Bucket = agingBuckets[bucket].label
+
+		if r.LastSaleAt != nil {
+			days := daysBetween(*r.LastSaleAt, now)
+			item.DaysWithoutSale = &days
+			item.IsDeadStock = days > deadDays
+		} else {
+			item.IsDeadStock = true
+		}
+
+		b := &report.Buckets[bucket]
+		b.ProductCount++
+		b.Quantity = b.Quantity.Add(r.Quantity)
+		b.Value = b.Value.Add(value)
+		report.TotalValue = report.TotalValue.Add(value)
+		if item.IsDeadStock {
+			report.DeadStockCount++
+			report.DeadStockValue = report.DeadStockValue.Add(value)
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	if report.TotalValue.IsPositive() {
+		for i := range report.Buckets {
+			report.Buckets[i].ValuePct = report.Buckets[i].Value.Div(report.TotalValue).Mul(hundred).Round(2)
+		}
+	}
+	return report
+}
+
+func agingBucketFor(days int) int {
+	for i, b := range agingBuckets {
+		if b.maxDays < 0 || days <= b.maxDays {
+			return i
+		}
+	}
+	return len(agingBuckets) - 1
+}
+
+func latest(a, b *time.Time) *time.Time {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case b.After(*a):
+		return b
+	}
+	return a
+}
+
+// daysBetween días calendario completos entre from y now (nunca negativo).
+func daysBetween(from, now time.Time) int {
+	days := int(now.Sub(from).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// InventoryAgingCSV serializa el detalle del reporte en CSV (una fila por producto).
+func InventoryAgingCSV(report *dto.InventoryAgingReportDTO) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{
+		"sku", "producto", "cantidad", "costo_unitario", "valor",
+		"ultima_venta", "ultima_recepcion", "dias_sin_movimiento", "dias_sin_venta",
+		"rango", "stock_muerto",
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, it := range report.Items {
+		record := []string{
+			it.SKU,
+			it.ProductName,
+			it.Quantity.String(),
+			it.UnitCost.StringFixed(2),
+			it.Value.StringFixed(2),
+			formatDate(it.LastSaleAt),
+			formatDate(it.LastReceiptAt),
+			formatDays(it.DaysSinceMove),
+			formatDays(it.DaysWithoutSale),
+			it.Bucket,
+			strconv.FormatBool(it.IsDeadStock),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatDays(d *int) string {
+	if d == nil {
+		return ""
+	}
+	return strconv.Itoa(*d)
+}
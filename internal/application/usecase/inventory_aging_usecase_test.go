@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+func TestBuildInventoryAging(t *testing.T) {
+	now := time.Date(2026, 6, 30, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) *time.Time {
+		d := now.AddDate(0, 0, -n)
+		return &d
+	}
+	rows := []repository.InventoryAgingRow{
+		// Vendido hace 10 días → 0-30, no muerto.
+		{ProductID: "p1", SKU: "A", Quantity: decimal.NewFromInt(10), UnitCost: decimal.NewFromInt(100), LastSaleAt: daysAgo(10), LastReceiptAt: daysAgo(40)},
+		// Recibido hace 20 días pero sin ventas hace 120 → 0-30 por la recepción, muerto por ventas.
+		{ProductID: "p2", SKU: "B", Quantity: decimal.NewFromInt(5), UnitCost: decimal.NewFromInt(200), LastSaleAt: daysAgo(120), LastReceiptAt: daysAgo(20)},
+		// Última venta hace 150 días → 91-180.
+		{ProductID: "p3", SKU: "C", Quantity: decimal.NewFromInt(2), UnitCost: decimal.NewFromInt(50), LastSaleAt: daysAgo(150)},
+		// Sin ventas ni recepciones → 180+ y muerto.
+		{ProductID: "p4", SKU: "D", Quantity: decimal.NewFromInt(1), UnitCost: decimal.NewFromInt(1000)},
+	}
+
+	report := buildInventoryAging(rows, now, 90)
+
+	require.Len(t, report.Buckets, 4)
+	assert.Equal(t, 2, report.Buckets[0].ProductCount)
+	assert.True(t, report.Buckets[0].Value.Equal(decimal.NewFromInt(2000)), "0-30: %s", report.Buckets[0].Value)
+	assert.Equal(t, 0, report.Buckets[1].ProductCount)
+	assert.Equal(t, 1, report.Buckets[2].ProductCount)
+	assert.Equal(t, 1, report.Buckets[3].ProductCount)
+	assert.Nil(t, report.Buckets[3].MaxDays)
+	assert.True(t, report.TotalValue.Equal(decimal.NewFromInt(3100)))
+	assert.True(t, report.Buckets[0].ValuePct.Equal(decimal.RequireFromString("64.52")), "pct: %s", report.Buckets[0].ValuePct)
+
+	assert.False(t, report.Items[0].IsDeadStock)
+	assert.True(t, report.Items[1].IsDeadStock)
+	assert.True(t, report.Items[2].IsDeadStock)
+	assert.True(t, report.Items[3].IsDeadStock)
+	assert.Nil(t, report.Items[3].DaysSinceMove)
+	assert.Equal(t, 3, report.DeadStockCount)
+	assert.True(t, report.DeadStockValue.Equal(decimal.NewFromInt(2100)))
+
+	data, err := InventoryAgingCSV(report)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[0], "sku,producto,cantidad"))
+	assert.Contains(t, lines[4], "180+,true")
+}
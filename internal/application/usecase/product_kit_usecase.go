@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/shopspring/decimal"
+)
+
+// ProductKitUseCase administra la composición de los productos tipo KIT.
+type ProductKitUseCase struct {
+	productRepo repository.ProductRepository
+	kitRepo     repository.ProductKitRepository
+}
+
+// NewProductKitUseCase construye el caso de uso.
+func NewProductKitUseCase(productRepo repository.ProductRepository, kitRepo repository.ProductKitRepository) *ProductKitUseCase {
+	return &ProductKitUseCase{productRepo: productRepo, kitRepo: kitRepo}
+}
+
+// GetComponents devuelve la composición del kit.
+func (uc *ProductKitUseCase) GetComponents(companyID, kitID string) ([]dto.KitComponentDTO, error) {
+	if _, err := uc.getKit(companyID, kitID); err != nil {
+		return nil, err
+	}
+	components, err := uc.kitRepo.ListComponents(kitID)
+	if err != nil {
+		return nil, err
+	}
+	return toKitComponentDTOs(components), nil
+}
+
+// ReplaceComponents reemplaza la composición del kit. Cada componente debe ser un producto
+// estándar de la misma empresa, distinto del kit, sin repetirse y con cantidad positiva.
+func (uc *ProductKitUseCase) ReplaceComponents(companyID, kitID string, in dto.UpdateKitComponentsRequest) ([]dto.KitComponentDTO, error) {
+	kit, err := uc.getKit(companyID, kitID)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.Components) == 0 {
+		return nil, domain.ErrInvalidInput
+	}
+	seen := make(map[string]struct{}, len(in.Components))
+	components := make([]*entity.KitComponent, 0, len(in.Components))
+	for _, c := range in.Components {
+		componentID := strings.TrimSpace(c.ComponentProductID)
+		if componentID == "" || componentID == kit.ID || !c.Quantity.GreaterThan(decimal.Zero) {
+			return nil, domain.ErrInvalidInput
+		}
+		if _, dup := seen[componentID]; dup {
+			return nil, domain.ErrInvalidInput
+		}
+		seen[componentID] = struct{}{}
+		component, err := uc.productRepo.GetByID(componentID)
+		if err != nil {
+			return nil, err
+		}
+		if component == nil || component.CompanyID != companyID {
+			return nil, domain.ErrNotFound
+		}
+		// Sin kits anidados: el descuento de stock se hace en un solo nivel.
+		if component.IsKit() {
+			return nil, domain.ErrInvalidInput
+		}
+		components = append(components, &entity.KitComponent{
+			KitProductID:       kit.ID,
+			ComponentProductID: componentID,
+			Quantity:           c.Quantity,
+		})
+	}
+	if err := uc.kitRepo.ReplaceComponents(kit.ID, components); err != nil {
+		return nil, err
+	}
+	return uc.GetComponents(companyID, kitID)
+}
+
+func (uc *ProductKitUseCase) getKit(companyID, kitID string) (*entity.Product, error) {
+	if companyID == "" || strings.TrimSpace(kitID) == "" {
+		return nil, domain.ErrInvalidInput
+	}
+	kit, err := uc.productRepo.GetByID(kitID)
+	if err != nil {
+		return nil, err
+	}
+	if kit == nil || kit.CompanyID != companyID {
+		return nil, domain.ErrNotFound
+	}
+	if !kit.IsKit() {
+		return nil, domain.ErrInvalidInput
+	}
+	return kit, nil
+}
+
+func toKitComponentDTOs(components []*entity.KitComponent) []dto.KitComponentDTO {
+	out := make([]dto.KitComponentDTO, 0, len(components))
+	for _, c := range components {
+		item := dto.KitComponentDTO{
+			ComponentProductID: c.ComponentProductID,
+			Quantity:           c.Quantity,
+		}
+		if c.Component != nil {
+			item.SKU = c.Component.SKU
+			item.Name = c.Component.Name
+		}
+		out = append(out, item)
+	}
+	return out
+}
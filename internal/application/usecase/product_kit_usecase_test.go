@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+type fakeProductKitRepository struct {
+	components map[string][]*entity.KitComponent
+}
+
+func (f *fakeProductKitRepository) ListComponents(kitProductID string) ([]*entity.KitComponent, error) {
+	return f.components[kitProductID], nil
+}
+
+func (f *fakeProductKitRepository) ReplaceComponents(kitProductID string, components []*entity.KitComponent) error {
+	f.components[kitProductID] = components
+	return nil
+}
+
+var _ repository.ProductKitRepository = (*fakeProductKitRepository)(nil)
+
+func TestProductKitUseCase_ReplaceComponents(t *testing.T) {
+	products := map[string]*entity.Product{
+		"kit":       {ID: "kit", CompanyID: "c1", ProductType: entity.ProductTypeKit},
+		"plain":     {ID: "plain", CompanyID: "c1", ProductType: entity.ProductTypeStandard},
+		"chocolate": {ID: "chocolate", CompanyID: "c1", ProductType: entity.ProductTypeStandard},
+		"vino":      {ID: "vino", CompanyID: "c1"},
+		"otro-kit":  {ID: "otro-kit", CompanyID: "c1", ProductType: entity.ProductTypeKit},
+		"ajeno":     {ID: "ajeno", CompanyID: "c2"},
+	}
+	productRepo := &fakeProductRepository{
+		getByIDFunc: func(id string) (*entity.Product, error) { return products[id], nil },
+	}
+	qty := decimal.NewFromInt(2)
+
+	tests := []struct {
+		name       string
+		kitID      string
+		components []dto.KitComponentDTO
+		wantErr    error
+	}{
+		{"NotAKit", "plain", []dto.KitComponentDTO{{ComponentProductID: "vino", Quantity: qty}}, domain.ErrInvalidInput},
+		{"KitFromOtherCompany", "ajeno", []dto.KitComponentDTO{{ComponentProductID: "vino", Quantity: qty}}, domain.ErrNotFound},
+		{"Empty", "kit", nil, domain.ErrInvalidInput},
+		{"SelfReference", "kit", []dto.KitComponentDTO{{ComponentProductID: "kit", Quantity: qty}}, domain.ErrInvalidInput},
+		{"ZeroQuantity", "kit", []dto.KitComponentDTO{{ComponentProductID: "vino"}}, domain.ErrInvalidInput},
+		{"Duplicated", "kit", []dto.KitComponentDTO{{ComponentProductID: "vino", Quantity: qty}, {ComponentProductID: "vino", Quantity: qty}}, domain.ErrInvalidInput},
+		{"NestedKit", "kit", []dto.KitComponentDTO{{ComponentProductID: "otro-kit", Quantity: qty}}, domain.ErrInvalidInput},
+		{"ForeignComponent", "kit", []dto.KitComponentDTO{{ComponentProductID: "ajeno", Quantity: qty}}, domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewProductKitUseCase(productRepo, &fakeProductKitRepository{components: map[string][]*entity.KitComponent{}})
+			_, err := uc.ReplaceComponents("c1", tt.kitID, dto.UpdateKitComponentsRequest{Components: tt.components})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		kitRepo := &fakeProductKitRepository{components: map[string][]*entity.KitComponent{}}
+		uc := NewProductKitUseCase(productRepo, kitRepo)
+		out, err := uc.ReplaceComponents("c1", "kit", dto.UpdateKitComponentsRequest{Components: []dto.KitComponentDTO{
+			{ComponentProductID: "chocolate", Quantity: qty},
+			{ComponentProductID: "vino", Quantity: decimal.NewFromInt(1)},
+		}})
+		require.NoError(t, err)
+		require.Len(t, out, 2)
+		assert.Equal(t, "chocolate", out[0].ComponentProductID)
+		assert.Len(t, kitRepo.components["kit"], 2)
+	})
+}
@@ -1,14 +1,17 @@
 package usecase
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/shopspring/decimal"
 	"github.com/jhoicas/Inventario-api/internal/application/dto"
 	"github.com/jhoicas/Inventario-api/internal/domain"
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+	"github.com/shopspring/decimal"
 )
 
 // ProductUseCase casos de uso CRUD para productos. Cost y Stock se manejan vía movimientos.
@@ -32,22 +35,39 @@ func (uc *ProductUseCase) Create(companyID string, in dto.CreateProductRequest)
 	if in.TaxRate.LessThan(decimal.Zero) || in.TaxRate.GreaterThan(decimal.NewFromInt(100)) {
 		return nil, domain.ErrInvalidInput
 	}
+	unitTaxCode, err := validateOtherTaxes(in.IncRate, in.UnitTaxCode, in.UnitTaxAmount)
+	if err != nil {
+		return nil, err
+	}
+	productType := strings.ToUpper(strings.TrimSpace(in.ProductType))
+	switch productType {
+	case "":
+		productType = entity.ProductTypeStandard
+	case entity.ProductTypeStandard, entity.ProductTypeKit:
+	default:
+		return nil, domain.ErrInvalidInput
+	}
 	// UnitMeasure e información DIAN provienen exclusivamente del DTO (parametrización manual).
 	now := time.Now()
 	product := &entity.Product{
-		ID:           uuid.New().String(),
-		CompanyID:    companyID,
-		SKU:          in.SKU,
-		Name:         in.Name,
-		Description:  in.Description,
-		Price:        in.Price,
-		Cost:         decimal.Zero,
-		TaxRate:      in.TaxRate,
-		UNSPSC_Code:  in.UNSPSC_Code,
-		UnitMeasure:  in.UnitMeasure,
-		Attributes:   in.Attributes,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+		ID:                 uuid.New().String(),
+		CompanyID:          companyID,
+		SKU:                in.SKU,
+		Name:               in.Name,
+		Description:        in.Description,
+		Price:              in.Price,
+		Cost:               decimal.Zero,
+		TaxRate:            in.TaxRate,
+		IncRate:            in.IncRate,
+		UnitTaxCode:        unitTaxCode,
+		UnitTaxAmount:      in.UnitTaxAmount,
+		WithholdingConcept: domaindian.NormalizeWithholdingConcept(in.WithholdingConcept),
+		UNSPSC_Code:        in.UNSPSC_Code,
+		UnitMeasure:        in.UnitMeasure,
+		Attributes:         in.Attributes,
+		ProductType:        productType,
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
 	if err := uc.repo.Create(product); err != nil {
 		return nil, err
@@ -91,6 +111,25 @@ func (uc *ProductUseCase) Update(id string, in dto.UpdateProductRequest) (*dto.P
 		}
 		product.TaxRate = *in.TaxRate
 	}
+	if in.IncRate != nil || in.UnitTaxCode != nil || in.UnitTaxAmount != nil {
+		incRate, code, amount := product.IncRate, product.UnitTaxCode, product.UnitTaxAmount
+		if in.IncRate != nil {
+			incRate = *in.IncRate
+		}
+		if in.UnitTaxCode != nil {
+			code = *in.UnitTaxCode
+		}
+		if in.UnitTaxAmount != nil {
+			amount = *in.UnitTaxAmount
+		}
+		if code, err = validateOtherTaxes(incRate, code, amount); err != nil {
+			return nil, err
+		}
+		product.IncRate, product.UnitTaxCode, product.UnitTaxAmount = incRate, code, amount
+	}
+	if in.WithholdingConcept != nil {
+		product.WithholdingConcept = domaindian.NormalizeWithholdingConcept(*in.WithholdingConcept)
+	}
 	if in.UNSPSC_Code != nil {
 		product.UNSPSC_Code = *in.UNSPSC_Code
 	}
@@ -133,18 +172,46 @@ func toProductResponse(p *entity.Product) *dto.ProductResponse {
 		return nil
 	}
 	return &dto.ProductResponse{
-		ID:          p.ID,
-		CompanyID:   p.CompanyID,
-		SKU:         p.SKU,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		Cost:        p.Cost,
-		TaxRate:     p.TaxRate,
-		UNSPSC_Code: p.UNSPSC_Code,
-		UnitMeasure: p.UnitMeasure,
-		Attributes:  p.Attributes,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		ID:                 p.ID,
+		CompanyID:          p.CompanyID,
+		SKU:                p.SKU,
+		Name:               p.Name,
+		Description:        p.Description,
+		Price:              p.Price,
+		Cost:               p.Cost,
+		TaxRate:            p.TaxRate,
+		IncRate:            p.IncRate,
+		UnitTaxCode:        p.UnitTaxCode,
+		UnitTaxAmount:      p.UnitTaxAmount,
+		WithholdingConcept: domaindian.NormalizeWithholdingConcept(p.WithholdingConcept),
+		UNSPSC_Code:        p.UNSPSC_Code,
+		UnitMeasure:        p.UnitMeasure,
+		Attributes:         p.Attributes,
+		ProductType:        p.TypeOrDefault(),
+		CreatedAt:          p.CreatedAt,
+		UpdatedAt:          p.UpdatedAt,
+	}
+}
+
+// validateOtherTaxes valida el INC (porcentaje 0-100) y el impuesto por unidad (valor >= 0).
+// Si hay valor por unidad sin código se asume el impuesto a las bolsas plásticas ("22");
+// sin valor por unidad el código se descarta. Devuelve el código normalizado.
+func validateOtherTaxes(incRate decimal.Decimal, unitTaxCode string, unitTaxAmount decimal.Decimal) (string, error) {
+	if incRate.LessThan(decimal.Zero) || incRate.GreaterThan(decimal.NewFromInt(100)) {
+		return "", domain.ErrInvalidInput
+	}
+	if unitTaxAmount.LessThan(decimal.Zero) {
+		return "", domain.ErrInvalidInput
+	}
+	if unitTaxAmount.IsZero() {
+		return "", nil
+	}
+	code := strings.TrimSpace(unitTaxCode)
+	if code == "" {
+		return dian.TaxCodeINCBolsas, nil
+	}
+	if len(code) > 4 {
+		return "", domain.ErrInvalidInput
 	}
+	return code, nil
 }
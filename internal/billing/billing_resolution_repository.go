@@ -34,7 +34,10 @@ func (r *BillingResolutionRepository) GetByCompanyID(companyID string) ([]Resolu
 		           FROM invoices i
 		           WHERE i.company_id = br.company_id
 		             AND i.prefix = br.prefix
-		             AND COALESCE(i.document_type, 'INVOICE') = 'INVOICE'
+		             AND CASE WHEN br.document_type IN ('CREDIT_NOTE', 'DEBIT_NOTE')
+		                      THEN i.document_type = br.document_type
+		                      ELSE COALESCE(i.document_type, 'INVOICE') IN ('INVOICE', 'POS')
+		                 END
 		       ), 0) AS used_numbers
 		FROM billing_resolutions br
 		WHERE br.company_id = $1
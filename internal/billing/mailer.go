@@ -18,7 +18,9 @@ import (
 	"gopkg.in/gomail.v2"
 
 	appbilling "github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
 )
 
 // SMTPConfig parámetros del servidor SMTP saliente.
@@ -33,8 +35,8 @@ type SMTPConfig struct {
 	ResendAPIURL string
 }
 
-// InvoiceMailer envía la factura electrónica (PDF + XML) al correo del cliente
-// tras ser validada por la DIAN.
+// InvoiceMailer envía la factura electrónica al correo del cliente tras ser validada por la DIAN: el ZIP
+// con el AttachedDocument firmado y el PDF o, sin firmante configurado, el PDF y el XML sueltos.
 type InvoiceMailer struct {
 	invoiceRepo  repository.InvoiceRepository
 	companyRepo  repository.CompanyRepository
@@ -42,6 +44,17 @@ type InvoiceMailer struct {
 	productRepo  repository.ProductRepository
 	pdfGen       appbilling.InvoicePDFGenerator
 	smtp         SMTPConfig
+
+	// AttachedDocument (opcional; ver SetAttachedDocumentSigner)
+	attachedDocs appbilling.AttachedDocumentBuilder
+	signer       pkgdian.Signer
+	credentials  appbilling.DIANCredentialsProvider
+}
+
+// mailAttachment archivo adjunto del correo.
+type mailAttachment struct {
+	Name    string
+	Content []byte
 }
 
 // NewInvoiceMailer construye el mailer inyectando todas sus dependencias.
@@ -63,6 +76,14 @@ func NewInvoiceMailer(
 	}
 }
 
+// SetAttachedDocumentSigner habilita la entrega en el ZIP DIAN: el AttachedDocument se firma con el
+// certificado de la empresa que resuelve credentials.
+func (m *InvoiceMailer) SetAttachedDocumentSigner(attachedDocs appbilling.AttachedDocumentBuilder, signer pkgdian.Signer, credentials appbilling.DIANCredentialsProvider) {
+	m.attachedDocs = attachedDocs
+	m.signer = signer
+	m.credentials = credentials
+}
+
 // SendInvoiceEmail dispara el envío del correo en una goroutine independiente.
 // Los errores se registran en el log; nunca bloquea el flujo principal.
 func (m *InvoiceMailer) SendInvoiceEmail(invoiceID string) {
@@ -93,15 +114,15 @@ func (m *InvoiceMailer) SendCustomEmailSync(ctx context.Context, companyID, to,
 	}
 
 	if m.smtp.Host == "" && m.hasResendAPIConfig() {
-		return m.sendWithResendAPI(ctx, from, to, subject, body, "", nil, "", "")
+		return m.sendWithResendAPI(ctx, from, to, subject, body, nil)
 	}
 
-	err := m.sendWithSMTP(from, to, subject, body, "", nil, "", "")
+	err := m.sendWithSMTP(from, to, subject, body, nil)
 	if err == nil {
 		return nil
 	}
 	if m.hasResendAPIConfig() && isSMTPConnectivityError(err) {
-		if apiErr := m.sendWithResendAPI(ctx, from, to, subject, body, "", nil, "", ""); apiErr == nil {
+		if apiErr := m.sendWithResendAPI(ctx, from, to, subject, body, nil); apiErr == nil {
 			return nil
 		} else {
 			return fmt.Errorf("error SMTP: %v; error Resend API: %w", err, apiErr)
@@ -151,6 +172,11 @@ func (m *InvoiceMailer) send(ctx context.Context, invoiceID string) error {
 		}
 		enriched = append(enriched, appbilling.InvoiceDetailForPDF{InvoiceDetail: *d, ProductName: name})
 	}
+	if inv.WithholdingTotal.IsPositive() {
+		if inv.Withholdings, err = m.invoiceRepo.GetWithholdingsByInvoiceID(invoiceID); err != nil {
+			return fmt.Errorf("retenciones de factura no encontradas: %w", err)
+		}
+	}
 	pdfBytes, err := m.pdfGen.GenerateInvoicePDF(ctx, inv, company, customer, enriched)
 	if err != nil {
 		return fmt.Errorf("error generando PDF para correo: %w", err)
@@ -162,43 +188,49 @@ func (m *InvoiceMailer) send(ctx context.Context, invoiceID string) error {
 		from = m.smtp.User
 	}
 
-	// ── 6. Construir mensaje ──────────────────────────────────────────────────
+	// ── 6. Adjuntos: ZIP DIAN (AttachedDocument + PDF) o PDF y XML sueltos ────
 	docNum := strings.TrimSpace(inv.Prefix) + strings.TrimSpace(inv.Number)
+	attachments, err := m.attachedDocumentZip(ctx, inv, company, customer, pdfBytes)
+	if err != nil {
+		log.Printf("[MAILER][%s] AttachedDocument no disponible, se adjuntan PDF y XML: %v", invoiceID, err)
+	}
+	attachmentsNote := "Adjunto encontrará el archivo ZIP con la factura electrónica (AttachedDocument) y su representación gráfica en PDF."
+	if attachments == nil {
+		attachments = []mailAttachment{{Name: fmt.Sprintf("factura_%s.pdf", docNum), Content: pdfBytes}}
+		if inv.XMLSigned != "" {
+			attachments = append(attachments, mailAttachment{Name: fmt.Sprintf("factura_%s.xml", docNum), Content: []byte(inv.XMLSigned)})
+		}
+		attachmentsNote = "Adjunto encontrará el PDF y el archivo XML de la factura."
+	}
+
+	// ── 7. Construir mensaje ──────────────────────────────────────────────────
 	subject := fmt.Sprintf("Factura electrónica %s – %s", docNum, company.Name)
 	body := fmt.Sprintf(
 		"Estimado(a) %s,\n\n"+
 			"Le informamos que la factura electrónica %s emitida por %s "+
 			"ha sido validada correctamente por la DIAN.\n\n"+
 			"CUFE: %s\n\n"+
-			"Adjunto encontrará el PDF y el archivo XML de la factura.\n\n"+
+			"%s\n\n"+
 			"Gracias por su preferencia.\n\n"+
 			"— %s",
-		customer.Name, docNum, company.Name, inv.CUFE, company.Name,
+		customer.Name, docNum, company.Name, inv.CUFE, attachmentsNote, company.Name,
 	)
 
-	pdfName := fmt.Sprintf("factura_%s.pdf", docNum)
-
-	// ── 7. Adjuntar XML firmado (si existe) ───────────────────────────────────
-	xmlName := ""
-	if inv.XMLSigned != "" {
-		xmlName = fmt.Sprintf("factura_%s.xml", docNum)
-	}
-
 	// ── 8. Enviar ─────────────────────────────────────────────────────────────
 	if m.smtp.Host == "" && m.hasResendAPIConfig() {
-		if err := m.sendWithResendAPI(ctx, from, customer.Email, subject, body, pdfName, pdfBytes, xmlName, inv.XMLSigned); err != nil {
+		if err := m.sendWithResendAPI(ctx, from, customer.Email, subject, body, attachments); err != nil {
 			return fmt.Errorf("error al enviar correo Resend API: %w", err)
 		}
 		return nil
 	}
 
-	err = m.sendWithSMTP(from, customer.Email, subject, body, pdfName, pdfBytes, xmlName, inv.XMLSigned)
+	err = m.sendWithSMTP(from, customer.Email, subject, body, attachments)
 	if err == nil {
 		return nil
 	}
 
 	if m.hasResendAPIConfig() && isSMTPConnectivityError(err) {
-		if apiErr := m.sendWithResendAPI(ctx, from, customer.Email, subject, body, pdfName, pdfBytes, xmlName, inv.XMLSigned); apiErr == nil {
+		if apiErr := m.sendWithResendAPI(ctx, from, customer.Email, subject, body, attachments); apiErr == nil {
 			log.Printf("[MAILER][%s] SMTP falló por conectividad, enviado vía Resend API", invoiceID)
 			return nil
 		} else {
@@ -209,26 +241,37 @@ func (m *InvoiceMailer) send(ctx context.Context, invoiceID string) error {
 	return fmt.Errorf("error al enviar correo SMTP: %w", err)
 }
 
-func (m *InvoiceMailer) sendWithSMTP(from, to, subject, body, pdfName string, pdfBytes []byte, xmlName, xmlContent string) error {
+// attachedDocumentZip arma el ZIP DIAN de entrega (AttachedDocument firmado con el ApplicationResponse de
+// la validación, más el PDF). Devuelve nil sin firmante configurado o sin XML firmado.
+func (m *InvoiceMailer) attachedDocumentZip(ctx context.Context, inv *entity.Invoice, company *entity.Company, customer *entity.Customer, pdf []byte) ([]mailAttachment, error) {
+	if m.attachedDocs == nil || m.signer == nil || m.credentials == nil || inv.XMLSigned == "" {
+		return nil, nil
+	}
+	creds, err := m.credentials.Resolve(ctx, company)
+	if err != nil {
+		return nil, fmt.Errorf("credenciales DIAN: %w", err)
+	}
+	zipName, zipBytes, err := m.attachedDocs.BuildAttachedDocumentZip(inv, company, customer, creds.TipoAmbiente, m.signer, creds.Certificate, pdf)
+	if err != nil {
+		return nil, err
+	}
+	return []mailAttachment{{Name: zipName, Content: zipBytes}}, nil
+}
+
+func (m *InvoiceMailer) sendWithSMTP(from, to, subject, body string, attachments []mailAttachment) error {
 	msg := gomail.NewMessage()
 	msg.SetHeader("From", from)
 	msg.SetHeader("To", to)
 	msg.SetHeader("Subject", subject)
 	msg.SetBody("text/plain", body)
 
-	if pdfName != "" && len(pdfBytes) > 0 {
-		pdfCopy := make([]byte, len(pdfBytes))
-		copy(pdfCopy, pdfBytes)
-		msg.Attach(pdfName, gomail.SetCopyFunc(func(w io.Writer) error {
-			_, err := io.Copy(w, bytes.NewReader(pdfCopy))
-			return err
-		}))
-	}
-
-	if xmlName != "" && xmlContent != "" {
-		xmlCopy := xmlContent
-		msg.Attach(xmlName, gomail.SetCopyFunc(func(w io.Writer) error {
-			_, err := io.Copy(w, strings.NewReader(xmlCopy))
+	for _, a := range attachments {
+		if a.Name == "" || len(a.Content) == 0 {
+			continue
+		}
+		content := a.Content
+		msg.Attach(a.Name, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := io.Copy(w, bytes.NewReader(content))
 			return err
 		}))
 	}
@@ -257,7 +300,7 @@ type resendEmailRequest struct {
 	Attachments []resendAttachment `json:"attachments,omitempty"`
 }
 
-func (m *InvoiceMailer) sendWithResendAPI(ctx context.Context, from, to, subject, body, pdfName string, pdfBytes []byte, xmlName, xmlContent string) error {
+func (m *InvoiceMailer) sendWithResendAPI(ctx context.Context, from, to, subject, body string, attachments []mailAttachment) error {
 	apiURL := strings.TrimSpace(m.smtp.ResendAPIURL)
 	if apiURL == "" {
 		apiURL = "https://api.resend.com/emails"
@@ -277,15 +320,11 @@ func (m *InvoiceMailer) sendWithResendAPI(ctx context.Context, from, to, subject
 		Subject: subject,
 		Text:    body,
 	}
-	if pdfName != "" && len(pdfBytes) > 0 {
-		reqBody.Attachments = append(reqBody.Attachments, resendAttachment{Filename: pdfName, Content: base64.StdEncoding.EncodeToString(pdfBytes)})
-	}
-
-	if xmlName != "" && xmlContent != "" {
-		reqBody.Attachments = append(reqBody.Attachments, resendAttachment{
-			Filename: xmlName,
-			Content:  base64.StdEncoding.EncodeToString([]byte(xmlContent)),
-		})
+	for _, a := range attachments {
+		if a.Name == "" || len(a.Content) == 0 {
+			continue
+		}
+		reqBody.Attachments = append(reqBody.Attachments, resendAttachment{Filename: a.Name, Content: base64.StdEncoding.EncodeToString(a.Content)})
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
@@ -312,7 +351,7 @@ func (m *InvoiceMailer) sendWithResendAPI(ctx context.Context, from, to, subject
 		if resp.StatusCode == http.StatusForbidden && strings.Contains(strings.ToLower(string(respBody)), "not authorized to send emails from") {
 			fallbackFrom := strings.TrimSpace(m.smtp.From)
 			if fallbackFrom != "" && !strings.EqualFold(fallbackFrom, from) {
-				return m.sendWithResendAPI(ctx, fallbackFrom, to, subject, body, pdfName, pdfBytes, xmlName, xmlContent)
+				return m.sendWithResendAPI(ctx, fallbackFrom, to, subject, body, attachments)
 			}
 		}
 		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
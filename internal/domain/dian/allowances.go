@@ -0,0 +1,32 @@
+package dian
+
+import (
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+
+	"github.com/shopspring/decimal"
+)
+
+// AllowanceAmount valor de un descuento o cargo sobre base: si rate es positivo se aplica como
+// porcentaje; en otro caso se toma el valor fijo amount. Redondeado a 2 decimales.
+func AllowanceAmount(base, rate, amount decimal.Decimal) decimal.Decimal {
+	if rate.IsPositive() {
+		return base.Mul(rate).Div(hundred).Round(2)
+	}
+	return amount.Round(2)
+}
+
+// SumAllowanceCharges suma los descuentos y cargos globales (sin línea asociada) de la factura:
+// AllowanceTotalAmount y ChargeTotalAmount del LegalMonetaryTotal.
+func SumAllowanceCharges(items []*entity.InvoiceAllowanceCharge) (allowances, charges decimal.Decimal) {
+	for _, ac := range items {
+		if ac == nil || ac.InvoiceDetailID != "" {
+			continue
+		}
+		if ac.ChargeIndicator {
+			charges = charges.Add(ac.Amount)
+		} else {
+			allowances = allowances.Add(ac.Amount)
+		}
+	}
+	return allowances, charges
+}
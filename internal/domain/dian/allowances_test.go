@@ -0,0 +1,29 @@
+package dian
+
+import (
+	"testing"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowanceAmount(t *testing.T) {
+	base := decimal.NewFromInt(150000)
+	assert.True(t, AllowanceAmount(base, decimal.NewFromInt(10), decimal.Zero).Equal(decimal.NewFromInt(15000)))
+	assert.True(t, AllowanceAmount(base, decimal.RequireFromString("2.5"), decimal.NewFromInt(999)).Equal(decimal.NewFromInt(3750)))
+	assert.True(t, AllowanceAmount(base, decimal.Zero, decimal.RequireFromString("1234.567")).Equal(decimal.RequireFromString("1234.57")))
+}
+
+func TestSumAllowanceCharges_IgnoresLineLevel(t *testing.T) {
+	items := []*entity.InvoiceAllowanceCharge{
+		{InvoiceDetailID: "d1", Amount: decimal.NewFromInt(500)},
+		{Amount: decimal.NewFromInt(1000)},
+		{ChargeIndicator: true, Amount: decimal.NewFromInt(8000)},
+		{ChargeIndicator: true, Amount: decimal.NewFromInt(2000)},
+	}
+	allowances, charges := SumAllowanceCharges(items)
+	assert.True(t, allowances.Equal(decimal.NewFromInt(1000)))
+	assert.True(t, charges.Equal(decimal.NewFromInt(10000)))
+}
@@ -0,0 +1,130 @@
+package dian
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+// NITDIAN NIT de la DIAN, titular del certificado con que firma sus ApplicationResponse.
+const NITDIAN = "800197268"
+
+// Códigos de cbc:ResponseCode del ApplicationResponse de validación DIAN.
+const (
+	ApplicationResponseAccepted = "02" // Documento validado por la DIAN
+	ApplicationResponseRejected = "04" // Documento rechazado por la DIAN
+)
+
+// ruleMessageRe reconoce los mensajes de GetStatusZip con el formato
+// "Regla: FAD06, Rechazo: descripción" o "Regla: FAJ44b, Notificación: descripción".
+var ruleMessageRe = regexp.MustCompile(`(?i)^\s*regla\s*:\s*([^,]+?)\s*,\s*(rechazo|notificaci[oó]n)\s*:\s*(.*)$`)
+
+// ParseValidationMessages convierte los mensajes de ErrorMessage de GetStatusZip en reglas.
+// Los mensajes sin el formato "Regla: …" se conservan como rechazo sin código.
+func ParseValidationMessages(messages []string) []entity.DIANValidationRule {
+	rules := make([]entity.DIANValidationRule, 0, len(messages))
+	for _, msg := range messages {
+		msg = strings.TrimSpace(msg)
+		if msg == "" {
+			continue
+		}
+		m := ruleMessageRe.FindStringSubmatch(msg)
+		if m == nil {
+			rules = append(rules, entity.DIANValidationRule{Description: msg, Severity: entity.DIANRuleError})
+			continue
+		}
+		severity := entity.DIANRuleError
+		if !strings.EqualFold(m[2], "rechazo") {
+			severity = entity.DIANRuleNotification
+		}
+		rules = append(rules, entity.DIANValidationRule{
+			Code:        strings.TrimSpace(m[1]),
+			Description: strings.TrimSpace(m[3]),
+			Severity:    severity,
+		})
+	}
+	return rules
+}
+
+type applicationResponseXML struct {
+	DocumentResponse []struct {
+		Response     responseXML `xml:"Response"`
+		LineResponse []struct {
+			Response responseXML `xml:"Response"`
+		} `xml:"LineResponse"`
+	} `xml:"DocumentResponse"`
+}
+
+type responseXML struct {
+	ResponseCode string `xml:"ResponseCode"`
+	Description  string `xml:"Description"`
+}
+
+// ParseApplicationResponse lee el ApplicationResponse devuelto por la DIAN (XmlBase64Bytes):
+// el código de respuesta del documento (02 validado, 04 rechazado) y las reglas de cada
+// cac:LineResponse. En un documento rechazado las reglas son rechazos; en uno validado,
+// notificaciones.
+func ParseApplicationResponse(data []byte) (string, []entity.DIANValidationRule, error) {
+	var doc applicationResponseXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return "", nil, fmt.Errorf("parsear ApplicationResponse: %w", err)
+	}
+	if len(doc.DocumentResponse) == 0 {
+		return "", nil, fmt.Errorf("ApplicationResponse sin cac:DocumentResponse")
+	}
+	dr := doc.DocumentResponse[0]
+	code := strings.TrimSpace(dr.Response.ResponseCode)
+	severity := entity.DIANRuleNotification
+	if code == ApplicationResponseRejected {
+		severity = entity.DIANRuleError
+	}
+	rules := make([]entity.DIANValidationRule, 0, len(dr.LineResponse))
+	for _, lr := range dr.LineResponse {
+		rules = append(rules, entity.DIANValidationRule{
+			Code:        strings.TrimSpace(lr.Response.ResponseCode),
+			Description: strings.TrimSpace(lr.Response.Description),
+			Severity:    severity,
+		})
+	}
+	return code, rules, nil
+}
+
+// MergeValidationRules une las reglas de varias fuentes sin repetir código y severidad;
+// las reglas sin código se comparan por descripción.
+func MergeValidationRules(sets ...[]entity.DIANValidationRule) []entity.DIANValidationRule {
+	seen := make(map[string]bool)
+	out := make([]entity.DIANValidationRule, 0)
+	for _, set := range sets {
+		for _, r := range set {
+			key := r.Severity + "|" + r.Code
+			if r.Code == "" {
+				key += "|" + r.Description
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// FormatRejections resume los rechazos en texto ("FAD06: descripción; …") para dian_errors.
+func FormatRejections(rules []entity.DIANValidationRule) string {
+	parts := make([]string, 0, len(rules))
+	for _, r := range rules {
+		if r.Severity != entity.DIANRuleError {
+			continue
+		}
+		if r.Code == "" {
+			parts = append(parts, r.Description)
+			continue
+		}
+		parts = append(parts, r.Code+": "+r.Description)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,62 @@
+package dian_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+func TestParseValidationMessages(t *testing.T) {
+	rules := dian.ParseValidationMessages([]string{
+		"Regla: FAD06, Rechazo: El NIT del emisor no es válido",
+		"Regla: FAJ44b, Notificación: Nit o Documento de Identificación informado no se encuentra registrado en el RUT",
+		"  ",
+		"Documento con errores en campos mandatorios.",
+	})
+	require.Len(t, rules, 3)
+	assert.Equal(t, entity.DIANValidationRule{Code: "FAD06", Description: "El NIT del emisor no es válido", Severity: entity.DIANRuleError}, rules[0])
+	assert.Equal(t, "FAJ44b", rules[1].Code)
+	assert.Equal(t, entity.DIANRuleNotification, rules[1].Severity)
+	assert.Equal(t, "", rules[2].Code)
+	assert.Equal(t, entity.DIANRuleError, rules[2].Severity)
+
+	assert.Equal(t, "FAD06: El NIT del emisor no es válido; Documento con errores en campos mandatorios.",
+		dian.FormatRejections(rules))
+}
+
+func TestParseApplicationResponse(t *testing.T) {
+	const xmlDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<ApplicationResponse xmlns="urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2"
+  xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+  xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+  <cac:DocumentResponse>
+    <cac:Response>
+      <cbc:ResponseCode>04</cbc:ResponseCode>
+      <cbc:Description>Documento con errores en campos mandatorios.</cbc:Description>
+    </cac:Response>
+    <cac:LineResponse>
+      <cac:LineReference><cbc:LineID>1</cbc:LineID></cac:LineReference>
+      <cac:Response>
+        <cbc:ResponseCode>FAD06</cbc:ResponseCode>
+        <cbc:Description>El NIT del emisor no es válido</cbc:Description>
+      </cac:Response>
+    </cac:LineResponse>
+  </cac:DocumentResponse>
+</ApplicationResponse>`
+
+	code, rules, err := dian.ParseApplicationResponse([]byte(xmlDoc))
+	require.NoError(t, err)
+	assert.Equal(t, dian.ApplicationResponseRejected, code)
+	require.Len(t, rules, 1)
+	assert.Equal(t, entity.DIANValidationRule{Code: "FAD06", Description: "El NIT del emisor no es válido", Severity: entity.DIANRuleError}, rules[0])
+
+	merged := dian.MergeValidationRules(rules, dian.ParseValidationMessages([]string{"Regla: FAD06, Rechazo: El NIT del emisor no es válido"}))
+	assert.Len(t, merged, 1)
+
+	_, _, err = dian.ParseApplicationResponse([]byte("<Invoice/>"))
+	assert.Error(t, err)
+}
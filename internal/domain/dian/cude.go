@@ -0,0 +1,77 @@
+// Package dian: cálculo del CUDE (Código Único de Documento Electrónico) del documento equivalente
+// electrónico POS según el Anexo Técnico de documentos equivalentes.
+
+package dian
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// CudeParams contiene los datos para calcular el CUDE del documento equivalente POS.
+type CudeParams struct {
+	NumDE       string          // Prefijo + número del documento equivalente
+	FecDE       string          // Fecha de emisión YYYY-MM-DD
+	HorDE       string          // Hora de emisión HH:MM:SS-05:00
+	ValDE       decimal.Decimal // Valor antes de impuestos
+	ValImp1     decimal.Decimal // IVA (código 01)
+	ValImp2     decimal.Decimal // Impoconsumo (código 04)
+	ValImp3     decimal.Decimal // ICA (código 03)
+	ValTot      decimal.Decimal // Valor total (con impuestos)
+	NitOFE      string          // NIT del emisor
+	NumAdq      string          // Identificación del adquiriente (222222222222 para consumidor final)
+	SoftwarePIN string          // PIN del software registrado en la DIAN
+	TipoAmb     string          // '1' = Producción, '2' = Pruebas
+}
+
+// CalculateCUDE genera el CUDE (SHA-384 hex).
+// Fórmula (sin separadores): NumDE + FecDE + HorDE + ValDE + CodImp1 + ValImp1 + CodImp2 + ValImp2 +
+// CodImp3 + ValImp3 + ValTot + NitOFE + NumAdq + Software-PIN + TipoAmb
+// Como el CUDS, usa el PIN del software en lugar de la clave técnica de la resolución.
+func CalculateCUDE(p *CudeParams) (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("dian: CudeParams es obligatorio")
+	}
+	numDE := strings.Join(strings.Fields(p.NumDE), "")
+	if numDE == "" {
+		return "", fmt.Errorf("dian: NumDE es obligatorio")
+	}
+	if p.FecDE == "" || p.HorDE == "" {
+		return "", fmt.Errorf("dian: FecDE y HorDE son obligatorios para el CUDE")
+	}
+	nitOFE := onlyDigits(p.NitOFE)
+	numAdq := onlyDigits(p.NumAdq)
+	if nitOFE == "" {
+		return "", fmt.Errorf("dian: NitOFE es obligatorio para el CUDE")
+	}
+	if numAdq == "" {
+		return "", fmt.Errorf("dian: NumAdq es obligatorio para el CUDE")
+	}
+	if p.SoftwarePIN == "" {
+		return "", fmt.Errorf("dian: el PIN del software es obligatorio para el CUDE")
+	}
+	tipoAmb := p.TipoAmb
+	if tipoAmb == "" {
+		tipoAmb = "1"
+	}
+
+	cadena := numDE +
+		p.FecDE +
+		p.HorDE +
+		formatAmount(p.ValDE) +
+		CodImpIVA + formatAmount(p.ValImp1) +
+		CodImpImpoconsumo + formatAmount(p.ValImp2) +
+		CodImpICA + formatAmount(p.ValImp3) +
+		formatAmount(p.ValTot) +
+		nitOFE +
+		numAdq +
+		p.SoftwarePIN +
+		tipoAmb
+
+	hash := sha512.Sum384([]byte(cadena))
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,52 @@
+package dian_test
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/dian"
+)
+
+// Vector calculado con SHA-384 sobre
+// "POS1" + "2024-05-10" + "10:15:00-05:00" + "10000.00" + "01" + "1900.00" + "04" + "0.00" + "03" + "0.00" +
+// "11900.00" + "900123456" + "222222222222" + "12345" + "2".
+const testCudeExpected = "6e87ac5321c47e182cb60d592d25a5710dd579b871096c9e62aa9cb170e337b96c927da7ffb612d5d052a9c90fddfba7"
+
+func buildTestCudeParams() *dian.CudeParams {
+	return &dian.CudeParams{
+		NumDE:       "POS1",
+		FecDE:       "2024-05-10",
+		HorDE:       "10:15:00-05:00",
+		ValDE:       decimal.NewFromInt(10_000),
+		ValImp1:     decimal.NewFromInt(1_900),
+		ValTot:      decimal.NewFromInt(11_900),
+		NitOFE:      "900.123.456",
+		NumAdq:      "222222222222",
+		SoftwarePIN: "12345",
+		TipoAmb:     "2",
+	}
+}
+
+func TestCalculateCUDE_VectorExacto(t *testing.T) {
+	cude, err := dian.CalculateCUDE(buildTestCudeParams())
+	require.NoError(t, err)
+	assert.Equal(t, testCudeExpected, cude)
+}
+
+func TestCalculateCUDE_Errores(t *testing.T) {
+	_, err := dian.CalculateCUDE(nil)
+	assert.Error(t, err)
+
+	p := buildTestCudeParams()
+	p.SoftwarePIN = ""
+	_, err = dian.CalculateCUDE(p)
+	assert.Error(t, err, "el CUDE exige el PIN del software")
+
+	p = buildTestCudeParams()
+	p.NumAdq = ""
+	_, err = dian.CalculateCUDE(p)
+	assert.Error(t, err, "el CUDE exige la identificación del adquiriente")
+}
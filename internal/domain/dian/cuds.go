@@ -0,0 +1,72 @@
+// Package dian: cálculo del CUDS (Código Único de Documento Soporte) según el Anexo Técnico del
+// documento soporte en adquisiciones efectuadas a no obligados a facturar.
+
+package dian
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// CudsParams contiene los datos para calcular el CUDS del documento soporte o de su nota de ajuste.
+type CudsParams struct {
+	NumDS       string          // Prefijo + número del documento soporte (o de la nota de ajuste)
+	FecDS       string          // Fecha de emisión YYYY-MM-DD
+	HorDS       string          // Hora de emisión HH:MM:SS-05:00
+	ValDS       decimal.Decimal // Valor antes de impuestos
+	ValImp      decimal.Decimal // Valor del IVA (código 01)
+	ValTol      decimal.Decimal // Valor total (con impuestos)
+	NumSNO      string          // Identificación del vendedor no obligado a facturar
+	NITABS      string          // NIT del adquiriente (la empresa que emite el documento)
+	SoftwarePIN string          // PIN del software registrado en la DIAN
+	TipoAmb     string          // '1' = Producción, '2' = Pruebas
+}
+
+// CalculateCUDS genera el CUDS (SHA-384 hex).
+// Fórmula (sin separadores): NumDS + FecDS + HorDS + ValDS + CodImp + ValImp + ValTol + NumSNO + NITABS + Software-PIN + TipoAmb
+// A diferencia del CUFE, el documento soporte usa el PIN del software en lugar de la clave técnica.
+func CalculateCUDS(p *CudsParams) (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("dian: CudsParams es obligatorio")
+	}
+	numDS := strings.Join(strings.Fields(p.NumDS), "")
+	if numDS == "" {
+		return "", fmt.Errorf("dian: NumDS es obligatorio")
+	}
+	if p.FecDS == "" || p.HorDS == "" {
+		return "", fmt.Errorf("dian: FecDS y HorDS son obligatorios para el CUDS")
+	}
+	numSNO := onlyDigits(p.NumSNO)
+	nitABS := onlyDigits(p.NITABS)
+	if numSNO == "" {
+		return "", fmt.Errorf("dian: NumSNO es obligatorio para el CUDS")
+	}
+	if nitABS == "" {
+		return "", fmt.Errorf("dian: NITABS es obligatorio para el CUDS")
+	}
+	if p.SoftwarePIN == "" {
+		return "", fmt.Errorf("dian: el PIN del software es obligatorio para el CUDS")
+	}
+	tipoAmb := p.TipoAmb
+	if tipoAmb == "" {
+		tipoAmb = "1"
+	}
+
+	cadena := numDS +
+		p.FecDS +
+		p.HorDS +
+		formatAmount(p.ValDS) +
+		CodImpIVA + formatAmount(p.ValImp) +
+		formatAmount(p.ValTol) +
+		numSNO +
+		nitABS +
+		p.SoftwarePIN +
+		tipoAmb
+
+	hash := sha512.Sum384([]byte(cadena))
+	return hex.EncodeToString(hash[:]), nil
+}
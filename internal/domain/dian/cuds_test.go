@@ -0,0 +1,52 @@
+package dian_test
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/dian"
+)
+
+// Vector calculado con SHA-384 sobre
+// "DSP1" + "2024-05-10" + "10:15:00-05:00" + "500000.00" + "01" + "0.00" + "500000.00" +
+// "1020304050" + "900123456" + "12345" + "2".
+const testCudsExpected = "44a1570921bad65f4e4c0e57685e4c0732783803f9b52d38e87f28b5400d19342e6b46eb6613f41948c8d78239be3533"
+
+func buildTestCudsParams() *dian.CudsParams {
+	return &dian.CudsParams{
+		NumDS:       "DSP1",
+		FecDS:       "2024-05-10",
+		HorDS:       "10:15:00-05:00",
+		ValDS:       decimal.NewFromInt(500_000),
+		ValImp:      decimal.Zero,
+		ValTol:      decimal.NewFromInt(500_000),
+		NumSNO:      "1.020.304.050",
+		NITABS:      "900123456",
+		SoftwarePIN: "12345",
+		TipoAmb:     "2",
+	}
+}
+
+func TestCalculateCUDS_VectorExacto(t *testing.T) {
+	cuds, err := dian.CalculateCUDS(buildTestCudsParams())
+	require.NoError(t, err)
+	assert.Equal(t, testCudsExpected, cuds)
+}
+
+func TestCalculateCUDS_Errores(t *testing.T) {
+	_, err := dian.CalculateCUDS(nil)
+	assert.Error(t, err)
+
+	p := buildTestCudsParams()
+	p.SoftwarePIN = ""
+	_, err = dian.CalculateCUDS(p)
+	assert.Error(t, err, "el CUDS exige el PIN del software")
+
+	p = buildTestCudsParams()
+	p.NumSNO = ""
+	_, err = dian.CalculateCUDS(p)
+	assert.Error(t, err, "el CUDS exige la identificación del vendedor")
+}
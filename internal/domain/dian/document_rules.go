@@ -0,0 +1,303 @@
+package dian
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+
+	"github.com/shopspring/decimal"
+)
+
+// Reglas de la validación local previa a la firma. Los códigos son propios (prefijo LV): agrupan las
+// causas de rechazo más frecuentes del Anexo Técnico 1.9 para detectarlas antes de enviar a la DIAN.
+const (
+	RuleSchema             = "LV-XSD"   // el XML no cumple el esquema UBL 2.1
+	RuleMandatoryField     = "LV-REQ"   // falta un dato obligatorio del documento
+	RuleSupplierNIT        = "LV-NIT01" // dígito de verificación del NIT del emisor
+	RuleCustomerNIT        = "LV-NIT02" // dígito de verificación del NIT del adquiriente
+	RuleResolutionPrefix   = "LV-RES01" // el número no usa el prefijo de la resolución
+	RuleResolutionRange    = "LV-RES02" // número fuera del rango autorizado
+	RuleResolutionValidity = "LV-RES03" // fecha de emisión fuera de la vigencia de la resolución
+	RuleLineCount          = "LV-TOT01" // LineCountNumeric distinto del número de líneas
+	RuleLineAmount         = "LV-TOT02" // valor de la línea ≠ cantidad × precio − descuentos + cargos
+	RuleLineExtension      = "LV-TOT03" // LineExtensionAmount ≠ suma de las líneas
+	RuleTaxAmount          = "LV-TOT04" // impuesto ≠ base × tarifa o TaxTotal ≠ suma de subtotales
+	RuleTaxInclusive       = "LV-TOT05" // TaxInclusiveAmount ≠ LineExtensionAmount + impuestos
+	RuleAllowanceTotal     = "LV-TOT06" // AllowanceTotalAmount / ChargeTotalAmount ≠ descuentos / cargos globales
+	RulePayable            = "LV-TOT07" // PayableAmount ≠ TaxInclusive − descuentos + cargos − anticipos
+)
+
+// Raíces UBL de los documentos que se validan.
+const (
+	RootInvoice    = "Invoice"
+	RootCreditNote = "CreditNote"
+	RootDebitNote  = "DebitNote"
+)
+
+// roundingTolerance diferencia admitida por redondeo en cada comparación de valores (2 decimales).
+var roundingTolerance = decimal.NewFromFloat(0.01)
+
+// ValidationDocument datos del XML UBL generado que revisan las reglas de negocio locales.
+type ValidationDocument struct {
+	Root               string // Invoice | CreditNote | DebitNote
+	ID                 string // prefijo + número
+	UUID               string // CUFE/CUDE
+	ProfileExecutionID string
+	IssueDate          string // AAAA-MM-DD
+	IssueTime          string
+	TypeCode           string // InvoiceTypeCode (solo facturas)
+	Currency           string
+	LineCountNumeric   string
+
+	SupplierID     string // cac:AccountingSupplierParty/cac:PartyIdentification/cbc:ID
+	SupplierScheme string // tipo de identificación (31 = NIT)
+	CustomerID     string
+	CustomerScheme string
+
+	Resolution *ValidationResolution // sts:InvoiceControl; nil si el documento no la lleva
+
+	BillingReferenceID string // factura afectada (notas)
+
+	Lines      []ValidationLine
+	TaxTotals  []ValidationTaxTotal
+	Allowances decimal.Decimal // descuentos globales (cac:AllowanceCharge ChargeIndicator=false)
+	Charges    decimal.Decimal // cargos globales
+	Totals     ValidationTotals
+	HasTotals  bool // el documento trae LegalMonetaryTotal / RequestedMonetaryTotal
+}
+
+// ValidationResolution rango autorizado de la resolución (sts:InvoiceControl).
+type ValidationResolution struct {
+	Number    string
+	Prefix    string
+	From, To  string
+	StartDate string
+	EndDate   string
+}
+
+// ValidationLine línea del documento.
+type ValidationLine struct {
+	ID            string
+	Quantity      decimal.Decimal
+	LineExtension decimal.Decimal
+	PriceAmount   decimal.Decimal
+	BaseQuantity  decimal.Decimal // cero = 1
+	Allowances    decimal.Decimal
+	Charges       decimal.Decimal
+	TaxTotals     []ValidationTaxTotal
+}
+
+// ValidationTaxTotal cac:TaxTotal con sus subtotales.
+type ValidationTaxTotal struct {
+	TaxAmount decimal.Decimal
+	Subtotals []ValidationTaxSubtotal
+}
+
+// ValidationTaxSubtotal cac:TaxSubtotal: porcentual (Percent) o por unidad (PerUnitAmount).
+type ValidationTaxSubtotal struct {
+	TaxCode       string
+	TaxableAmount decimal.Decimal
+	TaxAmount     decimal.Decimal
+	Percent       *decimal.Decimal
+	BaseUnit      decimal.Decimal
+	PerUnitAmount *decimal.Decimal
+}
+
+// ValidationTotals cac:LegalMonetaryTotal (o RequestedMonetaryTotal en la nota débito).
+type ValidationTotals struct {
+	LineExtension  decimal.Decimal
+	TaxExclusive   decimal.Decimal
+	TaxInclusive   decimal.Decimal
+	AllowanceTotal decimal.Decimal
+	ChargeTotal    decimal.Decimal
+	Prepaid        decimal.Decimal
+	Payable        decimal.Decimal
+}
+
+// CheckDocumentRules revisa las reglas de negocio DIAN sobre el documento generado: datos obligatorios,
+// dígito de verificación de los NIT, rango y vigencia de la resolución y coherencia de los totales.
+// Devuelve las reglas incumplidas; los NIT sin dígito de verificación quedan como notificación.
+func CheckDocumentRules(doc *ValidationDocument) []entity.DIANValidationRule {
+	var rules []entity.DIANValidationRule
+	fail := func(code, format string, args ...any) {
+		rules = append(rules, entity.DIANValidationRule{Code: code, Description: fmt.Sprintf(format, args...), Severity: entity.DIANRuleError})
+	}
+	notify := func(code, format string, args ...any) {
+		rules = append(rules, entity.DIANValidationRule{Code: code, Description: fmt.Sprintf(format, args...), Severity: entity.DIANRuleNotification})
+	}
+
+	// ── Datos obligatorios ────────────────────────────────────────────────────
+	required := []struct{ value, name string }{
+		{doc.ID, "cbc:ID (número del documento)"},
+		{doc.UUID, "cbc:UUID (CUFE/CUDE)"},
+		{doc.ProfileExecutionID, "cbc:ProfileExecutionID (ambiente)"},
+		{doc.IssueDate, "cbc:IssueDate"},
+		{doc.IssueTime, "cbc:IssueTime"},
+		{doc.Currency, "cbc:DocumentCurrencyCode"},
+		{doc.SupplierID, "identificación del emisor"},
+		{doc.CustomerID, "identificación del adquiriente"},
+	}
+	if doc.Root == RootInvoice {
+		required = append(required, struct{ value, name string }{doc.TypeCode, "cbc:InvoiceTypeCode"})
+	} else {
+		required = append(required, struct{ value, name string }{doc.BillingReferenceID, "cac:BillingReference (factura afectada)"})
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			fail(RuleMandatoryField, "falta %s", r.name)
+		}
+	}
+	if doc.ProfileExecutionID != "" && doc.ProfileExecutionID != "1" && doc.ProfileExecutionID != "2" {
+		fail(RuleMandatoryField, "cbc:ProfileExecutionID %q: debe ser 1 (producción) o 2 (pruebas)", doc.ProfileExecutionID)
+	}
+	if len(doc.Lines) == 0 {
+		fail(RuleMandatoryField, "el documento no tiene líneas")
+	}
+	if !doc.HasTotals {
+		fail(RuleMandatoryField, "falta el grupo de totales del documento")
+	}
+	if doc.Root == RootInvoice && doc.Resolution == nil {
+		fail(RuleMandatoryField, "falta sts:InvoiceControl (resolución de facturación)")
+	}
+
+	// ── NIT: dígito de verificación ──────────────────────────────────────────
+	checkNIT := func(code, party, id, scheme string) {
+		if scheme != dian.IdentificationTypeNIT || id == "" {
+			return
+		}
+		if len(digits(id)) == 9 {
+			notify(code, "el NIT del %s (%s) no incluye dígito de verificación", party, id)
+			return
+		}
+		if err := dian.ValidateNITVerificationDigit(id); err != nil {
+			fail(code, "NIT del %s %s: %v", party, id, err)
+		}
+	}
+	checkNIT(RuleSupplierNIT, "emisor", doc.SupplierID, doc.SupplierScheme)
+	checkNIT(RuleCustomerNIT, "adquiriente", doc.CustomerID, doc.CustomerScheme)
+
+	// ── Resolución: prefijo, rango y vigencia ────────────────────────────────
+	if res := doc.Resolution; res != nil && doc.ID != "" {
+		number := doc.ID
+		if res.Prefix != "" {
+			if !strings.HasPrefix(doc.ID, res.Prefix) {
+				fail(RuleResolutionPrefix, "el número %s no usa el prefijo autorizado %s", doc.ID, res.Prefix)
+			}
+			number = strings.TrimPrefix(doc.ID, res.Prefix)
+		}
+		n, errN := strconv.ParseInt(number, 10, 64)
+		from, errF := strconv.ParseInt(res.From, 10, 64)
+		to, errT := strconv.ParseInt(res.To, 10, 64)
+		switch {
+		case errN != nil:
+			fail(RuleResolutionRange, "el consecutivo %q no es numérico", number)
+		case errF != nil || errT != nil:
+			fail(RuleResolutionRange, "rango de la resolución %s inválido (%s-%s)", res.Number, res.From, res.To)
+		case n < from || n > to:
+			fail(RuleResolutionRange, "el número %d está fuera del rango autorizado %d-%d de la resolución %s", n, from, to, res.Number)
+		}
+		issued, errI := time.Parse("2006-01-02", doc.IssueDate)
+		start, errS := time.Parse("2006-01-02", res.StartDate)
+		end, errE := time.Parse("2006-01-02", res.EndDate)
+		if errI == nil && errS == nil && errE == nil && (issued.Before(start) || issued.After(end)) {
+			fail(RuleResolutionValidity, "la fecha de emisión %s está fuera de la vigencia %s a %s de la resolución %s", doc.IssueDate, res.StartDate, res.EndDate, res.Number)
+		}
+	}
+
+	// ── Totales ───────────────────────────────────────────────────────────────
+	if doc.LineCountNumeric != "" && doc.LineCountNumeric != strconv.Itoa(len(doc.Lines)) {
+		fail(RuleLineCount, "LineCountNumeric %s, pero el documento tiene %d líneas", doc.LineCountNumeric, len(doc.Lines))
+	}
+	sumLines := decimal.Zero
+	for _, l := range doc.Lines {
+		sumLines = sumLines.Add(l.LineExtension)
+		base := l.BaseQuantity
+		if !base.IsPositive() {
+			base = decimal.NewFromInt(1)
+		}
+		expected := l.Quantity.Mul(l.PriceAmount).Div(base).Sub(l.Allowances).Add(l.Charges)
+		if !closeTo(expected, l.LineExtension, 1) {
+			fail(RuleLineAmount, "línea %s: LineExtensionAmount %s ≠ cantidad × precio − descuentos + cargos (%s)", l.ID, fmtAmount(l.LineExtension), fmtAmount(expected))
+		}
+		checkTaxTotals(fail, "línea "+l.ID+": ", l.TaxTotals, 1)
+	}
+	// Los subtotales del documento agrupan las líneas por tarifa: admiten el redondeo de cada línea.
+	taxes := checkTaxTotals(fail, "", doc.TaxTotals, len(doc.Lines))
+	if !doc.HasTotals {
+		return rules
+	}
+	t := doc.Totals
+	n := len(doc.Lines)
+	if !closeTo(sumLines, t.LineExtension, n) {
+		fail(RuleLineExtension, "LineExtensionAmount %s ≠ suma de las líneas %s", fmtAmount(t.LineExtension), fmtAmount(sumLines))
+	}
+	if !closeTo(t.LineExtension.Add(taxes), t.TaxInclusive, n+len(doc.TaxTotals)) {
+		fail(RuleTaxInclusive, "TaxInclusiveAmount %s ≠ LineExtensionAmount + impuestos (%s)", fmtAmount(t.TaxInclusive), fmtAmount(t.LineExtension.Add(taxes)))
+	}
+	if !closeTo(doc.Allowances, t.AllowanceTotal, 1) {
+		fail(RuleAllowanceTotal, "AllowanceTotalAmount %s ≠ descuentos globales %s", fmtAmount(t.AllowanceTotal), fmtAmount(doc.Allowances))
+	}
+	if !closeTo(doc.Charges, t.ChargeTotal, 1) {
+		fail(RuleAllowanceTotal, "ChargeTotalAmount %s ≠ cargos globales %s", fmtAmount(t.ChargeTotal), fmtAmount(doc.Charges))
+	}
+	payable := t.TaxInclusive.Sub(t.AllowanceTotal).Add(t.ChargeTotal).Sub(t.Prepaid)
+	if !closeTo(payable, t.Payable, 1) {
+		fail(RulePayable, "PayableAmount %s ≠ TaxInclusiveAmount − descuentos + cargos − anticipos (%s)", fmtAmount(t.Payable), fmtAmount(payable))
+	}
+	return rules
+}
+
+// checkTaxTotals revisa cada cac:TaxTotal (suma de subtotales y base × tarifa) y devuelve el total.
+// terms es el número de valores redondeados que acumula cada subtotal.
+func checkTaxTotals(fail func(code, format string, args ...any), scope string, totals []ValidationTaxTotal, terms int) decimal.Decimal {
+	sum := decimal.Zero
+	for _, tt := range totals {
+		sum = sum.Add(tt.TaxAmount)
+		subSum := decimal.Zero
+		for _, st := range tt.Subtotals {
+			subSum = subSum.Add(st.TaxAmount)
+			var expected decimal.Decimal
+			switch {
+			case st.PerUnitAmount != nil:
+				expected = st.BaseUnit.Mul(*st.PerUnitAmount)
+			case st.Percent != nil:
+				expected = st.TaxableAmount.Mul(*st.Percent).Div(hundred)
+			default:
+				continue
+			}
+			if !closeTo(expected, st.TaxAmount, terms) {
+				fail(RuleTaxAmount, "%simpuesto %s: TaxAmount %s ≠ base × tarifa (%s)", scope, st.TaxCode, fmtAmount(st.TaxAmount), fmtAmount(expected))
+			}
+		}
+		if len(tt.Subtotals) > 0 && !closeTo(subSum, tt.TaxAmount, len(tt.Subtotals)) {
+			fail(RuleTaxAmount, "%sTaxTotal %s ≠ suma de sus subtotales %s", scope, fmtAmount(tt.TaxAmount), fmtAmount(subSum))
+		}
+	}
+	return sum
+}
+
+// closeTo compara dos valores admitiendo el redondeo a 2 decimales de n sumandos.
+func closeTo(a, b decimal.Decimal, n int) bool {
+	if n < 1 {
+		n = 1
+	}
+	return a.Sub(b).Abs().LessThanOrEqual(roundingTolerance.Mul(decimal.NewFromInt(int64(n))))
+}
+
+func fmtAmount(d decimal.Decimal) string {
+	return d.Round(2).StringFixed(2)
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
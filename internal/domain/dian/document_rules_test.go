@@ -0,0 +1,126 @@
+package dian_test
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func decPtr(s string) *decimal.Decimal {
+	d := dec(s)
+	return &d
+}
+
+// validRulesDocument factura de dos líneas (IVA 19% y bolsa plástica por unidad) que cumple todas las reglas.
+func validRulesDocument() *dian.ValidationDocument {
+	return &dian.ValidationDocument{
+		Root: dian.RootInvoice, ID: "SETP1001", UUID: "cufe", ProfileExecutionID: "2",
+		IssueDate: "2024-05-10", IssueTime: "10:15:00-05:00", TypeCode: "01", Currency: "COP", LineCountNumeric: "2",
+		SupplierID: "9001112221", SupplierScheme: pkgdian.IdentificationTypeNIT,
+		CustomerID: "10203040", CustomerScheme: pkgdian.IdentificationTypeCC,
+		Resolution: &dian.ValidationResolution{
+			Number: "18760000001", Prefix: "SETP", From: "1000", To: "5000", StartDate: "2024-01-01", EndDate: "2025-01-01",
+		},
+		Lines: []dian.ValidationLine{
+			{
+				ID: "1", Quantity: dec("2"), PriceAmount: dec("10000"), BaseQuantity: dec("1"), Allowances: dec("1000"), LineExtension: dec("19000"),
+				TaxTotals: []dian.ValidationTaxTotal{{TaxAmount: dec("3610"), Subtotals: []dian.ValidationTaxSubtotal{
+					{TaxCode: pkgdian.TaxCodeIVA, TaxableAmount: dec("19000"), TaxAmount: dec("3610"), Percent: decPtr("19")},
+				}}},
+			},
+			{
+				ID: "2", Quantity: dec("3"), PriceAmount: dec("0"), LineExtension: dec("0"),
+				TaxTotals: []dian.ValidationTaxTotal{{TaxAmount: dec("198"), Subtotals: []dian.ValidationTaxSubtotal{
+					{TaxCode: pkgdian.TaxCodeINCBolsas, TaxAmount: dec("198"), BaseUnit: dec("3"), PerUnitAmount: decPtr("66")},
+				}}},
+			},
+		},
+		TaxTotals: []dian.ValidationTaxTotal{
+			{TaxAmount: dec("3610"), Subtotals: []dian.ValidationTaxSubtotal{{TaxCode: pkgdian.TaxCodeIVA, TaxableAmount: dec("19000"), TaxAmount: dec("3610"), Percent: decPtr("19")}}},
+			{TaxAmount: dec("198"), Subtotals: []dian.ValidationTaxSubtotal{{TaxCode: pkgdian.TaxCodeINCBolsas, TaxAmount: dec("198"), BaseUnit: dec("3"), PerUnitAmount: decPtr("66")}}},
+		},
+		Allowances: dec("500"),
+		HasTotals:  true,
+		Totals: dian.ValidationTotals{
+			LineExtension: dec("19000"), TaxExclusive: dec("19000"), TaxInclusive: dec("22808"),
+			AllowanceTotal: dec("500"), Payable: dec("22308"),
+		},
+	}
+}
+
+func ruleCodes(rules []entity.DIANValidationRule) []string {
+	codes := make([]string, 0, len(rules))
+	for _, r := range rules {
+		codes = append(codes, r.Code)
+	}
+	return codes
+}
+
+func TestCheckDocumentRules_DocumentoValido(t *testing.T) {
+	assert.Empty(t, dian.CheckDocumentRules(validRulesDocument()))
+}
+
+func TestCheckDocumentRules_Incumplimientos(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(d *dian.ValidationDocument)
+		code   string
+	}{
+		{"falta el CUFE", func(d *dian.ValidationDocument) { d.UUID = "" }, dian.RuleMandatoryField},
+		{"ambiente inválido", func(d *dian.ValidationDocument) { d.ProfileExecutionID = "3" }, dian.RuleMandatoryField},
+		{"factura sin resolución", func(d *dian.ValidationDocument) { d.Resolution = nil }, dian.RuleMandatoryField},
+		{"nota sin factura referenciada", func(d *dian.ValidationDocument) { d.Root = dian.RootCreditNote; d.Resolution = nil }, dian.RuleMandatoryField},
+		{"DV del emisor", func(d *dian.ValidationDocument) { d.SupplierID = "9001112220" }, dian.RuleSupplierNIT},
+		{"DV del adquiriente", func(d *dian.ValidationDocument) {
+			d.CustomerID, d.CustomerScheme = "8001972685", pkgdian.IdentificationTypeNIT
+		}, dian.RuleCustomerNIT},
+		{"prefijo distinto", func(d *dian.ValidationDocument) { d.ID = "FE1001" }, dian.RuleResolutionPrefix},
+		{"número fuera de rango", func(d *dian.ValidationDocument) { d.ID = "SETP5001" }, dian.RuleResolutionRange},
+		{"fecha fuera de vigencia", func(d *dian.ValidationDocument) { d.IssueDate = "2025-02-01" }, dian.RuleResolutionValidity},
+		{"LineCountNumeric", func(d *dian.ValidationDocument) { d.LineCountNumeric = "3" }, dian.RuleLineCount},
+		{"valor de línea", func(d *dian.ValidationDocument) { d.Lines[0].Allowances = decimal.Zero }, dian.RuleLineAmount},
+		{"suma de líneas", func(d *dian.ValidationDocument) { d.Totals.LineExtension = dec("20000") }, dian.RuleLineExtension},
+		{"impuesto ≠ base × tarifa", func(d *dian.ValidationDocument) { d.TaxTotals[0].Subtotals[0].TaxAmount = dec("3600") }, dian.RuleTaxAmount},
+		{"impuesto por unidad", func(d *dian.ValidationDocument) { d.Lines[1].TaxTotals[0].Subtotals[0].BaseUnit = dec("2") }, dian.RuleTaxAmount},
+		{"TaxInclusiveAmount", func(d *dian.ValidationDocument) { d.Totals.TaxInclusive = dec("22900") }, dian.RuleTaxInclusive},
+		{"descuentos globales", func(d *dian.ValidationDocument) { d.Totals.AllowanceTotal = dec("0") }, dian.RuleAllowanceTotal},
+		{"PayableAmount", func(d *dian.ValidationDocument) { d.Totals.Payable = dec("22808") }, dian.RulePayable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := validRulesDocument()
+			tt.mutate(doc)
+			rules := dian.CheckDocumentRules(doc)
+			assert.Contains(t, ruleCodes(rules), tt.code)
+			for _, r := range rules {
+				if r.Code == tt.code {
+					assert.Equal(t, entity.DIANRuleError, r.Severity)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDocumentRules_NITSinDVEsNotificacion(t *testing.T) {
+	doc := validRulesDocument()
+	doc.SupplierID = "900111222"
+	rules := dian.CheckDocumentRules(doc)
+	if assert.Len(t, rules, 1) {
+		assert.Equal(t, dian.RuleSupplierNIT, rules[0].Code)
+		assert.Equal(t, entity.DIANRuleNotification, rules[0].Severity)
+	}
+	assert.Empty(t, dian.FormatRejections(rules), "la notificación no bloquea el envío")
+}
+
+func TestCheckDocumentRules_ToleranciaDeRedondeo(t *testing.T) {
+	doc := validRulesDocument()
+	doc.Totals.Payable = doc.Totals.Payable.Add(dec("0.01"))
+	assert.Empty(t, dian.CheckDocumentRules(doc), "un centavo de redondeo no es rechazo")
+}
@@ -0,0 +1,121 @@
+// Package dian: eventos RADIAN que el adquiriente emite sobre las facturas electrónicas recibidas
+// (ApplicationResponse): CUDE del evento, orden de los eventos y término de la aceptación tácita.
+
+package dian
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// TacitAcceptanceBusinessDays días hábiles tras el recibo del bien (032) sin reclamo ni aceptación
+// expresa para que la factura quede aceptada tácitamente (art. 773 del Código de Comercio).
+const TacitAcceptanceBusinessDays = 3
+
+// EventCudeParams contiene los datos para calcular el CUDE de un evento (ApplicationResponse).
+type EventCudeParams struct {
+	NumDE            string // Número del evento (cbc:ID del ApplicationResponse)
+	FecEmi           string // Fecha de emisión del evento YYYY-MM-DD
+	HorEmi           string // Hora de emisión HH:MM:SS-05:00
+	NitFE            string // NIT de quien emite el evento (SenderParty)
+	DocAdq           string // Identificación del receptor del evento (ReceiverParty)
+	ResponseCode     string // Código del evento (030, 031, 032, 033)
+	ID               string // Número de la factura referenciada (prefijo + número)
+	DocumentTypeCode string // Tipo del documento referenciado (01 factura de venta)
+	SoftwarePIN      string // PIN del software registrado en la DIAN
+}
+
+// CalculateEventCUDE genera el CUDE del evento (SHA-384 hex).
+// Fórmula (sin separadores): Num_DE + Fec_Emi + Hor_Emi + NitFE + DocAdq + ResponseCode + ID +
+// DocumentTypeCode + Software-PIN
+func CalculateEventCUDE(p *EventCudeParams) (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("dian: EventCudeParams es obligatorio")
+	}
+	numDE := strings.Join(strings.Fields(p.NumDE), "")
+	if numDE == "" {
+		return "", fmt.Errorf("dian: NumDE es obligatorio")
+	}
+	if p.FecEmi == "" || p.HorEmi == "" {
+		return "", fmt.Errorf("dian: FecEmi y HorEmi son obligatorios para el CUDE del evento")
+	}
+	nitFE := onlyDigits(p.NitFE)
+	docAdq := onlyDigits(p.DocAdq)
+	if nitFE == "" || docAdq == "" {
+		return "", fmt.Errorf("dian: NitFE y DocAdq son obligatorios para el CUDE del evento")
+	}
+	if p.ResponseCode == "" || p.ID == "" {
+		return "", fmt.Errorf("dian: ResponseCode e ID son obligatorios para el CUDE del evento")
+	}
+	if p.SoftwarePIN == "" {
+		return "", fmt.Errorf("dian: el PIN del software es obligatorio para el CUDE del evento")
+	}
+	docType := p.DocumentTypeCode
+	if docType == "" {
+		docType = dian.InvoiceTypeVenta
+	}
+
+	cadena := numDE +
+		p.FecEmi +
+		p.HorEmi +
+		nitFE +
+		docAdq +
+		p.ResponseCode +
+		strings.Join(strings.Fields(p.ID), "") +
+		docType +
+		p.SoftwarePIN
+
+	hash := sha512.Sum384([]byte(cadena))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// eventPrerequisite evento que debe estar validado por la DIAN antes de emitir cada evento del adquiriente.
+var eventPrerequisite = map[string]string{
+	dian.EventAcuseRecibo:       "",
+	dian.EventReciboBien:        dian.EventAcuseRecibo,
+	dian.EventAceptacionExpresa: dian.EventReciboBien,
+	dian.EventReclamo:           dian.EventReciboBien,
+}
+
+// CheckEventSequence valida que el adquiriente pueda emitir el evento code. validated son los eventos
+// ya validados por la DIAN (o registrados, como la aceptación tácita) y pending los que siguen en curso.
+// Cada evento se emite una sola vez, en orden 030 → 032 → 033 | 031, y la aceptación (expresa o tácita)
+// y el reclamo se excluyen entre sí.
+func CheckEventSequence(code string, validated, pending map[string]bool) error {
+	prerequisite, ok := eventPrerequisite[code]
+	if !ok {
+		return fmt.Errorf("dian: el adquiriente no emite el evento %q", code)
+	}
+	if validated[code] || pending[code] {
+		return fmt.Errorf("dian: el evento %s ya fue emitido para la factura", code)
+	}
+	if prerequisite != "" && !validated[prerequisite] {
+		return fmt.Errorf("dian: el evento %s requiere el evento %s validado por la DIAN", code, prerequisite)
+	}
+	if code == dian.EventAceptacionExpresa || code == dian.EventReclamo {
+		for _, other := range []string{dian.EventAceptacionExpresa, dian.EventReclamo, dian.EventAceptacionTacita} {
+			if validated[other] || pending[other] {
+				return fmt.Errorf("dian: la factura ya tiene el evento %s; aceptación y reclamo se excluyen", other)
+			}
+		}
+	}
+	return nil
+}
+
+// TacitAcceptanceDeadline instante desde el que la factura queda aceptada tácitamente: el fin del
+// tercer día hábil (lunes a viernes) siguiente al recibo del bien. No descuenta festivos.
+func TacitAcceptanceDeadline(goodsReceivedAt time.Time) time.Time {
+	d := time.Date(goodsReceivedAt.Year(), goodsReceivedAt.Month(), goodsReceivedAt.Day(), 0, 0, 0, 0, goodsReceivedAt.Location())
+	for n := 0; n < TacitAcceptanceBusinessDays; {
+		d = d.AddDate(0, 0, 1)
+		if wd := d.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			n++
+		}
+	}
+	return d.AddDate(0, 0, 1)
+}
@@ -0,0 +1,61 @@
+package dian_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/dian"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// Vector calculado con SHA-384 sobre
+// "EV1" + "2024-05-10" + "10:15:00-05:00" + "900123456" + "800987654" + "030" + "SETP990000001" + "01" + "12345".
+const testEventCudeExpected = "2ec52393f2716e1666a39e172e932b8ed7f1f76283081c569132f6ac08d3519cd4767deba76d1cfb63a77796bf459f8f"
+
+func TestCalculateEventCUDE_VectorExacto(t *testing.T) {
+	cude, err := dian.CalculateEventCUDE(&dian.EventCudeParams{
+		NumDE:        "EV1",
+		FecEmi:       "2024-05-10",
+		HorEmi:       "10:15:00-05:00",
+		NitFE:        "900.123.456",
+		DocAdq:       "800987654",
+		ResponseCode: pkgdian.EventAcuseRecibo,
+		ID:           "SETP990000001",
+		SoftwarePIN:  "12345",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testEventCudeExpected, cude)
+
+	_, err = dian.CalculateEventCUDE(&dian.EventCudeParams{NumDE: "EV1", FecEmi: "2024-05-10", HorEmi: "10:15:00-05:00",
+		NitFE: "900123456", DocAdq: "800987654", ResponseCode: "030", ID: "SETP990000001"})
+	assert.Error(t, err, "el CUDE del evento exige el PIN del software")
+}
+
+func TestCheckEventSequence(t *testing.T) {
+	none := map[string]bool{}
+	assert.NoError(t, dian.CheckEventSequence(pkgdian.EventAcuseRecibo, none, none))
+	assert.Error(t, dian.CheckEventSequence(pkgdian.EventAceptacionTacita, none, none), "034 lo emite el facturador")
+	assert.Error(t, dian.CheckEventSequence(pkgdian.EventReciboBien, none, map[string]bool{"030": true}),
+		"032 exige el acuse validado, no solo enviado")
+	assert.Error(t, dian.CheckEventSequence(pkgdian.EventAcuseRecibo, map[string]bool{"030": true}, none))
+
+	received := map[string]bool{"030": true, "032": true}
+	assert.NoError(t, dian.CheckEventSequence(pkgdian.EventAceptacionExpresa, received, none))
+	assert.NoError(t, dian.CheckEventSequence(pkgdian.EventReclamo, received, none))
+	assert.Error(t, dian.CheckEventSequence(pkgdian.EventReclamo, received, map[string]bool{"033": true}))
+	assert.Error(t, dian.CheckEventSequence(pkgdian.EventAceptacionExpresa,
+		map[string]bool{"030": true, "032": true, "034": true}, none), "ya aceptada tácitamente")
+}
+
+func TestTacitAcceptanceDeadline(t *testing.T) {
+	bogota := time.FixedZone("COT", -5*3600)
+	// Miércoles → jueves, viernes y lunes hábiles: aceptada desde el martes 00:00.
+	got := dian.TacitAcceptanceDeadline(time.Date(2024, 5, 8, 16, 30, 0, 0, bogota))
+	assert.Equal(t, time.Date(2024, 5, 14, 0, 0, 0, 0, bogota), got)
+	// Viernes → lunes, martes y miércoles.
+	got = dian.TacitAcceptanceDeadline(time.Date(2024, 5, 10, 9, 0, 0, 0, bogota))
+	assert.Equal(t, time.Date(2024, 5, 16, 0, 0, 0, 0, bogota), got)
+}
@@ -0,0 +1,28 @@
+package dian
+
+import (
+	"errors"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+// ErrNotElectronicInvoice el archivo no contiene una factura electrónica UBL (AttachedDocument o Invoice).
+var ErrNotElectronicInvoice = errors.New("dian: el archivo no contiene una factura electrónica")
+
+// ReceivedInvoice factura electrónica de un proveedor leída de su AttachedDocument (o de un Invoice suelto).
+type ReceivedInvoice struct {
+	// Document trae emisor, adquiriente, número, CUFE, totales, líneas e impuestos; sin IDs ni estado.
+	Document *entity.ReceivedDocument
+
+	InvoiceXML     []byte // Invoice firmado por el proveedor
+	ContainerXML   []byte // AttachedDocument completo; nil si llegó el Invoice suelto
+	UUIDScheme     string // cbc:UUID@schemeName del Invoice (CUFE-SHA384)
+	OrderReference string // cac:OrderReference/cbc:ID: orden de compra del adquiriente
+
+	// Validación de la DIAN incluida en el AttachedDocument (cac:ParentDocumentLineReference). Sin
+	// ApplicationResponse el código viene de cac:ResultOfVerification, que nadie firma.
+	ParentCUFE             string // CUFE que declara el contenedor
+	DIANResponseCode       string // 02 validado, 04 rechazado; vacío si no viene la validación
+	ApplicationResponseXML []byte // ApplicationResponse firmado por la DIAN; nil si no viene
+	ResponseCUFE           string // CUFE del documento que valida el ApplicationResponse
+}
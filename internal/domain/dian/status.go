@@ -0,0 +1,37 @@
+package dian
+
+// Ambientes de envío DIAN (DIAN_ENV).
+const (
+	AppEnvTest = "test" // habilitación/pruebas
+	AppEnvProd = "prod" // producción
+	AppEnvDev  = "dev"  // local: no envía al WS DIAN
+)
+
+// Códigos de estado (StatusCode) de GetStatusZip y SendEventUpdateStatus.
+const (
+	StatusCodeProcessed      = "00" // Procesado correctamente
+	StatusCodeNSUNotFound    = "66" // NSU no encontrado
+	StatusCodeTrackNotFound  = "90" // TrackId no encontrado (aún no registrado)
+	StatusCodeInValidation   = "98" // En proceso de validación
+	StatusCodeValidationErrs = "99" // Validaciones con errores en campos mandatorios
+)
+
+// StatusResult resultado de la validación DIAN de un envío (primer documento del ZIP).
+type StatusResult struct {
+	IsValid             bool
+	StatusCode          string // ver StatusCode*
+	StatusDescription   string
+	StatusMessage       string
+	ErrorMessages       []string // "Regla: FAD06, Rechazo: …" / "Regla: FAJ44b, Notificación: …"
+	DocumentKey         string   // CUFE/CUDE del documento validado
+	ApplicationResponse []byte   // XML ApplicationResponse decodificado de XmlBase64Bytes (puede ser vacío)
+}
+
+// IsPending indica que la DIAN aún no tiene un resultado definitivo para el TrackID.
+func (r *StatusResult) IsPending() bool {
+	switch r.StatusCode {
+	case "", StatusCodeNSUNotFound, StatusCodeTrackNotFound, StatusCodeInValidation:
+		return !r.IsValid
+	}
+	return false
+}
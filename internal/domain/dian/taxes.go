@@ -0,0 +1,189 @@
+package dian
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// PercentRate normaliza una tarifa a porcentaje: los productos guardan 19 y los detalles 0.19.
+// Valores <= 1 se interpretan como fracción, igual que al calcular los totales de la factura.
+func PercentRate(rate decimal.Decimal) decimal.Decimal {
+	if rate.GreaterThan(decimal.NewFromInt(1)) {
+		return rate
+	}
+	return rate.Mul(hundred)
+}
+
+// LineTaxes calcula los impuestos de una línea de venta según la parametrización del producto:
+//   - IVA (01) sobre el subtotal; se incluye siempre, con tarifa 0 para líneas exentas.
+//   - INC (04) sobre el subtotal cuando el producto tiene IncRate.
+//   - Impuesto por unidad (p. ej. 22 bolsas) = cantidad × UnitTaxAmount.
+//
+// Cada valor se redondea a 2 decimales; el TaxTotal de la factura es la suma de estos valores.
+func LineTaxes(product *entity.Product, quantity, subtotal decimal.Decimal) []*entity.InvoiceTax {
+	ivaRate := PercentRate(product.TaxRate)
+	taxes := []*entity.InvoiceTax{{
+		TaxCode:    dian.TaxCodeIVA,
+		BaseAmount: subtotal.Round(2),
+		Rate:       ivaRate,
+		Amount:     subtotal.Mul(ivaRate).Div(hundred).Round(2),
+	}}
+	if product.IncRate.GreaterThan(decimal.Zero) {
+		taxes = append(taxes, &entity.InvoiceTax{
+			TaxCode:    dian.TaxCodeINC,
+			BaseAmount: subtotal.Round(2),
+			Rate:       product.IncRate,
+			Amount:     subtotal.Mul(product.IncRate).Div(hundred).Round(2),
+		})
+	}
+	if product.UnitTaxAmount.GreaterThan(decimal.Zero) {
+		code := strings.TrimSpace(product.UnitTaxCode)
+		if code == "" {
+			code = dian.TaxCodeINCBolsas
+		}
+		taxes = append(taxes, &entity.InvoiceTax{
+			TaxCode:       code,
+			BaseAmount:    decimal.Zero,
+			PerUnitAmount: product.UnitTaxAmount,
+			Quantity:      quantity,
+			Amount:        quantity.Mul(product.UnitTaxAmount).Round(2),
+		})
+	}
+	return taxes
+}
+
+// TaxesFromDetails reconstruye el IVA por línea de documentos emitidos antes del desglose de
+// impuestos (sin filas en invoice_taxes): un IVA por detalle con su TaxRate.
+func TaxesFromDetails(details []*entity.InvoiceDetail) []*entity.InvoiceTax {
+	taxes := make([]*entity.InvoiceTax, 0, len(details))
+	for _, d := range details {
+		rate := PercentRate(d.TaxRate)
+		taxes = append(taxes, &entity.InvoiceTax{
+			InvoiceID:       d.InvoiceID,
+			InvoiceDetailID: d.ID,
+			TaxCode:         dian.TaxCodeIVA,
+			BaseAmount:      d.Subtotal.Round(2),
+			Rate:            rate,
+			Amount:          d.Subtotal.Mul(rate).Div(hundred).Round(2),
+		})
+	}
+	return taxes
+}
+
+// ProrateTaxes aplica a una nueva cantidad y subtotal los mismos tributos y tarifas de una línea ya
+// facturada (plantilla). Lo usan las notas para acreditar o anular exactamente los impuestos que se
+// cobraron, aunque la parametrización del producto haya cambiado después.
+func ProrateTaxes(template []*entity.InvoiceTax, quantity, subtotal decimal.Decimal) []*entity.InvoiceTax {
+	taxes := make([]*entity.InvoiceTax, 0, len(template))
+	for _, t := range template {
+		if t.IsPerUnit() {
+			taxes = append(taxes, &entity.InvoiceTax{
+				TaxCode:       t.TaxCode,
+				BaseAmount:    decimal.Zero,
+				PerUnitAmount: t.PerUnitAmount,
+				Quantity:      quantity,
+				Amount:        quantity.Mul(t.PerUnitAmount).Round(2),
+			})
+			continue
+		}
+		taxes = append(taxes, &entity.InvoiceTax{
+			TaxCode:    t.TaxCode,
+			BaseAmount: subtotal.Round(2),
+			Rate:       t.Rate,
+			Amount:     subtotal.Mul(t.Rate).Div(hundred).Round(2),
+		})
+	}
+	return taxes
+}
+
+// TaxSubtotal agrupa los impuestos de un tributo con la misma tarifa (o el mismo valor por unidad).
+type TaxSubtotal struct {
+	BaseAmount    decimal.Decimal
+	Rate          decimal.Decimal
+	PerUnitAmount decimal.Decimal
+	Quantity      decimal.Decimal
+	Amount        decimal.Decimal
+}
+
+// IsPerUnit indica si el subtotal corresponde a un impuesto por unidad.
+func (s TaxSubtotal) IsPerUnit() bool {
+	return s.PerUnitAmount.GreaterThan(decimal.Zero)
+}
+
+// TaxTotal total de un tributo (cac:TaxTotal del UBL) con un subtotal por tarifa.
+type TaxTotal struct {
+	TaxCode   string
+	Amount    decimal.Decimal
+	Subtotals []TaxSubtotal
+}
+
+// GroupTaxes agrupa los impuestos por tributo y, dentro de cada uno, por tarifa o valor por unidad.
+// El resultado queda ordenado por código de tributo y tarifa para que el XML sea determinístico.
+func GroupTaxes(taxes []*entity.InvoiceTax) []TaxTotal {
+	byCode := make(map[string]*TaxTotal)
+	codes := make([]string, 0)
+	for _, t := range taxes {
+		total, ok := byCode[t.TaxCode]
+		if !ok {
+			total = &TaxTotal{TaxCode: t.TaxCode}
+			byCode[t.TaxCode] = total
+			codes = append(codes, t.TaxCode)
+		}
+		total.Amount = total.Amount.Add(t.Amount)
+		idx := -1
+		for i, s := range total.Subtotals {
+			if s.Rate.Equal(t.Rate) && s.PerUnitAmount.Equal(t.PerUnitAmount) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			total.Subtotals = append(total.Subtotals, TaxSubtotal{Rate: t.Rate, PerUnitAmount: t.PerUnitAmount})
+			idx = len(total.Subtotals) - 1
+		}
+		s := &total.Subtotals[idx]
+		s.BaseAmount = s.BaseAmount.Add(t.BaseAmount)
+		s.Quantity = s.Quantity.Add(t.Quantity)
+		s.Amount = s.Amount.Add(t.Amount)
+	}
+	sort.Strings(codes)
+	out := make([]TaxTotal, 0, len(codes))
+	for _, code := range codes {
+		total := byCode[code]
+		sort.SliceStable(total.Subtotals, func(i, j int) bool {
+			if !total.Subtotals[i].Rate.Equal(total.Subtotals[j].Rate) {
+				return total.Subtotals[i].Rate.LessThan(total.Subtotals[j].Rate)
+			}
+			return total.Subtotals[i].PerUnitAmount.LessThan(total.Subtotals[j].PerUnitAmount)
+		})
+		out = append(out, *total)
+	}
+	return out
+}
+
+// SumTaxes suma el valor de los impuestos (TaxTotal de la factura).
+func SumTaxes(taxes []*entity.InvoiceTax) decimal.Decimal {
+	sum := decimal.Zero
+	for _, t := range taxes {
+		sum = sum.Add(t.Amount)
+	}
+	return sum
+}
+
+// TaxAmountByCode suma el valor de los impuestos de un tributo (ValImp del CUFE).
+func TaxAmountByCode(taxes []*entity.InvoiceTax, code string) decimal.Decimal {
+	sum := decimal.Zero
+	for _, t := range taxes {
+		if t.TaxCode == code {
+			sum = sum.Add(t.Amount)
+		}
+	}
+	return sum
+}
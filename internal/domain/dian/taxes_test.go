@@ -0,0 +1,97 @@
+package dian_test
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+func d(v string) decimal.Decimal { return decimal.RequireFromString(v) }
+
+func TestLineTaxes(t *testing.T) {
+	t.Run("IVAOnly", func(t *testing.T) {
+		taxes := dian.LineTaxes(&entity.Product{TaxRate: d("19")}, d("2"), d("20000"))
+		require.Len(t, taxes, 1)
+		assert.Equal(t, pkgdian.TaxCodeIVA, taxes[0].TaxCode)
+		assert.True(t, taxes[0].Rate.Equal(d("19")))
+		assert.True(t, taxes[0].Amount.Equal(d("3800")))
+	})
+
+	t.Run("ExemptKeepsZeroRateIVA", func(t *testing.T) {
+		taxes := dian.LineTaxes(&entity.Product{}, d("1"), d("5000"))
+		require.Len(t, taxes, 1)
+		assert.True(t, taxes[0].Rate.IsZero())
+		assert.True(t, taxes[0].Amount.IsZero())
+		assert.True(t, taxes[0].BaseAmount.Equal(d("5000")))
+	})
+
+	t.Run("INCAndBagTax", func(t *testing.T) {
+		p := &entity.Product{IncRate: d("8"), UnitTaxAmount: d("66")}
+		taxes := dian.LineTaxes(p, d("3"), d("30000"))
+		require.Len(t, taxes, 3)
+		assert.Equal(t, pkgdian.TaxCodeINC, taxes[1].TaxCode)
+		assert.True(t, taxes[1].Amount.Equal(d("2400")))
+		assert.Equal(t, pkgdian.TaxCodeINCBolsas, taxes[2].TaxCode)
+		assert.True(t, taxes[2].IsPerUnit())
+		assert.True(t, taxes[2].Amount.Equal(d("198")))
+		assert.True(t, dian.SumTaxes(taxes).Equal(d("2598")))
+	})
+
+	t.Run("FractionRate", func(t *testing.T) {
+		taxes := dian.LineTaxes(&entity.Product{TaxRate: d("0.05")}, d("1"), d("1000"))
+		assert.True(t, taxes[0].Rate.Equal(d("5")))
+		assert.True(t, taxes[0].Amount.Equal(d("50")))
+	})
+}
+
+func TestGroupTaxes_MixedRates(t *testing.T) {
+	var taxes []*entity.InvoiceTax
+	taxes = append(taxes, dian.LineTaxes(&entity.Product{TaxRate: d("19")}, d("1"), d("10000"))...)
+	taxes = append(taxes, dian.LineTaxes(&entity.Product{TaxRate: d("5")}, d("1"), d("4000"))...)
+	taxes = append(taxes, dian.LineTaxes(&entity.Product{TaxRate: d("19"), UnitTaxAmount: d("66")}, d("2"), d("6000"))...)
+	taxes = append(taxes, dian.LineTaxes(&entity.Product{IncRate: d("8")}, d("1"), d("2000"))...)
+
+	groups := dian.GroupTaxes(taxes)
+	require.Len(t, groups, 3)
+
+	iva := groups[0]
+	assert.Equal(t, pkgdian.TaxCodeIVA, iva.TaxCode)
+	assert.True(t, iva.Amount.Equal(d("3240")), iva.Amount.String())
+	require.Len(t, iva.Subtotals, 3)
+	assert.True(t, iva.Subtotals[0].Rate.IsZero())
+	assert.True(t, iva.Subtotals[0].BaseAmount.Equal(d("2000")))
+	assert.True(t, iva.Subtotals[1].Rate.Equal(d("5")))
+	assert.True(t, iva.Subtotals[2].Rate.Equal(d("19")))
+	assert.True(t, iva.Subtotals[2].BaseAmount.Equal(d("16000")))
+	assert.True(t, iva.Subtotals[2].Amount.Equal(d("3040")))
+
+	inc := groups[1]
+	assert.Equal(t, pkgdian.TaxCodeINC, inc.TaxCode)
+	assert.True(t, inc.Amount.Equal(d("160")))
+
+	bags := groups[2]
+	assert.Equal(t, pkgdian.TaxCodeINCBolsas, bags.TaxCode)
+	require.Len(t, bags.Subtotals, 1)
+	assert.True(t, bags.Subtotals[0].IsPerUnit())
+	assert.True(t, bags.Subtotals[0].Quantity.Equal(d("2")))
+	assert.True(t, bags.Amount.Equal(d("132")))
+
+	assert.True(t, dian.TaxAmountByCode(taxes, dian.CodImpIVA).Equal(d("3240")))
+	assert.True(t, dian.TaxAmountByCode(taxes, dian.CodImpImpoconsumo).Equal(d("160")))
+	assert.True(t, dian.TaxAmountByCode(taxes, dian.CodImpICA).IsZero())
+}
+
+func TestProrateTaxes(t *testing.T) {
+	template := dian.LineTaxes(&entity.Product{TaxRate: d("19"), UnitTaxAmount: d("66")}, d("4"), d("40000"))
+	taxes := dian.ProrateTaxes(template, d("1"), d("10000"))
+	require.Len(t, taxes, 2)
+	assert.True(t, taxes[0].Amount.Equal(d("1900")))
+	assert.True(t, taxes[1].Quantity.Equal(d("1")))
+	assert.True(t, taxes[1].Amount.Equal(d("66")))
+}
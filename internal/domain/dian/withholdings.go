@@ -0,0 +1,157 @@
+package dian
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+
+	"github.com/shopspring/decimal"
+)
+
+// WithholdingLine base de una línea facturada para el cálculo de retenciones.
+type WithholdingLine struct {
+	Concept  string          // concepto de retención del producto; vacío = COMPRAS
+	Subtotal decimal.Decimal // base de ReteFuente y ReteICA
+	IVA      decimal.Decimal // base de ReteIVA
+}
+
+// WithholdingInput parámetros para calcular las retenciones de una factura.
+type WithholdingInput struct {
+	SellerResponsibilities []string // responsabilidades fiscales del emisor (empresa)
+	BuyerResponsibilities  []string // responsabilidades fiscales del cliente
+	Rules                  []*entity.WithholdingRule
+	UVT                    decimal.Decimal // valor de la UVT vigente
+	ExchangeRate           decimal.Decimal // COP por unidad de la moneda del documento; cero = 1 (COP)
+	Lines                  []WithholdingLine
+}
+
+// hasResponsibility indica si la lista contiene alguna de las responsabilidades dadas.
+func hasResponsibility(list []string, codes ...string) bool {
+	for _, r := range list {
+		r = strings.TrimSpace(r)
+		for _, c := range codes {
+			if r == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsWithholdingAgent indica si el cliente practica retenciones: gran contribuyente (O-13) o
+// agente de retención de IVA (O-23).
+func IsWithholdingAgent(responsibilities []string) bool {
+	return hasResponsibility(responsibilities, dian.TaxLevelGranContribuyente, dian.TaxLevelAgenteRetencionIVA)
+}
+
+// ComputeWithholdings calcula las retenciones que practicará el cliente sobre la factura:
+//   - Solo aplican si el cliente es agente de retención (IsWithholdingAgent).
+//   - ReteFuente (06) no aplica si el emisor es autorretenedor (O-15) o del Régimen Simple (O-47).
+//   - ReteIVA (05) se calcula sobre el IVA facturado y no aplica si el emisor es gran contribuyente.
+//   - ReteICA (07) se calcula sobre el subtotal.
+//
+// Las bases se acumulan por concepto y la regla aplica cuando el subtotal del concepto, convertido a
+// pesos con ExchangeRate, alcanza la base mínima (MinBaseUVT × UVT). Bases y valores quedan en la moneda
+// del documento. El resultado queda ordenado por tributo y concepto.
+func ComputeWithholdings(in WithholdingInput) []*entity.InvoiceWithholding {
+	if !IsWithholdingAgent(in.BuyerResponsibilities) || len(in.Rules) == 0 {
+		return nil
+	}
+	subtotals := make(map[string]decimal.Decimal)
+	ivas := make(map[string]decimal.Decimal)
+	for _, l := range in.Lines {
+		concept := NormalizeWithholdingConcept(l.Concept)
+		subtotals[concept] = subtotals[concept].Add(l.Subtotal)
+		ivas[concept] = ivas[concept].Add(l.IVA)
+	}
+	rate := in.ExchangeRate
+	if !rate.IsPositive() {
+		rate = decimal.NewFromInt(1)
+	}
+	skipReteFuente := hasResponsibility(in.SellerResponsibilities, dian.TaxLevelAutorretenedor, dian.TaxLevelRégimenSimple)
+	skipReteIVA := hasResponsibility(in.SellerResponsibilities, dian.TaxLevelGranContribuyente)
+
+	out := make([]*entity.InvoiceWithholding, 0)
+	for _, r := range in.Rules {
+		if r == nil || !r.IsActive || !r.Rate.GreaterThan(decimal.Zero) {
+			continue
+		}
+		concept := NormalizeWithholdingConcept(r.Concept)
+		subtotal, ok := subtotals[concept]
+		if !ok {
+			continue
+		}
+		if subtotal.Mul(rate).LessThan(r.MinBaseUVT.Mul(in.UVT)) {
+			continue
+		}
+		var base decimal.Decimal
+		switch r.TaxCode {
+		case dian.TaxCodeReteFuente:
+			if skipReteFuente {
+				continue
+			}
+			base = subtotal
+		case dian.TaxCodeReteIVA:
+			if skipReteIVA {
+				continue
+			}
+			base = ivas[concept]
+		case dian.TaxCodeReteICA:
+			base = subtotal
+		default:
+			continue
+		}
+		if !base.GreaterThan(decimal.Zero) {
+			continue
+		}
+		out = append(out, &entity.InvoiceWithholding{
+			TaxCode:    r.TaxCode,
+			Concept:    concept,
+			BaseAmount: base.Round(2),
+			Rate:       r.Rate,
+			Amount:     base.Mul(r.Rate).Div(hundred).Round(2),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].TaxCode != out[j].TaxCode {
+			return out[i].TaxCode < out[j].TaxCode
+		}
+		return out[i].Concept < out[j].Concept
+	})
+	return out
+}
+
+// SumWithholdings suma el valor de las retenciones (WithholdingTotal de la factura).
+func SumWithholdings(withholdings []*entity.InvoiceWithholding) decimal.Decimal {
+	sum := decimal.Zero
+	for _, w := range withholdings {
+		sum = sum.Add(w.Amount)
+	}
+	return sum
+}
+
+// GroupWithholdings agrupa las retenciones por tributo y tarifa (cac:WithholdingTaxTotal del UBL),
+// con el mismo orden determinístico de GroupTaxes.
+func GroupWithholdings(withholdings []*entity.InvoiceWithholding) []TaxTotal {
+	taxes := make([]*entity.InvoiceTax, 0, len(withholdings))
+	for _, w := range withholdings {
+		taxes = append(taxes, &entity.InvoiceTax{
+			TaxCode:    w.TaxCode,
+			BaseAmount: w.BaseAmount,
+			Rate:       w.Rate,
+			Amount:     w.Amount,
+		})
+	}
+	return GroupTaxes(taxes)
+}
+
+// NormalizeWithholdingConcept homologa el concepto de retención (mayúsculas; vacío = COMPRAS).
+func NormalizeWithholdingConcept(concept string) string {
+	concept = strings.ToUpper(strings.TrimSpace(concept))
+	if concept == "" {
+		return entity.WithholdingConceptCompras
+	}
+	return concept
+}
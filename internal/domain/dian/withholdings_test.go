@@ -0,0 +1,113 @@
+package dian_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	pkgdian "github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+func withholdingRules() []*entity.WithholdingRule {
+	return []*entity.WithholdingRule{
+		{TaxCode: pkgdian.TaxCodeReteFuente, Concept: "COMPRAS", Rate: d("2.5"), MinBaseUVT: d("27"), IsActive: true},
+		{TaxCode: pkgdian.TaxCodeReteFuente, Concept: "SERVICIOS", Rate: d("4"), MinBaseUVT: d("4"), IsActive: true},
+		{TaxCode: pkgdian.TaxCodeReteIVA, Concept: "COMPRAS", Rate: d("15"), IsActive: true},
+		{TaxCode: pkgdian.TaxCodeReteICA, Concept: "COMPRAS", Rate: d("0.966"), IsActive: true},
+	}
+}
+
+func TestComputeWithholdings(t *testing.T) {
+	uvt := d("50000")
+	lines := []dian.WithholdingLine{
+		{Concept: "", Subtotal: d("1000000"), IVA: d("190000")},
+		{Concept: "compras", Subtotal: d("500000"), IVA: d("95000")},
+		{Concept: "SERVICIOS", Subtotal: d("100000"), IVA: d("19000")},
+	}
+
+	t.Run("BuyerNotAgent", func(t *testing.T) {
+		got := dian.ComputeWithholdings(dian.WithholdingInput{
+			BuyerResponsibilities: []string{pkgdian.TaxLevelResponsableIVA},
+			Rules:                 withholdingRules(),
+			UVT:                   uvt,
+			Lines:                 lines,
+		})
+		assert.Empty(t, got)
+	})
+
+	t.Run("AgentAppliesAllAboveThreshold", func(t *testing.T) {
+		got := dian.ComputeWithholdings(dian.WithholdingInput{
+			BuyerResponsibilities: []string{pkgdian.TaxLevelGranContribuyente},
+			Rules:                 withholdingRules(),
+			UVT:                   uvt,
+			Lines:                 lines,
+		})
+		// SERVICIOS (100.000) no alcanza 4 UVT (200.000).
+		require.Len(t, got, 3)
+		assert.Equal(t, pkgdian.TaxCodeReteIVA, got[0].TaxCode)
+		assert.True(t, got[0].BaseAmount.Equal(d("285000")))
+		assert.True(t, got[0].Amount.Equal(d("42750")))
+		assert.Equal(t, pkgdian.TaxCodeReteFuente, got[1].TaxCode)
+		assert.Equal(t, entity.WithholdingConceptCompras, got[1].Concept)
+		assert.True(t, got[1].Amount.Equal(d("37500")))
+		assert.Equal(t, pkgdian.TaxCodeReteICA, got[2].TaxCode)
+		assert.True(t, got[2].Amount.Equal(d("14490")))
+		assert.True(t, dian.SumWithholdings(got).Equal(d("94740")))
+	})
+
+	t.Run("SellerAutorretenedorSkipsReteFuente", func(t *testing.T) {
+		got := dian.ComputeWithholdings(dian.WithholdingInput{
+			SellerResponsibilities: []string{pkgdian.TaxLevelAutorretenedor, pkgdian.TaxLevelGranContribuyente},
+			BuyerResponsibilities:  []string{pkgdian.TaxLevelAgenteRetencionIVA},
+			Rules:                  withholdingRules(),
+			UVT:                    uvt,
+			Lines:                  lines,
+		})
+		require.Len(t, got, 1)
+		assert.Equal(t, pkgdian.TaxCodeReteICA, got[0].TaxCode)
+	})
+
+	t.Run("BelowMinimumBase", func(t *testing.T) {
+		got := dian.ComputeWithholdings(dian.WithholdingInput{
+			BuyerResponsibilities: []string{pkgdian.TaxLevelGranContribuyente},
+			Rules:                 withholdingRules()[:1],
+			UVT:                   uvt,
+			Lines:                 []dian.WithholdingLine{{Subtotal: d("1349999"), IVA: d("0")}},
+		})
+		assert.Empty(t, got)
+	})
+
+	t.Run("ForeignCurrencyComparesBaseInCOP", func(t *testing.T) {
+		in := dian.WithholdingInput{
+			BuyerResponsibilities: []string{pkgdian.TaxLevelGranContribuyente},
+			Rules:                 withholdingRules()[:1],
+			UVT:                   uvt,
+			Lines:                 []dian.WithholdingLine{{Subtotal: d("400"), IVA: d("0")}},
+		}
+		assert.Empty(t, dian.ComputeWithholdings(in), "400 COP no alcanza la base mínima")
+
+		// USD 400 × 4.000 = 1.600.000 COP ≥ 27 UVT; base y valor quedan en dólares.
+		in.ExchangeRate = d("4000")
+		got := dian.ComputeWithholdings(in)
+		require.Len(t, got, 1)
+		assert.True(t, got[0].BaseAmount.Equal(d("400")))
+		assert.True(t, got[0].Amount.Equal(d("10")))
+	})
+}
+
+func TestGroupWithholdings(t *testing.T) {
+	totals := dian.GroupWithholdings([]*entity.InvoiceWithholding{
+		{TaxCode: pkgdian.TaxCodeReteFuente, Concept: "SERVICIOS", BaseAmount: d("300000"), Rate: d("4"), Amount: d("12000")},
+		{TaxCode: pkgdian.TaxCodeReteFuente, Concept: "COMPRAS", BaseAmount: d("2000000"), Rate: d("2.5"), Amount: d("50000")},
+		{TaxCode: pkgdian.TaxCodeReteIVA, Concept: "COMPRAS", BaseAmount: d("380000"), Rate: d("15"), Amount: d("57000")},
+	})
+	require.Len(t, totals, 2)
+	assert.Equal(t, pkgdian.TaxCodeReteIVA, totals[0].TaxCode)
+	assert.Equal(t, pkgdian.TaxCodeReteFuente, totals[1].TaxCode)
+	assert.True(t, totals[1].Amount.Equal(d("62000")))
+	require.Len(t, totals[1].Subtotals, 2)
+	assert.True(t, totals[1].Subtotals[0].Rate.Equal(d("2.5")))
+}
@@ -2,6 +2,14 @@ package entity
 
 import "time"
 
+// Tipos de documento que numera una resolución. Las facturas (venta, exportación, POS, contingencia y
+// documento soporte) toman consecutivo del prefijo; las notas crédito y débito, de su propia resolución.
+const (
+	ResolutionDocumentInvoice    = "INVOICE"
+	ResolutionDocumentCreditNote = "CREDIT_NOTE"
+	ResolutionDocumentDebitNote  = "DEBIT_NOTE"
+)
+
 // BillingResolution representa la resolución de facturación autorizada por la DIAN.
 // Es obligatoria en el nodo <sts:DianExtensions> del XML UBL 2.1.
 // Cada empresa puede tener una o varias resoluciones; solo una activa por prefijo.
@@ -15,8 +23,36 @@ type BillingResolution struct {
 	DateFrom         time.Time // Fecha de inicio de vigencia
 	DateTo           time.Time // Fecha de vencimiento
 	Environment      string    // test|prod
-	UsedNumbers      int64     // Conteo de números consumidos del rango (calculado)
+	DocumentType     string    // INVOICE (por defecto) | CREDIT_NOTE | DEBIT_NOTE
+	UsedNumbers      int64     // Números ya asignados del rango (consecutivo = RangeFrom + UsedNumbers)
 	IsActive         bool
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 }
+
+// NextNumber devuelve el siguiente consecutivo sin asignar del rango.
+func (r *BillingResolution) NextNumber() int64 {
+	return r.RangeFrom + r.UsedNumbers
+}
+
+// IsNoteResolution indica si la resolución numera notas crédito o débito.
+func (r *BillingResolution) IsNoteResolution() bool {
+	return r.DocumentType == ResolutionDocumentCreditNote || r.DocumentType == ResolutionDocumentDebitNote
+}
+
+// IssuedDocumentTypes tipos de documento (invoices.document_type) que consumen números de la
+// resolución: las de facturación numeran facturas y documentos equivalentes POS; las de notas, su nota.
+func (r *BillingResolution) IssuedDocumentTypes() []string {
+	if r.IsNoteResolution() {
+		return []string{r.DocumentType}
+	}
+	return []string{ResolutionDocumentInvoice, DocumentTypePOS}
+}
+
+// CoversDate indica si la fecha (por día calendario) está dentro de la vigencia de la resolución.
+func (r *BillingResolution) CoversDate(t time.Time) bool {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	from := time.Date(r.DateFrom.Year(), r.DateFrom.Month(), r.DateFrom.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(r.DateTo.Year(), r.DateTo.Month(), r.DateTo.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.Before(from) && !day.After(to)
+}
@@ -4,13 +4,17 @@ import "time"
 
 // Customer representa un cliente de la empresa (facturación).
 type Customer struct {
-	ID        string
-	CompanyID string
-	Name      string
-	TaxID     string // NIT o Cédula (Colombia)
-	Email     string
-	Phone     string
-	IsActive  bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID                     string
+	CompanyID              string
+	Name                   string
+	TaxID                  string // NIT o Cédula (Colombia); documento extranjero para clientes del exterior
+	IdentificationType     string // Tabla 3 DIAN (13, 31, 22, 41, 42, 50); vacío = se infiere de TaxID
+	CountryCode            string // ISO 3166-1 alfa-2; "CO" por defecto
+	Email                  string
+	Phone                  string
+	FiscalResponsibilities []string // Responsabilidades fiscales del RUT (O-13, O-23…); deciden las retenciones
+	PaymentTermDays        int      // Plazo de pago en días; vencimiento por defecto de sus facturas a crédito
+	IsActive               bool
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
 }
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// DIANContingencyTransmissionWindow plazo para transmitir a la DIAN las facturas de contingencia
+// (tipo 03) contado desde que termina la contingencia (Resolución 000042/2020, art. 31).
+const DIANContingencyTransmissionWindow = 48 * time.Hour
+
+// DIANContingency periodo en que la empresa factura en contingencia porque no puede transmitir a la DIAN
+// (caída prolongada del servicio). Mientras está activa las facturas se emiten como tipo 03 con la
+// numeración de contingencia (Prefix), se firman y entregan al adquiriente, y quedan pendientes de
+// transmisión hasta que la contingencia termina.
+type DIANContingency struct {
+	ID            string
+	CompanyID     string
+	Prefix        string // prefijo de la resolución de numeración de contingencia
+	Reason        string
+	StartedAt     time.Time
+	StartedBy     string
+	EndedAt       *time.Time // nil mientras está activa
+	EndedBy       string
+	TransmittedAt *time.Time // última transmisión masiva de sus facturas
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// IsActive indica si la empresa sigue facturando en contingencia.
+func (c *DIANContingency) IsActive() bool {
+	return c.EndedAt == nil
+}
+
+// TransmissionDeadline fecha límite para transmitir las facturas del periodo; nil si sigue activa.
+func (c *DIANContingency) TransmissionDeadline() *time.Time {
+	if c.EndedAt == nil {
+		return nil
+	}
+	deadline := c.EndedAt.Add(DIANContingencyTransmissionWindow)
+	return &deadline
+}
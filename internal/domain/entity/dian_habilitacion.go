@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+// Estados del proceso de habilitación DIAN (set de pruebas).
+const (
+	DIANHabilitacionInProgress = "IN_PROGRESS" // documentos pendientes de envío o de validación
+	DIANHabilitacionAccepted   = "ACCEPTED"    // todos los documentos del set aceptados
+	DIANHabilitacionFailed     = "FAILED"      // set terminado con documentos rechazados
+)
+
+// Estados de cada documento del set de pruebas.
+const (
+	DIANTestDocPending  = "PENDING"  // firmado, pendiente de SendTestSetAsync
+	DIANTestDocSent     = "SENT"     // enviado; en espera de GetStatusZip
+	DIANTestDocAccepted = "ACCEPTED" // validado por la DIAN
+	DIANTestDocRejected = "REJECTED" // rechazado por la DIAN
+)
+
+// DIANHabilitacion proceso de habilitación de una empresa ante la DIAN: el set de pruebas
+// (facturas, notas crédito y débito) generado con la resolución de pruebas y enviado con el TestSetId.
+type DIANHabilitacion struct {
+	ID          string
+	CompanyID   string
+	TestSetID   string
+	Prefix      string // prefijo de la resolución de pruebas (SETP)
+	Status      string
+	LastError   string
+	Documents   []*DIANTestSetDocument
+	CreatedBy   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// DIANTestSetDocument documento del set de pruebas con su XML firmado y el resultado de la DIAN.
+type DIANTestSetDocument struct {
+	ID             string
+	HabilitacionID string
+	Sequence       int
+	DocumentType   string // "INVOICE" | "CREDIT_NOTE" | "DEBIT_NOTE"
+	Prefix         string
+	Number         string
+	CUFE           string
+	XMLSigned      string
+	ZipName        string
+	TrackID        string
+	Status         string
+	Errors         string
+	StatusChecks   int
+	NextCheckAt    *time.Time
+	UpdatedAt      time.Time
+}
+
+// IsFinal indica que el documento ya tiene resultado definitivo de la DIAN.
+func (d *DIANTestSetDocument) IsFinal() bool {
+	return d.Status == DIANTestDocAccepted || d.Status == DIANTestDocRejected
+}
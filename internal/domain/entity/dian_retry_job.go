@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// Estados de un trabajo de la cola de reintentos DIAN.
+const (
+	DIANRetryJobQueued  = "QUEUED"  // Pendiente de reintento en NextAttemptAt
+	DIANRetryJobRunning = "RUNNING" // Tomado por un worker; el reintento está en curso
+	DIANRetryJobDead    = "DEAD"    // Intentos agotados; requiere revisión manual
+)
+
+// DIANRetryJob reintento de envío a la DIAN de una factura en CONTINGENCIA.
+type DIANRetryJob struct {
+	InvoiceID     string
+	CompanyID     string
+	InvoiceNumber string // prefijo + número (solo en listados)
+	Status        string // QUEUED | RUNNING | DEAD
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
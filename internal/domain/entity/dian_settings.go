@@ -10,6 +10,9 @@ type DIANSettings struct {
 	CertificateFileName          string
 	CertificateFileSize          int64
 	CertificatePasswordEncrypted string
+	TechnicalKey                 string // clave técnica del rango de numeración (CUFE)
+	SoftwareID                   string // identificador del software de facturación ante la DIAN
+	SoftwarePINEncrypted         string // PIN del software cifrado (SoftwareSecurityCode)
 	CreatedAt                    time.Time
 	UpdatedAt                    time.Time
 }
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// Severidad de una regla de validación DIAN.
+const (
+	DIANRuleError        = "ERROR"        // Rechazo: el documento no es válido
+	DIANRuleNotification = "NOTIFICATION" // Notificación: no impide la validación
+)
+
+// DIANValidationRule regla reportada por la DIAN al validar un documento (ej. FAD06, FAJ44b).
+type DIANValidationRule struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"` // ERROR | NOTIFICATION
+}
+
+// DIANStatusCheck envío asíncrono (estado Sent) pendiente de consultar con GetStatusZip.
+type DIANStatusCheck struct {
+	InvoiceID   string
+	CompanyID   string
+	TrackID     string
+	Attempts    int       // consultas realizadas sin resultado definitivo
+	NextCheckAt time.Time // no consultar antes de esta fecha (backoff)
+}
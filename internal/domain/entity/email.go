@@ -41,4 +41,5 @@ type EmailAttachment struct {
 	FileURL  string
 	MIMEType string
 	Size     int
+	Content  []byte // solo en adjuntos ZIP/XML (posibles facturas electrónicas); nil en los demás
 }
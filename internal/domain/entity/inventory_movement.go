@@ -16,6 +16,7 @@ const (
 	MovementTypeADJUSTMENT MovementType = "ADJUSTMENT" // ajuste
 	MovementTypeTRANSFER   MovementType = "TRANSFER"   // traslado entre bodegas
 	MovementTypeReturn     MovementType = "RETURN"     // devolución de venta (entrada por devolución)
+	MovementTypeReversal   MovementType = "REVERSAL"   // asiento compensatorio de otro movimiento
 )
 
 // InventoryMovement representa un movimiento de inventario (entrada, salida, ajuste o traslado).
@@ -32,4 +33,14 @@ type InventoryMovement struct {
 	Date          time.Time
 	CreatedAt     time.Time
 	CreatedBy     string
+	// ReversalOfID referencia el movimiento original cuando Type == REVERSAL.
+	ReversalOfID string
+	// ReversedAt y ReversedBy se llenan en el movimiento original al revertirlo.
+	ReversedAt *time.Time
+	ReversedBy string
+}
+
+// IsReversed indica si el movimiento ya fue revertido por un asiento compensatorio.
+func (m *InventoryMovement) IsReversed() bool {
+	return m.ReversedAt != nil
 }
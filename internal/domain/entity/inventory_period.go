@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Estados de un período mensual de inventario.
+const (
+	InventoryPeriodStatusOpen   = "OPEN"
+	InventoryPeriodStatusClosed = "CLOSED"
+)
+
+// Acciones registradas en la bitácora de períodos.
+const (
+	InventoryPeriodActionClose  = "CLOSE"
+	InventoryPeriodActionReopen = "REOPEN"
+)
+
+// DefaultCompanyTimeZone zona horaria de las empresas que no tienen una configurada.
+const DefaultCompanyTimeZone = "America/Bogota"
+
+// defaultCompanyLocation Colombia no tiene horario de verano: UTC-5 fijo evita depender de tzdata.
+var defaultCompanyLocation = time.FixedZone(DefaultCompanyTimeZone, -5*60*60)
+
+// LoadCompanyLocation resuelve la zona horaria configurada de una empresa (vacía = DefaultCompanyTimeZone).
+func LoadCompanyLocation(name string) (*time.Location, error) {
+	if name == "" || name == DefaultCompanyTimeZone {
+		return defaultCompanyLocation, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// InventoryPeriod representa el cierre mensual de inventario de una empresa.
+// Un período cerrado congela la valorización y bloquea movimientos fechados dentro de él.
+type InventoryPeriod struct {
+	ID        string
+	CompanyID string
+	Year      int
+	Month     int
+	Status    string
+	ClosedAt  *time.Time
+	ClosedBy  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Location zona horaria de la empresa que delimita el mes (nil = DefaultCompanyTimeZone).
+	Location *time.Location
+}
+
+// IsClosed indica si el período está cerrado.
+func (p *InventoryPeriod) IsClosed() bool {
+	return p != nil && p.Status == InventoryPeriodStatusClosed
+}
+
+// Start primer instante del período en la zona horaria de la empresa.
+func (p *InventoryPeriod) Start() time.Time {
+	loc := p.Location
+	if loc == nil {
+		loc = defaultCompanyLocation
+	}
+	return time.Date(p.Year, time.Month(p.Month), 1, 0, 0, 0, 0, loc)
+}
+
+// End primer instante del período siguiente (límite exclusivo).
+func (p *InventoryPeriod) End() time.Time {
+	return p.Start().AddDate(0, 1, 0)
+}
+
+// InventoryPeriodValuation cantidad y costo congelados al cierre por producto y bodega.
+type InventoryPeriodValuation struct {
+	PeriodID    string
+	ProductID   string
+	WarehouseID string
+	Quantity    decimal.Decimal
+	UnitCost    decimal.Decimal
+	TotalValue  decimal.Decimal
+}
+
+// InventoryPeriodEvent registro de auditoría de cierres y reaperturas.
+type InventoryPeriodEvent struct {
+	ID        string
+	PeriodID  string
+	Action    string
+	Reason    string
+	UserID    string
+	CreatedAt time.Time
+}
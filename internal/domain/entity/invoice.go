@@ -19,27 +19,38 @@ const (
 	DIANStatusErrorGeneration = "ERROR_GENERATION" // Falló firma o generación XML
 )
 
+// DocumentTypePOS documento equivalente electrónico POS (tiquete de venta en mostrador); usa la
+// cabecera de factura con InvoiceTypeCode 20 y CUDE en lugar de CUFE.
+const DocumentTypePOS = "POS"
+
 // Invoice representa la cabecera de una factura o Nota Crédito.
 type Invoice struct {
-	ID          string
-	CompanyID   string
-	CustomerID  string
-	Prefix      string
-	Number      string
-	Date        time.Time
-	NetTotal    decimal.Decimal
-	TaxTotal    decimal.Decimal
-	GrandTotal  decimal.Decimal
-	DIAN_Status string
-	CUFE        string // Código Único de Factura Electrónica / CUDE (SHA-384)
-	UUID        string // Mismo valor que CUFE/CUDE; en <cbc:UUID> del XML DIAN
-	XMLSigned   string // XML firmado (contenido completo)
-	QRData      string // String para QR (NumFac|FecFac|...|Cufe|UrlValidacionDIAN)
-	TrackID     string // ZipKey / TrackID devuelto por el WS DIAN tras el envío
-	DIANErrors  string // Mensajes de rechazo devueltos por la DIAN (JSON o texto plano)
+	ID               string
+	CompanyID        string
+	CustomerID       string
+	Prefix           string
+	Number           string
+	Date             time.Time
+	NetTotal         decimal.Decimal
+	TaxTotal         decimal.Decimal
+	GrandTotal       decimal.Decimal
+	WithholdingTotal decimal.Decimal // Retenciones practicadas por el cliente (informativas en el XML)
+	DiscountTotal    decimal.Decimal // Descuentos de línea + descuentos globales
+	AllowanceTotal   decimal.Decimal // Descuentos globales (AllowanceTotalAmount)
+	ChargeTotal      decimal.Decimal // Cargos globales (ChargeTotalAmount)
+	DIAN_Status      string
+	CUFE             string               // Código Único de Factura Electrónica / CUDE (SHA-384)
+	UUID             string               // Mismo valor que CUFE/CUDE; en <cbc:UUID> del XML DIAN
+	XMLSigned        string               // XML firmado (contenido completo)
+	QRData           string               // String para QR (NumFac|FecFac|...|Cufe|UrlValidacionDIAN)
+	TrackID          string               // ZipKey / TrackID devuelto por el WS DIAN tras el envío
+	DIANErrors       string               // Mensajes de rechazo devueltos por la DIAN (JSON o texto plano)
+	DIANRules        []DIANValidationRule // Reglas de validación (rechazos y notificaciones) de GetStatusZip
+	// ApplicationResponse XML con que la DIAN validó el documento (GetStatusZip); vacío en modo dev.
+	ApplicationResponse string
 
 	// Campos adicionales para Notas Crédito / referencias
-	DocumentType           string            // "INVOICE" | "CREDIT_NOTE" | "DEBIT_NOTE"
+	DocumentType           string            // "INVOICE" | "CREDIT_NOTE" | "DEBIT_NOTE" | "POS"
 	OriginalInvoiceID      string            // ID de la factura origen
 	OriginalInvoiceNumber  string            // Prefijo+Número de la factura origen
 	OriginalInvoiceCUFE    string            // CUFE de la factura origen
@@ -47,6 +58,72 @@ type Invoice struct {
 	DiscrepancyCode        CreditNoteConcept // Código de concepto DIAN (1..6)
 	DiscrepancyReason      string            // Motivo textual de la Nota Crédito
 
+	// Moneda y exportación: los totales anteriores están en CurrencyCode; los *COP son su
+	// equivalente en pesos con ExchangeRate (1 en facturas en COP).
+	CurrencyCode    string          // ISO 4217 (COP, USD, EUR)
+	ExchangeRate    decimal.Decimal // TRM: COP por unidad de CurrencyCode
+	InvoiceTypeCode string          // "01" venta nacional | "02" exportación | "03" contingencia
+	Incoterm        string          // Condición de entrega (solo exportación)
+	NetTotalCOP     decimal.Decimal
+	TaxTotalCOP     decimal.Decimal
+	GrandTotalCOP   decimal.Decimal
+
+	// Forma y medios de pago: contado (1) o crédito (2) con vencimiento y cuotas opcionales.
+	PaymentFormCode    string     // Tabla 14 DIAN; vacío = contado
+	PaymentMethodCodes []string   // Tabla 13 DIAN (10 efectivo, 47 transferencia…); vacío = efectivo
+	DueDate            *time.Time // Vencimiento (solo crédito)
+
+	// ContingencyID periodo de contingencia DIAN en que se emitió la factura tipo 03; vacío en las demás.
+	ContingencyID string
+
+	// Withholdings desglose de retenciones; se carga bajo demanda (PDF, XML), no en los listados.
+	Withholdings []*InvoiceWithholding
+	// AllowanceCharges descuentos y cargos (de línea y globales); se carga bajo demanda.
+	AllowanceCharges []*InvoiceAllowanceCharge
+	// Installments plan de cuotas de la venta a crédito; se carga bajo demanda.
+	Installments []*InvoiceInstallment
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// IsForeignCurrency indica si la factura está en una moneda distinta al peso colombiano.
+func (i *Invoice) IsForeignCurrency() bool {
+	return i.CurrencyCode != "" && i.CurrencyCode != "COP"
+}
+
+// ToCOP convierte un valor de la moneda del documento a pesos con la tasa de la factura.
+func (i *Invoice) ToCOP(amount decimal.Decimal) decimal.Decimal {
+	if !i.IsForeignCurrency() || !i.ExchangeRate.IsPositive() {
+		return amount
+	}
+	return amount.Mul(i.ExchangeRate).Round(2)
+}
+
+// ComputeCOPTotals calcula los totales en pesos a partir de los totales del documento.
+func (i *Invoice) ComputeCOPTotals() {
+	i.NetTotalCOP = i.ToCOP(i.NetTotal)
+	i.TaxTotalCOP = i.ToCOP(i.TaxTotal)
+	i.GrandTotalCOP = i.ToCOP(i.GrandTotal)
+}
+
+// IsPOS indica si el documento es un documento equivalente electrónico POS.
+func (i *Invoice) IsPOS() bool {
+	return i.DocumentType == DocumentTypePOS
+}
+
+// IsContingency indica si es una factura de contingencia (tipo 03) pendiente de transmitir o ya
+// transmitida al terminar la contingencia.
+func (i *Invoice) IsContingency() bool {
+	return i.InvoiceTypeCode == "03"
+}
+
+// IsCredit indica si la factura es una venta a crédito.
+func (i *Invoice) IsCredit() bool {
+	return i.PaymentFormCode == "2"
+}
+
+// PayableAmount neto a cobrar al cliente después de retenciones.
+func (i *Invoice) PayableAmount() decimal.Decimal {
+	return i.GrandTotal.Sub(i.WithholdingTotal)
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "github.com/shopspring/decimal"
+
+// InvoiceAllowanceCharge descuento (ChargeIndicator=false) o cargo (ChargeIndicator=true) de una
+// factura. Con InvoiceDetailID aplica a esa línea; sin él es global (ej: descuento comercial, flete).
+type InvoiceAllowanceCharge struct {
+	ID              string
+	InvoiceID       string
+	InvoiceDetailID string // vacío = a nivel de documento
+	ChargeIndicator bool
+	ReasonCode      string          // código de descuento DIAN (Tabla 13.3.8); vacío en cargos
+	Reason          string          // motivo textual (cbc:AllowanceChargeReason)
+	Rate            decimal.Decimal // porcentaje aplicado sobre BaseAmount (0 = valor fijo)
+	BaseAmount      decimal.Decimal
+	Amount          decimal.Decimal
+}
@@ -3,12 +3,22 @@ package entity
 import "github.com/shopspring/decimal"
 
 // InvoiceDetail representa una línea de detalle de una factura.
+// Subtotal es el valor neto de la línea: Quantity × UnitPrice − DiscountAmount.
 type InvoiceDetail struct {
-	ID        string
-	InvoiceID string
-	ProductID string
-	Quantity  decimal.Decimal
-	UnitPrice decimal.Decimal
-	TaxRate   decimal.Decimal
-	Subtotal  decimal.Decimal
+	ID             string
+	InvoiceID      string
+	ProductID      string
+	Quantity       decimal.Decimal
+	UnitPrice      decimal.Decimal
+	TaxRate        decimal.Decimal
+	DiscountAmount decimal.Decimal // Descuento de la línea (cac:AllowanceCharge a nivel de línea)
+	Subtotal       decimal.Decimal
+}
+
+// NetUnitPrice precio unitario neto de descuentos (Subtotal / Quantity).
+func (d *InvoiceDetail) NetUnitPrice() decimal.Decimal {
+	if !d.DiscountAmount.IsPositive() || d.Quantity.IsZero() {
+		return d.UnitPrice
+	}
+	return d.Subtotal.Div(d.Quantity)
 }
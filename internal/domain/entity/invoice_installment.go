@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// InvoiceInstallment cuota del plan de pagos de una factura a crédito. La suma de las cuotas
+// es el neto a cobrar (total menos retenciones) y la última vence en la fecha de vencimiento.
+type InvoiceInstallment struct {
+	ID        string
+	InvoiceID string
+	Number    int // 1..n en orden de vencimiento
+	DueDate   time.Time
+	Amount    decimal.Decimal
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "github.com/shopspring/decimal"
+
+// InvoiceTax impuesto de una línea facturada (tabla invoice_taxes). Una línea puede llevar varios:
+// IVA y/o INC porcentuales sobre el subtotal, y tributos por unidad (p. ej. bolsas plásticas).
+type InvoiceTax struct {
+	ID              string
+	InvoiceID       string
+	InvoiceDetailID string          // línea a la que pertenece; vacío en facturas anteriores al desglose
+	TaxCode         string          // código DIAN (01 IVA, 04 INC, 22 bolsas…)
+	BaseAmount      decimal.Decimal // base gravable (impuestos porcentuales)
+	Rate            decimal.Decimal // tarifa en porcentaje (19, 5, 8, 0); 0 en impuestos por unidad
+	PerUnitAmount   decimal.Decimal // valor por unidad (impuestos por unidad)
+	Quantity        decimal.Decimal // unidades gravadas (impuestos por unidad)
+	Amount          decimal.Decimal // valor del impuesto
+}
+
+// IsPerUnit indica si el impuesto se liquida por unidad y no por porcentaje.
+func (t *InvoiceTax) IsPerUnit() bool {
+	return t.PerUnitAmount.GreaterThan(decimal.Zero)
+}
@@ -10,21 +10,45 @@ import (
 // Product representa un producto o SKU del inventario (multi-bodega).
 // Cost es promedio ponderado calculado desde movimientos; Stock se maneja por bodega en InventoryLevel.
 type Product struct {
-	ID           string
-	CompanyID    string
-	SKU          string    // código único por empresa
-	Name         string
-	Description  string
-	Price        decimal.Decimal // precio de venta
-	Cost         decimal.Decimal // costo promedio ponderado (inicia en 0)
-	TaxRate      decimal.Decimal // Porcentaje (ej: 19, 5, 0, 7.5). Se normaliza a fracción en cálculos.
-	UNSPSC_Code  string
-	UnitMeasure  string
-	Attributes   json.RawMessage
-	COGS         decimal.Decimal // costo de bienes vendidos (analítica)
-	ReorderPoint decimal.Decimal // punto de reorden para alertas de ruptura
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID                 string
+	CompanyID          string
+	SKU                string // código único por empresa
+	Name               string
+	Description        string
+	Price              decimal.Decimal // precio de venta
+	Cost               decimal.Decimal // costo promedio ponderado (inicia en 0)
+	TaxRate            decimal.Decimal // Porcentaje (ej: 19, 5, 0, 7.5). Se normaliza a fracción en cálculos.
+	IncRate            decimal.Decimal // Impuesto Nacional al Consumo (INC) en porcentaje (ej: 8); 0 = no aplica
+	UnitTaxCode        string          // Código DIAN del impuesto por unidad (ej: "22" bolsas plásticas); vacío = no aplica
+	UnitTaxAmount      decimal.Decimal // Valor del impuesto por unidad vendida (ej: 66 por bolsa)
+	WithholdingConcept string          // Concepto de retención (COMPRAS, SERVICIOS…); vacío = COMPRAS
+	UNSPSC_Code        string
+	UnitMeasure        string
+	Attributes         json.RawMessage
+	COGS               decimal.Decimal // costo de bienes vendidos (analítica)
+	ReorderPoint       decimal.Decimal // punto de reorden para alertas de ruptura
+	ProductType        string          // STANDARD | KIT (kit = caja armada al vender con otros productos)
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// Tipos de producto.
+const (
+	ProductTypeStandard = "STANDARD"
+	ProductTypeKit      = "KIT" // no maneja stock propio: descuenta sus componentes al venderse
+)
+
+// IsKit indica si el producto es un kit/combo cuyo stock se deriva de sus componentes.
+func (p *Product) IsKit() bool {
+	return p != nil && p.ProductType == ProductTypeKit
+}
+
+// TypeOrDefault devuelve el tipo de producto o STANDARD si está vacío.
+func (p *Product) TypeOrDefault() string {
+	if p.ProductType == "" {
+		return ProductTypeStandard
+	}
+	return p.ProductType
 }
 
 // IdealStock retorna el nivel de stock objetivo: 1.5× el punto de reorden.
@@ -0,0 +1,27 @@
+package entity
+
+import "github.com/shopspring/decimal"
+
+// KitComponent representa una línea de la composición de un kit: producto kit ↔ componente.
+// Quantity es la cantidad del componente por cada unidad del kit.
+type KitComponent struct {
+	KitProductID       string
+	ComponentProductID string
+	Quantity           decimal.Decimal
+
+	// Component se carga al armar la venta (precio y costo para el prorrateo).
+	Component *Product
+}
+
+// InvoiceKitComponent desglose de un kit facturado: qué componentes salieron por cada unidad
+// del kit, con el precio prorrateado y el costo al momento de la venta. Las notas crédito lo
+// usan para devolver exactamente los componentes que se despacharon.
+type InvoiceKitComponent struct {
+	ID                 string
+	InvoiceID          string
+	KitProductID       string
+	ComponentProductID string
+	Quantity           decimal.Decimal // cantidad del componente por unidad de kit
+	UnitPrice          decimal.Decimal // precio prorrateado por unidad de componente
+	UnitCost           decimal.Decimal // costo promedio del componente al vender
+}
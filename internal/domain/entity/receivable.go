@@ -0,0 +1,104 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Estados de pago de una factura (derivados de su saldo).
+const (
+	PaymentStatusPending = "PENDING" // Sin abonos
+	PaymentStatusPartial = "PARTIAL" // Abonada parcialmente
+	PaymentStatusPaid    = "PAID"    // Saldo en cero
+)
+
+// ReceivableDIANStatuses estados DIAN de una factura emitida, que genera cartera: aceptada, en contingencia
+// o firmada/enviada con la respuesta de la DIAN pendiente. Borradores, errores y rechazadas no se cobran.
+var ReceivableDIANStatuses = []string{
+	DIANStatusExitoso,
+	DIANStatusContingencia,
+	DIANStatusSigned,
+	DIANStatusSent,
+	DIANStatusPending,
+}
+
+// IsReceivableDIANStatus indica si una factura con ese estado DIAN entra en cartera.
+func IsReceivableDIANStatus(status string) bool {
+	for _, s := range ReceivableDIANStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Payment recaudo de un cliente. Se aplica a una o varias facturas; lo que no se aplica queda
+// como saldo a favor del cliente (UnappliedAmount) para cruzarlo con facturas posteriores.
+type Payment struct {
+	ID              string
+	CompanyID       string
+	CustomerID      string
+	Date            time.Time
+	Amount          decimal.Decimal
+	CurrencyCode    string // moneda del recaudo; solo se aplica a facturas en la misma moneda
+	MethodCode      string // medio de pago (Tabla 13 DIAN: 10 efectivo, 47 transferencia, 48/49 tarjeta…)
+	Reference       string // número de consignación, voucher, etc.
+	Notes           string
+	UnappliedAmount decimal.Decimal
+	CreatedBy       string
+	CreatedAt       time.Time
+
+	// Applications abonos del recaudo a facturas; se carga bajo demanda.
+	Applications []*PaymentApplication
+}
+
+// PaymentApplication abono de un recaudo a una factura.
+type PaymentApplication struct {
+	ID        string
+	PaymentID string
+	InvoiceID string
+	Amount    decimal.Decimal
+	AppliedAt time.Time
+}
+
+// ReceivableInvoice factura vista desde cartera: valor a cobrar, abonos y saldo pendiente.
+type ReceivableInvoice struct {
+	InvoiceID    string
+	CustomerID   string
+	CustomerName string
+	Prefix       string
+	Number       string
+	Date         time.Time
+	DueDate      time.Time // vencimiento; en facturas de contado es la fecha de emisión
+	CurrencyCode string
+	Total        decimal.Decimal // neto a pagar más notas débito menos notas crédito
+	AmountPaid   decimal.Decimal
+}
+
+// Balance saldo pendiente de la factura.
+func (r *ReceivableInvoice) Balance() decimal.Decimal {
+	return r.Total.Sub(r.AmountPaid)
+}
+
+// PaymentStatus estado de pago según el saldo.
+func (r *ReceivableInvoice) PaymentStatus() string {
+	switch {
+	case !r.Balance().IsPositive():
+		return PaymentStatusPaid
+	case r.AmountPaid.IsPositive():
+		return PaymentStatusPartial
+	default:
+		return PaymentStatusPending
+	}
+}
+
+// DaysOverdue días transcurridos desde el vencimiento hasta asOf (0 si no ha vencido).
+func (r *ReceivableInvoice) DaysOverdue(asOf time.Time) int {
+	due := time.Date(r.DueDate.Year(), r.DueDate.Month(), r.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	if !day.After(due) {
+		return 0
+	}
+	return int(day.Sub(due).Hours() / 24)
+}
@@ -0,0 +1,185 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Estados RADIAN de una factura recibida según el último evento validado.
+const (
+	ReceivedDocStatusReceived        = "RECEIVED"         // registrada, sin eventos
+	ReceivedDocStatusAcknowledged    = "ACKNOWLEDGED"     // acuse de recibo (030)
+	ReceivedDocStatusGoodsReceived   = "GOODS_RECEIVED"   // recibo del bien o servicio (032)
+	ReceivedDocStatusAccepted        = "ACCEPTED"         // aceptación expresa (033)
+	ReceivedDocStatusClaimed         = "CLAIMED"          // reclamo (031)
+	ReceivedDocStatusTacitlyAccepted = "TACITLY_ACCEPTED" // vencido el término sin reclamo (034)
+)
+
+// Orígenes del registro de una factura recibida.
+const (
+	ReceivedDocSourceManual = "MANUAL" // capturada o cargada por un usuario
+	ReceivedDocSourceEmail  = "EMAIL"  // AttachedDocument leído del buzón de la empresa
+)
+
+// Resultados del procesamiento de un adjunto del buzón candidato a factura electrónica.
+const (
+	EmailInvoiceImported  = "IMPORTED"  // se registró la factura recibida
+	EmailInvoiceDuplicate = "DUPLICATE" // la empresa ya tenía la factura (mismo CUFE)
+	EmailInvoiceIgnored   = "IGNORED"   // no es una factura electrónica dirigida a la empresa
+	EmailInvoiceFailed    = "FAILED"    // XML ilegible o incompleto
+)
+
+// EventDIANStatusLocal estado de los eventos que solo quedan en el historial (la aceptación tácita la
+// emite el facturador electrónico, no el adquiriente).
+const EventDIANStatusLocal = "LOCAL"
+
+// ReceivedDocument factura electrónica de venta recibida de un proveedor (la empresa es el adquiriente)
+// sobre la que se emiten los eventos RADIAN.
+type ReceivedDocument struct {
+	ID               string
+	CompanyID        string
+	SupplierID       string // proveedor de la empresa con el NIT del emisor; vacío si no está registrado
+	IssuerNIT        string
+	IssuerName       string
+	Number           string // prefijo + número de la factura del proveedor
+	DocumentTypeCode string // 01 factura de venta
+	CUFE             string
+	IssueDate        time.Time
+	CustomerNIT      string // NIT del adquiriente según el XML; vacío en los registros manuales
+	Currency         string
+
+	NetTotal   decimal.Decimal
+	TaxTotal   decimal.Decimal
+	GrandTotal decimal.Decimal
+	XML        string // XML de la factura (o del AttachedDocument) tal como se recibió; puede ser vacío
+	Lines      []ReceivedDocumentLine
+	Taxes      []ReceivedDocumentTax
+
+	// Conciliación y validación de las facturas leídas del buzón. SignatureValid y CUFEValid son nil
+	// cuando no se verificaron (registro manual).
+	PurchaseOrderID   string
+	EmailAttachmentID string
+	SignatureValid    *bool
+	CUFEValid         *bool
+	ValidationErrors  string
+
+	Source          string     // MANUAL | EMAIL
+	Status          string     // RECEIVED | ACKNOWLEDGED | GOODS_RECEIVED | ACCEPTED | CLAIMED | TACITLY_ACCEPTED
+	GoodsReceivedAt *time.Time // validación del 032: inicio del término de la aceptación tácita
+
+	Events    []*ReceivedDocumentEvent
+	CreatedBy string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ReceivedDocumentLine línea (cac:InvoiceLine) de una factura recibida.
+type ReceivedDocumentLine struct {
+	ID            string
+	LineNumber    int
+	ItemCode      string
+	Description   string
+	Quantity      decimal.Decimal
+	UnitCode      string
+	UnitPrice     decimal.Decimal
+	LineExtension decimal.Decimal // base de la línea
+	TaxAmount     decimal.Decimal
+}
+
+// ReceivedDocumentTax total de un impuesto y tarifa (cac:TaxSubtotal) de una factura recibida.
+type ReceivedDocumentTax struct {
+	TaxCode       string // 01 IVA, 04 INC, 03 ICA
+	TaxName       string
+	Percent       decimal.Decimal
+	TaxableAmount decimal.Decimal
+	TaxAmount     decimal.Decimal
+}
+
+// EmailInvoiceAttachment adjunto ZIP/XML de un correo sincronizado, candidato a factura electrónica de
+// un proveedor.
+type EmailInvoiceAttachment struct {
+	ID          string
+	EmailID     string
+	CompanyID   string
+	FileName    string
+	MIMEType    string
+	Content     []byte
+	FromAddress string
+	Subject     string
+	ReceivedAt  time.Time
+}
+
+// EmailInvoiceIngestion resultado del procesamiento de un adjunto del buzón.
+type EmailInvoiceIngestion struct {
+	AttachmentID       string
+	CompanyID          string
+	Status             string // IMPORTED | DUPLICATE | IGNORED | FAILED
+	ReceivedDocumentID string
+	Error              string
+	ProcessedAt        time.Time
+}
+
+// ReceivedDocumentEvent evento RADIAN (ApplicationResponse) sobre una factura recibida, con su CUDE,
+// XML firmado y resultado de la DIAN.
+type ReceivedDocumentEvent struct {
+	ID                 string
+	ReceivedDocumentID string
+	CompanyID          string
+	EventCode          string // 030 | 031 | 032 | 033 | 034
+	Number             string // consecutivo de eventos de la empresa (cbc:ID)
+	Date               time.Time
+
+	ClaimConcept string // concepto del reclamo (031): 01..04
+	Note         string
+
+	// Persona que recibe la factura o el bien (obligatoria en 030 y 032).
+	PersonID       string
+	PersonName     string
+	PersonJobTitle string
+
+	CUDE       string
+	XMLSigned  string
+	DIANStatus string // DRAFT, SIGNED, EXITOSO, RECHAZADO, CONTINGENCIA, ERROR_GENERATION o LOCAL
+	DIANErrors string
+	TrackID    string
+
+	// Reenvíos en contingencia.
+	StatusChecks int
+	NextCheckAt  *time.Time
+
+	CreatedBy string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// StatusForEvent estado de la factura recibida cuando el evento queda validado.
+func StatusForEvent(code string) string {
+	switch code {
+	case "030":
+		return ReceivedDocStatusAcknowledged
+	case "032":
+		return ReceivedDocStatusGoodsReceived
+	case "033":
+		return ReceivedDocStatusAccepted
+	case "031":
+		return ReceivedDocStatusClaimed
+	case "034":
+		return ReceivedDocStatusTacitlyAccepted
+	}
+	return ""
+}
+
+// IsValidated indica si la DIAN aceptó el evento (o si es un registro local del historial).
+func (e *ReceivedDocumentEvent) IsValidated() bool {
+	return e.DIANStatus == DIANStatusExitoso || e.DIANStatus == EventDIANStatusLocal
+}
+
+// IsFailed indica si el evento terminó sin validar y puede volver a emitirse.
+func (e *ReceivedDocumentEvent) IsFailed() bool {
+	switch e.DIANStatus {
+	case DIANStatusRechazado, DIANStatusErrorGeneration, DIANStatusError:
+		return true
+	}
+	return false
+}
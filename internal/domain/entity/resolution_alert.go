@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Tipos de alerta sobre resoluciones de facturación.
+const (
+	ResolutionAlertKindLowNumbers = "LOW_NUMBERS" // quedan pocos números o la proyección de agotamiento está cerca
+	ResolutionAlertKindNearExpiry = "NEAR_EXPIRY" // la vigencia de la resolución está por vencer
+)
+
+// ResolutionAlert alerta emitida para una resolución; se registra una sola vez por tipo y cruce de
+// umbral, identificado por el rango final y la vigencia de la resolución al momento de alertar.
+type ResolutionAlert struct {
+	ID           string
+	CompanyID    string
+	ResolutionID string
+	Kind         string
+	RangeTo      int64
+	DateTo       time.Time
+	Message      string
+	CreatedAt    time.Time
+}
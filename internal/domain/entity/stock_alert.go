@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tipos de alerta de stock por bodega.
+const (
+	StockAlertKindReorderPoint = "REORDER_POINT" // stock por debajo del punto de reorden
+	StockAlertKindMinStock     = "MIN_STOCK"     // stock por debajo del mínimo configurado
+	StockAlertKindNearExpiry   = "NEAR_EXPIRY"   // lote con saldo que vence dentro de la ventana configurada
+	StockAlertKindExpired      = "EXPIRED"       // lote con saldo ya vencido
+)
+
+// Estados de una alerta de stock.
+const (
+	StockAlertStatusOpen     = "OPEN"
+	StockAlertStatusResolved = "RESOLVED"
+)
+
+// StockAlert alerta generada cuando un producto cruza por debajo de un umbral en una bodega o cuando
+// un lote se acerca a su vencimiento. Solo existe una alerta OPEN por (producto, bodega, tipo, lote);
+// las de stock se resuelven al recuperar el stock y las de vencimiento al agotarse el lote.
+type StockAlert struct {
+	ID            string
+	CompanyID     string
+	ProductID     string
+	WarehouseID   string
+	Kind          string
+	Quantity      decimal.Decimal // stock al momento de generar la alerta
+	Threshold     decimal.Decimal // umbral de stock; en alertas de vencimiento, la ventana en días
+	Status        string
+	CreatedAt     time.Time
+	ResolvedAt    *time.Time
+	LotID         string     // solo alertas de vencimiento
+	LotNumber     string     // solo lectura (join)
+	ExpiryDate    *time.Time // solo lectura (join)
+	ProductName   string     // solo lectura (join)
+	SKU           string     // solo lectura (join)
+	WarehouseName string     // solo lectura (join)
+}
+
+// StockLevelThresholds stock actual de un producto en una bodega junto con sus umbrales.
+// ReorderPoint toma la configuración por bodega y, si no existe, el punto de reorden del producto.
+type StockLevelThresholds struct {
+	CompanyID     string
+	ProductID     string
+	WarehouseID   string
+	ProductName   string
+	SKU           string
+	WarehouseName string
+	Quantity      decimal.Decimal
+	ReorderPoint  decimal.Decimal
+	MinStock      decimal.Decimal
+}
+
+// StockAlertSubscription preferencia de un usuario para recibir alertas de stock.
+// WarehouseID vacío = todas las bodegas de la empresa.
+type StockAlertSubscription struct {
+	ID          string
+	CompanyID   string
+	UserID      string
+	WarehouseID string
+	NotifyEmail bool
+	NotifyInApp bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// StockAlertRecipient destinatario resuelto de una alerta (suscripción + email del usuario).
+type StockAlertRecipient struct {
+	UserID      string
+	Email       string
+	NotifyEmail bool
+	NotifyInApp bool
+}
+
+// Notification notificación in-app dirigida a un usuario.
+type Notification struct {
+	ID          string
+	CompanyID   string
+	UserID      string
+	Kind        string
+	Title       string
+	Body        string
+	ReferenceID string // p.ej. ID de la alerta de stock
+	ReadAt      *time.Time
+	CreatedAt   time.Time
+}
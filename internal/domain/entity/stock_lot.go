@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockLot lote con fecha de vencimiento de un producto en una bodega. Se crea en las entradas que
+// informan vencimiento y las salidas lo consumen FEFO (primero en vencer, primero en salir).
+// El stock que entró sin vencimiento no tiene lote y no se controla.
+type StockLot struct {
+	ID              string
+	CompanyID       string
+	ProductID       string
+	WarehouseID     string
+	LotNumber       string // número de lote del proveedor (opcional)
+	ExpiryDate      time.Time
+	InitialQuantity decimal.Decimal
+	Quantity        decimal.Decimal // saldo pendiente de consumir
+	MovementID      string          // movimiento de entrada que creó el lote
+	CreatedAt       time.Time
+	ProductName     string // solo lectura (join)
+	SKU             string // solo lectura (join)
+	WarehouseName   string // solo lectura (join)
+}
+
+// IsExpired indica si el lote ya venció el día today (se puede usar hasta su fecha de vencimiento inclusive).
+func (l *StockLot) IsExpired(today time.Time) bool {
+	y, m, d := today.Date()
+	return l.ExpiryDate.Before(time.Date(y, m, d, 0, 0, 0, 0, l.ExpiryDate.Location()))
+}
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tipos de documento soporte en adquisiciones a no obligados a facturar.
+const (
+	SupportDocumentTypeDS         = "SUPPORT_DOCUMENT"   // Documento soporte (InvoiceTypeCode 05)
+	SupportDocumentTypeAdjustment = "SUPPORT_ADJUSTMENT" // Nota de ajuste al documento soporte (95)
+)
+
+// SupportDocument documento soporte electrónico que la empresa (adquiriente) emite por las compras a
+// proveedores no obligados a facturar, o su nota de ajuste. Se identifica con el CUDS y usa una
+// resolución de numeración propia (prefijo distinto al de las facturas de venta).
+type SupportDocument struct {
+	ID              string
+	CompanyID       string
+	SupplierID      string
+	PurchaseOrderID string // orden de compra recibida que origina el documento; vacío = compra manual
+	DocumentType    string // SUPPORT_DOCUMENT | SUPPORT_ADJUSTMENT
+	Prefix          string
+	Number          string
+	Date            time.Time
+
+	// Vendedor no obligado a facturar (AccountingSupplierParty), copiado del proveedor al emitir.
+	SupplierName               string
+	SupplierIdentification     string
+	SupplierIdentificationType string // 13=CC, 31=NIT, 22/41/42/50=extranjeros
+
+	NetTotal   decimal.Decimal
+	TaxTotal   decimal.Decimal
+	GrandTotal decimal.Decimal
+
+	CUDS       string // Código Único de Documento Soporte (SHA-384)
+	XMLSigned  string
+	DIANStatus string // mismos estados que las facturas (DRAFT, SIGNED, Sent, EXITOSO, RECHAZADO…)
+	DIANErrors string
+	TrackID    string
+
+	// Seguimiento de GetStatusZip y reenvíos en contingencia.
+	StatusChecks int
+	NextCheckAt  *time.Time
+
+	// Nota de ajuste: documento soporte ajustado y concepto (tabla de conceptos de nota crédito).
+	OriginalDocumentID     string
+	OriginalDocumentNumber string
+	OriginalDocumentCUDS   string
+	OriginalIssueOn        time.Time
+	DiscrepancyCode        CreditNoteConcept
+	DiscrepancyReason      string
+
+	Notes     string
+	Lines     []*SupportDocumentLine
+	CreatedBy string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SupportDocumentLine línea del documento soporte (bien o servicio adquirido).
+type SupportDocumentLine struct {
+	ID                string
+	SupportDocumentID string
+	ProductID         string // opcional: servicios o compras sin producto de inventario
+	Description       string
+	Quantity          decimal.Decimal
+	UnitPrice         decimal.Decimal
+	TaxRate           decimal.Decimal // fracción (0.19); los no obligados a facturar normalmente no cobran IVA
+	Subtotal          decimal.Decimal
+	TaxAmount         decimal.Decimal
+}
+
+// IsAdjustment indica si el documento es una nota de ajuste.
+func (d *SupportDocument) IsAdjustment() bool {
+	return d.DocumentType == SupportDocumentTypeAdjustment
+}
+
+// FullNumber prefijo y consecutivo del documento (como va en el XML y en el CUDS).
+func (d *SupportDocument) FullNumber() string {
+	return d.Prefix + d.Number
+}
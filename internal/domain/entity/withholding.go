@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// WithholdingConceptCompras concepto de retención por defecto de los productos (compras generales).
+const WithholdingConceptCompras = "COMPRAS"
+
+// WithholdingRule tarifa de retención de la empresa para un tributo (05 ReteIVA, 06 ReteFuente,
+// 07 ReteICA) y un concepto (COMPRAS, SERVICIOS, HONORARIOS…).
+type WithholdingRule struct {
+	ID         string
+	CompanyID  string
+	TaxCode    string
+	Concept    string
+	Rate       decimal.Decimal // porcentaje (ej: 2.5 ReteFuente, 15 ReteIVA, 0.966 ReteICA)
+	MinBaseUVT decimal.Decimal // base mínima en UVT; por debajo no se retiene (0 = siempre)
+	IsActive   bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// InvoiceWithholding retención practicada sobre una factura, por tributo y concepto.
+// Para ReteIVA la base es el IVA facturado; para ReteFuente y ReteICA, el subtotal.
+type InvoiceWithholding struct {
+	ID         string
+	InvoiceID  string
+	TaxCode    string
+	Concept    string
+	BaseAmount decimal.Decimal
+	Rate       decimal.Decimal
+	Amount     decimal.Decimal
+}
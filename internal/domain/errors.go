@@ -13,4 +13,7 @@ var (
 	ErrForbidden         = errors.New("acceso denegado")
 	ErrConflict          = errors.New("conflicto con el estado actual")
 	ErrInsufficientStock = errors.New("stock insuficiente")
+	ErrPeriodClosed      = errors.New("período de inventario cerrado")
+	ErrNoActiveResolution = errors.New("no hay resolución de facturación vigente para el prefijo")
+	ErrResolutionExhausted = errors.New("rango de numeración de la resolución agotado")
 )
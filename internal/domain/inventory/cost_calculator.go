@@ -12,3 +12,18 @@ func CostCalculator(stockActual, costoActual, cantEntrada, costoEntrada decimal.
 	num := stockActual.Mul(costoActual).Add(cantEntrada.Mul(costoEntrada))
 	return num.Div(sum)
 }
+
+// ReverseCostCalculator deshace el efecto de una entrada sobre el costo promedio ponderado.
+// NuevoCosto = ((StockActual * CostoActual) - (CantEntrada * CostoEntrada)) / (StockActual - CantEntrada)
+// Si el stock resultante queda en cero se conserva el costo actual.
+func ReverseCostCalculator(stockActual, costoActual, cantEntrada, costoEntrada decimal.Decimal) decimal.Decimal {
+	rest := stockActual.Sub(cantEntrada)
+	if rest.LessThanOrEqual(decimal.Zero) {
+		return costoActual
+	}
+	num := stockActual.Mul(costoActual).Sub(cantEntrada.Mul(costoEntrada))
+	if num.LessThan(decimal.Zero) {
+		return decimal.Zero
+	}
+	return num.Div(rest)
+}
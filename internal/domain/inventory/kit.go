@@ -0,0 +1,59 @@
+package inventory
+
+import "github.com/shopspring/decimal"
+
+// KitPriceShares reparte el precio de una unidad de kit entre sus componentes en proporción
+// a weights (normalmente precio de lista × cantidad del componente). Cada parte se redondea a
+// 2 decimales y el residuo se asigna al último componente para que la suma cuadre exacto.
+// Si todos los pesos son cero el precio se reparte en partes iguales.
+func KitPriceShares(kitPrice decimal.Decimal, weights []decimal.Decimal) []decimal.Decimal {
+	shares := make([]decimal.Decimal, len(weights))
+	if len(weights) == 0 {
+		return shares
+	}
+	total := decimal.Zero
+	for _, w := range weights {
+		if w.GreaterThan(decimal.Zero) {
+			total = total.Add(w)
+		}
+	}
+	n := decimal.NewFromInt(int64(len(weights)))
+	assigned := decimal.Zero
+	for i, w := range weights {
+		if i == len(weights)-1 {
+			shares[i] = kitPrice.Sub(assigned)
+			break
+		}
+		var share decimal.Decimal
+		if total.IsZero() {
+			share = kitPrice.Div(n).Round(2)
+		} else if w.GreaterThan(decimal.Zero) {
+			share = kitPrice.Mul(w).Div(total).Round(2)
+		}
+		shares[i] = share
+		assigned = assigned.Add(share)
+	}
+	return shares
+}
+
+// KitAvailability calcula cuántos kits completos se pueden armar en una bodega:
+// el mínimo, sobre los componentes, de floor(stock / cantidad por kit). Nunca negativo.
+func KitAvailability(stocks, perKit []decimal.Decimal) decimal.Decimal {
+	if len(stocks) == 0 || len(stocks) != len(perKit) {
+		return decimal.Zero
+	}
+	var available decimal.Decimal
+	for i := range stocks {
+		if !perKit[i].GreaterThan(decimal.Zero) {
+			return decimal.Zero
+		}
+		kits := stocks[i].Div(perKit[i]).Floor()
+		if i == 0 || kits.LessThan(available) {
+			available = kits
+		}
+	}
+	if available.LessThan(decimal.Zero) {
+		return decimal.Zero
+	}
+	return available
+}
@@ -36,6 +36,18 @@ type SKUMarginResult struct {
 	GrossProfit  decimal.Decimal // GrossRevenue - TotalCOGS
 }
 
+// InventoryAgingRow stock vigente de un producto con sus últimas fechas de venta y recepción.
+// La valorización se hace al costo promedio actual del producto.
+type InventoryAgingRow struct {
+	ProductID     string
+	SKU           string
+	ProductName   string
+	Quantity      decimal.Decimal
+	UnitCost      decimal.Decimal
+	LastSaleAt    *time.Time // nil si nunca se ha vendido
+	LastReceiptAt *time.Time // nil si nunca tuvo entradas
+}
+
 // AnalyticsRepository define las consultas de lectura para analítica de rentabilidad.
 // Las implementaciones son read-only (no modifican datos).
 type AnalyticsRepository interface {
@@ -88,4 +100,12 @@ type AnalyticsRepository interface {
 		startDate, endDate time.Time,
 		limit int,
 	) ([]dto.RawMaterialImpactDTO, error)
+
+	// GetInventoryAging devuelve los productos con stock positivo (en warehouseID o en todas las
+	// bodegas si está vacío) con la fecha de su última venta (facturas válidas, incluidas las
+	// ventas como componente de un kit) y de su última entrada de inventario.
+	GetInventoryAging(
+		ctx context.Context,
+		companyID, warehouseID string,
+	) ([]InventoryAgingRow, error)
 }
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/shopspring/decimal"
 )
 
 // MovementFilters filtros para consultar movimientos de inventario.
@@ -24,4 +25,15 @@ type InventoryMovementRepository interface {
 	List(companyID string, f MovementFilters) ([]*entity.InventoryMovement, int64, error)
 	ListByWarehouse(warehouseID string, from, to *time.Time, limit, offset int) ([]*entity.InventoryMovement, error)
 	ListByProduct(productID string, from, to *time.Time, limit, offset int) ([]*entity.InventoryMovement, error)
+	// ListByTransaction devuelve todos los movimientos de una transacción (orden de creación).
+	ListByTransaction(transactionID string) ([]*entity.InventoryMovement, error)
+	// ListByProductChronological lista movimientos del producto con from <= date < to en orden cronológico ascendente.
+	ListByProductChronological(productID string, from, to *time.Time) ([]*entity.InventoryMovement, error)
+	// UpdateCost reestampa el costo de un movimiento (recálculo de costo promedio por movimientos retroactivos).
+	UpdateCost(id string, unitCost, totalCost decimal.Decimal) error
+	// MarkReversed marca el movimiento como revertido; devuelve domain.ErrConflict si ya lo estaba.
+	MarkReversed(id, reversedBy string, reversedAt time.Time) error
+	// LockOpenPeriod bloquea hasta el fin de la transacción el período (year, month) de la empresa y
+	// los posteriores; devuelve domain.ErrPeriodClosed si alguno está cerrado.
+	LockOpenPeriod(companyID string, year, month int) error
 }
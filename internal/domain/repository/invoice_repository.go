@@ -16,6 +16,41 @@ type InvoiceRepository interface {
 	Update(invoice *entity.Invoice) error
 	GetByID(id string) (*entity.Invoice, error)
 	GetDetailsByInvoiceID(invoiceID string) ([]*entity.InvoiceDetail, error)
+	// CreateKitComponent persiste una línea del desglose de un kit facturado.
+	CreateKitComponent(line *entity.InvoiceKitComponent) error
+	// GetKitComponentsByInvoiceID devuelve el desglose de kits de la factura (vacío si no vendió kits).
+	GetKitComponentsByInvoiceID(invoiceID string) ([]*entity.InvoiceKitComponent, error)
+	// CreateTax persiste un impuesto de línea (IVA, INC, impuesto por unidad) en invoice_taxes.
+	CreateTax(tax *entity.InvoiceTax) error
+	// GetTaxesByInvoiceID devuelve el desglose de impuestos del documento (vacío en documentos
+	// emitidos antes del desglose; el llamador reconstruye el IVA desde los detalles).
+	GetTaxesByInvoiceID(invoiceID string) ([]*entity.InvoiceTax, error)
+	// CreateWithholding persiste una retención (ReteFuente, ReteIVA, ReteICA) practicada sobre la factura.
+	CreateWithholding(w *entity.InvoiceWithholding) error
+	// GetWithholdingsByInvoiceID devuelve las retenciones de la factura (vacío si no tiene).
+	GetWithholdingsByInvoiceID(invoiceID string) ([]*entity.InvoiceWithholding, error)
+	// CreateAllowanceCharge persiste un descuento o cargo (de línea o global) del documento.
+	CreateAllowanceCharge(ac *entity.InvoiceAllowanceCharge) error
+	// GetAllowanceChargesByInvoiceID devuelve los descuentos y cargos del documento (vacío si no tiene).
+	GetAllowanceChargesByInvoiceID(invoiceID string) ([]*entity.InvoiceAllowanceCharge, error)
+	// CreateInstallment persiste una cuota del plan de pagos de una factura a crédito.
+	CreateInstallment(inst *entity.InvoiceInstallment) error
+	// GetInstallmentsByInvoiceID devuelve las cuotas de la factura ordenadas por número (vacío si no tiene).
+	GetInstallmentsByInvoiceID(invoiceID string) ([]*entity.InvoiceInstallment, error)
+	// LockActiveResolution devuelve la resolución activa de la empresa para el prefijo bloqueando
+	// su fila hasta el fin de la transacción, de modo que las facturas concurrentes del mismo
+	// prefijo se serialicen al tomar consecutivo. nil, nil si no hay resolución activa.
+	LockActiveResolution(companyID, prefix string) (*entity.BillingResolution, error)
+	// LockActiveNoteResolution es LockActiveResolution para la resolución de notas de la empresa
+	// (entity.ResolutionDocumentCreditNote o entity.ResolutionDocumentDebitNote), sin prefijo explícito.
+	LockActiveNoteResolution(companyID, documentType string) (*entity.BillingResolution, error)
+	// NextNoteSequence incrementa y devuelve la secuencia de notas de la empresa para el tipo
+	// (crédito o débito), usada cuando no hay resolución de notas. Bloquea la fila de la secuencia
+	// hasta el fin de la transacción: si se revierte, el número vuelve a estar disponible.
+	NextNoteSequence(companyID, documentType string) (int64, error)
+	// ConsumeResolutionNumber marca como usado el siguiente número de la resolución (used_numbers + 1).
+	// Debe ejecutarse en la misma transacción que persiste el documento para no dejar huecos.
+	ConsumeResolutionNumber(resolutionID string) error
 	// GetDIANStatus devuelve solo los campos de estado DIAN (ligero, para polling).
 	GetDIANStatus(id string) (*entity.Invoice, error)
 	// GetDIANSummary devuelve los contadores de resumen DIAN para una empresa.
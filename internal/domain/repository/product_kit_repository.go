@@ -0,0 +1,11 @@
+package repository
+
+import "github.com/jhoicas/Inventario-api/internal/domain/entity"
+
+// ProductKitRepository define el puerto de persistencia para la composición de kits.
+type ProductKitRepository interface {
+	// ListComponents devuelve los componentes del kit con el producto componente cargado.
+	ListComponents(kitProductID string) ([]*entity.KitComponent, error)
+	// ReplaceComponents reemplaza atómicamente la composición del kit.
+	ReplaceComponents(kitProductID string, components []*entity.KitComponent) error
+}
@@ -25,4 +25,19 @@ type StockRepository interface {
 	Upsert(stock *entity.Stock) error
 	// GetForUpdate opcional: bloquea la fila para update (SELECT FOR UPDATE).
 	GetForUpdate(productID, warehouseID string) (*entity.Stock, error)
+	// CreateLot registra un lote con vencimiento (entradas y destino de traslados).
+	CreateLot(lot *entity.StockLot) error
+	// ConsumeLotsFEFO descuenta quantity de los lotes con saldo del producto en la bodega, del que vence
+	// primero al último, y devuelve las porciones consumidas (Quantity = lo descontado de cada lote).
+	// Si los lotes no alcanzan, el resto sale del stock sin lote.
+	ConsumeLotsFEFO(productID, warehouseID string, quantity decimal.Decimal) ([]entity.StockLot, error)
+	// ConsumeMovementLots descuenta hasta quantity de los lotes que creó la entrada movementID (FEFO entre
+	// ellos) y devuelve las porciones consumidas. Se usa al revertir esa entrada.
+	ConsumeMovementLots(movementID string, quantity decimal.Decimal) ([]entity.StockLot, error)
+	// LotBalance suma el saldo de los lotes del producto en la bodega.
+	LotBalance(productID, warehouseID string) (decimal.Decimal, error)
+	// RecordLotConsumption registra las porciones de lote que consumió el movimiento movementID.
+	RecordLotConsumption(movementID string, lots []entity.StockLot) error
+	// RestoreLotConsumption devuelve a sus lotes las porciones que consumió movementID y las retorna.
+	RestoreLotConsumption(movementID string) ([]entity.StockLot, error)
 }
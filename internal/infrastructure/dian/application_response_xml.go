@@ -0,0 +1,185 @@
+package dian
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// Perfil DIAN de los eventos RADIAN sobre la factura electrónica de venta.
+const (
+	profileApplicationResponse       = "DIAN 2.1: ApplicationResponse de la Factura Electrónica de Venta"
+	customizationApplicationResponse = "1"
+)
+
+// BuildRADIANEvent calcula el CUDE del evento RADIAN (queda en ev.CUDE) y genera su ApplicationResponse
+// sin firmar. Con softwareID vacío omite el SoftwareSecurityCode.
+func (s *XMLBuilderService) BuildRADIANEvent(ev *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument, company *entity.Company, softwareID, softwarePIN, tipoAmbiente string) ([]byte, error) {
+	if _, err := CalculateCudeFromEvent(ev, doc, company, softwarePIN); err != nil {
+		return nil, err
+	}
+	var securityCode string
+	if softwareID != "" {
+		securityCode = SoftwareSecurityCode(softwareID, softwarePIN, ev.Number)
+	}
+	return s.BuildApplicationResponse(&ApplicationResponseBuildContext{
+		Event:                ev,
+		Document:             doc,
+		Company:              company,
+		SoftwareID:           softwareID,
+		SoftwareSecurityCode: securityCode,
+		TipoAmbiente:         tipoAmbiente,
+	})
+}
+
+// PackageRADIANEvent comprime el ApplicationResponse firmado del evento (ev.XMLSigned) en el ZIP de envío.
+// Devuelve el nombre y los bytes del ZIP.
+func (s *XMLBuilderService) PackageRADIANEvent(ev *entity.ReceivedDocumentEvent, company *entity.Company) (string, []byte, error) {
+	_, zipName := DIANDocumentFilenames(company, "AR", ev.Number)
+	xmlName := strings.TrimSuffix(zipName, ".zip") + ".xml"
+	zipBytes, err := CompressXMLToZip([]byte(ev.XMLSigned), xmlName)
+	if err != nil {
+		return "", nil, err
+	}
+	return zipName, zipBytes, nil
+}
+
+// BuildApplicationResponse genera el XML UBL 2.1 (sin firma) del evento RADIAN (raíz ApplicationResponse):
+// la empresa como SenderParty, el proveedor como ReceiverParty y la factura recibida en
+// cac:DocumentReference con su CUFE. El segundo ext:ExtensionContent queda vacío para la firma.
+func (s *XMLBuilderService) BuildApplicationResponse(ctx *ApplicationResponseBuildContext) ([]byte, error) {
+	if ctx == nil || ctx.Event == nil || ctx.Document == nil || ctx.Company == nil {
+		return nil, fmt.Errorf("dian: faltan evento, factura recibida o company en el contexto")
+	}
+	ev, doc := ctx.Event, ctx.Document
+	if doc.CUFE == "" {
+		return nil, fmt.Errorf("dian: la factura recibida %s no tiene CUFE", doc.Number)
+	}
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Space: NsApplicationResponse, Local: "ApplicationResponse"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "Id"}, Value: "applicationresponse-id"},
+			{Name: xml.Name{Local: "xmlns"}, Value: NsApplicationResponse},
+			{Name: xml.Name{Local: "xmlns:cac"}, Value: NsCac},
+			{Name: xml.Name{Local: "xmlns:cbc"}, Value: NsCbc},
+			{Name: xml.Name{Local: "xmlns:ds"}, Value: NsDs},
+			{Name: xml.Name{Local: "xmlns:ext"}, Value: NsExt},
+			{Name: xml.Name{Local: "xmlns:sts"}, Value: NsSts},
+			{Name: xml.Name{Local: "xmlns:xades"}, Value: NsXades},
+			{Name: xml.Name{Local: "xmlns:xsi"}, Value: nsXsi},
+			{Name: xml.Name{Space: nsXsi, Local: "schemaLocation"}, Value: schemaLocationApplicationResponse},
+		},
+	}
+	if err := enc.EncodeToken(root); err != nil {
+		return nil, err
+	}
+
+	// ext:UBLExtensions: software de la empresa (los eventos no llevan resolución); placeholder de firma.
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtensions"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	writeDianExtensions(enc, nil, ctx.Company.NIT, ctx.SoftwareID, ctx.SoftwareSecurityCode)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtensions"}})
+
+	writeCbc(enc, "UBLVersionID", "2.1")
+	writeCbc(enc, "CustomizationID", customizationApplicationResponse)
+	writeCbc(enc, "ProfileID", profileApplicationResponse)
+	if ctx.TipoAmbiente != "" {
+		writeCbc(enc, "ProfileExecutionID", ctx.TipoAmbiente)
+	}
+	writeCbc(enc, "ID", ev.Number)
+	if ev.CUDE != "" {
+		writeCbcWithAttr(enc, "UUID", ev.CUDE, "schemeName", "CUDE-SHA384")
+	}
+	writeCbc(enc, "IssueDate", ev.Date.Format("2006-01-02"))
+	writeCbc(enc, "IssueTime", ev.Date.Format("15:04:05-07:00"))
+	if ev.Note != "" {
+		writeCbc(enc, "Note", ev.Note)
+	}
+
+	writeEventParty(enc, "SenderParty", ctx.Company.Name, ctx.Company.NIT)
+	writeEventParty(enc, "ReceiverParty", doc.IssuerName, doc.IssuerNIT)
+
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "DocumentResponse"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Response"}})
+	if ev.EventCode == dian.EventReclamo {
+		writeCbcWithAttr(enc, "ResponseCode", ev.EventCode, "listID", ev.ClaimConcept)
+	} else {
+		writeCbc(enc, "ResponseCode", ev.EventCode)
+	}
+	writeCbc(enc, "Description", dian.EventNames[ev.EventCode])
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Response"}})
+
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "DocumentReference"}})
+	writeCbc(enc, "ID", doc.Number)
+	writeCbcWithAttr(enc, "UUID", doc.CUFE, "schemeName", "CUFE-SHA384")
+	docType := doc.DocumentTypeCode
+	if docType == "" {
+		docType = dian.InvoiceTypeVenta
+	}
+	writeCbc(enc, "DocumentTypeCode", docType)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "DocumentReference"}})
+
+	// Persona que recibe la factura (030) o el bien o servicio (032).
+	if ev.PersonID != "" {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "IssuerParty"}})
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Person"}})
+		writeCbcWithAttr(enc, "ID", normalizeNIT(ev.PersonID), "schemeName", dian.IdentificationTypeCC)
+		writeCbc(enc, "FirstName", ev.PersonName)
+		if ev.PersonJobTitle != "" {
+			writeCbc(enc, "JobTitle", ev.PersonJobTitle)
+		}
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Person"}})
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "IssuerParty"}})
+	}
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "DocumentResponse"}})
+
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeEventParty escribe SenderParty o ReceiverParty con la razón social y el NIT (cac:PartyTaxScheme).
+func writeEventParty(enc *xml.Encoder, element, name, nit string) {
+	writePartyTaxScheme(enc, element, name, normalizeNIT(nit), dian.IdentificationTypeNIT)
+}
+
+// writePartyTaxScheme escribe la parte con la razón social y la identificación del tipo schemeName.
+func writePartyTaxScheme(enc *xml.Encoder, element, name, id, schemeName string) {
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: element}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PartyTaxScheme"}})
+	writeCbc(enc, "RegistrationName", name)
+	_ = enc.EncodeToken(xml.StartElement{
+		Name: xml.Name{Space: NsCbc, Local: "CompanyID"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "schemeAgencyID"}, Value: "195"},
+			{Name: xml.Name{Local: "schemeName"}, Value: schemeName},
+		},
+	})
+	_ = enc.EncodeToken(xml.CharData(id))
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCbc, Local: "CompanyID"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "TaxScheme"}})
+	writeCbc(enc, "ID", "01")
+	writeCbc(enc, "Name", "IVA")
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "TaxScheme"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PartyTaxScheme"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: element}})
+}
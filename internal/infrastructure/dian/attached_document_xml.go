@@ -0,0 +1,233 @@
+package dian
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/tls"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	domdian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// Contenedor DIAN de entrega del documento electrónico al adquiriente.
+const (
+	customizationAttachedDocument = "Documentos adjuntos"
+	profileAttachedDocument       = "Factura Electrónica de Venta"
+	documentTypeAttachedDocument  = "Contenedor de Factura Electrónica"
+	validatorIDDIAN               = "Unidad Especial Dirección de Impuestos y Aduanas Nacionales"
+)
+
+// BuildAttachedDocument genera el XML UBL 2.1 (sin firma) del AttachedDocument: el emisor como SenderParty,
+// el adquiriente como ReceiverParty, el documento firmado en cac:Attachment y el ApplicationResponse de la
+// DIAN con su resultado en cac:ParentDocumentLineReference. El único ext:ExtensionContent queda vacío para
+// la firma.
+func (s *XMLBuilderService) BuildAttachedDocument(ctx *AttachedDocumentBuildContext) ([]byte, error) {
+	if ctx == nil || ctx.Invoice == nil || ctx.Company == nil || ctx.Customer == nil {
+		return nil, fmt.Errorf("dian: faltan factura, company o customer en el contexto")
+	}
+	inv := ctx.Invoice
+	if len(ctx.SignedXML) == 0 {
+		return nil, fmt.Errorf("dian: la factura %s%s no tiene XML firmado", inv.Prefix, inv.Number)
+	}
+	if inv.CUFE == "" {
+		return nil, fmt.Errorf("dian: la factura %s%s no tiene CUFE", inv.Prefix, inv.Number)
+	}
+	docNum := strings.TrimSpace(inv.Prefix) + strings.TrimSpace(inv.Number)
+	validatedAt := applicationResponseIssued(ctx.ApplicationResponse, inv.UpdatedAt)
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Space: NsAttachedDocument, Local: "AttachedDocument"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns"}, Value: NsAttachedDocument},
+			{Name: xml.Name{Local: "xmlns:cac"}, Value: NsCac},
+			{Name: xml.Name{Local: "xmlns:cbc"}, Value: NsCbc},
+			{Name: xml.Name{Local: "xmlns:ds"}, Value: NsDs},
+			{Name: xml.Name{Local: "xmlns:ext"}, Value: NsExt},
+			{Name: xml.Name{Local: "xmlns:xades"}, Value: NsXades},
+			{Name: xml.Name{Local: "xmlns:xsi"}, Value: nsXsi},
+			{Name: xml.Name{Space: nsXsi, Local: "schemaLocation"}, Value: schemaLocationAttachedDocument},
+		},
+	}
+	if err := enc.EncodeToken(root); err != nil {
+		return nil, err
+	}
+
+	// ext:UBLExtensions: el contenedor solo lleva la firma del emisor.
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtensions"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtensions"}})
+
+	writeCbc(enc, "UBLVersionID", "UBL 2.1")
+	writeCbc(enc, "CustomizationID", customizationAttachedDocument)
+	writeCbc(enc, "ProfileID", profileAttachedDocument)
+	if ctx.TipoAmbiente != "" {
+		writeCbc(enc, "ProfileExecutionID", ctx.TipoAmbiente)
+	}
+	writeCbc(enc, "ID", docNum)
+	writeCbc(enc, "IssueDate", validatedAt.Format("2006-01-02"))
+	writeCbc(enc, "IssueTime", validatedAt.Format("15:04:05-07:00"))
+	writeCbc(enc, "DocumentType", documentTypeAttachedDocument)
+	writeCbc(enc, "ParentDocumentID", docNum)
+
+	writeEventParty(enc, "SenderParty", ctx.Company.Name, ctx.Company.NIT)
+	identCode := schemeIDFromCode(ctx.Customer.IdentificationType)
+	writePartyTaxScheme(enc, "ReceiverParty", ctx.Customer.Name, customerIdentification(ctx.Customer.TaxID, identCode), identCode)
+
+	// Documento electrónico firmado, tal como se envió a la DIAN.
+	writeAttachment(enc, ctx.SignedXML)
+
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "ParentDocumentLineReference"}})
+	writeCbc(enc, "LineID", "1")
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "DocumentReference"}})
+	writeCbc(enc, "ID", docNum)
+	uuidScheme := "CUFE-SHA384"
+	if inv.DocumentType != "" && inv.DocumentType != "INVOICE" {
+		uuidScheme = "CUDE-SHA384"
+	}
+	writeCbcWithAttr(enc, "UUID", inv.CUFE, "schemeName", uuidScheme)
+	writeCbc(enc, "IssueDate", inv.Date.Format("2006-01-02"))
+	writeCbc(enc, "DocumentType", "ApplicationResponse")
+	if len(ctx.ApplicationResponse) > 0 {
+		writeAttachment(enc, ctx.ApplicationResponse)
+	}
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "ResultOfVerification"}})
+	writeCbc(enc, "ValidatorID", validatorIDDIAN)
+	writeCbc(enc, "ValidationResultCode", validationResultCode(ctx.ApplicationResponse, inv.DIAN_Status))
+	writeCbc(enc, "ValidationDate", validatedAt.Format("2006-01-02"))
+	writeCbc(enc, "ValidationTime", validatedAt.Format("15:04:05-07:00"))
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "ResultOfVerification"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "DocumentReference"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "ParentDocumentLineReference"}})
+
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeAttachment escribe cac:Attachment/cac:ExternalReference con el XML embebido en CDATA.
+func writeAttachment(enc *xml.Encoder, content []byte) {
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Attachment"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "ExternalReference"}})
+	writeCbc(enc, "MimeCode", "text/xml")
+	writeCbc(enc, "EncodingCode", "UTF-8")
+	_ = enc.EncodeElement(struct {
+		Text string `xml:",cdata"`
+	}{string(content)}, xml.StartElement{Name: xml.Name{Space: NsCbc, Local: "Description"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "ExternalReference"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Attachment"}})
+}
+
+// applicationResponseIssued fecha y hora del ApplicationResponse (momento de la validación DIAN); sin
+// ApplicationResponse legible usa fallback.
+func applicationResponseIssued(ar []byte, fallback time.Time) time.Time {
+	var doc struct {
+		IssueDate string `xml:"IssueDate"`
+		IssueTime string `xml:"IssueTime"`
+	}
+	if len(ar) > 0 && xml.Unmarshal(ar, &doc) == nil && doc.IssueDate != "" {
+		for _, layout := range []string{"2006-01-02T15:04:05-07:00", "2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05"} {
+			if t, err := time.Parse(layout, doc.IssueDate+"T"+doc.IssueTime); err == nil {
+				return t
+			}
+		}
+	}
+	if fallback.IsZero() {
+		return time.Now()
+	}
+	return fallback
+}
+
+// validationResultCode código del cac:ResultOfVerification: el del ApplicationResponse o, sin él, 02 si
+// el documento quedó EXITOSO (simulado en dev) y 04 en otro caso.
+func validationResultCode(ar []byte, status string) string {
+	if len(ar) > 0 {
+		if code, _, err := domdian.ParseApplicationResponse(ar); err == nil && code != "" {
+			return code
+		}
+	}
+	if status == entity.DIANStatusExitoso {
+		return domdian.ApplicationResponseAccepted
+	}
+	return domdian.ApplicationResponseRejected
+}
+
+// AttachedDocumentFilenames nombres del paquete de entrega al adquiriente (Anexo Técnico, nombres de
+// archivos): z{NIT}{PPP}{AA}{CONSECUTIVO}.zip con ad{…}.xml y la representación gráfica ad{…}.pdf. El NIT
+// va en 10 dígitos, PPP es 000 (software propio), AA el año de emisión y el consecutivo, en 8 dígitos
+// hexadecimales, sale del número del documento.
+func AttachedDocumentFilenames(company *entity.Company, inv *entity.Invoice) (xmlName, pdfName, zipName string) {
+	nit := normalizeNIT(company.NIT)
+	if i := strings.Index(company.NIT, "-"); i != -1 {
+		nit = normalizeNIT(company.NIT[:i])
+	}
+	if len(nit) < 10 {
+		nit = strings.Repeat("0", 10-len(nit)) + nit
+	}
+	consecutive, _ := strconv.ParseUint(normalizeNIT(inv.Number), 10, 32)
+	base := fmt.Sprintf("%s000%02d%08x", nit, inv.Date.Year()%100, consecutive)
+	return "ad" + base + ".xml", "ad" + base + ".pdf", "z" + base + ".zip"
+}
+
+// BuildAttachedDocumentZip construye el AttachedDocument de la factura validada (su XML firmado y el
+// ApplicationResponse de la DIAN), lo firma y lo empaqueta con el PDF de la representación gráfica en el
+// ZIP que se entrega al adquiriente. Devuelve el nombre y los bytes del ZIP.
+func (s *XMLBuilderService) BuildAttachedDocumentZip(inv *entity.Invoice, company *entity.Company, customer *entity.Customer, tipoAmbiente string, signer dian.Signer, cert tls.Certificate, pdf []byte) (string, []byte, error) {
+	if inv == nil {
+		return "", nil, fmt.Errorf("dian: falta la factura del AttachedDocument")
+	}
+	xmlBytes, err := s.BuildAttachedDocument(&AttachedDocumentBuildContext{
+		Invoice:             inv,
+		Company:             company,
+		Customer:            customer,
+		SignedXML:           []byte(inv.XMLSigned),
+		ApplicationResponse: []byte(inv.ApplicationResponse),
+		TipoAmbiente:        tipoAmbiente,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	signed, err := signer.Sign(xmlBytes, cert)
+	if err != nil {
+		return "", nil, fmt.Errorf("dian: firmar AttachedDocument: %w", err)
+	}
+	xmlName, pdfName, zipName := AttachedDocumentFilenames(company, inv)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range []struct {
+		name    string
+		content []byte
+	}{{xmlName, signed}, {pdfName, pdf}} {
+		if len(f.content) == 0 {
+			continue
+		}
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return "", nil, fmt.Errorf("zip: crear entrada %s: %w", f.name, err)
+		}
+		if _, err := fw.Write(f.content); err != nil {
+			return "", nil, fmt.Errorf("zip: escribir %s: %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", nil, fmt.Errorf("zip: cerrar archivo: %w", err)
+	}
+	return zipName, buf.Bytes(), nil
+}
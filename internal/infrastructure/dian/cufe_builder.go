@@ -1,6 +1,8 @@
 package dian
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"regexp"
 	"strings"
@@ -15,17 +17,23 @@ type CufeContext struct {
 	Invoice      *entity.Invoice
 	Company      *entity.Company
 	Customer     *entity.Customer
-	ClaveTecnica string // Clave técnica de la resolución (DB)
-	TipoAmbiente string // "1" = Producción, "2" = Pruebas
+	ClaveTecnica string               // Clave técnica de la resolución (DB)
+	SoftwarePIN  string               // PIN del software; solo para el CUDE de documentos equivalentes POS
+	TipoAmbiente string               // "1" = Producción, "2" = Pruebas
+	Taxes        []*entity.InvoiceTax // desglose de impuestos; vacío = todo el TaxTotal como IVA
 }
 
 // CalculateCufeFromInvoice construye CufeParams desde el contexto y devuelve el CUFE (hex).
-// Asigna el valor a inv.CUFE e inv.UUID. ValFac = NetTotal, ValImp_01 = IVA; Impoconsumo e ICA en 0 si no aplican.
+// Asigna el valor a inv.CUFE e inv.UUID. ValFac = NetTotal; ValImp_01/04/03 son la suma de los impuestos
+// de cada tributo. Sin desglose (documentos antiguos) todo el TaxTotal se toma como IVA.
 func CalculateCufeFromInvoice(ctx *CufeContext) (string, error) {
 	if ctx == nil || ctx.Invoice == nil || ctx.Company == nil || ctx.Customer == nil {
 		return "", errors.New("dian: se requieren factura, empresa y cliente para calcular el CUFE")
 	}
 	inv := ctx.Invoice
+	if inv.IsPOS() {
+		return calculateCudeFromPOS(ctx)
+	}
 	tipoAmb := ctx.TipoAmbiente
 	if tipoAmb == "" {
 		tipoAmb = "1"
@@ -40,14 +48,21 @@ func CalculateCufeFromInvoice(ctx *CufeContext) (string, error) {
 		docType = "92"
 	}
 
+	valIVA, valINC, valICA := inv.TaxTotal, decimal.Zero, decimal.Zero
+	if len(ctx.Taxes) > 0 {
+		valIVA = domdian.TaxAmountByCode(ctx.Taxes, domdian.CodImpIVA)
+		valINC = domdian.TaxAmountByCode(ctx.Taxes, domdian.CodImpImpoconsumo)
+		valICA = domdian.TaxAmountByCode(ctx.Taxes, domdian.CodImpICA)
+	}
+
 	params := &domdian.CufeParams{
 		NumFac:    strings.TrimSpace(inv.Prefix) + strings.TrimSpace(inv.Number),
 		DocType:   docType,
 		FecFac:    inv.Date.Format("2006-01-02"), // YYYY-MM-DD
 		ValFac:    inv.NetTotal,                  // Valor total sin impuestos
-		ValImp_01: inv.TaxTotal,                  // IVA (código 01)
-		ValImp_04: decimal.Zero,                  // Impoconsumo (04); poner valor si aplica
-		ValImp_03: decimal.Zero,                  // ICA (03); poner valor si aplica
+		ValImp_01: valIVA,                        // IVA (código 01)
+		ValImp_04: valINC,                        // Impoconsumo (04)
+		ValImp_03: valICA,                        // ICA (03)
 		ValPag:    inv.GrandTotal,
 		NitOfe:    onlyDigitsNIT(ctx.Company.NIT),
 		DocAdq:    onlyDigitsNIT(ctx.Customer.TaxID),
@@ -64,6 +79,94 @@ func CalculateCufeFromInvoice(ctx *CufeContext) (string, error) {
 	return cufe, nil
 }
 
+// calculateCudeFromPOS calcula el CUDE del documento equivalente POS (PIN del software en lugar de la
+// clave técnica, con hora de emisión) y lo asigna a inv.CUFE e inv.UUID.
+func calculateCudeFromPOS(ctx *CufeContext) (string, error) {
+	inv := ctx.Invoice
+	valIVA, valINC, valICA := inv.TaxTotal, decimal.Zero, decimal.Zero
+	if len(ctx.Taxes) > 0 {
+		valIVA = domdian.TaxAmountByCode(ctx.Taxes, domdian.CodImpIVA)
+		valINC = domdian.TaxAmountByCode(ctx.Taxes, domdian.CodImpImpoconsumo)
+		valICA = domdian.TaxAmountByCode(ctx.Taxes, domdian.CodImpICA)
+	}
+	cude, err := domdian.CalculateCUDE(&domdian.CudeParams{
+		NumDE:       strings.TrimSpace(inv.Prefix) + strings.TrimSpace(inv.Number),
+		FecDE:       inv.Date.Format("2006-01-02"),
+		HorDE:       inv.Date.Format("15:04:05-07:00"),
+		ValDE:       inv.NetTotal,
+		ValImp1:     valIVA,
+		ValImp2:     valINC,
+		ValImp3:     valICA,
+		ValTot:      inv.GrandTotal,
+		NitOFE:      ctx.Company.NIT,
+		NumAdq:      ctx.Customer.TaxID,
+		SoftwarePIN: ctx.SoftwarePIN,
+		TipoAmb:     ctx.TipoAmbiente,
+	})
+	if err != nil {
+		return "", err
+	}
+	inv.CUFE = cude
+	inv.UUID = cude
+	return cude, nil
+}
+
 func onlyDigitsNIT(s string) string {
 	return regexp.MustCompile(`[^0-9]`).ReplaceAllString(s, "")
 }
+
+// SoftwareSecurityCode calcula sts:SoftwareSecurityCode: SHA-384 (hex) de SoftwareID + PIN + número
+// del documento (prefijo y consecutivo).
+func SoftwareSecurityCode(softwareID, pin, number string) string {
+	sum := sha512.Sum384([]byte(softwareID + pin + number))
+	return hex.EncodeToString(sum[:])
+}
+
+// CalculateCudsFromSupportDocument calcula el CUDS del documento soporte (o nota de ajuste) y lo asigna
+// a doc.CUDS. NumSNO es la identificación del vendedor y NITABS el NIT de la empresa adquiriente.
+func CalculateCudsFromSupportDocument(doc *entity.SupportDocument, company *entity.Company, softwarePIN, tipoAmbiente string) (string, error) {
+	if doc == nil || company == nil {
+		return "", errors.New("dian: se requieren documento soporte y empresa para calcular el CUDS")
+	}
+	cuds, err := domdian.CalculateCUDS(&domdian.CudsParams{
+		NumDS:       doc.FullNumber(),
+		FecDS:       doc.Date.Format("2006-01-02"),
+		HorDS:       doc.Date.Format("15:04:05-07:00"),
+		ValDS:       doc.NetTotal,
+		ValImp:      doc.TaxTotal,
+		ValTol:      doc.GrandTotal,
+		NumSNO:      doc.SupplierIdentification,
+		NITABS:      company.NIT,
+		SoftwarePIN: softwarePIN,
+		TipoAmb:     tipoAmbiente,
+	})
+	if err != nil {
+		return "", err
+	}
+	doc.CUDS = cuds
+	return cuds, nil
+}
+
+// CalculateCudeFromEvent calcula el CUDE del evento RADIAN y lo asigna a event.CUDE. NitFE es el NIT de
+// la empresa (adquiriente que emite el evento) y DocAdq el del proveedor que emitió la factura.
+func CalculateCudeFromEvent(event *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument, company *entity.Company, softwarePIN string) (string, error) {
+	if event == nil || doc == nil || company == nil {
+		return "", errors.New("dian: se requieren evento, factura recibida y empresa para calcular el CUDE del evento")
+	}
+	cude, err := domdian.CalculateEventCUDE(&domdian.EventCudeParams{
+		NumDE:            event.Number,
+		FecEmi:           event.Date.Format("2006-01-02"),
+		HorEmi:           event.Date.Format("15:04:05-07:00"),
+		NitFE:            company.NIT,
+		DocAdq:           doc.IssuerNIT,
+		ResponseCode:     event.EventCode,
+		ID:               doc.Number,
+		DocumentTypeCode: doc.DocumentTypeCode,
+		SoftwarePIN:      softwarePIN,
+	})
+	if err != nil {
+		return "", err
+	}
+	event.CUDE = cude
+	return cude, nil
+}
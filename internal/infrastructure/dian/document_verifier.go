@@ -0,0 +1,440 @@
+// Verificación de integridad de documentos electrónicos firmados para auditoría: firma XAdES-EPES,
+// certificado del firmante y CUFE/CUDE recalculado desde los campos del XML.
+
+package dian
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/shopspring/decimal"
+
+	domdian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/infrastructure/dian/signer"
+)
+
+// Comprobaciones de DocumentVerifierService.
+const (
+	CheckSignature           = "SIGNATURE"            // ds:SignatureValue y digest de cada ds:Reference
+	CheckSignedProperties    = "XADES"                // propiedades firmadas: hora, certificado y política
+	CheckCertificateValidity = "CERTIFICATE_VALIDITY" // vigencia del certificado en la hora de firma
+	CheckCertificateChain    = "CERTIFICATE_CHAIN"    // cadena hasta una CA de confianza
+	CheckDocumentUUID        = "UUID"                 // CUFE/CUDE recalculado contra cbc:UUID
+)
+
+// Resultado de cada comprobación.
+const (
+	VerificationOK      = "OK"
+	VerificationFailed  = "FAILED"
+	VerificationSkipped = "SKIPPED"
+)
+
+// VerificationCheck resultado de una comprobación; Detail explica la falla o por qué se omitió.
+type VerificationCheck struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// DocumentVerification resultado de verificar un documento firmado.
+type DocumentVerification struct {
+	DocumentType string // raíz UBL: Invoice, CreditNote, DebitNote…
+	Number       string // cbc:ID
+	UUID         string // cbc:UUID del documento
+	UUIDScheme   string // CUFE-SHA384 o CUDE-SHA384
+	SupplierNIT  string // identificación del emisor en el XML
+	Signer       string // sujeto del certificado del firmante
+	SignerNIT    string // NIT del titular del certificado (SERIALNUMBER del sujeto)
+	SigningTime  time.Time
+	Checks       []VerificationCheck
+}
+
+// Valid indica que ninguna comprobación falló; las omitidas no invalidan el documento.
+func (v *DocumentVerification) Valid() bool {
+	for _, c := range v.Checks {
+		if c.Status == VerificationFailed {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *DocumentVerification) add(name, status, detail string) {
+	v.Checks = append(v.Checks, VerificationCheck{Name: name, Status: status, Detail: detail})
+}
+
+// DocumentKeys secretos del emisor con que se calculó el código único; no viajan en el XML. Vacíos
+// omiten el recálculo (documentos de otros emisores).
+type DocumentKeys struct {
+	TechnicalKey string // clave técnica de la resolución (CUFE y notas)
+	SoftwarePIN  string // PIN del software (CUDE del documento equivalente POS)
+}
+
+// DocumentVerifierService verifica documentos firmados guardados (Invoice.XMLSigned) o cargados.
+type DocumentVerifierService struct {
+	roots *x509.CertPool
+}
+
+// NewDocumentVerifierService crea el verificador. roots son las CA de confianza para la cadena del
+// certificado (Certicámara, GSE, Andes…); nil usa las del sistema.
+func NewDocumentVerifierService(roots *x509.CertPool) *DocumentVerifierService {
+	return &DocumentVerifierService{roots: roots}
+}
+
+// LoadCertPool lee un archivo PEM con los certificados de las CA de confianza.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("leer CA de confianza: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("dian: %s no contiene certificados PEM", path)
+	}
+	return pool, nil
+}
+
+// Verify comprueba la firma XAdES-EPES (valor, digest y propiedades firmadas), la vigencia del
+// certificado en la hora de firma, su cadena y el CUFE/CUDE. Solo devuelve error si el XML no se puede
+// leer; cada falla queda en Checks.
+func (s *DocumentVerifierService) Verify(xmlBytes []byte, keys DocumentKeys) (*DocumentVerification, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(xmlBytes); err != nil {
+		return nil, fmt.Errorf("dian: parsear XML: %w", err)
+	}
+	root := doc.Root()
+	if root == nil {
+		return nil, fmt.Errorf("dian: documento sin raíz")
+	}
+	var fields documentCodeXML
+	if err := xml.Unmarshal(xmlBytes, &fields); err != nil {
+		return nil, fmt.Errorf("dian: parsear XML: %w", err)
+	}
+	out := &DocumentVerification{
+		DocumentType: root.Tag,
+		Number:       strings.TrimSpace(fields.ID),
+		UUID:         strings.TrimSpace(fields.UUID.Value),
+		UUIDScheme:   strings.TrimSpace(fields.UUID.SchemeName),
+		SupplierNIT:  firstNonEmpty(fields.Supplier.ID, fields.Supplier.nit()),
+	}
+
+	sig := findElement(root, func(e *etree.Element) bool {
+		return e.Tag == "Signature" && e.NamespaceURI() == NamespaceDS
+	})
+	if sig == nil {
+		out.add(CheckSignature, VerificationFailed, ErrSignatureNotFound.Error())
+		for _, name := range []string{CheckSignedProperties, CheckCertificateValidity, CheckCertificateChain} {
+			out.add(name, VerificationSkipped, "documento sin firma")
+		}
+	} else {
+		s.verifySignature(xmlBytes, sig, out)
+	}
+	checkDocumentUUID(&fields, keys, out)
+	return out, nil
+}
+
+// VerifySigner comprueba solo la firma del documento (valor y digest, propiedades XAdES, vigencia del
+// certificado en la hora de firma y cadena) y devuelve el NIT del titular del certificado. Es la
+// verificación de documentos de terceros cuyo código único no se puede recalcular: facturas de
+// proveedores y ApplicationResponse de la DIAN.
+func (s *DocumentVerifierService) VerifySigner(xmlBytes []byte) (string, error) {
+	doc, err := signer.ParseDocument(xmlBytes)
+	if err != nil {
+		return "", fmt.Errorf("dian: parsear XML firmado: %w", err)
+	}
+	root := doc.Root()
+	if root == nil {
+		return "", fmt.Errorf("dian: documento sin raíz")
+	}
+	sig := findElement(root, func(e *etree.Element) bool {
+		return e.Tag == "Signature" && e.NamespaceURI() == NamespaceDS
+	})
+	if sig == nil {
+		return "", ErrSignatureNotFound
+	}
+	out := &DocumentVerification{}
+	s.verifySignature(xmlBytes, sig, out)
+	var failed []string
+	for _, c := range out.Checks {
+		if c.Status == VerificationFailed {
+			failed = append(failed, c.Detail)
+		}
+	}
+	if len(failed) > 0 {
+		return out.SignerNIT, errors.New(strings.Join(failed, "; "))
+	}
+	return out.SignerNIT, nil
+}
+
+func (s *DocumentVerifierService) verifySignature(xmlBytes []byte, sig *etree.Element, out *DocumentVerification) {
+	if _, err := VerifyXMLSignature(xmlBytes); err != nil {
+		out.add(CheckSignature, VerificationFailed, err.Error())
+	} else {
+		out.add(CheckSignature, VerificationOK, "")
+	}
+
+	certs, err := keyInfoCertificates(sig)
+	if err != nil {
+		out.add(CheckSignedProperties, VerificationFailed, err.Error())
+		out.add(CheckCertificateValidity, VerificationSkipped, "sin certificado del firmante")
+		out.add(CheckCertificateChain, VerificationSkipped, "sin certificado del firmante")
+		return
+	}
+	cert := certs[0]
+	out.Signer = cert.Subject.String()
+	out.SignerNIT = certificateNIT(cert)
+
+	signingTime, err := checkSignedProperties(sig, cert)
+	out.SigningTime = signingTime
+	if err != nil {
+		out.add(CheckSignedProperties, VerificationFailed, err.Error())
+	} else {
+		out.add(CheckSignedProperties, VerificationOK, "")
+	}
+
+	switch {
+	case signingTime.IsZero():
+		out.add(CheckCertificateValidity, VerificationFailed, "sin xades:SigningTime no se puede comprobar la vigencia")
+	case signingTime.Before(cert.NotBefore) || signingTime.After(cert.NotAfter):
+		out.add(CheckCertificateValidity, VerificationFailed, fmt.Sprintf("firmado el %s con un certificado vigente del %s al %s",
+			signingTime.Format(time.RFC3339), cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339)))
+	default:
+		out.add(CheckCertificateValidity, VerificationOK, "")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         s.roots,
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   signingTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if chains, err := cert.Verify(opts); err != nil {
+		out.add(CheckCertificateChain, VerificationFailed, err.Error())
+	} else {
+		chain := chains[0]
+		out.add(CheckCertificateChain, VerificationOK, "emitido por "+chain[len(chain)-1].Subject.String())
+	}
+}
+
+// certificateNIT NIT del titular del certificado de firma: las CA colombianas lo llevan en el atributo
+// SERIALNUMBER (2.5.4.5) del sujeto, a veces con prefijo o dígito de verificación.
+func certificateNIT(cert *x509.Certificate) string {
+	var b strings.Builder
+	for _, r := range cert.Subject.SerialNumber {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// keyInfoCertificates devuelve los ds:X509Certificate de ds:KeyInfo; el primero es el del firmante y
+// los demás se usan como intermedios de la cadena.
+func keyInfoCertificates(sig *etree.Element) ([]*x509.Certificate, error) {
+	leaf, err := signatureCertificate(sig)
+	if err != nil {
+		return nil, err
+	}
+	certs := []*x509.Certificate{leaf}
+	keyInfo := childElement(sig, "KeyInfo")
+	for _, data := range keyInfo.ChildElements() {
+		for _, el := range data.ChildElements() {
+			if el.Tag != "X509Certificate" {
+				continue
+			}
+			der, err := decodeBase64Text(el)
+			if err != nil {
+				continue
+			}
+			if c, err := x509.ParseCertificate(der); err == nil && !c.Equal(leaf) {
+				certs = append(certs, c)
+			}
+		}
+	}
+	return certs, nil
+}
+
+// checkSignedProperties revisa las propiedades XAdES-EPES: que una ds:Reference las cubra, la hora de
+// firma, el digest del certificado firmante y la política de firma. Devuelve la hora de firma aunque
+// falle otra propiedad.
+func checkSignedProperties(sig *etree.Element, cert *x509.Certificate) (time.Time, error) {
+	props := findElement(sig, func(e *etree.Element) bool {
+		return e.Tag == "SignedProperties" && e.NamespaceURI() == NamespaceXAdES
+	})
+	if props == nil {
+		return time.Time{}, fmt.Errorf("la firma no tiene xades:SignedProperties (no es XAdES)")
+	}
+	var signingTime time.Time
+	if st := findElement(props, func(e *etree.Element) bool { return e.Tag == "SigningTime" }); st != nil {
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(st.Text()))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("xades:SigningTime inválido: %q", st.Text())
+		}
+		signingTime = t
+	}
+
+	covered := false
+	if id := props.SelectAttrValue("Id", ""); id != "" {
+		if signedInfo := childElement(sig, "SignedInfo"); signedInfo != nil {
+			for _, ref := range signedInfo.ChildElements() {
+				if ref.Tag == "Reference" && ref.SelectAttrValue("URI", "") == "#"+id {
+					covered = true
+				}
+			}
+		}
+	}
+	if !covered {
+		return signingTime, fmt.Errorf("ninguna ds:Reference cubre xades:SignedProperties")
+	}
+	if signingTime.IsZero() {
+		return signingTime, fmt.Errorf("falta xades:SigningTime")
+	}
+
+	certDigest := findElement(props, func(e *etree.Element) bool { return e.Tag == "CertDigest" })
+	if certDigest == nil {
+		return signingTime, fmt.Errorf("falta xades:SigningCertificate")
+	}
+	hash, ok := digestAlgorithms[algorithmOf(childElement(certDigest, "DigestMethod"))]
+	if !ok {
+		return signingTime, fmt.Errorf("algoritmo de digest del certificado no soportado")
+	}
+	expected, err := decodeBase64Text(childElement(certDigest, "DigestValue"))
+	if err != nil {
+		return signingTime, fmt.Errorf("digest del certificado: %w", err)
+	}
+	h := hash.New()
+	h.Write(cert.Raw)
+	if !bytes.Equal(h.Sum(nil), expected) {
+		return signingTime, fmt.Errorf("xades:SigningCertificate no corresponde al certificado de ds:KeyInfo")
+	}
+
+	policy := findElement(props, func(e *etree.Element) bool { return e.Tag == "SigPolicyId" })
+	if policy == nil || strings.TrimSpace(childText(policy, "Identifier")) == "" {
+		return signingTime, fmt.Errorf("falta la política de firma (xades:SignaturePolicyIdentifier)")
+	}
+	return signingTime, nil
+}
+
+func childText(el *etree.Element, tag string) string {
+	if c := childElement(el, tag); c != nil {
+		return c.Text()
+	}
+	return ""
+}
+
+// codePartyXML identificación de la parte: cac:PartyIdentification (documentos propios) o el NIT de
+// PartyTaxScheme/PartyLegalEntity (documentos de terceros).
+type codePartyXML struct {
+	partyXML
+	ID string `xml:"PartyIdentification>ID"`
+}
+
+type uuidXML struct {
+	Value      string `xml:",chardata"`
+	SchemeName string `xml:"schemeName,attr"`
+}
+
+// documentCodeXML campos del XML que entran en el CUFE/CUDE.
+type documentCodeXML struct {
+	XMLName            xml.Name
+	ProfileExecutionID string                  `xml:"ProfileExecutionID"`
+	ID                 string                  `xml:"ID"`
+	UUID               uuidXML                 `xml:"UUID"`
+	IssueDate          string                  `xml:"IssueDate"`
+	IssueTime          string                  `xml:"IssueTime"`
+	Supplier           codePartyXML            `xml:"AccountingSupplierParty>Party"`
+	Customer           codePartyXML            `xml:"AccountingCustomerParty>Party"`
+	TaxTotals          []validationTaxTotalXML `xml:"TaxTotal"`
+	LegalTotal         monetaryTotalXML        `xml:"LegalMonetaryTotal"`
+	RequestedTotal     monetaryTotalXML        `xml:"RequestedMonetaryTotal"`
+}
+
+// checkDocumentUUID recalcula el CUFE (facturas y notas) o el CUDE (documento equivalente POS) con la
+// misma cadena con que se generó y lo compara con cbc:UUID.
+func checkDocumentUUID(x *documentCodeXML, keys DocumentKeys, out *DocumentVerification) {
+	uuid := strings.TrimSpace(x.UUID.Value)
+	if uuid == "" {
+		out.add(CheckDocumentUUID, VerificationFailed, "el documento no trae cbc:UUID")
+		return
+	}
+	totals := x.LegalTotal
+	if x.XMLName.Local == domdian.RootDebitNote {
+		totals = x.RequestedTotal
+	}
+	taxes := map[string]decimal.Decimal{}
+	for _, tt := range x.TaxTotals {
+		for _, st := range tt.TaxSubtotal {
+			code := strings.TrimSpace(st.TaxCategory.TaxSchemeID)
+			taxes[code] = taxes[code].Add(parseAmount(st.TaxAmount.Value))
+		}
+	}
+	customer := firstNonEmpty(x.Customer.ID, x.Customer.nit())
+
+	var computed string
+	var err error
+	if x.XMLName.Local == domdian.RootInvoice && strings.HasPrefix(strings.ToUpper(x.UUID.SchemeName), "CUDE") {
+		if keys.SoftwarePIN == "" {
+			out.add(CheckDocumentUUID, VerificationSkipped, "sin el PIN del software del emisor no se puede recalcular el CUDE")
+			return
+		}
+		computed, err = domdian.CalculateCUDE(&domdian.CudeParams{
+			NumDE:       x.ID,
+			FecDE:       strings.TrimSpace(x.IssueDate),
+			HorDE:       strings.TrimSpace(x.IssueTime),
+			ValDE:       parseAmount(totals.LineExtension.Value),
+			ValImp1:     taxes[domdian.CodImpIVA],
+			ValImp2:     taxes[domdian.CodImpImpoconsumo],
+			ValImp3:     taxes[domdian.CodImpICA],
+			ValTot:      parseAmount(totals.Payable.Value),
+			NitOFE:      out.SupplierNIT,
+			NumAdq:      customer,
+			SoftwarePIN: keys.SoftwarePIN,
+			TipoAmb:     strings.TrimSpace(x.ProfileExecutionID),
+		})
+	} else {
+		if keys.TechnicalKey == "" {
+			out.add(CheckDocumentUUID, VerificationSkipped, "sin la clave técnica del emisor no se puede recalcular el CUFE")
+			return
+		}
+		docType := "01"
+		switch x.XMLName.Local {
+		case domdian.RootCreditNote:
+			docType = "91"
+		case domdian.RootDebitNote:
+			docType = "92"
+		}
+		computed, err = domdian.NewCufeCalculatorService().Calculate(&domdian.CufeParams{
+			NumFac:    x.ID,
+			DocType:   docType,
+			FecFac:    strings.TrimSpace(x.IssueDate),
+			ValFac:    parseAmount(totals.LineExtension.Value),
+			ValImp_01: taxes[domdian.CodImpIVA],
+			ValImp_04: taxes[domdian.CodImpImpoconsumo],
+			ValImp_03: taxes[domdian.CodImpICA],
+			ValPag:    parseAmount(totals.Payable.Value),
+			NitOfe:    out.SupplierNIT,
+			DocAdq:    customer,
+			ClTec:     keys.TechnicalKey,
+			TipoAmb:   strings.TrimSpace(x.ProfileExecutionID),
+		})
+	}
+	if err != nil {
+		out.add(CheckDocumentUUID, VerificationFailed, err.Error())
+		return
+	}
+	if !strings.EqualFold(computed, uuid) {
+		out.add(CheckDocumentUUID, VerificationFailed, "el código recalculado desde el XML es "+computed)
+		return
+	}
+	out.add(CheckDocumentUUID, VerificationOK, "")
+}
@@ -0,0 +1,365 @@
+package dian
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+// ErrNotElectronicInvoice el archivo no contiene una factura electrónica; ver domaindian.
+var ErrNotElectronicInvoice = domaindian.ErrNotElectronicInvoice
+
+// ReceivedInvoice factura electrónica de un proveedor; ver domaindian.ReceivedInvoice.
+type ReceivedInvoice = domaindian.ReceivedInvoice
+
+// ReceivedInvoiceReader lee y verifica las facturas electrónicas que los proveedores envían por correo.
+type ReceivedInvoiceReader struct {
+	verifier *DocumentVerifierService
+}
+
+// NewReceivedInvoiceReader construye el lector de facturas recibidas. roots son las CA de confianza
+// para la cadena de los certificados de firma; nil usa las del sistema.
+func NewReceivedInvoiceReader(roots *x509.CertPool) *ReceivedInvoiceReader {
+	return &ReceivedInvoiceReader{verifier: NewDocumentVerifierService(roots)}
+}
+
+// ParseReceivedInvoice lee el adjunto recibido; ver la función ParseReceivedInvoice.
+func (r *ReceivedInvoiceReader) ParseReceivedInvoice(fileName string, data []byte) (*ReceivedInvoice, error) {
+	return ParseReceivedInvoice(fileName, data)
+}
+
+// VerifySignature verifica la firma XAdES del documento, la cadena y la vigencia del certificado en la
+// hora de firma, y devuelve el NIT de su titular; ver DocumentVerifierService.VerifySigner.
+func (r *ReceivedInvoiceReader) VerifySignature(xmlBytes []byte) (string, error) {
+	return r.verifier.VerifySigner(xmlBytes)
+}
+
+// ParseReceivedInvoice lee un adjunto recibido por correo: ZIP con el AttachedDocument (o el Invoice) en
+// XML, o el XML directamente. ErrNotElectronicInvoice si no encuentra una factura electrónica.
+func ParseReceivedInvoice(fileName string, data []byte) (*ReceivedInvoice, error) {
+	if strings.HasSuffix(strings.ToLower(fileName), ".zip") || bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("dian: abrir ZIP %s: %w", fileName, err)
+		}
+		for _, f := range zr.File {
+			if !strings.HasSuffix(strings.ToLower(f.Name), ".xml") {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("dian: abrir %s del ZIP: %w", f.Name, err)
+			}
+			content, err := io.ReadAll(io.LimitReader(rc, 20<<20))
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("dian: leer %s del ZIP: %w", f.Name, err)
+			}
+			inv, err := parseReceivedXML(content)
+			if errors.Is(err, ErrNotElectronicInvoice) {
+				continue
+			}
+			return inv, err
+		}
+		return nil, ErrNotElectronicInvoice
+	}
+	return parseReceivedXML(data)
+}
+
+func parseReceivedXML(data []byte) (*ReceivedInvoice, error) {
+	switch rootElementName(data) {
+	case "AttachedDocument":
+		return parseAttachedDocument(data)
+	case "Invoice":
+		return parseInvoice(data)
+	}
+	return nil, ErrNotElectronicInvoice
+}
+
+func rootElementName(data []byte) string {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local
+		}
+	}
+}
+
+type attachedDocumentXML struct {
+	Attachment struct {
+		Description string `xml:"ExternalReference>Description"`
+	} `xml:"Attachment"`
+	ParentLines []struct {
+		DocumentReference struct {
+			UUID       string `xml:"UUID"`
+			Attachment struct {
+				Description string `xml:"ExternalReference>Description"`
+			} `xml:"Attachment"`
+			ValidationResultCode string `xml:"ResultOfVerification>ValidationResultCode"`
+		} `xml:"DocumentReference"`
+	} `xml:"ParentDocumentLineReference"`
+}
+
+// parseAttachedDocument extrae el Invoice del cac:Attachment y la validación DIAN del
+// cac:ParentDocumentLineReference (ApplicationResponse embebido o cac:ResultOfVerification).
+func parseAttachedDocument(data []byte) (*ReceivedInvoice, error) {
+	var ad attachedDocumentXML
+	if err := xml.Unmarshal(data, &ad); err != nil {
+		return nil, fmt.Errorf("dian: parsear AttachedDocument: %w", err)
+	}
+	embedded := []byte(strings.TrimSpace(ad.Attachment.Description))
+	if rootElementName(embedded) != "Invoice" {
+		return nil, ErrNotElectronicInvoice
+	}
+	inv, err := parseInvoice(embedded)
+	if err != nil {
+		return nil, err
+	}
+	inv.ContainerXML = data
+	if len(ad.ParentLines) > 0 {
+		ref := ad.ParentLines[0].DocumentReference
+		inv.ParentCUFE = strings.ToLower(strings.TrimSpace(ref.UUID))
+		inv.DIANResponseCode = strings.TrimSpace(ref.ValidationResultCode)
+		if ar := strings.TrimSpace(ref.Attachment.Description); ar != "" {
+			if code, _, err := domaindian.ParseApplicationResponse([]byte(ar)); err == nil && code != "" {
+				inv.DIANResponseCode = code
+				inv.ApplicationResponseXML = []byte(ar)
+				inv.ResponseCUFE = applicationResponseCUFE([]byte(ar))
+			}
+		}
+	}
+	return inv, nil
+}
+
+// applicationResponseCUFE CUFE del documento que valida el ApplicationResponse
+// (cac:DocumentResponse/cac:DocumentReference/cbc:UUID).
+func applicationResponseCUFE(data []byte) string {
+	var ar struct {
+		UUID string `xml:"DocumentResponse>DocumentReference>UUID"`
+	}
+	if err := xml.Unmarshal(data, &ar); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(ar.UUID))
+}
+
+type amountXML struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"currencyID,attr"`
+}
+
+type partyXML struct {
+	PartyName []struct {
+		Name string `xml:"Name"`
+	} `xml:"PartyName"`
+	TaxScheme []struct {
+		RegistrationName string `xml:"RegistrationName"`
+		CompanyID        string `xml:"CompanyID"`
+	} `xml:"PartyTaxScheme"`
+	LegalEntity []struct {
+		RegistrationName string `xml:"RegistrationName"`
+		CompanyID        string `xml:"CompanyID"`
+	} `xml:"PartyLegalEntity"`
+}
+
+func (p partyXML) nit() string {
+	for _, t := range p.TaxScheme {
+		if v := strings.TrimSpace(t.CompanyID); v != "" {
+			return v
+		}
+	}
+	for _, l := range p.LegalEntity {
+		if v := strings.TrimSpace(l.CompanyID); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (p partyXML) name() string {
+	for _, t := range p.TaxScheme {
+		if v := strings.TrimSpace(t.RegistrationName); v != "" {
+			return v
+		}
+	}
+	for _, l := range p.LegalEntity {
+		if v := strings.TrimSpace(l.RegistrationName); v != "" {
+			return v
+		}
+	}
+	for _, n := range p.PartyName {
+		if v := strings.TrimSpace(n.Name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+type taxTotalXML struct {
+	TaxAmount   amountXML `xml:"TaxAmount"`
+	TaxSubtotal []struct {
+		TaxableAmount amountXML `xml:"TaxableAmount"`
+		TaxAmount     amountXML `xml:"TaxAmount"`
+		TaxCategory   struct {
+			Percent   string `xml:"Percent"`
+			TaxScheme struct {
+				ID   string `xml:"ID"`
+				Name string `xml:"Name"`
+			} `xml:"TaxScheme"`
+		} `xml:"TaxCategory"`
+	} `xml:"TaxSubtotal"`
+}
+
+type invoiceXML struct {
+	ID   string `xml:"ID"`
+	UUID struct {
+		Value      string `xml:",chardata"`
+		SchemeName string `xml:"schemeName,attr"`
+	} `xml:"UUID"`
+	IssueDate       string `xml:"IssueDate"`
+	IssueTime       string `xml:"IssueTime"`
+	InvoiceTypeCode string `xml:"InvoiceTypeCode"`
+	Currency        string `xml:"DocumentCurrencyCode"`
+	OrderReference  struct {
+		ID string `xml:"ID"`
+	} `xml:"OrderReference"`
+	Supplier  partyXML      `xml:"AccountingSupplierParty>Party"`
+	Customer  partyXML      `xml:"AccountingCustomerParty>Party"`
+	TaxTotals []taxTotalXML `xml:"TaxTotal"`
+	Monetary  struct {
+		LineExtension amountXML `xml:"LineExtensionAmount"`
+		TaxExclusive  amountXML `xml:"TaxExclusiveAmount"`
+		TaxInclusive  amountXML `xml:"TaxInclusiveAmount"`
+		Payable       amountXML `xml:"PayableAmount"`
+	} `xml:"LegalMonetaryTotal"`
+	Lines []struct {
+		ID       string `xml:"ID"`
+		Quantity struct {
+			Value    string `xml:",chardata"`
+			UnitCode string `xml:"unitCode,attr"`
+		} `xml:"InvoicedQuantity"`
+		LineExtension amountXML     `xml:"LineExtensionAmount"`
+		TaxTotals     []taxTotalXML `xml:"TaxTotal"`
+		Item          struct {
+			Description []string `xml:"Description"`
+			SellersID   string   `xml:"SellersItemIdentification>ID"`
+			StandardID  string   `xml:"StandardItemIdentification>ID"`
+		} `xml:"Item"`
+		Price amountXML `xml:"Price>PriceAmount"`
+	} `xml:"InvoiceLine"`
+}
+
+// parseInvoice lee del Invoice UBL 2.1 los datos que necesita el registro de la factura recibida.
+func parseInvoice(data []byte) (*ReceivedInvoice, error) {
+	var x invoiceXML
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return nil, fmt.Errorf("dian: parsear Invoice: %w", err)
+	}
+	number := strings.TrimSpace(x.ID)
+	issuerNIT := x.Supplier.nit()
+	if number == "" || issuerNIT == "" || strings.TrimSpace(x.UUID.Value) == "" {
+		return nil, fmt.Errorf("dian: el Invoice no trae número, NIT del emisor o CUFE")
+	}
+	issueDate, err := parseIssueDateTime(x.IssueDate, x.IssueTime)
+	if err != nil {
+		return nil, err
+	}
+	typeCode := strings.TrimSpace(x.InvoiceTypeCode)
+	if typeCode == "" {
+		typeCode = "01"
+	}
+	currency := strings.TrimSpace(x.Currency)
+	if currency == "" {
+		currency = "COP"
+	}
+
+	doc := &entity.ReceivedDocument{
+		IssuerNIT:        issuerNIT,
+		IssuerName:       x.Supplier.name(),
+		CustomerNIT:      x.Customer.nit(),
+		Number:           number,
+		DocumentTypeCode: typeCode,
+		CUFE:             strings.ToLower(strings.TrimSpace(x.UUID.Value)),
+		IssueDate:        issueDate,
+		Currency:         currency,
+		NetTotal:         parseAmount(x.Monetary.LineExtension.Value),
+		GrandTotal:       parseAmount(x.Monetary.Payable.Value),
+	}
+	for _, tt := range x.TaxTotals {
+		doc.TaxTotal = doc.TaxTotal.Add(parseAmount(tt.TaxAmount.Value))
+		for _, st := range tt.TaxSubtotal {
+			doc.Taxes = append(doc.Taxes, entity.ReceivedDocumentTax{
+				TaxCode:       strings.TrimSpace(st.TaxCategory.TaxScheme.ID),
+				TaxName:       strings.TrimSpace(st.TaxCategory.TaxScheme.Name),
+				Percent:       parseAmount(st.TaxCategory.Percent),
+				TaxableAmount: parseAmount(st.TaxableAmount.Value),
+				TaxAmount:     parseAmount(st.TaxAmount.Value),
+			})
+		}
+	}
+	if doc.GrandTotal.IsZero() {
+		doc.GrandTotal = parseAmount(x.Monetary.TaxInclusive.Value)
+	}
+	for i, l := range x.Lines {
+		line := entity.ReceivedDocumentLine{
+			LineNumber:    i + 1,
+			ItemCode:      strings.TrimSpace(l.Item.StandardID),
+			Description:   strings.TrimSpace(strings.Join(l.Item.Description, " ")),
+			Quantity:      parseAmount(l.Quantity.Value),
+			UnitCode:      strings.TrimSpace(l.Quantity.UnitCode),
+			UnitPrice:     parseAmount(l.Price.Value),
+			LineExtension: parseAmount(l.LineExtension.Value),
+		}
+		if line.ItemCode == "" {
+			line.ItemCode = strings.TrimSpace(l.Item.SellersID)
+		}
+		for _, tt := range l.TaxTotals {
+			line.TaxAmount = line.TaxAmount.Add(parseAmount(tt.TaxAmount.Value))
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+
+	return &ReceivedInvoice{
+		Document:       doc,
+		InvoiceXML:     data,
+		UUIDScheme:     strings.TrimSpace(x.UUID.SchemeName),
+		OrderReference: strings.TrimSpace(x.OrderReference.ID),
+	}, nil
+}
+
+// parseIssueDateTime combina cbc:IssueDate y cbc:IssueTime (HH:MM:SS-05:00); sin hora válida, la fecha
+// queda a medianoche en la hora legal colombiana.
+func parseIssueDateTime(date, clock string) (time.Time, error) {
+	date, clock = strings.TrimSpace(date), strings.TrimSpace(clock)
+	if t, err := time.Parse("2006-01-02T15:04:05Z07:00", date+"T"+clock); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", date, time.FixedZone("COT", -5*3600))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("dian: cbc:IssueDate inválida: %q", date)
+	}
+	return t, nil
+}
+
+func parseAmount(s string) decimal.Decimal {
+	d, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
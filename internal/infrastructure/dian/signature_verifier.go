@@ -0,0 +1,269 @@
+// Verificación de firmas XMLDSig/XAdES de documentos electrónicos: facturas de proveedores y documentos
+// propios firmados (auditoría).
+
+package dian
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha512" // SHA-384 y SHA-512 para digest y firma
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+
+	"github.com/jhoicas/Inventario-api/internal/infrastructure/dian/signer"
+)
+
+// Algoritmos adicionales de XMLDSig aceptados en documentos de terceros. La canonicalización es solo
+// C14N inclusivo sin comentarios, la única que implementa signer.CanonicalizeElement.
+const (
+	AlgRSASHA384    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha384"
+	AlgRSASHA512    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	AlgXMLEncSHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+	AlgSHA384       = "http://www.w3.org/2001/04/xmldsig-more#sha384"
+	AlgXMLEncSHA512 = "http://www.w3.org/2001/04/xmlenc#sha512"
+)
+
+// ErrSignatureNotFound el documento no tiene ds:Signature.
+var ErrSignatureNotFound = errors.New("dian: el documento no tiene ds:Signature")
+
+var digestAlgorithms = map[string]crypto.Hash{
+	AlgSHA256:       crypto.SHA256,
+	AlgXMLEncSHA256: crypto.SHA256,
+	AlgSHA384:       crypto.SHA384,
+	AlgXMLEncSHA512: crypto.SHA512,
+}
+
+var signatureAlgorithms = map[string]crypto.Hash{
+	AlgRSASHA256: crypto.SHA256,
+	AlgRSASHA384: crypto.SHA384,
+	AlgRSASHA512: crypto.SHA512,
+}
+
+// XMLSignatureInfo datos de una firma verificada.
+type XMLSignatureInfo struct {
+	Certificate *x509.Certificate // ds:X509Certificate con que se verificó la firma
+	SigningTime time.Time         // xades:SigningTime; cero si no viene
+	References  int               // ds:Reference verificadas
+}
+
+// VerifyXMLSignature verifica la firma enveloped del documento: el ds:SignatureValue sobre ds:SignedInfo
+// canonicalizado (C14N inclusivo) con la llave pública del ds:X509Certificate y el digest de cada
+// ds:Reference. ds:SignedInfo debe tener exactamente dos: el documento completo (URI vacía o el Id de la
+// raíz) con la transformada enveloped-signature y las xades:SignedProperties de la firma; cualquier otra
+// se rechaza, así una firma sobre un fragmento no pasa por firma del documento. No valida la cadena ni
+// la vigencia del certificado.
+func VerifyXMLSignature(xmlBytes []byte) (*XMLSignatureInfo, error) {
+	doc, err := signer.ParseDocument(xmlBytes)
+	if err != nil {
+		return nil, fmt.Errorf("dian: parsear XML firmado: %w", err)
+	}
+	root := doc.Root()
+	if root == nil {
+		return nil, fmt.Errorf("dian: documento sin raíz")
+	}
+	sig := findElement(root, func(e *etree.Element) bool {
+		return e.Tag == "Signature" && e.NamespaceURI() == NamespaceDS
+	})
+	if sig == nil {
+		return nil, ErrSignatureNotFound
+	}
+	signedInfo := childElement(sig, "SignedInfo")
+	if signedInfo == nil {
+		return nil, fmt.Errorf("dian: ds:Signature sin ds:SignedInfo")
+	}
+	if alg := algorithmOf(childElement(signedInfo, "CanonicalizationMethod")); alg != AlgC14N {
+		return nil, fmt.Errorf("dian: canonicalización no soportada: %q", alg)
+	}
+	sigAlg := algorithmOf(childElement(signedInfo, "SignatureMethod"))
+	sigHash, ok := signatureAlgorithms[sigAlg]
+	if !ok {
+		return nil, fmt.Errorf("dian: algoritmo de firma no soportado: %q", sigAlg)
+	}
+
+	cert, err := signatureCertificate(sig)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("dian: el certificado del firmante no tiene llave RSA")
+	}
+	sigValue, err := decodeBase64Text(childElement(sig, "SignatureValue"))
+	if err != nil {
+		return nil, fmt.Errorf("dian: ds:SignatureValue: %w", err)
+	}
+	h := sigHash.New()
+	h.Write(signer.CanonicalizeElement(signedInfo, nil))
+	if err := rsa.VerifyPKCS1v15(pub, sigHash, h.Sum(nil), sigValue); err != nil {
+		return nil, fmt.Errorf("dian: el valor de la firma no corresponde a ds:SignedInfo: %w", err)
+	}
+
+	info := &XMLSignatureInfo{Certificate: cert}
+	documentRefs, propsRefs := 0, 0
+	for _, ref := range signedInfo.ChildElements() {
+		if ref.Tag != "Reference" {
+			continue
+		}
+		target, err := verifyReference(root, sig, ref)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case target == root:
+			documentRefs++
+		case target.Tag == "SignedProperties" && target.NamespaceURI() == NamespaceXAdES && isWithin(target, sig):
+			propsRefs++
+		default:
+			return nil, fmt.Errorf("dian: ds:Reference %q no cubre el documento ni xades:SignedProperties", ref.SelectAttrValue("URI", ""))
+		}
+		info.References++
+	}
+	if documentRefs != 1 {
+		return nil, fmt.Errorf("dian: ds:SignedInfo debe tener exactamente una ds:Reference al documento completo (tiene %d)", documentRefs)
+	}
+	if propsRefs != 1 {
+		return nil, fmt.Errorf("dian: ds:SignedInfo debe tener exactamente una ds:Reference a xades:SignedProperties (tiene %d)", propsRefs)
+	}
+	if st := findElement(sig, func(e *etree.Element) bool { return e.Tag == "SigningTime" }); st != nil {
+		if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(st.Text())); err == nil {
+			info.SigningTime = t
+		}
+	}
+	return info, nil
+}
+
+// verifyReference recalcula el digest del elemento referenciado (URI vacía = documento completo), lo
+// compara con ds:DigestValue y devuelve el elemento. La referencia al documento debe excluir la firma
+// con la transformada enveloped-signature.
+func verifyReference(root, sig, ref *etree.Element) (*etree.Element, error) {
+	uri := ref.SelectAttrValue("URI", "")
+	target := root
+	if uri != "" {
+		if !strings.HasPrefix(uri, "#") {
+			return nil, fmt.Errorf("dian: ds:Reference externa no soportada: %q", uri)
+		}
+		id := uri[1:]
+		target = findElement(root, func(e *etree.Element) bool {
+			for _, key := range []string{"Id", "ID", "id"} {
+				if e.SelectAttrValue(key, "") == id {
+					return true
+				}
+			}
+			return false
+		})
+		if target == nil {
+			return nil, fmt.Errorf("dian: ds:Reference %q no apunta a ningún elemento", uri)
+		}
+	}
+	var exclude *etree.Element
+	if transforms := childElement(ref, "Transforms"); transforms != nil {
+		for _, tr := range transforms.ChildElements() {
+			switch alg := algorithmOf(tr); alg {
+			case TransformEnveloped:
+				exclude = sig
+			case AlgC14N:
+			default:
+				return nil, fmt.Errorf("dian: transformada no soportada en %q: %q", uri, alg)
+			}
+		}
+	}
+	if target == root && exclude == nil {
+		return nil, fmt.Errorf("dian: la ds:Reference al documento no usa la transformada enveloped-signature")
+	}
+	digestAlg := algorithmOf(childElement(ref, "DigestMethod"))
+	hash, ok := digestAlgorithms[digestAlg]
+	if !ok {
+		return nil, fmt.Errorf("dian: algoritmo de digest no soportado en %q: %q", uri, digestAlg)
+	}
+	expected, err := decodeBase64Text(childElement(ref, "DigestValue"))
+	if err != nil {
+		return nil, fmt.Errorf("dian: ds:DigestValue de %q: %w", uri, err)
+	}
+	h := hash.New()
+	h.Write(signer.CanonicalizeElement(target, exclude))
+	if !bytes.Equal(h.Sum(nil), expected) {
+		return nil, fmt.Errorf("dian: el digest de %q no coincide: el contenido firmado fue modificado", referenceLabel(uri))
+	}
+	return target, nil
+}
+
+func referenceLabel(uri string) string {
+	if uri == "" {
+		return "documento"
+	}
+	return uri
+}
+
+// signatureCertificate devuelve el primer ds:X509Certificate de ds:KeyInfo.
+func signatureCertificate(sig *etree.Element) (*x509.Certificate, error) {
+	keyInfo := childElement(sig, "KeyInfo")
+	if keyInfo == nil {
+		return nil, fmt.Errorf("dian: ds:Signature sin ds:KeyInfo")
+	}
+	certEl := findElement(keyInfo, func(e *etree.Element) bool { return e.Tag == "X509Certificate" })
+	if certEl == nil {
+		return nil, fmt.Errorf("dian: ds:KeyInfo sin ds:X509Certificate")
+	}
+	der, err := decodeBase64Text(certEl)
+	if err != nil {
+		return nil, fmt.Errorf("dian: ds:X509Certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("dian: parsear certificado del firmante: %w", err)
+	}
+	return cert, nil
+}
+
+func decodeBase64Text(el *etree.Element) ([]byte, error) {
+	if el == nil {
+		return nil, fmt.Errorf("elemento ausente")
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(el.Text()), ""))
+}
+
+func algorithmOf(el *etree.Element) string {
+	if el == nil {
+		return ""
+	}
+	return el.SelectAttrValue("Algorithm", "")
+}
+
+func childElement(el *etree.Element, tag string) *etree.Element {
+	for _, c := range el.ChildElements() {
+		if c.Tag == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+// isWithin indica si el es ancestor o uno de sus descendientes.
+func isWithin(el, ancestor *etree.Element) bool {
+	for ; el != nil; el = el.Parent() {
+		if el == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// findElement recorre el árbol en profundidad y devuelve el primer elemento que cumple match.
+func findElement(el *etree.Element, match func(*etree.Element) bool) *etree.Element {
+	if match(el) {
+		return el
+	}
+	for _, c := range el.ChildElements() {
+		if found := findElement(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
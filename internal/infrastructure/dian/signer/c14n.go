@@ -0,0 +1,265 @@
+// Canonicalización XML (C14N 1.0 inclusivo) de un elemento en el contexto de su documento: la usan la
+// firma y la verificación para que los digest coincidan con los que calcula la DIAN.
+
+package signer
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+const namespaceXML = "http://www.w3.org/XML/1998/namespace"
+
+// ParseDocument lee el XML como lo haría un parser conforme antes de canonicalizar: encoding/xml no aplica
+// la normalización de valores de atributo de XML 1.0 (sección 3.3.3), así que los tabuladores y saltos de
+// línea literales de un valor pasan aquí a espacio; los que llegan como referencia (&#x9;, &#xA;, &#xD;)
+// se conservan. Las secciones CDATA (documentos embebidos del AttachedDocument) se mantienen al serializar;
+// la canonicalización las trata como texto.
+func ParseDocument(data []byte) (*etree.Document, error) {
+	doc := etree.NewDocument()
+	doc.ReadSettings.PreserveCData = true
+	if err := doc.ReadFromBytes(normalizeAttributeValues(data)); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// CanonicalizeElement serializa el elemento según Canonical XML 1.0 inclusivo sin comentarios: el
+// elemento raíz declara todos los namespaces en alcance y hereda los atributos xml:* de sus ancestros,
+// los atributos van ordenados y exclude (la firma, en la transformada enveloped) se omite. Los valores de
+// atributo deben venir normalizados (ver ParseDocument).
+func CanonicalizeElement(el, exclude *etree.Element) []byte {
+	scope := map[string]string{}
+	var ancestors []*etree.Element
+	for p := el.Parent(); p != nil; p = p.Parent() {
+		ancestors = append(ancestors, p)
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		declareNamespaces(ancestors[i], scope)
+	}
+	var buf bytes.Buffer
+	writeCanonical(&buf, el, scope, map[string]string{}, exclude, inheritedXMLAttrs(el, ancestors))
+	return buf.Bytes()
+}
+
+// inheritedXMLAttrs atributos xml:* (xml:lang, xml:space…) de los ancestros que el elemento no declara; el
+// más cercano prevalece.
+func inheritedXMLAttrs(el *etree.Element, ancestors []*etree.Element) []etree.Attr {
+	seen := map[string]bool{}
+	for _, a := range el.Attr {
+		if a.Space == "xml" {
+			seen[a.Key] = true
+		}
+	}
+	var inherited []etree.Attr
+	for _, p := range ancestors {
+		for _, a := range p.Attr {
+			if a.Space == "xml" && !seen[a.Key] {
+				seen[a.Key] = true
+				inherited = append(inherited, a)
+			}
+		}
+	}
+	return inherited
+}
+
+func declareNamespaces(el *etree.Element, scope map[string]string) {
+	for _, a := range el.Attr {
+		switch {
+		case a.Space == "xmlns":
+			scope[a.Key] = a.Value
+		case a.Space == "" && a.Key == "xmlns":
+			scope[""] = a.Value
+		}
+	}
+}
+
+func writeCanonical(buf *bytes.Buffer, el *etree.Element, parentScope, rendered map[string]string, exclude *etree.Element, inherited []etree.Attr) {
+	scope := make(map[string]string, len(parentScope))
+	for k, v := range parentScope {
+		scope[k] = v
+	}
+	declareNamespaces(el, scope)
+
+	var prefixes []string
+	out := make(map[string]string, len(rendered))
+	for k, v := range rendered {
+		out[k] = v
+	}
+	for prefix, uri := range scope {
+		prev, seen := rendered[prefix]
+		if prefix == "" && uri == "" {
+			if seen && prev != "" {
+				prefixes = append(prefixes, prefix) // xmlns="" solo si el ancestro declaró otro default
+				out[prefix] = uri
+			}
+			continue
+		}
+		if !seen || prev != uri {
+			prefixes = append(prefixes, prefix)
+			out[prefix] = uri
+		}
+	}
+	sort.Strings(prefixes)
+
+	type attr struct{ uri, local, qname, value string }
+	var attrs []attr
+	for _, a := range append(el.Attr[:len(el.Attr):len(el.Attr)], inherited...) {
+		if a.Space == "xmlns" || (a.Space == "" && a.Key == "xmlns") {
+			continue
+		}
+		at := attr{local: a.Key, qname: a.Key, value: a.Value}
+		if a.Space != "" {
+			at.qname = a.Space + ":" + a.Key
+			at.uri = scope[a.Space]
+			if a.Space == "xml" {
+				at.uri = namespaceXML
+			}
+		}
+		attrs = append(attrs, at)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].uri != attrs[j].uri {
+			return attrs[i].uri < attrs[j].uri
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	qname := el.Tag
+	if el.Space != "" {
+		qname = el.Space + ":" + el.Tag
+	}
+	buf.WriteString("<" + qname)
+	for _, p := range prefixes {
+		if p == "" {
+			buf.WriteString(` xmlns="` + escapeC14NAttr(scope[p]) + `"`)
+		} else {
+			buf.WriteString(` xmlns:` + p + `="` + escapeC14NAttr(scope[p]) + `"`)
+		}
+	}
+	for _, a := range attrs {
+		buf.WriteString(" " + a.qname + `="` + escapeC14NAttr(a.value) + `"`)
+	}
+	buf.WriteString(">")
+	for _, tok := range el.Child {
+		switch t := tok.(type) {
+		case *etree.Element:
+			if t != exclude {
+				writeCanonical(buf, t, scope, out, exclude, nil)
+			}
+		case *etree.CharData:
+			buf.WriteString(escapeC14NText(t.Data))
+		case *etree.ProcInst:
+			buf.WriteString("<?" + t.Target)
+			if t.Inst != "" {
+				buf.WriteString(" " + t.Inst)
+			}
+			buf.WriteString("?>")
+		}
+	}
+	buf.WriteString("</" + qname + ">")
+}
+
+var (
+	c14nTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	c14nAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeC14NText(s string) string { return c14nTextEscaper.Replace(s) }
+func escapeC14NAttr(s string) string { return c14nAttrEscaper.Replace(s) }
+
+// normalizeAttributeValues reemplaza por espacio los tabuladores y saltos de línea literales dentro de los
+// valores de atributo (CRLF cuenta como uno solo). Comentarios, CDATA, instrucciones de proceso y DOCTYPE
+// se copian sin cambios.
+func normalizeAttributeValues(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		if data[i] != '<' {
+			out = append(out, data[i])
+			i++
+			continue
+		}
+		rest := data[i:]
+		var end int
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			end = skipPast(rest, "-->")
+		case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+			end = skipPast(rest, "]]>")
+		case bytes.HasPrefix(rest, []byte("<?")):
+			end = skipPast(rest, "?>")
+		case bytes.HasPrefix(rest, []byte("<!")):
+			end = skipDeclaration(rest)
+		default:
+			n := copyTag(&out, rest)
+			i += n
+			continue
+		}
+		out = append(out, rest[:end]...)
+		i += end
+	}
+	return out
+}
+
+// skipPast devuelve la longitud hasta el final de marker inclusive (o de todo data si no aparece).
+func skipPast(data []byte, marker string) int {
+	if idx := bytes.Index(data, []byte(marker)); idx >= 0 {
+		return idx + len(marker)
+	}
+	return len(data)
+}
+
+// skipDeclaration devuelve la longitud de una declaración <!…> (DOCTYPE con su subconjunto interno).
+func skipDeclaration(data []byte) int {
+	depth := 0
+	var quote byte
+	for i := 2; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '>' && depth <= 0:
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// copyTag copia una etiqueta normalizando los espacios de sus valores de atributo; devuelve su longitud.
+func copyTag(out *[]byte, data []byte) int {
+	var quote byte
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case quote == 0:
+			*out = append(*out, c)
+			if c == '"' || c == '\'' {
+				quote = c
+			} else if c == '>' {
+				return i + 1
+			}
+		case c == quote:
+			*out = append(*out, c)
+			quote = 0
+		case c == '\r' && i+1 < len(data) && data[i+1] == '\n':
+			*out = append(*out, ' ')
+			i++
+		case c == '\t' || c == '\n' || c == '\r':
+			*out = append(*out, ' ')
+		default:
+			*out = append(*out, c)
+		}
+	}
+	return len(data)
+}
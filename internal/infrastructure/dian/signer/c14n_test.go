@@ -0,0 +1,114 @@
+package signer_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/infrastructure/dian/signer"
+)
+
+// testdata/invoice_signed.xml sigue la estructura de la factura de ejemplo del Anexo Técnico (extensiones
+// DIAN, namespaces declarados en la raíz, firma enveloped en el segundo ExtensionContent). Los .c14n y el
+// DigestValue de la firma se generaron con libxml2 (xmllint --c14n), independiente de esta implementación.
+func readSample(t *testing.T) (*etree.Document, []byte) {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/invoice_signed.xml")
+	require.NoError(t, err)
+	doc := etree.NewDocument()
+	doc.ReadSettings.PreserveCData = true
+	require.NoError(t, doc.ReadFromBytes(raw))
+	return doc, raw
+}
+
+func readGolden(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestCanonicalizeElement_MuestraDIAN(t *testing.T) {
+	doc, _ := readSample(t)
+	root := doc.Root()
+	sig := root.FindElement(".//Signature")
+	require.NotNil(t, sig)
+
+	t.Run("documento completo igual a libxml2", func(t *testing.T) {
+		assert.Equal(t, readGolden(t, "invoice_signed.c14n"), string(signer.CanonicalizeElement(root, nil)))
+	})
+
+	t.Run("transformada enveloped reproduce el DigestValue", func(t *testing.T) {
+		digest := sha256.Sum256(signer.CanonicalizeElement(root, sig))
+		want := sig.FindElement("./SignedInfo/Reference/DigestValue").Text()
+		assert.Equal(t, want, base64.StdEncoding.EncodeToString(digest[:]))
+	})
+
+	t.Run("SignedInfo hereda los namespaces del documento", func(t *testing.T) {
+		assert.Equal(t, readGolden(t, "signedinfo.c14n"), string(signer.CanonicalizeElement(sig.SelectElement("SignedInfo"), nil)))
+	})
+
+	t.Run("serializar y volver a leer no altera la forma canónica", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := doc.WriteTo(&out)
+		require.NoError(t, err)
+		again := etree.NewDocument()
+		require.NoError(t, again.ReadFromBytes(out.Bytes()))
+		assert.Equal(t, readGolden(t, "invoice_signed.c14n"), string(signer.CanonicalizeElement(again.Root(), nil)))
+	})
+}
+
+// Casos de las secciones 3.3 y 3.4 de la recomendación W3C Canonical XML 1.0 (sin la DTD, que
+// encoding/xml no procesa); los .c14n se generaron con xmllint --c14n.
+func TestCanonicalizeElement_W3C(t *testing.T) {
+	for _, name := range []string{"w3c_start_end_tags", "w3c_character_modifications"} {
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile("testdata/" + name + ".xml")
+			require.NoError(t, err)
+			doc, err := signer.ParseDocument(raw)
+			require.NoError(t, err)
+			assert.Equal(t, readGolden(t, name+".c14n"), string(signer.CanonicalizeElement(doc.Root(), nil)))
+		})
+	}
+}
+
+func TestCanonicalizeElement_Subconjunto(t *testing.T) {
+	doc, err := signer.ParseDocument([]byte(`<doc xmlns="http://www.ietf.org" xmlns:w3c="http://www.w3.org" xml:lang="es-CO">
+  <e1>
+    <e2 xmlns="" xml:space="preserve">
+      <e3 id="E3" w3c:attr="x"/>
+    </e2>
+  </e1>
+</doc>`))
+	require.NoError(t, err)
+	e1 := doc.Root().SelectElement("e1")
+	e3 := doc.FindElement("//e3")
+	require.NotNil(t, e3)
+
+	t.Run("el ápice declara los namespaces en alcance y hereda xml:*", func(t *testing.T) {
+		want := `<e1 xmlns="http://www.ietf.org" xmlns:w3c="http://www.w3.org" xml:lang="es-CO">
+    <e2 xmlns="" xml:space="preserve">
+      <e3 id="E3" w3c:attr="x"></e3>
+    </e2>
+  </e1>`
+		assert.Equal(t, want, string(signer.CanonicalizeElement(e1, nil)))
+	})
+
+	t.Run("atributos ordenados por namespace y nombre local", func(t *testing.T) {
+		want := `<e3 xmlns:w3c="http://www.w3.org" id="E3" w3c:attr="x" xml:lang="es-CO" xml:space="preserve"></e3>`
+		assert.Equal(t, want, string(signer.CanonicalizeElement(e3, nil)))
+	})
+}
+
+func TestParseDocument_NormalizaValoresDeAtributo(t *testing.T) {
+	doc, err := signer.ParseDocument([]byte("<!DOCTYPE e [<!ENTITY x \"a\tb\">]><e a=\"1\t2\r\n3\n4\" b='&#x9;&#xA;&#xD;'><!-- \t --><![CDATA[\t]]></e>"))
+	require.NoError(t, err)
+	assert.Equal(t, "1 2 3 4", doc.Root().SelectAttrValue("a", ""))
+	assert.Equal(t, `<e a="1 2 3 4" b="&#x9;&#xA;&#xD;">`+"\t</e>", string(signer.CanonicalizeElement(doc.Root(), nil)))
+}
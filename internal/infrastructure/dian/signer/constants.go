@@ -21,5 +21,12 @@ const (
 	TransformEnveloped = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
 )
 
-// ID del elemento raíz al que apunta la Reference (debe coincidir con el Id del <Invoice>).
-const InvoiceElementID = "invoice-id"
+// Ids de la firma XAdES: la Reference del documento apunta al Id de la raíz (invoice-id, creditnote-id…)
+// y la segunda a xades:SignedProperties.
+const (
+	SignatureID                 = "xmldsig-signature"
+	SignatureReferenceID        = "xmldsig-ref0"
+	SignedPropertiesReferenceID = "xmldsig-ref-props"
+	SignedPropertiesID          = "signed-props"
+	TypeSignedProperties        = "http://uri.etsi.org/01903#SignedProperties"
+)
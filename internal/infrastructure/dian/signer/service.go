@@ -11,14 +11,12 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
-	"encoding/xml"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/beevik/etree"
 	"github.com/jhoicas/Inventario-api/pkg/dian"
-	"github.com/ucarion/c14n"
 )
 
 // DigitalSignatureService implementa la firma XAdES-EPES e inyecta el nodo en el XML.
@@ -30,6 +28,8 @@ func NewDigitalSignatureService() *DigitalSignatureService {
 }
 
 // Sign implementa pkg/dian.Signer. Firma el XML e inyecta ds:Signature en el segundo ExtensionContent.
+// Los digest y el ds:SignatureValue se calculan con la firma ya ubicada en el documento (C14N inclusivo en
+// contexto), como los recalcula la DIAN al validar.
 func (s *DigitalSignatureService) Sign(xmlBytes []byte, cert tls.Certificate) ([]byte, error) {
 	if len(xmlBytes) == 0 {
 		return nil, fmt.Errorf("dian: XML vacío")
@@ -43,70 +43,90 @@ func (s *DigitalSignatureService) Sign(xmlBytes []byte, cert tls.Certificate) ([
 		return nil, fmt.Errorf("dian: parsear certificado: %w", err)
 	}
 
-	// 1) Digest del documento (C14N). Reference URI="#invoice-id"
-	canonicalDoc, err := canonicalizeXML(xmlBytes)
+	doc, slot, err := s.signatureSlot(xmlBytes)
 	if err != nil {
-		canonicalDoc = xmlBytes
+		return nil, err
 	}
-	docDigest := sha256.Sum256(canonicalDoc)
-	docDigestB64 := base64.StdEncoding.EncodeToString(docDigest[:])
+	root := doc.Root()
 
-	// 2) SignedInfo (C14N, Reference #invoice-id, Digest SHA-256)
-	signedInfoXML := s.buildSignedInfo(docDigestB64)
-	canonicalSignedInfo, err := canonicalizeXML([]byte(signedInfoXML))
-	if err != nil {
-		canonicalSignedInfo = []byte(signedInfoXML)
+	// 1) Nodo ds:Signature con digest y valor vacíos. La Reference del documento apunta al Id de la raíz
+	// (invoice-id, creditnote-id…); sin Id, URI vacía = documento completo.
+	docURI := ""
+	if id := root.SelectAttrValue("Id", ""); id != "" {
+		docURI = "#" + id
+	}
+	certB64 := base64.StdEncoding.EncodeToString(x509Cert.Raw)
+	signingTime := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	certDigestB64, issuerName, serialHex := CertDigestAndIssuerSerial(x509Cert)
+	signatureXML := s.buildFullSignature(s.buildSignedInfo(docURI), certB64, signingTime, certDigestB64, issuerName, serialHex)
+	sigDoc := etree.NewDocument()
+	if err := sigDoc.ReadFromString(signatureXML); err != nil {
+		return nil, fmt.Errorf("dian: parsear Signature: %w", err)
 	}
-	signHash := sha256.Sum256(canonicalSignedInfo)
+	sig := sigDoc.Root()
+	slot.AddChild(sig)
+
+	// 2) Digest del documento (transformada enveloped: sin la firma) y de xades:SignedProperties.
+	signedInfo := sig.SelectElement("SignedInfo")
+	docDigest := sha256.Sum256(CanonicalizeElement(root, sig))
+	setDigestValue(signedInfo, SignatureReferenceID, docDigest[:])
+	propsDigest := sha256.Sum256(CanonicalizeElement(sig.FindElement(".//SignedProperties"), nil))
+	setDigestValue(signedInfo, SignedPropertiesReferenceID, propsDigest[:])
+
+	// 3) SignatureValue: RSA-SHA256 sobre ds:SignedInfo canonicalizado.
+	signHash := sha256.Sum256(CanonicalizeElement(signedInfo, nil))
 	signatureValue, err := rsa.SignPKCS1v15(nil, priv, crypto.SHA256, signHash[:])
 	if err != nil {
 		return nil, fmt.Errorf("dian: firmar SignedInfo: %w", err)
 	}
-	signatureValueB64 := base64.StdEncoding.EncodeToString(signatureValue)
-
-	// 3) KeyInfo (X509Certificate)
-	certB64 := base64.StdEncoding.EncodeToString(x509Cert.Raw)
-
-	// 4) QualifyingProperties: SignedSignatureProperties (SigningTime, SigningCertificate), SignaturePolicyIdentifier
-	signingTime := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
-	certDigestB64, issuerName, serialHex := CertDigestAndIssuerSerial(x509Cert)
-	signatureXML := s.buildFullSignature(signedInfoXML, signatureValueB64, certB64, signingTime, certDigestB64, issuerName, serialHex)
+	sig.SelectElement("SignatureValue").SetText(base64.StdEncoding.EncodeToString(signatureValue))
 
-	// 5) Inyectar en segundo ext:ExtensionContent
-	return s.injectSignature(xmlBytes, signatureXML)
+	var out bytes.Buffer
+	if _, err := doc.WriteTo(&out); err != nil {
+		return nil, fmt.Errorf("dian: serializar XML firmado: %w", err)
+	}
+	return out.Bytes(), nil
 }
 
-func canonicalizeXML(data []byte) ([]byte, error) {
-	dec := xml.NewDecoder(bytes.NewReader(data))
-	dec.Entity = map[string]string{}
-	return c14n.Canonicalize(dec)
+// setDigestValue asigna el ds:DigestValue de la ds:Reference con el Id indicado.
+func setDigestValue(signedInfo *etree.Element, refID string, digest []byte) {
+	for _, ref := range signedInfo.SelectElements("Reference") {
+		if ref.SelectAttrValue("Id", "") == refID {
+			ref.SelectElement("DigestValue").SetText(base64.StdEncoding.EncodeToString(digest))
+		}
+	}
 }
 
-func (s *DigitalSignatureService) buildSignedInfo(docDigestB64 string) string {
-	uri := "#" + InvoiceElementID
+func (s *DigitalSignatureService) buildSignedInfo(docURI string) string {
 	var sb strings.Builder
-	sb.WriteString(`<ds:SignedInfo xmlns:ds="` + NamespaceDS + `">`)
+	sb.WriteString(`<ds:SignedInfo>`)
 	sb.WriteString(`<ds:CanonicalizationMethod Algorithm="` + AlgC14N + `"/>`)
 	sb.WriteString(`<ds:SignatureMethod Algorithm="` + AlgRSASHA256 + `"/>`)
-	sb.WriteString(`<ds:Reference URI="` + uri + `">`)
+	sb.WriteString(`<ds:Reference Id="` + SignatureReferenceID + `" URI="` + docURI + `">`)
 	sb.WriteString(`<ds:Transforms><ds:Transform Algorithm="` + TransformEnveloped + `"/>`)
 	sb.WriteString(`<ds:Transform Algorithm="` + AlgC14N + `"/></ds:Transforms>`)
 	sb.WriteString(`<ds:DigestMethod Algorithm="` + AlgSHA256 + `"/>`)
-	sb.WriteString(`<ds:DigestValue>` + docDigestB64 + `</ds:DigestValue>`)
+	sb.WriteString(`<ds:DigestValue></ds:DigestValue>`)
+	sb.WriteString(`</ds:Reference>`)
+	// XAdES: las propiedades firmadas (hora, certificado y política) quedan cubiertas por la firma.
+	sb.WriteString(`<ds:Reference Id="` + SignedPropertiesReferenceID + `" Type="` + TypeSignedProperties + `" URI="#` + SignedPropertiesID + `">`)
+	sb.WriteString(`<ds:Transforms><ds:Transform Algorithm="` + AlgC14N + `"/></ds:Transforms>`)
+	sb.WriteString(`<ds:DigestMethod Algorithm="` + AlgSHA256 + `"/>`)
+	sb.WriteString(`<ds:DigestValue></ds:DigestValue>`)
 	sb.WriteString(`</ds:Reference>`)
 	sb.WriteString(`</ds:SignedInfo>`)
 	return sb.String()
 }
 
-func (s *DigitalSignatureService) buildFullSignature(signedInfoXML, signatureValueB64, certB64, signingTime, certDigestB64, issuerName, serialHex string) string {
+func (s *DigitalSignatureService) buildFullSignature(signedInfoXML, certB64, signingTime, certDigestB64, issuerName, serialHex string) string {
 	var sb strings.Builder
-	sb.WriteString(`<ds:Signature xmlns:ds="` + NamespaceDS + `" xmlns:xades="` + NamespaceXAdES + `">`)
+	sb.WriteString(`<ds:Signature xmlns:ds="` + NamespaceDS + `" xmlns:xades="` + NamespaceXAdES + `" Id="` + SignatureID + `">`)
 	sb.WriteString(signedInfoXML)
-	sb.WriteString(`<ds:SignatureValue>` + signatureValueB64 + `</ds:SignatureValue>`)
+	sb.WriteString(`<ds:SignatureValue></ds:SignatureValue>`)
 	sb.WriteString(`<ds:KeyInfo><ds:X509Data><ds:X509Certificate>` + certB64 + `</ds:X509Certificate></ds:X509Data></ds:KeyInfo>`)
-	sb.WriteString(`<ds:Object><xades:QualifyingProperties>`)
+	sb.WriteString(`<ds:Object><xades:QualifyingProperties Target="#` + SignatureID + `">`)
 	// SignedSignatureProperties: SigningTime, SigningCertificate
-	sb.WriteString(`<xades:SignedProperties Id="signed-props">`)
+	sb.WriteString(`<xades:SignedProperties Id="` + SignedPropertiesID + `">`)
 	sb.WriteString(`<xades:SignedSignatureProperties>`)
 	sb.WriteString(`<xades:SigningTime>` + signingTime + `</xades:SigningTime>`)
 	sb.WriteString(`<xades:SigningCertificate><xades:Cert><xades:CertDigest><ds:DigestMethod Algorithm="` + AlgSHA256 + `"/>`)
@@ -131,14 +151,16 @@ func escapeXML(s string) string {
 	return s
 }
 
-func (s *DigitalSignatureService) injectSignature(xmlBytes []byte, signatureXML string) ([]byte, error) {
-	doc := etree.NewDocument()
-	if err := doc.ReadFromBytes(xmlBytes); err != nil {
-		return nil, fmt.Errorf("dian: parsear XML: %w", err)
+// signatureSlot parsea el XML y devuelve el ext:ExtensionContent que recibe la firma.
+func (s *DigitalSignatureService) signatureSlot(xmlBytes []byte) (*etree.Document, *etree.Element, error) {
+	// Mismo parseo que la verificación: los digest se calculan sobre los valores de atributo normalizados.
+	doc, err := ParseDocument(xmlBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dian: parsear XML: %w", err)
 	}
 	root := doc.Root()
 	if root == nil {
-		return nil, fmt.Errorf("dian: documento sin raíz")
+		return nil, nil, fmt.Errorf("dian: documento sin raíz")
 	}
 	var ublExt *etree.Element
 	for _, child := range root.ChildElements() {
@@ -152,10 +174,11 @@ func (s *DigitalSignatureService) injectSignature(xmlBytes []byte, signatureXML
 		}
 	}
 	if ublExt == nil {
-		return nil, fmt.Errorf("dian: no se encontró ext:UBLExtensions")
+		return nil, nil, fmt.Errorf("dian: no se encontró ext:UBLExtensions")
 	}
-	// Buscar el segundo ext:ExtensionContent (el builder deja el 2.º vacío para la firma)
-	var secondExtContent *etree.Element
+	// Buscar el segundo ext:ExtensionContent (el builder deja el 2.º vacío para la firma); documentos con
+	// una sola extensión (AttachedDocument) la dejan vacía para la firma.
+	var firstExtContent, secondExtContent *etree.Element
 	var count int
 	for _, ext := range ublExt.ChildElements() {
 		localTag := ext.Tag
@@ -172,6 +195,9 @@ func (s *DigitalSignatureService) injectSignature(xmlBytes []byte, signatureXML
 			}
 			if ecTag == "ExtensionContent" {
 				count++
+				if count == 1 {
+					firstExtContent = ec
+				}
 				if count == 2 {
 					secondExtContent = ec
 					break
@@ -182,19 +208,13 @@ func (s *DigitalSignatureService) injectSignature(xmlBytes []byte, signatureXML
 			break
 		}
 	}
-	if secondExtContent == nil {
-		return nil, fmt.Errorf("dian: no se encontró el segundo ext:ExtensionContent para inyectar la firma")
+	if secondExtContent == nil && count == 1 && len(firstExtContent.ChildElements()) == 0 {
+		secondExtContent = firstExtContent
 	}
-	sigDoc := etree.NewDocument()
-	if err := sigDoc.ReadFromString(signatureXML); err != nil {
-		return nil, fmt.Errorf("dian: parsear Signature: %w", err)
-	}
-	if sigRoot := sigDoc.Root(); sigRoot != nil {
-		secondExtContent.AddChild(sigRoot)
+	if secondExtContent == nil {
+		return nil, nil, fmt.Errorf("dian: no se encontró el segundo ext:ExtensionContent para inyectar la firma")
 	}
-	var out bytes.Buffer
-	doc.WriteTo(&out)
-	return out.Bytes(), nil
+	return doc, secondExtContent, nil
 }
 
 var _ dian.Signer = (*DigitalSignatureService)(nil)
@@ -0,0 +1,290 @@
+package signer_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	infradian "github.com/jhoicas/Inventario-api/internal/infrastructure/dian"
+	"github.com/jhoicas/Inventario-api/internal/infrastructure/dian/signer"
+)
+
+const testTechnicalKey = "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c"
+
+// testPKI CA raíz y certificado de firma emitido por ella, vigente desde hace un día.
+type testPKI struct {
+	roots *x509.CertPool
+	cert  tls.Certificate
+}
+
+func newTestPKI(t *testing.T, notAfter time.Time) *testPKI {
+	t.Helper()
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "CA de pruebas", Organization: []string{"Certificadora"}},
+		NotBefore:             time.Now().Add(-48 * time.Hour),
+		NotAfter:              time.Now().AddDate(5, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(4242),
+		Subject:      pkix.Name{CommonName: "Empresa Test", SerialNumber: "9001112221"},
+		NotBefore:    time.Now().Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	return &testPKI{roots: roots, cert: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+// buildInvoiceXML genera con el builder una factura (o nota crédito) con su CUFE, como la firma el orquestador.
+func buildInvoiceXML(t *testing.T, docType string) []byte {
+	t.Helper()
+	company := &entity.Company{ID: "c1", Name: "Empresa Test", NIT: "900111222-1", Address: "Calle 1 # 2-3"}
+	customer := &entity.Customer{ID: "cu1", Name: "Cliente & Cía", TaxID: "10203040"}
+	inv := &entity.Invoice{
+		ID: "inv-1", CompanyID: "c1", CustomerID: "cu1", Prefix: "SETP", Number: "1001", Date: time.Now(),
+		NetTotal: decimal.NewFromInt(20000), TaxTotal: decimal.NewFromInt(3800), GrandTotal: decimal.NewFromInt(23800),
+		DocumentType: docType,
+	}
+	detail := &entity.InvoiceDetail{
+		ID: "d1", InvoiceID: "inv-1", ProductID: "p1", Quantity: decimal.NewFromInt(2),
+		UnitPrice: decimal.NewFromInt(10000), TaxRate: decimal.NewFromInt(19), Subtotal: decimal.NewFromInt(20000),
+	}
+	_, err := infradian.CalculateCufeFromInvoice(&infradian.CufeContext{
+		Invoice: inv, Company: company, Customer: customer, ClaveTecnica: testTechnicalKey, TipoAmbiente: "2",
+	})
+	require.NoError(t, err)
+
+	ctx := &infradian.InvoiceBuildContext{
+		Invoice: inv, Company: company, Customer: customer, TipoAmbiente: "2", DocumentType: docType,
+		Details: []infradian.InvoiceLineForXML{{
+			Detail: detail, ProductName: "Producto 1", ProductCode: "p1", UnitCode: "94",
+			Quantity: detail.Quantity, UnitPrice: detail.UnitPrice, TaxRate: detail.TaxRate, Subtotal: detail.Subtotal,
+		}},
+	}
+	if docType == "CREDIT_NOTE" {
+		ctx.OriginalInvoiceNumber, ctx.OriginalInvoiceCUFE = "SETP1000", "cufe-original"
+		ctx.DiscrepancyCode, ctx.DiscrepancyReason = entity.CreditNoteConceptOtros, "Devolución"
+	} else {
+		ctx.Resolution = &infradian.BillingResolutionData{
+			Number: "18760000001", Prefix: "SETP", From: 1000, To: 5000,
+			DateFrom: time.Now().AddDate(0, -1, 0), DateTo: time.Now().AddDate(1, 0, 0),
+		}
+	}
+	xmlBytes, err := infradian.NewXMLBuilderService().Build(ctx)
+	require.NoError(t, err)
+	return xmlBytes
+}
+
+func checkStatuses(v *infradian.DocumentVerification) map[string]string {
+	out := make(map[string]string, len(v.Checks))
+	for _, c := range v.Checks {
+		out[c.Name] = c.Status + " " + c.Detail
+	}
+	return out
+}
+
+func TestDigitalSignatureService_FirmaVerificable(t *testing.T) {
+	pki := newTestPKI(t, time.Now().AddDate(1, 0, 0))
+	verifier := infradian.NewDocumentVerifierService(pki.roots)
+	keys := infradian.DocumentKeys{TechnicalKey: testTechnicalKey}
+
+	for _, docType := range []string{"INVOICE", "CREDIT_NOTE"} {
+		t.Run(docType, func(t *testing.T) {
+			signed, err := signer.NewDigitalSignatureService().Sign(buildInvoiceXML(t, docType), pki.cert)
+			require.NoError(t, err)
+
+			info, err := infradian.VerifyXMLSignature(signed)
+			require.NoError(t, err)
+			assert.Equal(t, 2, info.References, "documento y xades:SignedProperties")
+			assert.WithinDuration(t, time.Now(), info.SigningTime, time.Minute)
+
+			v, err := verifier.Verify(signed, keys)
+			require.NoError(t, err)
+			assert.True(t, v.Valid(), checkStatuses(v))
+			assert.Len(t, v.Checks, 5)
+			assert.Contains(t, v.Signer, "Empresa Test")
+		})
+	}
+}
+
+func TestDigitalSignatureService_DetectaAlteraciones(t *testing.T) {
+	pki := newTestPKI(t, time.Now().AddDate(1, 0, 0))
+	verifier := infradian.NewDocumentVerifierService(pki.roots)
+	signed, err := signer.NewDigitalSignatureService().Sign(buildInvoiceXML(t, "INVOICE"), pki.cert)
+	require.NoError(t, err)
+
+	t.Run("monto alterado: digest y CUFE", func(t *testing.T) {
+		tampered := bytes.Replace(signed, []byte(">23800.00<"), []byte(">28300.00<"), -1)
+		require.NotEqual(t, signed, tampered)
+		v, err := verifier.Verify(tampered, infradian.DocumentKeys{TechnicalKey: testTechnicalKey})
+		require.NoError(t, err)
+		statuses := checkStatuses(v)
+		assert.False(t, v.Valid())
+		assert.Contains(t, statuses[infradian.CheckSignature], infradian.VerificationFailed+" dian: el digest")
+		assert.Contains(t, statuses[infradian.CheckDocumentUUID], infradian.VerificationFailed)
+		assert.Contains(t, statuses[infradian.CheckCertificateChain], infradian.VerificationOK)
+	})
+
+	t.Run("CA no confiable", func(t *testing.T) {
+		v, err := infradian.NewDocumentVerifierService(x509.NewCertPool()).Verify(signed, infradian.DocumentKeys{})
+		require.NoError(t, err)
+		statuses := checkStatuses(v)
+		assert.Contains(t, statuses[infradian.CheckCertificateChain], infradian.VerificationFailed)
+		assert.Contains(t, statuses[infradian.CheckSignature], infradian.VerificationOK)
+		assert.Contains(t, statuses[infradian.CheckDocumentUUID], infradian.VerificationSkipped, "sin clave técnica no se recalcula")
+	})
+
+	t.Run("sin firma", func(t *testing.T) {
+		v, err := verifier.Verify(buildInvoiceXML(t, "INVOICE"), infradian.DocumentKeys{TechnicalKey: testTechnicalKey})
+		require.NoError(t, err)
+		statuses := checkStatuses(v)
+		assert.Contains(t, statuses[infradian.CheckSignature], infradian.VerificationFailed)
+		assert.Contains(t, statuses[infradian.CheckDocumentUUID], infradian.VerificationOK)
+	})
+}
+
+// Un valor de atributo con tabuladores o saltos de línea literales se normaliza igual al firmar que al
+// verificar (ParseDocument), así que el digest del documento coincide.
+func TestDigitalSignatureService_AtributoConEspaciosLiterales(t *testing.T) {
+	pki := newTestPKI(t, time.Now().AddDate(1, 0, 0))
+	unsigned := buildInvoiceXML(t, "INVOICE")
+	withTabs := bytes.Replace(unsigned, []byte("<UBLVersionID "), []byte("<UBLVersionID schemeName=\"UBL\t2.1\nDIAN\" "), 1)
+	require.NotEqual(t, unsigned, withTabs)
+
+	signed, err := signer.NewDigitalSignatureService().Sign(withTabs, pki.cert)
+	require.NoError(t, err)
+	v, err := infradian.NewDocumentVerifierService(pki.roots).Verify(signed, infradian.DocumentKeys{TechnicalKey: testTechnicalKey})
+	require.NoError(t, err)
+	assert.True(t, v.Valid(), checkStatuses(v))
+}
+
+func TestDigitalSignatureService_CertificadoVencidoAlFirmar(t *testing.T) {
+	pki := newTestPKI(t, time.Now().Add(-time.Hour))
+	signed, err := signer.NewDigitalSignatureService().Sign(buildInvoiceXML(t, "INVOICE"), pki.cert)
+	require.NoError(t, err)
+
+	v, err := infradian.NewDocumentVerifierService(pki.roots).Verify(signed, infradian.DocumentKeys{TechnicalKey: testTechnicalKey})
+	require.NoError(t, err)
+	statuses := checkStatuses(v)
+	assert.Contains(t, statuses[infradian.CheckCertificateValidity], infradian.VerificationFailed)
+	assert.Contains(t, statuses[infradian.CheckSignature], infradian.VerificationOK, "la firma en sí es íntegra")
+}
+
+// resign modifica ds:SignedInfo del documento firmado y vuelve a calcular ds:SignatureValue con la llave
+// del firmante, de modo que la firma sigue siendo válida y solo queda por rechazar el cambio.
+func resign(t *testing.T, signed []byte, key *rsa.PrivateKey, mutate func(root, signedInfo *etree.Element)) []byte {
+	t.Helper()
+	doc, err := signer.ParseDocument(signed)
+	require.NoError(t, err)
+	sig := doc.FindElement("//Signature")
+	require.NotNil(t, sig)
+	signedInfo := sig.SelectElement("SignedInfo")
+	mutate(doc.Root(), signedInfo)
+	h := sha256.Sum256(signer.CanonicalizeElement(signedInfo, nil))
+	value, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, h[:])
+	require.NoError(t, err)
+	sig.SelectElement("SignatureValue").SetText(base64.StdEncoding.EncodeToString(value))
+	out, err := doc.WriteToBytes()
+	require.NoError(t, err)
+	return out
+}
+
+func TestVerifyXMLSignature_ExigeReferenciasYCanonicalizacion(t *testing.T) {
+	pki := newTestPKI(t, time.Now().AddDate(1, 0, 0))
+	key := pki.cert.PrivateKey.(*rsa.PrivateKey)
+	signed, err := signer.NewDigitalSignatureService().Sign(buildInvoiceXML(t, "INVOICE"), pki.cert)
+	require.NoError(t, err)
+
+	t.Run("C14N con comentarios", func(t *testing.T) {
+		doc := resign(t, signed, key, func(_, si *etree.Element) {
+			si.SelectElement("CanonicalizationMethod").CreateAttr("Algorithm", signer.AlgC14N+"#WithComments")
+		})
+		_, err := infradian.VerifyXMLSignature(doc)
+		assert.ErrorContains(t, err, "canonicalización no soportada")
+	})
+
+	t.Run("transformada C14N con comentarios", func(t *testing.T) {
+		doc := resign(t, signed, key, func(_, si *etree.Element) {
+			tr := si.SelectElement("Reference").SelectElement("Transforms").SelectElements("Transform")[1]
+			tr.CreateAttr("Algorithm", signer.AlgC14N+"#WithComments")
+		})
+		_, err := infradian.VerifyXMLSignature(doc)
+		assert.ErrorContains(t, err, "transformada no soportada")
+	})
+
+	t.Run("referencia al documento sin enveloped-signature", func(t *testing.T) {
+		doc := resign(t, signed, key, func(_, si *etree.Element) {
+			transforms := si.SelectElement("Reference").SelectElement("Transforms")
+			transforms.RemoveChild(transforms.SelectElements("Transform")[0])
+		})
+		_, err := infradian.VerifyXMLSignature(doc)
+		assert.ErrorContains(t, err, "enveloped-signature")
+	})
+
+	t.Run("solo SignedProperties", func(t *testing.T) {
+		doc := resign(t, signed, key, func(_, si *etree.Element) {
+			si.RemoveChild(si.SelectElement("Reference"))
+		})
+		_, err := infradian.VerifyXMLSignature(doc)
+		assert.ErrorContains(t, err, "exactamente una ds:Reference al documento completo")
+	})
+
+	t.Run("referencia adicional", func(t *testing.T) {
+		doc := resign(t, signed, key, func(_, si *etree.Element) {
+			si.AddChild(si.SelectElements("Reference")[1].Copy())
+		})
+		_, err := infradian.VerifyXMLSignature(doc)
+		assert.ErrorContains(t, err, "exactamente una ds:Reference a xades:SignedProperties")
+	})
+
+	t.Run("referencia a un fragmento", func(t *testing.T) {
+		// La referencia al fragmento lleva su digest correcto y el del documento se recalcula: solo su
+		// alcance la invalida.
+		doc := resign(t, signed, key, func(root, si *etree.Element) {
+			supplier := root.FindElement("//AccountingSupplierParty")
+			supplier.CreateAttr("Id", "proveedor")
+			docDigest := sha256.Sum256(signer.CanonicalizeElement(root, si.Parent()))
+			si.SelectElements("Reference")[0].SelectElement("DigestValue").SetText(base64.StdEncoding.EncodeToString(docDigest[:]))
+			digest := sha256.Sum256(signer.CanonicalizeElement(supplier, nil))
+			ref := si.SelectElements("Reference")[1].Copy()
+			ref.CreateAttr("URI", "#proveedor")
+			ref.RemoveAttr("Type")
+			ref.SelectElement("DigestValue").SetText(base64.StdEncoding.EncodeToString(digest[:]))
+			si.AddChild(ref)
+		})
+		_, err := infradian.VerifyXMLSignature(doc)
+		assert.ErrorContains(t, err, "no cubre el documento ni xades:SignedProperties")
+	})
+}
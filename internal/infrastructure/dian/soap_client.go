@@ -10,17 +10,19 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
 )
 
 // ── Constantes de entorno ──────────────────────────────────────────────────────
 
 const (
 	// AppEnvTest es el identificador de ambiente de habilitación/pruebas DIAN.
-	AppEnvTest = "test"
+	AppEnvTest = domaindian.AppEnvTest
 	// AppEnvProd es el identificador de ambiente de producción DIAN.
-	AppEnvProd = "prod"
+	AppEnvProd = domaindian.AppEnvProd
 	// AppEnvDev es el identificador local: no envía al WS DIAN.
-	AppEnvDev = "dev"
+	AppEnvDev = domaindian.AppEnvDev
 
 	soapURLTest = "https://vpfe-hab.dian.gov.co/WcfDianCustomerServices.svc"
 	soapURLProd = "https://vpfe.dian.gov.co/WcfDianCustomerServices.svc"
@@ -39,6 +41,18 @@ type SubmitResult struct {
 	Errors   string // mensajes de error/rechazo de la DIAN (puede ser vacío)
 }
 
+// Códigos de estado (StatusCode) de GetStatusZip; ver domaindian.
+const (
+	StatusCodeProcessed      = domaindian.StatusCodeProcessed
+	StatusCodeNSUNotFound    = domaindian.StatusCodeNSUNotFound
+	StatusCodeTrackNotFound  = domaindian.StatusCodeTrackNotFound
+	StatusCodeInValidation   = domaindian.StatusCodeInValidation
+	StatusCodeValidationErrs = domaindian.StatusCodeValidationErrs
+)
+
+// StatusResult resultado de GetStatusZip para un envío asíncrono; ver domaindian.StatusResult.
+type StatusResult = domaindian.StatusResult
+
 // DIANSubmitter define el puerto de salida para la entrega de documentos al WS DIAN.
 // La implementación concreta usa SOAP; para tests se puede inyectar un mock.
 type DIANSubmitter interface {
@@ -46,6 +60,15 @@ type DIANSubmitter interface {
 	// env debe ser "test" o "prod"; determina la URL del endpoint.
 	// filename es el nombre del archivo ZIP (ej: "900123456SETP000001.zip").
 	SubmitZip(ctx context.Context, zipBytes []byte, filename, env string) (*SubmitResult, error)
+	// GetStatusZip consulta el resultado de validación de un envío asíncrono por su TrackID (ZipKey).
+	GetStatusZip(ctx context.Context, trackID, env string) (*StatusResult, error)
+}
+
+// DIANTestSetSubmitter puerto del proceso de habilitación: envío del set de pruebas al ambiente
+// de habilitación con el TestSetId asignado por la DIAN y consulta de su validación.
+type DIANTestSetSubmitter interface {
+	SendTestSetAsync(ctx context.Context, zipBytes []byte, filename, testSetID string) (*SubmitResult, error)
+	GetStatusZip(ctx context.Context, trackID, env string) (*StatusResult, error)
 }
 
 // ── Implementación SOAP ────────────────────────────────────────────────────────
@@ -106,6 +129,20 @@ type sendTestSetAsyncBody struct {
 	TestSetID   string   `xml:"testSetId"`   // ID del set de pruebas DIAN (se puede dejar vacío)
 }
 
+// getStatusZipBody cuerpo para la operación GetStatusZip (ambos entornos).
+type getStatusZipBody struct {
+	XMLName xml.Name `xml:"GetStatusZip"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	TrackID string   `xml:"trackId"`
+}
+
+// sendEventUpdateStatusBody cuerpo para la operación SendEventUpdateStatus (eventos RADIAN).
+type sendEventUpdateStatusBody struct {
+	XMLName     xml.Name `xml:"SendEventUpdateStatus"`
+	Xmlns       string   `xml:"xmlns,attr"`
+	ContentFile string   `xml:"contentFile"` // ZIP en Base64
+}
+
 // ── Estructuras de respuesta SOAP ─────────────────────────────────────────────
 
 type soapResponseEnvelope struct {
@@ -115,9 +152,29 @@ type soapResponseEnvelope struct {
 type soapResponseBody struct {
 	SendBillResponse    *sendBillAsyncResponse    `xml:"SendBillAsyncResponse"`
 	SendTestSetResponse *sendTestSetAsyncResponse `xml:"SendTestSetAsyncResponse"`
+	GetStatusZip        *getStatusZipResponse     `xml:"GetStatusZipResponse"`
+	SendEvent           *sendEventResponse        `xml:"SendEventUpdateStatusResponse"`
 	Fault               *soapFault                `xml:"Fault"`
 }
 
+type getStatusZipResponse struct {
+	Responses []dianResponse `xml:"GetStatusZipResult>DianResponse"`
+}
+
+type sendEventResponse struct {
+	Response dianResponse `xml:"SendEventUpdateStatusResult"`
+}
+
+type dianResponse struct {
+	IsValid           bool     `xml:"IsValid"`
+	StatusCode        string   `xml:"StatusCode"`
+	StatusDescription string   `xml:"StatusDescription"`
+	StatusMessage     string   `xml:"StatusMessage"`
+	ErrorMessages     []string `xml:"ErrorMessage>string"`
+	XMLBase64Bytes    string   `xml:"XmlBase64Bytes"`
+	XMLDocumentKey    string   `xml:"XmlDocumentKey"`
+}
+
 type sendBillAsyncResponse struct {
 	Result sendBillAsyncResult `xml:"SendBillAsyncResult"`
 }
@@ -146,6 +203,79 @@ func (c *SOAPDIANClient) SubmitZip(ctx context.Context, zipBytes []byte, filenam
 		return nil, err
 	}
 
+	rawBody, err := c.call(ctx, soapURL, soapAction, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.parseResponse(rawBody, env)
+}
+
+// SendTestSetAsync envía un documento del set de pruebas al ambiente de habilitación con su TestSetId.
+func (c *SOAPDIANClient) SendTestSetAsync(ctx context.Context, zipBytes []byte, filename, testSetID string) (*SubmitResult, error) {
+	rawBody, err := c.call(ctx, soapURLTest, soapActionBase+"SendTestSetAsync", &sendTestSetAsyncBody{
+		Xmlns:       soapNSTempuri,
+		FileName:    filename,
+		ContentFile: base64.StdEncoding.EncodeToString(zipBytes),
+		TestSetID:   testSetID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.parseResponse(rawBody, AppEnvTest)
+}
+
+// GetStatusZip consulta el estado de validación del envío identificado por trackID.
+func (c *SOAPDIANClient) GetStatusZip(ctx context.Context, trackID, env string) (*StatusResult, error) {
+	var soapURL string
+	switch env {
+	case AppEnvProd:
+		soapURL = soapURLProd
+	case AppEnvTest:
+		soapURL = soapURLTest
+	default:
+		return nil, fmt.Errorf("soap: entorno desconocido %q (usar 'test' o 'prod')", env)
+	}
+	rawBody, err := c.call(ctx, soapURL, soapActionBase+"GetStatusZip",
+		&getStatusZipBody{Xmlns: soapNSTempuri, TrackID: trackID})
+	if err != nil {
+		return nil, err
+	}
+	return parseStatusResponse(rawBody)
+}
+
+// SendEventUpdateStatus envía el ZIP del evento RADIAN y devuelve la validación de la DIAN.
+func (c *SOAPDIANClient) SendEventUpdateStatus(ctx context.Context, zipBytes []byte, env string) (*StatusResult, error) {
+	var soapURL string
+	switch env {
+	case AppEnvProd:
+		soapURL = soapURLProd
+	case AppEnvTest:
+		soapURL = soapURLTest
+	default:
+		return nil, fmt.Errorf("soap: entorno desconocido %q (usar 'test' o 'prod')", env)
+	}
+	rawBody, err := c.call(ctx, soapURL, soapActionBase+"SendEventUpdateStatus", &sendEventUpdateStatusBody{
+		Xmlns:       soapNSTempuri,
+		ContentFile: base64.StdEncoding.EncodeToString(zipBytes),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var envResp soapResponseEnvelope
+	if err := xml.Unmarshal(rawBody, &envResp); err != nil {
+		return nil, fmt.Errorf("soap: parsear respuesta SendEventUpdateStatus: %w", err)
+	}
+	if envResp.Body.Fault != nil {
+		return nil, fmt.Errorf("soap: SendEventUpdateStatus Fault [%s]: %s", envResp.Body.Fault.FaultCode, envResp.Body.Fault.FaultString)
+	}
+	if envResp.Body.SendEvent == nil {
+		return &StatusResult{}, nil
+	}
+	return statusResultFrom(envResp.Body.SendEvent.Response)
+}
+
+// call serializa el envelope, lo envía con la SOAPAction indicada y devuelve el cuerpo de la respuesta.
+func (c *SOAPDIANClient) call(ctx context.Context, soapURL, soapAction string, body interface{}) ([]byte, error) {
 	envelope := soapEnvelope{
 		XmlnsS: soapNS,
 		Body:   soapBody{Content: body},
@@ -173,12 +303,11 @@ func (c *SOAPDIANClient) SubmitZip(ctx context.Context, zipBytes []byte, filenam
 	}
 	defer resp.Body.Close()
 
-	rawBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) // max 1 MB
+	rawBody, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20)) // max 4 MB (GetStatusZip incluye el ApplicationResponse)
 	if err != nil {
 		return nil, fmt.Errorf("soap: leer respuesta: %w", err)
 	}
-
-	return c.parseResponse(rawBody, env)
+	return rawBody, nil
 }
 
 // buildRequest construye la URL, SOAPAction y body según el entorno.
@@ -249,3 +378,39 @@ func (c *SOAPDIANClient) parseResponse(rawBody []byte, env string) (*SubmitResul
 		Errors:   errMsg,
 	}, nil
 }
+
+// parseStatusResponse desempaqueta la respuesta de GetStatusZip. Un SOAP Fault o una respuesta
+// ilegible se devuelven como error para que el TrackID se vuelva a consultar más tarde.
+func parseStatusResponse(rawBody []byte) (*StatusResult, error) {
+	var envResp soapResponseEnvelope
+	if err := xml.Unmarshal(rawBody, &envResp); err != nil {
+		return nil, fmt.Errorf("soap: parsear respuesta GetStatusZip: %w", err)
+	}
+	if envResp.Body.Fault != nil {
+		return nil, fmt.Errorf("soap: GetStatusZip Fault [%s]: %s", envResp.Body.Fault.FaultCode, envResp.Body.Fault.FaultString)
+	}
+	if envResp.Body.GetStatusZip == nil || len(envResp.Body.GetStatusZip.Responses) == 0 {
+		return &StatusResult{}, nil // sin DianResponse: la DIAN aún no procesa el ZIP
+	}
+	return statusResultFrom(envResp.Body.GetStatusZip.Responses[0])
+}
+
+// statusResultFrom convierte un DianResponse (GetStatusZip o SendEventUpdateStatus) en StatusResult.
+func statusResultFrom(r dianResponse) (*StatusResult, error) {
+	result := &StatusResult{
+		IsValid:           r.IsValid,
+		StatusCode:        strings.TrimSpace(r.StatusCode),
+		StatusDescription: strings.TrimSpace(r.StatusDescription),
+		StatusMessage:     strings.TrimSpace(r.StatusMessage),
+		ErrorMessages:     r.ErrorMessages,
+		DocumentKey:       strings.TrimSpace(r.XMLDocumentKey),
+	}
+	if b64 := strings.TrimSpace(r.XMLBase64Bytes); b64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("soap: decodificar XmlBase64Bytes: %w", err)
+		}
+		result.ApplicationResponse = decoded
+	}
+	return result, nil
+}
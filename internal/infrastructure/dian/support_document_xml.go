@@ -0,0 +1,230 @@
+package dian
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	domdian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
+)
+
+// Perfiles DIAN del documento soporte en adquisiciones a no obligados a facturar.
+const (
+	profileSupportDocument   = "DIAN 2.1: documento soporte en adquisiciones efectuadas a no obligados a facturar."
+	profileSupportAdjustment = "DIAN 2.1: Nota de ajuste al documento soporte en adquisiciones efectuadas a sujetos no obligados a expedir factura o documento equivalente"
+	// CustomizationID del documento soporte: 10 vendedor residente, 11 no residente.
+	customizationResident    = "10"
+	customizationNonResident = "11"
+)
+
+// BuildSupportDocument genera el XML UBL 2.1 (sin firma) del documento soporte (raíz Invoice,
+// InvoiceTypeCode 05) o de su nota de ajuste (raíz CreditNote, CreditNoteTypeCode 95).
+func (s *XMLBuilderService) BuildSupportDocument(ctx *SupportDocumentBuildContext) ([]byte, error) {
+	if ctx == nil || ctx.Document == nil || ctx.Company == nil {
+		return nil, fmt.Errorf("dian: faltan documento soporte o company en el contexto")
+	}
+	doc := ctx.Document
+	if len(doc.Lines) == 0 {
+		return nil, fmt.Errorf("dian: el documento soporte %s no tiene líneas", doc.FullNumber())
+	}
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+
+	ns, local, id, schemaLocation := NsInvoice, "Invoice", "invoice-id", schemaLocationInvoice
+	if doc.IsAdjustment() {
+		ns, local, id, schemaLocation = NsCreditNote, "CreditNote", "creditnote-id", schemaLocationCreditNote
+	}
+	root := xml.StartElement{
+		Name: xml.Name{Space: ns, Local: local},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "Id"}, Value: id},
+			{Name: xml.Name{Local: "xmlns"}, Value: ns},
+			{Name: xml.Name{Local: "xmlns:cac"}, Value: NsCac},
+			{Name: xml.Name{Local: "xmlns:cbc"}, Value: NsCbc},
+			{Name: xml.Name{Local: "xmlns:ds"}, Value: NsDs},
+			{Name: xml.Name{Local: "xmlns:ext"}, Value: NsExt},
+			{Name: xml.Name{Local: "xmlns:sts"}, Value: NsSts},
+			{Name: xml.Name{Local: "xmlns:xades"}, Value: NsXades},
+			{Name: xml.Name{Local: "xmlns:xsi"}, Value: nsXsi},
+			{Name: xml.Name{Space: nsXsi, Local: "schemaLocation"}, Value: schemaLocation},
+		},
+	}
+	if err := enc.EncodeToken(root); err != nil {
+		return nil, err
+	}
+
+	// ext:UBLExtensions: resolución del documento soporte y software de la empresa; placeholder de firma.
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtensions"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	writeDianExtensions(enc, ctx.Resolution, ctx.Company.NIT, ctx.SoftwareID, ctx.SoftwareSecurityCode)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtensions"}})
+
+	currency := dian.CurrencyCOP
+	writeCbc(enc, "UBLVersionID", "2.1")
+	writeCbc(enc, "CustomizationID", supportDocumentCustomization(doc))
+	if doc.IsAdjustment() {
+		writeCbc(enc, "ProfileID", profileSupportAdjustment)
+	} else {
+		writeCbc(enc, "ProfileID", profileSupportDocument)
+	}
+	if ctx.TipoAmbiente != "" {
+		writeCbc(enc, "ProfileExecutionID", ctx.TipoAmbiente)
+	}
+	writeCbc(enc, "ID", doc.FullNumber())
+	if doc.CUDS != "" {
+		writeCbcWithAttr(enc, "UUID", doc.CUDS, "schemeName", "CUDS-SHA384")
+	}
+	writeCbc(enc, "IssueDate", doc.Date.Format("2006-01-02"))
+	writeCbc(enc, "IssueTime", doc.Date.Format("15:04:05-07:00"))
+	if doc.IsAdjustment() {
+		writeCbc(enc, "CreditNoteTypeCode", dian.CreditNoteTypeAjusteDS)
+	} else {
+		writeCbc(enc, "InvoiceTypeCode", dian.InvoiceTypeDocumentoSoporte)
+	}
+	if doc.Notes != "" {
+		writeCbc(enc, "Note", doc.Notes)
+	}
+	writeCbc(enc, "DocumentCurrencyCode", currency)
+	writeCbc(enc, "LineCountNumeric", strconv.Itoa(len(doc.Lines)))
+
+	if doc.IsAdjustment() {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "DiscrepancyResponse"}})
+		writeCbc(enc, "ReferenceID", doc.OriginalDocumentNumber)
+		writeCbc(enc, "ResponseCode", string(doc.DiscrepancyCode))
+		if doc.DiscrepancyReason != "" {
+			writeCbc(enc, "Description", doc.DiscrepancyReason)
+		}
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "DiscrepancyResponse"}})
+
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "BillingReference"}})
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "InvoiceDocumentReference"}})
+		writeCbc(enc, "ID", doc.OriginalDocumentNumber)
+		writeCbcWithAttr(enc, "UUID", doc.OriginalDocumentCUDS, "schemeName", "CUDS-SHA384")
+		writeCbc(enc, "IssueDate", doc.OriginalIssueOn.Format("2006-01-02"))
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "InvoiceDocumentReference"}})
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "BillingReference"}})
+	}
+
+	// Vendedor: proveedor no obligado a facturar. Adquiriente: la empresa que emite el documento.
+	writeSupportDocumentParty(enc, "AccountingSupplierParty", doc.SupplierIdentificationType,
+		customerIdentification(doc.SupplierIdentification, doc.SupplierIdentificationType), doc.SupplierName, "")
+	writeSupportDocumentParty(enc, "AccountingCustomerParty", schemeIDFromCode(ctx.CompanyIdentificationTypeCode),
+		normalizeNIT(ctx.Company.NIT), ctx.Company.Name, ctx.Company.Address)
+
+	// Contado en efectivo: el pago al proveedor se gestiona fuera del documento.
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PaymentMeans"}})
+	writeCbc(enc, "ID", dian.PaymentFormContado)
+	writeCbc(enc, "PaymentMeansCode", dian.PaymentMethodEfectivo)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PaymentMeans"}})
+
+	lines := make([]InvoiceLineForXML, len(doc.Lines))
+	var taxes []*entity.InvoiceTax
+	for i, l := range doc.Lines {
+		lines[i] = supportDocumentLineForXML(l)
+		taxes = append(taxes, lineTaxes(lines[i])...)
+	}
+	writeTaxTotals(enc, domdian.GroupTaxes(taxes), "", currency)
+
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "LegalMonetaryTotal"}})
+	writeCbcAmount(enc, "LineExtensionAmount", formatDecimal(doc.NetTotal), currency)
+	writeCbcAmount(enc, "TaxExclusiveAmount", formatDecimal(doc.NetTotal), currency)
+	writeCbcAmount(enc, "TaxInclusiveAmount", formatDecimal(doc.NetTotal.Add(doc.TaxTotal)), currency)
+	writeCbcAmount(enc, "PayableAmount", formatDecimal(doc.GrandTotal), currency)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "LegalMonetaryTotal"}})
+
+	lineElement, quantityElement := "InvoiceLine", "InvoicedQuantity"
+	if doc.IsAdjustment() {
+		lineElement, quantityElement = "CreditNoteLine", "CreditedQuantity"
+	}
+	for i, line := range lines {
+		writeSupportDocumentLine(enc, lineElement, quantityElement, i+1, line, doc, currency)
+	}
+
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// supportDocumentCustomization 11 si el vendedor se identifica con documento extranjero, 10 si es residente.
+func supportDocumentCustomization(doc *entity.SupportDocument) string {
+	switch doc.SupplierIdentificationType {
+	case dian.IdentificationTypeExtranjero, dian.IdentificationTypeNITOtroPais:
+		return customizationNonResident
+	}
+	return customizationResident
+}
+
+func writeSupportDocumentParty(enc *xml.Encoder, element, schemeID, identification, name, address string) {
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: element}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Party"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PartyIdentification"}})
+	writeCbcWithAttr(enc, "ID", identification, "schemeID", schemeIDFromCode(schemeID))
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PartyIdentification"}})
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PartyName"}})
+	writeCbc(enc, "Name", name)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PartyName"}})
+	if address != "" {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PostalAddress"}})
+		writeCbc(enc, "StreetName", address)
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PostalAddress"}})
+	}
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Party"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: element}})
+}
+
+// writeSupportDocumentLine escribe una línea del documento soporte con su cac:InvoicePeriod
+// (fecha de la adquisición; DescriptionCode 1 = por operación), obligatorio en este documento.
+func writeSupportDocumentLine(enc *xml.Encoder, element, quantityElement string, lineNum int, line InvoiceLineForXML, doc *entity.SupportDocument, currency string) {
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: element}})
+	writeCbc(enc, "ID", strconv.Itoa(lineNum))
+	writeCbcWithAttr(enc, quantityElement, formatDecimal(line.Quantity), "unitCode", line.UnitCode)
+	writeCbcAmount(enc, "LineExtensionAmount", formatDecimal(line.Subtotal), currency)
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "InvoicePeriod"}})
+	writeCbc(enc, "StartDate", doc.Date.Format("2006-01-02"))
+	writeCbc(enc, "DescriptionCode", "1")
+	writeCbc(enc, "Description", "Por operación")
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "InvoicePeriod"}})
+	writeLineTaxTotal(enc, line, line.UnitCode, currency)
+
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Item"}})
+	writeCbc(enc, "Description", line.ProductName)
+	if line.ProductCode != "" {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "SellersItemIdentification"}})
+		writeCbc(enc, "ID", line.ProductCode)
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "SellersItemIdentification"}})
+	}
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Item"}})
+
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Price"}})
+	writeCbcAmount(enc, "PriceAmount", formatDecimal(line.UnitPrice), currency)
+	writeCbcWithAttr(enc, "BaseQuantity", "1", "unitCode", line.UnitCode)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Price"}})
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: element}})
+}
+
+// supportDocumentLineForXML adapta la línea al formato de impuestos de las facturas (IVA con TaxRate).
+func supportDocumentLineForXML(l *entity.SupportDocumentLine) InvoiceLineForXML {
+	return InvoiceLineForXML{
+		ProductName: l.Description,
+		UnitCode:    dian.UnitUnit,
+		Quantity:    l.Quantity,
+		UnitPrice:   l.UnitPrice,
+		TaxRate:     l.TaxRate,
+		Subtotal:    l.Subtotal,
+	}
+}
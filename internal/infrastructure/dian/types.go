@@ -28,6 +28,9 @@ type InvoiceLineForXML struct {
 	UnitPrice   decimal.Decimal
 	TaxRate     decimal.Decimal
 	Subtotal    decimal.Decimal
+	Taxes       []*entity.InvoiceTax // impuestos de la línea (IVA, INC, por unidad); vacío = IVA con TaxRate
+	// AllowanceCharges descuentos de la línea (cac:AllowanceCharge de la línea).
+	AllowanceCharges []*entity.InvoiceAllowanceCharge
 }
 
 // InvoiceBuildContext contexto con todos los datos necesarios para construir el XML de la factura
@@ -38,15 +41,32 @@ type InvoiceBuildContext struct {
 	Customer   *entity.Customer // Cliente (AccountingCustomerParty)
 	Details    []InvoiceLineForXML
 	Resolution *BillingResolutionData
+	Taxes      []*entity.InvoiceTax // desglose de impuestos del documento; vacío = IVA desde los detalles
+
+	// Retenciones practicadas por el cliente (cac:WithholdingTaxTotal, solo facturas); informativas,
+	// no modifican el PayableAmount del documento.
+	Withholdings []*entity.InvoiceWithholding
+
+	// Descuentos y cargos globales del documento (cac:AllowanceCharge); los de línea van en Details.
+	AllowanceCharges []*entity.InvoiceAllowanceCharge
 
 	// Opcionales (si la factura los tiene en BD)
-	PaymentFormCode                string // 1=Contado, 2=Crédito
-	PaymentMethodCode              string // 10=Efectivo, 47=Transferencia, etc.
+	PaymentFormCode                string                       // 1=Contado, 2=Crédito
+	PaymentMethodCode              string                       // 10=Efectivo, 47=Transferencia, etc.
+	PaymentMethodCodes             []string                     // varios medios de pago; si viene, reemplaza a PaymentMethodCode
+	Installments                   []*entity.InvoiceInstallment // plan de cuotas (cac:PaymentTerms) de ventas a crédito
 	DueDate                        *time.Time
 	IssueDate                      *time.Time // Si no se usa Invoice.Date
-	CustomerIdentificationTypeCode string     // 13=CC, 31=NIT
+	CustomerIdentificationTypeCode string     // 13=CC, 31=NIT, 22/41/42/50=extranjeros
 	CompanyIdentificationTypeCode  string
 
+	// Software de facturación del emisor (sts:SoftwareProvider y sts:SoftwareSecurityCode);
+	// vacío omite ambos elementos.
+	SoftwareID           string
+	SoftwareSecurityCode string
+
+	TipoAmbiente string // cbc:ProfileExecutionID: 1 producción, 2 pruebas; vacío lo omite
+
 	// Tipo de documento UBL: "INVOICE" (por defecto), "CREDIT_NOTE" o "DEBIT_NOTE"
 	DocumentType string
 
@@ -59,3 +79,46 @@ type InvoiceBuildContext struct {
 	DiscrepancyCode   entity.CreditNoteConcept
 	DiscrepancyReason string
 }
+
+// SupportDocumentBuildContext datos para el XML del documento soporte o de su nota de ajuste. La
+// empresa es el adquiriente (AccountingCustomerParty) y el proveedor no obligado a facturar el
+// vendedor (AccountingSupplierParty); las líneas salen de Document.Lines.
+type SupportDocumentBuildContext struct {
+	Document   *entity.SupportDocument
+	Company    *entity.Company
+	Resolution *BillingResolutionData // obligatoria en el documento soporte; las notas de ajuste no la llevan
+
+	// Software de la empresa (sts:SoftwareProvider y sts:SoftwareSecurityCode).
+	SoftwareID           string
+	SoftwareSecurityCode string
+
+	TipoAmbiente                  string // cbc:ProfileExecutionID: 1 producción, 2 pruebas
+	CompanyIdentificationTypeCode string
+}
+
+// ApplicationResponseBuildContext datos para el XML de un evento RADIAN sobre una factura recibida. La
+// empresa (adquiriente) emite el evento (SenderParty) y el proveedor que facturó lo recibe (ReceiverParty).
+type ApplicationResponseBuildContext struct {
+	Event    *entity.ReceivedDocumentEvent
+	Document *entity.ReceivedDocument
+	Company  *entity.Company
+
+	// Software de la empresa (sts:SoftwareProvider y sts:SoftwareSecurityCode).
+	SoftwareID           string
+	SoftwareSecurityCode string
+
+	TipoAmbiente string // cbc:ProfileExecutionID: 1 producción, 2 pruebas
+}
+
+// AttachedDocumentBuildContext datos del contenedor AttachedDocument con que se entrega el documento al
+// adquiriente: el XML firmado y el ApplicationResponse con que la DIAN lo validó.
+type AttachedDocumentBuildContext struct {
+	Invoice  *entity.Invoice
+	Company  *entity.Company  // emisor (SenderParty)
+	Customer *entity.Customer // adquiriente (ReceiverParty)
+
+	SignedXML           []byte // documento firmado enviado a la DIAN (Invoice.XMLSigned)
+	ApplicationResponse []byte // vacío si la DIAN no lo devolvió (modo dev): se omite su cac:Attachment
+
+	TipoAmbiente string // cbc:ProfileExecutionID: 1 producción, 2 pruebas
+}
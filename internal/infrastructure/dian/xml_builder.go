@@ -5,7 +5,10 @@ import (
 	"encoding/xml"
 	"fmt"
 	"strconv"
+	"time"
 
+	domdian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/pkg/dian"
 	"github.com/shopspring/decimal"
 )
@@ -18,6 +21,10 @@ const (
 	NsCreditNote = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
 	// Namespace para UBL DebitNote
 	NsDebitNote = "urn:oasis:names:specification:ubl:schema:xsd:DebitNote-2"
+	// Namespace para UBL ApplicationResponse (eventos RADIAN)
+	NsApplicationResponse = "urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2"
+	// Namespace para UBL AttachedDocument (contenedor de entrega al adquiriente)
+	NsAttachedDocument = "urn:oasis:names:specification:ubl:schema:xsd:AttachedDocument-2"
 	// Common Aggregate Components
 	NsCac = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
 	// Common Basic Components
@@ -38,6 +45,10 @@ const (
 	schemaLocationCreditNote = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2 http://docs.oasis-open.org/ubl/os-UBL-2.1/xsd/maindoc/UBL-CreditNote-2.1.xsd"
 	// Schema location UBL DebitNote 2.1
 	schemaLocationDebitNote = "urn:oasis:names:specification:ubl:schema:xsd:DebitNote-2 http://docs.oasis-open.org/ubl/os-UBL-2.1/xsd/maindoc/UBL-DebitNote-2.1.xsd"
+	// Schema location UBL ApplicationResponse 2.1
+	schemaLocationApplicationResponse = "urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2 http://docs.oasis-open.org/ubl/os-UBL-2.1/xsd/maindoc/UBL-ApplicationResponse-2.1.xsd"
+	// Schema location UBL AttachedDocument 2.1
+	schemaLocationAttachedDocument = "urn:oasis:names:specification:ubl:schema:xsd:AttachedDocument-2 http://docs.oasis-open.org/ubl/os-UBL-2.1/xsd/maindoc/UBL-AttachedDocument-2.1.xsd"
 )
 
 // XMLBuilderService construye el XML UBL 2.1 de la factura (sin firma XAdES).
@@ -129,6 +140,7 @@ func (s *XMLBuilderService) Build(ctx *InvoiceBuildContext) ([]byte, error) {
 	if ctx.IssueDate != nil {
 		issueDate = *ctx.IssueDate
 	}
+	currency := documentCurrency(ctx)
 	invoiceID := ctx.Invoice.Prefix + ctx.Invoice.Number
 	if ctx.Invoice.Prefix == "" {
 		invoiceID = ctx.Invoice.Number
@@ -140,24 +152,34 @@ func (s *XMLBuilderService) Build(ctx *InvoiceBuildContext) ([]byte, error) {
 		writeCbc(enc, "ProfileID", "DIAN 2.1: Nota Crédito de Venta")
 	} else if docType == "DEBIT_NOTE" {
 		writeCbc(enc, "ProfileID", "DIAN 2.1: Nota Débito de Venta")
+	} else if ctx.Invoice.IsPOS() {
+		writeCbc(enc, "ProfileID", "DIAN 2.1: documento equivalente electrónico del tiquete de máquina registradora con sistema P.O.S.")
 	} else {
 		writeCbc(enc, "ProfileID", "DIAN 2.1: Factura Electrónica de Venta")
 	}
+	if ctx.TipoAmbiente != "" {
+		writeCbc(enc, "ProfileExecutionID", ctx.TipoAmbiente)
+	}
 	writeCbc(enc, "ID", invoiceID)
-	// cbc:UUID = CUFE (Código Único de Factura Electrónica)
-	if u := ctx.Invoice.UUID; u != "" {
+	// cbc:UUID = CUFE (Código Único de Factura Electrónica); CUDE en el documento equivalente POS
+	if ctx.Invoice.IsPOS() && ctx.Invoice.CUFE != "" {
+		writeCbcWithAttr(enc, "UUID", ctx.Invoice.CUFE, "schemeName", "CUDE-SHA384")
+	} else if u := ctx.Invoice.UUID; u != "" {
 		writeCbc(enc, "UUID", u)
 	} else if ctx.Invoice.CUFE != "" {
 		writeCbc(enc, "UUID", ctx.Invoice.CUFE)
 	}
 	writeCbc(enc, "IssueDate", issueDate.Format("2006-01-02"))
 	writeCbc(enc, "IssueTime", issueDate.Format("15:04:05-07:00"))
-	writeCbc(enc, "DocumentCurrencyCode", "COP")
-	writeCbc(enc, "LineCountNumeric", strconv.Itoa(len(ctx.Details)))
-
-	if ctx.DueDate != nil {
-		writeCbc(enc, "DueDate", ctx.DueDate.Format("2006-01-02"))
+	if docType == "INVOICE" {
+		// UBL: cbc:DueDate va antes de InvoiceTypeCode (las notas no lo llevan).
+		if ctx.DueDate != nil {
+			writeCbc(enc, "DueDate", ctx.DueDate.Format("2006-01-02"))
+		}
+		writeCbc(enc, "InvoiceTypeCode", invoiceTypeCode(ctx.Invoice))
 	}
+	writeCbc(enc, "DocumentCurrencyCode", currency)
+	writeCbc(enc, "LineCountNumeric", strconv.Itoa(len(ctx.Details)))
 
 	// Elementos específicos de Nota Crédito
 	if docType == "CREDIT_NOTE" || docType == "DEBIT_NOTE" {
@@ -205,12 +227,40 @@ func (s *XMLBuilderService) Build(ctx *InvoiceBuildContext) ([]byte, error) {
 	if err := s.writeCustomerParty(enc, ctx); err != nil {
 		return nil, err
 	}
+	// ---- cac:DeliveryTerms (Incoterm; solo facturas de exportación)
+	if docType == "INVOICE" && ctx.Invoice.Incoterm != "" {
+		writeDeliveryTerms(enc, ctx.Invoice.Incoterm)
+	}
 	// ---- cac:PaymentMeans (forma y medio de pago)
 	s.writePaymentMeans(enc, ctx)
+	// ---- cac:PaymentTerms (cuotas de la venta a crédito)
+	if ctx.PaymentFormCode == dian.PaymentFormCredito {
+		writePaymentTerms(enc, ctx.Installments, currency)
+	}
+	// ---- cac:AllowanceCharge (descuentos y cargos globales) y cac:PaymentExchangeRate (TRM; solo
+	// documentos en moneda extranjera). UBL los ordena distinto en la factura y en las notas.
+	isNote := docType == "CREDIT_NOTE" || docType == "DEBIT_NOTE"
+	if !isNote {
+		for i, ac := range ctx.AllowanceCharges {
+			writeAllowanceCharge(enc, i+1, ac, true, currency)
+		}
+	}
+	if ctx.Invoice.IsForeignCurrency() {
+		writePaymentExchangeRate(enc, currency, ctx.Invoice.ExchangeRate, issueDate)
+	}
+	if isNote {
+		for i, ac := range ctx.AllowanceCharges {
+			writeAllowanceCharge(enc, i+1, ac, true, currency)
+		}
+	}
 	// ---- cac:TaxTotal
 	if err := s.writeTaxTotal(enc, ctx); err != nil {
 		return nil, err
 	}
+	// ---- cac:WithholdingTaxTotal (retenciones del cliente; solo facturas)
+	if !isNote {
+		writeWithholdingTaxTotal(enc, ctx.Withholdings, currency)
+	}
 	// ---- cac:LegalMonetaryTotal
 	if err := s.writeLegalMonetaryTotal(enc, ctx); err != nil {
 		return nil, err
@@ -218,15 +268,15 @@ func (s *XMLBuilderService) Build(ctx *InvoiceBuildContext) ([]byte, error) {
 	// ---- Líneas: InvoiceLine o CreditNoteLine según tipo de documento
 	for i, line := range ctx.Details {
 		if docType == "CREDIT_NOTE" {
-			if err := s.writeCreditNoteLine(enc, i+1, line); err != nil {
+			if err := s.writeCreditNoteLine(enc, i+1, line, currency); err != nil {
 				return nil, err
 			}
 		} else if docType == "DEBIT_NOTE" {
-			if err := s.writeDebitNoteLine(enc, i+1, line); err != nil {
+			if err := s.writeDebitNoteLine(enc, i+1, line, currency); err != nil {
 				return nil, err
 			}
 		} else {
-			if err := s.writeInvoiceLine(enc, i+1, line); err != nil {
+			if err := s.writeInvoiceLine(enc, i+1, line, currency); err != nil {
 				return nil, err
 			}
 		}
@@ -267,22 +317,7 @@ func (s *XMLBuilderService) writeUBLExtensions(enc *xml.Encoder, ctx *InvoiceBui
 	// 1. Extensión DIAN (datos de resolución o placeholder vacío)
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
-	if ctx.Resolution != nil {
-		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "DianExtensions"}})
-		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "InvoiceControl"}})
-		writeSts(enc, "InvoiceAuthorization", ctx.Resolution.Number)
-		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "AuthorizationPeriod"}})
-		writeSts(enc, "StartDate", ctx.Resolution.DateFrom.Format("2006-01-02"))
-		writeSts(enc, "EndDate", ctx.Resolution.DateTo.Format("2006-01-02"))
-		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "AuthorizationPeriod"}})
-		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "AuthorizedInvoices"}})
-		writeSts(enc, "Prefix", ctx.Resolution.Prefix)
-		writeSts(enc, "From", strconv.FormatInt(ctx.Resolution.From, 10))
-		writeSts(enc, "To", strconv.FormatInt(ctx.Resolution.To, 10))
-		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "AuthorizedInvoices"}})
-		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "InvoiceControl"}})
-		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "DianExtensions"}})
-	}
+	writeDianExtensions(enc, ctx.Resolution, ctx.Company.NIT, ctx.SoftwareID, ctx.SoftwareSecurityCode)
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "ExtensionContent"}})
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsExt, Local: "UBLExtension"}})
 
@@ -296,6 +331,49 @@ func (s *XMLBuilderService) writeUBLExtensions(enc *xml.Encoder, ctx *InvoiceBui
 	return nil
 }
 
+// writeDianExtensions escribe sts:DianExtensions (resolución y software del emisor); sin resolución ni
+// software no escribe nada. providerNIT es el NIT de quien emite el documento con su software propio.
+func writeDianExtensions(enc *xml.Encoder, resolution *BillingResolutionData, providerNIT, softwareID, securityCode string) {
+	if resolution != nil || softwareID != "" {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "DianExtensions"}})
+	}
+	if resolution != nil {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "InvoiceControl"}})
+		writeSts(enc, "InvoiceAuthorization", resolution.Number)
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "AuthorizationPeriod"}})
+		writeSts(enc, "StartDate", resolution.DateFrom.Format("2006-01-02"))
+		writeSts(enc, "EndDate", resolution.DateTo.Format("2006-01-02"))
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "AuthorizationPeriod"}})
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "AuthorizedInvoices"}})
+		writeSts(enc, "Prefix", resolution.Prefix)
+		writeSts(enc, "From", strconv.FormatInt(resolution.From, 10))
+		writeSts(enc, "To", strconv.FormatInt(resolution.To, 10))
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "AuthorizedInvoices"}})
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "InvoiceControl"}})
+	}
+	if softwareID != "" {
+		// Software del emisor: NIT del proveedor (software propio) e identificador registrado en la DIAN.
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: "SoftwareProvider"}})
+		_ = enc.EncodeToken(xml.StartElement{
+			Name: xml.Name{Space: NsSts, Local: "ProviderID"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "schemeAgencyID"}, Value: "195"},
+				{Name: xml.Name{Local: "schemeName"}, Value: "31"},
+			},
+		})
+		_ = enc.EncodeToken(xml.CharData(normalizeNIT(providerNIT)))
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "ProviderID"}})
+		writeSts(enc, "SoftwareID", softwareID)
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "SoftwareProvider"}})
+		if securityCode != "" {
+			writeSts(enc, "SoftwareSecurityCode", securityCode)
+		}
+	}
+	if resolution != nil || softwareID != "" {
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsSts, Local: "DianExtensions"}})
+	}
+}
+
 func writeSts(enc *xml.Encoder, local, value string) {
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsSts, Local: local}})
 	_ = enc.EncodeToken(xml.CharData(value))
@@ -303,7 +381,7 @@ func writeSts(enc *xml.Encoder, local, value string) {
 }
 
 // writeCreditNoteLine escribe una línea de Nota Crédito (CreditNoteLine) usando los datos de producto.
-func (s *XMLBuilderService) writeCreditNoteLine(enc *xml.Encoder, lineNumber int, line InvoiceLineForXML) error {
+func (s *XMLBuilderService) writeCreditNoteLine(enc *xml.Encoder, lineNumber int, line InvoiceLineForXML, currency string) error {
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "CreditNoteLine"}})
 
 	// cbc:ID — número de línea
@@ -319,8 +397,11 @@ func (s *XMLBuilderService) writeCreditNoteLine(enc *xml.Encoder, lineNumber int
 	_ = enc.EncodeToken(xml.CharData(line.Quantity.String()))
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCbc, Local: "CreditedQuantity"}})
 
-	// LineExtensionAmount (subtotal de la línea).
-	writeCbcAmount(enc, "LineExtensionAmount", line.Subtotal.Round(2).StringFixed(2), "COP")
+	// LineExtensionAmount (subtotal de la línea) e impuestos de la línea.
+	writeCbcAmount(enc, "LineExtensionAmount", line.Subtotal.Round(2).StringFixed(2), currency)
+	// En las líneas de las notas UBL ubica cac:TaxTotal antes de cac:AllowanceCharge.
+	writeLineTaxTotal(enc, line, line.UnitCode, currency)
+	writeLineAllowanceCharges(enc, line, currency)
 
 	// Item (descripción y código del producto).
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Item"}})
@@ -332,7 +413,7 @@ func (s *XMLBuilderService) writeCreditNoteLine(enc *xml.Encoder, lineNumber int
 
 	// Price (precio unitario).
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Price"}})
-	writeCbcAmount(enc, "PriceAmount", line.UnitPrice.Round(2).StringFixed(2), "COP")
+	writeCbcAmount(enc, "PriceAmount", line.UnitPrice.Round(2).StringFixed(2), currency)
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Price"}})
 
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "CreditNoteLine"}})
@@ -340,7 +421,7 @@ func (s *XMLBuilderService) writeCreditNoteLine(enc *xml.Encoder, lineNumber int
 }
 
 // writeDebitNoteLine escribe una línea de Nota Débito (DebitNoteLine).
-func (s *XMLBuilderService) writeDebitNoteLine(enc *xml.Encoder, lineNumber int, line InvoiceLineForXML) error {
+func (s *XMLBuilderService) writeDebitNoteLine(enc *xml.Encoder, lineNumber int, line InvoiceLineForXML, currency string) error {
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "DebitNoteLine"}})
 
 	writeCbc(enc, "ID", strconv.Itoa(lineNumber))
@@ -352,7 +433,10 @@ func (s *XMLBuilderService) writeDebitNoteLine(enc *xml.Encoder, lineNumber int,
 	_ = enc.EncodeToken(xml.CharData(line.Quantity.String()))
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCbc, Local: "DebitedQuantity"}})
 
-	writeCbcAmount(enc, "LineExtensionAmount", line.Subtotal.Round(2).StringFixed(2), "COP")
+	writeCbcAmount(enc, "LineExtensionAmount", line.Subtotal.Round(2).StringFixed(2), currency)
+	// En las líneas de las notas UBL ubica cac:TaxTotal antes de cac:AllowanceCharge.
+	writeLineTaxTotal(enc, line, line.UnitCode, currency)
+	writeLineAllowanceCharges(enc, line, currency)
 
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Item"}})
 	writeCbc(enc, "Description", line.ProductName)
@@ -362,7 +446,7 @@ func (s *XMLBuilderService) writeDebitNoteLine(enc *xml.Encoder, lineNumber int,
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Item"}})
 
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Price"}})
-	writeCbcAmount(enc, "PriceAmount", line.UnitPrice.Round(2).StringFixed(2), "COP")
+	writeCbcAmount(enc, "PriceAmount", line.UnitPrice.Round(2).StringFixed(2), currency)
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Price"}})
 
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "DebitNoteLine"}})
@@ -407,7 +491,7 @@ func (s *XMLBuilderService) writeCustomerParty(enc *xml.Encoder, ctx *InvoiceBui
 		Name: xml.Name{Space: NsCbc, Local: "ID"},
 		Attr: []xml.Attr{{Name: xml.Name{Local: "schemeID"}, Value: schemeIDFromCode(ctx.CustomerIdentificationTypeCode)}},
 	})
-	_ = enc.EncodeToken(xml.CharData(normalizeNIT(ctx.Customer.TaxID)))
+	_ = enc.EncodeToken(xml.CharData(customerIdentification(ctx.Customer.TaxID, ctx.CustomerIdentificationTypeCode)))
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCbc, Local: "ID"}})
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PartyIdentification"}})
 
@@ -415,6 +499,17 @@ func (s *XMLBuilderService) writeCustomerParty(enc *xml.Encoder, ctx *InvoiceBui
 	writeCbc(enc, "Name", ctx.Customer.Name)
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PartyName"}})
 
+	// Cliente del exterior: país de residencia (obligatorio en exportaciones).
+	if country := ctx.Customer.CountryCode; country != "" && country != "CO" {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PhysicalLocation"}})
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Address"}})
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Country"}})
+		writeCbc(enc, "IdentificationCode", country)
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Country"}})
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Address"}})
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PhysicalLocation"}})
+	}
+
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Party"}})
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "AccountingCustomerParty"}})
 	return nil
@@ -425,49 +520,233 @@ func (s *XMLBuilderService) writePaymentMeans(enc *xml.Encoder, ctx *InvoiceBuil
 	if form == "" {
 		form = dian.PaymentFormContado
 	}
-	method := ctx.PaymentMethodCode
-	if method == "" {
-		method = dian.PaymentMethodEfectivo
+	methods := ctx.PaymentMethodCodes
+	if len(methods) == 0 && ctx.PaymentMethodCode != "" {
+		methods = []string{ctx.PaymentMethodCode}
+	}
+	if len(methods) == 0 {
+		methods = []string{dian.PaymentMethodEfectivo}
 	}
-	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PaymentMeans"}})
-	writeCbc(enc, "PaymentMeansCode", method)
-	if ctx.DueDate != nil && form == dian.PaymentFormCredito {
-		writeCbc(enc, "PaymentDueDate", ctx.DueDate.Format("2006-01-02"))
+	// Un cac:PaymentMeans por medio de pago; cbc:ID lleva la forma de pago (1 contado, 2 crédito).
+	for _, method := range methods {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PaymentMeans"}})
+		writeCbc(enc, "ID", form)
+		writeCbc(enc, "PaymentMeansCode", method)
+		if ctx.DueDate != nil && form == dian.PaymentFormCredito {
+			writeCbc(enc, "PaymentDueDate", ctx.DueDate.Format("2006-01-02"))
+		}
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PaymentMeans"}})
+	}
+}
+
+// writePaymentTerms escribe el plan de cuotas de una venta a crédito: un cac:PaymentTerms por cuota
+// con su valor y fecha de vencimiento.
+func writePaymentTerms(enc *xml.Encoder, installments []*entity.InvoiceInstallment, currency string) {
+	for _, inst := range installments {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PaymentTerms"}})
+		writeCbc(enc, "ID", strconv.Itoa(inst.Number))
+		writeCbcAmount(enc, "Amount", formatDecimal(inst.Amount), currency)
+		writeCbc(enc, "InstallmentDueDate", inst.DueDate.Format("2006-01-02"))
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PaymentTerms"}})
 	}
-	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PaymentMeans"}})
 }
 
+// writeTaxTotal escribe un cac:TaxTotal por tributo (IVA, INC, bolsas…) con un cac:TaxSubtotal por
+// tarifa, a partir del desglose de impuestos del documento (o de sus líneas si no viene agrupado).
 func (s *XMLBuilderService) writeTaxTotal(enc *xml.Encoder, ctx *InvoiceBuildContext) error {
-	percent := "19"
-	if ctx.Invoice.NetTotal.IsPositive() {
-		pct := ctx.Invoice.TaxTotal.Div(ctx.Invoice.NetTotal).Mul(decimal.NewFromInt(100)).Round(0)
-		percent = pct.String()
-	}
-	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "TaxTotal"}})
-	writeCbcAmount(enc, "TaxAmount", formatDecimal(ctx.Invoice.TaxTotal), "COP")
-	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "TaxSubtotal"}})
-	writeCbcAmount(enc, "TaxableAmount", formatDecimal(ctx.Invoice.NetTotal), "COP")
-	writeCbcAmount(enc, "TaxAmount", formatDecimal(ctx.Invoice.TaxTotal), "COP")
-	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "TaxCategory"}})
-	writeCbc(enc, "ID", dian.TaxCodeIVA)
-	writeCbc(enc, "Percent", percent)
-	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "TaxCategory"}})
-	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "TaxSubtotal"}})
-	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "TaxTotal"}})
+	taxes := ctx.Taxes
+	if len(taxes) == 0 {
+		for _, line := range ctx.Details {
+			taxes = append(taxes, lineTaxes(line)...)
+		}
+	}
+	writeTaxTotals(enc, domdian.GroupTaxes(taxes), "", documentCurrency(ctx))
 	return nil
 }
 
+// lineTaxes impuestos de una línea; sin desglose se asume un IVA con la tarifa del detalle.
+func lineTaxes(line InvoiceLineForXML) []*entity.InvoiceTax {
+	if len(line.Taxes) > 0 {
+		return line.Taxes
+	}
+	rate := domdian.PercentRate(line.TaxRate)
+	return []*entity.InvoiceTax{{
+		TaxCode:    dian.TaxCodeIVA,
+		BaseAmount: line.Subtotal.Round(2),
+		Rate:       rate,
+		Amount:     line.Subtotal.Mul(rate).Div(decimal.NewFromInt(100)).Round(2),
+	}}
+}
+
+// writeLineTaxTotal escribe los cac:TaxTotal de una línea (antes de cac:Item).
+func writeLineTaxTotal(enc *xml.Encoder, line InvoiceLineForXML, unitCode, currency string) {
+	writeTaxTotals(enc, domdian.GroupTaxes(lineTaxes(line)), unitCode, currency)
+}
+
+// documentCurrency moneda del documento (cbc:DocumentCurrencyCode y @currencyID); COP por defecto.
+func documentCurrency(ctx *InvoiceBuildContext) string {
+	if ctx.Invoice.CurrencyCode == "" {
+		return dian.CurrencyCOP
+	}
+	return ctx.Invoice.CurrencyCode
+}
+
+// invoiceTypeCode tipo de factura (Tabla 13.1.3): 01 venta nacional por defecto, 02 exportación, 03 contingencia.
+func invoiceTypeCode(inv *entity.Invoice) string {
+	if inv.InvoiceTypeCode == "" {
+		return dian.InvoiceTypeVenta
+	}
+	return inv.InvoiceTypeCode
+}
+
+// writeDeliveryTerms escribe la condición de entrega (Incoterm) de una factura de exportación.
+func writeDeliveryTerms(enc *xml.Encoder, incoterm string) {
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "DeliveryTerms"}})
+	writeCbc(enc, "LossRiskResponsibilityCode", incoterm)
+	if desc, ok := dian.ValidIncoterms[incoterm]; ok {
+		writeCbc(enc, "LossRisk", desc)
+	}
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "DeliveryTerms"}})
+}
+
+// writePaymentExchangeRate escribe la TRM del documento: unidades de COP por unidad de la moneda
+// del documento en la fecha de emisión.
+func writePaymentExchangeRate(enc *xml.Encoder, currency string, rate decimal.Decimal, date time.Time) {
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "PaymentExchangeRate"}})
+	writeCbc(enc, "SourceCurrencyCode", currency)
+	writeCbc(enc, "SourceCurrencyBaseRate", "1.00")
+	writeCbc(enc, "TargetCurrencyCode", dian.CurrencyCOP)
+	writeCbc(enc, "TargetCurrencyBaseRate", "1.00")
+	writeCbc(enc, "CalculationRate", formatDecimal(rate))
+	writeCbc(enc, "Date", date.Format("2006-01-02"))
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "PaymentExchangeRate"}})
+}
+
+// writeLineAllowanceCharges escribe los descuentos de una línea (antes de su cac:TaxTotal en la factura,
+// después en las notas).
+func writeLineAllowanceCharges(enc *xml.Encoder, line InvoiceLineForXML, currency string) {
+	for i, ac := range line.AllowanceCharges {
+		writeAllowanceCharge(enc, i+1, ac, false, currency)
+	}
+}
+
+// writeAllowanceCharge escribe un cac:AllowanceCharge. El código de descuento (Tabla 13.3.8) solo se
+// informa en los descuentos globales; MultiplierFactorNumeric es el porcentaje aplicado a BaseAmount.
+func writeAllowanceCharge(enc *xml.Encoder, id int, ac *entity.InvoiceAllowanceCharge, global bool, currency string) {
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "AllowanceCharge"}})
+	writeCbc(enc, "ID", strconv.Itoa(id))
+	writeCbc(enc, "ChargeIndicator", strconv.FormatBool(ac.ChargeIndicator))
+	if global && !ac.ChargeIndicator && ac.ReasonCode != "" {
+		writeCbc(enc, "AllowanceChargeReasonCode", ac.ReasonCode)
+	}
+	if ac.Reason != "" {
+		writeCbc(enc, "AllowanceChargeReason", ac.Reason)
+	}
+	rate := ac.Rate
+	if !rate.IsPositive() && ac.BaseAmount.IsPositive() {
+		rate = ac.Amount.Mul(decimal.NewFromInt(100)).Div(ac.BaseAmount)
+	}
+	writeCbc(enc, "MultiplierFactorNumeric", formatDecimal(rate))
+	writeCbcAmount(enc, "Amount", formatDecimal(ac.Amount), currency)
+	writeCbcAmount(enc, "BaseAmount", formatDecimal(ac.BaseAmount), currency)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "AllowanceCharge"}})
+}
+
+// writeTaxTotals escribe los grupos de impuestos. Los impuestos porcentuales llevan TaxableAmount y
+// Percent; los impuestos por unidad llevan BaseUnitMeasure y PerUnitAmount con base gravable 0.
+// unitCode es la unidad de la línea para BaseUnitMeasure (vacío = unidad genérica).
+func writeTaxTotals(enc *xml.Encoder, totals []domdian.TaxTotal, unitCode, currency string) {
+	writeTaxTotalElements(enc, "TaxTotal", totals, unitCode, currency)
+}
+
+// writeWithholdingTaxTotal escribe un cac:WithholdingTaxTotal por tributo de retención
+// (05 ReteIVA, 06 ReteFuente, 07 ReteICA) con la misma estructura de subtotales que cac:TaxTotal.
+func writeWithholdingTaxTotal(enc *xml.Encoder, withholdings []*entity.InvoiceWithholding, currency string) {
+	if len(withholdings) == 0 {
+		return
+	}
+	writeTaxTotalElements(enc, "WithholdingTaxTotal", domdian.GroupWithholdings(withholdings), "", currency)
+}
+
+// writeTaxTotalElements escribe los grupos de tributos bajo el elemento indicado (TaxTotal o
+// WithholdingTaxTotal).
+func writeTaxTotalElements(enc *xml.Encoder, element string, totals []domdian.TaxTotal, unitCode, currency string) {
+	if unitCode == "" {
+		unitCode = dian.UnitUnit
+	}
+	for _, total := range totals {
+		_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: element}})
+		writeCbcAmount(enc, "TaxAmount", formatDecimal(total.Amount), currency)
+		for _, sub := range total.Subtotals {
+			_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "TaxSubtotal"}})
+			writeCbcAmount(enc, "TaxableAmount", formatDecimal(sub.BaseAmount), currency)
+			writeCbcAmount(enc, "TaxAmount", formatDecimal(sub.Amount), currency)
+			if sub.IsPerUnit() {
+				writeCbcWithAttr(enc, "BaseUnitMeasure", formatDecimal(sub.Quantity), "unitCode", unitCode)
+				writeCbcAmount(enc, "PerUnitAmount", formatDecimal(sub.PerUnitAmount), currency)
+			}
+			_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "TaxCategory"}})
+			if !sub.IsPerUnit() {
+				writeCbc(enc, "Percent", formatDecimal(sub.Rate))
+			}
+			_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "TaxScheme"}})
+			writeCbc(enc, "ID", total.TaxCode)
+			writeCbc(enc, "Name", taxSchemeName(total.TaxCode))
+			_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "TaxScheme"}})
+			_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "TaxCategory"}})
+			_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "TaxSubtotal"}})
+		}
+		_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: element}})
+	}
+}
+
+// taxSchemeName nombre del tributo según la tabla 13.2.2 del Anexo Técnico.
+func taxSchemeName(code string) string {
+	switch code {
+	case dian.TaxCodeIVA:
+		return "IVA"
+	case dian.TaxCodeINC:
+		return "INC"
+	case domdian.CodImpICA:
+		return "ICA"
+	case dian.TaxCodeINCBolsas:
+		return "INC Bolsas"
+	case dian.TaxCodeReteIVA:
+		return "ReteIVA"
+	case dian.TaxCodeReteFuente:
+		return "ReteRenta"
+	case dian.TaxCodeReteICA:
+		return "ReteICA"
+	default:
+		return code
+	}
+}
+
+// writeLegalMonetaryTotal escribe los totales del documento: cac:LegalMonetaryTotal, o
+// cac:RequestedMonetaryTotal en la nota débito.
 func (s *XMLBuilderService) writeLegalMonetaryTotal(enc *xml.Encoder, ctx *InvoiceBuildContext) error {
-	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "LegalMonetaryTotal"}})
-	writeCbcAmount(enc, "LineExtensionAmount", formatDecimal(ctx.Invoice.NetTotal), "COP")
-	writeCbcAmount(enc, "TaxExclusiveAmount", formatDecimal(ctx.Invoice.NetTotal), "COP")
-	writeCbcAmount(enc, "TaxInclusiveAmount", formatDecimal(ctx.Invoice.GrandTotal), "COP")
-	writeCbcAmount(enc, "PayableAmount", formatDecimal(ctx.Invoice.GrandTotal), "COP")
-	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "LegalMonetaryTotal"}})
+	currency := documentCurrency(ctx)
+	element := "LegalMonetaryTotal"
+	if ctx.DocumentType == "DEBIT_NOTE" {
+		element = "RequestedMonetaryTotal"
+	}
+	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: element}})
+	writeCbcAmount(enc, "LineExtensionAmount", formatDecimal(ctx.Invoice.NetTotal), currency)
+	writeCbcAmount(enc, "TaxExclusiveAmount", formatDecimal(ctx.Invoice.NetTotal), currency)
+	// TaxInclusiveAmount = bruto + impuestos; PayableAmount = TaxInclusive − descuentos + cargos globales.
+	writeCbcAmount(enc, "TaxInclusiveAmount", formatDecimal(ctx.Invoice.NetTotal.Add(ctx.Invoice.TaxTotal)), currency)
+	if ctx.Invoice.AllowanceTotal.IsPositive() {
+		writeCbcAmount(enc, "AllowanceTotalAmount", formatDecimal(ctx.Invoice.AllowanceTotal), currency)
+	}
+	if ctx.Invoice.ChargeTotal.IsPositive() {
+		writeCbcAmount(enc, "ChargeTotalAmount", formatDecimal(ctx.Invoice.ChargeTotal), currency)
+	}
+	writeCbcAmount(enc, "PayableAmount", formatDecimal(ctx.Invoice.GrandTotal), currency)
+	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: element}})
 	return nil
 }
 
-func (s *XMLBuilderService) writeInvoiceLine(enc *xml.Encoder, lineNum int, line InvoiceLineForXML) error {
+func (s *XMLBuilderService) writeInvoiceLine(enc *xml.Encoder, lineNum int, line InvoiceLineForXML, currency string) error {
 	unitCode := line.UnitCode
 	if unitCode == "" {
 		unitCode = dian.UnitUnit
@@ -475,7 +754,9 @@ func (s *XMLBuilderService) writeInvoiceLine(enc *xml.Encoder, lineNum int, line
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "InvoiceLine"}})
 	writeCbc(enc, "ID", strconv.Itoa(lineNum))
 	writeCbcWithAttr(enc, "InvoicedQuantity", formatDecimal(line.Quantity), "unitCode", unitCode)
-	writeCbcAmount(enc, "LineExtensionAmount", formatDecimal(line.Subtotal), "COP")
+	writeCbcAmount(enc, "LineExtensionAmount", formatDecimal(line.Subtotal), currency)
+	writeLineAllowanceCharges(enc, line, currency)
+	writeLineTaxTotal(enc, line, unitCode, currency)
 
 	// cac:Item
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Item"}})
@@ -493,7 +774,7 @@ func (s *XMLBuilderService) writeInvoiceLine(enc *xml.Encoder, lineNum int, line
 
 	// cac:Price
 	_ = enc.EncodeToken(xml.StartElement{Name: xml.Name{Space: NsCac, Local: "Price"}})
-	writeCbcAmount(enc, "PriceAmount", formatDecimal(line.UnitPrice), "COP")
+	writeCbcAmount(enc, "PriceAmount", formatDecimal(line.UnitPrice), currency)
 	writeCbcWithAttr(enc, "BaseQuantity", "1", "unitCode", unitCode)
 	_ = enc.EncodeToken(xml.EndElement{Name: xml.Name{Space: NsCac, Local: "Price"}})
 
@@ -502,15 +783,27 @@ func (s *XMLBuilderService) writeInvoiceLine(enc *xml.Encoder, lineNum int, line
 }
 
 func schemeIDFromCode(code string) string {
-	if code == "31" {
-		return "31"
-	}
-	if code == "13" {
-		return "13"
+	if dian.ValidIdentificationTypes[code] {
+		return code
 	}
 	return "31"
 }
 
+// customerIdentification NIT y cédula se envían solo con dígitos; los documentos extranjeros
+// (pasaporte, NIT de otro país, etc.) conservan letras y números.
+func customerIdentification(taxID, code string) string {
+	if code == "" || code == dian.IdentificationTypeNIT || code == dian.IdentificationTypeCC {
+		return normalizeNIT(taxID)
+	}
+	var out []byte
+	for _, b := range []byte(taxID) {
+		if (b >= '0' && b <= '9') || (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') {
+			out = append(out, b)
+		}
+	}
+	return string(out)
+}
+
 func normalizeNIT(nit string) string {
 	var out []byte
 	for _, b := range []byte(nit) {
@@ -0,0 +1,124 @@
+package dian
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// xmllintErrorRe línea de error de xmllint sobre la entrada estándar: "-:12: Schemas validity error : …".
+var xmllintErrorRe = regexp.MustCompile(`^-:(\d+): [^:]*error : (.*)$`)
+
+// xmllintSchema valida con xmllint (libxml2) contra los esquemas oficiales UBL 2.1: el directorio xsd/ del
+// paquete os-UBL-2.1 de OASIS (ver scripts/fetch-ubl-xsd.sh).
+//
+// Costo: cada documento lanza un proceso xmllint (fork/exec) que vuelve a leer y compilar el juego completo
+// de XSD de UBL (maindoc/ más common/, varios MB) antes de validar; los esquemas no quedan en memoria entre
+// documentos. Lo pagan todos los documentos que firma el orquestador, incluidos los tiquetes POS en
+// mostrador, y se suma a la latencia de la venta. Si ese costo no es aceptable, despliegue sin los esquemas
+// (imagen construida sin UBL_ZIP_SHA256): la API arranca sin validación local y la DIAN sigue validando
+// al recibir.
+type xmllintSchema struct {
+	bin string
+	dir string
+}
+
+func newXMLLintSchema(dir string) (*xmllintSchema, error) {
+	bin, err := exec.LookPath("xmllint")
+	if err != nil {
+		return nil, fmt.Errorf("dian: xmllint (libxml2) no está instalado: %w", err)
+	}
+	v := &xmllintSchema{bin: bin, dir: dir}
+	if _, err := v.schemaFor("Invoice"); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// schemaFor ruta del esquema del documento: <dir>/maindoc/UBL-<raíz>-2.1.xsd, como en el paquete de OASIS,
+// o <dir>/UBL-<raíz>-2.1.xsd.
+func (v *xmllintSchema) schemaFor(root string) (string, error) {
+	name := "UBL-" + root + "-2.1.xsd"
+	for _, p := range []string{filepath.Join(v.dir, "maindoc", name), filepath.Join(v.dir, name)} {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("dian: no se encontró el esquema %s en %s", name, v.dir)
+}
+
+// Validate devuelve los errores de esquema del documento con su línea. La extensión UBL reservada para la
+// firma se omite mientras esté vacía: UBL exige contenido en ext:ExtensionContent y la validación corre
+// antes de firmar. El error indica un fallo de xmllint o de los esquemas, no del documento; si ctx termina
+// se cancela xmllint.
+func (v *xmllintSchema) Validate(ctx context.Context, doc *etree.Document) ([]string, error) {
+	schema, err := v.schemaFor(doc.Root().Tag)
+	if err != nil {
+		return nil, err
+	}
+	doc = doc.Copy()
+	dropEmptyExtensions(doc.Root())
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("dian: serializar documento para xmllint: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, v.bin, "--noout", "--nonet", "--schema", schema, "-")
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err == nil {
+		return nil, nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("dian: xmllint: %w", ctxErr)
+	}
+	// xmllint sale con 3 o 4 cuando el documento no es válido; otro código es un fallo propio o del esquema.
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || (exitErr.ExitCode() != 3 && exitErr.ExitCode() != 4) {
+		return nil, fmt.Errorf("dian: xmllint: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var errs []string
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, "fails to validate") {
+			continue
+		}
+		if m := xmllintErrorRe.FindStringSubmatch(line); m != nil {
+			line = "línea " + m[1] + ": " + m[2]
+		}
+		errs = append(errs, line)
+	}
+	if len(errs) == 0 {
+		errs = append(errs, "el documento no cumple el esquema UBL 2.1")
+	}
+	return errs, nil
+}
+
+// dropEmptyExtensions quita de la raíz los ext:UBLExtension sin contenido y, si no queda ninguno,
+// ext:UBLExtensions.
+func dropEmptyExtensions(root *etree.Element) {
+	for _, exts := range root.ChildElements() {
+		if exts.Tag != "UBLExtensions" || exts.NamespaceURI() != NsExt {
+			continue
+		}
+		for _, ext := range exts.ChildElements() {
+			content := ext.SelectElement("ExtensionContent")
+			if content != nil && len(content.ChildElements()) == 0 {
+				exts.RemoveChild(ext)
+			}
+		}
+		if len(exts.ChildElements()) == 0 {
+			root.RemoveChild(exts)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package dian
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/shopspring/decimal"
+
+	domaindian "github.com/jhoicas/Inventario-api/internal/domain/dian"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+// maxSchemaErrors errores de esquema que se reportan como reglas; el resto se resume en uno.
+const maxSchemaErrors = 20
+
+// XMLValidatorService valida el XML UBL 2.1 generado por XMLBuilderService antes de firmarlo: esquema,
+// con xmllint contra los XSD oficiales de UBL 2.1 de OASIS, y reglas de negocio DIAN
+// (domain/dian.CheckDocumentRules). El esquema se valida en un proceso xmllint por documento (ver el costo
+// en xmllintSchema).
+type XMLValidatorService struct {
+	xmllint *xmllintSchema
+}
+
+// NewXMLValidatorService crea el servicio sobre schemaDir, el directorio xsd/ del paquete os-UBL-2.1 de
+// OASIS (scripts/fetch-ubl-xsd.sh). Falla si schemaDir está vacío o no tiene los esquemas, o si xmllint
+// (libxml2) no está instalado.
+func NewXMLValidatorService(schemaDir string) (*XMLValidatorService, error) {
+	if schemaDir == "" {
+		return nil, fmt.Errorf("dian: falta el directorio de los XSD oficiales de UBL 2.1")
+	}
+	v, err := newXMLLintSchema(schemaDir)
+	if err != nil {
+		return nil, err
+	}
+	return &XMLValidatorService{xmllint: v}, nil
+}
+
+// Validate devuelve las reglas que incumple el documento (vacío si es válido). Los errores de esquema
+// llevan el código domaindian.RuleSchema. El error indica un fallo de los esquemas o de xmllint (o que
+// ctx terminó), no del documento.
+func (s *XMLValidatorService) Validate(ctx context.Context, xmlBytes []byte) ([]entity.DIANValidationRule, error) {
+	rules := make([]entity.DIANValidationRule, 0)
+	schemaRule := func(msg string) {
+		rules = append(rules, entity.DIANValidationRule{Code: domaindian.RuleSchema, Description: msg, Severity: entity.DIANRuleError})
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(xmlBytes); err != nil || doc.Root() == nil {
+		schemaRule(fmt.Sprintf("XML mal formado: %v", err))
+		return rules, nil
+	}
+	schemaErrs, err := s.xmllint.Validate(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, msg := range schemaErrs {
+		if i == maxSchemaErrors {
+			schemaRule(fmt.Sprintf("… y %d error(es) de esquema más", len(schemaErrs)-maxSchemaErrors))
+			break
+		}
+		schemaRule(msg)
+	}
+
+	vdoc, err := parseValidationDocument(xmlBytes)
+	if err != nil {
+		schemaRule(err.Error())
+		return rules, nil
+	}
+	return append(rules, domaindian.CheckDocumentRules(vdoc)...), nil
+}
+
+type identifierXML struct {
+	Value    string `xml:",chardata"`
+	SchemeID string `xml:"schemeID,attr"`
+}
+
+type quantityXML struct {
+	Value string `xml:",chardata"`
+}
+
+type allowanceChargeXML struct {
+	ChargeIndicator string    `xml:"ChargeIndicator"`
+	Amount          amountXML `xml:"Amount"`
+}
+
+type taxCategoryXML struct {
+	Percent       string    `xml:"Percent"`
+	BaseUnit      string    `xml:"BaseUnitMeasure"`
+	PerUnitAmount amountXML `xml:"PerUnitAmount"`
+	TaxSchemeID   string    `xml:"TaxScheme>ID"`
+}
+
+type validationTaxTotalXML struct {
+	TaxAmount   amountXML `xml:"TaxAmount"`
+	TaxSubtotal []struct {
+		TaxableAmount amountXML      `xml:"TaxableAmount"`
+		TaxAmount     amountXML      `xml:"TaxAmount"`
+		Percent       string         `xml:"Percent"`
+		BaseUnit      string         `xml:"BaseUnitMeasure"`
+		PerUnitAmount amountXML      `xml:"PerUnitAmount"`
+		TaxCategory   taxCategoryXML `xml:"TaxCategory"`
+	} `xml:"TaxSubtotal"`
+}
+
+type monetaryTotalXML struct {
+	LineExtension  amountXML `xml:"LineExtensionAmount"`
+	TaxExclusive   amountXML `xml:"TaxExclusiveAmount"`
+	TaxInclusive   amountXML `xml:"TaxInclusiveAmount"`
+	AllowanceTotal amountXML `xml:"AllowanceTotalAmount"`
+	ChargeTotal    amountXML `xml:"ChargeTotalAmount"`
+	Prepaid        amountXML `xml:"PrepaidAmount"`
+	Payable        amountXML `xml:"PayableAmount"`
+}
+
+type validationLineXML struct {
+	ID               string                  `xml:"ID"`
+	InvoicedQuantity quantityXML             `xml:"InvoicedQuantity"`
+	CreditedQuantity quantityXML             `xml:"CreditedQuantity"`
+	DebitedQuantity  quantityXML             `xml:"DebitedQuantity"`
+	LineExtension    amountXML               `xml:"LineExtensionAmount"`
+	AllowanceCharges []allowanceChargeXML    `xml:"AllowanceCharge"`
+	TaxTotals        []validationTaxTotalXML `xml:"TaxTotal"`
+	PriceAmount      amountXML               `xml:"Price>PriceAmount"`
+	BaseQuantity     quantityXML             `xml:"Price>BaseQuantity"`
+}
+
+type validationXML struct {
+	XMLName    xml.Name
+	Extensions []struct {
+		Control *struct {
+			Authorization string `xml:"InvoiceAuthorization"`
+			StartDate     string `xml:"AuthorizationPeriod>StartDate"`
+			EndDate       string `xml:"AuthorizationPeriod>EndDate"`
+			Prefix        string `xml:"AuthorizedInvoices>Prefix"`
+			From          string `xml:"AuthorizedInvoices>From"`
+			To            string `xml:"AuthorizedInvoices>To"`
+		} `xml:"ExtensionContent>DianExtensions>InvoiceControl"`
+	} `xml:"UBLExtensions>UBLExtension"`
+	ProfileExecutionID string                  `xml:"ProfileExecutionID"`
+	ID                 string                  `xml:"ID"`
+	UUID               string                  `xml:"UUID"`
+	IssueDate          string                  `xml:"IssueDate"`
+	IssueTime          string                  `xml:"IssueTime"`
+	InvoiceTypeCode    string                  `xml:"InvoiceTypeCode"`
+	Currency           string                  `xml:"DocumentCurrencyCode"`
+	LineCountNumeric   string                  `xml:"LineCountNumeric"`
+	BillingReferenceID string                  `xml:"BillingReference>InvoiceDocumentReference>ID"`
+	Supplier           identifierXML           `xml:"AccountingSupplierParty>Party>PartyIdentification>ID"`
+	Customer           identifierXML           `xml:"AccountingCustomerParty>Party>PartyIdentification>ID"`
+	AllowanceCharges   []allowanceChargeXML    `xml:"AllowanceCharge"`
+	TaxTotals          []validationTaxTotalXML `xml:"TaxTotal"`
+	LegalTotal         *monetaryTotalXML       `xml:"LegalMonetaryTotal"`
+	RequestedTotal     *monetaryTotalXML       `xml:"RequestedMonetaryTotal"`
+	InvoiceLines       []validationLineXML     `xml:"InvoiceLine"`
+	CreditNoteLines    []validationLineXML     `xml:"CreditNoteLine"`
+	DebitNoteLines     []validationLineXML     `xml:"DebitNoteLine"`
+}
+
+// parseValidationDocument extrae del XML los datos que revisan las reglas de negocio locales.
+func parseValidationDocument(xmlBytes []byte) (*domaindian.ValidationDocument, error) {
+	var x validationXML
+	if err := xml.Unmarshal(xmlBytes, &x); err != nil {
+		return nil, fmt.Errorf("XML ilegible: %v", err)
+	}
+	doc := &domaindian.ValidationDocument{
+		Root:               x.XMLName.Local,
+		ID:                 strings.TrimSpace(x.ID),
+		UUID:               strings.TrimSpace(x.UUID),
+		ProfileExecutionID: strings.TrimSpace(x.ProfileExecutionID),
+		IssueDate:          strings.TrimSpace(x.IssueDate),
+		IssueTime:          strings.TrimSpace(x.IssueTime),
+		TypeCode:           strings.TrimSpace(x.InvoiceTypeCode),
+		Currency:           strings.TrimSpace(x.Currency),
+		LineCountNumeric:   strings.TrimSpace(x.LineCountNumeric),
+		SupplierID:         strings.TrimSpace(x.Supplier.Value),
+		SupplierScheme:     strings.TrimSpace(x.Supplier.SchemeID),
+		CustomerID:         strings.TrimSpace(x.Customer.Value),
+		CustomerScheme:     strings.TrimSpace(x.Customer.SchemeID),
+		BillingReferenceID: strings.TrimSpace(x.BillingReferenceID),
+		TaxTotals:          validationTaxTotals(x.TaxTotals),
+	}
+	for _, ext := range x.Extensions {
+		if c := ext.Control; c != nil {
+			doc.Resolution = &domaindian.ValidationResolution{
+				Number:    strings.TrimSpace(c.Authorization),
+				Prefix:    strings.TrimSpace(c.Prefix),
+				From:      strings.TrimSpace(c.From),
+				To:        strings.TrimSpace(c.To),
+				StartDate: strings.TrimSpace(c.StartDate),
+				EndDate:   strings.TrimSpace(c.EndDate),
+			}
+			break
+		}
+	}
+	doc.Allowances, doc.Charges = sumAllowanceCharges(x.AllowanceCharges)
+
+	totals := x.LegalTotal
+	if x.XMLName.Local == domaindian.RootDebitNote {
+		totals = x.RequestedTotal
+	}
+	if totals != nil {
+		doc.HasTotals = true
+		doc.Totals = domaindian.ValidationTotals{
+			LineExtension:  parseAmount(totals.LineExtension.Value),
+			TaxExclusive:   parseAmount(totals.TaxExclusive.Value),
+			TaxInclusive:   parseAmount(totals.TaxInclusive.Value),
+			AllowanceTotal: parseAmount(totals.AllowanceTotal.Value),
+			ChargeTotal:    parseAmount(totals.ChargeTotal.Value),
+			Prepaid:        parseAmount(totals.Prepaid.Value),
+			Payable:        parseAmount(totals.Payable.Value),
+		}
+	}
+
+	lines := x.InvoiceLines
+	switch x.XMLName.Local {
+	case domaindian.RootCreditNote:
+		lines = x.CreditNoteLines
+	case domaindian.RootDebitNote:
+		lines = x.DebitNoteLines
+	}
+	for _, l := range lines {
+		quantity := l.InvoicedQuantity.Value
+		if strings.TrimSpace(quantity) == "" {
+			quantity = l.CreditedQuantity.Value + l.DebitedQuantity.Value
+		}
+		line := domaindian.ValidationLine{
+			ID:            strings.TrimSpace(l.ID),
+			Quantity:      parseAmount(quantity),
+			LineExtension: parseAmount(l.LineExtension.Value),
+			PriceAmount:   parseAmount(l.PriceAmount.Value),
+			BaseQuantity:  parseAmount(l.BaseQuantity.Value),
+			TaxTotals:     validationTaxTotals(l.TaxTotals),
+		}
+		line.Allowances, line.Charges = sumAllowanceCharges(l.AllowanceCharges)
+		doc.Lines = append(doc.Lines, line)
+	}
+	return doc, nil
+}
+
+// sumAllowanceCharges suma los descuentos (ChargeIndicator=false) y los cargos.
+func sumAllowanceCharges(acs []allowanceChargeXML) (allowances, charges decimal.Decimal) {
+	for _, ac := range acs {
+		if strings.TrimSpace(ac.ChargeIndicator) == "true" {
+			charges = charges.Add(parseAmount(ac.Amount.Value))
+		} else {
+			allowances = allowances.Add(parseAmount(ac.Amount.Value))
+		}
+	}
+	return allowances, charges
+}
+
+// validationTaxTotals convierte los cac:TaxTotal; Percent, BaseUnitMeasure y PerUnitAmount se leen del
+// TaxSubtotal o, si no vienen ahí, de su TaxCategory.
+func validationTaxTotals(totals []validationTaxTotalXML) []domaindian.ValidationTaxTotal {
+	out := make([]domaindian.ValidationTaxTotal, 0, len(totals))
+	for _, tt := range totals {
+		vt := domaindian.ValidationTaxTotal{TaxAmount: parseAmount(tt.TaxAmount.Value)}
+		for _, st := range tt.TaxSubtotal {
+			sub := domaindian.ValidationTaxSubtotal{
+				TaxCode:       strings.TrimSpace(st.TaxCategory.TaxSchemeID),
+				TaxableAmount: parseAmount(st.TaxableAmount.Value),
+				TaxAmount:     parseAmount(st.TaxAmount.Value),
+			}
+			percent := firstNonEmpty(st.Percent, st.TaxCategory.Percent)
+			perUnit := firstNonEmpty(st.PerUnitAmount.Value, st.TaxCategory.PerUnitAmount.Value)
+			switch {
+			case perUnit != "":
+				amount := parseAmount(perUnit)
+				sub.PerUnitAmount = &amount
+				sub.BaseUnit = parseAmount(firstNonEmpty(st.BaseUnit, st.TaxCategory.BaseUnit))
+			case percent != "":
+				rate := parseAmount(percent)
+				sub.Percent = &rate
+			}
+			vt.Subtotals = append(vt.Subtotals, sub)
+		}
+		out = append(out, vt)
+	}
+	return out
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -40,11 +40,17 @@ var nonDigit = regexp.MustCompile(`[^0-9]`)
 // Formato: {NIT_OFE}{PREFIX}{NUMBER}  (sin DV, solo dígitos del NIT)
 // Ejemplo: 900123456SETP000001
 func DIANFilenames(company *entity.Company, inv *entity.Invoice) (xmlName, zipName string) {
+	return DIANDocumentFilenames(company, inv.Prefix, inv.Number)
+}
+
+// DIANDocumentFilenames igual que DIANFilenames para documentos que no son facturas (documento
+// soporte, eventos): {NIT_OFE}{PREFIX}{NUMBER}.
+func DIANDocumentFilenames(company *entity.Company, prefix, number string) (xmlName, zipName string) {
 	nit := nonDigit.ReplaceAllString(company.NIT, "")
 	// Quitar dígito de verificación si el NIT tiene más de 9 dígitos y termina en "-DV"
 	if idx := strings.Index(nit, "-"); idx != -1 {
 		nit = nit[:idx]
 	}
-	base := nit + strings.TrimSpace(inv.Prefix) + strings.TrimSpace(inv.Number)
+	base := nit + strings.TrimSpace(prefix) + strings.TrimSpace(number)
 	return base + ".xml", base + ".zip"
 }
@@ -8,10 +8,11 @@
 //	│  ─────────────────────────────────────────────────────────  │
 //	│  EMISOR: Dirección / Tel / Email                             │
 //	│  RECEPTOR: Nombre + NIT/CC + contacto                       │
+//	│  PAGO: Forma / Medios / Vencimiento / Cuotas                 │
 //	│  ─────────────────────────────────────────────────────────  │
 //	│  TABLA: Cant | Descripción | P.Unit | IVA | Subtotal         │
 //	│  ─────────────────────────────────────────────────────────  │
-//	│  TOTALES: Subtotal neto / Impuestos / TOTAL A PAGAR          │
+//	│  TOTALES: Subtotal / Impuestos / Desc.-Cargos / TOTAL / Ret. │
 //	│  ─────────────────────────────────────────────────────────  │
 //	│  FOOTER DIAN: CUFE + QR + Leyenda legal                      │
 //	└─────────────────────────────────────────────────────────────┘
@@ -20,6 +21,7 @@ package pdf
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	maroto "github.com/johnfercher/maroto/v2"
 	"github.com/johnfercher/maroto/v2/pkg/components/code"
@@ -36,6 +38,7 @@ import (
 
 	appbilling "github.com/jhoicas/Inventario-api/internal/application/billing"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/pkg/dian"
 )
 
 // ── Paleta de colores ─────────────────────────────────────────────────────────
@@ -78,6 +81,7 @@ func (g *MarotoPDFGenerator) GenerateInvoicePDF(
 	m.AddRows(line.NewRow(1, props.Line{Color: colorPrimary, Thickness: 0.5}))
 	m.AddRows(emisorRow(company))
 	m.AddRows(receptorRow(customer))
+	m.AddRows(paymentRow(invoice))
 	m.AddRows(line.NewRow(1, props.Line{Color: colorPrimary, Thickness: 0.3}))
 
 	// Tabla de detalles
@@ -123,7 +127,7 @@ func headerRow(invoice *entity.Invoice, company *entity.Company) core.Row {
 			}),
 		),
 		col.New(5).Add(
-			text.New("FACTURA ELECTRÓNICA DE VENTA", props.Text{
+			text.New(documentTitle(invoice), props.Text{
 				Style: fontstyle.Bold, Size: 8, Align: align.Right,
 				Color: colorPrimary, Top: 1,
 			}),
@@ -137,6 +141,14 @@ func headerRow(invoice *entity.Invoice, company *entity.Company) core.Row {
 	)
 }
 
+// documentTitle tipo de documento del encabezado; la factura de contingencia se identifica como tal.
+func documentTitle(invoice *entity.Invoice) string {
+	if invoice.IsContingency() {
+		return "FACTURA DE VENTA - CONTINGENCIA (TIPO 03)"
+	}
+	return "FACTURA ELECTRÓNICA DE VENTA"
+}
+
 // emisorRow: datos del emisor (empresa).
 func emisorRow(company *entity.Company) core.Row {
 	return row.New(14).Add(
@@ -172,6 +184,60 @@ func receptorRow(customer *entity.Customer) core.Row {
 	)
 }
 
+// paymentRow: forma y medios de pago; en ventas a crédito, vencimiento y cuotas.
+func paymentRow(invoice *entity.Invoice) core.Row {
+	methods := make([]string, 0, len(invoice.PaymentMethodCodes))
+	for _, code := range invoice.PaymentMethodCodes {
+		methods = append(methods, paymentMethodLabel(code))
+	}
+	if len(methods) == 0 {
+		methods = append(methods, paymentMethodLabel(dian.PaymentMethodEfectivo))
+	}
+	summary := "Forma de pago: Contado   |   Medio: " + strings.Join(methods, ", ")
+	if invoice.IsCredit() {
+		summary = "Forma de pago: Crédito   |   Medio: " + strings.Join(methods, ", ")
+		if invoice.DueDate != nil {
+			summary += "   |   Vence: " + invoice.DueDate.Format("02/01/2006")
+		}
+	}
+	installments := make([]string, 0, len(invoice.Installments))
+	for _, inst := range invoice.Installments {
+		installments = append(installments, fmt.Sprintf("%d) %s $%s",
+			inst.Number, inst.DueDate.Format("02/01/2006"), formatMoney(inst.Amount.StringFixed(0))))
+	}
+	c := col.New(12).Add(
+		text.New("CONDICIONES DE PAGO", props.Text{
+			Style: fontstyle.Bold, Size: 8, Color: colorPrimary, Top: 1,
+		}),
+		text.New(summary, props.Text{Size: 8, Top: 6, Color: colorGray}),
+	)
+	if len(installments) == 0 {
+		return row.New(12).Add(c)
+	}
+	c.Add(text.New("Cuotas: "+strings.Join(installments, "   "), props.Text{Size: 8, Top: 11, Color: colorGray}))
+	return row.New(17).Add(c)
+}
+
+// paymentMethodLabel nombre del medio de pago (Tabla 13 DIAN) para la representación gráfica.
+func paymentMethodLabel(code string) string {
+	switch code {
+	case dian.PaymentMethodEfectivo:
+		return "Efectivo"
+	case dian.PaymentMethodTransferencia, dian.PaymentMethodTransferenciaCred:
+		return "Transferencia"
+	case dian.PaymentMethodTarjetaCredito:
+		return "Tarjeta crédito"
+	case dian.PaymentMethodTarjetaDebito:
+		return "Tarjeta débito"
+	case dian.PaymentMethodCheque:
+		return "Cheque"
+	case dian.PaymentMethodConsignacion:
+		return "Consignación"
+	default:
+		return code
+	}
+}
+
 // tableHeaderRow: cabecera de la tabla de detalles con fondo azul simulado.
 func tableHeaderRow() core.Row {
 	h := func(label string, size int, a align.Type) core.Col {
@@ -193,13 +259,17 @@ func tableHeaderRow() core.Row {
 func tableDetailRows(details []appbilling.InvoiceDetailForPDF) []core.Row {
 	result := make([]core.Row, 0, len(details)*2)
 	for _, d := range details {
+		description := d.ProductName
+		if d.DiscountAmount.IsPositive() {
+			description += " (Desc. -$" + formatMoney(d.DiscountAmount.StringFixed(0)) + ")"
+		}
 		result = append(result, row.New(10).Add(
 			col.New(1).Add(text.New(
 				d.Quantity.StringFixed(0),
 				props.Text{Size: 8, Align: align.Center, Top: 2},
 			)),
 			col.New(5).Add(text.New(
-				d.ProductName,
+				description,
 				props.Text{Size: 8, Align: align.Left, Top: 2, Left: 1},
 			)),
 			col.New(2).Add(text.New(
@@ -221,6 +291,8 @@ func tableDetailRows(details []appbilling.InvoiceDetailForPDF) []core.Row {
 }
 
 // totalsRows: bloque de totales alineado a la derecha usando una fila por total.
+// Los descuentos y cargos globales se muestran antes del total (los de línea van en cada detalle).
+// Si el cliente practica retenciones se listan debajo del total junto con el NETO A PAGAR.
 func totalsRows(invoice *entity.Invoice) []core.Row {
 	labelCol := func(label string, isGrand bool) core.Col {
 		textProps := props.Text{Style: fontstyle.Bold, Size: 9, Align: align.Right, Right: 1}
@@ -240,7 +312,7 @@ func totalsRows(invoice *entity.Invoice) []core.Row {
 		return col.New(2).Add(text.New(value, textProps))
 	}
 
-	return []core.Row{
+	rows := []core.Row{
 		row.New(8).Add(
 			col.New(8),
 			labelCol("Subtotal:", false),
@@ -251,12 +323,82 @@ func totalsRows(invoice *entity.Invoice) []core.Row {
 			labelCol("Impuestos:", false),
 			valueCol("$"+formatMoney(invoice.TaxTotal.StringFixed(0)), false),
 		),
+	}
+	if invoice.AllowanceTotal.IsPositive() {
+		rows = append(rows, row.New(8).Add(
+			col.New(8),
+			labelCol("Descuentos:", false),
+			valueCol("-$"+formatMoney(invoice.AllowanceTotal.StringFixed(0)), false),
+		))
+	}
+	if invoice.ChargeTotal.IsPositive() {
+		rows = append(rows, row.New(8).Add(
+			col.New(8),
+			labelCol("Cargos:", false),
+			valueCol("$"+formatMoney(invoice.ChargeTotal.StringFixed(0)), false),
+		))
+	}
+	rows = append(rows,
 		row.New(2).Add(col.New(8), col.New(4).Add(line.New(props.Line{Color: colorPrimary, Thickness: 0.3}))),
 		row.New(10).Add(
 			col.New(8),
 			labelCol("TOTAL:", true),
 			valueCol("$"+formatMoney(invoice.GrandTotal.StringFixed(0)), true),
 		),
+	)
+	if invoice.IsForeignCurrency() {
+		// Factura en moneda extranjera: TRM aplicada y equivalente en pesos.
+		rows = append(rows,
+			row.New(8).Add(
+				col.New(8),
+				labelCol("Moneda / TRM:", false),
+				valueCol(invoice.CurrencyCode+" / $"+formatMoney(invoice.ExchangeRate.StringFixed(0)), false),
+			),
+			row.New(8).Add(
+				col.New(8),
+				labelCol("TOTAL COP:", false),
+				valueCol("$"+formatMoney(invoice.GrandTotalCOP.StringFixed(0)), false),
+			),
+		)
+	}
+	if !invoice.WithholdingTotal.IsPositive() {
+		return rows
+	}
+	for _, w := range invoice.Withholdings {
+		rows = append(rows, row.New(8).Add(
+			col.New(8),
+			labelCol(withholdingLabel(w.TaxCode)+" "+w.Rate.String()+"%:", false),
+			valueCol("-$"+formatMoney(w.Amount.StringFixed(0)), false),
+		))
+	}
+	if len(invoice.Withholdings) == 0 {
+		rows = append(rows, row.New(8).Add(
+			col.New(8),
+			labelCol("Retenciones:", false),
+			valueCol("-$"+formatMoney(invoice.WithholdingTotal.StringFixed(0)), false),
+		))
+	}
+	return append(rows,
+		row.New(2).Add(col.New(8), col.New(4).Add(line.New(props.Line{Color: colorPrimary, Thickness: 0.3}))),
+		row.New(10).Add(
+			col.New(8),
+			labelCol("NETO A PAGAR:", true),
+			valueCol("$"+formatMoney(invoice.PayableAmount().StringFixed(0)), true),
+		),
+	)
+}
+
+// withholdingLabel nombre corto de la retención para la representación gráfica.
+func withholdingLabel(taxCode string) string {
+	switch taxCode {
+	case dian.TaxCodeReteFuente:
+		return "ReteFuente"
+	case dian.TaxCodeReteIVA:
+		return "ReteIVA"
+	case dian.TaxCodeReteICA:
+		return "ReteICA"
+	default:
+		return "Retención " + taxCode
 	}
 }
 
@@ -284,6 +426,18 @@ func dianFooterRows(invoice *entity.Invoice) []core.Row {
 		}
 	}
 
+	// Factura de contingencia: se entrega antes de transmitirla a la DIAN.
+	if invoice.IsContingency() {
+		rows = append(rows, row.New(8).Add(col.New(12).Add(
+			text.New(
+				"Factura expedida en contingencia (tipo 03) por indisponibilidad de la DIAN. "+
+					"Se transmite a la DIAN dentro de las 48 horas siguientes al restablecimiento del servicio "+
+					"(estado DIAN: "+invoice.DIAN_Status+").",
+				props.Text{Style: fontstyle.Bold, Size: 7, Top: 2},
+			),
+		)))
+	}
+
 	rows = append(rows, row.New(3))
 
 	// QR + leyenda
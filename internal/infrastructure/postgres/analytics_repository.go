@@ -27,6 +27,8 @@ func NewAnalyticsRepository(pool *pgxpool.Pool) *AnalyticsRepo {
 // Fórmula del margen: GrossRevenue - TotalCOGS - CommissionCost - LogisticsCost - DiscountTotal.
 // Las facturas sin canal se consolidan en el grupo "Directo".
 // logistics_cost y discount_total se reparten por factura (una vez por invoice, no por línea).
+// GrossRevenue es antes de descuentos: discount_total ya incluye los descuentos de línea.
+// Los montos de facturas en moneda extranjera se convierten a COP con la TRM guardada en la factura.
 func (r *AnalyticsRepo) GetSalesByChannel(
 	ctx context.Context,
 	companyID string,
@@ -40,11 +42,11 @@ func (r *AnalyticsRepo) GetSalesByChannel(
 	    COALESCE(sc.commission_rate, 0)                                                                  AS commission_rate,
 	    COUNT(DISTINCT i.id)                                                                              AS invoice_count,
 	    SUM(d.quantity)                                                                                   AS units_sold,
-	    SUM(d.subtotal)                                                                                   AS gross_revenue,
+	    SUM((d.subtotal + COALESCE(d.discount_amount, 0)) * COALESCE(i.exchange_rate, 1))                                                  AS gross_revenue,
 	    SUM(d.quantity * p.cost)                                                                          AS total_cogs,
-	    SUM(d.subtotal * COALESCE(sc.commission_rate, 0) / 100)                                           AS commission_cost,
+	    SUM(d.subtotal * COALESCE(i.exchange_rate, 1) * COALESCE(sc.commission_rate, 0) / 100)                                           AS commission_cost,
 	    SUM(COALESCE(i.logistics_cost, 0) / NULLIF((SELECT COUNT(*) FROM invoice_details d2 WHERE d2.invoice_id = i.id), 0)) AS logistics_cost,
-	    SUM(COALESCE(i.discount_total, 0) / NULLIF((SELECT COUNT(*) FROM invoice_details d2 WHERE d2.invoice_id = i.id), 0))  AS discount_total
+	    SUM(COALESCE(i.discount_total, 0) * COALESCE(i.exchange_rate, 1) / NULLIF((SELECT COUNT(*) FROM invoice_details d2 WHERE d2.invoice_id = i.id), 0))  AS discount_total
 	FROM invoices i
 	JOIN invoice_details d ON d.invoice_id = i.id
 	JOIN products       p  ON p.id         = d.product_id
@@ -53,9 +55,9 @@ func (r *AnalyticsRepo) GetSalesByChannel(
 	  AND i.date BETWEEN $2 AND $3
 	  AND i.dian_status NOT IN ('DRAFT', 'ERROR_GENERATION')
 	GROUP BY sc.id, sc.name, sc.channel_type, sc.commission_rate
-	ORDER BY SUM(d.subtotal) - SUM(d.quantity * p.cost) - SUM(d.subtotal * COALESCE(sc.commission_rate, 0) / 100)
+	ORDER BY SUM((d.subtotal + COALESCE(d.discount_amount, 0)) * COALESCE(i.exchange_rate, 1)) - SUM(d.quantity * p.cost) - SUM(d.subtotal * COALESCE(i.exchange_rate, 1) * COALESCE(sc.commission_rate, 0) / 100)
 	       - SUM(COALESCE(i.logistics_cost, 0) / NULLIF((SELECT COUNT(*) FROM invoice_details d2 WHERE d2.invoice_id = i.id), 0))
-	       - SUM(COALESCE(i.discount_total, 0) / NULLIF((SELECT COUNT(*) FROM invoice_details d2 WHERE d2.invoice_id = i.id), 0)) DESC`
+	       - SUM(COALESCE(i.discount_total, 0) * COALESCE(i.exchange_rate, 1) / NULLIF((SELECT COUNT(*) FROM invoice_details d2 WHERE d2.invoice_id = i.id), 0)) DESC`
 
 	rows, err := r.pool.Query(ctx, query, companyID, startDate, endDate)
 	if err != nil {
@@ -97,7 +99,7 @@ func (r *AnalyticsRepo) GetSalesMetrics(
 ) (revenue, cost decimal.Decimal, err error) {
 	const query = `
 	SELECT
-	    COALESCE(SUM(d.subtotal),           0) AS revenue,
+	    COALESCE(SUM(d.subtotal * COALESCE(i.exchange_rate, 1)), 0) AS revenue,
 	    COALESCE(SUM(d.quantity * p.cost),  0) AS cost
 	FROM invoices i
 	JOIN invoice_details d ON d.invoice_id = i.id
@@ -128,12 +130,12 @@ func (r *AnalyticsRepo) GetTopSKUs(
 	    p.sku,
 	    p.name                                      AS product_name,
 	    SUM(d.quantity)                             AS quantity_sold,
-	    SUM(d.subtotal)                             AS total_revenue,
+	    SUM(d.subtotal * COALESCE(i.exchange_rate, 1))                             AS total_revenue,
 	    CASE
-	        WHEN SUM(d.subtotal) > 0
+	        WHEN SUM(d.subtotal * COALESCE(i.exchange_rate, 1)) > 0
 	        THEN ROUND(
-	            (SUM(d.subtotal) - SUM(d.quantity * p.cost))
-	            / SUM(d.subtotal) * 100, 2)
+	            (SUM(d.subtotal * COALESCE(i.exchange_rate, 1)) - SUM(d.quantity * p.cost))
+	            / SUM(d.subtotal * COALESCE(i.exchange_rate, 1)) * 100, 2)
 	        ELSE 0
 	    END                                         AS margin_percentage
 	FROM invoice_details d
@@ -190,9 +192,9 @@ func (r *AnalyticsRepo) GetSKUMargins(
 	    p.sku,
 	    p.name                                        AS product_name,
 	    SUM(d.quantity)                               AS units_sold,
-	    SUM(d.subtotal)                               AS gross_revenue,
+	    SUM(d.subtotal * COALESCE(i.exchange_rate, 1))                               AS gross_revenue,
 	    SUM(d.quantity * p.cost)                      AS total_cogs,
-	    SUM(d.subtotal - d.quantity * p.cost)         AS gross_profit
+	    SUM(d.subtotal * COALESCE(i.exchange_rate, 1) - d.quantity * p.cost) AS gross_profit
 	FROM invoice_details d
 	JOIN invoices i ON i.id  = d.invoice_id
 	JOIN products p ON p.id  = d.product_id
@@ -303,3 +305,82 @@ func (r *AnalyticsRepo) GetRawMaterialImpactRanking(
 	}
 	return results, nil
 }
+
+// GetInventoryAging lista el stock vigente por producto con su última venta y última recepción.
+// Las ventas salen de invoice_details (y de invoice_kit_components para componentes de kits)
+// de facturas y documentos POS válidos; las recepciones, de movimientos IN en inventory_movements.
+func (r *AnalyticsRepo) GetInventoryAging(
+	ctx context.Context,
+	companyID, warehouseID string,
+) ([]repository.InventoryAgingRow, error) {
+	const query = `
+	WITH on_hand AS (
+	    SELECT s.product_id, SUM(s.quantity) AS quantity
+	    FROM stock s
+	    JOIN products p ON p.id = s.product_id
+	    WHERE p.company_id = $1
+	      AND ($2 = '' OR s.warehouse_id::TEXT = $2)
+	    GROUP BY s.product_id
+	    HAVING SUM(s.quantity) > 0
+	),
+	sales AS (
+	    SELECT d.product_id, MAX(i.date) AS last_sale
+	    FROM invoice_details d
+	    JOIN invoices i ON i.id = d.invoice_id
+	    WHERE i.company_id = $1
+	      AND COALESCE(i.document_type, 'INVOICE') IN ('INVOICE', 'POS')
+	      AND i.dian_status NOT IN ('DRAFT', 'ERROR_GENERATION')
+	    GROUP BY d.product_id
+	    UNION ALL
+	    SELECT k.component_product_id, MAX(i.date)
+	    FROM invoice_kit_components k
+	    JOIN invoices i ON i.id = k.invoice_id
+	    WHERE i.company_id = $1
+	      AND i.dian_status NOT IN ('DRAFT', 'ERROR_GENERATION')
+	    GROUP BY k.component_product_id
+	),
+	receipts AS (
+	    SELECT m.product_id, MAX(m.date) AS last_receipt
+	    FROM inventory_movements m
+	    JOIN on_hand oh ON oh.product_id = m.product_id
+	    WHERE m.type = 'IN'
+	      AND ($2 = '' OR m.warehouse_id::TEXT = $2)
+	    GROUP BY m.product_id
+	)
+	SELECT
+	    p.id,
+	    p.sku,
+	    p.name,
+	    oh.quantity,
+	    p.cost,
+	    (SELECT MAX(s.last_sale)::TIMESTAMPTZ FROM sales s WHERE s.product_id = p.id) AS last_sale,
+	    rc.last_receipt
+	FROM on_hand oh
+	JOIN products p ON p.id = oh.product_id
+	LEFT JOIN receipts rc ON rc.product_id = p.id
+	ORDER BY p.sku`
+
+	rows, err := r.pool.Query(ctx, query, companyID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("analytics.GetInventoryAging: %w", err)
+	}
+	defer rows.Close()
+
+	var results []repository.InventoryAgingRow
+	for rows.Next() {
+		var row repository.InventoryAgingRow
+		if err := rows.Scan(
+			&row.ProductID,
+			&row.SKU,
+			&row.ProductName,
+			&row.Quantity,
+			&row.UnitCost,
+			&row.LastSaleAt,
+			&row.LastReceiptAt,
+		); err != nil {
+			return nil, fmt.Errorf("analytics.GetInventoryAging scan: %w", err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
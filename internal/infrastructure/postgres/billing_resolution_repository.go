@@ -28,12 +28,13 @@ func NewBillingResolutionRepository(pool *pgxpool.Pool) *BillingResolutionRepo {
 func (r *BillingResolutionRepo) Create(ctx context.Context, res *entity.BillingResolution) error {
 	const qWithIsActive = `
 		INSERT INTO billing_resolutions
-			(id, company_id, resolution_number, prefix, range_from, range_to, date_from, date_to, environment, is_active, created_at, updated_at)
+			(id, company_id, resolution_number, prefix, range_from, range_to, date_from, date_to, environment, is_active,
+			 document_type, created_at, updated_at)
 		VALUES
-			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, now(), now())`
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE(NULLIF($11, ''), 'INVOICE'), now(), now())`
 	_, err := r.pool.Exec(ctx, qWithIsActive,
 		res.ID, res.CompanyID, res.ResolutionNumber, res.Prefix,
-		res.RangeFrom, res.RangeTo, res.DateFrom, res.DateTo, res.Environment, res.IsActive,
+		res.RangeFrom, res.RangeTo, res.DateFrom, res.DateTo, res.Environment, res.IsActive, res.DocumentType,
 	)
 	if isUndefinedColumnError(err, "is_active") {
 		const qWithoutIsActive = `
@@ -55,13 +56,13 @@ func (r *BillingResolutionRepo) Create(ctx context.Context, res *entity.BillingR
 func (r *BillingResolutionRepo) GetByID(ctx context.Context, id string) (*entity.BillingResolution, error) {
 	const qWithIsActive = `
 		SELECT id, company_id, resolution_number, prefix, range_from, range_to,
-		       date_from, date_to, environment, 0::bigint AS used_numbers, is_active, created_at, updated_at
+		       date_from, date_to, environment, document_type, used_numbers, is_active, created_at, updated_at
 		FROM billing_resolutions WHERE id = $1`
 	res, err := scanResolution(r.pool.QueryRow(ctx, qWithIsActive, id))
 	if isUndefinedColumnError(err, "is_active") {
 		const qWithoutIsActive = `
 			SELECT id, company_id, resolution_number, prefix, range_from, range_to,
-			       date_from, date_to, environment, 0::bigint AS used_numbers, true AS is_active, created_at, updated_at
+			       date_from, date_to, environment, 'INVOICE' AS document_type, 0::bigint AS used_numbers, true AS is_active, created_at, updated_at
 			FROM billing_resolutions WHERE id = $1`
 		res, err = scanResolution(r.pool.QueryRow(ctx, qWithoutIsActive, id))
 	}
@@ -79,7 +80,7 @@ func (r *BillingResolutionRepo) GetByID(ctx context.Context, id string) (*entity
 func (r *BillingResolutionRepo) GetActiveByCompanyAndPrefix(ctx context.Context, companyID, prefix string) (*entity.BillingResolution, error) {
 	const qWithIsActive = `
 		SELECT id, company_id, resolution_number, prefix, range_from, range_to,
-		       date_from, date_to, environment, 0::bigint AS used_numbers, is_active, created_at, updated_at
+		       date_from, date_to, environment, document_type, used_numbers, is_active, created_at, updated_at
 		FROM billing_resolutions
 		WHERE company_id = $1
 		  AND prefix     = $2
@@ -91,7 +92,7 @@ func (r *BillingResolutionRepo) GetActiveByCompanyAndPrefix(ctx context.Context,
 	if isUndefinedColumnError(err, "is_active") {
 		const qWithoutIsActive = `
 			SELECT id, company_id, resolution_number, prefix, range_from, range_to,
-			       date_from, date_to, environment, 0::bigint AS used_numbers, true AS is_active, created_at, updated_at
+			       date_from, date_to, environment, 'INVOICE' AS document_type, 0::bigint AS used_numbers, true AS is_active, created_at, updated_at
 			FROM billing_resolutions
 			WHERE company_id = $1
 			  AND prefix     = $2
@@ -112,29 +113,24 @@ func (r *BillingResolutionRepo) GetActiveByCompanyAndPrefix(ctx context.Context,
 func (r *BillingResolutionRepo) ListByCompany(ctx context.Context, companyID string) ([]*entity.BillingResolution, error) {
 	const qWithIsActive = `
 		SELECT br.id, br.company_id, br.resolution_number, br.prefix, br.range_from, br.range_to,
-		       br.date_from, br.date_to, br.environment,
-		       COALESCE((
-			   SELECT COUNT(1)::bigint
-			   FROM invoices i
-			   WHERE i.company_id = br.company_id
-				 AND i.prefix = br.prefix
-				 AND i.number ~ '^[0-9]+$'
-				 AND i.number::bigint BETWEEN br.range_from AND br.range_to
-		   ), 0) AS used_numbers,
+		       br.date_from, br.date_to, br.environment, br.document_type, br.used_numbers,
 		       br.is_active, br.created_at, br.updated_at
 		FROM billing_resolutions br
 		WHERE br.company_id = $1
 		ORDER BY br.date_from DESC`
 	rows, err := r.pool.Query(ctx, qWithIsActive, companyID)
 	if isUndefinedColumnError(err, "is_active") {
+		// Esquema previo a used_numbers y document_type: todas las resoluciones son de facturas y lo usado
+		// se cuenta como en CountIssuedSince (facturas y POS del prefijo dentro del rango).
 		const qWithoutIsActive = `
 			SELECT br.id, br.company_id, br.resolution_number, br.prefix, br.range_from, br.range_to,
-			       br.date_from, br.date_to, br.environment,
+			       br.date_from, br.date_to, br.environment, 'INVOICE' AS document_type,
 			       COALESCE((
 				   SELECT COUNT(1)::bigint
 				   FROM invoices i
 				   WHERE i.company_id = br.company_id
 					 AND i.prefix = br.prefix
+					 AND COALESCE(i.document_type, 'INVOICE') IN ('INVOICE', 'POS')
 					 AND i.number ~ '^[0-9]+$'
 					 AND i.number::bigint BETWEEN br.range_from AND br.range_to
 			   ), 0) AS used_numbers,
@@ -163,11 +159,12 @@ func (r *BillingResolutionRepo) Update(ctx context.Context, res *entity.BillingR
 	const qWithIsActive = `
 		UPDATE billing_resolutions
 		SET resolution_number = $2, prefix = $3, range_from = $4, range_to = $5,
-		    date_from = $6, date_to = $7, environment = $8, is_active = $9, updated_at = now()
+		    date_from = $6, date_to = $7, environment = $8, is_active = $9,
+		    document_type = COALESCE(NULLIF($10, ''), document_type), updated_at = now()
 		WHERE id = $1`
 	_, err := r.pool.Exec(ctx, qWithIsActive,
 		res.ID, res.ResolutionNumber, res.Prefix,
-		res.RangeFrom, res.RangeTo, res.DateFrom, res.DateTo, res.Environment, res.IsActive,
+		res.RangeFrom, res.RangeTo, res.DateFrom, res.DateTo, res.Environment, res.IsActive, res.DocumentType,
 	)
 	if isUndefinedColumnError(err, "is_active") {
 		const qWithoutIsActive = `
@@ -199,7 +196,7 @@ func scanResolution(row pgxScanner) (*entity.BillingResolution, error) {
 		&res.ID, &res.CompanyID, &res.ResolutionNumber, &res.Prefix,
 		&res.RangeFrom, &res.RangeTo,
 		&res.DateFrom, &res.DateTo,
-		&res.Environment, &res.UsedNumbers,
+		&res.Environment, &res.DocumentType, &res.UsedNumbers,
 		&res.IsActive, &res.CreatedAt, &res.UpdatedAt,
 	)
 	if err != nil {
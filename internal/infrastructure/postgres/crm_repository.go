@@ -275,8 +275,8 @@ func (r *CRMProfileRepo) GetDashboardKPIs(companyID string) (*repository.CRMDash
 	err := r.q.QueryRow(context.Background(), `
 		SELECT
 			(SELECT COUNT(1)::bigint FROM customers WHERE company_id = $1) AS total_customers,
-			COALESCE((SELECT SUM(grand_total) FROM invoices WHERE company_id = $1), 0) AS total_sales,
-			COALESCE((SELECT AVG(grand_total) FROM invoices WHERE company_id = $1), 0) AS average_ticket
+			COALESCE((SELECT SUM(grand_total_cop) FROM invoices WHERE company_id = $1), 0) AS total_sales,
+			COALESCE((SELECT AVG(grand_total_cop) FROM invoices WHERE company_id = $1), 0) AS average_ticket
 	`, companyID).Scan(&out.TotalCustomers, &totalSales, &avgTicket)
 	if err != nil {
 		return nil, err
@@ -333,7 +333,7 @@ func (r *CRMProfileRepo) GetDashboardMonthlyEvolution(companyID string, months i
 			FROM generate_series(0, $2 - 1) AS gs(i)
 		),
 		invoice_totals AS (
-			SELECT date_trunc('month', i.date) AS month_start, SUM(i.grand_total) AS sales
+			SELECT date_trunc('month', i.date) AS month_start, SUM(i.grand_total_cop) AS sales
 			FROM invoices i
 			WHERE i.company_id = $1
 			  AND i.date >= date_trunc('month', now()) - (interval '1 month' * ($2 - 1))
@@ -26,12 +26,15 @@ func NewCustomerRepository(q Querier) *CustomerRepo {
 // Create persiste un nuevo cliente.
 func (r *CustomerRepo) Create(customer *entity.Customer) error {
 	query := `
-		INSERT INTO customers (id, company_id, name, tax_id, email, phone, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+		INSERT INTO customers (id, company_id, name, tax_id, email, phone, fiscal_responsibilities, is_active, created_at, updated_at,
+		                       identification_type, country_code, payment_term_days)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
 	_, err := r.q.Exec(context.Background(), query,
 		customer.ID, customer.CompanyID, customer.Name, customer.TaxID, customer.Email, customer.Phone,
+		responsibilitiesOrEmpty(customer.FiscalResponsibilities),
 		customer.IsActive,
 		customer.CreatedAt, customer.UpdatedAt,
+		customer.IdentificationType, countryOrDefault(customer.CountryCode), customer.PaymentTermDays,
 	)
 	if err != nil {
 		if isUniqueViolation(err) {
@@ -45,11 +48,12 @@ func (r *CustomerRepo) Create(customer *entity.Customer) error {
 // GetByID obtiene un cliente por ID.
 func (r *CustomerRepo) GetByID(id string) (*entity.Customer, error) {
 	query := `
-		SELECT id, company_id, name, tax_id, COALESCE(email, ''), COALESCE(phone, ''), is_active, created_at, updated_at
+		SELECT id, company_id, name, tax_id, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(fiscal_responsibilities, '{}'),
+		       COALESCE(identification_type, ''), COALESCE(country_code, 'CO'), COALESCE(payment_term_days, 0), is_active, created_at, updated_at
 		FROM customers WHERE id = $1`
 	var c entity.Customer
 	err := r.q.QueryRow(context.Background(), query, id).Scan(
-		&c.ID, &c.CompanyID, &c.Name, &c.TaxID, &c.Email, &c.Phone, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+		&c.ID, &c.CompanyID, &c.Name, &c.TaxID, &c.Email, &c.Phone, &c.FiscalResponsibilities, &c.IdentificationType, &c.CountryCode, &c.PaymentTermDays, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -63,11 +67,12 @@ func (r *CustomerRepo) GetByID(id string) (*entity.Customer, error) {
 // GetByCompanyAndTaxID obtiene un cliente por empresa y NIT/cédula.
 func (r *CustomerRepo) GetByCompanyAndTaxID(companyID, taxID string) (*entity.Customer, error) {
 	query := `
-		SELECT id, company_id, name, tax_id, COALESCE(email, ''), COALESCE(phone, ''), is_active, created_at, updated_at
+		SELECT id, company_id, name, tax_id, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(fiscal_responsibilities, '{}'),
+		       COALESCE(identification_type, ''), COALESCE(country_code, 'CO'), COALESCE(payment_term_days, 0), is_active, created_at, updated_at
 		FROM customers WHERE company_id = $1 AND tax_id = $2`
 	var c entity.Customer
 	err := r.q.QueryRow(context.Background(), query, companyID, taxID).Scan(
-		&c.ID, &c.CompanyID, &c.Name, &c.TaxID, &c.Email, &c.Phone, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+		&c.ID, &c.CompanyID, &c.Name, &c.TaxID, &c.Email, &c.Phone, &c.FiscalResponsibilities, &c.IdentificationType, &c.CountryCode, &c.PaymentTermDays, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -81,11 +86,12 @@ func (r *CustomerRepo) GetByCompanyAndTaxID(companyID, taxID string) (*entity.Cu
 // GetByCompanyAndEmail obtiene un cliente por empresa y correo electrónico.
 func (r *CustomerRepo) GetByCompanyAndEmail(companyID, email string) (*entity.Customer, error) {
 	query := `
-		SELECT id, company_id, name, tax_id, COALESCE(email, ''), COALESCE(phone, ''), is_active, created_at, updated_at
+		SELECT id, company_id, name, tax_id, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(fiscal_responsibilities, '{}'),
+		       COALESCE(identification_type, ''), COALESCE(country_code, 'CO'), COALESCE(payment_term_days, 0), is_active, created_at, updated_at
 		FROM customers WHERE company_id = $1 AND LOWER(email) = LOWER($2)`
 	var c entity.Customer
 	err := r.q.QueryRow(context.Background(), query, companyID, email).Scan(
-		&c.ID, &c.CompanyID, &c.Name, &c.TaxID, &c.Email, &c.Phone, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+		&c.ID, &c.CompanyID, &c.Name, &c.TaxID, &c.Email, &c.Phone, &c.FiscalResponsibilities, &c.IdentificationType, &c.CountryCode, &c.PaymentTermDays, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -99,7 +105,8 @@ func (r *CustomerRepo) GetByCompanyAndEmail(companyID, email string) (*entity.Cu
 // ListByCompany lista clientes de la empresa con paginación.
 func (r *CustomerRepo) ListByCompany(companyID string, search string, limit, offset int) ([]*entity.Customer, error) {
 	base := `
-		SELECT id, company_id, name, tax_id, COALESCE(email, ''), COALESCE(phone, ''), is_active, created_at, updated_at
+		SELECT id, company_id, name, tax_id, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(fiscal_responsibilities, '{}'),
+		       COALESCE(identification_type, ''), COALESCE(country_code, 'CO'), COALESCE(payment_term_days, 0), is_active, created_at, updated_at
 		FROM customers
 		WHERE company_id = $1 AND is_active = true`
 	args := []any{companyID}
@@ -120,7 +127,7 @@ func (r *CustomerRepo) ListByCompany(companyID string, search string, limit, off
 	var list []*entity.Customer
 	for rows.Next() {
 		var c entity.Customer
-		if err := rows.Scan(&c.ID, &c.CompanyID, &c.Name, &c.TaxID, &c.Email, &c.Phone, &c.IsActive, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.CompanyID, &c.Name, &c.TaxID, &c.Email, &c.Phone, &c.FiscalResponsibilities, &c.IdentificationType, &c.CountryCode, &c.PaymentTermDays, &c.IsActive, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("scan customer: %w", err)
 		}
 		list = append(list, &c)
@@ -131,10 +138,14 @@ func (r *CustomerRepo) ListByCompany(companyID string, search string, limit, off
 // Update actualiza un cliente.
 func (r *CustomerRepo) Update(customer *entity.Customer) error {
 	query := `
-		UPDATE customers SET name = $2, tax_id = $3, email = $4, phone = $5, updated_at = $6
+		UPDATE customers SET name = $2, tax_id = $3, email = $4, phone = $5, updated_at = $6,
+		       fiscal_responsibilities = $7, identification_type = $8, country_code = $9,
+		       payment_term_days = $10
 		WHERE id = $1`
 	_, err := r.q.Exec(context.Background(), query,
 		customer.ID, customer.Name, customer.TaxID, customer.Email, customer.Phone, customer.UpdatedAt,
+		responsibilitiesOrEmpty(customer.FiscalResponsibilities),
+		customer.IdentificationType, countryOrDefault(customer.CountryCode), customer.PaymentTermDays,
 	)
 	if err != nil {
 		if isUniqueViolation(err) {
@@ -164,3 +175,19 @@ func (r *CustomerRepo) SetActive(companyID, id string, isActive bool) error {
 	}
 	return nil
 }
+
+// responsibilitiesOrEmpty evita insertar NULL en columnas TEXT[] NOT NULL.
+func responsibilitiesOrEmpty(codes []string) []string {
+	if codes == nil {
+		return []string{}
+	}
+	return codes
+}
+
+// countryOrDefault país del cliente; vacío = Colombia.
+func countryOrDefault(code string) string {
+	if code == "" {
+		return "CO"
+	}
+	return code
+}
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.DIANContingencyRepository = (*DIANContingencyRepo)(nil)
+
+// DIANContingencyRepo implementación de los periodos de contingencia DIAN sobre PostgreSQL
+// (dian_contingencies e invoices.contingency_id).
+type DIANContingencyRepo struct {
+	q Querier
+}
+
+// NewDIANContingencyRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewDIANContingencyRepository(q Querier) *DIANContingencyRepo {
+	return &DIANContingencyRepo{q: q}
+}
+
+// Create inserta la contingencia. El índice único parcial sobre ended_at IS NULL impide dos
+// contingencias activas para la misma empresa.
+func (r *DIANContingencyRepo) Create(ctx context.Context, c *entity.DIANContingency) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO dian_contingencies (id, company_id, prefix, reason, started_at, started_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7, $8)`,
+		c.ID, c.CompanyID, c.Prefix, c.Reason, c.StartedAt, c.StartedBy, c.CreatedAt, c.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("insert dian contingency: %w", err)
+	}
+	return nil
+}
+
+const dianContingencyColumns = `id, company_id, prefix, reason, started_at, COALESCE(started_by::text, ''),
+	ended_at, COALESCE(ended_by::text, ''), transmitted_at, created_at, updated_at`
+
+func scanDIANContingency(row interface{ Scan(dest ...any) error }) (*entity.DIANContingency, error) {
+	var c entity.DIANContingency
+	if err := row.Scan(&c.ID, &c.CompanyID, &c.Prefix, &c.Reason, &c.StartedAt, &c.StartedBy,
+		&c.EndedAt, &c.EndedBy, &c.TransmittedAt, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetActive devuelve la contingencia activa de la empresa; nil si no tiene.
+func (r *DIANContingencyRepo) GetActive(ctx context.Context, companyID string) (*entity.DIANContingency, error) {
+	c, err := scanDIANContingency(r.q.QueryRow(ctx, `
+		SELECT `+dianContingencyColumns+`
+		FROM dian_contingencies
+		WHERE company_id = $1 AND ended_at IS NULL`, companyID))
+	if err != nil {
+		if isNoRows(err) || isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get active dian contingency: %w", err)
+	}
+	return c, nil
+}
+
+// GetByID devuelve la contingencia; nil si no existe.
+func (r *DIANContingencyRepo) GetByID(ctx context.Context, id string) (*entity.DIANContingency, error) {
+	c, err := scanDIANContingency(r.q.QueryRow(ctx, `
+		SELECT `+dianContingencyColumns+`
+		FROM dian_contingencies
+		WHERE id = $1`, id))
+	if err != nil {
+		if isNoRows(err) || isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get dian contingency: %w", err)
+	}
+	return c, nil
+}
+
+// List devuelve las contingencias de la empresa, de la más reciente a la más antigua.
+func (r *DIANContingencyRepo) List(ctx context.Context, companyID string) ([]*entity.DIANContingency, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+dianContingencyColumns+`
+		FROM dian_contingencies
+		WHERE company_id = $1
+		ORDER BY started_at DESC`, companyID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.DIANContingency{}, nil
+		}
+		return nil, fmt.Errorf("list dian contingencies: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.DIANContingency, 0)
+	for rows.Next() {
+		c, err := scanDIANContingency(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan dian contingency: %w", err)
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// End cierra la contingencia si sigue activa.
+func (r *DIANContingencyRepo) End(ctx context.Context, c *entity.DIANContingency) error {
+	res, err := r.q.Exec(ctx, `
+		UPDATE dian_contingencies
+		SET ended_at = $2, ended_by = NULLIF($3, '')::uuid, updated_at = $4
+		WHERE id = $1 AND ended_at IS NULL`,
+		c.ID, c.EndedAt, c.EndedBy, c.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("end dian contingency: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+// MarkTransmitted registra el inicio de una transmisión masiva de sus facturas.
+func (r *DIANContingencyRepo) MarkTransmitted(ctx context.Context, id string, at time.Time) error {
+	if _, err := r.q.Exec(ctx, `
+		UPDATE dian_contingencies SET transmitted_at = $2, updated_at = $2 WHERE id = $1`, id, at,
+	); err != nil {
+		return fmt.Errorf("mark dian contingency transmitted: %w", err)
+	}
+	return nil
+}
+
+// ListInvoices devuelve las facturas de la contingencia ordenadas por número, sin XML ni detalle.
+func (r *DIANContingencyRepo) ListInvoices(ctx context.Context, contingencyID string) ([]*entity.Invoice, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, company_id, customer_id, prefix, number, date, grand_total, invoice_type_code,
+		       dian_status, COALESCE(cufe, ''), COALESCE(track_id_dian, ''), COALESCE(dian_errors, ''),
+		       contingency_id::text, created_at, updated_at
+		FROM invoices
+		WHERE contingency_id = $1
+		ORDER BY prefix, length(number), number`, contingencyID)
+	if err != nil {
+		return nil, fmt.Errorf("list contingency invoices: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.Invoice, 0)
+	for rows.Next() {
+		var inv entity.Invoice
+		if err := rows.Scan(&inv.ID, &inv.CompanyID, &inv.CustomerID, &inv.Prefix, &inv.Number, &inv.Date,
+			&inv.GrandTotal, &inv.InvoiceTypeCode, &inv.DIAN_Status, &inv.CUFE, &inv.TrackID, &inv.DIANErrors,
+			&inv.ContingencyID, &inv.CreatedAt, &inv.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan contingency invoice: %w", err)
+		}
+		list = append(list, &inv)
+	}
+	return list, rows.Err()
+}
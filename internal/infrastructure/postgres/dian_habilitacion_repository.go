@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.DIANHabilitacionRepository = (*DIANHabilitacionRepo)(nil)
+
+// DIANHabilitacionRepo implementación del proceso de habilitación DIAN sobre PostgreSQL
+// (dian_habilitaciones y dian_habilitacion_documents).
+type DIANHabilitacionRepo struct {
+	q Querier
+}
+
+// NewDIANHabilitacionRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewDIANHabilitacionRepository(q Querier) *DIANHabilitacionRepo {
+	return &DIANHabilitacionRepo{q: q}
+}
+
+// Create inserta el proceso y sus documentos en una transacción. El índice único parcial sobre
+// IN_PROGRESS impide dos procesos en curso para la misma empresa.
+func (r *DIANHabilitacionRepo) Create(ctx context.Context, h *entity.DIANHabilitacion) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin create dian habilitacion tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO dian_habilitaciones (id, company_id, test_set_id, prefix, status, last_error, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid, $8, $9)`,
+		h.ID, h.CompanyID, h.TestSetID, h.Prefix, h.Status, h.LastError, h.CreatedBy, h.CreatedAt, h.UpdatedAt,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("insert dian habilitacion: %w", err)
+	}
+	for _, d := range h.Documents {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO dian_habilitacion_documents (id, habilitacion_id, sequence, document_type, prefix, number,
+				cufe, xml_signed, zip_name, status, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			d.ID, h.ID, d.Sequence, d.DocumentType, d.Prefix, d.Number, d.CUFE, d.XMLSigned, d.ZipName, d.Status, d.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("insert dian habilitacion document: %w", err)
+		}
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit create dian habilitacion: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+const dianHabilitacionColumns = `id, company_id, test_set_id, prefix, status, last_error,
+	COALESCE(created_by::text, ''), created_at, updated_at, completed_at`
+
+// GetLatest devuelve el último proceso de la empresa con sus documentos; nil si no tiene.
+func (r *DIANHabilitacionRepo) GetLatest(ctx context.Context, companyID string) (*entity.DIANHabilitacion, error) {
+	var h entity.DIANHabilitacion
+	err := r.q.QueryRow(ctx, `
+		SELECT `+dianHabilitacionColumns+`
+		FROM dian_habilitaciones
+		WHERE company_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`, companyID,
+	).Scan(&h.ID, &h.CompanyID, &h.TestSetID, &h.Prefix, &h.Status, &h.LastError,
+		&h.CreatedBy, &h.CreatedAt, &h.UpdatedAt, &h.CompletedAt)
+	if err != nil {
+		if isNoRows(err) || isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get latest dian habilitacion: %w", err)
+	}
+	if h.Documents, err = r.documents(ctx, h.ID); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// ListInProgress devuelve los procesos en curso más antiguos primero, con sus documentos.
+func (r *DIANHabilitacionRepo) ListInProgress(ctx context.Context, limit int) ([]*entity.DIANHabilitacion, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+dianHabilitacionColumns+`
+		FROM dian_habilitaciones
+		WHERE status = $1
+		ORDER BY created_at
+		LIMIT $2`, entity.DIANHabilitacionInProgress, limit)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.DIANHabilitacion{}, nil
+		}
+		return nil, fmt.Errorf("list dian habilitaciones in progress: %w", err)
+	}
+	list := make([]*entity.DIANHabilitacion, 0)
+	for rows.Next() {
+		var h entity.DIANHabilitacion
+		if err := rows.Scan(&h.ID, &h.CompanyID, &h.TestSetID, &h.Prefix, &h.Status, &h.LastError,
+			&h.CreatedBy, &h.CreatedAt, &h.UpdatedAt, &h.CompletedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan dian habilitacion: %w", err)
+		}
+		list = append(list, &h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, h := range list {
+		if h.Documents, err = r.documents(ctx, h.ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (r *DIANHabilitacionRepo) documents(ctx context.Context, habilitacionID string) ([]*entity.DIANTestSetDocument, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, habilitacion_id, sequence, document_type, prefix, number, cufe, xml_signed, zip_name,
+		       track_id, status, errors, status_checks, next_check_at, updated_at
+		FROM dian_habilitacion_documents
+		WHERE habilitacion_id = $1
+		ORDER BY sequence`, habilitacionID)
+	if err != nil {
+		return nil, fmt.Errorf("list dian habilitacion documents: %w", err)
+	}
+	defer rows.Close()
+	docs := make([]*entity.DIANTestSetDocument, 0)
+	for rows.Next() {
+		var d entity.DIANTestSetDocument
+		if err := rows.Scan(&d.ID, &d.HabilitacionID, &d.Sequence, &d.DocumentType, &d.Prefix, &d.Number,
+			&d.CUFE, &d.XMLSigned, &d.ZipName, &d.TrackID, &d.Status, &d.Errors, &d.StatusChecks,
+			&d.NextCheckAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan dian habilitacion document: %w", err)
+		}
+		docs = append(docs, &d)
+	}
+	return docs, rows.Err()
+}
+
+// LastNumber mayor consecutivo usado en los sets de pruebas de la empresa (0 si no hay).
+func (r *DIANHabilitacionRepo) LastNumber(ctx context.Context, companyID string) (int64, error) {
+	var last int64
+	err := r.q.QueryRow(ctx, `
+		SELECT COALESCE(MAX(d.number::bigint), 0)
+		FROM dian_habilitacion_documents d
+		JOIN dian_habilitaciones h ON h.id = d.habilitacion_id
+		WHERE h.company_id = $1`, companyID,
+	).Scan(&last)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("last dian habilitacion number: %w", err)
+	}
+	return last, nil
+}
+
+// UpdateDocument persiste el envío y la validación de un documento del set.
+func (r *DIANHabilitacionRepo) UpdateDocument(ctx context.Context, d *entity.DIANTestSetDocument) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE dian_habilitacion_documents
+		SET track_id = $2, status = $3, errors = $4, status_checks = $5, next_check_at = $6, updated_at = $7
+		WHERE id = $1`,
+		d.ID, d.TrackID, d.Status, d.Errors, d.StatusChecks, d.NextCheckAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update dian habilitacion document: %w", err)
+	}
+	return nil
+}
+
+// UpdateStatus actualiza estado, último error y fecha de cierre del proceso.
+func (r *DIANHabilitacionRepo) UpdateStatus(ctx context.Context, h *entity.DIANHabilitacion) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE dian_habilitaciones
+		SET status = $2, last_error = $3, completed_at = $4, updated_at = $5
+		WHERE id = $1`,
+		h.ID, h.Status, h.LastError, h.CompletedAt, h.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update dian habilitacion: %w", err)
+	}
+	return nil
+}
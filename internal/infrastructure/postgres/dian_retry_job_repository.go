@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.DIANRetryJobRepository = (*DIANRetryJobRepo)(nil)
+
+// DIANRetryJobRepo implementación de la cola de reintentos DIAN sobre PostgreSQL (dian_retry_jobs).
+type DIANRetryJobRepo struct {
+	q Querier
+}
+
+// NewDIANRetryJobRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewDIANRetryJobRepository(q Querier) *DIANRetryJobRepo {
+	return &DIANRetryJobRepo{q: q}
+}
+
+// Enqueue inserta el trabajo; si existe actualiza el último error y reactiva los que estaban en DEAD.
+// Un trabajo RUNNING conserva la reserva: lo devuelve a QUEUED el Release del worker que lo tomó.
+func (r *DIANRetryJobRepo) Enqueue(ctx context.Context, invoiceID, companyID, lastError string, next time.Time) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO dian_retry_jobs (invoice_id, company_id, status, attempts, next_attempt_at, last_error)
+		VALUES ($1, $2, $3, 0, $4, $5)
+		ON CONFLICT (invoice_id) DO UPDATE SET
+			last_error      = EXCLUDED.last_error,
+			attempts        = CASE WHEN dian_retry_jobs.status = $6 THEN 0 ELSE dian_retry_jobs.attempts END,
+			next_attempt_at = CASE WHEN dian_retry_jobs.status = $6 THEN EXCLUDED.next_attempt_at ELSE dian_retry_jobs.next_attempt_at END,
+			status          = CASE WHEN dian_retry_jobs.status = $7 THEN $7 ELSE $3 END,
+			updated_at      = now()`,
+		invoiceID, companyID, entity.DIANRetryJobQueued, next, lastError, entity.DIANRetryJobDead, entity.DIANRetryJobRunning,
+	)
+	if err != nil {
+		return fmt.Errorf("enqueue dian retry job: %w", err)
+	}
+	return nil
+}
+
+// EnqueueContingent encola las facturas en CONTINGENCIA que no tienen trabajo.
+func (r *DIANRetryJobRepo) EnqueueContingent(ctx context.Context, next time.Time) (int, error) {
+	res, err := r.q.Exec(ctx, `
+		INSERT INTO dian_retry_jobs (invoice_id, company_id, status, attempts, next_attempt_at, last_error)
+		SELECT i.id, i.company_id, $2, 0, $3, COALESCE(i.dian_errors, '')
+		FROM invoices i
+		WHERE i.dian_status = $1
+		ON CONFLICT (invoice_id) DO NOTHING`,
+		entity.DIANStatusContingencia, entity.DIANRetryJobQueued, next,
+	)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("enqueue contingent invoices: %w", err)
+	}
+	return int(res.RowsAffected()), nil
+}
+
+// DeleteResolved elimina los trabajos cuya factura ya no está en CONTINGENCIA.
+func (r *DIANRetryJobRepo) DeleteResolved(ctx context.Context) (int, error) {
+	res, err := r.q.Exec(ctx, `
+		DELETE FROM dian_retry_jobs j
+		USING invoices i
+		WHERE i.id = j.invoice_id AND i.dian_status <> $1`, entity.DIANStatusContingencia)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("delete resolved dian retry jobs: %w", err)
+	}
+	return int(res.RowsAffected()), nil
+}
+
+// ClaimDue pasa a RUNNING, en una sola sentencia, los trabajos vencidos más antiguos. FOR UPDATE SKIP
+// LOCKED salta las filas que otro worker está tomando, así que cada trabajo se entrega a un solo proceso.
+func (r *DIANRetryJobRepo) ClaimDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]*entity.DIANRetryJob, error) {
+	rows, err := r.q.Query(ctx, `
+		UPDATE dian_retry_jobs SET status = $2, updated_at = $3
+		WHERE invoice_id IN (
+			SELECT invoice_id FROM dian_retry_jobs
+			WHERE (status = $1 AND next_attempt_at <= $3) OR (status = $2 AND updated_at < $4)
+			ORDER BY next_attempt_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED)
+		RETURNING invoice_id, company_id, '', status, attempts, next_attempt_at, last_error, created_at, updated_at`,
+		entity.DIANRetryJobQueued, entity.DIANRetryJobRunning, now, staleBefore, limit)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.DIANRetryJob{}, nil
+		}
+		return nil, fmt.Errorf("claim due dian retry jobs: %w", err)
+	}
+	return scanDIANRetryJobs(rows)
+}
+
+// Release devuelve el trabajo a QUEUED si sigue en RUNNING.
+func (r *DIANRetryJobRepo) Release(ctx context.Context, invoiceID string) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE dian_retry_jobs SET status = $2, updated_at = now()
+		WHERE invoice_id = $1 AND status = $3`, invoiceID, entity.DIANRetryJobQueued, entity.DIANRetryJobRunning)
+	if err != nil {
+		return fmt.Errorf("release dian retry job: %w", err)
+	}
+	return nil
+}
+
+// RecordAttempt actualiza el contador de intentos y el próximo intento (renueva la reserva del trabajo).
+func (r *DIANRetryJobRepo) RecordAttempt(ctx context.Context, invoiceID string, attempts int, next time.Time) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE dian_retry_jobs SET attempts = $2, next_attempt_at = $3, updated_at = now()
+		WHERE invoice_id = $1`, invoiceID, attempts, next)
+	if err != nil {
+		return fmt.Errorf("record dian retry attempt: %w", err)
+	}
+	return nil
+}
+
+// MarkDead pasa el trabajo a DEAD.
+func (r *DIANRetryJobRepo) MarkDead(ctx context.Context, invoiceID, reason string) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE dian_retry_jobs SET status = $2, last_error = $3, updated_at = now()
+		WHERE invoice_id = $1`, invoiceID, entity.DIANRetryJobDead, reason)
+	if err != nil {
+		return fmt.Errorf("mark dian retry job dead: %w", err)
+	}
+	return nil
+}
+
+// List lista los trabajos de la empresa con el número de factura; status vacío = todos.
+func (r *DIANRetryJobRepo) List(ctx context.Context, companyID, status string) ([]*entity.DIANRetryJob, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT j.invoice_id, j.company_id, COALESCE(i.prefix, '') || COALESCE(i.number, ''), j.status,
+		       j.attempts, j.next_attempt_at, j.last_error, j.created_at, j.updated_at
+		FROM dian_retry_jobs j
+		JOIN invoices i ON i.id = j.invoice_id
+		WHERE j.company_id = $1 AND ($2 = '' OR j.status = $2)
+		ORDER BY j.status DESC, j.next_attempt_at`, companyID, status)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.DIANRetryJob{}, nil
+		}
+		return nil, fmt.Errorf("list dian retry jobs: %w", err)
+	}
+	return scanDIANRetryJobs(rows)
+}
+
+func scanDIANRetryJobs(rows pgx.Rows) ([]*entity.DIANRetryJob, error) {
+	defer rows.Close()
+	list := make([]*entity.DIANRetryJob, 0)
+	for rows.Next() {
+		var j entity.DIANRetryJob
+		if err := rows.Scan(&j.InvoiceID, &j.CompanyID, &j.InvoiceNumber, &j.Status, &j.Attempts,
+			&j.NextAttemptAt, &j.LastError, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan dian retry job: %w", err)
+		}
+		list = append(list, &j)
+	}
+	return list, rows.Err()
+}
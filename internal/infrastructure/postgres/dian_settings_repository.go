@@ -32,15 +32,21 @@ func (r *DIANSettingsRepo) Upsert(ctx context.Context, settings *entity.DIANSett
 			certificate_file_name,
 			certificate_file_size,
 			certificate_password_encrypted,
+			technical_key,
+			software_id,
+			software_pin_encrypted,
 			created_at,
 			updated_at
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
 		ON CONFLICT (company_id, environment) DO UPDATE SET
 			certificate_path = EXCLUDED.certificate_path,
 			certificate_file_name = EXCLUDED.certificate_file_name,
 			certificate_file_size = EXCLUDED.certificate_file_size,
 			certificate_password_encrypted = EXCLUDED.certificate_password_encrypted,
+			technical_key = EXCLUDED.technical_key,
+			software_id = EXCLUDED.software_id,
+			software_pin_encrypted = EXCLUDED.software_pin_encrypted,
 			updated_at = EXCLUDED.updated_at
 	`
 
@@ -53,6 +59,9 @@ func (r *DIANSettingsRepo) Upsert(ctx context.Context, settings *entity.DIANSett
 		settings.CertificateFileName,
 		settings.CertificateFileSize,
 		settings.CertificatePasswordEncrypted,
+		settings.TechnicalKey,
+		settings.SoftwareID,
+		settings.SoftwarePINEncrypted,
 		settings.CreatedAt,
 		settings.UpdatedAt,
 	)
@@ -65,16 +74,22 @@ func (r *DIANSettingsRepo) Upsert(ctx context.Context, settings *entity.DIANSett
 				certificate_file_name,
 				certificate_file_size,
 				certificate_password_encrypted,
+				technical_key,
+				software_id,
+				software_pin_encrypted,
 				created_at,
 				updated_at
 			)
-			VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
 			ON CONFLICT (company_id) DO UPDATE SET
 				environment = EXCLUDED.environment,
 				certificate_path = EXCLUDED.certificate_path,
 				certificate_file_name = EXCLUDED.certificate_file_name,
 				certificate_file_size = EXCLUDED.certificate_file_size,
 				certificate_password_encrypted = EXCLUDED.certificate_password_encrypted,
+				technical_key = EXCLUDED.technical_key,
+				software_id = EXCLUDED.software_id,
+				software_pin_encrypted = EXCLUDED.software_pin_encrypted,
 				updated_at = EXCLUDED.updated_at
 		`
 		_, err = r.pool.Exec(
@@ -86,6 +101,9 @@ func (r *DIANSettingsRepo) Upsert(ctx context.Context, settings *entity.DIANSett
 			settings.CertificateFileName,
 			settings.CertificateFileSize,
 			settings.CertificatePasswordEncrypted,
+			settings.TechnicalKey,
+			settings.SoftwareID,
+			settings.SoftwarePINEncrypted,
 			settings.CreatedAt,
 			settings.UpdatedAt,
 		)
@@ -96,6 +114,15 @@ func (r *DIANSettingsRepo) Upsert(ctx context.Context, settings *entity.DIANSett
 	return nil
 }
 
+// HasAny indica si alguna empresa tiene configuración DIAN.
+func (r *DIANSettingsRepo) HasAny(ctx context.Context) (bool, error) {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM dian_settings)`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check dian_settings: %w", err)
+	}
+	return exists, nil
+}
+
 func (r *DIANSettingsRepo) GetByCompanyID(ctx context.Context, companyID string) (*entity.DIANSettings, error) {
 	const q = `
 		SELECT
@@ -105,6 +132,9 @@ func (r *DIANSettingsRepo) GetByCompanyID(ctx context.Context, companyID string)
 			certificate_file_name,
 			certificate_file_size,
 			certificate_password_encrypted,
+			technical_key,
+			software_id,
+			software_pin_encrypted,
 			created_at,
 			updated_at
 		FROM dian_settings
@@ -121,6 +151,9 @@ func (r *DIANSettingsRepo) GetByCompanyID(ctx context.Context, companyID string)
 		&settings.CertificateFileName,
 		&settings.CertificateFileSize,
 		&settings.CertificatePasswordEncrypted,
+		&settings.TechnicalKey,
+		&settings.SoftwareID,
+		&settings.SoftwarePINEncrypted,
 		&settings.CreatedAt,
 		&settings.UpdatedAt,
 	)
@@ -143,6 +176,9 @@ func (r *DIANSettingsRepo) GetByCompanyIDAndEnvironment(ctx context.Context, com
 			certificate_file_name,
 			certificate_file_size,
 			certificate_password_encrypted,
+			technical_key,
+			software_id,
+			software_pin_encrypted,
 			created_at,
 			updated_at
 		FROM dian_settings
@@ -158,6 +194,9 @@ func (r *DIANSettingsRepo) GetByCompanyIDAndEnvironment(ctx context.Context, com
 		&settings.CertificateFileName,
 		&settings.CertificateFileSize,
 		&settings.CertificatePasswordEncrypted,
+		&settings.TechnicalKey,
+		&settings.SoftwareID,
+		&settings.SoftwarePINEncrypted,
 		&settings.CreatedAt,
 		&settings.UpdatedAt,
 	)
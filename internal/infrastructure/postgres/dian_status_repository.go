@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.DIANStatusRepository = (*DIANStatusRepo)(nil)
+
+// DIANStatusRepo implementación del seguimiento de envíos asíncronos DIAN sobre PostgreSQL.
+type DIANStatusRepo struct {
+	q Querier
+}
+
+// NewDIANStatusRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewDIANStatusRepository(q Querier) *DIANStatusRepo {
+	return &DIANStatusRepo{q: q}
+}
+
+// ListAwaitingStatus lista facturas en Sent con TrackID cuya próxima consulta ya venció
+// (sin consulta previa se toma updated_at, el momento del envío). Sin la migración 055 devuelve vacío.
+func (r *DIANStatusRepo) ListAwaitingStatus(ctx context.Context, now time.Time, limit int) ([]*entity.DIANStatusCheck, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, company_id, track_id_dian, dian_status_checks, COALESCE(dian_next_check_at, updated_at)
+		FROM invoices
+		WHERE dian_status = $1
+		  AND COALESCE(track_id_dian, '') <> ''
+		  AND COALESCE(dian_next_check_at, updated_at) <= $2
+		ORDER BY COALESCE(dian_next_check_at, updated_at)
+		LIMIT $3`, entity.DIANStatusSent, now, limit)
+	if err != nil {
+		if isUndefinedColumnError(err, "dian_") {
+			return []*entity.DIANStatusCheck{}, nil
+		}
+		return nil, fmt.Errorf("list invoices awaiting dian status: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.DIANStatusCheck, 0)
+	for rows.Next() {
+		var c entity.DIANStatusCheck
+		if err := rows.Scan(&c.InvoiceID, &c.CompanyID, &c.TrackID, &c.Attempts, &c.NextCheckAt); err != nil {
+			return nil, fmt.Errorf("scan dian status check: %w", err)
+		}
+		list = append(list, &c)
+	}
+	return list, rows.Err()
+}
+
+// ScheduleStatusCheck actualiza el contador de consultas y la fecha de la siguiente.
+func (r *DIANStatusRepo) ScheduleStatusCheck(ctx context.Context, invoiceID string, attempts int, next time.Time) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE invoices SET dian_status_checks = $2, dian_next_check_at = $3
+		WHERE id = $1`, invoiceID, attempts, next)
+	if err != nil {
+		return fmt.Errorf("schedule dian status check: %w", err)
+	}
+	return nil
+}
+
+// SaveValidationResult guarda el estado definitivo (y el ApplicationResponse, si llegó) solo si la
+// factura sigue en Sent, para no pisar un resultado ya registrado por otra instancia del worker.
+func (r *DIANStatusRepo) SaveValidationResult(ctx context.Context, invoiceID, status, errors string, rules []entity.DIANValidationRule, applicationResponse []byte) error {
+	if rules == nil {
+		rules = []entity.DIANValidationRule{}
+	}
+	res, err := r.q.Exec(ctx, `
+		UPDATE invoices
+		SET dian_status = $2, dian_errors = $3, dian_rules = $4, dian_next_check_at = NULL,
+		    dian_application_response = COALESCE(NULLIF($6, ''), dian_application_response), updated_at = now()
+		WHERE id = $1 AND dian_status = $5`,
+		invoiceID, status, errors, rules, entity.DIANStatusSent, string(applicationResponse),
+	)
+	if err != nil {
+		return fmt.Errorf("save dian validation result: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
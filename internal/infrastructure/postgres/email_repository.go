@@ -200,14 +200,15 @@ func (r *EmailRepo) Create(email *entity.Email, attachments []entity.EmailAttach
 		}
 		attachments[i].EmailID = email.ID
 		_, err := r.q.Exec(context.Background(), `
-			INSERT INTO email_attachments (id, email_id, file_name, file_url, mime_type, size)
-			VALUES ($1, $2, $3, $4, $5, $6)`,
+			INSERT INTO email_attachments (id, email_id, file_name, file_url, mime_type, size, content)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
 			attachments[i].ID,
 			attachments[i].EmailID,
 			attachments[i].FileName,
 			attachments[i].FileURL,
 			attachments[i].MIMEType,
 			attachments[i].Size,
+			attachments[i].Content,
 		)
 		if err != nil {
 			return fmt.Errorf("insert email attachment: %w", err)
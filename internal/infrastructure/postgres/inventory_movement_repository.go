@@ -9,8 +9,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jhoicas/Inventario-api/internal/domain"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+	"github.com/shopspring/decimal"
 )
 
 var _ repository.InventoryMovementRepository = (*InventoryMovementRepo)(nil)
@@ -31,8 +33,8 @@ func (r *InventoryMovementRepo) Create(movement *entity.InventoryMovement) error
 		movement.ID = uuid.New().String()
 	}
 	query := `
-		INSERT INTO inventory_movements (id, transaction_id, product_id, warehouse_id, type, quantity, unit_cost, total_cost, notes, date, created_at, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+		INSERT INTO inventory_movements (id, transaction_id, product_id, warehouse_id, type, quantity, unit_cost, total_cost, notes, date, created_at, created_by, reversal_of_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
 	legacyQuery := `
 		INSERT INTO inventory_movements (id, transaction_id, product_id, warehouse_id, type, quantity, unit_cost, total_cost, date, created_at, created_by)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
@@ -44,13 +46,20 @@ func (r *InventoryMovementRepo) Create(movement *entity.InventoryMovement) error
 	if movement.Notes != "" {
 		notes = &movement.Notes
 	}
+	reversalOf := (*string)(nil)
+	if movement.ReversalOfID != "" {
+		reversalOf = &movement.ReversalOfID
+	}
 	_, err := r.q.Exec(context.Background(), query,
 		movement.ID, movement.TransactionID, movement.ProductID, movement.WarehouseID,
 		movement.Type, movement.Quantity, movement.UnitCost, movement.TotalCost,
-		notes, movement.Date, movement.CreatedAt, createdBy,
+		notes, movement.Date, movement.CreatedAt, createdBy, reversalOf,
 	)
 	if err != nil {
-		if isUndefinedColumn(err) {
+		if isUniqueViolation(err) && reversalOf != nil {
+			return domain.ErrConflict
+		}
+		if isUndefinedColumn(err) && reversalOf == nil {
 			_, legacyErr := r.q.Exec(context.Background(), legacyQuery,
 				movement.ID, movement.TransactionID, movement.ProductID, movement.WarehouseID,
 				movement.Type, movement.Quantity, movement.UnitCost, movement.TotalCost,
@@ -69,23 +78,14 @@ func (r *InventoryMovementRepo) Create(movement *entity.InventoryMovement) error
 // GetByID obtiene un movimiento por ID.
 func (r *InventoryMovementRepo) GetByID(id string) (*entity.InventoryMovement, error) {
 	query := `
-		SELECT id, transaction_id, product_id, warehouse_id, type, quantity, unit_cost, total_cost, notes, date, created_at, created_by
-		FROM inventory_movements WHERE id = $1`
+		SELECT ` + movementColumns + `
+		FROM inventory_movements im WHERE im.id = $1`
 	legacyQuery := `
-		SELECT id, transaction_id, product_id, warehouse_id, type, quantity, unit_cost, total_cost, ''::text AS notes, date, created_at, created_by
-		FROM inventory_movements WHERE id = $1`
-	var m entity.InventoryMovement
-	var createdBy *string
-	var notes *string
-	err := r.q.QueryRow(context.Background(), query, id).Scan(
-		&m.ID, &m.TransactionID, &m.ProductID, &m.WarehouseID, &m.Type,
-		&m.Quantity, &m.UnitCost, &m.TotalCost, &notes, &m.Date, &m.CreatedAt, &createdBy,
-	)
+		SELECT ` + legacyMovementColumns + `
+		FROM inventory_movements im WHERE im.id = $1`
+	m, err := scanMovement(r.q.QueryRow(context.Background(), query, id))
 	if err != nil && isUndefinedColumn(err) {
-		err = r.q.QueryRow(context.Background(), legacyQuery, id).Scan(
-			&m.ID, &m.TransactionID, &m.ProductID, &m.WarehouseID, &m.Type,
-			&m.Quantity, &m.UnitCost, &m.TotalCost, &notes, &m.Date, &m.CreatedAt, &createdBy,
-		)
+		m, err = scanMovement(r.q.QueryRow(context.Background(), legacyQuery, id))
 	}
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -93,12 +93,183 @@ func (r *InventoryMovementRepo) GetByID(id string) (*entity.InventoryMovement, e
 		}
 		return nil, fmt.Errorf("get movement: %w", err)
 	}
+	return m, nil
+}
+
+// ListByTransaction devuelve los movimientos de una transacción en orden de creación.
+func (r *InventoryMovementRepo) ListByTransaction(transactionID string) ([]*entity.InventoryMovement, error) {
+	query := `
+		SELECT ` + movementColumns + `
+		FROM inventory_movements im WHERE im.transaction_id = $1
+		ORDER BY im.created_at ASC, im.id ASC`
+	legacyQuery := `
+		SELECT ` + legacyMovementColumns + `
+		FROM inventory_movements im WHERE im.transaction_id = $1
+		ORDER BY im.created_at ASC, im.id ASC`
+	rows, err := r.q.Query(context.Background(), query, transactionID)
+	if err != nil && isUndefinedColumn(err) {
+		rows, err = r.q.Query(context.Background(), legacyQuery, transactionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list by transaction: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.InventoryMovement, 0)
+	for rows.Next() {
+		m, err := scanMovement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan movement: %w", err)
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// ListByProductChronological lista movimientos del producto en orden cronológico (from inclusivo, to exclusivo).
+func (r *InventoryMovementRepo) ListByProductChronological(productID string, from, to *time.Time) ([]*entity.InventoryMovement, error) {
+	where := "im.product_id = $1"
+	args := []any{productID}
+	pos := 2
+	if from != nil {
+		where += fmt.Sprintf(" AND im.date >= $%d", pos)
+		args = append(args, *from)
+		pos++
+	}
+	if to != nil {
+		where += fmt.Sprintf(" AND im.date < $%d", pos)
+		args = append(args, *to)
+	}
+	query := `SELECT ` + movementColumns + `
+		FROM inventory_movements im WHERE ` + where + `
+		ORDER BY im.date ASC, im.created_at ASC, im.id ASC`
+	legacyQuery := `SELECT ` + legacyMovementColumns + `
+		FROM inventory_movements im WHERE ` + where + `
+		ORDER BY im.date ASC, im.created_at ASC, im.id ASC`
+	rows, err := r.q.Query(context.Background(), query, args...)
+	if err != nil && isUndefinedColumn(err) {
+		rows, err = r.q.Query(context.Background(), legacyQuery, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list by product chronological: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.InventoryMovement, 0)
+	for rows.Next() {
+		m, err := scanMovement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan movement: %w", err)
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// UpdateCost actualiza costo unitario y total de un movimiento existente.
+func (r *InventoryMovementRepo) UpdateCost(id string, unitCost, totalCost decimal.Decimal) error {
+	_, err := r.q.Exec(context.Background(),
+		`UPDATE inventory_movements SET unit_cost = $2, total_cost = $3 WHERE id = $1`,
+		id, unitCost, totalCost,
+	)
+	if err != nil {
+		return fmt.Errorf("update movement cost: %w", err)
+	}
+	return nil
+}
+
+// MarkReversed marca el movimiento original como revertido. El UPDATE condicional
+// (reversed_at IS NULL) garantiza que dos reversiones concurrentes no prosperen.
+func (r *InventoryMovementRepo) MarkReversed(id, reversedBy string, reversedAt time.Time) error {
+	by := (*string)(nil)
+	if reversedBy != "" {
+		by = &reversedBy
+	}
+	tag, err := r.q.Exec(context.Background(),
+		`UPDATE inventory_movements SET reversed_at = $2, reversed_by = $3
+		 WHERE id = $1 AND reversed_at IS NULL`,
+		id, reversedAt, by,
+	)
+	if err != nil {
+		return fmt.Errorf("mark movement reversed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+// LockOpenPeriod registra como abierto el período (year, month) si aún no existe y bloquea con
+// FOR SHARE su fila y las de meses posteriores: el cierre (UPDATE del período) espera a que la
+// transacción del movimiento termine, y un período ya cerrado se detecta con la fila bloqueada.
+func (r *InventoryMovementRepo) LockOpenPeriod(companyID string, year, month int) error {
+	ctx := context.Background()
+	if _, err := r.q.Exec(ctx,
+		`INSERT INTO inventory_periods (company_id, year, month, status) VALUES ($1, $2, $3, 'OPEN')
+		 ON CONFLICT (company_id, year, month) DO NOTHING`,
+		companyID, year, month,
+	); err != nil {
+		return fmt.Errorf("lock inventory period: %w", err)
+	}
+	rows, err := r.q.Query(ctx,
+		`SELECT status FROM inventory_periods
+		 WHERE company_id = $1 AND (year * 100 + month) >= ($2 * 100 + $3)
+		 FOR SHARE`,
+		companyID, year, month,
+	)
+	if err != nil {
+		return fmt.Errorf("lock inventory period: %w", err)
+	}
+	defer rows.Close()
+	closed := false
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			return fmt.Errorf("scan inventory period: %w", err)
+		}
+		closed = closed || status == entity.InventoryPeriodStatusClosed
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("lock inventory period: %w", err)
+	}
+	if closed {
+		return domain.ErrPeriodClosed
+	}
+	return nil
+}
+
+// movementColumns columnas completas de inventory_movements (alias im) en el orden de scanMovement.
+const movementColumns = `im.id, im.transaction_id, im.product_id, im.warehouse_id,
+		       im.type, im.quantity, im.unit_cost, im.total_cost, im.notes, im.date, im.created_at, im.created_by,
+		       im.reversal_of_id, im.reversed_at, im.reversed_by`
+
+// legacyMovementColumns equivalente para esquemas sin notes ni columnas de reversión.
+const legacyMovementColumns = `im.id, im.transaction_id, im.product_id, im.warehouse_id,
+		       im.type, im.quantity, im.unit_cost, im.total_cost, ''::text AS notes, im.date, im.created_at, im.created_by,
+		       NULL::uuid AS reversal_of_id, NULL::timestamptz AS reversed_at, NULL::uuid AS reversed_by`
+
+// scanMovement lee una fila con movementColumns o legacyMovementColumns.
+func scanMovement(row pgx.Row) (*entity.InventoryMovement, error) {
+	var m entity.InventoryMovement
+	var createdBy, notes, reversalOf, reversedBy *string
+	if err := row.Scan(
+		&m.ID, &m.TransactionID, &m.ProductID, &m.WarehouseID,
+		&m.Type, &m.Quantity, &m.UnitCost, &m.TotalCost,
+		&notes, &m.Date, &m.CreatedAt, &createdBy,
+		&reversalOf, &m.ReversedAt, &reversedBy,
+	); err != nil {
+		return nil, err
+	}
 	if notes != nil {
 		m.Notes = *notes
 	}
 	if createdBy != nil {
 		m.CreatedBy = *createdBy
 	}
+	if reversalOf != nil {
+		m.ReversalOfID = *reversalOf
+	}
+	if reversedBy != nil {
+		m.ReversedBy = *reversedBy
+	}
 	return &m, nil
 }
 
@@ -158,19 +329,17 @@ func (r *InventoryMovementRepo) List(companyID string, f repository.MovementFilt
 	}
 
 	dataQuery := fmt.Sprintf(`
-		SELECT im.id, im.transaction_id, im.product_id, im.warehouse_id,
-		       im.type, im.quantity, im.unit_cost, im.total_cost, im.notes, im.date, im.created_at, im.created_by
+		SELECT %s
 		FROM inventory_movements im
 		WHERE %s
 		ORDER BY im.date ASC, im.created_at ASC
-		LIMIT $%d OFFSET $%d`, where, pos, pos+1)
+		LIMIT $%d OFFSET $%d`, movementColumns, where, pos, pos+1)
 	legacyDataQuery := fmt.Sprintf(`
-		SELECT im.id, im.transaction_id, im.product_id, im.warehouse_id,
-		       im.type, im.quantity, im.unit_cost, im.total_cost, ''::text AS notes, im.date, im.created_at, im.created_by
+		SELECT %s
 		FROM inventory_movements im
 		WHERE %s
 		ORDER BY im.date ASC, im.created_at ASC
-		LIMIT $%d OFFSET $%d`, where, pos, pos+1)
+		LIMIT $%d OFFSET $%d`, legacyMovementColumns, where, pos, pos+1)
 
 	dataArgs := append(args, limit, offset)
 	rows, err := r.q.Query(context.Background(), dataQuery, dataArgs...)
@@ -188,23 +357,11 @@ func (r *InventoryMovementRepo) List(companyID string, f repository.MovementFilt
 
 	list := make([]*entity.InventoryMovement, 0)
 	for rows.Next() {
-		var m entity.InventoryMovement
-		var createdBy *string
-		var notes *string
-		if err := rows.Scan(
-			&m.ID, &m.TransactionID, &m.ProductID, &m.WarehouseID,
-			&m.Type, &m.Quantity, &m.UnitCost, &m.TotalCost,
-			&notes, &m.Date, &m.CreatedAt, &createdBy,
-		); err != nil {
+		m, err := scanMovement(rows)
+		if err != nil {
 			return nil, 0, fmt.Errorf("scan movement list: %w", err)
 		}
-		if notes != nil {
-			m.Notes = *notes
-		}
-		if createdBy != nil {
-			m.CreatedBy = *createdBy
-		}
-		list = append(list, &m)
+		list = append(list, m)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -0,0 +1,337 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jhoicas/Inventario-api/internal/application/inventory"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ inventory.InventoryPeriodRepository = (*InventoryPeriodRepo)(nil)
+
+// InventoryPeriodRepo implementación de cierres mensuales de inventario sobre PostgreSQL.
+type InventoryPeriodRepo struct {
+	q Querier
+}
+
+// NewInventoryPeriodRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewInventoryPeriodRepository(q Querier) *InventoryPeriodRepo {
+	return &InventoryPeriodRepo{q: q}
+}
+
+const inventoryPeriodColumns = `id, company_id, year, month, status, closed_at, closed_by, created_at, updated_at`
+
+func scanInventoryPeriod(row pgx.Row) (*entity.InventoryPeriod, error) {
+	var p entity.InventoryPeriod
+	var closedBy *string
+	if err := row.Scan(&p.ID, &p.CompanyID, &p.Year, &p.Month, &p.Status,
+		&p.ClosedAt, &closedBy, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if closedBy != nil {
+		p.ClosedBy = *closedBy
+	}
+	return &p, nil
+}
+
+// Get devuelve el período (year, month) de la empresa o nil si no existe.
+func (r *InventoryPeriodRepo) Get(ctx context.Context, companyID string, year, month int) (*entity.InventoryPeriod, error) {
+	p, err := scanInventoryPeriod(r.q.QueryRow(ctx, `
+		SELECT `+inventoryPeriodColumns+`
+		FROM inventory_periods
+		WHERE company_id = $1 AND year = $2 AND month = $3`, companyID, year, month))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get inventory period: %w", err)
+	}
+	if p.Location, err = r.CompanyLocation(ctx, companyID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ListByCompany lista los períodos de la empresa del más reciente al más antiguo.
+func (r *InventoryPeriodRepo) ListByCompany(ctx context.Context, companyID string) ([]*entity.InventoryPeriod, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+inventoryPeriodColumns+`
+		FROM inventory_periods
+		WHERE company_id = $1
+		ORDER BY year DESC, month DESC`, companyID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.InventoryPeriod{}, nil
+		}
+		return nil, fmt.Errorf("list inventory periods: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.InventoryPeriod, 0)
+	for rows.Next() {
+		p, err := scanInventoryPeriod(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan inventory period: %w", err)
+		}
+		list = append(list, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	loc, err := r.CompanyLocation(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range list {
+		p.Location = loc
+	}
+	return list, nil
+}
+
+// HasClosedAfter informa si existe un período cerrado posterior a (year, month).
+func (r *InventoryPeriodRepo) HasClosedAfter(ctx context.Context, companyID string, year, month int) (bool, error) {
+	var exists bool
+	err := r.q.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM inventory_periods
+			WHERE company_id = $1 AND status = 'CLOSED'
+			  AND (year * 100 + month) > ($2 * 100 + $3)
+		)`, companyID, year, month).Scan(&exists)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("check later closed periods: %w", err)
+	}
+	return exists, nil
+}
+
+// LastClosedBefore devuelve el último período cerrado cuyo fin es anterior o igual a date.
+func (r *InventoryPeriodRepo) LastClosedBefore(ctx context.Context, companyID string, date time.Time) (*entity.InventoryPeriod, error) {
+	loc, err := r.CompanyLocation(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	local := date.In(loc)
+	// El período (y, m) termina en o antes de date si es anterior al mes de date.
+	p, err := scanInventoryPeriod(r.q.QueryRow(ctx, `
+		SELECT `+inventoryPeriodColumns+`
+		FROM inventory_periods
+		WHERE company_id = $1 AND status = 'CLOSED'
+		  AND (year * 100 + month) < ($2 * 100 + $3)
+		ORDER BY year DESC, month DESC
+		LIMIT 1`, companyID, local.Year(), int(local.Month())))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get last closed inventory period: %w", err)
+	}
+	p.Location = loc
+	return p, nil
+}
+
+// CompanyLocation lee la zona horaria configurada de la empresa (la por defecto si no existe la columna).
+func (r *InventoryPeriodRepo) CompanyLocation(ctx context.Context, companyID string) (*time.Location, error) {
+	var name string
+	err := r.q.QueryRow(ctx, `SELECT time_zone FROM companies WHERE id = $1`, companyID).Scan(&name)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) && !isUndefinedColumn(err) {
+		return nil, fmt.Errorf("get company time zone: %w", err)
+	}
+	loc, err := entity.LoadCompanyLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("load company time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// StockAt suma los movimientos anteriores a cutoff por producto y bodega de la empresa.
+func (r *InventoryPeriodRepo) StockAt(ctx context.Context, companyID string, cutoff time.Time) ([]entity.InventoryPeriodValuation, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT im.product_id, im.warehouse_id, SUM(im.quantity)
+		FROM inventory_movements im
+		JOIN products p ON p.id = im.product_id
+		WHERE p.company_id = $1 AND im.date < $2
+		GROUP BY im.product_id, im.warehouse_id
+		HAVING SUM(im.quantity) <> 0
+		ORDER BY im.product_id, im.warehouse_id`, companyID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("stock at cutoff: %w", err)
+	}
+	defer rows.Close()
+	list := make([]entity.InventoryPeriodValuation, 0)
+	for rows.Next() {
+		var v entity.InventoryPeriodValuation
+		if err := rows.Scan(&v.ProductID, &v.WarehouseID, &v.Quantity); err != nil {
+			return nil, fmt.Errorf("scan stock at cutoff: %w", err)
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// Close guarda el período como cerrado, calcula y reemplaza la valorización y registra el evento en una
+// sola transacción. La valorización se calcula después del UPSERT, que deja la fila del período bloqueada:
+// los movimientos retroactivos que ya la tenían (LockOpenPeriod) terminan antes y los nuevos esperan.
+func (r *InventoryPeriodRepo) Close(ctx context.Context, period *entity.InventoryPeriod, event *entity.InventoryPeriodEvent, valuate inventory.PeriodValuator) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin inventory period close tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	closedBy := (*string)(nil)
+	if period.ClosedBy != "" {
+		closedBy = &period.ClosedBy
+	}
+	// El UPSERT condicional evita cerrar dos veces el mismo período en cierres concurrentes. Si el
+	// período ya existía abierto (p. ej. registrado por LockOpenPeriod) se conserva su id.
+	err = tx.QueryRow(ctx, `
+		INSERT INTO inventory_periods (id, company_id, year, month, status, closed_at, closed_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (company_id, year, month) DO UPDATE
+		   SET status = EXCLUDED.status, closed_at = EXCLUDED.closed_at,
+		       closed_by = EXCLUDED.closed_by, updated_at = EXCLUDED.updated_at
+		 WHERE inventory_periods.status <> 'CLOSED'
+		RETURNING id`,
+		period.ID, period.CompanyID, period.Year, period.Month, period.Status,
+		period.ClosedAt, closedBy, period.CreatedAt, period.UpdatedAt,
+	).Scan(&period.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("upsert inventory period: %w", err)
+	}
+	event.PeriodID = period.ID
+
+	valuations, err := valuate(NewInventoryPeriodRepository(tx), NewInventoryMovementRepository(tx))
+	if err != nil {
+		return err
+	}
+	for i := range valuations {
+		valuations[i].PeriodID = period.ID
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM inventory_period_valuations WHERE period_id = $1`, period.ID); err != nil {
+		return fmt.Errorf("delete inventory period valuations: %w", err)
+	}
+	for _, v := range valuations {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO inventory_period_valuations (period_id, product_id, warehouse_id, quantity, unit_cost, total_value)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			period.ID, v.ProductID, v.WarehouseID, v.Quantity, v.UnitCost, v.TotalValue.Round(2),
+		); err != nil {
+			return fmt.Errorf("insert inventory period valuation: %w", err)
+		}
+	}
+	if err := insertInventoryPeriodEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit inventory period close: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+// Reopen marca el período como abierto y registra el evento de auditoría.
+func (r *InventoryPeriodRepo) Reopen(ctx context.Context, period *entity.InventoryPeriod, event *entity.InventoryPeriodEvent) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin inventory period reopen tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE inventory_periods SET status = $2, updated_at = $3 WHERE id = $1`,
+		period.ID, period.Status, period.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("reopen inventory period: %w", err)
+	}
+	if err := insertInventoryPeriodEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit inventory period reopen: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+func insertInventoryPeriodEvent(ctx context.Context, q Querier, event *entity.InventoryPeriodEvent) error {
+	reason := (*string)(nil)
+	if event.Reason != "" {
+		reason = &event.Reason
+	}
+	userID := (*string)(nil)
+	if event.UserID != "" {
+		userID = &event.UserID
+	}
+	if _, err := q.Exec(ctx, `
+		INSERT INTO inventory_period_events (id, period_id, action, reason, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		event.ID, event.PeriodID, event.Action, reason, userID, event.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert inventory period event: %w", err)
+	}
+	return nil
+}
+
+// ListValuations devuelve la valorización congelada del período.
+func (r *InventoryPeriodRepo) ListValuations(ctx context.Context, periodID string) ([]entity.InventoryPeriodValuation, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT period_id, product_id, warehouse_id, quantity, unit_cost, total_value
+		FROM inventory_period_valuations
+		WHERE period_id = $1
+		ORDER BY product_id, warehouse_id`, periodID)
+	if err != nil {
+		return nil, fmt.Errorf("list inventory period valuations: %w", err)
+	}
+	defer rows.Close()
+	list := make([]entity.InventoryPeriodValuation, 0)
+	for rows.Next() {
+		var v entity.InventoryPeriodValuation
+		if err := rows.Scan(&v.PeriodID, &v.ProductID, &v.WarehouseID, &v.Quantity, &v.UnitCost, &v.TotalValue); err != nil {
+			return nil, fmt.Errorf("scan inventory period valuation: %w", err)
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// ListEvents devuelve la bitácora de cierres y reaperturas del período.
+func (r *InventoryPeriodRepo) ListEvents(ctx context.Context, periodID string) ([]entity.InventoryPeriodEvent, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, period_id, action, COALESCE(reason, ''), user_id, created_at
+		FROM inventory_period_events
+		WHERE period_id = $1
+		ORDER BY created_at ASC`, periodID)
+	if err != nil {
+		return nil, fmt.Errorf("list inventory period events: %w", err)
+	}
+	defer rows.Close()
+	list := make([]entity.InventoryPeriodEvent, 0)
+	for rows.Next() {
+		var e entity.InventoryPeriodEvent
+		var userID *string
+		if err := rows.Scan(&e.ID, &e.PeriodID, &e.Action, &e.Reason, &userID, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan inventory period event: %w", err)
+		}
+		if userID != nil {
+			e.UserID = *userID
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
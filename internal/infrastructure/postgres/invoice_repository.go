@@ -26,11 +26,13 @@ func NewInvoiceRepository(q Querier) *InvoiceRepo {
 	return &InvoiceRepo{q: q}
 }
 
-// Create persiste la cabecera de la factura.
+// Create persiste la cabecera de la factura. Los totales en COP se derivan de la tasa de cambio
+// del documento (iguales a los totales en facturas en pesos).
 func (r *InvoiceRepo) Create(invoice *entity.Invoice) error {
 	if invoice.ID == "" {
 		invoice.ID = uuid.New().String()
 	}
+	invoice.ComputeCOPTotals()
 	query := `
 		INSERT INTO invoices (
 			id, company_id, customer_id, prefix, number, date,
@@ -43,7 +45,13 @@ func (r *InvoiceRepo) Create(invoice *entity.Invoice) error {
 			original_invoice_issue_on,
 			discrepancy_code,
 			discrepancy_reason,
-			created_at, updated_at
+			created_at, updated_at,
+			withholding_total,
+			discount_total, allowance_total, charge_total,
+			currency_code, exchange_rate, invoice_type_code, incoterm,
+			net_total_cop, tax_total_cop, grand_total_cop,
+			payment_form_code, payment_method_codes, due_date,
+			contingency_id
 		)
 		VALUES (
 			$1, $2, $3, $4, $5, $6,
@@ -56,7 +64,13 @@ func (r *InvoiceRepo) Create(invoice *entity.Invoice) error {
 			$21,
 			$22,
 			$23,
-			$24, $25
+			$24, $25,
+			$26,
+			$27, $28, $29,
+			$30, $31, $32, $33,
+			$34, $35, $36,
+			$37, $38, $39,
+			$40
 		)`
 	_, err := r.q.Exec(context.Background(), query,
 		invoice.ID, invoice.CompanyID, invoice.CustomerID, invoice.Prefix, invoice.Number,
@@ -82,6 +96,13 @@ func (r *InvoiceRepo) Create(invoice *entity.Invoice) error {
 		}(),
 		nullIfEmpty(invoice.DiscrepancyReason),
 		invoice.CreatedAt, invoice.UpdatedAt,
+		invoice.WithholdingTotal,
+		invoice.DiscountTotal, invoice.AllowanceTotal, invoice.ChargeTotal,
+		currencyOrDefault(invoice.CurrencyCode), rateOrOne(invoice.ExchangeRate),
+		invoiceTypeOrDefault(invoice.InvoiceTypeCode), invoice.Incoterm,
+		invoice.NetTotalCOP, invoice.TaxTotalCOP, invoice.GrandTotalCOP,
+		paymentFormOrDefault(invoice.PaymentFormCode), paymentMethodsOrDefault(invoice.PaymentMethodCodes), invoice.DueDate,
+		nullIfEmpty(invoice.ContingencyID),
 	)
 	if err != nil {
 		if isUniqueViolation(err) {
@@ -92,17 +113,57 @@ func (r *InvoiceRepo) Create(invoice *entity.Invoice) error {
 	return nil
 }
 
+// currencyOrDefault moneda del documento; vacío = COP.
+func currencyOrDefault(code string) string {
+	if code == "" {
+		return "COP"
+	}
+	return code
+}
+
+// rateOrOne tasa de cambio del documento; sin tasa (facturas en COP) se guarda 1.
+func rateOrOne(rate decimal.Decimal) decimal.Decimal {
+	if !rate.IsPositive() {
+		return decimal.NewFromInt(1)
+	}
+	return rate
+}
+
+// invoiceTypeOrDefault tipo de factura; vacío = 01 venta nacional.
+func invoiceTypeOrDefault(code string) string {
+	if code == "" {
+		return "01"
+	}
+	return code
+}
+
+// paymentFormOrDefault forma de pago; vacío = 1 contado.
+func paymentFormOrDefault(code string) string {
+	if code == "" {
+		return "1"
+	}
+	return code
+}
+
+// paymentMethodsOrDefault medios de pago; vacío = 10 efectivo.
+func paymentMethodsOrDefault(codes []string) []string {
+	if len(codes) == 0 {
+		return []string{"10"}
+	}
+	return codes
+}
+
 // CreateDetail persiste una línea de detalle.
 func (r *InvoiceRepo) CreateDetail(detail *entity.InvoiceDetail) error {
 	if detail.ID == "" {
 		detail.ID = uuid.New().String()
 	}
 	query := `
-		INSERT INTO invoice_details (id, invoice_id, product_id, quantity, unit_price, tax_rate, subtotal)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		INSERT INTO invoice_details (id, invoice_id, product_id, quantity, unit_price, tax_rate, subtotal, discount_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 	_, err := r.q.Exec(context.Background(), query,
 		detail.ID, detail.InvoiceID, detail.ProductID, detail.Quantity, detail.UnitPrice,
-		detail.TaxRate, detail.Subtotal,
+		detail.TaxRate, detail.Subtotal, detail.DiscountAmount,
 	)
 	if err != nil {
 		return fmt.Errorf("insert invoice detail: %w", err)
@@ -110,7 +171,8 @@ func (r *InvoiceRepo) CreateDetail(detail *entity.InvoiceDetail) error {
 	return nil
 }
 
-// Update actualiza todos los campos DIAN de la factura.
+// Update actualiza todos los campos DIAN de la factura. Las reglas de validación solo se reemplazan
+// si la factura trae DIANRules (validación local); nil conserva las guardadas.
 func (r *InvoiceRepo) Update(invoice *entity.Invoice) error {
 	query := `
 		UPDATE invoices
@@ -121,8 +183,13 @@ func (r *InvoiceRepo) Update(invoice *entity.Invoice) error {
 		    qr_data       = COALESCE($6,  qr_data),
 		    track_id_dian = COALESCE($7,  track_id_dian),
 		    dian_errors   = COALESCE($8,  dian_errors),
-		    updated_at    = $9
+		    updated_at    = $9,
+		    dian_rules    = COALESCE($10, dian_rules)
 		WHERE id = $1`
+	var rules any
+	if invoice.DIANRules != nil {
+		rules = invoice.DIANRules
+	}
 	_, err := r.q.Exec(context.Background(), query,
 		invoice.ID,
 		nullIfEmpty(invoice.CUFE),
@@ -133,6 +200,7 @@ func (r *InvoiceRepo) Update(invoice *entity.Invoice) error {
 		nullIfEmpty(invoice.TrackID),
 		nullIfEmpty(invoice.DIANErrors),
 		invoice.UpdatedAt,
+		rules,
 	)
 	if err != nil {
 		return fmt.Errorf("update invoice: %w", err)
@@ -153,7 +221,14 @@ func (r *InvoiceRepo) GetByID(id string) (*entity.Invoice, error) {
 		       original_invoice_issue_on,
 		       discrepancy_code,
 		       discrepancy_reason,
-		       created_at, updated_at
+		       created_at, updated_at,
+		       withholding_total,
+		       discount_total, allowance_total, charge_total,
+		       currency_code, exchange_rate, invoice_type_code, incoterm,
+		       net_total_cop, tax_total_cop, grand_total_cop,
+		       payment_form_code, payment_method_codes, due_date,
+		       COALESCE(dian_application_response, ''),
+		       COALESCE(contingency_id::text, '')
 		FROM invoices WHERE id = $1`
 	var inv entity.Invoice
 	var cufe, uuid, xmlSigned, qrData, trackID, dianErrors *string
@@ -172,6 +247,13 @@ func (r *InvoiceRepo) GetByID(id string) (*entity.Invoice, error) {
 		&discCode,
 		&discReason,
 		&inv.CreatedAt, &inv.UpdatedAt,
+		&inv.WithholdingTotal,
+		&inv.DiscountTotal, &inv.AllowanceTotal, &inv.ChargeTotal,
+		&inv.CurrencyCode, &inv.ExchangeRate, &inv.InvoiceTypeCode, &inv.Incoterm,
+		&inv.NetTotalCOP, &inv.TaxTotalCOP, &inv.GrandTotalCOP,
+		&inv.PaymentFormCode, &inv.PaymentMethodCodes, &inv.DueDate,
+		&inv.ApplicationResponse,
+		&inv.ContingencyID,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -209,12 +291,14 @@ func (r *InvoiceRepo) GetByID(id string) (*entity.Invoice, error) {
 func (r *InvoiceRepo) GetDIANStatus(id string) (*entity.Invoice, error) {
 	const query = `
 		SELECT id, company_id, dian_status,
-		       COALESCE(cufe, ''), COALESCE(track_id_dian, ''), COALESCE(dian_errors, '')
+		       COALESCE(cufe, ''), COALESCE(track_id_dian, ''), COALESCE(dian_errors, ''),
+		       dian_rules
 		FROM invoices WHERE id = $1`
 	var inv entity.Invoice
 	err := r.q.QueryRow(context.Background(), query, id).Scan(
 		&inv.ID, &inv.CompanyID, &inv.DIAN_Status,
 		&inv.CUFE, &inv.TrackID, &inv.DIANErrors,
+		&inv.DIANRules,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -250,7 +334,7 @@ func (r *InvoiceRepo) GetDIANSummary(companyID string) (*repository.DIANSummary,
 // GetDetailsByInvoiceID obtiene todas las líneas de una factura.
 func (r *InvoiceRepo) GetDetailsByInvoiceID(invoiceID string) ([]*entity.InvoiceDetail, error) {
 	query := `
-		SELECT id, invoice_id, product_id, quantity, unit_price, tax_rate, subtotal
+		SELECT id, invoice_id, product_id, quantity, unit_price, tax_rate, subtotal, discount_amount
 		FROM invoice_details WHERE invoice_id = $1 ORDER BY id`
 	rows, err := r.q.Query(context.Background(), query, invoiceID)
 	if err != nil {
@@ -260,7 +344,7 @@ func (r *InvoiceRepo) GetDetailsByInvoiceID(invoiceID string) ([]*entity.Invoice
 	var list []*entity.InvoiceDetail
 	for rows.Next() {
 		var d entity.InvoiceDetail
-		if err := rows.Scan(&d.ID, &d.InvoiceID, &d.ProductID, &d.Quantity, &d.UnitPrice, &d.TaxRate, &d.Subtotal); err != nil {
+		if err := rows.Scan(&d.ID, &d.InvoiceID, &d.ProductID, &d.Quantity, &d.UnitPrice, &d.TaxRate, &d.Subtotal, &d.DiscountAmount); err != nil {
 			return nil, fmt.Errorf("scan detail: %w", err)
 		}
 		list = append(list, &d)
@@ -268,6 +352,279 @@ func (r *InvoiceRepo) GetDetailsByInvoiceID(invoiceID string) ([]*entity.Invoice
 	return list, rows.Err()
 }
 
+// LockActiveResolution bloquea (FOR UPDATE) la resolución activa de la empresa para el prefijo.
+func (r *InvoiceRepo) LockActiveResolution(companyID, prefix string) (*entity.BillingResolution, error) {
+	res, err := scanResolution(r.q.QueryRow(context.Background(), `
+		SELECT id, company_id, resolution_number, prefix, range_from, range_to,
+		       date_from, date_to, environment, document_type, used_numbers, is_active, created_at, updated_at
+		FROM billing_resolutions
+		WHERE company_id = $1 AND prefix = $2 AND is_active = true
+		ORDER BY date_from DESC
+		LIMIT 1
+		FOR UPDATE`, companyID, prefix))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("lock active billing_resolution: %w", err)
+	}
+	return res, nil
+}
+
+// LockActiveNoteResolution bloquea (FOR UPDATE) la resolución activa más reciente de la empresa para
+// notas del tipo dado (CREDIT_NOTE o DEBIT_NOTE).
+func (r *InvoiceRepo) LockActiveNoteResolution(companyID, documentType string) (*entity.BillingResolution, error) {
+	res, err := scanResolution(r.q.QueryRow(context.Background(), `
+		SELECT id, company_id, resolution_number, prefix, range_from, range_to,
+		       date_from, date_to, environment, document_type, used_numbers, is_active, created_at, updated_at
+		FROM billing_resolutions
+		WHERE company_id = $1 AND document_type = $2 AND is_active = true
+		ORDER BY date_from DESC
+		LIMIT 1
+		FOR UPDATE`, companyID, documentType))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("lock active note billing_resolution: %w", err)
+	}
+	return res, nil
+}
+
+// NextNoteSequence incrementa (o crea en 1) la secuencia de notas de la empresa para el tipo; el
+// upsert deja la fila bloqueada hasta el commit.
+func (r *InvoiceRepo) NextNoteSequence(companyID, documentType string) (int64, error) {
+	var n int64
+	err := r.q.QueryRow(context.Background(), `
+		INSERT INTO note_sequences (company_id, document_type, last_number)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (company_id, document_type)
+		DO UPDATE SET last_number = note_sequences.last_number + 1, updated_at = now()
+		RETURNING last_number`, companyID, documentType).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("next note sequence: %w", err)
+	}
+	return n, nil
+}
+
+// ConsumeResolutionNumber incrementa used_numbers de la resolución.
+func (r *InvoiceRepo) ConsumeResolutionNumber(resolutionID string) error {
+	tag, err := r.q.Exec(context.Background(), `
+		UPDATE billing_resolutions SET used_numbers = used_numbers + 1, updated_at = now()
+		WHERE id = $1`, resolutionID)
+	if err != nil {
+		return fmt.Errorf("consume billing_resolution number: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("consume billing_resolution number: resolución %s no encontrada", resolutionID)
+	}
+	return nil
+}
+
+// CreateKitComponent persiste una línea del desglose de un kit facturado.
+func (r *InvoiceRepo) CreateKitComponent(line *entity.InvoiceKitComponent) error {
+	if line.ID == "" {
+		line.ID = uuid.New().String()
+	}
+	_, err := r.q.Exec(context.Background(), `
+		INSERT INTO invoice_kit_components (id, invoice_id, kit_product_id, component_product_id, quantity, unit_price, unit_cost)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		line.ID, line.InvoiceID, line.KitProductID, line.ComponentProductID, line.Quantity, line.UnitPrice, line.UnitCost,
+	)
+	if err != nil {
+		return fmt.Errorf("insert invoice kit component: %w", err)
+	}
+	return nil
+}
+
+// GetKitComponentsByInvoiceID devuelve el desglose de kits de la factura.
+func (r *InvoiceRepo) GetKitComponentsByInvoiceID(invoiceID string) ([]*entity.InvoiceKitComponent, error) {
+	rows, err := r.q.Query(context.Background(), `
+		SELECT id, invoice_id, kit_product_id, component_product_id, quantity, unit_price, unit_cost
+		FROM invoice_kit_components WHERE invoice_id = $1 ORDER BY kit_product_id, component_product_id`, invoiceID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list invoice kit components: %w", err)
+	}
+	defer rows.Close()
+	var list []*entity.InvoiceKitComponent
+	for rows.Next() {
+		var l entity.InvoiceKitComponent
+		if err := rows.Scan(&l.ID, &l.InvoiceID, &l.KitProductID, &l.ComponentProductID, &l.Quantity, &l.UnitPrice, &l.UnitCost); err != nil {
+			return nil, fmt.Errorf("scan invoice kit component: %w", err)
+		}
+		list = append(list, &l)
+	}
+	return list, rows.Err()
+}
+
+// CreateTax persiste un impuesto de línea en invoice_taxes.
+func (r *InvoiceRepo) CreateTax(tax *entity.InvoiceTax) error {
+	if tax.ID == "" {
+		tax.ID = uuid.New().String()
+	}
+	_, err := r.q.Exec(context.Background(), `
+		INSERT INTO invoice_taxes (id, invoice_id, invoice_detail_id, tax_code, base_amount, rate, per_unit_amount, quantity, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		tax.ID, tax.InvoiceID, nullIfEmpty(tax.InvoiceDetailID), tax.TaxCode, tax.BaseAmount, tax.Rate,
+		tax.PerUnitAmount, tax.Quantity, tax.Amount,
+	)
+	if err != nil {
+		return fmt.Errorf("insert invoice tax: %w", err)
+	}
+	return nil
+}
+
+// GetTaxesByInvoiceID devuelve el desglose de impuestos del documento.
+// Sin la migración 049 (esquema sin desglose por línea) devuelve vacío.
+func (r *InvoiceRepo) GetTaxesByInvoiceID(invoiceID string) ([]*entity.InvoiceTax, error) {
+	rows, err := r.q.Query(context.Background(), `
+		SELECT id, invoice_id, COALESCE(invoice_detail_id::text, ''), tax_code, base_amount, rate,
+		       per_unit_amount, quantity, amount
+		FROM invoice_taxes WHERE invoice_id = $1 ORDER BY tax_code, rate`, invoiceID)
+	if err != nil {
+		if isUndefinedTable(err) || isUndefinedColumnError(err, "invoice_detail_id") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list invoice taxes: %w", err)
+	}
+	defer rows.Close()
+	var list []*entity.InvoiceTax
+	for rows.Next() {
+		var t entity.InvoiceTax
+		if err := rows.Scan(&t.ID, &t.InvoiceID, &t.InvoiceDetailID, &t.TaxCode, &t.BaseAmount, &t.Rate,
+			&t.PerUnitAmount, &t.Quantity, &t.Amount); err != nil {
+			return nil, fmt.Errorf("scan invoice tax: %w", err)
+		}
+		list = append(list, &t)
+	}
+	return list, rows.Err()
+}
+
+// CreateWithholding persiste una retención practicada sobre la factura en invoice_withholdings.
+func (r *InvoiceRepo) CreateWithholding(w *entity.InvoiceWithholding) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	_, err := r.q.Exec(context.Background(), `
+		INSERT INTO invoice_withholdings (id, invoice_id, tax_code, concept, base_amount, rate, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		w.ID, w.InvoiceID, w.TaxCode, w.Concept, w.BaseAmount, w.Rate, w.Amount,
+	)
+	if err != nil {
+		return fmt.Errorf("insert invoice withholding: %w", err)
+	}
+	return nil
+}
+
+// GetWithholdingsByInvoiceID devuelve las retenciones de la factura.
+// Sin la migración 050 devuelve vacío.
+func (r *InvoiceRepo) GetWithholdingsByInvoiceID(invoiceID string) ([]*entity.InvoiceWithholding, error) {
+	rows, err := r.q.Query(context.Background(), `
+		SELECT id, invoice_id, tax_code, concept, base_amount, rate, amount
+		FROM invoice_withholdings WHERE invoice_id = $1 ORDER BY tax_code, concept`, invoiceID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list invoice withholdings: %w", err)
+	}
+	defer rows.Close()
+	var list []*entity.InvoiceWithholding
+	for rows.Next() {
+		var w entity.InvoiceWithholding
+		if err := rows.Scan(&w.ID, &w.InvoiceID, &w.TaxCode, &w.Concept, &w.BaseAmount, &w.Rate, &w.Amount); err != nil {
+			return nil, fmt.Errorf("scan invoice withholding: %w", err)
+		}
+		list = append(list, &w)
+	}
+	return list, rows.Err()
+}
+
+// CreateAllowanceCharge persiste un descuento o cargo (de línea o global) en invoice_allowance_charges.
+func (r *InvoiceRepo) CreateAllowanceCharge(ac *entity.InvoiceAllowanceCharge) error {
+	if ac.ID == "" {
+		ac.ID = uuid.New().String()
+	}
+	_, err := r.q.Exec(context.Background(), `
+		INSERT INTO invoice_allowance_charges (id, invoice_id, invoice_detail_id, charge_indicator, reason_code, reason, rate, base_amount, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		ac.ID, ac.InvoiceID, nullIfEmpty(ac.InvoiceDetailID), ac.ChargeIndicator, ac.ReasonCode, ac.Reason,
+		ac.Rate, ac.BaseAmount, ac.Amount,
+	)
+	if err != nil {
+		return fmt.Errorf("insert invoice allowance charge: %w", err)
+	}
+	return nil
+}
+
+// GetAllowanceChargesByInvoiceID devuelve los descuentos y cargos del documento.
+// Sin la migración 051 devuelve vacío.
+func (r *InvoiceRepo) GetAllowanceChargesByInvoiceID(invoiceID string) ([]*entity.InvoiceAllowanceCharge, error) {
+	rows, err := r.q.Query(context.Background(), `
+		SELECT id, invoice_id, COALESCE(invoice_detail_id::text, ''), charge_indicator, reason_code, reason,
+		       rate, base_amount, amount
+		FROM invoice_allowance_charges WHERE invoice_id = $1 ORDER BY charge_indicator, id`, invoiceID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list invoice allowance charges: %w", err)
+	}
+	defer rows.Close()
+	var list []*entity.InvoiceAllowanceCharge
+	for rows.Next() {
+		var ac entity.InvoiceAllowanceCharge
+		if err := rows.Scan(&ac.ID, &ac.InvoiceID, &ac.InvoiceDetailID, &ac.ChargeIndicator, &ac.ReasonCode, &ac.Reason,
+			&ac.Rate, &ac.BaseAmount, &ac.Amount); err != nil {
+			return nil, fmt.Errorf("scan invoice allowance charge: %w", err)
+		}
+		list = append(list, &ac)
+	}
+	return list, rows.Err()
+}
+
+// CreateInstallment persiste una cuota del plan de pagos en invoice_installments.
+func (r *InvoiceRepo) CreateInstallment(inst *entity.InvoiceInstallment) error {
+	if inst.ID == "" {
+		inst.ID = uuid.New().String()
+	}
+	_, err := r.q.Exec(context.Background(), `
+		INSERT INTO invoice_installments (id, invoice_id, installment_number, due_date, amount)
+		VALUES ($1, $2, $3, $4, $5)`,
+		inst.ID, inst.InvoiceID, inst.Number, inst.DueDate, inst.Amount,
+	)
+	if err != nil {
+		return fmt.Errorf("insert invoice installment: %w", err)
+	}
+	return nil
+}
+
+// GetInstallmentsByInvoiceID devuelve las cuotas de la factura en orden. Sin la migración 053 devuelve vacío.
+func (r *InvoiceRepo) GetInstallmentsByInvoiceID(invoiceID string) ([]*entity.InvoiceInstallment, error) {
+	rows, err := r.q.Query(context.Background(), `
+		SELECT id, invoice_id, installment_number, due_date, amount
+		FROM invoice_installments WHERE invoice_id = $1 ORDER BY installment_number`, invoiceID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list invoice installments: %w", err)
+	}
+	defer rows.Close()
+	var list []*entity.InvoiceInstallment
+	for rows.Next() {
+		var inst entity.InvoiceInstallment
+		if err := rows.Scan(&inst.ID, &inst.InvoiceID, &inst.Number, &inst.DueDate, &inst.Amount); err != nil {
+			return nil, fmt.Errorf("scan invoice installment: %w", err)
+		}
+		list = append(list, &inst)
+	}
+	return list, rows.Err()
+}
+
 // UpdateReturnStatus marca una factura como devuelta total o parcialmente.
 // Esta implementación almacena el estado en la columna notes, preservando cualquier contenido previo.
 func (r *InvoiceRepo) UpdateReturnStatus(invoiceID string, status string) error {
@@ -364,7 +721,8 @@ func (r *InvoiceRepo) List(filter repository.InvoiceListFilter) ([]*entity.Invoi
 		       original_invoice_issue_on,
 		       COALESCE(discrepancy_code, ''),
 		       COALESCE(discrepancy_reason, ''),
-		       created_at, updated_at
+		       created_at, updated_at,
+		       currency_code, exchange_rate, grand_total_cop
 		FROM invoices
 		WHERE %s
 		ORDER BY date DESC, created_at DESC
@@ -394,6 +752,7 @@ func (r *InvoiceRepo) List(filter repository.InvoiceListFilter) ([]*entity.Invoi
 			&discCode,
 			&inv.DiscrepancyReason,
 			&inv.CreatedAt, &inv.UpdatedAt,
+			&inv.CurrencyCode, &inv.ExchangeRate, &inv.GrandTotalCOP,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("scan invoice row: %w", err)
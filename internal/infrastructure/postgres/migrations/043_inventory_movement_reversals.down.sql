@@ -0,0 +1,48 @@
+-- 043_inventory_movement_reversals.down.sql
+
+CREATE OR REPLACE FUNCTION actualizar_costo_promedio()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_total_qty  DECIMAL(15,4);
+    v_total_cost DECIMAL(15,4);
+    v_new_cost   DECIMAL(15,4);
+BEGIN
+    IF NEW.type <> 'IN' OR NEW.quantity <= 0 THEN
+        RETURN NEW;
+    END IF;
+
+    SELECT
+        COALESCE(SUM(quantity),   0),
+        COALESCE(SUM(total_cost), 0)
+      INTO v_total_qty, v_total_cost
+      FROM inventory_movements
+     WHERE product_id = NEW.product_id
+       AND type = 'IN';
+
+    IF v_total_qty > 0 THEN
+        v_new_cost := v_total_cost / v_total_qty;
+
+        UPDATE products
+           SET cost       = ROUND(v_new_cost, 4),
+               updated_at = now()
+         WHERE id = NEW.product_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$;
+
+DROP INDEX IF EXISTS uq_inventory_movements_reversal_of;
+
+DELETE FROM inventory_movements WHERE type = 'REVERSAL';
+
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_type_check;
+ALTER TABLE inventory_movements ADD CONSTRAINT inventory_movements_type_check
+    CHECK (type IN ('IN', 'OUT', 'ADJUSTMENT', 'TRANSFER', 'RETURN'));
+
+ALTER TABLE inventory_movements
+    DROP COLUMN IF EXISTS reversed_by,
+    DROP COLUMN IF EXISTS reversed_at,
+    DROP COLUMN IF EXISTS reversal_of_id;
@@ -0,0 +1,54 @@
+-- 043_inventory_movement_reversals.up.sql
+-- Reversión de movimientos de inventario mediante asientos compensatorios.
+
+ALTER TABLE inventory_movements
+    ADD COLUMN IF NOT EXISTS notes          TEXT,
+    ADD COLUMN IF NOT EXISTS reversal_of_id UUID REFERENCES inventory_movements(id) ON DELETE RESTRICT,
+    ADD COLUMN IF NOT EXISTS reversed_at    TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS reversed_by    UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Ampliar el CHECK de type con devoluciones y reversiones.
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_type_check;
+ALTER TABLE inventory_movements ADD CONSTRAINT inventory_movements_type_check
+    CHECK (type IN ('IN', 'OUT', 'ADJUSTMENT', 'TRANSFER', 'RETURN', 'REVERSAL'));
+
+-- Un movimiento solo puede tener un asiento compensatorio (evita doble reversión).
+CREATE UNIQUE INDEX IF NOT EXISTS uq_inventory_movements_reversal_of
+    ON inventory_movements (reversal_of_id)
+    WHERE reversal_of_id IS NOT NULL;
+
+-- El costo promedio del trigger ignora las entradas revertidas.
+CREATE OR REPLACE FUNCTION actualizar_costo_promedio()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_total_qty  DECIMAL(15,4);
+    v_total_cost DECIMAL(15,4);
+    v_new_cost   DECIMAL(15,4);
+BEGIN
+    IF NEW.type <> 'IN' OR NEW.quantity <= 0 THEN
+        RETURN NEW;
+    END IF;
+
+    SELECT
+        COALESCE(SUM(quantity),   0),
+        COALESCE(SUM(total_cost), 0)
+      INTO v_total_qty, v_total_cost
+      FROM inventory_movements
+     WHERE product_id = NEW.product_id
+       AND type = 'IN'
+       AND reversed_at IS NULL;
+
+    IF v_total_qty > 0 THEN
+        v_new_cost := v_total_cost / v_total_qty;
+
+        UPDATE products
+           SET cost       = ROUND(v_new_cost, 4),
+               updated_at = now()
+         WHERE id = NEW.product_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$;
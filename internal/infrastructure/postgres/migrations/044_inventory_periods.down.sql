@@ -0,0 +1,5 @@
+-- 044_inventory_periods.down.sql
+
+DROP TABLE IF EXISTS inventory_period_events;
+DROP TABLE IF EXISTS inventory_period_valuations;
+DROP TABLE IF EXISTS inventory_periods;
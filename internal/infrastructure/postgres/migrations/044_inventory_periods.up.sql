@@ -0,0 +1,40 @@
+-- 044_inventory_periods.up.sql
+-- Cierres mensuales de inventario: valorización congelada y bitácora de cierre/reapertura.
+
+CREATE TABLE IF NOT EXISTS inventory_periods (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id  UUID        NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    year        SMALLINT    NOT NULL,
+    month       SMALLINT    NOT NULL CHECK (month BETWEEN 1 AND 12),
+    status      VARCHAR(10) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'CLOSED')),
+    closed_at   TIMESTAMPTZ,
+    closed_by   UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (company_id, year, month)
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_periods_company_status
+    ON inventory_periods (company_id, status, year, month);
+
+CREATE TABLE IF NOT EXISTS inventory_period_valuations (
+    period_id    UUID          NOT NULL REFERENCES inventory_periods(id) ON DELETE CASCADE,
+    product_id   UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id UUID          NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    quantity     DECIMAL(15,4) NOT NULL,
+    unit_cost    DECIMAL(15,4) NOT NULL DEFAULT 0,
+    total_value  DECIMAL(15,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (period_id, product_id, warehouse_id)
+);
+
+CREATE TABLE IF NOT EXISTS inventory_period_events (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    period_id  UUID        NOT NULL REFERENCES inventory_periods(id) ON DELETE CASCADE,
+    action     VARCHAR(10) NOT NULL CHECK (action IN ('CLOSE', 'REOPEN')),
+    reason     TEXT,
+    user_id    UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_period_events_period
+    ON inventory_period_events (period_id, created_at);
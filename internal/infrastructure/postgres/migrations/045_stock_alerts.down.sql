@@ -0,0 +1,5 @@
+-- 045_stock_alerts.down.sql
+
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS stock_alert_subscriptions;
+DROP TABLE IF EXISTS stock_alerts;
@@ -0,0 +1,56 @@
+-- 045_stock_alerts.up.sql
+-- Alertas de stock bajo por bodega, suscripciones de usuarios y notificaciones in-app.
+
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id   UUID          NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    product_id   UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id UUID          NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    kind         VARCHAR(20)   NOT NULL CHECK (kind IN ('REORDER_POINT', 'MIN_STOCK')),
+    quantity     DECIMAL(15,4) NOT NULL,
+    threshold    DECIMAL(15,4) NOT NULL,
+    status       VARCHAR(10)   NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'RESOLVED')),
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    resolved_at  TIMESTAMPTZ
+);
+
+-- Deduplicación: una sola alerta abierta por producto, bodega y tipo.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_alerts_open
+    ON stock_alerts (product_id, warehouse_id, kind)
+    WHERE status = 'OPEN';
+
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_company_status
+    ON stock_alerts (company_id, status, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS stock_alert_subscriptions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id    UUID        NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id       UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    warehouse_id  UUID        REFERENCES warehouses(id) ON DELETE CASCADE, -- NULL = todas las bodegas
+    notify_email  BOOLEAN     NOT NULL DEFAULT true,
+    notify_in_app BOOLEAN     NOT NULL DEFAULT true,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_alert_subscriptions_user_warehouse
+    ON stock_alert_subscriptions (user_id, COALESCE(warehouse_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+CREATE INDEX IF NOT EXISTS idx_stock_alert_subscriptions_company
+    ON stock_alert_subscriptions (company_id, warehouse_id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id   UUID         NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id      UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind         VARCHAR(30)  NOT NULL,
+    title        VARCHAR(200) NOT NULL,
+    body         TEXT         NOT NULL DEFAULT '',
+    reference_id UUID,
+    read_at      TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread
+    ON notifications (user_id, created_at DESC)
+    WHERE read_at IS NULL;
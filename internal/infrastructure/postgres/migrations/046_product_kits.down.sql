@@ -0,0 +1,5 @@
+-- 046_product_kits.down.sql
+
+DROP TABLE IF EXISTS invoice_kit_components;
+DROP TABLE IF EXISTS product_kit_components;
+ALTER TABLE products DROP COLUMN IF EXISTS product_type;
@@ -0,0 +1,32 @@
+-- 046_product_kits.up.sql
+-- Productos tipo KIT (cajas/combos armados al vender) y su composición.
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS product_type VARCHAR(20) NOT NULL DEFAULT 'STANDARD'
+        CHECK (product_type IN ('STANDARD', 'KIT'));
+
+CREATE TABLE IF NOT EXISTS product_kit_components (
+    kit_product_id       UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    component_product_id UUID          NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    quantity             DECIMAL(15,4) NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (kit_product_id, component_product_id),
+    CHECK (kit_product_id <> component_product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_kit_components_component
+    ON product_kit_components (component_product_id);
+
+-- Desglose de los kits facturados: componentes despachados por unidad de kit,
+-- con precio prorrateado y costo al momento de la venta.
+CREATE TABLE IF NOT EXISTS invoice_kit_components (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id           UUID          NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    kit_product_id       UUID          NOT NULL REFERENCES products(id),
+    component_product_id UUID          NOT NULL REFERENCES products(id),
+    quantity             DECIMAL(15,4) NOT NULL CHECK (quantity > 0),
+    unit_price           DECIMAL(15,2) NOT NULL DEFAULT 0,
+    unit_cost            DECIMAL(15,4) NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_kit_components_invoice
+    ON invoice_kit_components (invoice_id);
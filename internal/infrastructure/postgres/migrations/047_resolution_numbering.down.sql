@@ -0,0 +1,3 @@
+-- 047_resolution_numbering.down.sql
+
+ALTER TABLE billing_resolutions DROP COLUMN IF EXISTS used_numbers;
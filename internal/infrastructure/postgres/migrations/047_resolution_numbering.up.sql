@@ -0,0 +1,19 @@
+-- 047_resolution_numbering.up.sql
+-- Consecutivo de facturación por resolución: used_numbers lleva la cuenta de números
+-- asignados del rango y se incrementa dentro de la transacción de cada documento,
+-- con la fila de la resolución bloqueada (SELECT ... FOR UPDATE).
+
+ALTER TABLE billing_resolutions
+    ADD COLUMN IF NOT EXISTS used_numbers BIGINT NOT NULL DEFAULT 0 CHECK (used_numbers >= 0);
+
+-- Arrancar después del mayor número numérico ya emitido dentro del rango, para no repetir
+-- consecutivos de facturas existentes.
+UPDATE billing_resolutions br
+SET used_numbers = COALESCE((
+        SELECT MAX(i.number::bigint) - br.range_from + 1
+        FROM invoices i
+        WHERE i.company_id = br.company_id
+          AND i.prefix = br.prefix
+          AND i.number ~ '^[0-9]+$'
+          AND i.number::bigint BETWEEN br.range_from AND br.range_to
+    ), 0);
@@ -0,0 +1,4 @@
+-- 048_resolution_alerts.down.sql
+
+DROP INDEX IF EXISTS idx_invoices_company_prefix_date;
+DROP TABLE IF EXISTS resolution_alerts;
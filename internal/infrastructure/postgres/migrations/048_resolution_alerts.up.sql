@@ -0,0 +1,20 @@
+-- 048_resolution_alerts.up.sql
+-- Alertas de agotamiento / vencimiento de resoluciones de facturación DIAN.
+-- Cada resolución genera como máximo una alerta por tipo (se notifica una sola vez).
+
+CREATE TABLE IF NOT EXISTS resolution_alerts (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id    UUID        NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    resolution_id UUID        NOT NULL REFERENCES billing_resolutions(id) ON DELETE CASCADE,
+    kind          VARCHAR(20) NOT NULL CHECK (kind IN ('LOW_NUMBERS', 'NEAR_EXPIRY')),
+    message       TEXT        NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (resolution_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_resolution_alerts_company
+    ON resolution_alerts (company_id, created_at DESC);
+
+-- Velocidad de facturación por prefijo (conteo de facturas recientes).
+CREATE INDEX IF NOT EXISTS idx_invoices_company_prefix_date
+    ON invoices (company_id, prefix, date);
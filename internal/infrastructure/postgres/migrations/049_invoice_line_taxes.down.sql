@@ -0,0 +1,10 @@
+-- 049_invoice_line_taxes.down.sql
+
+DROP INDEX IF EXISTS idx_invoice_taxes_detail;
+ALTER TABLE invoice_taxes DROP COLUMN IF EXISTS quantity;
+ALTER TABLE invoice_taxes DROP COLUMN IF EXISTS per_unit_amount;
+ALTER TABLE invoice_taxes DROP COLUMN IF EXISTS invoice_detail_id;
+
+ALTER TABLE products DROP COLUMN IF EXISTS unit_tax_amount;
+ALTER TABLE products DROP COLUMN IF EXISTS unit_tax_code;
+ALTER TABLE products DROP COLUMN IF EXISTS inc_rate;
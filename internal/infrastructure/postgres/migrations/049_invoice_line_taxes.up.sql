@@ -0,0 +1,14 @@
+-- 049_invoice_line_taxes.up.sql
+-- Impuestos múltiples por línea de factura (IVA, INC y tributos por unidad como las bolsas).
+-- El producto parametriza el INC y el impuesto por unidad; cada línea facturada guarda su
+-- desglose en invoice_taxes para armar los TaxTotal del XML y el CUFE.
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS inc_rate DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (inc_rate >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unit_tax_code VARCHAR(4) NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unit_tax_amount DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (unit_tax_amount >= 0);
+
+ALTER TABLE invoice_taxes ADD COLUMN IF NOT EXISTS invoice_detail_id UUID REFERENCES invoice_details(id) ON DELETE CASCADE;
+ALTER TABLE invoice_taxes ADD COLUMN IF NOT EXISTS per_unit_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE invoice_taxes ADD COLUMN IF NOT EXISTS quantity DECIMAL(15,4) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_invoice_taxes_detail ON invoice_taxes(invoice_detail_id);
@@ -0,0 +1,8 @@
+-- 050_withholdings.down.sql
+
+DROP TABLE IF EXISTS invoice_withholdings;
+ALTER TABLE invoices DROP COLUMN IF EXISTS withholding_total;
+DROP TABLE IF EXISTS withholding_rules;
+ALTER TABLE products  DROP COLUMN IF EXISTS withholding_concept;
+ALTER TABLE customers DROP COLUMN IF EXISTS fiscal_responsibilities;
+ALTER TABLE companies DROP COLUMN IF EXISTS fiscal_responsibilities;
@@ -0,0 +1,34 @@
+-- 050_withholdings.up.sql
+-- Retenciones en la fuente (ReteFuente, ReteIVA, ReteICA) practicadas por clientes agentes de retención.
+-- Las responsabilidades fiscales (Tabla 17 DIAN: O-13, O-15, O-23…) del emisor y del cliente deciden
+-- qué retenciones aplican; las tarifas y bases mínimas (en UVT) se parametrizan por empresa y concepto.
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS fiscal_responsibilities TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS fiscal_responsibilities TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE products  ADD COLUMN IF NOT EXISTS withholding_concept VARCHAR(30) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS withholding_rules (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id   UUID          NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    tax_code     VARCHAR(4)    NOT NULL CHECK (tax_code IN ('05', '06', '07')),
+    concept      VARCHAR(30)   NOT NULL,
+    rate         DECIMAL(7,4)  NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    min_base_uvt DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (min_base_uvt >= 0),
+    is_active    BOOLEAN       NOT NULL DEFAULT true,
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    UNIQUE (company_id, tax_code, concept)
+);
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS withholding_total DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS invoice_withholdings (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id  UUID          NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    tax_code    VARCHAR(4)    NOT NULL,
+    concept     VARCHAR(30)   NOT NULL,
+    base_amount DECIMAL(15,2) NOT NULL,
+    rate        DECIMAL(7,4)  NOT NULL,
+    amount      DECIMAL(15,2) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_invoice_withholdings_invoice ON invoice_withholdings(invoice_id);
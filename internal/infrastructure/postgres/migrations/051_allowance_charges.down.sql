@@ -0,0 +1,6 @@
+-- 051_allowance_charges.down.sql
+
+DROP TABLE IF EXISTS invoice_allowance_charges;
+ALTER TABLE invoices DROP COLUMN IF EXISTS charge_total;
+ALTER TABLE invoices DROP COLUMN IF EXISTS allowance_total;
+ALTER TABLE invoice_details DROP COLUMN IF EXISTS discount_amount;
@@ -0,0 +1,22 @@
+-- 051_allowance_charges.up.sql
+-- Descuentos y cargos (cac:AllowanceCharge del UBL): descuentos por línea (porcentaje o valor) y
+-- descuentos/cargos globales de la factura (ej: flete) con código de motivo DIAN (Tabla 13.3.8).
+-- invoices.discount_total (migración 020) acumula los descuentos de línea y globales.
+
+ALTER TABLE invoice_details ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS allowance_total DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS charge_total    DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS invoice_allowance_charges (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id        UUID          NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    invoice_detail_id UUID          REFERENCES invoice_details(id) ON DELETE CASCADE,
+    charge_indicator  BOOLEAN       NOT NULL DEFAULT false,
+    reason_code       VARCHAR(4)    NOT NULL DEFAULT '',
+    reason            TEXT          NOT NULL DEFAULT '',
+    rate              DECIMAL(7,4)  NOT NULL DEFAULT 0,
+    base_amount       DECIMAL(15,2) NOT NULL,
+    amount            DECIMAL(15,2) NOT NULL CHECK (amount >= 0)
+);
+CREATE INDEX IF NOT EXISTS idx_invoice_allowance_charges_invoice ON invoice_allowance_charges(invoice_id);
@@ -0,0 +1,11 @@
+-- 052_foreign_currency.down.sql
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS grand_total_cop;
+ALTER TABLE invoices DROP COLUMN IF EXISTS tax_total_cop;
+ALTER TABLE invoices DROP COLUMN IF EXISTS net_total_cop;
+ALTER TABLE invoices DROP COLUMN IF EXISTS incoterm;
+ALTER TABLE invoices DROP COLUMN IF EXISTS invoice_type_code;
+ALTER TABLE invoices DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE invoices DROP COLUMN IF EXISTS currency_code;
+ALTER TABLE customers DROP COLUMN IF EXISTS country_code;
+ALTER TABLE customers DROP COLUMN IF EXISTS identification_type;
@@ -0,0 +1,28 @@
+-- 052_foreign_currency.up.sql
+-- Facturas en moneda extranjera (USD, EUR…) y de exportación (InvoiceTypeCode 02 con Incoterms).
+-- Los totales se guardan en la moneda del documento y en COP con la tasa de cambio (TRM) de la
+-- factura; la analítica convierte a COP con exchange_rate.
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS identification_type VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS country_code        VARCHAR(2) NOT NULL DEFAULT 'CO';
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS currency_code     VARCHAR(3)    NOT NULL DEFAULT 'COP';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS exchange_rate     DECIMAL(15,4) NOT NULL DEFAULT 1 CHECK (exchange_rate > 0);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS invoice_type_code VARCHAR(2)    NOT NULL DEFAULT '01';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS incoterm          VARCHAR(3)    NOT NULL DEFAULT '';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS net_total_cop     DECIMAL(15,2);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tax_total_cop     DECIMAL(15,2);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS grand_total_cop   DECIMAL(15,2);
+
+UPDATE invoices
+SET net_total_cop   = net_total,
+    tax_total_cop   = tax_total,
+    grand_total_cop = grand_total
+WHERE grand_total_cop IS NULL;
+
+ALTER TABLE invoices ALTER COLUMN net_total_cop   SET NOT NULL;
+ALTER TABLE invoices ALTER COLUMN tax_total_cop   SET NOT NULL;
+ALTER TABLE invoices ALTER COLUMN grand_total_cop SET NOT NULL;
+ALTER TABLE invoices ALTER COLUMN net_total_cop   SET DEFAULT 0;
+ALTER TABLE invoices ALTER COLUMN tax_total_cop   SET DEFAULT 0;
+ALTER TABLE invoices ALTER COLUMN grand_total_cop SET DEFAULT 0;
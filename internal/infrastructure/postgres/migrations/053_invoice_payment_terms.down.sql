@@ -0,0 +1,9 @@
+-- 053_invoice_payment_terms.down.sql
+
+DROP TABLE IF EXISTS invoice_installments;
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS due_date;
+ALTER TABLE invoices DROP COLUMN IF EXISTS payment_method_codes;
+ALTER TABLE invoices DROP COLUMN IF EXISTS payment_form_code;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS payment_term_days;
@@ -0,0 +1,20 @@
+-- 053_invoice_payment_terms.up.sql
+-- Ventas a crédito: forma de pago (Tabla 14: 1 contado, 2 crédito), medios de pago (Tabla 13),
+-- fecha de vencimiento y plan de cuotas opcional por factura. El plazo de pago del cliente
+-- define el vencimiento por defecto de sus facturas a crédito.
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS payment_term_days INTEGER NOT NULL DEFAULT 0 CHECK (payment_term_days >= 0);
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS payment_form_code    VARCHAR(2) NOT NULL DEFAULT '1';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS payment_method_codes TEXT[]     NOT NULL DEFAULT '{10}';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS due_date             DATE;
+
+CREATE TABLE IF NOT EXISTS invoice_installments (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id         UUID          NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    installment_number INTEGER       NOT NULL CHECK (installment_number > 0),
+    due_date           DATE          NOT NULL,
+    amount             DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    UNIQUE (invoice_id, installment_number)
+);
+CREATE INDEX IF NOT EXISTS idx_invoice_installments_due_date ON invoice_installments(due_date);
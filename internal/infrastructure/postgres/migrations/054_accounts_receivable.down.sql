@@ -0,0 +1,5 @@
+-- 054_accounts_receivable.down.sql
+
+DROP TABLE IF EXISTS payment_applications;
+DROP TABLE IF EXISTS customer_payments;
+ALTER TABLE invoices DROP COLUMN IF EXISTS amount_paid;
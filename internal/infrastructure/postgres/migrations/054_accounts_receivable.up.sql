@@ -0,0 +1,33 @@
+-- 054_accounts_receivable.up.sql
+-- Cartera: recaudos de clientes (efectivo, transferencia, tarjeta…) aplicados a una o varias facturas.
+-- invoices.amount_paid acumula lo abonado; el saldo de una factura es el neto a pagar (total menos
+-- retenciones) más notas débito, menos notas crédito y menos lo abonado. Lo recibido que no se aplica
+-- a facturas queda como saldo a favor del cliente (unapplied_amount) para aplicarlo después.
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS amount_paid DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (amount_paid >= 0);
+
+CREATE TABLE IF NOT EXISTS customer_payments (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id       UUID          NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    customer_id      UUID          NOT NULL REFERENCES customers(id) ON DELETE RESTRICT,
+    payment_date     DATE          NOT NULL,
+    amount           DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency_code    VARCHAR(3)    NOT NULL DEFAULT 'COP',
+    method_code      VARCHAR(3)    NOT NULL,
+    reference        TEXT          NOT NULL DEFAULT '',
+    notes            TEXT          NOT NULL DEFAULT '',
+    unapplied_amount DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (unapplied_amount >= 0 AND unapplied_amount <= amount),
+    created_by       UUID,
+    created_at       TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_customer_payments_customer ON customer_payments(company_id, customer_id, payment_date);
+
+CREATE TABLE IF NOT EXISTS payment_applications (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID          NOT NULL REFERENCES customer_payments(id) ON DELETE CASCADE,
+    invoice_id UUID          NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    amount     DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    applied_at TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_payment_applications_payment ON payment_applications(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_applications_invoice ON payment_applications(invoice_id);
@@ -0,0 +1,7 @@
+-- 055_dian_status_polling.down.sql
+
+DROP INDEX IF EXISTS idx_invoices_dian_awaiting_status;
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS dian_next_check_at;
+ALTER TABLE invoices DROP COLUMN IF EXISTS dian_status_checks;
+ALTER TABLE invoices DROP COLUMN IF EXISTS dian_rules;
@@ -0,0 +1,12 @@
+-- 055_dian_status_polling.up.sql
+-- Seguimiento de envíos asíncronos a la DIAN: las facturas quedan en 'Sent' con su TrackID hasta
+-- que GetStatusZip devuelve el resultado definitivo. Se guardan las reglas de validación
+-- (rechazos y notificaciones) y el backoff de las consultas.
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS dian_rules         JSONB       NOT NULL DEFAULT '[]';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS dian_status_checks INTEGER     NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS dian_next_check_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_invoices_dian_awaiting_status
+    ON invoices(dian_next_check_at)
+    WHERE dian_status = 'Sent';
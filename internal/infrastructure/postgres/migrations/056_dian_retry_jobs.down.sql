@@ -0,0 +1,3 @@
+-- 056_dian_retry_jobs.down.sql
+
+DROP TABLE IF EXISTS dian_retry_jobs;
@@ -0,0 +1,16 @@
+-- 056_dian_retry_jobs.up.sql
+-- Cola persistente de reintentos DIAN: una fila por factura en CONTINGENCIA con intentos,
+-- próximo intento (backoff exponencial) y estado DEAD cuando se agotan los intentos.
+
+CREATE TABLE IF NOT EXISTS dian_retry_jobs (
+    invoice_id      UUID PRIMARY KEY REFERENCES invoices(id) ON DELETE CASCADE,
+    company_id      UUID        NOT NULL,
+    status          VARCHAR(10) NOT NULL DEFAULT 'QUEUED' CHECK (status IN ('QUEUED', 'DEAD')),
+    attempts        INTEGER     NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_dian_retry_jobs_due ON dian_retry_jobs(next_attempt_at) WHERE status = 'QUEUED';
+CREATE INDEX IF NOT EXISTS idx_dian_retry_jobs_company ON dian_retry_jobs(company_id, status);
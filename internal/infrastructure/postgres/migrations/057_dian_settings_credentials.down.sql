@@ -0,0 +1,5 @@
+-- 057_dian_settings_credentials.down.sql
+
+ALTER TABLE dian_settings DROP COLUMN IF EXISTS software_pin_encrypted;
+ALTER TABLE dian_settings DROP COLUMN IF EXISTS software_id;
+ALTER TABLE dian_settings DROP COLUMN IF EXISTS technical_key;
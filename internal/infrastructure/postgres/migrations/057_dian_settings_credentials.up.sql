@@ -0,0 +1,7 @@
+-- 057_dian_settings_credentials.up.sql
+-- Credenciales DIAN por empresa y ambiente: además del certificado, la clave técnica del rango
+-- de numeración y el identificador/PIN del software (el PIN se guarda cifrado como la contraseña).
+
+ALTER TABLE dian_settings ADD COLUMN IF NOT EXISTS technical_key          TEXT         NOT NULL DEFAULT '';
+ALTER TABLE dian_settings ADD COLUMN IF NOT EXISTS software_id            VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE dian_settings ADD COLUMN IF NOT EXISTS software_pin_encrypted TEXT         NOT NULL DEFAULT '';
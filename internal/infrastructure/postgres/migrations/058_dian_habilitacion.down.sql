@@ -0,0 +1,4 @@
+-- 058_dian_habilitacion.down.sql
+
+DROP TABLE IF EXISTS dian_habilitacion_documents;
+DROP TABLE IF EXISTS dian_habilitaciones;
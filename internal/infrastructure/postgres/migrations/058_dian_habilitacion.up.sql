@@ -0,0 +1,42 @@
+-- 058_dian_habilitacion.up.sql
+-- Habilitación DIAN guiada: cada proceso genera el set de pruebas (facturas, notas crédito y
+-- débito) con la resolución de pruebas, lo envía con SendTestSetAsync y sigue su validación.
+
+CREATE TABLE IF NOT EXISTS dian_habilitaciones (
+    id           UUID PRIMARY KEY,
+    company_id   UUID         NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    test_set_id  VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(10)  NOT NULL,
+    status       VARCHAR(20)  NOT NULL DEFAULT 'IN_PROGRESS'
+        CHECK (status IN ('IN_PROGRESS', 'ACCEPTED', 'FAILED')),
+    last_error   TEXT         NOT NULL DEFAULT '',
+    created_by   UUID,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ
+);
+
+-- Un solo proceso en curso por empresa.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_dian_habilitaciones_in_progress
+    ON dian_habilitaciones(company_id) WHERE status = 'IN_PROGRESS';
+CREATE INDEX IF NOT EXISTS idx_dian_habilitaciones_company ON dian_habilitaciones(company_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS dian_habilitacion_documents (
+    id              UUID PRIMARY KEY,
+    habilitacion_id UUID         NOT NULL REFERENCES dian_habilitaciones(id) ON DELETE CASCADE,
+    sequence        INTEGER      NOT NULL,
+    document_type   VARCHAR(20)  NOT NULL CHECK (document_type IN ('INVOICE', 'CREDIT_NOTE', 'DEBIT_NOTE')),
+    prefix          VARCHAR(10)  NOT NULL,
+    number          VARCHAR(20)  NOT NULL,
+    cufe            VARCHAR(96)  NOT NULL,
+    xml_signed      TEXT         NOT NULL,
+    zip_name        VARCHAR(100) NOT NULL,
+    track_id        VARCHAR(100) NOT NULL DEFAULT '',
+    status          VARCHAR(20)  NOT NULL DEFAULT 'PENDING'
+        CHECK (status IN ('PENDING', 'SENT', 'ACCEPTED', 'REJECTED')),
+    errors          TEXT         NOT NULL DEFAULT '',
+    status_checks   INTEGER      NOT NULL DEFAULT 0,
+    next_check_at   TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    UNIQUE (habilitacion_id, sequence)
+);
@@ -0,0 +1,4 @@
+-- 059_support_documents.down.sql
+
+DROP TABLE IF EXISTS support_document_lines;
+DROP TABLE IF EXISTS support_documents;
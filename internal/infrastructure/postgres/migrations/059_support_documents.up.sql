@@ -0,0 +1,61 @@
+-- 059_support_documents.up.sql
+-- Documento soporte en adquisiciones a no obligados a facturar (y su nota de ajuste): lo emite la
+-- empresa como adquiriente, con resolución/prefijo propio y CUDS, por compras recibidas o manuales.
+
+CREATE TABLE IF NOT EXISTS support_documents (
+    id                           UUID PRIMARY KEY,
+    company_id                   UUID          NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    supplier_id                  UUID          NOT NULL REFERENCES suppliers(id) ON DELETE RESTRICT,
+    purchase_order_id            UUID          REFERENCES purchase_orders(id) ON DELETE SET NULL,
+    document_type                VARCHAR(20)   NOT NULL DEFAULT 'SUPPORT_DOCUMENT'
+        CHECK (document_type IN ('SUPPORT_DOCUMENT', 'SUPPORT_ADJUSTMENT')),
+    prefix                       VARCHAR(10)   NOT NULL,
+    number                       VARCHAR(20)   NOT NULL,
+    date                         TIMESTAMPTZ   NOT NULL,
+    supplier_name                VARCHAR(255)  NOT NULL,
+    supplier_identification      VARCHAR(50)   NOT NULL,
+    supplier_identification_type VARCHAR(2)    NOT NULL DEFAULT '13',
+    net_total                    NUMERIC(18,2) NOT NULL DEFAULT 0,
+    tax_total                    NUMERIC(18,2) NOT NULL DEFAULT 0,
+    grand_total                  NUMERIC(18,2) NOT NULL DEFAULT 0,
+    cuds                         VARCHAR(96)   NOT NULL DEFAULT '',
+    xml_signed                   TEXT          NOT NULL DEFAULT '',
+    dian_status                  VARCHAR(30)   NOT NULL DEFAULT 'DRAFT',
+    dian_errors                  TEXT          NOT NULL DEFAULT '',
+    track_id                     VARCHAR(100)  NOT NULL DEFAULT '',
+    status_checks                INTEGER       NOT NULL DEFAULT 0,
+    next_check_at                TIMESTAMPTZ,
+    original_document_id         UUID          REFERENCES support_documents(id) ON DELETE RESTRICT,
+    original_document_number     VARCHAR(30)   NOT NULL DEFAULT '',
+    original_document_cuds       VARCHAR(96)   NOT NULL DEFAULT '',
+    original_issue_on            TIMESTAMPTZ,
+    discrepancy_code             VARCHAR(2)    NOT NULL DEFAULT '',
+    discrepancy_reason           TEXT          NOT NULL DEFAULT '',
+    notes                        TEXT          NOT NULL DEFAULT '',
+    created_by                   UUID          REFERENCES users(id) ON DELETE SET NULL,
+    created_at                   TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    updated_at                   TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    UNIQUE (company_id, prefix, number)
+);
+
+-- Una orden de compra origina a lo sumo un documento soporte.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_support_documents_purchase_order
+    ON support_documents(purchase_order_id) WHERE document_type = 'SUPPORT_DOCUMENT' AND purchase_order_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_support_documents_company ON support_documents(company_id, date DESC);
+-- Envíos pendientes de validación (Sent) o de reenvío (CONTINGENCIA) para el worker.
+CREATE INDEX IF NOT EXISTS idx_support_documents_pending
+    ON support_documents(next_check_at) WHERE dian_status IN ('Sent', 'CONTINGENCIA');
+
+CREATE TABLE IF NOT EXISTS support_document_lines (
+    id                  UUID PRIMARY KEY,
+    support_document_id UUID          NOT NULL REFERENCES support_documents(id) ON DELETE CASCADE,
+    line_number         INTEGER       NOT NULL,
+    product_id          UUID          REFERENCES products(id) ON DELETE SET NULL,
+    description         VARCHAR(500)  NOT NULL,
+    quantity            NUMERIC(18,4) NOT NULL,
+    unit_price          NUMERIC(18,2) NOT NULL,
+    tax_rate            NUMERIC(7,4)  NOT NULL DEFAULT 0,
+    subtotal            NUMERIC(18,2) NOT NULL,
+    tax_amount          NUMERIC(18,2) NOT NULL DEFAULT 0,
+    UNIQUE (support_document_id, line_number)
+);
@@ -0,0 +1,3 @@
+-- 060_pos_documents.down.sql
+
+DROP INDEX IF EXISTS idx_invoices_pos_drafts;
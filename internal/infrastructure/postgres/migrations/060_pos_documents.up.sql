@@ -0,0 +1,8 @@
+-- 060_pos_documents.up.sql
+-- Documento equivalente electrónico POS: se guarda en invoices con document_type = 'POS' e
+-- invoice_type_code = '20', numerado con la resolución de su propio prefijo. El barrido de
+-- documentos POS que siguen en DRAFT (cola de envío llena o reinicio) usa este índice.
+
+CREATE INDEX IF NOT EXISTS idx_invoices_pos_drafts
+    ON invoices(created_at)
+    WHERE document_type = 'POS' AND dian_status = 'DRAFT';
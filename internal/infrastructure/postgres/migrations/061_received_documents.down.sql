@@ -0,0 +1,5 @@
+-- 061_received_documents.down.sql
+
+DROP TABLE IF EXISTS received_document_events;
+DROP TABLE IF EXISTS received_document_event_sequences;
+DROP TABLE IF EXISTS received_documents;
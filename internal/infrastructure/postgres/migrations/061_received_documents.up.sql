@@ -0,0 +1,73 @@
+-- 061_received_documents.up.sql
+-- Facturas electrónicas recibidas de proveedores (la empresa es el adquiriente) y su historial de eventos
+-- RADIAN (ApplicationResponse): acuse 030, recibo del bien 032, aceptación expresa 033, reclamo 031 y
+-- aceptación tácita 034 (registro local).
+
+CREATE TABLE IF NOT EXISTS received_documents (
+    id                 UUID PRIMARY KEY,
+    company_id         UUID          NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    supplier_id        UUID          REFERENCES suppliers(id) ON DELETE SET NULL,
+    issuer_nit         VARCHAR(20)   NOT NULL,
+    issuer_name        VARCHAR(255)  NOT NULL,
+    number             VARCHAR(30)   NOT NULL,
+    document_type_code VARCHAR(2)    NOT NULL DEFAULT '01',
+    cufe               VARCHAR(96)   NOT NULL,
+    issue_date         TIMESTAMPTZ   NOT NULL,
+    net_total          NUMERIC(18,2) NOT NULL DEFAULT 0,
+    tax_total          NUMERIC(18,2) NOT NULL DEFAULT 0,
+    grand_total        NUMERIC(18,2) NOT NULL DEFAULT 0,
+    xml                TEXT          NOT NULL DEFAULT '',
+    source             VARCHAR(20)   NOT NULL DEFAULT 'MANUAL',
+    status             VARCHAR(20)   NOT NULL DEFAULT 'RECEIVED'
+        CHECK (status IN ('RECEIVED', 'ACKNOWLEDGED', 'GOODS_RECEIVED', 'ACCEPTED', 'CLAIMED', 'TACITLY_ACCEPTED')),
+    goods_received_at  TIMESTAMPTZ,
+    created_by         UUID          REFERENCES users(id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    updated_at         TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    UNIQUE (company_id, cufe)
+);
+
+CREATE INDEX IF NOT EXISTS idx_received_documents_company ON received_documents(company_id, issue_date DESC);
+-- Candidatas a la aceptación tácita para el worker.
+CREATE INDEX IF NOT EXISTS idx_received_documents_goods_received
+    ON received_documents(goods_received_at) WHERE status = 'GOODS_RECEIVED';
+
+-- Consecutivo de eventos por empresa (cbc:ID del ApplicationResponse).
+CREATE TABLE IF NOT EXISTS received_document_event_sequences (
+    company_id  UUID    PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    last_number BIGINT  NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS received_document_events (
+    id                   UUID PRIMARY KEY,
+    received_document_id UUID          NOT NULL REFERENCES received_documents(id) ON DELETE CASCADE,
+    company_id           UUID          NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    event_code           VARCHAR(3)    NOT NULL CHECK (event_code IN ('030', '031', '032', '033', '034')),
+    number               VARCHAR(20)   NOT NULL DEFAULT '',
+    date                 TIMESTAMPTZ   NOT NULL,
+    claim_concept        VARCHAR(2)    NOT NULL DEFAULT '',
+    note                 TEXT          NOT NULL DEFAULT '',
+    person_id            VARCHAR(30)   NOT NULL DEFAULT '',
+    person_name          VARCHAR(255)  NOT NULL DEFAULT '',
+    person_job_title     VARCHAR(100)  NOT NULL DEFAULT '',
+    cude                 VARCHAR(96)   NOT NULL DEFAULT '',
+    xml_signed           TEXT          NOT NULL DEFAULT '',
+    dian_status          VARCHAR(30)   NOT NULL DEFAULT 'DRAFT',
+    dian_errors          TEXT          NOT NULL DEFAULT '',
+    track_id             VARCHAR(100)  NOT NULL DEFAULT '',
+    status_checks        INTEGER       NOT NULL DEFAULT 0,
+    next_check_at        TIMESTAMPTZ,
+    created_by           UUID          REFERENCES users(id) ON DELETE SET NULL,
+    created_at           TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    updated_at           TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+-- Cada evento se emite una vez por factura; los rechazados o fallidos pueden volver a emitirse.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_received_document_events_code
+    ON received_document_events(received_document_id, event_code)
+    WHERE dian_status NOT IN ('RECHAZADO', 'ERROR_GENERATION', 'Error');
+CREATE INDEX IF NOT EXISTS idx_received_document_events_document
+    ON received_document_events(received_document_id, date);
+-- Reenvíos pendientes (CONTINGENCIA) para el worker.
+CREATE INDEX IF NOT EXISTS idx_received_document_events_pending
+    ON received_document_events(next_check_at) WHERE dian_status = 'CONTINGENCIA';
@@ -0,0 +1,15 @@
+-- 062_received_documents_mailbox.down.sql
+
+DROP TABLE IF EXISTS email_invoice_ingestions;
+DROP TABLE IF EXISTS received_document_taxes;
+DROP TABLE IF EXISTS received_document_lines;
+
+ALTER TABLE received_documents DROP COLUMN IF EXISTS validation_errors;
+ALTER TABLE received_documents DROP COLUMN IF EXISTS cufe_valid;
+ALTER TABLE received_documents DROP COLUMN IF EXISTS signature_valid;
+ALTER TABLE received_documents DROP COLUMN IF EXISTS email_attachment_id;
+ALTER TABLE received_documents DROP COLUMN IF EXISTS purchase_order_id;
+ALTER TABLE received_documents DROP COLUMN IF EXISTS currency;
+ALTER TABLE received_documents DROP COLUMN IF EXISTS customer_nit;
+
+ALTER TABLE email_attachments DROP COLUMN IF EXISTS content;
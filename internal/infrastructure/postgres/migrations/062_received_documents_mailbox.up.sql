@@ -0,0 +1,54 @@
+-- 062_received_documents_mailbox.up.sql
+-- Ingesta de facturas electrónicas de proveedores desde el buzón: contenido de los adjuntos ZIP/XML
+-- sincronizados por IMAP, detalle de la factura leído del UBL (líneas e impuestos), validación de firma y
+-- CUFE, conciliación con la orden de compra y bitácora de adjuntos procesados.
+
+ALTER TABLE email_attachments ADD COLUMN IF NOT EXISTS content BYTEA;
+
+ALTER TABLE received_documents ADD COLUMN IF NOT EXISTS customer_nit        VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE received_documents ADD COLUMN IF NOT EXISTS currency            VARCHAR(3)  NOT NULL DEFAULT 'COP';
+ALTER TABLE received_documents ADD COLUMN IF NOT EXISTS purchase_order_id   UUID REFERENCES purchase_orders(id) ON DELETE SET NULL;
+ALTER TABLE received_documents ADD COLUMN IF NOT EXISTS email_attachment_id UUID REFERENCES email_attachments(id) ON DELETE SET NULL;
+ALTER TABLE received_documents ADD COLUMN IF NOT EXISTS signature_valid     BOOLEAN;
+ALTER TABLE received_documents ADD COLUMN IF NOT EXISTS cufe_valid          BOOLEAN;
+ALTER TABLE received_documents ADD COLUMN IF NOT EXISTS validation_errors   TEXT        NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS received_document_lines (
+    id                   UUID PRIMARY KEY,
+    received_document_id UUID          NOT NULL REFERENCES received_documents(id) ON DELETE CASCADE,
+    line_number          INTEGER       NOT NULL,
+    item_code            VARCHAR(100)  NOT NULL DEFAULT '',
+    description          TEXT          NOT NULL DEFAULT '',
+    quantity             NUMERIC(18,4) NOT NULL DEFAULT 0,
+    unit_code            VARCHAR(10)   NOT NULL DEFAULT '',
+    unit_price           NUMERIC(18,4) NOT NULL DEFAULT 0,
+    line_extension       NUMERIC(18,2) NOT NULL DEFAULT 0,
+    tax_amount           NUMERIC(18,2) NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_received_document_lines_document
+    ON received_document_lines(received_document_id, line_number);
+
+-- Totales por impuesto y tarifa (cac:TaxTotal de la factura).
+CREATE TABLE IF NOT EXISTS received_document_taxes (
+    received_document_id UUID          NOT NULL REFERENCES received_documents(id) ON DELETE CASCADE,
+    tax_code             VARCHAR(4)    NOT NULL,
+    tax_name             VARCHAR(30)   NOT NULL DEFAULT '',
+    percent              NUMERIC(7,3)  NOT NULL DEFAULT 0,
+    taxable_amount       NUMERIC(18,2) NOT NULL DEFAULT 0,
+    tax_amount           NUMERIC(18,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (received_document_id, tax_code, percent)
+);
+
+-- Un registro por adjunto candidato ya procesado, para no volver a leerlo.
+CREATE TABLE IF NOT EXISTS email_invoice_ingestions (
+    attachment_id        UUID PRIMARY KEY REFERENCES email_attachments(id) ON DELETE CASCADE,
+    company_id           UUID         NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    status               VARCHAR(20)  NOT NULL CHECK (status IN ('IMPORTED', 'DUPLICATE', 'IGNORED', 'FAILED')),
+    received_document_id UUID         REFERENCES received_documents(id) ON DELETE SET NULL,
+    error                TEXT         NOT NULL DEFAULT '',
+    processed_at         TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_invoice_ingestions_company
+    ON email_invoice_ingestions(company_id, processed_at DESC);
@@ -0,0 +1,3 @@
+-- 063_invoice_application_response.down.sql
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS dian_application_response;
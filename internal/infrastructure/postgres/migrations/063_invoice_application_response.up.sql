@@ -0,0 +1,5 @@
+-- 063_invoice_application_response.up.sql
+-- ApplicationResponse con que la DIAN validó la factura (XmlBase64Bytes de GetStatusZip): se conserva
+-- para armar el AttachedDocument que se entrega al adquiriente junto con la representación gráfica.
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS dian_application_response TEXT;
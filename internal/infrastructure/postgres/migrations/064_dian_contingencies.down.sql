@@ -0,0 +1,5 @@
+-- 064_dian_contingencies.down.sql
+
+DROP INDEX IF EXISTS idx_invoices_contingency;
+ALTER TABLE invoices DROP COLUMN IF EXISTS contingency_id;
+DROP TABLE IF EXISTS dian_contingencies;
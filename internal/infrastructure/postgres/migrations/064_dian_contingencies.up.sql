@@ -0,0 +1,26 @@
+-- 064_dian_contingencies.up.sql
+-- Facturación en contingencia (tipo 03): periodos en que la empresa no puede transmitir a la DIAN.
+-- Las facturas del periodo usan la numeración de contingencia (prefix), quedan firmadas y se
+-- transmiten en bloque al terminar la contingencia (plazo de 48 horas).
+
+CREATE TABLE IF NOT EXISTS dian_contingencies (
+    id             UUID PRIMARY KEY,
+    company_id     UUID         NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    prefix         VARCHAR(10)  NOT NULL,
+    reason         TEXT         NOT NULL DEFAULT '',
+    started_at     TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    started_by     UUID,
+    ended_at       TIMESTAMPTZ,
+    ended_by       UUID,
+    transmitted_at TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+-- Una sola contingencia activa por empresa.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_dian_contingencies_active
+    ON dian_contingencies(company_id) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_dian_contingencies_company ON dian_contingencies(company_id, started_at DESC);
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS contingency_id UUID REFERENCES dian_contingencies(id);
+CREATE INDEX IF NOT EXISTS idx_invoices_contingency ON invoices(contingency_id) WHERE contingency_id IS NOT NULL;
@@ -0,0 +1,4 @@
+-- 065_resolution_document_type.down.sql
+
+DROP INDEX IF EXISTS idx_billing_resolutions_document_type;
+ALTER TABLE billing_resolutions DROP COLUMN IF EXISTS document_type;
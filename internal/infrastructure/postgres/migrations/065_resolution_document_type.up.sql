@@ -0,0 +1,30 @@
+-- 065_resolution_document_type.up.sql
+-- Tipo de documento que numera cada resolución: las notas crédito y débito toman su consecutivo de
+-- una resolución propia en lugar de un número derivado de la factura.
+
+ALTER TABLE billing_resolutions
+    ADD COLUMN IF NOT EXISTS document_type VARCHAR(20) NOT NULL DEFAULT 'INVOICE'
+        CHECK (document_type IN ('INVOICE', 'CREDIT_NOTE', 'DEBIT_NOTE'));
+
+-- Resoluciones cuyos prefijos solo se han usado en notas.
+UPDATE billing_resolutions br
+SET document_type = n.document_type
+FROM (
+    SELECT company_id, prefix, MIN(document_type) AS document_type
+    FROM invoices
+    WHERE document_type IN ('CREDIT_NOTE', 'DEBIT_NOTE')
+    GROUP BY company_id, prefix
+    HAVING COUNT(DISTINCT document_type) = 1
+) n
+WHERE br.company_id = n.company_id
+  AND br.prefix = n.prefix
+  AND NOT EXISTS (
+      SELECT 1 FROM invoices i
+      WHERE i.company_id = br.company_id
+        AND i.prefix = br.prefix
+        AND COALESCE(i.document_type, 'INVOICE') NOT IN ('CREDIT_NOTE', 'DEBIT_NOTE')
+  );
+
+CREATE INDEX IF NOT EXISTS idx_billing_resolutions_document_type
+    ON billing_resolutions (company_id, document_type, date_from DESC)
+    WHERE is_active = true;
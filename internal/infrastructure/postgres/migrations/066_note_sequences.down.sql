@@ -0,0 +1,3 @@
+-- 066_note_sequences.down.sql
+
+DROP TABLE IF EXISTS note_sequences;
@@ -0,0 +1,11 @@
+-- 066_note_sequences.up.sql
+-- Secuencia de notas crédito y débito por empresa y tipo, para las empresas sin resolución de notas:
+-- las notas no requieren resolución de la DIAN pero sí un consecutivo propio sin huecos.
+
+CREATE TABLE IF NOT EXISTS note_sequences (
+    company_id    UUID        NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    document_type VARCHAR(20) NOT NULL CHECK (document_type IN ('CREDIT_NOTE', 'DEBIT_NOTE')),
+    last_number   BIGINT      NOT NULL DEFAULT 0,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (company_id, document_type)
+);
@@ -0,0 +1,16 @@
+-- 067_resolution_alert_thresholds.down.sql
+
+ALTER TABLE resolution_alerts DROP CONSTRAINT IF EXISTS resolution_alerts_threshold_key;
+
+-- Conserva la alerta más reciente por resolución y tipo para restaurar la restricción original.
+DELETE FROM resolution_alerts a
+USING resolution_alerts b
+WHERE a.resolution_id = b.resolution_id
+  AND a.kind = b.kind
+  AND (a.created_at, a.id) < (b.created_at, b.id);
+
+ALTER TABLE resolution_alerts
+    ADD CONSTRAINT resolution_alerts_resolution_id_kind_key UNIQUE (resolution_id, kind);
+ALTER TABLE resolution_alerts
+    DROP COLUMN IF EXISTS date_to,
+    DROP COLUMN IF EXISTS range_to;
@@ -0,0 +1,23 @@
+-- 067_resolution_alert_thresholds.up.sql
+-- Las alertas de resolución se registran una vez por cruce de umbral y no de por vida: guardan el
+-- rango final y la vigencia de la resolución al alertar, de modo que al ampliarla (nuevo range_to o
+-- date_to) vuelva a alertarse cuando se acerque al nuevo límite.
+
+ALTER TABLE resolution_alerts
+    ADD COLUMN IF NOT EXISTS range_to BIGINT,
+    ADD COLUMN IF NOT EXISTS date_to  DATE;
+
+UPDATE resolution_alerts a
+SET range_to = br.range_to,
+    date_to  = br.date_to
+FROM billing_resolutions br
+WHERE br.id = a.resolution_id
+  AND (a.range_to IS NULL OR a.date_to IS NULL);
+
+ALTER TABLE resolution_alerts
+    ALTER COLUMN range_to SET NOT NULL,
+    ALTER COLUMN date_to  SET NOT NULL;
+
+ALTER TABLE resolution_alerts DROP CONSTRAINT IF EXISTS resolution_alerts_resolution_id_kind_key;
+ALTER TABLE resolution_alerts
+    ADD CONSTRAINT resolution_alerts_threshold_key UNIQUE (resolution_id, kind, range_to, date_to);
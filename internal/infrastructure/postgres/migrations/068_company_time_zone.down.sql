@@ -0,0 +1,3 @@
+-- 068_company_time_zone.down.sql
+
+ALTER TABLE companies DROP COLUMN IF EXISTS time_zone;
@@ -0,0 +1,6 @@
+-- 068_company_time_zone.up.sql
+-- Zona horaria IANA de la empresa: delimita los meses de los cierres de inventario y el "hoy" de los
+-- movimientos retroactivos, independientemente de la zona horaria del servidor.
+
+ALTER TABLE companies
+    ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'America/Bogota';
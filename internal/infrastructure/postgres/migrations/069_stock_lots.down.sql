@@ -0,0 +1,16 @@
+-- 069_stock_lots.down.sql
+
+DELETE FROM stock_alerts WHERE lot_id IS NOT NULL OR kind IN ('NEAR_EXPIRY', 'EXPIRED');
+
+DROP INDEX IF EXISTS uq_stock_alerts_open;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_alerts_open
+    ON stock_alerts (product_id, warehouse_id, kind)
+    WHERE status = 'OPEN';
+
+ALTER TABLE stock_alerts DROP CONSTRAINT IF EXISTS stock_alerts_kind_check;
+ALTER TABLE stock_alerts
+    ADD CONSTRAINT stock_alerts_kind_check CHECK (kind IN ('REORDER_POINT', 'MIN_STOCK'));
+
+ALTER TABLE stock_alerts DROP COLUMN IF EXISTS lot_id;
+
+DROP TABLE IF EXISTS stock_lots;
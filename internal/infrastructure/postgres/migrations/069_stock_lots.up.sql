@@ -0,0 +1,40 @@
+-- 069_stock_lots.up.sql
+-- Lotes con vencimiento: las entradas que informan fecha de vencimiento crean un lote y las salidas los
+-- consumen FEFO. Las alertas de stock incorporan los vencimientos (una alerta abierta por lote y tipo).
+
+CREATE TABLE IF NOT EXISTS stock_lots (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id       UUID          NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    product_id       UUID          NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id     UUID          NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    lot_number       VARCHAR(64),
+    expiry_date      DATE          NOT NULL,
+    initial_quantity DECIMAL(15,4) NOT NULL CHECK (initial_quantity > 0),
+    quantity         DECIMAL(15,4) NOT NULL CHECK (quantity >= 0),
+    movement_id      UUID          REFERENCES inventory_movements(id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+-- Consumo FEFO por producto y bodega.
+CREATE INDEX IF NOT EXISTS idx_stock_lots_fefo
+    ON stock_lots (product_id, warehouse_id, expiry_date, created_at)
+    WHERE quantity > 0;
+
+-- Barrido diario de vencimientos.
+CREATE INDEX IF NOT EXISTS idx_stock_lots_expiry
+    ON stock_lots (expiry_date)
+    WHERE quantity > 0;
+
+ALTER TABLE stock_alerts
+    ADD COLUMN IF NOT EXISTS lot_id UUID REFERENCES stock_lots(id) ON DELETE CASCADE;
+
+ALTER TABLE stock_alerts DROP CONSTRAINT IF EXISTS stock_alerts_kind_check;
+ALTER TABLE stock_alerts
+    ADD CONSTRAINT stock_alerts_kind_check
+    CHECK (kind IN ('REORDER_POINT', 'MIN_STOCK', 'NEAR_EXPIRY', 'EXPIRED'));
+
+-- Deduplicación: una sola alerta abierta por producto, bodega, tipo y lote.
+DROP INDEX IF EXISTS uq_stock_alerts_open;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_alerts_open
+    ON stock_alerts (product_id, warehouse_id, kind, COALESCE(lot_id, '00000000-0000-0000-0000-000000000000'::uuid))
+    WHERE status = 'OPEN';
@@ -0,0 +1,6 @@
+-- 070_dian_retry_jobs_running.down.sql
+
+UPDATE dian_retry_jobs SET status = 'QUEUED' WHERE status = 'RUNNING';
+ALTER TABLE dian_retry_jobs DROP CONSTRAINT IF EXISTS dian_retry_jobs_status_check;
+ALTER TABLE dian_retry_jobs ADD CONSTRAINT dian_retry_jobs_status_check
+    CHECK (status IN ('QUEUED', 'DEAD'));
@@ -0,0 +1,7 @@
+-- 070_dian_retry_jobs_running.up.sql
+-- Estado RUNNING de la cola de reintentos DIAN: el worker toma los trabajos vencidos con
+-- UPDATE … FOR UPDATE SKIP LOCKED para que dos instancias no reenvíen la misma factura.
+
+ALTER TABLE dian_retry_jobs DROP CONSTRAINT IF EXISTS dian_retry_jobs_status_check;
+ALTER TABLE dian_retry_jobs ADD CONSTRAINT dian_retry_jobs_status_check
+    CHECK (status IN ('QUEUED', 'RUNNING', 'DEAD'));
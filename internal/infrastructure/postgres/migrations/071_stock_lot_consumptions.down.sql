@@ -0,0 +1,4 @@
+-- 071_stock_lot_consumptions.down.sql
+
+DROP INDEX IF EXISTS idx_stock_lots_movement;
+DROP TABLE IF EXISTS stock_lot_consumptions;
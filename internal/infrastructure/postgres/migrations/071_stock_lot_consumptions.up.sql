@@ -0,0 +1,15 @@
+-- 071_stock_lot_consumptions.up.sql
+-- Porciones de lote consumidas por cada movimiento de salida (salidas, ajustes negativos, origen de
+-- traslados y reversiones de entradas). Revertir una salida devuelve exactamente esas porciones a sus lotes.
+
+CREATE TABLE IF NOT EXISTS stock_lot_consumptions (
+    movement_id UUID          NOT NULL REFERENCES inventory_movements(id) ON DELETE CASCADE,
+    lot_id      UUID          NOT NULL REFERENCES stock_lots(id) ON DELETE CASCADE,
+    quantity    DECIMAL(15,4) NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (movement_id, lot_id)
+);
+
+-- Lotes creados por una entrada (se consumen primero al revertirla).
+CREATE INDEX IF NOT EXISTS idx_stock_lots_movement
+    ON stock_lots (movement_id)
+    WHERE movement_id IS NOT NULL;
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/application/inventory"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ inventory.NotificationRepository = (*NotificationRepo)(nil)
+
+// NotificationRepo implementación de notificaciones in-app sobre PostgreSQL.
+type NotificationRepo struct {
+	q Querier
+}
+
+// NewNotificationRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewNotificationRepository(q Querier) *NotificationRepo {
+	return &NotificationRepo{q: q}
+}
+
+// Create inserta una notificación.
+func (r *NotificationRepo) Create(ctx context.Context, n *entity.Notification) error {
+	var referenceID *string
+	if n.ReferenceID != "" {
+		referenceID = &n.ReferenceID
+	}
+	if _, err := r.q.Exec(ctx, `
+		INSERT INTO notifications (id, company_id, user_id, kind, title, body, reference_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		n.ID, n.CompanyID, n.UserID, n.Kind, n.Title, n.Body, referenceID, n.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert notification: %w", err)
+	}
+	return nil
+}
+
+// ListByUser lista las notificaciones del usuario de la más reciente a la más antigua.
+func (r *NotificationRepo) ListByUser(ctx context.Context, companyID, userID string, unreadOnly bool, limit, offset int) ([]*entity.Notification, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, company_id, user_id, kind, title, body, COALESCE(reference_id::text, ''), read_at, created_at
+		FROM notifications
+		WHERE company_id = $1 AND user_id = $2
+		  AND (NOT $3 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5`, companyID, userID, unreadOnly, limit, offset)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.Notification{}, nil
+		}
+		return nil, fmt.Errorf("list notifications: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.Notification, 0)
+	for rows.Next() {
+		var n entity.Notification
+		if err := rows.Scan(&n.ID, &n.CompanyID, &n.UserID, &n.Kind, &n.Title, &n.Body, &n.ReferenceID, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan notification: %w", err)
+		}
+		list = append(list, &n)
+	}
+	return list, rows.Err()
+}
+
+// MarkRead marca la notificación como leída (idempotente). ErrNotFound si no es del usuario.
+func (r *NotificationRepo) MarkRead(ctx context.Context, companyID, userID, id string, readAt time.Time) error {
+	tag, err := r.q.Exec(ctx, `
+		UPDATE notifications SET read_at = COALESCE(read_at, $4)
+		WHERE id = $1 AND company_id = $2 AND user_id = $3`, id, companyID, userID, readAt)
+	if err != nil {
+		return fmt.Errorf("mark notification read: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
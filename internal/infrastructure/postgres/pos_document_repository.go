@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.POSDocumentRepository = (*POSDocumentRepo)(nil)
+
+// POSDocumentRepo consultas de documentos equivalentes POS (invoices con document_type = 'POS').
+type POSDocumentRepo struct {
+	q Querier
+}
+
+// NewPOSDocumentRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewPOSDocumentRepository(q Querier) *POSDocumentRepo {
+	return &POSDocumentRepo{q: q}
+}
+
+// ListStaleDrafts documentos POS en DRAFT creados antes de before, más antiguos primero.
+func (r *POSDocumentRepo) ListStaleDrafts(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id
+		FROM invoices
+		WHERE document_type = $1 AND dian_status = $2 AND created_at < $3
+		ORDER BY created_at
+		LIMIT $4`, entity.DocumentTypePOS, entity.DIANStatusDraft, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list stale pos drafts: %w", err)
+	}
+	defer rows.Close()
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan pos draft: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
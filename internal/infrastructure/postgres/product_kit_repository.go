@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/jhoicas/Inventario-api/internal/domain/repository"
+)
+
+var _ repository.ProductKitRepository = (*ProductKitRepo)(nil)
+
+// ProductKitRepo implementación de la composición de kits sobre PostgreSQL.
+type ProductKitRepo struct {
+	q Querier
+}
+
+// NewProductKitRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewProductKitRepository(q Querier) *ProductKitRepo {
+	return &ProductKitRepo{q: q}
+}
+
+// ListComponents devuelve los componentes del kit con precio, costo y SKU del componente.
+func (r *ProductKitRepo) ListComponents(kitProductID string) ([]*entity.KitComponent, error) {
+	rows, err := r.q.Query(context.Background(), `
+		SELECT kc.kit_product_id, kc.component_product_id, kc.quantity,
+		       p.company_id, p.sku, p.name, p.price, p.cost, p.tax_rate,
+		       COALESCE(p.product_type, 'STANDARD')
+		FROM product_kit_components kc
+		JOIN products p ON p.id = kc.component_product_id
+		WHERE kc.kit_product_id = $1
+		ORDER BY p.sku`, kitProductID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.KitComponent{}, nil
+		}
+		return nil, fmt.Errorf("list kit components: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.KitComponent, 0)
+	for rows.Next() {
+		var kc entity.KitComponent
+		var p entity.Product
+		if err := rows.Scan(&kc.KitProductID, &kc.ComponentProductID, &kc.Quantity,
+			&p.CompanyID, &p.SKU, &p.Name, &p.Price, &p.Cost, &p.TaxRate, &p.ProductType); err != nil {
+			return nil, fmt.Errorf("scan kit component: %w", err)
+		}
+		p.ID = kc.ComponentProductID
+		kc.Component = &p
+		list = append(list, &kc)
+	}
+	return list, rows.Err()
+}
+
+// ReplaceComponents reemplaza atómicamente la composición del kit.
+func (r *ProductKitRepo) ReplaceComponents(kitProductID string, components []*entity.KitComponent) error {
+	ctx := context.Background()
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin kit components tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_kit_components WHERE kit_product_id = $1`, kitProductID); err != nil {
+		return fmt.Errorf("delete kit components: %w", err)
+	}
+	for _, c := range components {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO product_kit_components (kit_product_id, component_product_id, quantity)
+			VALUES ($1, $2, $3)`, kitProductID, c.ComponentProductID, c.Quantity); err != nil {
+			return fmt.Errorf("insert kit component: %w", err)
+		}
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit kit components: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
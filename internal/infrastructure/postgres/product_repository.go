@@ -27,12 +27,13 @@ func NewProductRepository(q Querier) *ProductRepo {
 // Create persiste un nuevo producto. Cost inicia en 0.
 func (r *ProductRepo) Create(product *entity.Product) error {
 	query := `
-		INSERT INTO products (id, company_id, sku, name, description, price, cost, tax_rate, unspsc_code, unit_measure, attributes, cogs, reorder_point, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
+		INSERT INTO products (id, company_id, sku, name, description, price, cost, tax_rate, unspsc_code, unit_measure, attributes, cogs, reorder_point, product_type, inc_rate, unit_tax_code, unit_tax_amount, withholding_concept, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`
 	_, err := r.q.Exec(context.Background(), query,
 		product.ID, product.CompanyID, product.SKU, product.Name, product.Description,
 		product.Price, product.Cost, product.TaxRate, product.UNSPSC_Code, product.UnitMeasure,
-		product.Attributes, product.COGS, product.ReorderPoint, product.CreatedAt, product.UpdatedAt,
+		product.Attributes, product.COGS, product.ReorderPoint, product.TypeOrDefault(),
+		product.IncRate, product.UnitTaxCode, product.UnitTaxAmount, product.WithholdingConcept, product.CreatedAt, product.UpdatedAt,
 	)
 	if err != nil {
 		if isUniqueViolation(err) {
@@ -56,12 +57,18 @@ func (r *ProductRepo) GetByID(id string) (*entity.Product, error) {
 		       COALESCE(attributes, '{}'::jsonb),
 		       COALESCE(cogs, 0),
 		       COALESCE(reorder_point, 0),
+		       COALESCE(product_type, 'STANDARD'),
+		       COALESCE(inc_rate, 0),
+		       COALESCE(unit_tax_code, ''),
+		       COALESCE(unit_tax_amount, 0),
+		       COALESCE(withholding_concept, ''),
 		       created_at, updated_at
 		FROM products WHERE id = $1`
 	var p entity.Product
 	err := r.q.QueryRow(context.Background(), query, id).Scan(
 		&p.ID, &p.CompanyID, &p.SKU, &p.Name, &p.Description, &p.Price, &p.Cost, &p.TaxRate,
-		&p.UNSPSC_Code, &p.UnitMeasure, &p.Attributes, &p.COGS, &p.ReorderPoint, &p.CreatedAt, &p.UpdatedAt,
+		&p.UNSPSC_Code, &p.UnitMeasure, &p.Attributes, &p.COGS, &p.ReorderPoint, &p.ProductType,
+		&p.IncRate, &p.UnitTaxCode, &p.UnitTaxAmount, &p.WithholdingConcept, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -85,12 +92,18 @@ func (r *ProductRepo) GetByCompanyAndSKU(companyID, sku string) (*entity.Product
 		       COALESCE(attributes, '{}'::jsonb),
 		       COALESCE(cogs, 0),
 		       COALESCE(reorder_point, 0),
+		       COALESCE(product_type, 'STANDARD'),
+		       COALESCE(inc_rate, 0),
+		       COALESCE(unit_tax_code, ''),
+		       COALESCE(unit_tax_amount, 0),
+		       COALESCE(withholding_concept, ''),
 		       created_at, updated_at
 		FROM products WHERE company_id = $1 AND sku = $2`
 	var p entity.Product
 	err := r.q.QueryRow(context.Background(), query, companyID, sku).Scan(
 		&p.ID, &p.CompanyID, &p.SKU, &p.Name, &p.Description, &p.Price, &p.Cost, &p.TaxRate,
-		&p.UNSPSC_Code, &p.UnitMeasure, &p.Attributes, &p.COGS, &p.ReorderPoint, &p.CreatedAt, &p.UpdatedAt,
+		&p.UNSPSC_Code, &p.UnitMeasure, &p.Attributes, &p.COGS, &p.ReorderPoint, &p.ProductType,
+		&p.IncRate, &p.UnitTaxCode, &p.UnitTaxAmount, &p.WithholdingConcept, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -104,11 +117,13 @@ func (r *ProductRepo) GetByCompanyAndSKU(companyID, sku string) (*entity.Product
 // Update actualiza un producto existente. No permite modificar Cost ni Stock (se manejan vía movimientos).
 func (r *ProductRepo) Update(product *entity.Product) error {
 	query := `
-		UPDATE products SET name = $2, description = $3, price = $4, tax_rate = $5, unspsc_code = $6, unit_measure = $7, attributes = $8, updated_at = $9
+		UPDATE products SET name = $2, description = $3, price = $4, tax_rate = $5, unspsc_code = $6, unit_measure = $7, attributes = $8, updated_at = $9,
+		       inc_rate = $10, unit_tax_code = $11, unit_tax_amount = $12, withholding_concept = $13
 		WHERE id = $1`
 	cmd, err := r.q.Exec(context.Background(), query,
 		product.ID, product.Name, product.Description, product.Price, product.TaxRate,
 		product.UNSPSC_Code, product.UnitMeasure, product.Attributes, product.UpdatedAt,
+		product.IncRate, product.UnitTaxCode, product.UnitTaxAmount, product.WithholdingConcept,
 	)
 	if err != nil {
 		return fmt.Errorf("update product: %w", err)
@@ -144,6 +159,11 @@ func (r *ProductRepo) ListByCompany(companyID string, limit, offset int) ([]*ent
 		       COALESCE(attributes, '{}'::jsonb),
 		       COALESCE(cogs, 0),
 		       COALESCE(reorder_point, 0),
+		       COALESCE(product_type, 'STANDARD'),
+		       COALESCE(inc_rate, 0),
+		       COALESCE(unit_tax_code, ''),
+		       COALESCE(unit_tax_amount, 0),
+		       COALESCE(withholding_concept, ''),
 		       created_at, updated_at
 		FROM products WHERE company_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
 	rows, err := r.q.Query(context.Background(), query, companyID, limit, offset)
@@ -155,7 +175,8 @@ func (r *ProductRepo) ListByCompany(companyID string, limit, offset int) ([]*ent
 	for rows.Next() {
 		var p entity.Product
 		if err := rows.Scan(&p.ID, &p.CompanyID, &p.SKU, &p.Name, &p.Description, &p.Price, &p.Cost, &p.TaxRate,
-			&p.UNSPSC_Code, &p.UnitMeasure, &p.Attributes, &p.COGS, &p.ReorderPoint, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			&p.UNSPSC_Code, &p.UnitMeasure, &p.Attributes, &p.COGS, &p.ReorderPoint, &p.ProductType,
+			&p.IncRate, &p.UnitTaxCode, &p.UnitTaxAmount, &p.WithholdingConcept, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("scan product: %w", err)
 		}
 		list = append(list, &p)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
 	"github.com/jhoicas/Inventario-api/internal/application/inventory"
 	"github.com/jhoicas/Inventario-api/internal/domain"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
@@ -14,6 +15,7 @@ import (
 )
 
 var _ inventory.PurchaseOrderRepository = (*PurchaseOrderRepo)(nil)
+var _ billing.OpenPurchaseOrderLister = (*PurchaseOrderRepo)(nil)
 
 type PurchaseOrderRepo struct {
 	q Querier
@@ -177,6 +179,47 @@ func (r *PurchaseOrderRepo) ListByCompany(ctx context.Context, companyID string,
 	return list, total, nil
 }
 
+// ListOpenBySupplier devuelve con sus ítems las órdenes del proveedor que aún esperan factura
+// (ENVIADA, CONFIRMADA o RECIBIDA_PARCIAL), más recientes primero.
+func (r *PurchaseOrderRepo) ListOpenBySupplier(ctx context.Context, companyID, supplierID string) ([]*entity.PurchaseOrder, error) {
+	const query = `
+		SELECT id
+		FROM purchase_orders
+		WHERE company_id = $1 AND supplier_id = $2 AND status IN ($3, $4, $5)
+		ORDER BY date DESC, created_at DESC`
+
+	rows, err := r.q.Query(ctx, query, companyID, supplierID,
+		entity.PurchaseOrderStatusSent, entity.PurchaseOrderStatusConfirmed, entity.PurchaseOrderStatusPartialReceipt)
+	if err != nil {
+		return nil, fmt.Errorf("list open purchase orders: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan open purchase order: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate open purchase orders: %w", err)
+	}
+
+	list := make([]*entity.PurchaseOrder, 0, len(ids))
+	for _, id := range ids {
+		po, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if po != nil {
+			list = append(list, po)
+		}
+	}
+	return list, nil
+}
+
 func (r *PurchaseOrderRepo) UpdateStatus(ctx context.Context, id, status string, updatedAt time.Time) error {
 	const query = `
 		UPDATE purchase_orders
@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.ReceivableRepository = (*ReceivableRepo)(nil)
+
+// receivableStatuses lista SQL de entity.ReceivableDIANStatuses: solo las facturas y notas emitidas
+// entran en cartera.
+var receivableStatuses = "('" + strings.Join(entity.ReceivableDIANStatuses, "', '") + "')"
+
+// receivableTotalExpr valor a cobrar de la factura i: neto a pagar (total menos retenciones)
+// más notas débito y menos notas crédito emitidas que la referencian.
+var receivableTotalExpr = receivableTotalBefore("")
+
+// receivableTotalBefore valor a cobrar contando solo las notas con fecha anterior al parámetro
+// cutoff (p. ej. "$3"); vacío = todas las notas.
+func receivableTotalBefore(cutoff string) string {
+	notesCond := ""
+	if cutoff != "" {
+		notesCond = " AND n.date < " + cutoff
+	}
+	return `(i.grand_total - COALESCE(i.withholding_total, 0) + COALESCE((
+		SELECT SUM(CASE WHEN n.document_type = 'DEBIT_NOTE' THEN n.grand_total ELSE -n.grand_total END)
+		FROM invoices n
+		WHERE n.original_invoice_id = i.id
+		  AND n.document_type IN ('CREDIT_NOTE', 'DEBIT_NOTE')
+		  AND n.dian_status IN ` + receivableStatuses + notesCond + `), 0))`
+}
+
+// ReceivableRepo implementación de la cartera (recaudos y saldos de facturas) sobre PostgreSQL.
+type ReceivableRepo struct {
+	q Querier
+}
+
+// NewReceivableRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewReceivableRepository(q Querier) *ReceivableRepo {
+	return &ReceivableRepo{q: q}
+}
+
+// ListInvoices lista las facturas de venta emitidas (sin notas, tiquetes POS pagados en mostrador, borradores,
+// errores ni rechazadas) con su valor a cobrar y lo abonado.
+// Con filter.Cutoff reconstruye ambos valores al corte: facturas y notas anteriores y solo los abonos
+// (payment_applications) aplicados antes del corte.
+func (r *ReceivableRepo) ListInvoices(ctx context.Context, companyID string, filter billing.ReceivableFilter) ([]*entity.ReceivableInvoice, error) {
+	conds := []string{
+		"i.company_id = $1",
+		"COALESCE(i.document_type, 'INVOICE') = 'INVOICE'",
+		"i.dian_status IN " + receivableStatuses,
+	}
+	args := []any{companyID}
+	if filter.CustomerID != "" {
+		args = append(args, filter.CustomerID)
+		conds = append(conds, fmt.Sprintf("i.customer_id = $%d", len(args)))
+	}
+	if filter.CurrencyCode != "" {
+		args = append(args, filter.CurrencyCode)
+		conds = append(conds, fmt.Sprintf("i.currency_code = $%d", len(args)))
+	}
+	totalExpr, paidExpr := receivableTotalExpr, "i.amount_paid"
+	if !filter.Cutoff.IsZero() {
+		args = append(args, filter.Cutoff)
+		cutoff := fmt.Sprintf("$%d", len(args))
+		conds = append(conds, "i.date < "+cutoff)
+		totalExpr = receivableTotalBefore(cutoff)
+		paidExpr = `COALESCE((
+			SELECT SUM(pa.amount) FROM payment_applications pa
+			WHERE pa.invoice_id = i.id AND pa.applied_at < ` + cutoff + `), 0)`
+	}
+	query := `
+		WITH r AS (
+			SELECT i.id, i.customer_id, COALESCE(c.name, ''), i.prefix, i.number, i.date,
+			       COALESCE(i.due_date, i.date::date) AS due, i.currency_code,
+			       ` + totalExpr + ` AS total,
+			       ` + paidExpr + ` AS amount_paid
+			FROM invoices i
+			LEFT JOIN customers c ON c.id = i.customer_id
+			WHERE ` + strings.Join(conds, " AND ") + `
+		)
+		SELECT * FROM r`
+	if filter.OpenOnly {
+		query += ` WHERE total - amount_paid > 0`
+	}
+	query += ` ORDER BY date, number`
+
+	rows, err := r.q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list receivable invoices: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.ReceivableInvoice, 0)
+	for rows.Next() {
+		var inv entity.ReceivableInvoice
+		if err := rows.Scan(&inv.InvoiceID, &inv.CustomerID, &inv.CustomerName, &inv.Prefix, &inv.Number,
+			&inv.Date, &inv.DueDate, &inv.CurrencyCode, &inv.Total, &inv.AmountPaid); err != nil {
+			return nil, fmt.Errorf("scan receivable invoice: %w", err)
+		}
+		list = append(list, &inv)
+	}
+	return list, rows.Err()
+}
+
+// CreatePayment inserta el recaudo y sus aplicaciones y abona las facturas en una transacción.
+func (r *ReceivableRepo) CreatePayment(ctx context.Context, p *entity.Payment) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin create payment tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO customer_payments (id, company_id, customer_id, payment_date, amount, currency_code,
+			method_code, reference, notes, unapplied_amount, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, '')::uuid, $12)`,
+		p.ID, p.CompanyID, p.CustomerID, p.Date, p.Amount, p.CurrencyCode,
+		p.MethodCode, p.Reference, p.Notes, p.UnappliedAmount, p.CreatedBy, p.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert customer payment: %w", err)
+	}
+	if err := applyToInvoices(ctx, tx, p.CompanyID, p.ID, p.Applications); err != nil {
+		return err
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit create payment: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+// ApplyPayment descuenta el saldo a favor del recaudo y abona las facturas en una transacción.
+func (r *ReceivableRepo) ApplyPayment(ctx context.Context, paymentID string, apps []*entity.PaymentApplication) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin apply payment tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	total := decimal.Zero
+	for _, a := range apps {
+		total = total.Add(a.Amount)
+	}
+	var companyID string
+	err = tx.QueryRow(ctx, `
+		UPDATE customer_payments SET unapplied_amount = unapplied_amount - $2
+		WHERE id = $1 AND unapplied_amount >= $2
+		RETURNING company_id`, paymentID, total,
+	).Scan(&companyID)
+	if err != nil {
+		if isNoRows(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("update payment unapplied amount: %w", err)
+	}
+	if err := applyToInvoices(ctx, tx, companyID, paymentID, apps); err != nil {
+		return err
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit apply payment: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+// applyToInvoices inserta las aplicaciones y suma lo abonado a cada factura sin superar su saldo.
+func applyToInvoices(ctx context.Context, q Querier, companyID, paymentID string, apps []*entity.PaymentApplication) error {
+	for _, a := range apps {
+		res, err := q.Exec(ctx, `
+			UPDATE invoices i SET amount_paid = i.amount_paid + $2
+			WHERE i.id = $1 AND i.company_id = $3 AND i.dian_status IN `+receivableStatuses+`
+			  AND `+receivableTotalExpr+` - i.amount_paid >= $2`,
+			a.InvoiceID, a.Amount, companyID,
+		)
+		if err != nil {
+			return fmt.Errorf("update invoice amount_paid: %w", err)
+		}
+		if res.RowsAffected() == 0 {
+			return domain.ErrConflict
+		}
+		if _, err := q.Exec(ctx, `
+			INSERT INTO payment_applications (id, payment_id, invoice_id, amount, applied_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			a.ID, paymentID, a.InvoiceID, a.Amount, a.AppliedAt,
+		); err != nil {
+			return fmt.Errorf("insert payment application: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetPayment obtiene el recaudo con sus aplicaciones; nil si no existe.
+func (r *ReceivableRepo) GetPayment(ctx context.Context, id string) (*entity.Payment, error) {
+	var p entity.Payment
+	err := r.q.QueryRow(ctx, `
+		SELECT `+paymentColumns+`
+		FROM customer_payments WHERE id = $1`, id,
+	).Scan(paymentScanDest(&p)...)
+	if err != nil {
+		if isNoRows(err) || isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get customer payment: %w", err)
+	}
+
+	rows, err := r.q.Query(ctx, `
+		SELECT id, payment_id, invoice_id, amount, applied_at
+		FROM payment_applications WHERE payment_id = $1
+		ORDER BY applied_at, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list payment applications: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a entity.PaymentApplication
+		if err := rows.Scan(&a.ID, &a.PaymentID, &a.InvoiceID, &a.Amount, &a.AppliedAt); err != nil {
+			return nil, fmt.Errorf("scan payment application: %w", err)
+		}
+		p.Applications = append(p.Applications, &a)
+	}
+	return &p, rows.Err()
+}
+
+// ListPayments lista los recaudos de la empresa ordenados por fecha. Con filter.Cutoff solo los
+// anteriores al corte, con el saldo a favor que quedaba antes de las aplicaciones posteriores.
+func (r *ReceivableRepo) ListPayments(ctx context.Context, companyID string, filter billing.PaymentFilter) ([]*entity.Payment, error) {
+	conds := []string{"company_id = $1"}
+	args := []any{companyID}
+	from := "customer_payments"
+	if !filter.Cutoff.IsZero() {
+		args = append(args, filter.Cutoff)
+		cutoff := fmt.Sprintf("$%d", len(args))
+		conds = append(conds, "payment_date < "+cutoff)
+		from = `(
+			SELECT id, company_id, customer_id, payment_date, amount, currency_code, method_code, reference, notes,
+			       amount - COALESCE((
+			           SELECT SUM(pa.amount) FROM payment_applications pa
+			           WHERE pa.payment_id = cp.id AND pa.applied_at < ` + cutoff + `), 0) AS unapplied_amount,
+			       created_by, created_at
+			FROM customer_payments cp) customer_payments`
+	}
+	if filter.CustomerID != "" {
+		args = append(args, filter.CustomerID)
+		conds = append(conds, fmt.Sprintf("customer_id = $%d", len(args)))
+	}
+	if filter.CurrencyCode != "" {
+		args = append(args, filter.CurrencyCode)
+		conds = append(conds, fmt.Sprintf("currency_code = $%d", len(args)))
+	}
+	if filter.UnappliedOnly {
+		conds = append(conds, "unapplied_amount > 0")
+	}
+	rows, err := r.q.Query(ctx, `
+		SELECT `+paymentColumns+`
+		FROM `+from+`
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY payment_date, created_at`, args...)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.Payment{}, nil
+		}
+		return nil, fmt.Errorf("list customer payments: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.Payment, 0)
+	for rows.Next() {
+		var p entity.Payment
+		if err := rows.Scan(paymentScanDest(&p)...); err != nil {
+			return nil, fmt.Errorf("scan customer payment: %w", err)
+		}
+		list = append(list, &p)
+	}
+	return list, rows.Err()
+}
+
+const paymentColumns = `id, company_id, customer_id, payment_date, amount, currency_code, method_code,
+		       COALESCE(reference, ''), COALESCE(notes, ''), unapplied_amount,
+		       COALESCE(created_by::text, ''), created_at`
+
+func paymentScanDest(p *entity.Payment) []any {
+	return []any{&p.ID, &p.CompanyID, &p.CustomerID, &p.Date, &p.Amount, &p.CurrencyCode, &p.MethodCode,
+		&p.Reference, &p.Notes, &p.UnappliedAmount, &p.CreatedBy, &p.CreatedAt}
+}
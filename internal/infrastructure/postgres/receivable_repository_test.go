@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var errReceivableQuery = errors.New("query stopped")
+
+// receivableQuerierFake captura el SQL de ListInvoices y corta la consulta con errReceivableQuery.
+type receivableQuerierFake struct {
+	querySQL string
+}
+
+func (f *receivableQuerierFake) Exec(_ context.Context, _ string, _ ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (f *receivableQuerierFake) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	f.querySQL = sql
+	return nil, errReceivableQuery
+}
+
+func (f *receivableQuerierFake) QueryRow(_ context.Context, _ string, _ ...any) pgx.Row {
+	return nil
+}
+
+func TestReceivableRepo_ListInvoices_OnlyIssuedStatuses(t *testing.T) {
+	for _, filter := range []billing.ReceivableFilter{{}, {Cutoff: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)}} {
+		fake := &receivableQuerierFake{}
+		_, err := NewReceivableRepository(fake).ListInvoices(context.Background(), "company-1", filter)
+		if !errors.Is(err, errReceivableQuery) {
+			t.Fatalf("expected query error, got %v", err)
+		}
+		// Facturas y notas que la referencian.
+		if got := strings.Count(fake.querySQL, "dian_status IN "+receivableStatuses); got != 2 {
+			t.Fatalf("expected issued-status filter on invoices and notes, found %d in SQL: %s", got, fake.querySQL)
+		}
+		for _, status := range entity.ReceivableDIANStatuses {
+			if !strings.Contains(receivableStatuses, "'"+status+"'") {
+				t.Fatalf("status %s missing from %s", status, receivableStatuses)
+			}
+		}
+		for _, status := range []string{
+			entity.DIANStatusDraft,
+			entity.DIANStatusErrorGeneration,
+			entity.DIANStatusError,
+			entity.DIANStatusRechazado,
+		} {
+			if strings.Contains(fake.querySQL, "'"+status+"'") {
+				t.Fatalf("status %s must be excluded, got SQL: %s", status, fake.querySQL)
+			}
+		}
+	}
+}
@@ -0,0 +1,475 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.ReceivedDocumentRepository = (*ReceivedDocumentRepo)(nil)
+
+// ReceivedDocumentRepo implementación de facturas recibidas y eventos RADIAN sobre PostgreSQL
+// (received_documents, received_document_events y el consecutivo de eventos por empresa).
+type ReceivedDocumentRepo struct {
+	q Querier
+}
+
+// NewReceivedDocumentRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewReceivedDocumentRepository(q Querier) *ReceivedDocumentRepo {
+	return &ReceivedDocumentRepo{q: q}
+}
+
+// Create registra la factura recibida con sus líneas e impuestos en una transacción; domain.ErrDuplicate
+// si la empresa ya tiene ese CUFE.
+func (r *ReceivedDocumentRepo) Create(ctx context.Context, d *entity.ReceivedDocument) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin create received document tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if d.Currency == "" {
+		d.Currency = "COP"
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO received_documents (
+			id, company_id, supplier_id, issuer_nit, issuer_name, number, document_type_code, cufe, issue_date,
+			net_total, tax_total, grand_total, xml, source, status, goods_received_at, created_by, created_at, updated_at,
+			customer_nit, currency, purchase_order_id, email_attachment_id, signature_valid, cufe_valid, validation_errors
+		) VALUES (
+			$1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9,
+			$10, $11, $12, $13, $14, $15, $16, NULLIF($17, '')::uuid, $18, $19,
+			$20, $21, NULLIF($22, '')::uuid, NULLIF($23, '')::uuid, $24, $25, $26
+		)`,
+		d.ID, d.CompanyID, d.SupplierID, d.IssuerNIT, d.IssuerName, d.Number, d.DocumentTypeCode, d.CUFE, d.IssueDate,
+		d.NetTotal, d.TaxTotal, d.GrandTotal, d.XML, d.Source, d.Status, d.GoodsReceivedAt, d.CreatedBy, d.CreatedAt, d.UpdatedAt,
+		d.CustomerNIT, d.Currency, d.PurchaseOrderID, d.EmailAttachmentID, d.SignatureValid, d.CUFEValid, d.ValidationErrors,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrDuplicate
+		}
+		return fmt.Errorf("insert received document: %w", err)
+	}
+	for i := range d.Lines {
+		l := &d.Lines[i]
+		if l.ID == "" {
+			l.ID = uuid.New().String()
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO received_document_lines (
+				id, received_document_id, line_number, item_code, description, quantity, unit_code, unit_price,
+				line_extension, tax_amount
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			l.ID, d.ID, l.LineNumber, l.ItemCode, l.Description, l.Quantity, l.UnitCode, l.UnitPrice,
+			l.LineExtension, l.TaxAmount,
+		); err != nil {
+			return fmt.Errorf("insert received document line: %w", err)
+		}
+	}
+	for _, t := range d.Taxes {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO received_document_taxes (received_document_id, tax_code, tax_name, percent, taxable_amount, tax_amount)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (received_document_id, tax_code, percent) DO UPDATE
+			SET taxable_amount = received_document_taxes.taxable_amount + EXCLUDED.taxable_amount,
+			    tax_amount = received_document_taxes.tax_amount + EXCLUDED.tax_amount`,
+			d.ID, t.TaxCode, t.TaxName, t.Percent, t.TaxableAmount, t.TaxAmount,
+		); err != nil {
+			return fmt.Errorf("insert received document tax: %w", err)
+		}
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit create received document: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+const receivedDocumentColumns = `id, company_id, COALESCE(supplier_id::text, ''), issuer_nit, issuer_name, number,
+	document_type_code, cufe, issue_date, net_total, tax_total, grand_total, xml, source, status,
+	goods_received_at, COALESCE(created_by::text, ''), created_at, updated_at,
+	customer_nit, currency, COALESCE(purchase_order_id::text, ''), COALESCE(email_attachment_id::text, ''),
+	signature_valid, cufe_valid, validation_errors`
+
+func scanReceivedDocument(row pgxScanner) (*entity.ReceivedDocument, error) {
+	var d entity.ReceivedDocument
+	if err := row.Scan(&d.ID, &d.CompanyID, &d.SupplierID, &d.IssuerNIT, &d.IssuerName, &d.Number,
+		&d.DocumentTypeCode, &d.CUFE, &d.IssueDate, &d.NetTotal, &d.TaxTotal, &d.GrandTotal, &d.XML, &d.Source, &d.Status,
+		&d.GoodsReceivedAt, &d.CreatedBy, &d.CreatedAt, &d.UpdatedAt,
+		&d.CustomerNIT, &d.Currency, &d.PurchaseOrderID, &d.EmailAttachmentID,
+		&d.SignatureValid, &d.CUFEValid, &d.ValidationErrors); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+const receivedEventColumns = `id, received_document_id, company_id, event_code, number, date, claim_concept, note,
+	person_id, person_name, person_job_title, cude, xml_signed, dian_status, dian_errors, track_id,
+	status_checks, next_check_at, COALESCE(created_by::text, ''), created_at, updated_at`
+
+func scanReceivedEvent(row pgxScanner) (*entity.ReceivedDocumentEvent, error) {
+	var e entity.ReceivedDocumentEvent
+	if err := row.Scan(&e.ID, &e.ReceivedDocumentID, &e.CompanyID, &e.EventCode, &e.Number, &e.Date, &e.ClaimConcept, &e.Note,
+		&e.PersonID, &e.PersonName, &e.PersonJobTitle, &e.CUDE, &e.XMLSigned, &e.DIANStatus, &e.DIANErrors, &e.TrackID,
+		&e.StatusChecks, &e.NextCheckAt, &e.CreatedBy, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetByID devuelve la factura con su historial de eventos; nil si no existe.
+func (r *ReceivedDocumentRepo) GetByID(ctx context.Context, id string) (*entity.ReceivedDocument, error) {
+	doc, err := scanReceivedDocument(r.q.QueryRow(ctx, `
+		SELECT `+receivedDocumentColumns+`
+		FROM received_documents
+		WHERE id = $1`, id))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get received document: %w", err)
+	}
+	if doc.Events, err = r.events(ctx, doc.ID); err != nil {
+		return nil, err
+	}
+	if err := r.loadDetail(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// loadDetail carga las líneas y los impuestos leídos del XML de la factura.
+func (r *ReceivedDocumentRepo) loadDetail(ctx context.Context, doc *entity.ReceivedDocument) error {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, line_number, item_code, description, quantity, unit_code, unit_price, line_extension, tax_amount
+		FROM received_document_lines
+		WHERE received_document_id = $1
+		ORDER BY line_number`, doc.ID)
+	if err != nil {
+		return fmt.Errorf("list received document lines: %w", err)
+	}
+	for rows.Next() {
+		var l entity.ReceivedDocumentLine
+		if err := rows.Scan(&l.ID, &l.LineNumber, &l.ItemCode, &l.Description, &l.Quantity, &l.UnitCode,
+			&l.UnitPrice, &l.LineExtension, &l.TaxAmount); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan received document line: %w", err)
+		}
+		doc.Lines = append(doc.Lines, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = r.q.Query(ctx, `
+		SELECT tax_code, tax_name, percent, taxable_amount, tax_amount
+		FROM received_document_taxes
+		WHERE received_document_id = $1
+		ORDER BY tax_code, percent`, doc.ID)
+	if err != nil {
+		return fmt.Errorf("list received document taxes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t entity.ReceivedDocumentTax
+		if err := rows.Scan(&t.TaxCode, &t.TaxName, &t.Percent, &t.TaxableAmount, &t.TaxAmount); err != nil {
+			return fmt.Errorf("scan received document tax: %w", err)
+		}
+		doc.Taxes = append(doc.Taxes, t)
+	}
+	return rows.Err()
+}
+
+// List devuelve las facturas recibidas de la empresa sin eventos, de la más reciente a la más antigua.
+func (r *ReceivedDocumentRepo) List(ctx context.Context, companyID, status string) ([]*entity.ReceivedDocument, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+receivedDocumentColumns+`
+		FROM received_documents
+		WHERE company_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY issue_date DESC, created_at DESC`, companyID, status)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.ReceivedDocument{}, nil
+		}
+		return nil, fmt.Errorf("list received documents: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.ReceivedDocument, 0)
+	for rows.Next() {
+		doc, err := scanReceivedDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan received document: %w", err)
+		}
+		list = append(list, doc)
+	}
+	return list, rows.Err()
+}
+
+func (r *ReceivedDocumentRepo) events(ctx context.Context, documentID string) ([]*entity.ReceivedDocumentEvent, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+receivedEventColumns+`
+		FROM received_document_events
+		WHERE received_document_id = $1
+		ORDER BY date, created_at`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("list received document events: %w", err)
+	}
+	defer rows.Close()
+	events := make([]*entity.ReceivedDocumentEvent, 0)
+	for rows.Next() {
+		e, err := scanReceivedEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan received document event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// CreateEvent toma el siguiente consecutivo de eventos de la empresa (salvo los registros locales) e
+// inserta el evento en una transacción; con doc != nil guarda también el estado de la factura.
+func (r *ReceivedDocumentRepo) CreateEvent(ctx context.Context, ev *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin create received document event tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if ev.DIANStatus != entity.EventDIANStatusLocal {
+		var next int64
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO received_document_event_sequences (company_id, last_number)
+			VALUES ($1, 1)
+			ON CONFLICT (company_id) DO UPDATE SET last_number = received_document_event_sequences.last_number + 1
+			RETURNING last_number`, ev.CompanyID).Scan(&next); err != nil {
+			return fmt.Errorf("next received document event number: %w", err)
+		}
+		ev.Number = strconv.FormatInt(next, 10)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO received_document_events (
+			id, received_document_id, company_id, event_code, number, date, claim_concept, note,
+			person_id, person_name, person_job_title, cude, xml_signed, dian_status, dian_errors, track_id,
+			created_by, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8,
+			$9, $10, $11, $12, $13, $14, $15, $16,
+			NULLIF($17, '')::uuid, $18, $19
+		)`,
+		ev.ID, ev.ReceivedDocumentID, ev.CompanyID, ev.EventCode, ev.Number, ev.Date, ev.ClaimConcept, ev.Note,
+		ev.PersonID, ev.PersonName, ev.PersonJobTitle, ev.CUDE, ev.XMLSigned, ev.DIANStatus, ev.DIANErrors, ev.TrackID,
+		ev.CreatedBy, ev.CreatedAt, ev.UpdatedAt,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("insert received document event: %w", err)
+	}
+	if doc != nil {
+		if err := updateReceivedDocumentStatus(ctx, tx, doc); err != nil {
+			return err
+		}
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit create received document event: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+// GetEvent devuelve el evento; nil si no existe.
+func (r *ReceivedDocumentRepo) GetEvent(ctx context.Context, id string) (*entity.ReceivedDocumentEvent, error) {
+	ev, err := scanReceivedEvent(r.q.QueryRow(ctx, `
+		SELECT `+receivedEventColumns+`
+		FROM received_document_events
+		WHERE id = $1`, id))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get received document event: %w", err)
+	}
+	return ev, nil
+}
+
+// UpdateEvent persiste CUDE, XML firmado, estado DIAN y reenvíos; con doc != nil guarda también el
+// estado de la factura en la misma transacción.
+func (r *ReceivedDocumentRepo) UpdateEvent(ctx context.Context, ev *entity.ReceivedDocumentEvent, doc *entity.ReceivedDocument) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin update received document event tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE received_document_events
+		SET cude = $2, xml_signed = $3, dian_status = $4, dian_errors = $5, track_id = $6,
+		    status_checks = $7, next_check_at = $8, updated_at = $9
+		WHERE id = $1`,
+		ev.ID, ev.CUDE, ev.XMLSigned, ev.DIANStatus, ev.DIANErrors, ev.TrackID, ev.StatusChecks, ev.NextCheckAt, ev.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("update received document event: %w", err)
+	}
+	if doc != nil {
+		if err := updateReceivedDocumentStatus(ctx, tx, doc); err != nil {
+			return err
+		}
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit update received document event: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+func updateReceivedDocumentStatus(ctx context.Context, q Querier, d *entity.ReceivedDocument) error {
+	if _, err := q.Exec(ctx, `
+		UPDATE received_documents
+		SET status = $2, goods_received_at = $3, updated_at = $4
+		WHERE id = $1`,
+		d.ID, d.Status, d.GoodsReceivedAt, d.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("update received document status: %w", err)
+	}
+	return nil
+}
+
+// ListPendingEvents devuelve los eventos en CONTINGENCIA cuyo reenvío venció, más antiguos primero.
+func (r *ReceivedDocumentRepo) ListPendingEvents(ctx context.Context, now time.Time, limit int) ([]*entity.ReceivedDocumentEvent, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+receivedEventColumns+`
+		FROM received_document_events
+		WHERE dian_status = $1 AND (next_check_at IS NULL OR next_check_at <= $2)
+		ORDER BY next_check_at NULLS FIRST
+		LIMIT $3`, entity.DIANStatusContingencia, now, limit)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.ReceivedDocumentEvent{}, nil
+		}
+		return nil, fmt.Errorf("list pending received document events: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.ReceivedDocumentEvent, 0)
+	for rows.Next() {
+		ev, err := scanReceivedEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan received document event: %w", err)
+		}
+		list = append(list, ev)
+	}
+	return list, rows.Err()
+}
+
+// ListGoodsReceivedBefore devuelve, con sus eventos, las facturas en GOODS_RECEIVED cuyo recibo del bien
+// es anterior a before, más antiguas primero.
+func (r *ReceivedDocumentRepo) ListGoodsReceivedBefore(ctx context.Context, before time.Time, limit int) ([]*entity.ReceivedDocument, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+receivedDocumentColumns+`
+		FROM received_documents
+		WHERE status = $1 AND goods_received_at <= $2
+		ORDER BY goods_received_at
+		LIMIT $3`, entity.ReceivedDocStatusGoodsReceived, before, limit)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.ReceivedDocument{}, nil
+		}
+		return nil, fmt.Errorf("list goods received documents: %w", err)
+	}
+	list := make([]*entity.ReceivedDocument, 0)
+	for rows.Next() {
+		doc, err := scanReceivedDocument(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan received document: %w", err)
+		}
+		list = append(list, doc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, doc := range list {
+		if doc.Events, err = r.events(ctx, doc.ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+var _ billing.EmailInvoiceAttachmentRepository = (*EmailInvoiceAttachmentRepo)(nil)
+
+// EmailInvoiceAttachmentRepo adjuntos ZIP/XML del buzón (email_attachments) y bitácora de su ingesta
+// como facturas recibidas (email_invoice_ingestions).
+type EmailInvoiceAttachmentRepo struct {
+	q Querier
+}
+
+// NewEmailInvoiceAttachmentRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewEmailInvoiceAttachmentRepository(q Querier) *EmailInvoiceAttachmentRepo {
+	return &EmailInvoiceAttachmentRepo{q: q}
+}
+
+// ListPending devuelve los adjuntos con contenido de cuentas activas sin registro de ingesta, más antiguos primero.
+func (r *EmailInvoiceAttachmentRepo) ListPending(ctx context.Context, limit int) ([]*entity.EmailInvoiceAttachment, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT a.id, a.email_id, acc.company_id, a.file_name, a.mime_type, a.content,
+		       e.from_address, e.subject, e.received_at
+		FROM email_attachments a
+		JOIN emails e ON e.id = a.email_id
+		JOIN email_accounts acc ON acc.id = e.account_id
+		LEFT JOIN email_invoice_ingestions i ON i.attachment_id = a.id
+		WHERE a.content IS NOT NULL AND acc.is_active AND i.attachment_id IS NULL
+		ORDER BY e.received_at
+		LIMIT $1`, limit)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.EmailInvoiceAttachment{}, nil
+		}
+		return nil, fmt.Errorf("list pending invoice attachments: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.EmailInvoiceAttachment, 0)
+	for rows.Next() {
+		var a entity.EmailInvoiceAttachment
+		if err := rows.Scan(&a.ID, &a.EmailID, &a.CompanyID, &a.FileName, &a.MIMEType, &a.Content,
+			&a.FromAddress, &a.Subject, &a.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("scan invoice attachment: %w", err)
+		}
+		list = append(list, &a)
+	}
+	return list, rows.Err()
+}
+
+// MarkProcessed registra (o reemplaza) el resultado de la ingesta del adjunto.
+func (r *EmailInvoiceAttachmentRepo) MarkProcessed(ctx context.Context, in *entity.EmailInvoiceIngestion) error {
+	if _, err := r.q.Exec(ctx, `
+		INSERT INTO email_invoice_ingestions (attachment_id, company_id, status, received_document_id, error, processed_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6)
+		ON CONFLICT (attachment_id) DO UPDATE
+		SET status = EXCLUDED.status, received_document_id = EXCLUDED.received_document_id,
+		    error = EXCLUDED.error, processed_at = EXCLUDED.processed_at`,
+		in.AttachmentID, in.CompanyID, in.Status, in.ReceivedDocumentID, in.Error, in.ProcessedAt,
+	); err != nil {
+		return fmt.Errorf("mark invoice attachment processed: %w", err)
+	}
+	return nil
+}
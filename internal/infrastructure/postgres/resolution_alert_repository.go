@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.ResolutionAlertRepository = (*ResolutionAlertRepo)(nil)
+
+// ResolutionAlertRepo implementación del monitoreo de resoluciones sobre PostgreSQL.
+type ResolutionAlertRepo struct {
+	q Querier
+}
+
+// NewResolutionAlertRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewResolutionAlertRepository(q Querier) *ResolutionAlertRepo {
+	return &ResolutionAlertRepo{q: q}
+}
+
+// ListActiveResolutions lista las resoluciones activas de la empresa (la más reciente primero).
+func (r *ResolutionAlertRepo) ListActiveResolutions(ctx context.Context, companyID string) ([]*entity.BillingResolution, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, company_id, resolution_number, prefix, range_from, range_to,
+		       date_from, date_to, environment, document_type, used_numbers, is_active, created_at, updated_at
+		FROM billing_resolutions
+		WHERE company_id = $1 AND is_active = true
+		ORDER BY prefix, date_from DESC`, companyID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.BillingResolution{}, nil
+		}
+		return nil, fmt.Errorf("list active billing_resolutions: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.BillingResolution, 0)
+	for rows.Next() {
+		res, err := scanResolution(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan billing_resolution: %w", err)
+		}
+		list = append(list, res)
+	}
+	return list, rows.Err()
+}
+
+// CountIssuedSince cuenta los documentos de los tipos dados emitidos con el prefijo desde la fecha dada
+// (sin document_type = factura).
+func (r *ResolutionAlertRepo) CountIssuedSince(ctx context.Context, companyID, prefix string, documentTypes []string, since time.Time) (int64, error) {
+	var n int64
+	err := r.q.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM invoices
+		WHERE company_id = $1 AND prefix = $2 AND date >= $3
+		  AND COALESCE(document_type, 'INVOICE') = ANY($4)`,
+		companyID, prefix, since, documentTypes,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count issued invoices: %w", err)
+	}
+	return n, nil
+}
+
+// CreateIfAbsent inserta la alerta; la restricción única (resolution_id, kind, range_to, date_to)
+// descarta las repetidas del mismo cruce de umbral.
+func (r *ResolutionAlertRepo) CreateIfAbsent(ctx context.Context, a *entity.ResolutionAlert) (bool, error) {
+	tag, err := r.q.Exec(ctx, `
+		INSERT INTO resolution_alerts (id, company_id, resolution_id, kind, range_to, date_to, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (resolution_id, kind, range_to, date_to) DO NOTHING`,
+		a.ID, a.CompanyID, a.ResolutionID, a.Kind, a.RangeTo, a.DateTo, a.Message, a.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert resolution alert: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ListAdminEmails devuelve los correos de los usuarios activos con rol admin de la empresa.
+func (r *ResolutionAlertRepo) ListAdminEmails(ctx context.Context, companyID string) ([]string, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT email FROM users
+		WHERE company_id = $1 AND status = 'active' AND $2 = ANY(roles)
+		ORDER BY email`, companyID, entity.RoleAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("list company admin emails: %w", err)
+	}
+	defer rows.Close()
+	var list []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, fmt.Errorf("scan admin email: %w", err)
+		}
+		list = append(list, email)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,282 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jhoicas/Inventario-api/internal/application/inventory"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ inventory.StockAlertRepository = (*StockAlertRepo)(nil)
+
+// StockAlertRepo implementación de alertas de stock (bajo y vencimiento de lotes) y suscripciones sobre PostgreSQL.
+type StockAlertRepo struct {
+	q Querier
+}
+
+// NewStockAlertRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewStockAlertRepository(q Querier) *StockAlertRepo {
+	return &StockAlertRepo{q: q}
+}
+
+const stockAlertSelect = `
+	SELECT a.id, a.company_id, a.product_id, a.warehouse_id, a.kind, a.quantity, a.threshold,
+	       a.status, a.created_at, a.resolved_at, COALESCE(a.lot_id::text, ''), COALESCE(l.lot_number, ''),
+	       l.expiry_date, p.name, p.sku, w.name
+	FROM stock_alerts a
+	JOIN products p ON p.id = a.product_id
+	JOIN warehouses w ON w.id = a.warehouse_id
+	LEFT JOIN stock_lots l ON l.id = a.lot_id`
+
+func scanStockAlert(row pgx.Row) (*entity.StockAlert, error) {
+	var a entity.StockAlert
+	if err := row.Scan(&a.ID, &a.CompanyID, &a.ProductID, &a.WarehouseID, &a.Kind, &a.Quantity, &a.Threshold,
+		&a.Status, &a.CreatedAt, &a.ResolvedAt, &a.LotID, &a.LotNumber, &a.ExpiryDate,
+		&a.ProductName, &a.SKU, &a.WarehouseName); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetThresholds devuelve el stock del producto en la bodega con sus umbrales: la configuración
+// por bodega (product_reorder_config) prevalece sobre el punto de reorden del producto.
+func (r *StockAlertRepo) GetThresholds(ctx context.Context, companyID, productID, warehouseID string) (*entity.StockLevelThresholds, error) {
+	var t entity.StockLevelThresholds
+	err := r.q.QueryRow(ctx, `
+		SELECT p.company_id, p.id, w.id, p.name, p.sku, w.name,
+		       COALESCE(s.quantity, 0),
+		       COALESCE(rc.reorder_point, p.reorder_point, 0),
+		       COALESCE(rc.min_stock, 0)
+		FROM products p
+		JOIN warehouses w ON w.id = $3 AND w.company_id = p.company_id
+		LEFT JOIN stock s ON s.product_id = p.id AND s.warehouse_id = w.id
+		LEFT JOIN product_reorder_config rc ON rc.product_id = p.id AND rc.warehouse_id = w.id
+		WHERE p.company_id = $1 AND p.id = $2`,
+		companyID, productID, warehouseID,
+	).Scan(&t.CompanyID, &t.ProductID, &t.WarehouseID, &t.ProductName, &t.SKU, &t.WarehouseName,
+		&t.Quantity, &t.ReorderPoint, &t.MinStock)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get stock thresholds: %w", err)
+	}
+	return &t, nil
+}
+
+// ListOpenFor devuelve las alertas abiertas del producto en la bodega.
+func (r *StockAlertRepo) ListOpenFor(ctx context.Context, productID, warehouseID string) ([]*entity.StockAlert, error) {
+	rows, err := r.q.Query(ctx, stockAlertSelect+`
+		WHERE a.product_id = $1 AND a.warehouse_id = $2 AND a.status = 'OPEN'`, productID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("list open stock alerts: %w", err)
+	}
+	defer rows.Close()
+	var list []*entity.StockAlert
+	for rows.Next() {
+		a, err := scanStockAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan stock alert: %w", err)
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// CreateIfAbsent inserta la alerta; el índice único parcial sobre alertas OPEN descarta duplicados
+// cuando dos evaluaciones concurrentes detectan el mismo cruce.
+func (r *StockAlertRepo) CreateIfAbsent(ctx context.Context, a *entity.StockAlert) (bool, error) {
+	lotID := (*string)(nil)
+	if a.LotID != "" {
+		lotID = &a.LotID
+	}
+	tag, err := r.q.Exec(ctx, `
+		INSERT INTO stock_alerts (id, company_id, product_id, warehouse_id, kind, quantity, threshold, status, created_at, lot_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (product_id, warehouse_id, kind, COALESCE(lot_id, '00000000-0000-0000-0000-000000000000'::uuid))
+		WHERE status = 'OPEN' DO NOTHING`,
+		a.ID, a.CompanyID, a.ProductID, a.WarehouseID, a.Kind, a.Quantity, a.Threshold, a.Status, a.CreatedAt, lotID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert stock alert: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Resolve marca la alerta como resuelta (no falla si ya lo estaba).
+func (r *StockAlertRepo) Resolve(ctx context.Context, alertID string, resolvedAt time.Time) error {
+	if _, err := r.q.Exec(ctx, `
+		UPDATE stock_alerts SET status = 'RESOLVED', resolved_at = $2
+		WHERE id = $1 AND status = 'OPEN'`, alertID, resolvedAt); err != nil {
+		return fmt.Errorf("resolve stock alert: %w", err)
+	}
+	return nil
+}
+
+// ListExpiringLots devuelve los lotes con saldo que vencen antes de before sin la alerta abierta que les
+// corresponde en today, del que vence primero al último.
+func (r *StockAlertRepo) ListExpiringLots(ctx context.Context, today, before time.Time, limit int) ([]*entity.StockLot, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT l.id, l.company_id, l.product_id, l.warehouse_id, COALESCE(l.lot_number, ''), l.expiry_date,
+		       l.initial_quantity, l.quantity, COALESCE(l.movement_id::text, ''), l.created_at,
+		       p.name, p.sku, w.name
+		FROM stock_lots l
+		JOIN products p ON p.id = l.product_id
+		JOIN warehouses w ON w.id = l.warehouse_id
+		WHERE l.quantity > 0 AND l.expiry_date < $2
+		  AND NOT EXISTS (
+			SELECT 1 FROM stock_alerts a
+			WHERE a.lot_id = l.id AND a.status = 'OPEN'
+			  AND a.kind = CASE WHEN l.expiry_date < $1 THEN 'EXPIRED' ELSE 'NEAR_EXPIRY' END
+		  )
+		ORDER BY l.expiry_date, l.id
+		LIMIT $3`, today, before, limit)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list expiring stock lots: %w", err)
+	}
+	defer rows.Close()
+	var list []*entity.StockLot
+	for rows.Next() {
+		var l entity.StockLot
+		if err := rows.Scan(&l.ID, &l.CompanyID, &l.ProductID, &l.WarehouseID, &l.LotNumber, &l.ExpiryDate,
+			&l.InitialQuantity, &l.Quantity, &l.MovementID, &l.CreatedAt,
+			&l.ProductName, &l.SKU, &l.WarehouseName); err != nil {
+			return nil, fmt.Errorf("scan stock lot: %w", err)
+		}
+		list = append(list, &l)
+	}
+	return list, rows.Err()
+}
+
+// ResolveDepletedLotAlerts resuelve las alertas de vencimiento abiertas de lotes sin saldo.
+func (r *StockAlertRepo) ResolveDepletedLotAlerts(ctx context.Context, resolvedAt time.Time) error {
+	if _, err := r.q.Exec(ctx, `
+		UPDATE stock_alerts a SET status = 'RESOLVED', resolved_at = $1
+		FROM stock_lots l
+		WHERE l.id = a.lot_id AND a.status = 'OPEN' AND l.quantity <= 0`, resolvedAt); err != nil {
+		if isUndefinedTable(err) || isUndefinedColumn(err) {
+			return nil
+		}
+		return fmt.Errorf("resolve depleted lot alerts: %w", err)
+	}
+	return nil
+}
+
+// ListByCompany lista alertas de la empresa; status y warehouseID vacíos no filtran.
+func (r *StockAlertRepo) ListByCompany(ctx context.Context, companyID, status, warehouseID string, limit, offset int) ([]*entity.StockAlert, error) {
+	rows, err := r.q.Query(ctx, stockAlertSelect+`
+		WHERE a.company_id = $1
+		  AND ($2 = '' OR a.status = $2)
+		  AND ($3 = '' OR a.warehouse_id::text = $3)
+		ORDER BY a.created_at DESC
+		LIMIT $4 OFFSET $5`, companyID, status, warehouseID, limit, offset)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.StockAlert{}, nil
+		}
+		return nil, fmt.Errorf("list stock alerts: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.StockAlert, 0)
+	for rows.Next() {
+		a, err := scanStockAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan stock alert: %w", err)
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// ListRecipients resuelve los suscriptores activos para la bodega. Si un usuario tiene una
+// suscripción específica de la bodega y otra general, se usa la específica.
+func (r *StockAlertRepo) ListRecipients(ctx context.Context, companyID, warehouseID string) ([]entity.StockAlertRecipient, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT DISTINCT ON (s.user_id) s.user_id, u.email, s.notify_email, s.notify_in_app
+		FROM stock_alert_subscriptions s
+		JOIN users u ON u.id = s.user_id AND u.company_id = s.company_id
+		WHERE s.company_id = $1
+		  AND (s.warehouse_id = $2 OR s.warehouse_id IS NULL)
+		  AND u.status = 'active'
+		ORDER BY s.user_id, s.warehouse_id NULLS LAST`, companyID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("list stock alert recipients: %w", err)
+	}
+	defer rows.Close()
+	var list []entity.StockAlertRecipient
+	for rows.Next() {
+		var rc entity.StockAlertRecipient
+		if err := rows.Scan(&rc.UserID, &rc.Email, &rc.NotifyEmail, &rc.NotifyInApp); err != nil {
+			return nil, fmt.Errorf("scan stock alert recipient: %w", err)
+		}
+		list = append(list, rc)
+	}
+	return list, rows.Err()
+}
+
+// ListSubscriptions lista las suscripciones del usuario (la general primero).
+func (r *StockAlertRepo) ListSubscriptions(ctx context.Context, companyID, userID string) ([]*entity.StockAlertSubscription, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, company_id, user_id, COALESCE(warehouse_id::text, ''), notify_email, notify_in_app, created_at, updated_at
+		FROM stock_alert_subscriptions
+		WHERE company_id = $1 AND user_id = $2
+		ORDER BY warehouse_id NULLS FIRST`, companyID, userID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.StockAlertSubscription{}, nil
+		}
+		return nil, fmt.Errorf("list stock alert subscriptions: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.StockAlertSubscription, 0)
+	for rows.Next() {
+		var s entity.StockAlertSubscription
+		if err := rows.Scan(&s.ID, &s.CompanyID, &s.UserID, &s.WarehouseID, &s.NotifyEmail, &s.NotifyInApp, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan stock alert subscription: %w", err)
+		}
+		list = append(list, &s)
+	}
+	return list, rows.Err()
+}
+
+// ReplaceSubscriptions reemplaza atómicamente las suscripciones del usuario.
+func (r *StockAlertRepo) ReplaceSubscriptions(ctx context.Context, companyID, userID string, subs []*entity.StockAlertSubscription) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin stock alert subscriptions tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM stock_alert_subscriptions WHERE company_id = $1 AND user_id = $2`, companyID, userID); err != nil {
+		return fmt.Errorf("delete stock alert subscriptions: %w", err)
+	}
+	for _, s := range subs {
+		var warehouseID *string
+		if s.WarehouseID != "" {
+			warehouseID = &s.WarehouseID
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO stock_alert_subscriptions (id, company_id, user_id, warehouse_id, notify_email, notify_in_app, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			s.ID, companyID, userID, warehouseID, s.NotifyEmail, s.NotifyInApp, s.CreatedAt, s.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("insert stock alert subscription: %w", err)
+		}
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit stock alert subscriptions: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jhoicas/Inventario-api/internal/domain/entity"
 	"github.com/jhoicas/Inventario-api/internal/domain/repository"
@@ -132,3 +133,145 @@ func (r *StockRepo) GetForUpdate(productID, warehouseID string) (*entity.Stock,
 	}
 	return &s, nil
 }
+
+// CreateLot registra un lote con vencimiento.
+func (r *StockRepo) CreateLot(lot *entity.StockLot) error {
+	if lot.ID == "" {
+		lot.ID = uuid.New().String()
+	}
+	lotNumber := (*string)(nil)
+	if lot.LotNumber != "" {
+		lotNumber = &lot.LotNumber
+	}
+	movementID := (*string)(nil)
+	if lot.MovementID != "" {
+		movementID = &lot.MovementID
+	}
+	_, err := r.q.Exec(context.Background(), `
+		INSERT INTO stock_lots (id, company_id, product_id, warehouse_id, lot_number, expiry_date,
+		                        initial_quantity, quantity, movement_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		lot.ID, lot.CompanyID, lot.ProductID, lot.WarehouseID, lotNumber, lot.ExpiryDate,
+		lot.InitialQuantity, lot.Quantity, movementID, lot.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert stock lot: %w", err)
+	}
+	return nil
+}
+
+// stockLotColumns columnas de stock_lots en el orden que espera scanStockLots.
+const stockLotColumns = `id, company_id, product_id, warehouse_id, COALESCE(lot_number, ''), expiry_date,
+		       initial_quantity, quantity, COALESCE(movement_id::text, ''), created_at`
+
+// ConsumeLotsFEFO bloquea los lotes con saldo (FOR UPDATE) en orden de vencimiento y los descuenta.
+func (r *StockRepo) ConsumeLotsFEFO(productID, warehouseID string, quantity decimal.Decimal) ([]entity.StockLot, error) {
+	return r.consumeLots(`
+		SELECT `+stockLotColumns+`
+		FROM stock_lots
+		WHERE product_id = $1 AND warehouse_id = $2 AND quantity > 0
+		ORDER BY expiry_date, created_at
+		FOR UPDATE`, quantity, productID, warehouseID)
+}
+
+// ConsumeMovementLots bloquea los lotes con saldo creados por la entrada movementID y los descuenta FEFO.
+func (r *StockRepo) ConsumeMovementLots(movementID string, quantity decimal.Decimal) ([]entity.StockLot, error) {
+	return r.consumeLots(`
+		SELECT `+stockLotColumns+`
+		FROM stock_lots
+		WHERE movement_id = $1 AND quantity > 0
+		ORDER BY expiry_date, created_at
+		FOR UPDATE`, quantity, movementID)
+}
+
+// consumeLots descuenta quantity de los lotes que devuelve query, en su orden, hasta agotarla.
+func (r *StockRepo) consumeLots(query string, quantity decimal.Decimal, args ...any) ([]entity.StockLot, error) {
+	ctx := context.Background()
+	rows, err := r.q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list stock lots: %w", err)
+	}
+	lots, err := scanStockLots(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed := make([]entity.StockLot, 0, len(lots))
+	remaining := quantity
+	for _, l := range lots {
+		if !remaining.GreaterThan(decimal.Zero) {
+			break
+		}
+		take := decimal.Min(l.Quantity, remaining)
+		if _, err := r.q.Exec(ctx, `UPDATE stock_lots SET quantity = quantity - $2 WHERE id = $1`, l.ID, take); err != nil {
+			return nil, fmt.Errorf("consume stock lot: %w", err)
+		}
+		remaining = remaining.Sub(take)
+		l.Quantity = take
+		consumed = append(consumed, l)
+	}
+	return consumed, nil
+}
+
+// LotBalance suma el saldo de los lotes del producto en la bodega.
+func (r *StockRepo) LotBalance(productID, warehouseID string) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := r.q.QueryRow(context.Background(), `
+		SELECT COALESCE(SUM(quantity), 0) FROM stock_lots WHERE product_id = $1 AND warehouse_id = $2`,
+		productID, warehouseID).Scan(&total)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("sum stock lots: %w", err)
+	}
+	return total, nil
+}
+
+// RecordLotConsumption guarda en stock_lot_consumptions las porciones de lote consumidas por el movimiento.
+func (r *StockRepo) RecordLotConsumption(movementID string, lots []entity.StockLot) error {
+	for _, l := range lots {
+		if !l.Quantity.GreaterThan(decimal.Zero) {
+			continue
+		}
+		_, err := r.q.Exec(context.Background(), `
+			INSERT INTO stock_lot_consumptions (movement_id, lot_id, quantity)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (movement_id, lot_id) DO UPDATE SET quantity = stock_lot_consumptions.quantity + EXCLUDED.quantity`,
+			movementID, l.ID, l.Quantity)
+		if err != nil {
+			return fmt.Errorf("insert stock lot consumption: %w", err)
+		}
+	}
+	return nil
+}
+
+// RestoreLotConsumption suma de vuelta a cada lote lo que consumió movementID. Devuelve los lotes con
+// Quantity = la porción restituida.
+func (r *StockRepo) RestoreLotConsumption(movementID string) ([]entity.StockLot, error) {
+	rows, err := r.q.Query(context.Background(), `
+		UPDATE stock_lots l SET quantity = l.quantity + c.quantity
+		FROM stock_lot_consumptions c
+		WHERE c.movement_id = $1 AND c.lot_id = l.id
+		RETURNING l.id, l.company_id, l.product_id, l.warehouse_id, COALESCE(l.lot_number, ''), l.expiry_date,
+		          l.initial_quantity, c.quantity, COALESCE(l.movement_id::text, ''), l.created_at`, movementID)
+	if err != nil {
+		return nil, fmt.Errorf("restore stock lots: %w", err)
+	}
+	return scanStockLots(rows)
+}
+
+// scanStockLots lee filas con las columnas de stockLotColumns y cierra rows.
+func scanStockLots(rows pgx.Rows) ([]entity.StockLot, error) {
+	defer rows.Close()
+	var lots []entity.StockLot
+	for rows.Next() {
+		var l entity.StockLot
+		if err := rows.Scan(&l.ID, &l.CompanyID, &l.ProductID, &l.WarehouseID, &l.LotNumber, &l.ExpiryDate,
+			&l.InitialQuantity, &l.Quantity, &l.MovementID, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan stock lot: %w", err)
+		}
+		lots = append(lots, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list stock lots: %w", err)
+	}
+	return lots, nil
+}
@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.SupportDocumentRepository = (*SupportDocumentRepo)(nil)
+
+// SupportDocumentRepo implementación de documentos soporte sobre PostgreSQL
+// (support_documents y support_document_lines).
+type SupportDocumentRepo struct {
+	q Querier
+}
+
+// NewSupportDocumentRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewSupportDocumentRepository(q Querier) *SupportDocumentRepo {
+	return &SupportDocumentRepo{q: q}
+}
+
+// Create bloquea la resolución activa del prefijo, toma su siguiente consecutivo e inserta el documento
+// y sus líneas en una transacción: si algo falla el número vuelve a estar disponible, sin huecos.
+func (r *SupportDocumentRepo) Create(ctx context.Context, doc *entity.SupportDocument) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin create support document tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	resolutions := NewInvoiceRepository(tx)
+	res, err := resolutions.LockActiveResolution(doc.CompanyID, doc.Prefix)
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return fmt.Errorf("prefijo %q: %w", doc.Prefix, domain.ErrNoActiveResolution)
+	}
+	if !res.CoversDate(doc.Date) {
+		return fmt.Errorf("resolución %s fuera de vigencia (%s a %s): %w",
+			res.ResolutionNumber, res.DateFrom.Format("2006-01-02"), res.DateTo.Format("2006-01-02"),
+			domain.ErrNoActiveResolution)
+	}
+	next := res.NextNumber()
+	if next > res.RangeTo {
+		return fmt.Errorf("resolución %s (rango %d-%d): %w",
+			res.ResolutionNumber, res.RangeFrom, res.RangeTo, domain.ErrResolutionExhausted)
+	}
+	if err := resolutions.ConsumeResolutionNumber(res.ID); err != nil {
+		return err
+	}
+	doc.Number = strconv.FormatInt(next, 10)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO support_documents (
+			id, company_id, supplier_id, purchase_order_id, document_type, prefix, number, date,
+			supplier_name, supplier_identification, supplier_identification_type,
+			net_total, tax_total, grand_total, cuds, xml_signed, dian_status, dian_errors, track_id,
+			original_document_id, original_document_number, original_document_cuds, original_issue_on,
+			discrepancy_code, discrepancy_reason, notes, created_by, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8,
+			$9, $10, $11,
+			$12, $13, $14, $15, $16, $17, $18, $19,
+			NULLIF($20, '')::uuid, $21, $22, $23,
+			$24, $25, $26, NULLIF($27, '')::uuid, $28, $29
+		)`,
+		doc.ID, doc.CompanyID, doc.SupplierID, doc.PurchaseOrderID, doc.DocumentType, doc.Prefix, doc.Number, doc.Date,
+		doc.SupplierName, doc.SupplierIdentification, doc.SupplierIdentificationType,
+		doc.NetTotal, doc.TaxTotal, doc.GrandTotal, doc.CUDS, doc.XMLSigned, doc.DIANStatus, doc.DIANErrors, doc.TrackID,
+		doc.OriginalDocumentID, doc.OriginalDocumentNumber, doc.OriginalDocumentCUDS, nullTime(doc.OriginalIssueOn),
+		string(doc.DiscrepancyCode), doc.DiscrepancyReason, doc.Notes, doc.CreatedBy, doc.CreatedAt, doc.UpdatedAt,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("insert support document: %w", err)
+	}
+	for i, l := range doc.Lines {
+		l.SupportDocumentID = doc.ID
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO support_document_lines (id, support_document_id, line_number, product_id, description,
+				quantity, unit_price, tax_rate, subtotal, tax_amount)
+			VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9, $10)`,
+			l.ID, doc.ID, i+1, l.ProductID, l.Description, l.Quantity, l.UnitPrice, l.TaxRate, l.Subtotal, l.TaxAmount,
+		); err != nil {
+			return fmt.Errorf("insert support document line: %w", err)
+		}
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit create support document: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+const supportDocumentColumns = `id, company_id, supplier_id, COALESCE(purchase_order_id::text, ''), document_type,
+	prefix, number, date, supplier_name, supplier_identification, supplier_identification_type,
+	net_total, tax_total, grand_total, cuds, xml_signed, dian_status, dian_errors, track_id,
+	status_checks, next_check_at, COALESCE(original_document_id::text, ''), original_document_number,
+	original_document_cuds, original_issue_on, discrepancy_code, discrepancy_reason, notes,
+	COALESCE(created_by::text, ''), created_at, updated_at`
+
+func scanSupportDocument(row pgxScanner) (*entity.SupportDocument, error) {
+	var d entity.SupportDocument
+	var originalIssueOn *time.Time
+	var discrepancyCode string
+	if err := row.Scan(&d.ID, &d.CompanyID, &d.SupplierID, &d.PurchaseOrderID, &d.DocumentType,
+		&d.Prefix, &d.Number, &d.Date, &d.SupplierName, &d.SupplierIdentification, &d.SupplierIdentificationType,
+		&d.NetTotal, &d.TaxTotal, &d.GrandTotal, &d.CUDS, &d.XMLSigned, &d.DIANStatus, &d.DIANErrors, &d.TrackID,
+		&d.StatusChecks, &d.NextCheckAt, &d.OriginalDocumentID, &d.OriginalDocumentNumber,
+		&d.OriginalDocumentCUDS, &originalIssueOn, &discrepancyCode, &d.DiscrepancyReason, &d.Notes,
+		&d.CreatedBy, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if originalIssueOn != nil {
+		d.OriginalIssueOn = *originalIssueOn
+	}
+	d.DiscrepancyCode = entity.CreditNoteConcept(discrepancyCode)
+	return &d, nil
+}
+
+// GetByID devuelve el documento con sus líneas; nil si no existe.
+func (r *SupportDocumentRepo) GetByID(ctx context.Context, id string) (*entity.SupportDocument, error) {
+	doc, err := scanSupportDocument(r.q.QueryRow(ctx, `
+		SELECT `+supportDocumentColumns+`
+		FROM support_documents
+		WHERE id = $1`, id))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get support document: %w", err)
+	}
+	if doc.Lines, err = r.lines(ctx, doc.ID); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// List devuelve los documentos de la empresa sin líneas, del más reciente al más antiguo.
+func (r *SupportDocumentRepo) List(ctx context.Context, companyID, documentType string) ([]*entity.SupportDocument, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+supportDocumentColumns+`
+		FROM support_documents
+		WHERE company_id = $1 AND ($2 = '' OR document_type = $2)
+		ORDER BY date DESC, created_at DESC`, companyID, documentType)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.SupportDocument{}, nil
+		}
+		return nil, fmt.Errorf("list support documents: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.SupportDocument, 0)
+	for rows.Next() {
+		doc, err := scanSupportDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan support document: %w", err)
+		}
+		list = append(list, doc)
+	}
+	return list, rows.Err()
+}
+
+func (r *SupportDocumentRepo) lines(ctx context.Context, documentID string) ([]*entity.SupportDocumentLine, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, support_document_id, COALESCE(product_id::text, ''), description,
+		       quantity, unit_price, tax_rate, subtotal, tax_amount
+		FROM support_document_lines
+		WHERE support_document_id = $1
+		ORDER BY line_number`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("list support document lines: %w", err)
+	}
+	defer rows.Close()
+	lines := make([]*entity.SupportDocumentLine, 0)
+	for rows.Next() {
+		var l entity.SupportDocumentLine
+		if err := rows.Scan(&l.ID, &l.SupportDocumentID, &l.ProductID, &l.Description,
+			&l.Quantity, &l.UnitPrice, &l.TaxRate, &l.Subtotal, &l.TaxAmount); err != nil {
+			return nil, fmt.Errorf("scan support document line: %w", err)
+		}
+		lines = append(lines, &l)
+	}
+	return lines, rows.Err()
+}
+
+// Update persiste CUDS, XML firmado, estado DIAN y seguimiento de validación.
+func (r *SupportDocumentRepo) Update(ctx context.Context, d *entity.SupportDocument) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE support_documents
+		SET cuds = $2, xml_signed = $3, dian_status = $4, dian_errors = $5, track_id = $6,
+		    status_checks = $7, next_check_at = $8, updated_at = $9
+		WHERE id = $1`,
+		d.ID, d.CUDS, d.XMLSigned, d.DIANStatus, d.DIANErrors, d.TrackID, d.StatusChecks, d.NextCheckAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update support document: %w", err)
+	}
+	return nil
+}
+
+// ListPending devuelve los documentos en Sent o CONTINGENCIA cuya próxima acción venció, más antiguos primero.
+func (r *SupportDocumentRepo) ListPending(ctx context.Context, now time.Time, limit int) ([]*entity.SupportDocument, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+supportDocumentColumns+`
+		FROM support_documents
+		WHERE dian_status IN ($1, $2) AND (next_check_at IS NULL OR next_check_at <= $3)
+		ORDER BY next_check_at NULLS FIRST
+		LIMIT $4`, entity.DIANStatusSent, entity.DIANStatusContingencia, now, limit)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.SupportDocument{}, nil
+		}
+		return nil, fmt.Errorf("list pending support documents: %w", err)
+	}
+	list := make([]*entity.SupportDocument, 0)
+	for rows.Next() {
+		doc, err := scanSupportDocument(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan support document: %w", err)
+		}
+		list = append(list, doc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, doc := range list {
+		if doc.Lines, err = r.lines(ctx, doc.ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// nullTime NULL para la fecha cero (documentos soporte sin documento ajustado).
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+)
+
+var _ billing.WithholdingRepository = (*WithholdingRepo)(nil)
+
+// WithholdingRepo implementación de la configuración de retenciones sobre PostgreSQL.
+type WithholdingRepo struct {
+	q Querier
+}
+
+// NewWithholdingRepository construye el adaptador. Pasar pool o tx (Querier).
+func NewWithholdingRepository(q Querier) *WithholdingRepo {
+	return &WithholdingRepo{q: q}
+}
+
+// GetCompanyResponsibilities lee companies.fiscal_responsibilities.
+// Sin la migración 050 devuelve vacío (la empresa no tiene responsabilidades configuradas).
+func (r *WithholdingRepo) GetCompanyResponsibilities(ctx context.Context, companyID string) ([]string, error) {
+	var codes []string
+	err := r.q.QueryRow(ctx, `
+		SELECT COALESCE(fiscal_responsibilities, '{}') FROM companies WHERE id = $1`, companyID,
+	).Scan(&codes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		if isUndefinedColumnError(err, "fiscal_responsibilities") {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("get company fiscal responsibilities: %w", err)
+	}
+	return codes, nil
+}
+
+// UpdateCompanyResponsibilities reemplaza companies.fiscal_responsibilities.
+func (r *WithholdingRepo) UpdateCompanyResponsibilities(ctx context.Context, companyID string, codes []string) error {
+	res, err := r.q.Exec(ctx, `
+		UPDATE companies SET fiscal_responsibilities = $2, updated_at = now() WHERE id = $1`,
+		companyID, responsibilitiesOrEmpty(codes),
+	)
+	if err != nil {
+		return fmt.Errorf("update company fiscal responsibilities: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ListRules lista las tarifas de retención de la empresa ordenadas por tributo y concepto.
+func (r *WithholdingRepo) ListRules(ctx context.Context, companyID string) ([]*entity.WithholdingRule, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, company_id, tax_code, concept, rate, min_base_uvt, is_active, created_at, updated_at
+		FROM withholding_rules
+		WHERE company_id = $1
+		ORDER BY tax_code, concept`, companyID)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []*entity.WithholdingRule{}, nil
+		}
+		return nil, fmt.Errorf("list withholding_rules: %w", err)
+	}
+	defer rows.Close()
+	list := make([]*entity.WithholdingRule, 0)
+	for rows.Next() {
+		var w entity.WithholdingRule
+		if err := rows.Scan(&w.ID, &w.CompanyID, &w.TaxCode, &w.Concept, &w.Rate, &w.MinBaseUVT,
+			&w.IsActive, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan withholding_rule: %w", err)
+		}
+		list = append(list, &w)
+	}
+	return list, rows.Err()
+}
+
+// ReplaceConfig actualiza companies.fiscal_responsibilities y reemplaza las tarifas de la empresa en una
+// sola transacción (o en la del Querier, si ya es una tx).
+func (r *WithholdingRepo) ReplaceConfig(ctx context.Context, companyID string, codes []string, rules []*entity.WithholdingRule) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin replace withholding config tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	inTx := NewWithholdingRepository(tx)
+	if err := inTx.UpdateCompanyResponsibilities(ctx, companyID, codes); err != nil {
+		return err
+	}
+	if err := inTx.ReplaceRules(ctx, companyID, rules); err != nil {
+		return err
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit replace withholding config: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
+
+// ReplaceRules borra las tarifas de la empresa e inserta las nuevas en una sola transacción.
+func (r *WithholdingRepo) ReplaceRules(ctx context.Context, companyID string, rules []*entity.WithholdingRule) error {
+	tx, shouldCommit, committed, err := beginIfPossible(ctx, r.q)
+	if err != nil {
+		return fmt.Errorf("begin replace withholding rules tx: %w", err)
+	}
+	defer rollbackUnlessCommitted(ctx, tx, shouldCommit, &committed)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM withholding_rules WHERE company_id = $1`, companyID); err != nil {
+		return fmt.Errorf("delete withholding rules: %w", err)
+	}
+	for _, w := range rules {
+		if w.ID == "" {
+			w.ID = uuid.New().String()
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO withholding_rules (id, company_id, tax_code, concept, rate, min_base_uvt, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			w.ID, companyID, w.TaxCode, w.Concept, w.Rate, w.MinBaseUVT, w.IsActive, w.CreatedAt, w.UpdatedAt,
+		); err != nil {
+			if isUniqueViolation(err) {
+				return domain.ErrDuplicate
+			}
+			return fmt.Errorf("insert withholding rule: %w", err)
+		}
+	}
+
+	if shouldCommit {
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit replace withholding rules: %w", err)
+		}
+		committed = true
+	}
+	return nil
+}
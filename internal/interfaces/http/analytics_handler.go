@@ -1,11 +1,15 @@
 package http
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jhoicas/Inventario-api/internal/application/dto"
 	"github.com/jhoicas/Inventario-api/internal/application/usecase"
+	"github.com/jhoicas/Inventario-api/internal/domain"
 )
 
 // AnalyticsHandler maneja los endpoints de analítica de rentabilidad.
@@ -131,3 +135,66 @@ func (h *AnalyticsHandler) GetRawMaterialImpactRanking(c *fiber.Ctx) error {
 	}
 	return c.JSON(resultados)
 }
+
+// GetInventoryAging godoc
+// @Summary      Antigüedad de inventario y stock muerto
+// @Description  Agrupa el stock vigente por días desde la última venta o recepción (0-30, 31-90, 91-180, 180+),
+//               lo valoriza al costo promedio y marca los productos con stock sin ventas en dead_stock_days días.
+//               Con format=csv devuelve el detalle por producto como archivo CSV.
+// @Tags         analytics
+// @Security     Bearer
+// @Produce      json
+// @Produce      text/csv
+// @Param        warehouse_id     query  string  false  "Bodega (vacío = todas)"
+// @Param        dead_stock_days  query  int     false  "Días sin venta para marcar stock muerto (default 90)"
+// @Param        format           query  string  false  "json | csv"
+// @Success      200  {object}  dto.InventoryAgingReportDTO
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      500  {object}  dto.ErrorResponse
+// @Router       /api/analytics/inventory-aging [get]
+func (h *AnalyticsHandler) GetInventoryAging(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{
+			Code: "UNAUTHORIZED", Message: "company_id no encontrado en el token",
+		})
+	}
+
+	var req dto.InventoryAgingRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code: "INVALID_PARAMS", Message: "parámetros de consulta inválidos",
+		})
+	}
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format != "" && format != "json" && format != "csv" {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code: "INVALID_PARAMS", Message: "format debe ser json o csv",
+		})
+	}
+
+	report, err := h.uc.GetInventoryAging(c.Context(), companyID, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+				Code: "INVALID_PARAMS", Message: "dead_stock_days inválido",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
+			Code: "INTERNAL", Message: err.Error(),
+		})
+	}
+	if format != "csv" {
+		return c.JSON(report)
+	}
+
+	data, err := usecase.InventoryAgingCSV(report)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{
+			Code: "INTERNAL", Message: err.Error(),
+		})
+	}
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="antiguedad-inventario-%s.csv"`, report.AsOf))
+	return c.Send(data)
+}
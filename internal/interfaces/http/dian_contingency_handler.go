@@ -0,0 +1,164 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// DIANContingencyUseCase interfaz local de la facturación en contingencia (tipo 03).
+type DIANContingencyUseCase interface {
+	Start(ctx context.Context, companyID, userID string, in dto.StartDIANContingencyRequest) (*dto.DIANContingencyDTO, error)
+	End(ctx context.Context, companyID, userID string) (*dto.DIANContingencyReconciliationDTO, error)
+	List(ctx context.Context, companyID string) ([]dto.DIANContingencyDTO, error)
+	Transmit(ctx context.Context, companyID, id string) (*dto.DIANContingencyReconciliationDTO, error)
+	Reconcile(ctx context.Context, companyID, id string) (*dto.DIANContingencyReconciliationDTO, error)
+}
+
+// DIANContingencyHandler expone la entrada y salida de contingencia, la transmisión en bloque y la
+// conciliación de las facturas tipo 03.
+type DIANContingencyHandler struct {
+	uc DIANContingencyUseCase
+}
+
+// NewDIANContingencyHandler construye el handler.
+func NewDIANContingencyHandler(uc DIANContingencyUseCase) *DIANContingencyHandler {
+	return &DIANContingencyHandler{uc: uc}
+}
+
+// Start godoc
+// @Summary      Activar contingencia DIAN
+// @Description  Pone la empresa en contingencia: las facturas siguientes se emiten como tipo 03 con la
+// @Description  numeración del prefijo de contingencia, se firman y quedan pendientes de transmisión.
+// @Tags         billing
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.StartDIANContingencyRequest  true  "Prefijo de contingencia y motivo"
+// @Success      201   {object}  dto.DIANContingencyDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/contingency [post]
+func (h *DIANContingencyHandler) Start(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.StartDIANContingencyRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.Start(c.Context(), companyID, GetUserID(c), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
+
+// End godoc
+// @Summary      Terminar contingencia DIAN
+// @Description  Termina la contingencia activa y encola la transmisión de sus facturas tipo 03 (plazo de 48 horas).
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Success      202  {object}  dto.DIANContingencyReconciliationDTO
+// @Failure      409  {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/contingency/end [post]
+func (h *DIANContingencyHandler) End(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.End(c.Context(), companyID, GetUserID(c))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(out)
+}
+
+// List godoc
+// @Summary      Contingencias DIAN
+// @Description  Periodos de contingencia de la empresa (el activo, si lo hay, primero).
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}   dto.DIANContingencyDTO
+// @Router       /api/billing/dian/contingency [get]
+func (h *DIANContingencyHandler) List(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.List(c.Context(), companyID)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// Transmit godoc
+// @Summary      Transmitir facturas de contingencia
+// @Description  Vuelve a encolar las facturas de la contingencia terminada que siguen sin transmitir a la DIAN.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      string  true  "ID de la contingencia"
+// @Success      202  {object}  dto.DIANContingencyReconciliationDTO
+// @Failure      404  {object}  dto.ErrorResponse
+// @Failure      409  {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/contingency/{id}/transmit [post]
+func (h *DIANContingencyHandler) Transmit(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.Transmit(c.Context(), companyID, c.Params("id"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(out)
+}
+
+// Reconcile godoc
+// @Summary      Conciliación de contingencia
+// @Description  Facturas de la contingencia con su estado DIAN: pendientes, enviadas, aceptadas, rechazadas y con
+// @Description  error, y si venció el plazo de transmisión con facturas pendientes.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      string  true  "ID de la contingencia"
+// @Success      200  {object}  dto.DIANContingencyReconciliationDTO
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/contingency/{id}/reconciliation [get]
+func (h *DIANContingencyHandler) Reconcile(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.Reconcile(c.Context(), companyID, c.Params("id"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+func (h *DIANContingencyHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: err.Error()})
+	case isNumberingError(err):
+		return c.Status(fiber.StatusConflict).JSON(numberingErrorResponse(err))
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "contingencia no encontrada"})
+	case errors.Is(err, domain.ErrConflict):
+		msg := err.Error()
+		if err == domain.ErrConflict {
+			msg = "la empresa ya está en contingencia"
+		}
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "CONFLICT", Message: msg})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
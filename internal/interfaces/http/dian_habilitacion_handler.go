@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// DIANHabilitacionUseCase interfaz local del proceso de habilitación DIAN.
+type DIANHabilitacionUseCase interface {
+	Start(ctx context.Context, companyID, userID string, in dto.StartDIANHabilitacionRequest) (*dto.DIANHabilitacionDTO, error)
+	Get(ctx context.Context, companyID string) (*dto.DIANHabilitacionDTO, error)
+}
+
+// DIANHabilitacionHandler expone el set de pruebas de habilitación DIAN de la empresa.
+type DIANHabilitacionHandler struct {
+	uc DIANHabilitacionUseCase
+}
+
+// NewDIANHabilitacionHandler construye el handler.
+func NewDIANHabilitacionHandler(uc DIANHabilitacionUseCase) *DIANHabilitacionHandler {
+	return &DIANHabilitacionHandler{uc: uc}
+}
+
+// Start godoc
+// @Summary      Iniciar habilitación DIAN
+// @Description  Genera y firma el set de pruebas (facturas, notas crédito y débito) con la resolución de pruebas
+// @Description  y las credenciales de la empresa; se envía en segundo plano con SendTestSetAsync y el TestSetId.
+// @Tags         billing
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.StartDIANHabilitacionRequest  true  "TestSetId y cantidades del set"
+// @Success      202   {object}  dto.DIANHabilitacionDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      401   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Failure      422   {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/habilitacion [post]
+func (h *DIANHabilitacionHandler) Start(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.StartDIANHabilitacionRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.Start(c.Context(), companyID, GetUserID(c), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(out)
+}
+
+// Get godoc
+// @Summary      Progreso de la habilitación DIAN
+// @Description  Estado del último set de pruebas: documentos pendientes, enviados, aceptados y rechazados.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  dto.DIANHabilitacionDTO
+// @Failure      401  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/habilitacion [get]
+func (h *DIANHabilitacionHandler) Get(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.Get(c.Context(), companyID)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+func (h *DIANHabilitacionHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: err.Error()})
+	case errors.Is(err, billing.ErrDIANCredentials):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(dto.ErrorResponse{Code: "DIAN_CREDENTIALS", Message: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "la empresa no tiene procesos de habilitación"})
+	case errors.Is(err, domain.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "CONFLICT", Message: "ya hay un set de pruebas en curso para la empresa"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
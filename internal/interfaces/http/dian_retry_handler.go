@@ -0,0 +1,51 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// DIANRetryQueueUseCase interfaz local para consultar la cola de reintentos DIAN.
+type DIANRetryQueueUseCase interface {
+	List(ctx context.Context, companyID, status string) ([]dto.DIANRetryJobDTO, error)
+}
+
+// DIANRetryQueueHandler expone la cola de reintentos de facturas en CONTINGENCIA.
+type DIANRetryQueueHandler struct {
+	uc DIANRetryQueueUseCase
+}
+
+// NewDIANRetryQueueHandler construye el handler.
+func NewDIANRetryQueueHandler(uc DIANRetryQueueUseCase) *DIANRetryQueueHandler {
+	return &DIANRetryQueueHandler{uc: uc}
+}
+
+// List godoc
+// @Summary      Cola de reintentos DIAN
+// @Description  Documentos en contingencia pendientes de reintento (QUEUED), en reintento (RUNNING) y con intentos agotados (DEAD).
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Param        status  query  string  false  "QUEUED | RUNNING | DEAD (vacío = todos)"
+// @Success      200  {array}   dto.DIANRetryJobDTO
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      401  {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/retry-queue [get]
+func (h *DIANRetryQueueHandler) List(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.List(c.Context(), companyID, c.Query("status"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "status debe ser QUEUED, RUNNING o DEAD"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+	}
+	return c.JSON(out)
+}
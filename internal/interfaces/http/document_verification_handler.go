@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/billing"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// DocumentVerificationUseCase interfaz local de la verificación de documentos firmados.
+type DocumentVerificationUseCase interface {
+	VerifyInvoice(ctx context.Context, companyID, invoiceID string) (*dto.DocumentVerificationDTO, error)
+	VerifyXML(ctx context.Context, companyID string, xmlBytes []byte) (*dto.DocumentVerificationDTO, error)
+}
+
+// DocumentVerificationHandler expone la verificación de firma y CUFE/CUDE para auditoría.
+type DocumentVerificationHandler struct {
+	uc DocumentVerificationUseCase
+}
+
+// NewDocumentVerificationHandler construye el handler.
+func NewDocumentVerificationHandler(uc DocumentVerificationUseCase) *DocumentVerificationHandler {
+	return &DocumentVerificationHandler{uc: uc}
+}
+
+// VerifyInvoice godoc
+// @Summary      Verificar documento firmado
+// @Description  Verifica el XML firmado guardado de la factura o nota: firma XAdES-EPES (valor, digest y
+// @Description  propiedades firmadas), vigencia y cadena del certificado en la hora de firma y el CUFE/CUDE
+// @Description  recalculado desde el XML.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Param        invoice_id  path      string  true  "ID de la factura o nota"
+// @Success      200         {object}  dto.DocumentVerificationDTO
+// @Failure      400         {object}  dto.ErrorResponse
+// @Failure      404         {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/verification/{invoice_id} [get]
+func (h *DocumentVerificationHandler) VerifyInvoice(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.VerifyInvoice(c.Context(), companyID, c.Params("invoice_id"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// VerifyXML godoc
+// @Summary      Verificar XML cargado
+// @Description  Verifica un XML firmado cargado como archivo (campo file) o en el cuerpo (application/xml).
+// @Description  El CUFE/CUDE solo se recalcula si el emisor es la empresa; en documentos de terceros queda SKIPPED.
+// @Tags         billing
+// @Security     Bearer
+// @Accept       mpfd
+// @Produce      json
+// @Param        file  formData  file  false  "XML firmado"
+// @Success      200   {object}  dto.DocumentVerificationDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Router       /api/billing/dian/verification [post]
+func (h *DocumentVerificationHandler) VerifyXML(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	xmlBytes := c.Body()
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), "multipart/form-data") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil || fileHeader == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "file es requerido"})
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_FILE", Message: "no se pudo abrir file"})
+		}
+		defer file.Close()
+		if xmlBytes, err = io.ReadAll(file); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_FILE", Message: "no se pudo leer file"})
+		}
+	}
+	out, err := h.uc.VerifyXML(c.Context(), companyID, xmlBytes)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+func (h *DocumentVerificationHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: err.Error()})
+	case errors.Is(err, billing.ErrDIANCredentials):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(dto.ErrorResponse{Code: "DIAN_CREDENTIALS", Message: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "documento no encontrado"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
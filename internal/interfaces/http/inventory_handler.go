@@ -54,6 +54,12 @@ type PurchaseOrderUseCase interface {
 	Receive(ctx context.Context, companyID, userID, purchaseOrderID, warehouseID string) error
 }
 
+// ReverseMovementUseCase interfaz local para revertir movimientos con asientos compensatorios.
+type ReverseMovementUseCase interface {
+	ReverseMovement(ctx context.Context, in appinventory.ReverseMovementInput) (*dto.MovementReversalDTO, error)
+	ReverseTransaction(ctx context.Context, in appinventory.ReverseMovementInput) (*dto.MovementReversalDTO, error)
+}
+
 // InventoryHandler maneja las peticiones HTTP de movimientos e inventario (protegido).
 type InventoryHandler struct {
 	uc            RegisterMovementUseCase
@@ -63,6 +69,7 @@ type InventoryHandler struct {
 	stocktake     StocktakeUseCase
 	reorderConfig ReorderConfigUseCase
 	purchaseOrder PurchaseOrderUseCase
+	reverse       ReverseMovementUseCase
 }
 
 // NewInventoryHandler construye el handler.
@@ -95,6 +102,10 @@ func NewInventoryHandler(uc RegisterMovementUseCase, replenishment Replenishment
 			if !isNilOption(v) {
 				h.purchaseOrder = v
 			}
+		case ReverseMovementUseCase:
+			if !isNilOption(v) {
+				h.reverse = v
+			}
 		}
 	}
 	return h
@@ -292,6 +303,7 @@ func (h *InventoryHandler) RegisterMovement(c *fiber.Ctx) error {
 	if err := c.BodyParser(&in); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
 	}
+	in.AllowBackdated = IsAdmin(c) || IsSuperAdmin(c)
 	err := h.uc.RegisterMovementFromRequest(c.Context(), companyID, userID, in)
 	if err != nil {
 		if err == domain.ErrInvalidInput {
@@ -306,6 +318,9 @@ func (h *InventoryHandler) RegisterMovement(c *fiber.Ctx) error {
 		if err == domain.ErrInsufficientStock {
 			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "INSUFFICIENT_STOCK", Message: "stock insuficiente"})
 		}
+		if err == domain.ErrPeriodClosed {
+			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "PERIOD_CLOSED", Message: "la fecha del movimiento pertenece a un período de inventario cerrado"})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "movimiento registrado"})
@@ -335,6 +350,7 @@ func (h *InventoryHandler) RegisterAdjustment(c *fiber.Ctx) error {
 	if err := c.BodyParser(&in); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
 	}
+	in.AllowBackdated = IsAdmin(c) || IsSuperAdmin(c)
 	movementID, err := h.uc.RegisterAdjustmentFromRequest(c.Context(), companyID, userID, in)
 	if err != nil {
 		if errors.Is(err, domain.ErrInvalidInput) {
@@ -349,11 +365,105 @@ func (h *InventoryHandler) RegisterAdjustment(c *fiber.Ctx) error {
 		if errors.Is(err, domain.ErrInsufficientStock) {
 			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "INSUFFICIENT_STOCK", Message: "stock insuficiente"})
 		}
+		if errors.Is(err, domain.ErrPeriodClosed) {
+			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "PERIOD_CLOSED", Message: "la fecha del movimiento pertenece a un período de inventario cerrado"})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"movement_id": movementID})
 }
 
+// ReverseMovement godoc
+// @Summary      Revertir movimiento de inventario
+// @Description  Registra un asiento compensatorio (REVERSAL) del movimiento, recalcula el costo promedio y lo marca como revertido. Los traslados se revierten completos.
+// @Tags         inventory
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string                      true   "ID del movimiento"
+// @Param        body  body  dto.ReverseMovementRequest  false  "reason"
+// @Success      201   {object}  dto.MovementReversalDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      403   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Failure      503   {object}  dto.ErrorResponse
+// @Router       /api/inventory/movements/{id}/reverse [post]
+func (h *InventoryHandler) ReverseMovement(c *fiber.Ctx) error {
+	return h.handleReversal(c, false)
+}
+
+// ReverseTransaction godoc
+// @Summary      Revertir transacción de inventario
+// @Description  Revierte todos los movimientos de una transacción (transaction_id) de forma atómica.
+// @Tags         inventory
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string                      true   "ID de la transacción"
+// @Param        body  body  dto.ReverseMovementRequest  false  "reason"
+// @Success      201   {object}  dto.MovementReversalDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      403   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Failure      503   {object}  dto.ErrorResponse
+// @Router       /api/inventory/transactions/{id}/reverse [post]
+func (h *InventoryHandler) ReverseTransaction(c *fiber.Ctx) error {
+	return h.handleReversal(c, true)
+}
+
+func (h *InventoryHandler) handleReversal(c *fiber.Ctx, wholeTransaction bool) error {
+	companyID := GetCompanyID(c)
+	userID := GetUserID(c)
+	if companyID == "" || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	if h.reverse == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(dto.ErrorResponse{Code: "SERVICE_UNAVAILABLE", Message: "reversión de movimientos no configurada"})
+	}
+	var body dto.ReverseMovementRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
+		}
+	}
+	in := appinventory.ReverseMovementInput{CompanyID: companyID, UserID: userID, Reason: body.Reason}
+	var (
+		out *dto.MovementReversalDTO
+		err error
+	)
+	if wholeTransaction {
+		in.TransactionID = c.Params("id")
+		out, err = h.reverse.ReverseTransaction(c.Context(), in)
+	} else {
+		in.MovementID = c.Params("id")
+		out, err = h.reverse.ReverseMovement(c.Context(), in)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "el movimiento no se puede revertir"})
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "movimiento no encontrado"})
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{Code: "FORBIDDEN", Message: "acceso denegado al recurso"})
+		}
+		if errors.Is(err, domain.ErrConflict) {
+			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "CONFLICT", Message: "el movimiento ya fue revertido"})
+		}
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "INSUFFICIENT_STOCK", Message: "stock insuficiente para revertir"})
+		}
+		if errors.Is(err, domain.ErrPeriodClosed) {
+			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "PERIOD_CLOSED", Message: "el movimiento pertenece a un período de inventario cerrado"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
+
 type createStocktakeRequest struct {
 	WarehouseID string `json:"warehouse_id"`
 }
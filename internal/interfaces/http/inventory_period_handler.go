@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	appinventory "github.com/jhoicas/Inventario-api/internal/application/inventory"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+	"github.com/jhoicas/Inventario-api/internal/domain/entity"
+	"github.com/shopspring/decimal"
+)
+
+// InventoryPeriodUseCase interfaz local para cierres mensuales de inventario.
+type InventoryPeriodUseCase interface {
+	List(ctx context.Context, companyID string) ([]*entity.InventoryPeriod, error)
+	Get(ctx context.Context, companyID string, year, month int) (*appinventory.InventoryPeriodDetail, error)
+	Close(ctx context.Context, companyID, userID string, year, month int) (*entity.InventoryPeriod, error)
+	Reopen(ctx context.Context, companyID, userID string, year, month int, reason string) (*entity.InventoryPeriod, error)
+}
+
+// InventoryPeriodHandler expone el cierre y la reapertura de períodos de inventario.
+type InventoryPeriodHandler struct {
+	uc InventoryPeriodUseCase
+}
+
+// NewInventoryPeriodHandler construye el handler.
+func NewInventoryPeriodHandler(uc InventoryPeriodUseCase) *InventoryPeriodHandler {
+	return &InventoryPeriodHandler{uc: uc}
+}
+
+// List godoc
+// @Summary      Listar períodos de inventario
+// @Description  Lista los cierres mensuales de inventario de la empresa (más reciente primero).
+// @Tags         inventory
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}   dto.InventoryPeriodDTO
+// @Failure      401  {object}  dto.ErrorResponse
+// @Router       /api/inventory/periods [get]
+func (h *InventoryPeriodHandler) List(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	periods, err := h.uc.List(c.Context(), companyID)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	out := make([]dto.InventoryPeriodDTO, 0, len(periods))
+	for _, p := range periods {
+		out = append(out, toInventoryPeriodDTO(p))
+	}
+	return c.JSON(out)
+}
+
+// Get godoc
+// @Summary      Detalle de período de inventario
+// @Description  Devuelve la valorización congelada por producto y bodega y la bitácora de cierres/reaperturas.
+// @Tags         inventory
+// @Security     Bearer
+// @Produce      json
+// @Param        year   path  int  true  "Año"
+// @Param        month  path  int  true  "Mes (1-12)"
+// @Success      200    {object}  dto.InventoryPeriodDetailDTO
+// @Failure      400    {object}  dto.ErrorResponse
+// @Failure      404    {object}  dto.ErrorResponse
+// @Router       /api/inventory/periods/{year}/{month} [get]
+func (h *InventoryPeriodHandler) Get(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	year, month, ok := periodParams(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "año o mes inválido"})
+	}
+	detail, err := h.uc.Get(c.Context(), companyID, year, month)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	out := dto.InventoryPeriodDetailDTO{
+		InventoryPeriodDTO: toInventoryPeriodDTO(detail.Period),
+		TotalValue:         decimal.Zero,
+		Valuations:         make([]dto.InventoryPeriodValuationDTO, 0, len(detail.Valuations)),
+		Events:             make([]dto.InventoryPeriodEventDTO, 0, len(detail.Events)),
+	}
+	for _, v := range detail.Valuations {
+		out.TotalValue = out.TotalValue.Add(v.TotalValue)
+		out.Valuations = append(out.Valuations, dto.InventoryPeriodValuationDTO{
+			ProductID:   v.ProductID,
+			WarehouseID: v.WarehouseID,
+			Quantity:    v.Quantity,
+			UnitCost:    v.UnitCost,
+			TotalValue:  v.TotalValue,
+		})
+	}
+	for _, e := range detail.Events {
+		out.Events = append(out.Events, dto.InventoryPeriodEventDTO{
+			Action:    e.Action,
+			Reason:    e.Reason,
+			UserID:    e.UserID,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	return c.JSON(out)
+}
+
+// Close godoc
+// @Summary      Cerrar período de inventario
+// @Description  Congela la valorización del mes y bloquea movimientos fechados en él. Solo meses terminados.
+// @Tags         inventory
+// @Security     Bearer
+// @Produce      json
+// @Param        year   path  int  true  "Año"
+// @Param        month  path  int  true  "Mes (1-12)"
+// @Success      200    {object}  dto.InventoryPeriodDTO
+// @Failure      400    {object}  dto.ErrorResponse
+// @Failure      409    {object}  dto.ErrorResponse
+// @Router       /api/inventory/periods/{year}/{month}/close [post]
+func (h *InventoryPeriodHandler) Close(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	userID := GetUserID(c)
+	if companyID == "" || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	year, month, ok := periodParams(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "año o mes inválido"})
+	}
+	period, err := h.uc.Close(c.Context(), companyID, userID, year, month)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(toInventoryPeriodDTO(period))
+}
+
+// Reopen godoc
+// @Summary      Reabrir período de inventario
+// @Description  Reabre un período cerrado dejando registro del motivo. No se permite si hay cierres posteriores.
+// @Tags         inventory
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        year   path  int                               true  "Año"
+// @Param        month  path  int                               true  "Mes (1-12)"
+// @Param        body   body  dto.ReopenInventoryPeriodRequest  true  "reason"
+// @Success      200    {object}  dto.InventoryPeriodDTO
+// @Failure      400    {object}  dto.ErrorResponse
+// @Failure      404    {object}  dto.ErrorResponse
+// @Failure      409    {object}  dto.ErrorResponse
+// @Router       /api/inventory/periods/{year}/{month}/reopen [post]
+func (h *InventoryPeriodHandler) Reopen(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	userID := GetUserID(c)
+	if companyID == "" || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	year, month, ok := periodParams(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "año o mes inválido"})
+	}
+	var in dto.ReopenInventoryPeriodRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
+	}
+	period, err := h.uc.Reopen(c.Context(), companyID, userID, year, month, in.Reason)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(toInventoryPeriodDTO(period))
+}
+
+func (h *InventoryPeriodHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "datos inválidos (el mes debe haber terminado y la reapertura requiere motivo)"})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "período no encontrado"})
+	case errors.Is(err, domain.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "CONFLICT", Message: "el período no admite la operación en su estado actual"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
+
+func periodParams(c *fiber.Ctx) (int, int, bool) {
+	year, err := c.ParamsInt("year")
+	if err != nil {
+		return 0, 0, false
+	}
+	month, err := c.ParamsInt("month")
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, false
+	}
+	return year, month, true
+}
+
+func toInventoryPeriodDTO(p *entity.InventoryPeriod) dto.InventoryPeriodDTO {
+	return dto.InventoryPeriodDTO{
+		ID:        p.ID,
+		Year:      p.Year,
+		Month:     p.Month,
+		Status:    p.Status,
+		ClosedAt:  p.ClosedAt,
+		ClosedBy:  p.ClosedBy,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
@@ -235,7 +235,7 @@ func (h *InvoiceHandler) GetDebitNotes(c *fiber.Ctx) error {
 
 // GetDIANSummary godoc
 // @Summary      Resumen de estado DIAN
-// @Description  Devuelve contadores para tablero DIAN: enviados hoy, pendientes y rechazados
+// @Description  Devuelve contadores para tablero DIAN (enviados hoy, pendientes y rechazados) y el estado de las resoluciones activas: números restantes, días a vencimiento, agotamiento proyectado y alertas.
 // @Tags         billing
 // @Security     Bearer
 // @Produce      json
@@ -285,8 +285,12 @@ func (h *InvoiceHandler) Create(c *fiber.Ctx) error {
 	}
 	invoice, err := h.uc.CreateInvoice(c.Context(), companyID, userID, in)
 	if err != nil {
-		if err == domain.ErrInvalidInput {
-			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "datos inválidos"})
+		if errors.Is(err, domain.ErrInvalidInput) {
+			msg := "datos inválidos"
+			if err != domain.ErrInvalidInput {
+				msg = err.Error()
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: msg})
 		}
 		if err == domain.ErrNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "cliente, bodega o producto no encontrado"})
@@ -301,6 +305,9 @@ func (h *InvoiceHandler) Create(c *fiber.Ctx) error {
 				Message: err.Error(),
 			})
 		}
+		if isNumberingError(err) {
+			return c.Status(fiber.StatusConflict).JSON(numberingErrorResponse(err))
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(invoice)
@@ -356,6 +363,9 @@ func (h *InvoiceHandler) HandleReturn(c *fiber.Ctx) error {
 				Message: err.Error(),
 			})
 		}
+		if isNumberingError(err) {
+			return c.Status(fiber.StatusConflict).JSON(numberingErrorResponse(err))
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
 	}
 
@@ -415,6 +425,9 @@ func (h *InvoiceHandler) HandleDebitNote(c *fiber.Ctx) error {
 				Message: err.Error(),
 			})
 		}
+		if isNumberingError(err) {
+			return c.Status(fiber.StatusConflict).JSON(numberingErrorResponse(err))
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
 	}
 
@@ -471,6 +484,9 @@ func (h *InvoiceHandler) HandleVoidInvoice(c *fiber.Ctx) error {
 		if err == domain.ErrConflict {
 			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "CONFLICT", Message: "la factura debe estar en estado Sent"})
 		}
+		if isNumberingError(err) {
+			return c.Status(fiber.StatusConflict).JSON(numberingErrorResponse(err))
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
 	}
 
@@ -734,3 +750,17 @@ func (h *InvoiceHandler) RetryDIAN(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(status)
 }
+
+// isNumberingError indica si el error proviene de la asignación de consecutivo (resolución).
+func isNumberingError(err error) bool {
+	return errors.Is(err, domain.ErrNoActiveResolution) || errors.Is(err, domain.ErrResolutionExhausted)
+}
+
+// numberingErrorResponse traduce los errores de numeración a una respuesta con código estable.
+func numberingErrorResponse(err error) dto.ErrorResponse {
+	code := "NO_ACTIVE_RESOLUTION"
+	if errors.Is(err, domain.ErrResolutionExhausted) {
+		code = "RESOLUTION_EXHAUSTED"
+	}
+	return dto.ErrorResponse{Code: code, Message: err.Error()}
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// POSDocumentUseCase interfaz local del documento equivalente electrónico POS.
+type POSDocumentUseCase interface {
+	Create(ctx context.Context, companyID, userID string, in dto.CreatePOSDocumentRequest) (*dto.InvoiceResponse, error)
+}
+
+// POSDocumentHandler expone la emisión de documentos equivalentes POS desde la caja.
+type POSDocumentHandler struct {
+	uc POSDocumentUseCase
+}
+
+// NewPOSDocumentHandler construye el handler.
+func NewPOSDocumentHandler(uc POSDocumentUseCase) *POSDocumentHandler {
+	return &POSDocumentHandler{uc: uc}
+}
+
+// Create godoc
+// @Summary      Emitir documento equivalente POS
+// @Description  Registra una venta de mostrador como documento equivalente electrónico POS (tipo 20) con el
+// @Description  consecutivo de la resolución del prefijo POS. Sin customer_id ni buyer sale a nombre del
+// @Description  consumidor final (222222222222). Responde en DRAFT: el CUDE, la firma y el envío a la DIAN
+// @Description  se procesan en cola sin bloquear la caja (consultar el estado en /api/invoices/{id}/status).
+// @Tags         billing
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.CreatePOSDocumentRequest  true  "Prefijo POS, bodega, ítems y comprador opcional"
+// @Success      201   {object}  dto.InvoiceResponse
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      403   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Router       /api/billing/pos-documents [post]
+func (h *POSDocumentHandler) Create(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	userID := GetUserID(c)
+	if companyID == "" || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	var in dto.CreatePOSDocumentRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.Create(c.Context(), companyID, userID, in)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "datos inválidos"})
+		case errors.Is(err, domain.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "cliente, bodega o producto no encontrado"})
+		case errors.Is(err, domain.ErrForbidden):
+			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{Code: "FORBIDDEN", Message: "acceso denegado al recurso"})
+		case errors.Is(err, domain.ErrInsufficientStock):
+			return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "INSUFFICIENT_STOCK", Message: err.Error()})
+		case isNumberingError(err):
+			return c.Status(fiber.StatusConflict).JSON(numberingErrorResponse(err))
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// ProductKitUseCase interfaz local para la composición de kits.
+type ProductKitUseCase interface {
+	GetComponents(companyID, kitID string) ([]dto.KitComponentDTO, error)
+	ReplaceComponents(companyID, kitID string, in dto.UpdateKitComponentsRequest) ([]dto.KitComponentDTO, error)
+}
+
+// ProductKitHandler expone la composición de productos tipo KIT.
+type ProductKitHandler struct {
+	uc ProductKitUseCase
+}
+
+// NewProductKitHandler construye el handler.
+func NewProductKitHandler(uc ProductKitUseCase) *ProductKitHandler {
+	return &ProductKitHandler{uc: uc}
+}
+
+// GetComponents godoc
+// @Summary      Componentes de un kit
+// @Tags         products
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path  string  true  "ID del producto kit"
+// @Success      200  {array}   dto.KitComponentDTO
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/products/{id}/components [get]
+func (h *ProductKitHandler) GetComponents(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	list, err := h.uc.GetComponents(companyID, c.Params("id"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(list)
+}
+
+// ReplaceComponents godoc
+// @Summary      Definir componentes de un kit
+// @Description  Reemplaza la composición del kit. Al facturar el kit se descuentan estos componentes.
+// @Tags         products
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "ID del producto kit"
+// @Param        body  body  dto.UpdateKitComponentsRequest  true  "Componentes"
+// @Success      200   {array}   dto.KitComponentDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Router       /api/products/{id}/components [put]
+func (h *ProductKitHandler) ReplaceComponents(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.UpdateKitComponentsRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
+	}
+	list, err := h.uc.ReplaceComponents(companyID, c.Params("id"), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(list)
+}
+
+func (h *ProductKitHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "el producto debe ser KIT y sus componentes productos estándar con cantidad positiva"})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "producto no encontrado"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
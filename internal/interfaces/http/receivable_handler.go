@@ -0,0 +1,226 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// ReceivableUseCase interfaz local para la cartera de clientes.
+type ReceivableUseCase interface {
+	RegisterPayment(ctx context.Context, companyID, userID string, in dto.RegisterPaymentRequest) (*dto.PaymentResponse, error)
+	ApplyCredit(ctx context.Context, companyID, paymentID string, in dto.ApplyPaymentRequest) (*dto.PaymentResponse, error)
+	GetPayment(ctx context.Context, companyID, paymentID string) (*dto.PaymentResponse, error)
+	ListInvoices(ctx context.Context, companyID, customerID string, openOnly bool) ([]dto.ReceivableInvoiceDTO, error)
+	GetStatement(ctx context.Context, companyID, customerID, currency string, start, end time.Time) (*dto.CustomerStatementDTO, error)
+	GetAging(ctx context.Context, companyID, currency string, asOf time.Time) (*dto.ReceivablesAgingDTO, error)
+}
+
+// ReceivableHandler expone recaudos de clientes, saldos de facturas, estado de cuenta y cartera por edades.
+type ReceivableHandler struct {
+	uc ReceivableUseCase
+}
+
+// NewReceivableHandler construye el handler.
+func NewReceivableHandler(uc ReceivableUseCase) *ReceivableHandler {
+	return &ReceivableHandler{uc: uc}
+}
+
+// RegisterPayment godoc
+// @Summary      Registrar recaudo
+// @Description  Registra un pago del cliente (efectivo, transferencia, tarjeta…) aplicado a una o varias facturas.
+// @Description  Sin applications se abona a las facturas más antiguas; el excedente queda como saldo a favor.
+// @Tags         receivables
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body  dto.RegisterPaymentRequest  true  "Recaudo"
+// @Success      201   {object}  dto.PaymentResponse
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Router       /api/receivables/payments [post]
+func (h *ReceivableHandler) RegisterPayment(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.RegisterPaymentRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.RegisterPayment(c.Context(), companyID, GetUserID(c), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
+
+// GetPayment godoc
+// @Summary      Obtener recaudo
+// @Description  Recaudo con sus aplicaciones a facturas y saldo a favor sin aplicar.
+// @Tags         receivables
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path  string  true  "ID del recaudo"
+// @Success      200  {object}  dto.PaymentResponse
+// @Failure      403  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/receivables/payments/{id} [get]
+func (h *ReceivableHandler) GetPayment(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.GetPayment(c.Context(), companyID, c.Params("id"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// ApplyCredit godoc
+// @Summary      Aplicar saldo a favor
+// @Description  Aplica el saldo sin aplicar de un recaudo a facturas abiertas del mismo cliente y moneda.
+// @Tags         receivables
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string                   true  "ID del recaudo"
+// @Param        body  body  dto.ApplyPaymentRequest  true  "Aplicaciones"
+// @Success      200   {object}  dto.PaymentResponse
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Router       /api/receivables/payments/{id}/apply [post]
+func (h *ReceivableHandler) ApplyCredit(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.ApplyPaymentRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.ApplyCredit(c.Context(), companyID, c.Params("id"), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// ListInvoices godoc
+// @Summary      Saldos de facturas
+// @Description  Facturas con valor a cobrar, abonado, saldo, estado de pago (PENDING, PARTIAL, PAID) y días vencidos.
+// @Tags         receivables
+// @Security     Bearer
+// @Produce      json
+// @Param        customer_id  query  string  false  "Filtrar por cliente"
+// @Param        open         query  bool    false  "Solo facturas con saldo pendiente"
+// @Success      200  {array}   dto.ReceivableInvoiceDTO
+// @Failure      401  {object}  dto.ErrorResponse
+// @Router       /api/receivables/invoices [get]
+func (h *ReceivableHandler) ListInvoices(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.ListInvoices(c.Context(), companyID, c.Query("customer_id"), c.QueryBool("open", false))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// GetStatement godoc
+// @Summary      Estado de cuenta del cliente
+// @Description  Saldo inicial, facturas y recaudos del período con saldo acumulado, saldo final y facturas abiertas.
+// @Tags         receivables
+// @Security     Bearer
+// @Produce      json
+// @Param        id          path   string  true   "ID del cliente"
+// @Param        start_date  query  string  false  "YYYY-MM-DD (por defecto: primer día del mes)"
+// @Param        end_date    query  string  false  "YYYY-MM-DD (por defecto: hoy)"
+// @Param        currency    query  string  false  "Moneda (por defecto COP)"
+// @Success      200  {object}  dto.CustomerStatementDTO
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/receivables/customers/{id}/statement [get]
+func (h *ReceivableHandler) GetStatement(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	now := time.Now()
+	start, ok := parseQueryDate(c, "start_date", time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_DATE", Message: "start_date debe tener formato YYYY-MM-DD"})
+	}
+	end, ok := parseQueryDate(c, "end_date", now)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_DATE", Message: "end_date debe tener formato YYYY-MM-DD"})
+	}
+	out, err := h.uc.GetStatement(c.Context(), companyID, c.Params("id"), c.Query("currency"), start, end)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// GetAging godoc
+// @Summary      Cartera por edades
+// @Description  Saldo pendiente por cliente en rangos de días vencidos: corriente, 1–30, 31–60, 61–90 y más de 90.
+// @Tags         receivables
+// @Security     Bearer
+// @Produce      json
+// @Param        as_of     query  string  false  "Fecha de corte YYYY-MM-DD (por defecto: hoy)"
+// @Param        currency  query  string  false  "Moneda (por defecto COP)"
+// @Success      200  {object}  dto.ReceivablesAgingDTO
+// @Failure      400  {object}  dto.ErrorResponse
+// @Router       /api/receivables/aging [get]
+func (h *ReceivableHandler) GetAging(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	asOf, ok := parseQueryDate(c, "as_of", time.Now())
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_DATE", Message: "as_of debe tener formato YYYY-MM-DD"})
+	}
+	out, err := h.uc.GetAging(c.Context(), companyID, c.Query("currency"), asOf)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// parseQueryDate lee un parámetro YYYY-MM-DD; si no viene devuelve def.
+func parseQueryDate(c *fiber.Ctx, key string, def time.Time) (time.Time, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return def, true
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (h *ReceivableHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "recaudo o aplicación inválidos: revise cliente, valor, moneda, medio de pago y saldos de las facturas"})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "cliente o recaudo no encontrado"})
+	case errors.Is(err, domain.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{Code: "FORBIDDEN", Message: "el recurso no pertenece a la empresa"})
+	case errors.Is(err, domain.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "CONFLICT", Message: "el saldo de la factura o del recaudo cambió; consulte de nuevo e intente otra vez"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
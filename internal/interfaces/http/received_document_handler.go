@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// ReceivedDocumentUseCase interfaz local de las facturas recibidas y sus eventos RADIAN.
+type ReceivedDocumentUseCase interface {
+	Register(ctx context.Context, companyID, userID string, in dto.RegisterReceivedDocumentRequest) (*dto.ReceivedDocumentDTO, error)
+	Get(ctx context.Context, companyID, id string) (*dto.ReceivedDocumentDTO, error)
+	List(ctx context.Context, companyID, status string) ([]dto.ReceivedDocumentDTO, error)
+	EmitEvent(ctx context.Context, companyID, userID, documentID string, in dto.CreateReceivedDocumentEventRequest) (*dto.ReceivedDocumentEventDTO, error)
+}
+
+// ReceivedDocumentHandler expone las facturas electrónicas recibidas de proveedores y sus eventos.
+type ReceivedDocumentHandler struct {
+	uc ReceivedDocumentUseCase
+}
+
+// NewReceivedDocumentHandler construye el handler.
+func NewReceivedDocumentHandler(uc ReceivedDocumentUseCase) *ReceivedDocumentHandler {
+	return &ReceivedDocumentHandler{uc: uc}
+}
+
+// Register godoc
+// @Summary      Registrar factura recibida
+// @Description  Registra una factura electrónica de venta recibida de un proveedor (CUFE, número y totales) para
+// @Description  emitir sobre ella los eventos RADIAN.
+// @Tags         billing
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.RegisterReceivedDocumentRequest  true  "Emisor, número, CUFE y totales"
+// @Success      201   {object}  dto.ReceivedDocumentDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Router       /api/billing/received-documents [post]
+func (h *ReceivedDocumentHandler) Register(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.RegisterReceivedDocumentRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.Register(c.Context(), companyID, GetUserID(c), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
+
+// Get godoc
+// @Summary      Obtener factura recibida
+// @Description  Factura recibida con su estado RADIAN, historial de eventos y fin del término para reclamar.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      string  true  "ID de la factura recibida"
+// @Success      200  {object}  dto.ReceivedDocumentDTO
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/billing/received-documents/{id} [get]
+func (h *ReceivedDocumentHandler) Get(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.Get(c.Context(), companyID, c.Params("id"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// List godoc
+// @Summary      Listar facturas recibidas
+// @Description  Facturas recibidas de proveedores, de la más reciente a la más antigua.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Param        status  query     string  false  "RECEIVED | ACKNOWLEDGED | GOODS_RECEIVED | ACCEPTED | CLAIMED | TACITLY_ACCEPTED"
+// @Success      200     {array}   dto.ReceivedDocumentDTO
+// @Router       /api/billing/received-documents [get]
+func (h *ReceivedDocumentHandler) List(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.List(c.Context(), companyID, c.Query("status"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// EmitEvent godoc
+// @Summary      Emitir evento RADIAN
+// @Description  Emite sobre la factura recibida el acuse de recibo (030), el recibo del bien (032), la aceptación
+// @Description  expresa (033) o un reclamo (031). Cada evento exige el anterior validado por la DIAN; el CUDE, la
+// @Description  firma y el envío (SendEventUpdateStatus) se hacen en segundo plano.
+// @Tags         billing
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                                  true  "ID de la factura recibida"
+// @Param        body  body      dto.CreateReceivedDocumentEventRequest  true  "Código del evento, concepto del reclamo y persona que recibe"
+// @Success      201   {object}  dto.ReceivedDocumentEventDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Router       /api/billing/received-documents/{id}/events [post]
+func (h *ReceivedDocumentHandler) EmitEvent(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.CreateReceivedDocumentEventRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.EmitEvent(c.Context(), companyID, GetUserID(c), c.Params("id"), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
+
+func (h *ReceivedDocumentHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "factura recibida no encontrada"})
+	case errors.Is(err, domain.ErrDuplicate):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "DUPLICATE", Message: "la factura ya está registrada (mismo CUFE)"})
+	case errors.Is(err, domain.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "EVENT_SEQUENCE", Message: err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
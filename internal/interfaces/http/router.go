@@ -19,6 +19,7 @@ type RouterDeps struct {
 	CompanyRepo            repository.CompanyRepository // Para inyectar configuración DIAN
 	WarehouseUC            *usecase.WarehouseUseCase
 	ProductUC              *usecase.ProductUseCase
+	ProductKits            *usecase.ProductKitUseCase
 	SupplierUC             *usecase.SupplierUseCase
 	UserRepo               repository.UserRepository
 	RegisterMovement       *inventory.RegisterMovementUseCase
@@ -29,12 +30,24 @@ type RouterDeps struct {
 	DIANSettingsUC         *usecase.DIANSettingsUseCase
 	Stocktake              *inventory.StocktakeUseCase
 	PurchaseOrder          *inventory.PurchaseOrderUseCase
+	ReverseMovement        *inventory.ReverseMovementUseCase
+	InventoryPeriod        *inventory.InventoryPeriodUseCase
+	StockAlerts            *inventory.StockAlertUseCase
 	CustomerUC             *billing.CustomerUseCase
 	CreateInvoice          *billing.CreateInvoiceUseCase
 	ReturnInvoice          *billing.CreateCreditNoteUseCase
 	DebitNote              *billing.CreateDebitNoteUseCase
 	VoidInvoice            *billing.CreateVoidInvoiceUseCase
 	InvoicePDF             *billing.PDFUseCase
+	Withholdings           *billing.WithholdingUseCase
+	Receivables            *billing.ReceivableUseCase
+	DIANRetryQueue         *billing.DIANRetryQueue
+	DIANHabilitacion       *billing.DIANHabilitacionUseCase
+	SupportDocuments       *billing.SupportDocumentUseCase
+	POSDocuments           *billing.POSDocumentUseCase
+	ReceivedDocuments      *billing.ReceivedDocumentUseCase
+	DocumentVerification   *billing.DocumentVerificationUseCase
+	DIANContingency        *billing.DIANContingencyUseCase
 	AuthUC                 *auth.AuthUseCase
 	ModuleService          *usecase.ModuleService
 	RBACUC                 *usecase.RBACUseCase
@@ -138,6 +151,11 @@ func Router(app *fiber.App, deps RouterDeps) {
 	prod.Get("/:id", productHandler.GetByID)
 	prod.Post("/", productHandler.Create)
 	prod.Put("/:id", productHandler.Update)
+	if deps.ProductKits != nil {
+		productKitHandler := NewProductKitHandler(deps.ProductKits)
+		prod.Get("/:id/components", productKitHandler.GetComponents)
+		prod.Put("/:id/components", productKitHandler.ReplaceComponents)
+	}
 
 	supplierHandler := NewSupplierHandler(deps.SupplierUC)
 	sup := protected.Group("/suppliers", RequireModule(entity.ModuleInventory, deps.ModuleService), screenAccess)
@@ -210,7 +228,7 @@ func Router(app *fiber.App, deps RouterDeps) {
 	}
 
 	// ── Inventario (módulo 'inventory' + roles) ────────────────────────────────
-	inventoryHandler := NewInventoryHandler(deps.RegisterMovement, deps.Replenishment, deps.GetStock, deps.ListMovements, deps.ReorderConfig, deps.Stocktake, deps.PurchaseOrder)
+	inventoryHandler := NewInventoryHandler(deps.RegisterMovement, deps.Replenishment, deps.GetStock, deps.ListMovements, deps.ReorderConfig, deps.Stocktake, deps.PurchaseOrder, deps.ReverseMovement)
 	po := protected.Group("/purchase-orders", RequireModule(entity.ModuleInventory, deps.ModuleService), screenAccess)
 	po.Get("/",
 		inventoryHandler.GetPurchaseOrders,
@@ -233,6 +251,12 @@ func Router(app *fiber.App, deps RouterDeps) {
 	invGroup.Post("/movements",
 		inventoryHandler.RegisterMovement,
 	)
+	invGroup.Post("/movements/:id/reverse",
+		inventoryHandler.ReverseMovement,
+	)
+	invGroup.Post("/transactions/:id/reverse",
+		inventoryHandler.ReverseTransaction,
+	)
 	invGroup.Get("/replenishment-list",
 		inventoryHandler.GetReplenishmentList,
 	)
@@ -249,6 +273,26 @@ func Router(app *fiber.App, deps RouterDeps) {
 		inventoryHandler.CloseStocktake,
 	)
 
+	// ── Cierres mensuales de inventario (cerrar/reabrir solo admin) ───────────
+	if deps.InventoryPeriod != nil {
+		periodHandler := NewInventoryPeriodHandler(deps.InventoryPeriod)
+		invGroup.Get("/periods", periodHandler.List)
+		invGroup.Get("/periods/:year/:month", periodHandler.Get)
+		invGroup.Post("/periods/:year/:month/close", RequireRole(entity.RoleAdmin), periodHandler.Close)
+		invGroup.Post("/periods/:year/:month/reopen", RequireRole(entity.RoleAdmin), periodHandler.Reopen)
+	}
+
+	// ── Alertas de stock bajo y notificaciones in-app ──────────────────────────
+	if deps.StockAlerts != nil {
+		alertHandler := NewStockAlertHandler(deps.StockAlerts)
+		invGroup.Get("/alerts", alertHandler.ListAlerts)
+		invGroup.Get("/alerts/subscriptions", alertHandler.ListSubscriptions)
+		invGroup.Put("/alerts/subscriptions", alertHandler.UpdateSubscriptions)
+		// Las notificaciones son personales: solo requieren JWT.
+		protected.Get("/notifications", alertHandler.ListNotifications)
+		protected.Post("/notifications/:id/read", alertHandler.MarkNotificationRead)
+	}
+
 	// ── Facturación (módulo 'billing' + roles) ─────────────────────────────────
 	invoiceHandler := NewInvoiceHandlerWithBillingOps(deps.CreateInvoice, deps.ReturnInvoice, deps.DebitNote, deps.VoidInvoice, deps.InvoicePDF, deps.InvoiceMailer)
 
@@ -297,6 +341,61 @@ func Router(app *fiber.App, deps RouterDeps) {
 	billingGroup.Get("/dian/summary",
 		invoiceHandler.GetDIANSummary,
 	)
+	if deps.Withholdings != nil {
+		withholdingHandler := NewWithholdingHandler(deps.Withholdings)
+		billingGroup.Get("/withholdings", RequireRole(entity.RoleAdmin), withholdingHandler.GetConfig)
+		billingGroup.Put("/withholdings", RequireRole(entity.RoleAdmin), withholdingHandler.UpdateConfig)
+	}
+	if deps.DIANRetryQueue != nil {
+		retryQueueHandler := NewDIANRetryQueueHandler(deps.DIANRetryQueue)
+		billingGroup.Get("/dian/retry-queue", RequireRole(entity.RoleAdmin), retryQueueHandler.List)
+	}
+	if deps.DIANHabilitacion != nil {
+		habilitacionHandler := NewDIANHabilitacionHandler(deps.DIANHabilitacion)
+		billingGroup.Post("/dian/habilitacion", RequireRole(entity.RoleAdmin), habilitacionHandler.Start)
+		billingGroup.Get("/dian/habilitacion", RequireRole(entity.RoleAdmin), habilitacionHandler.Get)
+	}
+	if deps.SupportDocuments != nil {
+		supportDocumentHandler := NewSupportDocumentHandler(deps.SupportDocuments)
+		billingGroup.Get("/support-documents", supportDocumentHandler.List)
+		billingGroup.Post("/support-documents", supportDocumentHandler.Create)
+		billingGroup.Get("/support-documents/:id", supportDocumentHandler.Get)
+		billingGroup.Post("/support-documents/:id/adjustment", supportDocumentHandler.CreateAdjustment)
+	}
+	if deps.POSDocuments != nil {
+		billingGroup.Post("/pos-documents", NewPOSDocumentHandler(deps.POSDocuments).Create)
+	}
+	if deps.ReceivedDocuments != nil {
+		receivedDocumentHandler := NewReceivedDocumentHandler(deps.ReceivedDocuments)
+		billingGroup.Get("/received-documents", receivedDocumentHandler.List)
+		billingGroup.Post("/received-documents", receivedDocumentHandler.Register)
+		billingGroup.Get("/received-documents/:id", receivedDocumentHandler.Get)
+		billingGroup.Post("/received-documents/:id/events", receivedDocumentHandler.EmitEvent)
+	}
+	if deps.DIANContingency != nil {
+		contingencyHandler := NewDIANContingencyHandler(deps.DIANContingency)
+		billingGroup.Get("/dian/contingency", contingencyHandler.List)
+		billingGroup.Post("/dian/contingency", RequireRole(entity.RoleAdmin), contingencyHandler.Start)
+		billingGroup.Post("/dian/contingency/end", RequireRole(entity.RoleAdmin), contingencyHandler.End)
+		billingGroup.Post("/dian/contingency/:id/transmit", RequireRole(entity.RoleAdmin), contingencyHandler.Transmit)
+		billingGroup.Get("/dian/contingency/:id/reconciliation", contingencyHandler.Reconcile)
+	}
+	if deps.DocumentVerification != nil {
+		verificationHandler := NewDocumentVerificationHandler(deps.DocumentVerification)
+		billingGroup.Get("/dian/verification/:invoice_id", verificationHandler.VerifyInvoice)
+		billingGroup.Post("/dian/verification", verificationHandler.VerifyXML)
+	}
+
+	if deps.Receivables != nil {
+		receivableHandler := NewReceivableHandler(deps.Receivables)
+		arGroup := protected.Group("/receivables", RequireModule(entity.ModuleBilling, deps.ModuleService), screenAccess)
+		arGroup.Post("/payments", receivableHandler.RegisterPayment)
+		arGroup.Get("/payments/:id", receivableHandler.GetPayment)
+		arGroup.Post("/payments/:id/apply", receivableHandler.ApplyCredit)
+		arGroup.Get("/invoices", receivableHandler.ListInvoices)
+		arGroup.Get("/customers/:id/statement", receivableHandler.GetStatement)
+		arGroup.Get("/aging", receivableHandler.GetAging)
+	}
 
 	// ── Analytics (módulo 'analytics' + solo admin) ────────────────────────────
 	analyticsHandler := NewAnalyticsHandler(deps.AnalyticsUC, deps.RawMaterialAnalyticsUC)
@@ -306,6 +405,7 @@ func Router(app *fiber.App, deps RouterDeps) {
 	)
 	analyticsGroup.Get("/margins", analyticsHandler.GetMargins)
 	analyticsGroup.Get("/raw-materials-impact", analyticsHandler.GetRawMaterialImpactRanking)
+	analyticsGroup.Get("/inventory-aging", analyticsHandler.GetInventoryAging)
 
 	// ── Dashboard (JWT + solo admin) ───────────────────────────────────────────
 	dashboardHandler := NewDashboardHandler(deps.DashboardUC)
@@ -36,6 +36,9 @@ func (f *fakeCompanyRepoForRouter) Update(company *entity.Company) error
 func (f *fakeCompanyRepoForRouter) List(limit, offset int) ([]*entity.Company, error) {
 	return nil, nil
 }
+func (f *fakeCompanyRepoForRouter) ListForAdmin(limit, offset int) ([]*entity.Company, error) {
+	return nil, nil
+}
 func (f *fakeCompanyRepoForRouter) Delete(id string) error { return nil }
 func (f *fakeCompanyRepoForRouter) ListModules(ctx context.Context, companyID string) ([]*entity.CompanyModule, error) {
 	return []*entity.CompanyModule{}, nil
@@ -55,6 +58,24 @@ func (f *fakeCompanyRepoForRouter) HasActiveModule(ctx context.Context, companyI
 	}
 	return false, nil
 }
+func (f *fakeCompanyRepoForRouter) HasActiveScreen(ctx context.Context, companyID, screenID string) (bool, error) {
+	return true, nil
+}
+func (f *fakeCompanyRepoForRouter) ListScreens(ctx context.Context, companyID string) ([]*entity.CompanyScreen, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepoForRouter) GetScreen(ctx context.Context, companyID, screenID string) (*entity.CompanyScreen, error) {
+	return nil, nil
+}
+func (f *fakeCompanyRepoForRouter) UpsertScreen(ctx context.Context, screen *entity.CompanyScreen) error {
+	return nil
+}
+func (f *fakeCompanyRepoForRouter) ReplaceScreens(ctx context.Context, companyID string, screenIDs []string) error {
+	return nil
+}
+func (f *fakeCompanyRepoForRouter) DeleteScreen(ctx context.Context, companyID, screenID string) error {
+	return nil
+}
 
 func tokenForRouterRole(t *testing.T, role string) string {
 	t.Helper()
@@ -63,6 +84,42 @@ func tokenForRouterRole(t *testing.T, role string) string {
 	return "Bearer " + tok
 }
 
+// fakeRoleRepoForRouter resuelve cualquier rol por su clave (el ID es la misma clave).
+type fakeRoleRepoForRouter struct{}
+
+func (f *fakeRoleRepoForRouter) GetByID(id string) (*entity.Role, error) { return nil, nil }
+func (f *fakeRoleRepoForRouter) GetByKey(key string) (*entity.Role, error) {
+	return &entity.Role{ID: key, Key: key}, nil
+}
+func (f *fakeRoleRepoForRouter) List(limit, offset int) ([]*entity.Role, error) { return nil, nil }
+
+// fakeRBACRepoForRouter concede todas las pantallas salvo a los roles de denied.
+type fakeRBACRepoForRouter struct {
+	denied map[string]bool
+}
+
+func (f *fakeRBACRepoForRouter) ListModulesWithScreens() ([]*entity.Module, error) { return nil, nil }
+func (f *fakeRBACRepoForRouter) GetMenuByRoleID(roleID string) ([]*entity.Module, error) {
+	return nil, nil
+}
+func (f *fakeRBACRepoForRouter) CanAccess(roleID, apiEndpoint string) (bool, error) {
+	return !f.denied[roleID], nil
+}
+func (f *fakeRBACRepoForRouter) GetScreenByID(ctx context.Context, id string) (*entity.Screen, error) {
+	return nil, nil
+}
+func (f *fakeRBACRepoForRouter) GetScreenByEndpoint(ctx context.Context, apiEndpoint string) (*entity.Screen, error) {
+	return nil, nil
+}
+func (f *fakeRBACRepoForRouter) ReplaceRoleScreens(roleID string, screenIDs []string) error {
+	return nil
+}
+
+// routerTestRBAC niega al vendedor todas las rutas protegidas por pantalla; admin las salta por rol.
+func routerTestRBAC() *usecase.RBACUseCase {
+	return usecase.NewRBACUseCase(&fakeRoleRepoForRouter{}, &fakeRBACRepoForRouter{denied: map[string]bool{entity.RoleVendedor: true}})
+}
+
 func TestRouter_ReorderConfigRouteProtections(t *testing.T) {
 	body := `{"warehouse_id":"wh-1","reorder_point":"10","min_stock":"2","max_stock":"30","lead_time_days":5}`
 
@@ -82,7 +139,7 @@ func TestRouter_ReorderConfigRouteProtections(t *testing.T) {
 		},
 		{
 			name:           "Forbidden_ModuleDisabled",
-			authHeader:     tokenForRouterRole(t, entity.RoleAdmin),
+			authHeader:     tokenForRouterRole(t, entity.RoleBodeguero),
 			moduleActive:   false,
 			expectedStatus: http.StatusForbidden,
 			expectedCode:   "MODULE_DISABLED",
@@ -118,6 +175,7 @@ func TestRouter_ReorderConfigRouteProtections(t *testing.T) {
 			app := fiber.New(fiber.Config{DisableStartupMessage: true})
 			Router(app, RouterDeps{
 				ModuleService: usecase.NewModuleService(companyRepo),
+				RBACUC:        routerTestRBAC(),
 				JWTSecret:     routerTestJWTSecret,
 			})
 
@@ -225,7 +283,7 @@ func TestRouter_StocktakeRoutesProtections(t *testing.T) {
 					return true, nil
 				}}
 				app := fiber.New(fiber.Config{DisableStartupMessage: true})
-				Router(app, RouterDeps{ModuleService: usecase.NewModuleService(companyRepo), JWTSecret: routerTestJWTSecret})
+				Router(app, RouterDeps{ModuleService: usecase.NewModuleService(companyRepo), RBACUC: routerTestRBAC(), JWTSecret: routerTestJWTSecret})
 
 				req := httptest.NewRequest(ep.method, ep.path, strings.NewReader(ep.body))
 				req.Header.Set("Content-Type", "application/json")
@@ -247,11 +305,11 @@ func TestRouter_StocktakeRoutesProtections(t *testing.T) {
 					return true, nil
 				}}
 				app := fiber.New(fiber.Config{DisableStartupMessage: true})
-				Router(app, RouterDeps{ModuleService: usecase.NewModuleService(companyRepo), JWTSecret: routerTestJWTSecret})
+				Router(app, RouterDeps{ModuleService: usecase.NewModuleService(companyRepo), RBACUC: routerTestRBAC(), JWTSecret: routerTestJWTSecret})
 
 				req := httptest.NewRequest(ep.method, ep.path, strings.NewReader(ep.body))
 				req.Header.Set("Content-Type", "application/json")
-				req.Header.Set("Authorization", tokenForRouterRole(t, entity.RoleAdmin))
+				req.Header.Set("Authorization", tokenForRouterRole(t, entity.RoleBodeguero))
 				resp, err := app.Test(req, -1)
 				require.NoError(t, err)
 				defer resp.Body.Close()
@@ -267,7 +325,7 @@ func TestRouter_StocktakeRoutesProtections(t *testing.T) {
 					return true, nil
 				}}
 				app := fiber.New(fiber.Config{DisableStartupMessage: true})
-				Router(app, RouterDeps{ModuleService: usecase.NewModuleService(companyRepo), JWTSecret: routerTestJWTSecret})
+				Router(app, RouterDeps{ModuleService: usecase.NewModuleService(companyRepo), RBACUC: routerTestRBAC(), JWTSecret: routerTestJWTSecret})
 
 				req := httptest.NewRequest(ep.method, ep.path, strings.NewReader(ep.body))
 				req.Header.Set("Content-Type", "application/json")
@@ -287,7 +345,7 @@ func TestRouter_StocktakeRoutesProtections(t *testing.T) {
 					return true, nil
 				}}
 				app := fiber.New(fiber.Config{DisableStartupMessage: true})
-				Router(app, RouterDeps{ModuleService: usecase.NewModuleService(companyRepo), JWTSecret: routerTestJWTSecret})
+				Router(app, RouterDeps{ModuleService: usecase.NewModuleService(companyRepo), RBACUC: routerTestRBAC(), JWTSecret: routerTestJWTSecret})
 
 				req := httptest.NewRequest(ep.method, ep.path, strings.NewReader(ep.body))
 				req.Header.Set("Content-Type", "application/json")
@@ -349,7 +407,7 @@ func TestRouter_DIANSettingsGetRouteProtections(t *testing.T) {
 		},
 		{
 			name:           "Forbidden_ModuleDisabled",
-			authHeader:     tokenForRouterRole(t, entity.RoleAdmin),
+			authHeader:     tokenForRouterRole(t, entity.RoleBodeguero),
 			moduleActive:   false,
 			expectedStatus: http.StatusForbidden,
 			expectedCode:   "MODULE_DISABLED",
@@ -387,6 +445,7 @@ func TestRouter_DIANSettingsGetRouteProtections(t *testing.T) {
 				Router(app, RouterDeps{
 					ModuleService:  usecase.NewModuleService(companyRepo),
 					DIANSettingsUC: dianSettingsUC,
+					RBACUC:         routerTestRBAC(),
 					JWTSecret:      routerTestJWTSecret,
 				})
 
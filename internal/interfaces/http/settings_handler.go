@@ -35,6 +35,9 @@ func NewSettingsHandler(uc DIANSettingsUseCase) *SettingsHandler {
 // @Param        environment           formData  string true  "Entorno DIAN: test|prod"
 // @Param        certificate_password  formData  string true  "Contraseña del certificado .p12"
 // @Param        certificate           formData  file   true  "Archivo .p12"
+// @Param        technical_key         formData  string false "Clave técnica del rango de numeración (vacío conserva la guardada)"
+// @Param        software_id           formData  string false "Identificador del software DIAN (vacío conserva el guardado)"
+// @Param        software_pin          formData  string false "PIN del software DIAN (vacío conserva el guardado)"
 // @Success      200  {object}  dto.DIANSettingsResponse
 // @Failure      400  {object}  dto.ErrorResponse
 // @Failure      401  {object}  dto.ErrorResponse
@@ -103,6 +106,9 @@ func (h *SettingsHandler) UpdateDIANSettings(c *fiber.Ctx) error {
 		CertificateFileName: fileHeader.Filename,
 		CertificateData:     fileData,
 		CertificatePassword: certificatePassword,
+		TechnicalKey:        c.FormValue("technical_key"),
+		SoftwareID:          c.FormValue("software_id"),
+		SoftwarePIN:         c.FormValue("software_pin"),
 	})
 	if err != nil {
 		switch err {
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// StockAlertUseCase interfaz local para alertas de stock bajo y notificaciones in-app.
+type StockAlertUseCase interface {
+	ListAlerts(ctx context.Context, companyID, status, warehouseID string, limit, offset int) ([]dto.StockAlertDTO, error)
+	ListSubscriptions(ctx context.Context, companyID, userID string) ([]dto.StockAlertSubscriptionDTO, error)
+	UpdateSubscriptions(ctx context.Context, companyID, userID string, in dto.UpdateStockAlertSubscriptionsRequest) ([]dto.StockAlertSubscriptionDTO, error)
+	ListNotifications(ctx context.Context, companyID, userID string, unreadOnly bool, limit, offset int) ([]dto.NotificationDTO, error)
+	MarkNotificationRead(ctx context.Context, companyID, userID, id string) error
+}
+
+// StockAlertHandler expone alertas de stock, preferencias de suscripción y notificaciones in-app.
+type StockAlertHandler struct {
+	uc StockAlertUseCase
+}
+
+// NewStockAlertHandler construye el handler.
+func NewStockAlertHandler(uc StockAlertUseCase) *StockAlertHandler {
+	return &StockAlertHandler{uc: uc}
+}
+
+// ListAlerts godoc
+// @Summary      Listar alertas de stock
+// @Description  Alertas de stock bajo (punto de reorden / stock mínimo) por bodega. status: OPEN (defecto), RESOLVED o ALL.
+// @Tags         inventory
+// @Security     Bearer
+// @Produce      json
+// @Param        status        query  string  false  "OPEN | RESOLVED | ALL"
+// @Param        warehouse_id  query  string  false  "Filtrar por bodega"
+// @Param        limit         query  int     false  "Límite (defecto 50)"
+// @Param        offset        query  int     false  "Desplazamiento"
+// @Success      200  {array}   dto.StockAlertDTO
+// @Failure      400  {object}  dto.ErrorResponse
+// @Router       /api/inventory/alerts [get]
+func (h *StockAlertHandler) ListAlerts(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	list, err := h.uc.ListAlerts(c.Context(), companyID, c.Query("status"), c.Query("warehouse_id"), c.QueryInt("limit", 50), c.QueryInt("offset", 0))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(list)
+}
+
+// ListSubscriptions godoc
+// @Summary      Mis suscripciones a alertas de stock
+// @Description  Preferencias del usuario autenticado por bodega (warehouse_id vacío = todas).
+// @Tags         inventory
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}   dto.StockAlertSubscriptionDTO
+// @Router       /api/inventory/alerts/subscriptions [get]
+func (h *StockAlertHandler) ListSubscriptions(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	userID := GetUserID(c)
+	if companyID == "" || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	list, err := h.uc.ListSubscriptions(c.Context(), companyID, userID)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(list)
+}
+
+// UpdateSubscriptions godoc
+// @Summary      Actualizar mis suscripciones a alertas de stock
+// @Description  Reemplaza las preferencias del usuario. Una entrada sin email ni in-app se descarta.
+// @Tags         inventory
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.UpdateStockAlertSubscriptionsRequest  true  "Suscripciones"
+// @Success      200   {array}   dto.StockAlertSubscriptionDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Router       /api/inventory/alerts/subscriptions [put]
+func (h *StockAlertHandler) UpdateSubscriptions(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	userID := GetUserID(c)
+	if companyID == "" || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	var in dto.UpdateStockAlertSubscriptionsRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
+	}
+	list, err := h.uc.UpdateSubscriptions(c.Context(), companyID, userID, in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(list)
+}
+
+// ListNotifications godoc
+// @Summary      Mis notificaciones
+// @Description  Notificaciones in-app del usuario autenticado (más recientes primero).
+// @Tags         notifications
+// @Security     Bearer
+// @Produce      json
+// @Param        unread  query  bool  false  "Solo no leídas"
+// @Param        limit   query  int   false  "Límite (defecto 50)"
+// @Param        offset  query  int   false  "Desplazamiento"
+// @Success      200  {array}   dto.NotificationDTO
+// @Router       /api/notifications [get]
+func (h *StockAlertHandler) ListNotifications(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	userID := GetUserID(c)
+	if companyID == "" || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	list, err := h.uc.ListNotifications(c.Context(), companyID, userID, c.QueryBool("unread", false), c.QueryInt("limit", 50), c.QueryInt("offset", 0))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(list)
+}
+
+// MarkNotificationRead godoc
+// @Summary      Marcar notificación como leída
+// @Tags         notifications
+// @Security     Bearer
+// @Param        id   path  string  true  "ID de la notificación"
+// @Success      204
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/notifications/{id}/read [post]
+func (h *StockAlertHandler) MarkNotificationRead(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	userID := GetUserID(c)
+	if companyID == "" || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "token inválido"})
+	}
+	if err := h.uc.MarkNotificationRead(c.Context(), companyID, userID, c.Params("id")); err != nil {
+		return h.fail(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *StockAlertHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "datos inválidos"})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "recurso no encontrado"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// SupportDocumentUseCase interfaz local del documento soporte en adquisiciones a no obligados a facturar.
+type SupportDocumentUseCase interface {
+	Create(ctx context.Context, companyID, userID string, in dto.CreateSupportDocumentRequest) (*dto.SupportDocumentDTO, error)
+	CreateAdjustment(ctx context.Context, companyID, userID, documentID string, in dto.CreateSupportAdjustmentRequest) (*dto.SupportDocumentDTO, error)
+	Get(ctx context.Context, companyID, id string) (*dto.SupportDocumentDTO, error)
+	List(ctx context.Context, companyID, documentType string) ([]dto.SupportDocumentDTO, error)
+}
+
+// SupportDocumentHandler expone los documentos soporte y sus notas de ajuste.
+type SupportDocumentHandler struct {
+	uc SupportDocumentUseCase
+}
+
+// NewSupportDocumentHandler construye el handler.
+func NewSupportDocumentHandler(uc SupportDocumentUseCase) *SupportDocumentHandler {
+	return &SupportDocumentHandler{uc: uc}
+}
+
+// Create godoc
+// @Summary      Emitir documento soporte
+// @Description  Emite el documento soporte de una compra a un proveedor no obligado a facturar, desde una orden de
+// @Description  compra recibida (purchase_order_id) o con líneas manuales. El consecutivo sale de la resolución del
+// @Description  prefijo y el envío a la DIAN (CUDS, firma y SOAP) se hace en segundo plano.
+// @Tags         billing
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.CreateSupportDocumentRequest  true  "Proveedor, prefijo y líneas u orden de compra"
+// @Success      201   {object}  dto.SupportDocumentDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Router       /api/billing/support-documents [post]
+func (h *SupportDocumentHandler) Create(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.CreateSupportDocumentRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.Create(c.Context(), companyID, GetUserID(c), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
+
+// CreateAdjustment godoc
+// @Summary      Emitir nota de ajuste al documento soporte
+// @Description  Ajusta total o parcialmente un documento soporte aceptado por la DIAN (CreditNoteTypeCode 95).
+// @Tags         billing
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                              true  "ID del documento soporte"
+// @Param        body  body      dto.CreateSupportAdjustmentRequest  true  "Prefijo, concepto y líneas"
+// @Success      201   {object}  dto.SupportDocumentDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse
+// @Router       /api/billing/support-documents/{id}/adjustment [post]
+func (h *SupportDocumentHandler) CreateAdjustment(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.CreateSupportAdjustmentRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.CreateAdjustment(c.Context(), companyID, GetUserID(c), c.Params("id"), in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(out)
+}
+
+// Get godoc
+// @Summary      Obtener documento soporte
+// @Description  Documento soporte o nota de ajuste con sus líneas, CUDS y estado DIAN.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      string  true  "ID del documento"
+// @Success      200  {object}  dto.SupportDocumentDTO
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/billing/support-documents/{id} [get]
+func (h *SupportDocumentHandler) Get(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.Get(c.Context(), companyID, c.Params("id"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// List godoc
+// @Summary      Listar documentos soporte
+// @Description  Documentos soporte y notas de ajuste de la empresa, del más reciente al más antiguo.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Param        document_type  query     string  false  "SUPPORT_DOCUMENT | SUPPORT_ADJUSTMENT"
+// @Success      200            {array}   dto.SupportDocumentDTO
+// @Router       /api/billing/support-documents [get]
+func (h *SupportDocumentHandler) List(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.List(c.Context(), companyID, c.Query("document_type"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+func (h *SupportDocumentHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "proveedor, orden de compra o documento soporte no encontrado"})
+	case isNumberingError(err):
+		return c.Status(fiber.StatusConflict).JSON(numberingErrorResponse(err))
+	case errors.Is(err, domain.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "CONFLICT", Message: "la orden de compra ya tiene documento soporte"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jhoicas/Inventario-api/internal/application/dto"
+	"github.com/jhoicas/Inventario-api/internal/domain"
+)
+
+// WithholdingUseCase interfaz local para la configuración de retenciones.
+type WithholdingUseCase interface {
+	GetConfig(ctx context.Context, companyID string) (*dto.WithholdingConfigDTO, error)
+	UpdateConfig(ctx context.Context, companyID string, in dto.UpdateWithholdingConfigRequest) (*dto.WithholdingConfigDTO, error)
+}
+
+// WithholdingHandler expone la configuración de retenciones (ReteFuente, ReteIVA, ReteICA).
+type WithholdingHandler struct {
+	uc WithholdingUseCase
+}
+
+// NewWithholdingHandler construye el handler.
+func NewWithholdingHandler(uc WithholdingUseCase) *WithholdingHandler {
+	return &WithholdingHandler{uc: uc}
+}
+
+// GetConfig godoc
+// @Summary      Configuración de retenciones
+// @Description  Responsabilidades fiscales del emisor, tarifas por tributo y concepto y valor UVT vigente.
+// @Tags         billing
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  dto.WithholdingConfigDTO
+// @Failure      401  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /api/billing/withholdings [get]
+func (h *WithholdingHandler) GetConfig(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	out, err := h.uc.GetConfig(c.Context(), companyID)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+// UpdateConfig godoc
+// @Summary      Actualizar configuración de retenciones
+// @Description  Reemplaza las responsabilidades fiscales del emisor y las tarifas de retención.
+// @Description  tax_code: 05 ReteIVA, 06 ReteFuente, 07 ReteICA; concept vacío = COMPRAS.
+// @Tags         billing
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body  dto.UpdateWithholdingConfigRequest  true  "Configuración"
+// @Success      200   {object}  dto.WithholdingConfigDTO
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Router       /api/billing/withholdings [put]
+func (h *WithholdingHandler) UpdateConfig(c *fiber.Ctx) error {
+	companyID := GetCompanyID(c)
+	if companyID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Code: "UNAUTHORIZED", Message: "company_id requerido"})
+	}
+	var in dto.UpdateWithholdingConfigRequest
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "INVALID_BODY", Message: "cuerpo inválido"})
+	}
+	out, err := h.uc.UpdateConfig(c.Context(), companyID, in)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(out)
+}
+
+func (h *WithholdingHandler) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrDuplicate):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "VALIDATION", Message: "responsabilidades fiscales o tarifas de retención inválidas"})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "NOT_FOUND", Message: "empresa no encontrada"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Code: "INTERNAL", Message: err.Error()})
+}
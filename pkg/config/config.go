@@ -18,6 +18,13 @@ type Config struct {
 	DIAN DIANConfig
 	AI   AIConfig
 	SMTP SMTPConfig
+
+	Inventory InventoryConfig
+}
+
+// InventoryConfig configuración del motor de inventario.
+type InventoryConfig struct {
+	ExpiryAlertDays int // Alerta si un lote con saldo vence en <= N días (INVENTORY_EXPIRY_ALERT_DAYS, default 30)
 }
 
 // AIConfig configuración para servicios de Inteligencia Artificial.
@@ -46,6 +53,19 @@ type DIANConfig struct {
 	CertKeyPath     string // Ruta a la llave privada .pem (si CertPath es solo el certificado)
 	CertPassword    string // Contraseña del .p12 (si CertPath es .p12)
 	CertStoragePath string // Ruta donde guardar certificados subidos por PUT /settings/dian (vacío = storage/private/dian). En servidor usar ruta con permisos de escritura (ej. /tmp/dian-certs).
+	TrustedCAPath   string // PEM con las CA de confianza para verificar la cadena de los certificados de firma (DIAN_TRUSTED_CA_PATH; vacío = CA del sistema)
+	SingleTenant    bool   // Instalación de una sola empresa: sin dian_settings se firma con DIAN_CERT_PATH y DIAN_TECHNICAL_KEY (DIAN_SINGLE_TENANT)
+	SingleTenantSet bool   // DIAN_SINGLE_TENANT definido; si no, se decide al arrancar (billing.SingleTenantDefault)
+
+	ResolutionAlertPercent int // Alerta si quedan <= este % de números en la resolución (DIAN_RESOLUTION_ALERT_PERCENT, default 10)
+	ResolutionAlertDays    int // Alerta si la resolución vence o se proyecta agotada en <= N días (DIAN_RESOLUTION_ALERT_DAYS, default 30)
+	ResolutionVelocityDays int // Ventana en días para la velocidad de facturación (DIAN_RESOLUTION_VELOCITY_DAYS, default 30)
+
+	RetryMaxAttempts int // Intentos de reenvío a la DIAN antes de pasar el trabajo a DEAD (DIAN_RETRY_MAX_ATTEMPTS, default 10)
+
+	UVTValue int // Valor de la UVT vigente en pesos para las bases mínimas de retención (DIAN_UVT_VALUE, default 52374 = 2026)
+
+	XSDDir string // Directorio xsd/ del paquete oficial UBL 2.1 de OASIS para validar con xmllint antes de firmar; sin esquemas no hay validación local (DIAN_XSD_DIR, default ./xsd de scripts/fetch-ubl-xsd.sh)
 }
 
 // AppConfig configuración general de la aplicación.
@@ -164,6 +184,19 @@ func Load() (*Config, error) {
 			CertKeyPath:     getString(v, "DIAN_CERT_KEY_PATH", ""),
 			CertPassword:    getString(v, "DIAN_CERT_PASSWORD", ""),
 			CertStoragePath: getString(v, "DIAN_CERT_STORAGE_PATH", ""),
+			TrustedCAPath:   getString(v, "DIAN_TRUSTED_CA_PATH", ""),
+			SingleTenant:    getBool(v, "DIAN_SINGLE_TENANT", false),
+			SingleTenantSet: v.IsSet("DIAN_SINGLE_TENANT"),
+
+			ResolutionAlertPercent: getInt(v, "DIAN_RESOLUTION_ALERT_PERCENT", 10),
+			ResolutionAlertDays:    getInt(v, "DIAN_RESOLUTION_ALERT_DAYS", 30),
+			ResolutionVelocityDays: getInt(v, "DIAN_RESOLUTION_VELOCITY_DAYS", 30),
+
+			RetryMaxAttempts: getInt(v, "DIAN_RETRY_MAX_ATTEMPTS", 10),
+
+			UVTValue: getInt(v, "DIAN_UVT_VALUE", 52374),
+
+			XSDDir: getString(v, "DIAN_XSD_DIR", "xsd"),
 		},
 		AI: AIConfig{
 			AnthropicAPIKey: getString(v, "ANTHROPIC_API_KEY", ""),
@@ -178,6 +211,9 @@ func Load() (*Config, error) {
 			ResendAPIKey: getString(v, "RESEND_API_KEY", ""),
 			ResendAPIURL: getString(v, "RESEND_API_URL", "https://api.resend.com/emails"),
 		},
+		Inventory: InventoryConfig{
+			ExpiryAlertDays: getInt(v, "INVENTORY_EXPIRY_ALERT_DAYS", 30),
+		},
 	}
 
 	return cfg, nil
@@ -195,6 +231,13 @@ func getString(v *viper.Viper, key, def string) string {
 	return def
 }
 
+func getBool(v *viper.Viper, key string, def bool) bool {
+	if v.IsSet(key) {
+		return v.GetBool(key)
+	}
+	return def
+}
+
 func getInt(v *viper.Viper, key string, def int) int {
 	if v.IsSet(key) {
 		switch v.Get(key).(type) {
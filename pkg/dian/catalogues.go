@@ -64,6 +64,9 @@ const (
 	PaymentFormCredito  = "2" // Crédito
 )
 
+// ValidPaymentForms formas de pago admitidas (cac:PaymentMeans/cbc:ID).
+var ValidPaymentForms = map[string]bool{PaymentFormContado: true, PaymentFormCredito: true}
+
 // =============================================================================
 // Tabla 13 - Medios de Pago (Anexo 1.9 - 13.3.4.2) - códigos de uso frecuente
 // =============================================================================
@@ -73,16 +76,62 @@ const (
 	PaymentMethodTransferencia     = "47" // Transferencia Débito Bancaria
 	PaymentMethodTarjetaCredito    = "48" // Tarjeta Crédito
 	PaymentMethodTarjetaDebito     = "49" // Tarjeta Débito
+	PaymentMethodInstrumentoNoDef  = "1"  // Instrumento no definido
+	PaymentMethodCheque            = "20" // Cheque
+	PaymentMethodConsignacion      = "42" // Consignación bancaria
+	PaymentMethodTransferenciaCred = "45" // Transferencia Crédito Bancaria
+	PaymentMethodAcuerdoMutuo      = "ZZZ" // Acuerdo mutuo
 )
 
+// ValidPaymentMethods medios de pago admitidos en facturas (cac:PaymentMeans/cbc:PaymentMeansCode).
+var ValidPaymentMethods = map[string]bool{
+	PaymentMethodEfectivo: true, PaymentMethodTransferencia: true, PaymentMethodTarjetaCredito: true,
+	PaymentMethodTarjetaDebito: true, PaymentMethodInstrumentoNoDef: true, PaymentMethodCheque: true,
+	PaymentMethodConsignacion: true, PaymentMethodTransferenciaCred: true, PaymentMethodAcuerdoMutuo: true,
+}
+
+// =============================================================================
+// Tabla 13.3.8 - Códigos de descuento (AllowanceChargeReasonCode, Anexo 1.9)
+// =============================================================================
+
+const (
+	DiscountReasonImpuestoAsumido = "00" // Descuento por impuesto asumido
+	DiscountReasonPagueUnoLleve   = "01" // Pague uno lleve otro
+	DiscountReasonContractual     = "02" // Descuentos contractuales
+	DiscountReasonProntoPago      = "03" // Descuento por pronto pago
+	DiscountReasonEnvioGratis     = "04" // Envío gratis
+	DiscountReasonInventarios     = "05" // Descuentos específicos por inventarios
+	DiscountReasonMontoCompras    = "06" // Descuento por monto de compras
+	DiscountReasonTemporada       = "07" // Descuento de temporada
+	DiscountReasonActualizacion   = "08" // Descuento por actualización de productos / servicios
+	DiscountReasonGeneral         = "09" // Descuento general
+	DiscountReasonVolumen         = "10" // Descuento por volumen
+	DiscountReasonOtro            = "11" // Otro descuento
+)
+
+// ValidDiscountReasonCodes códigos de descuento admitidos por la DIAN.
+var ValidDiscountReasonCodes = map[string]bool{
+	DiscountReasonImpuestoAsumido: true, DiscountReasonPagueUnoLleve: true, DiscountReasonContractual: true,
+	DiscountReasonProntoPago: true, DiscountReasonEnvioGratis: true, DiscountReasonInventarios: true,
+	DiscountReasonMontoCompras: true, DiscountReasonTemporada: true, DiscountReasonActualizacion: true,
+	DiscountReasonGeneral: true, DiscountReasonVolumen: true, DiscountReasonOtro: true,
+}
+
 // =============================================================================
 // Tabla 11 - Tipos de Impuesto (Anexo 1.9 - 13.2.2)
 // =============================================================================
 
 const (
-	TaxCodeIVA     = "01" // IVA
-	TaxCodeINC     = "04" // Impuesto Nacional al Consumo
-	TaxCodeReteIVA = "05" // Retención sobre el IVA
+	TaxCodeIVA       = "01" // IVA
+	TaxCodeINC       = "04" // Impuesto Nacional al Consumo
+	TaxCodeReteIVA   = "05" // Retención sobre el IVA
+	TaxCodeINCBolsas = "22" // INC a las bolsas plásticas (valor por unidad)
+)
+
+// Tributos de retención (WithholdingTaxTotal, Anexo 1.9 - 13.2.2).
+const (
+	TaxCodeReteFuente = "06" // Retención sobre la renta (ReteFuente)
+	TaxCodeReteICA    = "07" // Retención sobre el ICA
 )
 
 // =============================================================================
@@ -92,4 +141,103 @@ const (
 const (
 	IdentificationTypeNIT = "31" // NIT - requiere dígito de verificación
 	IdentificationTypeCC = "13" // Cédula de ciudadanía
+	IdentificationTypeCE            = "22" // Cédula de extranjería
+	IdentificationTypePasaporte     = "41" // Pasaporte
+	IdentificationTypeExtranjero    = "42" // Documento de identificación extranjero
+	IdentificationTypeNITOtroPais   = "50" // NIT de otro país
 )
+
+// ValidIdentificationTypes tipos de identificación admitidos para clientes.
+var ValidIdentificationTypes = map[string]bool{
+	IdentificationTypeNIT: true, IdentificationTypeCC: true, IdentificationTypeCE: true,
+	IdentificationTypePasaporte: true, IdentificationTypeExtranjero: true, IdentificationTypeNITOtroPais: true,
+}
+
+// =============================================================================
+// Tabla 13.1.3 - Tipos de factura (InvoiceTypeCode)
+// =============================================================================
+
+const (
+	InvoiceTypeVenta                   = "01" // Factura electrónica de venta
+	InvoiceTypeExportacion             = "02" // Factura electrónica de venta - exportación
+	InvoiceTypeContingencia            = "03" // Instrumento electrónico de transmisión - tipo 03 (contingencia del facturador)
+	InvoiceTypeDocumentoSoporte        = "05" // Documento soporte en adquisiciones a no obligados a facturar
+	InvoiceTypeDocumentoEquivalentePOS = "20" // Documento equivalente electrónico del tiquete de máquina registradora con sistema POS
+	CreditNoteTypeAjusteDS             = "95" // Nota de ajuste al documento soporte (CreditNoteTypeCode)
+)
+
+// Consumidor final: adquiriente genérico de los documentos equivalentes POS cuando el comprador
+// no se identifica (cédula 222222222222).
+const (
+	FinalConsumerIdentification = "222222222222"
+	FinalConsumerName           = "Consumidor final"
+)
+
+// =============================================================================
+// RADIAN - Eventos de la factura electrónica (cbc:ResponseCode del ApplicationResponse)
+// =============================================================================
+
+const (
+	EventAcuseRecibo       = "030" // Acuse de recibo de la factura electrónica de venta
+	EventReclamo           = "031" // Reclamo de la factura electrónica de venta
+	EventReciboBien        = "032" // Recibo del bien y/o prestación del servicio
+	EventAceptacionExpresa = "033" // Aceptación expresa
+	EventAceptacionTacita  = "034" // Aceptación tácita (la emite el facturador electrónico)
+)
+
+// EventNames descripción de los eventos que el adquiriente emite sobre una factura recibida.
+var EventNames = map[string]string{
+	EventAcuseRecibo:       "Acuse de recibo de la factura electrónica de venta",
+	EventReclamo:           "Reclamo de la factura electrónica de venta",
+	EventReciboBien:        "Recibo del bien y/o prestación del servicio",
+	EventAceptacionExpresa: "Aceptación expresa",
+	EventAceptacionTacita:  "Aceptación tácita",
+}
+
+// Conceptos de reclamo del evento 031 (atributo listID del cbc:ResponseCode).
+const (
+	ClaimConceptInconsistencias      = "01" // Documento con inconsistencias
+	ClaimConceptMercanciaNoEntregada = "02" // Mercancía no entregada totalmente
+	ClaimConceptEntregaParcial       = "03" // Mercancía no entregada parcialmente
+	ClaimConceptServicioNoPrestado   = "04" // Servicio no prestado
+)
+
+// ValidClaimConcepts conceptos de reclamo admitidos en el evento 031.
+var ValidClaimConcepts = map[string]bool{
+	ClaimConceptInconsistencias: true, ClaimConceptMercanciaNoEntregada: true,
+	ClaimConceptEntregaParcial: true, ClaimConceptServicioNoPrestado: true,
+}
+
+// =============================================================================
+// Tabla 13.3.3 - Monedas (ISO 4217) - códigos de uso frecuente
+// =============================================================================
+
+const (
+	CurrencyCOP = "COP" // Peso colombiano (moneda funcional)
+	CurrencyUSD = "USD" // Dólar estadounidense
+	CurrencyEUR = "EUR" // Euro
+)
+
+// ValidCurrencyCodes monedas admitidas para facturar.
+var ValidCurrencyCodes = map[string]bool{
+	CurrencyCOP: true, CurrencyUSD: true, CurrencyEUR: true,
+}
+
+// =============================================================================
+// Tabla 13.3.9 - Condiciones de entrega (Incoterms 2020), obligatorias en exportación
+// =============================================================================
+
+// ValidIncoterms códigos Incoterms 2020 (cac:DeliveryTerms/cbc:LossRiskResponsibilityCode).
+var ValidIncoterms = map[string]string{
+	"EXW": "En fábrica",
+	"FCA": "Franco transportista",
+	"CPT": "Transporte pagado hasta",
+	"CIP": "Transporte y seguro pagados hasta",
+	"DAP": "Entregado en lugar",
+	"DPU": "Entregado en lugar descargado",
+	"DDP": "Entregado con derechos pagados",
+	"FAS": "Franco al costado del buque",
+	"FOB": "Franco a bordo",
+	"CFR": "Costo y flete",
+	"CIF": "Costo, seguro y flete",
+}
@@ -0,0 +1,46 @@
+#!/bin/sh
+# ╔══════════════════════════════════════════════════════════════════════════════╗
+# ║  fetch-ubl-xsd.sh — Descarga los XSD oficiales de UBL 2.1 (OASIS)            ║
+# ║                                                                              ║
+# ║  Uso:                                                                        ║
+# ║    UBL_ZIP_SHA256=<sha256> scripts/fetch-ubl-xsd.sh [DIRECTORIO]             ║
+# ║                                              # por defecto ./xsd             ║
+# ║                                                                              ║
+# ║  Deja en DIRECTORIO el xsd/ del paquete os-UBL-2.1 (maindoc/ y common/).     ║
+# ║  La API lo usa con DIAN_XSD_DIR=DIRECTORIO y xmllint (libxml2) instalado.    ║
+# ║  UBL_ZIP_SHA256 (obligatorio) fija el paquete: se verifica con sha256sum -c  ║
+# ║  antes de descomprimirlo.                                                    ║
+# ╚══════════════════════════════════════════════════════════════════════════════╝
+set -eu
+
+DEST="${1:-xsd}"
+UBL_ZIP_URL="${UBL_ZIP_URL:-https://docs.oasis-open.org/ubl/os-UBL-2.1/UBL-2.1.zip}"
+UBL_ZIP_SHA256="${UBL_ZIP_SHA256:-}"
+
+if [ -z "$UBL_ZIP_SHA256" ]; then
+  echo "Defina UBL_ZIP_SHA256 con el SHA-256 de UBL-2.1.zip" >&2
+  exit 1
+fi
+
+TMP="$(mktemp -d)"
+trap 'rm -rf "$TMP"' EXIT
+
+echo "Descargando $UBL_ZIP_URL"
+if command -v curl >/dev/null 2>&1; then
+  curl -fsSL -o "$TMP/ubl.zip" "$UBL_ZIP_URL"
+else
+  wget -q -O "$TMP/ubl.zip" "$UBL_ZIP_URL"
+fi
+echo "$UBL_ZIP_SHA256  $TMP/ubl.zip" | sha256sum -c -
+unzip -q "$TMP/ubl.zip" -d "$TMP/ubl"
+
+INVOICE_XSD="$(find "$TMP/ubl" -path '*/maindoc/UBL-Invoice-2.1.xsd' | head -n 1)"
+if [ -z "$INVOICE_XSD" ]; then
+  echo "El paquete no trae maindoc/UBL-Invoice-2.1.xsd" >&2
+  exit 1
+fi
+XSD_ROOT="$(dirname "$(dirname "$INVOICE_XSD")")"
+
+mkdir -p "$DEST"
+cp -R "$XSD_ROOT/." "$DEST/"
+echo "Esquemas UBL 2.1 en $DEST"